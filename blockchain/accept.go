@@ -54,20 +54,30 @@ func (b *BlockChain) maybeAcceptBlock(block *btcutil.Block, flags BehaviorFlags)
 	// such as making blocks that never become part of the main chain or
 	// blocks that fail to connect available for further analysis.
 	err = b.db.Update(func(dbTx database.Tx) error {
-		return dbStoreBlock(dbTx, block)
+		if err := dbStoreBlock(dbTx, block); err != nil {
+			return err
+		}
+		return dbPutAuxPow(dbTx, &block.MsgBlock().Header)
 	})
 	if err != nil {
 		return false, err
 	}
 
-	// Create a new block node for the block and add it to the node index. Even
-	// if the block ultimately gets connected to the main chain, it starts out
-	// on a side chain.
-	blockHeader := &block.MsgBlock().Header
-	newNode := newBlockNode(blockHeader, prevNode)
-	newNode.status = statusDataStored
-
-	b.index.AddNode(newNode)
+	// Create a new block node for the block and add it to the node index
+	// unless its header is already known, in which case the existing node
+	// is marked as having its data stored. Even if the block ultimately gets
+	// connected to the main chain, it starts out on a side chain.
+	newNode := b.index.LookupNode(block.Hash())
+	if newNode == nil {
+		blockHeader := &block.MsgBlock().Header
+		newNode = newBlockNode(blockHeader, prevNode)
+		newNode.status = statusDataStored
+		b.index.AddNode(newNode)
+		b.maybeUpdateBestHeader(newNode)
+	} else {
+		b.index.SetStatusFlags(newNode, statusDataStored)
+	}
+	b.setReceived(newNode)
 	err = b.index.flushToDB()
 	if err != nil {
 		return false, err
@@ -78,6 +88,11 @@ func (b *BlockChain) maybeAcceptBlock(block *btcutil.Block, flags BehaviorFlags)
 	// also handles validation of the transaction scripts.
 	isMainChain, err := b.connectBestChain(newNode, block, flags)
 	if err != nil {
+		// The block might have been found to be invalid, in which case
+		// it can't be part of the best header chain either.
+		if b.index.NodeStatus(newNode).KnownInvalid() {
+			b.bestHeader.SetTip(b.findBestHeader())
+		}
 		return false, err
 	}
 
@@ -0,0 +1,471 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// MaxAssetValue is the maximum amount of an asset, in its smallest
+	// unit, that a single output may carry.  It also bounds the maximum
+	// and total supply of an asset.
+	MaxAssetValue = 1000000000000000000 - 1
+
+	// MaxAssetPrecision is the maximum number of decimal places an asset
+	// may be divided into.
+	MaxAssetPrecision = 8
+
+	// assetCapabilityFlagsMask is the set of all the update capability
+	// flags an asset may have.
+	assetCapabilityFlagsMask = wire.ASSET_UPDATE_DATA |
+		wire.ASSET_UPDATE_CONTRACT | wire.ASSET_UPDATE_SUPPLY |
+		wire.ASSET_UPDATE_NOTARY_KEY | wire.ASSET_UPDATE_NOTARY_DETAILS |
+		wire.ASSET_UPDATE_AUXFEE | wire.ASSET_UPDATE_CAPABILITYFLAGS
+)
+
+// assetOutput describes the asset value assigned to a transaction output.  A
+// zero guid means the output does not carry an asset.
+type assetOutput struct {
+	guid  uint64
+	value int64
+}
+
+// txAssetOutputs returns the asset values the Syscoin payload of the passed
+// transaction assigns to its outputs keyed by output index.  Nil is returned
+// for transactions which are not Syscoin transactions or whose payload can't
+// be decoded since they don't assign any asset value.
+func txAssetOutputs(msgTx *wire.MsgTx) map[uint32]assetOutput {
+	if !wire.IsSyscoinTx(msgTx.Version) {
+		return nil
+	}
+	allocation, err := msgTx.AssetAllocation()
+	if err != nil {
+		return nil
+	}
+
+	outputs := make(map[uint32]assetOutput)
+	for _, voutAsset := range allocation.VoutAssets {
+		for _, value := range voutAsset.Values {
+			outputs[value.N] = assetOutput{
+				guid:  voutAsset.AssetGuid,
+				value: value.ValueSat,
+			}
+		}
+	}
+	return outputs
+}
+
+// isAssetBurnTx returns whether or not the passed transaction version burns
+// asset value by assigning it to the null data output carrying the payload.
+func isAssetBurnTx(version int32) bool {
+	return version == wire.SyscoinTxVersionAllocationBurnToSyscoin ||
+		version == wire.SyscoinTxVersionAllocationBurnToEthereum
+}
+
+// maxAssetValue returns the maximum amount of an asset with the passed precision
+// in its smallest unit.  Every asset may have up to 10^10 - 1 whole units, so
+// MaxAssetValue is the maximum amount of an asset with the max precision.
+func maxAssetValue(precision uint8) int64 {
+	limit := int64(MaxAssetValue + 1)
+	for p := precision; p < MaxAssetPrecision; p++ {
+		limit /= 10
+	}
+	return limit - 1
+}
+
+// checkAssetPayload performs checks on the fields of the asset carried by an
+// asset activate, update or send transaction against the passed stored state of
+// the asset, which is nil when the asset does not exist.
+//
+// Activations must activate a new asset, set the init flag, use a precision of
+// at most MaxAssetPrecision and a maximum supply in the range (0, max value of
+// the precision], and may only set defined capability flags.  Updates may not
+// set the init flag, may only update fields the stored capability flags allow,
+// must carry the stored flags as the previous ones when they update them, may
+// only remove capabilities and may not raise the supply above the max supply.
+// Sends may not update any field.
+func checkAssetPayload(tx *btcutil.Tx, asset *wire.AssetType, stored *AssetEntry) error {
+	txHash := tx.Hash()
+	version := tx.MsgTx().Version
+	guid := asset.Allocation.VoutAssets[0].AssetGuid
+
+	if asset.Precision > MaxAssetPrecision {
+		str := fmt.Sprintf("asset precision of transaction %v is %d "+
+			"which is higher than the max allowed precision of %d",
+			txHash, asset.Precision, MaxAssetPrecision)
+		return ruleError(ErrBadAssetSupply, str)
+	}
+	if asset.UpdateFlags&wire.ASSET_UPDATE_SUPPLY != 0 &&
+		(asset.TotalSupply < 0 || asset.TotalSupply > MaxAssetValue) {
+
+		str := fmt.Sprintf("asset supply of transaction %v is %d "+
+			"which is outside of the allowed range of 0 to %d",
+			txHash, asset.TotalSupply, int64(MaxAssetValue))
+		return ruleError(ErrBadAssetSupply, str)
+	}
+	if version != wire.SyscoinTxVersionAssetActivate && stored == nil {
+		str := fmt.Sprintf("transaction %v references unknown asset %d",
+			txHash, guid)
+		return ruleError(ErrUnknownAsset, str)
+	}
+
+	switch version {
+	case wire.SyscoinTxVersionAssetActivate:
+		if stored != nil {
+			str := fmt.Sprintf("asset activation %v activates asset "+
+				"%d which already exists", txHash, guid)
+			return ruleError(ErrAssetExists, str)
+		}
+		if asset.UpdateFlags&wire.ASSET_INIT == 0 {
+			str := fmt.Sprintf("asset activation %v does not set "+
+				"the init flag", txHash)
+			return ruleError(ErrBadAssetUpdateFlags, str)
+		}
+		maxValue := maxAssetValue(asset.Precision)
+		if asset.MaxSupply <= 0 || asset.MaxSupply > maxValue {
+			str := fmt.Sprintf("asset max supply of transaction "+
+				"%v is %d which is outside of the allowed "+
+				"range of 1 to %d for precision %d", txHash,
+				asset.MaxSupply, maxValue, asset.Precision)
+			return ruleError(ErrBadAssetSupply, str)
+		}
+		if asset.UpdateFlags&wire.ASSET_UPDATE_SUPPLY != 0 &&
+			asset.TotalSupply > asset.MaxSupply {
+
+			str := fmt.Sprintf("asset supply of transaction %v "+
+				"is %d which is higher than its max supply "+
+				"of %d", txHash, asset.TotalSupply,
+				asset.MaxSupply)
+			return ruleError(ErrBadAssetSupply, str)
+		}
+		if asset.UpdateCapabilityFlags&^assetCapabilityFlagsMask != 0 {
+			str := fmt.Sprintf("asset activation %v sets unknown "+
+				"capability flags %#x", txHash,
+				asset.UpdateCapabilityFlags)
+			return ruleError(ErrBadAssetUpdateFlags, str)
+		}
+
+	case wire.SyscoinTxVersionAssetUpdate:
+		if asset.UpdateFlags&wire.ASSET_INIT != 0 {
+			str := fmt.Sprintf("asset update %v sets the init "+
+				"flag", txHash)
+			return ruleError(ErrBadAssetUpdateFlags, str)
+		}
+
+		// Every updated field must be allowed by the capability flags
+		// of the asset.
+		flags := stored.CapabilityFlags()
+		if asset.UpdateFlags&^flags != 0 {
+			str := fmt.Sprintf("asset update %v updates fields "+
+				"%#x which are not allowed by the capability "+
+				"flags %#x of asset %d", txHash,
+				asset.UpdateFlags&^flags, flags, guid)
+			return ruleError(ErrBadAssetUpdateFlags, str)
+		}
+
+		// Updates of the capability flags must carry the current flags
+		// as the previous ones and may only remove capabilities.
+		if asset.UpdateFlags&wire.ASSET_UPDATE_CAPABILITYFLAGS != 0 {
+			if asset.PrevUpdateCapabilityFlags != flags {
+				str := fmt.Sprintf("asset update %v carries "+
+					"previous capability flags %#x while "+
+					"asset %d has %#x", txHash,
+					asset.PrevUpdateCapabilityFlags, guid,
+					flags)
+				return ruleError(ErrBadAssetUpdateFlags, str)
+			}
+			if asset.UpdateCapabilityFlags&^flags != 0 {
+				str := fmt.Sprintf("asset update %v adds "+
+					"capability flags %#x which were not "+
+					"previously set", txHash,
+					asset.UpdateCapabilityFlags&^flags)
+				return ruleError(ErrBadAssetUpdateFlags, str)
+			}
+		}
+
+		// The supply added by the update may not raise the supply of
+		// the asset above its max supply.
+		remaining := stored.MaxSupply() - stored.TotalSupply()
+		if asset.UpdateFlags&wire.ASSET_UPDATE_SUPPLY != 0 &&
+			asset.TotalSupply > remaining {
+
+			str := fmt.Sprintf("asset update %v adds %d to the "+
+				"supply of asset %d which exceeds the remaining "+
+				"supply of %d", txHash, asset.TotalSupply, guid,
+				remaining)
+			return ruleError(ErrBadAssetSupply, str)
+		}
+
+	case wire.SyscoinTxVersionAssetSend:
+		if asset.UpdateFlags != 0 {
+			str := fmt.Sprintf("asset send %v updates asset "+
+				"fields %#x", txHash, asset.UpdateFlags)
+			return ruleError(ErrBadAssetUpdateFlags, str)
+		}
+	}
+
+	return nil
+}
+
+// checkAssetInputs performs checks on the asset values spent and created by
+// the passed transaction.  It ensures the outputs referenced by the payload
+// exist, the referenced assets exist, the asset values are in the range the
+// precision of their asset allows, the asset fields are sane and allowed by
+// the state of the asset, and the value of every asset spent by the inputs
+// equals the value assigned to the outputs.
+//
+// The only exceptions to the conservation of asset value are:
+//   - Asset activations create the asset, which may not already be spent by
+//     the inputs, without any value
+//   - Asset sends may issue new value of the asset being sent up to its max
+//     supply
+//   - Mints may create the value of the asset burned on the NEVM chain
+//   - Burns of SYS to an allocation create exactly the value of SYS burned of
+//     the SYSX asset
+//   - Burns of allocations assign the burned value to the null data output
+//
+// Transactions which are not Syscoin transactions may not spend asset outputs
+// since doing so would destroy the asset value they carry.
+//
+// NOTE: All of the inputs of the transaction, and the assets it references,
+// MUST have already been verified to be available in the passed view.
+func checkAssetInputs(tx *btcutil.Tx, utxoView *UtxoViewpoint, chainParams *chaincfg.Params) error {
+	txHash := tx.Hash()
+	msgTx := tx.MsgTx()
+
+	// Tally the value of every asset spent by the inputs.
+	assetsIn := make(map[uint64]int64)
+	for _, txIn := range msgTx.TxIn {
+		utxo := utxoView.LookupEntry(txIn.PreviousOutPoint)
+		if utxo == nil || utxo.AssetGuid() == 0 {
+			continue
+		}
+
+		guid := utxo.AssetGuid()
+		total := assetsIn[guid] + utxo.AssetValue()
+		if utxo.AssetValue() < 0 || total > MaxAssetValue {
+			str := fmt.Sprintf("total value of asset %d spent by "+
+				"transaction %v is higher than max allowed "+
+				"value of %d", guid, txHash, int64(MaxAssetValue))
+			return ruleError(ErrBadAssetValue, str)
+		}
+		assetsIn[guid] = total
+	}
+
+	if !wire.IsSyscoinTx(msgTx.Version) {
+		if len(assetsIn) != 0 {
+			str := fmt.Sprintf("transaction %v spends asset "+
+				"outputs but is not an asset transaction",
+				txHash)
+			return ruleError(ErrAssetNotConserved, str)
+		}
+		return nil
+	}
+
+	// Decode the payload and locate the null data output carrying it.
+	payload, err := msgTx.SyscoinPayload()
+	if err != nil {
+		str := fmt.Sprintf("unable to decode the payload of "+
+			"transaction %v: %v", txHash, err)
+		return ruleError(ErrBadAssetPayload, str)
+	}
+	_, dataOutIdx, err := msgTx.SyscoinData()
+	if err != nil {
+		str := fmt.Sprintf("unable to locate the payload of "+
+			"transaction %v: %v", txHash, err)
+		return ruleError(ErrBadAssetPayload, str)
+	}
+	allocation := payload.AssetAllocation()
+	if len(allocation.VoutAssets) == 0 {
+		str := fmt.Sprintf("payload of transaction %v does not "+
+			"reference any asset", txHash)
+		return ruleError(ErrBadAssetPayload, str)
+	}
+
+	// Tally the value of every asset assigned to the outputs while
+	// ensuring every referenced output exists and is only referenced once.
+	// The first asset referenced by the payload is the asset the
+	// transaction activates, updates, sends or mints.
+	isBurn := isAssetBurnTx(msgTx.Version)
+	isActivate := msgTx.Version == wire.SyscoinTxVersionAssetActivate
+	assetsOut := make(map[uint64]int64)
+	seenOuts := make(map[uint32]struct{})
+	for i, voutAsset := range allocation.VoutAssets {
+		guid := voutAsset.AssetGuid
+		if guid == 0 {
+			str := fmt.Sprintf("payload of transaction %v "+
+				"references asset guid 0", txHash)
+			return ruleError(ErrBadAssetPayload, str)
+		}
+		if _, ok := assetsOut[guid]; ok {
+			str := fmt.Sprintf("payload of transaction %v "+
+				"references asset %d more than once", txHash,
+				guid)
+			return ruleError(ErrBadAssetPayload, str)
+		}
+		if len(voutAsset.Values) == 0 {
+			str := fmt.Sprintf("payload of transaction %v does "+
+				"not assign any output to asset %d", txHash,
+				guid)
+			return ruleError(ErrBadAssetPayload, str)
+		}
+
+		// Every asset other than the one an activation creates must
+		// exist, and the precision of the asset limits its values.
+		var precision uint8
+		if stored := utxoView.LookupAsset(guid); stored != nil {
+			precision = stored.Precision()
+		} else if isActivate && i == 0 {
+			precision = payload.(*wire.AssetType).Precision
+		} else {
+			str := fmt.Sprintf("payload of transaction %v "+
+				"references unknown asset %d", txHash, guid)
+			return ruleError(ErrUnknownAsset, str)
+		}
+		maxValue := maxAssetValue(precision)
+
+		var total int64
+		for _, value := range voutAsset.Values {
+			if value.N >= uint32(len(msgTx.TxOut)) {
+				str := fmt.Sprintf("payload of transaction %v "+
+					"references output %d which does not "+
+					"exist", txHash, value.N)
+				return ruleError(ErrBadAssetOutputIndex, str)
+			}
+			if value.N == uint32(dataOutIdx) && !isBurn {
+				str := fmt.Sprintf("payload of transaction %v "+
+					"assigns asset value to the null data "+
+					"output %d", txHash, value.N)
+				return ruleError(ErrBadAssetOutputIndex, str)
+			}
+			if _, ok := seenOuts[value.N]; ok {
+				str := fmt.Sprintf("payload of transaction %v "+
+					"references output %d more than once",
+					txHash, value.N)
+				return ruleError(ErrBadAssetOutputIndex, str)
+			}
+			seenOuts[value.N] = struct{}{}
+
+			if value.ValueSat < 0 || value.ValueSat > maxValue {
+				str := fmt.Sprintf("asset value of output %d "+
+					"of transaction %v is %d which is "+
+					"outside of the allowed range of 0 to "+
+					"%d for precision %d", value.N, txHash,
+					value.ValueSat, maxValue, precision)
+				return ruleError(ErrBadAssetValue, str)
+			}
+			total += value.ValueSat
+			if total > maxValue {
+				str := fmt.Sprintf("total value of asset %d "+
+					"assigned by transaction %v is higher "+
+					"than max allowed value of %d", guid,
+					txHash, maxValue)
+				return ruleError(ErrBadAssetValue, str)
+			}
+		}
+		assetsOut[guid] = total
+	}
+
+	guid := allocation.VoutAssets[0].AssetGuid
+	stored := utxoView.LookupAsset(guid)
+	_, spendsAsset := assetsIn[guid]
+	var issued int64
+	switch msgTx.Version {
+	case wire.SyscoinTxVersionAssetActivate,
+		wire.SyscoinTxVersionAssetUpdate,
+		wire.SyscoinTxVersionAssetSend:
+
+		err := checkAssetPayload(tx, payload.(*wire.AssetType), stored)
+		if err != nil {
+			return err
+		}
+
+		// Activations must not spend the asset they create while
+		// updates and sends must spend an output of the asset to prove
+		// ownership.
+		if spendsAsset == isActivate {
+			str := fmt.Sprintf("transaction %v does not spend the "+
+				"owner output of asset %d", txHash, guid)
+			if isActivate {
+				str = fmt.Sprintf("asset activation %v spends "+
+					"outputs of asset %d", txHash, guid)
+			}
+			return ruleError(ErrAssetNotConserved, str)
+		}
+		if msgTx.Version != wire.SyscoinTxVersionAssetSend {
+			break
+		}
+
+		// The value issued by the send may not raise the supply of the
+		// asset above its max supply.
+		issued = assetsOut[guid] - assetsIn[guid]
+		if issued < 0 {
+			issued = 0
+		}
+		remaining := stored.MaxSupply() - stored.TotalSupply()
+		if issued > remaining {
+			str := fmt.Sprintf("asset send %v issues %d of asset "+
+				"%d which exceeds the remaining supply of %d",
+				txHash, issued, guid, remaining)
+			return ruleError(ErrBadAssetSupply, str)
+		}
+
+	case wire.SyscoinTxVersionAllocationMint:
+		// Mints issue the value burned on the NEVM chain, which must
+		// be proven against the roots the mint carries.
+		issued = assetsOut[guid] - assetsIn[guid]
+		if issued < 0 {
+			issued = 0
+		}
+		mint := payload.(*wire.MintSyscoinType)
+		err := checkMint(tx, mint, guid, issued, stored, utxoView,
+			chainParams)
+		if err != nil {
+			return err
+		}
+
+	case wire.SyscoinTxVersionSyscoinBurnToAllocation:
+		// Burns of SYS may only create value of the SYSX asset.
+		if chainParams.SysXAssetGuid == 0 ||
+			guid != chainParams.SysXAssetGuid {
+
+			str := fmt.Sprintf("burn of SYS %v creates value of "+
+				"asset %d which is not the SYSX asset", txHash,
+				guid)
+			return ruleError(ErrBadAssetPayload, str)
+		}
+		issued = msgTx.TxOut[dataOutIdx].Value
+	}
+
+	// Ensure the value of every asset is conserved save for the value of
+	// the asset issued by the transaction.
+	for outGuid, valueOut := range assetsOut {
+		valueIn := assetsIn[outGuid]
+		if outGuid == guid {
+			valueIn += issued
+		}
+		if valueIn != valueOut {
+			str := fmt.Sprintf("transaction %v assigns %d of asset "+
+				"%d to its outputs while its inputs provide %d",
+				txHash, valueOut, outGuid, valueIn)
+			return ruleError(ErrAssetNotConserved, str)
+		}
+	}
+	for inGuid, valueIn := range assetsIn {
+		if _, ok := assetsOut[inGuid]; !ok {
+			str := fmt.Sprintf("transaction %v spends %d of asset "+
+				"%d without assigning it to any output", txHash,
+				valueIn, inGuid)
+			return ruleError(ErrAssetNotConserved, str)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,437 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// TestCheckAssetInputs ensures the asset conservation rules accept and reject
+// the expected transactions.
+func TestCheckAssetInputs(t *testing.T) {
+	t.Parallel()
+
+	const guid = 1234
+	p2pkh := hexToBytes("76a914ee8bd501094a7d5ca318da2506de35e1cb025ddc88ac")
+
+	// The asset is the SYSX asset SYS may be burned to.
+	params := chaincfg.RegressionNetParams
+	params.AssetsSupported = true
+	params.SysXAssetGuid = guid
+
+	// Create a view with a plain output, two outputs of the asset, an
+	// output of another asset which may only update its capability flags
+	// and supply, and an output of an asset without decimal places.
+	plainOut := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 0}
+	assetOut1 := wire.OutPoint{Hash: chainhash.Hash{0x02}, Index: 0}
+	assetOut2 := wire.OutPoint{Hash: chainhash.Hash{0x02}, Index: 1}
+	otherOut := wire.OutPoint{Hash: chainhash.Hash{0x03}, Index: 0}
+	wholeOut := wire.OutPoint{Hash: chainhash.Hash{0x04}, Index: 0}
+	view := NewUtxoViewpoint()
+	view.entries[plainOut] = &UtxoEntry{amount: 5000, pkScript: p2pkh}
+	view.entries[assetOut1] = &UtxoEntry{amount: 1000, pkScript: p2pkh,
+		assetGuid: guid, assetValue: 60}
+	view.entries[assetOut2] = &UtxoEntry{amount: 1000, pkScript: p2pkh,
+		assetGuid: guid, assetValue: 40}
+	view.entries[otherOut] = &UtxoEntry{amount: 1000, pkScript: p2pkh,
+		assetGuid: guid + 1, assetValue: 0}
+	view.entries[wholeOut] = &UtxoEntry{amount: 1000, pkScript: p2pkh,
+		assetGuid: guid + 3, assetValue: 10}
+	view.assets[guid] = &AssetEntry{
+		precision:       8,
+		capabilityFlags: assetCapabilityFlagsMask,
+		maxSupply:       MaxAssetValue,
+		totalSupply:     100,
+	}
+	view.assets[guid+1] = &AssetEntry{
+		precision: 8,
+		capabilityFlags: wire.ASSET_UPDATE_CAPABILITYFLAGS |
+			wire.ASSET_UPDATE_SUPPLY,
+		maxSupply:   1000000,
+		totalSupply: 999500,
+	}
+	view.assets[guid+3] = &AssetEntry{
+		precision:       0,
+		capabilityFlags: assetCapabilityFlagsMask,
+		maxSupply:       1000,
+		totalSupply:     10,
+	}
+
+	// assetTx returns a transaction of the passed version spending the
+	// passed outpoints and paying numOuts outputs followed by a null data
+	// output carrying the passed payload.
+	assetTx := func(version int32, ins []wire.OutPoint, numOuts int, payload wire.SyscoinPayload) *btcutil.Tx {
+		tx := wire.NewMsgTx(version)
+		for i := range ins {
+			tx.AddTxIn(wire.NewTxIn(&ins[i], nil, nil))
+		}
+		for i := 0; i < numOuts; i++ {
+			tx.AddTxOut(wire.NewTxOut(500, p2pkh))
+		}
+		if payload != nil {
+			var buf bytes.Buffer
+			if err := payload.Serialize(&buf); err != nil {
+				t.Fatalf("Serialize: unexpected error: %v", err)
+			}
+			// Payloads such as mints exceed the standard null data
+			// size so build the script directly.
+			script, err := txscript.NewScriptBuilder().
+				AddOp(txscript.OP_RETURN).AddData(buf.Bytes()).
+				Script()
+			if err != nil {
+				t.Fatalf("Script: unexpected error: %v", err)
+			}
+			tx.AddTxOut(wire.NewTxOut(0, script))
+		}
+		return btcutil.NewTx(tx)
+	}
+
+	// allocation returns an asset allocation assigning the passed values
+	// of the asset to outputs in order.
+	allocation := func(guid uint64, values ...int64) wire.AssetAllocationType {
+		voutAsset := wire.AssetOutType{AssetGuid: guid}
+		for i, value := range values {
+			voutAsset.Values = append(voutAsset.Values,
+				wire.AssetOutValueType{N: uint32(i), ValueSat: value})
+		}
+		return wire.AssetAllocationType{
+			VoutAssets: []wire.AssetOutType{voutAsset},
+		}
+	}
+	send := func(values ...int64) *wire.AssetAllocationType {
+		a := allocation(guid, values...)
+		return &a
+	}
+
+	sysBurn := assetTx(wire.SyscoinTxVersionSyscoinBurnToAllocation,
+		[]wire.OutPoint{plainOut}, 1, send(700))
+	sysBurn.MsgTx().TxOut[1].Value = 700
+	otherSysBurn := assetTx(wire.SyscoinTxVersionSyscoinBurnToAllocation,
+		[]wire.OutPoint{plainOut}, 1, &wire.AssetAllocationType{
+			VoutAssets: allocation(guid+1, 700).VoutAssets,
+		})
+	otherSysBurn.MsgTx().TxOut[1].Value = 700
+
+	ethBurn := &wire.SyscoinBurnToEthereumType{
+		Allocation: allocation(guid, 90),
+		EthAddress: bytes.Repeat([]byte{0x01}, 20),
+	}
+	ethBurn.Allocation.VoutAssets[0].Values = append(
+		ethBurn.Allocation.VoutAssets[0].Values,
+		wire.AssetOutValueType{N: 1, ValueSat: 10})
+
+	badIndex := send(60, 40)
+	badIndex.VoutAssets[0].Values[1].N = 5
+
+	tests := []struct {
+		name string
+		tx   *btcutil.Tx
+		err  error
+	}{
+		{
+			name: "plain tx",
+			tx: assetTx(1, []wire.OutPoint{plainOut}, 1,
+				nil),
+			err: nil,
+		},
+		{
+			name: "plain tx spending asset",
+			tx: assetTx(1, []wire.OutPoint{assetOut1}, 1,
+				nil),
+			err: ruleError(ErrAssetNotConserved, ""),
+		},
+		{
+			name: "allocation send conserving value",
+			tx: assetTx(wire.SyscoinTxVersionAllocationSend,
+				[]wire.OutPoint{assetOut1, assetOut2}, 3,
+				send(50, 30, 20)),
+			err: nil,
+		},
+		{
+			name: "allocation send creating value",
+			tx: assetTx(wire.SyscoinTxVersionAllocationSend,
+				[]wire.OutPoint{assetOut1, assetOut2}, 2,
+				send(60, 41)),
+			err: ruleError(ErrAssetNotConserved, ""),
+		},
+		{
+			name: "allocation send dropping asset",
+			tx: assetTx(wire.SyscoinTxVersionAllocationSend,
+				[]wire.OutPoint{assetOut1, assetOut2, otherOut},
+				2, send(60, 40)),
+			err: ruleError(ErrAssetNotConserved, ""),
+		},
+		{
+			name: "allocation send to missing output",
+			tx: assetTx(wire.SyscoinTxVersionAllocationSend,
+				[]wire.OutPoint{assetOut1, assetOut2}, 2,
+				badIndex),
+			err: ruleError(ErrBadAssetOutputIndex, ""),
+		},
+		{
+			name: "allocation send to null data output",
+			tx: assetTx(wire.SyscoinTxVersionAllocationSend,
+				[]wire.OutPoint{assetOut1, assetOut2}, 1,
+				send(60, 40)),
+			err: ruleError(ErrBadAssetOutputIndex, ""),
+		},
+		{
+			name: "allocation send of unknown asset",
+			tx: assetTx(wire.SyscoinTxVersionAllocationSend,
+				[]wire.OutPoint{plainOut}, 1,
+				&wire.AssetAllocationType{
+					VoutAssets: allocation(guid+9, 0).VoutAssets,
+				}),
+			err: ruleError(ErrUnknownAsset, ""),
+		},
+		{
+			name: "allocation send beyond precision",
+			tx: assetTx(wire.SyscoinTxVersionAllocationSend,
+				[]wire.OutPoint{wholeOut}, 1,
+				&wire.AssetAllocationType{
+					VoutAssets: allocation(guid+3,
+						maxAssetValue(0)+1).VoutAssets,
+				}),
+			err: ruleError(ErrBadAssetValue, ""),
+		},
+		{
+			name: "allocation send negative value",
+			tx: assetTx(wire.SyscoinTxVersionAllocationSend,
+				[]wire.OutPoint{assetOut1, assetOut2}, 2,
+				send(110, -10)),
+			err: ruleError(ErrBadAssetValue, ""),
+		},
+		{
+			name: "burn to ethereum",
+			tx: assetTx(wire.SyscoinTxVersionAllocationBurnToEthereum,
+				[]wire.OutPoint{assetOut1, assetOut2}, 1,
+				ethBurn),
+			err: nil,
+		},
+		{
+			name: "burn sys to allocation",
+			tx:   sysBurn,
+			err:  nil,
+		},
+		{
+			name: "burn sys to other asset",
+			tx:   otherSysBurn,
+			err:  ruleError(ErrBadAssetPayload, ""),
+		},
+		{
+			name: "activate",
+			tx: assetTx(wire.SyscoinTxVersionAssetActivate,
+				[]wire.OutPoint{plainOut}, 1,
+				&wire.AssetType{
+					Allocation:  allocation(guid+2, 0),
+					Symbol:      []byte("SYSX"),
+					MaxSupply:   1000000,
+					Precision:   8,
+					UpdateFlags: wire.ASSET_INIT,
+				}),
+			err: nil,
+		},
+		{
+			name: "activate with bad precision",
+			tx: assetTx(wire.SyscoinTxVersionAssetActivate,
+				[]wire.OutPoint{plainOut}, 1,
+				&wire.AssetType{
+					Allocation:  allocation(guid+2, 0),
+					Symbol:      []byte("SYSX"),
+					MaxSupply:   1000000,
+					Precision:   MaxAssetPrecision + 1,
+					UpdateFlags: wire.ASSET_INIT,
+				}),
+			err: ruleError(ErrBadAssetSupply, ""),
+		},
+		{
+			name: "activate with max supply beyond precision",
+			tx: assetTx(wire.SyscoinTxVersionAssetActivate,
+				[]wire.OutPoint{plainOut}, 1,
+				&wire.AssetType{
+					Allocation:  allocation(guid+2, 0),
+					Symbol:      []byte("SYSX"),
+					MaxSupply:   maxAssetValue(0) + 1,
+					Precision:   0,
+					UpdateFlags: wire.ASSET_INIT,
+				}),
+			err: ruleError(ErrBadAssetSupply, ""),
+		},
+		{
+			name: "activate existing asset",
+			tx: assetTx(wire.SyscoinTxVersionAssetActivate,
+				[]wire.OutPoint{otherOut}, 1,
+				&wire.AssetType{
+					Allocation:  allocation(guid+1, 0),
+					Symbol:      []byte("SYSX"),
+					MaxSupply:   1000000,
+					UpdateFlags: wire.ASSET_INIT,
+				}),
+			err: ruleError(ErrAssetExists, ""),
+		},
+		{
+			name: "update removing capability",
+			tx: assetTx(wire.SyscoinTxVersionAssetUpdate,
+				[]wire.OutPoint{otherOut}, 1,
+				&wire.AssetType{
+					Allocation:                allocation(guid+1, 0),
+					UpdateFlags:               wire.ASSET_UPDATE_CAPABILITYFLAGS,
+					UpdateCapabilityFlags:     wire.ASSET_UPDATE_CAPABILITYFLAGS,
+					PrevUpdateCapabilityFlags: wire.ASSET_UPDATE_CAPABILITYFLAGS | wire.ASSET_UPDATE_SUPPLY,
+				}),
+			err: nil,
+		},
+		{
+			name: "update with wrong previous capabilities",
+			tx: assetTx(wire.SyscoinTxVersionAssetUpdate,
+				[]wire.OutPoint{otherOut}, 1,
+				&wire.AssetType{
+					Allocation:                allocation(guid+1, 0),
+					UpdateFlags:               wire.ASSET_UPDATE_CAPABILITYFLAGS,
+					UpdateCapabilityFlags:     wire.ASSET_UPDATE_CAPABILITYFLAGS,
+					PrevUpdateCapabilityFlags: assetCapabilityFlagsMask,
+				}),
+			err: ruleError(ErrBadAssetUpdateFlags, ""),
+		},
+		{
+			name: "update of field not allowed by capabilities",
+			tx: assetTx(wire.SyscoinTxVersionAssetUpdate,
+				[]wire.OutPoint{otherOut}, 1,
+				&wire.AssetType{
+					Allocation:  allocation(guid+1, 0),
+					UpdateFlags: wire.ASSET_UPDATE_DATA,
+					PubData:     []byte("data"),
+				}),
+			err: ruleError(ErrBadAssetUpdateFlags, ""),
+		},
+		{
+			name: "update supply up to max supply",
+			tx: assetTx(wire.SyscoinTxVersionAssetUpdate,
+				[]wire.OutPoint{otherOut}, 1,
+				&wire.AssetType{
+					Allocation:  allocation(guid+1, 0),
+					UpdateFlags: wire.ASSET_UPDATE_SUPPLY,
+					TotalSupply: 500,
+				}),
+			err: nil,
+		},
+		{
+			name: "update supply beyond max supply",
+			tx: assetTx(wire.SyscoinTxVersionAssetUpdate,
+				[]wire.OutPoint{otherOut}, 1,
+				&wire.AssetType{
+					Allocation:  allocation(guid+1, 0),
+					UpdateFlags: wire.ASSET_UPDATE_SUPPLY,
+					TotalSupply: 501,
+				}),
+			err: ruleError(ErrBadAssetSupply, ""),
+		},
+		{
+			name: "update of unknown asset",
+			tx: assetTx(wire.SyscoinTxVersionAssetUpdate,
+				[]wire.OutPoint{plainOut}, 1,
+				&wire.AssetType{
+					Allocation: allocation(guid+9, 0),
+				}),
+			err: ruleError(ErrUnknownAsset, ""),
+		},
+		{
+			name: "update adding capability",
+			tx: assetTx(wire.SyscoinTxVersionAssetUpdate,
+				[]wire.OutPoint{otherOut}, 1,
+				&wire.AssetType{
+					Allocation:                allocation(guid+1, 0),
+					UpdateFlags:               wire.ASSET_UPDATE_CAPABILITYFLAGS,
+					UpdateCapabilityFlags:     wire.ASSET_UPDATE_CAPABILITYFLAGS | wire.ASSET_UPDATE_DATA,
+					PrevUpdateCapabilityFlags: wire.ASSET_UPDATE_CAPABILITYFLAGS,
+				}),
+			err: ruleError(ErrBadAssetUpdateFlags, ""),
+		},
+		{
+			name: "update without owner output",
+			tx: assetTx(wire.SyscoinTxVersionAssetUpdate,
+				[]wire.OutPoint{plainOut}, 1,
+				&wire.AssetType{
+					Allocation: allocation(guid+1, 0),
+				}),
+			err: ruleError(ErrAssetNotConserved, ""),
+		},
+		{
+			name: "mint without valid proof",
+			tx: assetTx(wire.SyscoinTxVersionAllocationMint, nil, 1,
+				&wire.MintSyscoinType{
+					Allocation:  allocation(guid, 500),
+					TxHash:      make([]byte, 32),
+					BlockHash:   make([]byte, 32),
+					TxRoot:      bytes.Repeat([]byte{0x01}, 32),
+					TxPath:      []byte{0x80},
+					ReceiptRoot: bytes.Repeat([]byte{0x02}, 32),
+				}),
+			err: ruleError(ErrBadMintProof, ""),
+		},
+		{
+			name: "send issuing value",
+			tx: assetTx(wire.SyscoinTxVersionAssetSend,
+				[]wire.OutPoint{otherOut}, 2,
+				&wire.AssetType{
+					Allocation: allocation(guid+1, 0, 500),
+				}),
+			err: nil,
+		},
+		{
+			name: "send issuing value beyond max supply",
+			tx: assetTx(wire.SyscoinTxVersionAssetSend,
+				[]wire.OutPoint{otherOut}, 2,
+				&wire.AssetType{
+					Allocation: allocation(guid+1, 0, 501),
+				}),
+			err: ruleError(ErrBadAssetSupply, ""),
+		},
+	}
+
+	for _, test := range tests {
+		err := checkAssetInputs(test.tx, view, &params)
+		if test.err == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok {
+			t.Errorf("%s: did not get expected rule error %v - "+
+				"got %v", test.name, test.err, err)
+			continue
+		}
+		if rerr.ErrorCode != test.err.(RuleError).ErrorCode {
+			t.Errorf("%s: unexpected error code - got %v, want %v",
+				test.name, rerr.ErrorCode,
+				test.err.(RuleError).ErrorCode)
+		}
+	}
+
+	// Transactions with the version of an asset transaction are only
+	// subject to the asset rules on networks with assets.
+	noPayload := assetTx(wire.SyscoinTxVersionAssetSend,
+		[]wire.OutPoint{assetOut1}, 1, nil)
+	_, err := CheckTransactionInputs(noPayload, 1, view, &params)
+	if _, ok := err.(RuleError); !ok {
+		t.Errorf("CheckTransactionInputs: did not get expected rule "+
+			"error on a network with assets - got %v", err)
+	}
+	_, err = CheckTransactionInputs(noPayload, 1, view,
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Errorf("CheckTransactionInputs: unexpected error on a "+
+			"network without assets: %v", err)
+	}
+}
@@ -0,0 +1,708 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/wire"
+)
+
+var (
+	// assetStateBucketName is the name of the db bucket used to house the
+	// state of every asset the consensus rules depend on keyed by guid.
+	assetStateBucketName = []byte("assetstate")
+
+	// assetUndoBucketName is the name of the db bucket used to house the
+	// state the assets changed by each block in the main chain had before
+	// the block keyed by block hash.
+	assetUndoBucketName = []byte("assetundo")
+
+	// snapshotAssetStateBucketName is the name of the db bucket used to
+	// house the state of the assets which results from validating the
+	// blocks before the UTXO snapshot the chain was bootstrapped from.
+	snapshotAssetStateBucketName = []byte("snapshotassetstate")
+)
+
+// AssetEntry houses the state of an asset which the consensus rules and the
+// notary and aux fee policy of the memory pool depend on.  The remaining fields of an asset
+// are only tracked by the asset index.
+type AssetEntry struct {
+	precision       uint8
+	capabilityFlags uint8
+	maxSupply       int64
+	totalSupply     int64
+	notaryKeyID     []byte
+	auxFeeDetails   wire.AuxFeeDetailsType
+}
+
+// Precision returns the number of decimal places the asset may be divided
+// into.
+func (entry *AssetEntry) Precision() uint8 {
+	return entry.precision
+}
+
+// CapabilityFlags returns the flags of the fields of the asset which may be
+// updated.
+func (entry *AssetEntry) CapabilityFlags() uint8 {
+	return entry.capabilityFlags
+}
+
+// MaxSupply returns the maximum value of the asset which may be issued.
+func (entry *AssetEntry) MaxSupply() int64 {
+	return entry.maxSupply
+}
+
+// TotalSupply returns the value of the asset which has been issued.
+func (entry *AssetEntry) TotalSupply() int64 {
+	return entry.totalSupply
+}
+
+// NotaryKeyID returns the hash160 of the key of the notary which must sign off
+// on transactions moving the asset.  It is empty when the asset has no notary.
+func (entry *AssetEntry) NotaryKeyID() []byte {
+	return entry.notaryKeyID
+}
+
+// AuxFeeDetails returns the recipient and the fee schedule of the aux fee which
+// must be paid by transactions sending the asset.  The schedule is empty when
+// the asset has no aux fees.
+func (entry *AssetEntry) AuxFeeDetails() wire.AuxFeeDetailsType {
+	return entry.auxFeeDetails
+}
+
+// -----------------------------------------------------------------------------
+// The asset state bucket houses an entry for every asset which has been
+// activated in the main chain.
+//
+// The serialized key format is:
+//
+//   <guid>
+//
+//   Field      Type     Size
+//   guid       uint64   8
+//
+// The serialized value format is:
+//
+//   <precision><capability flags><max supply><total supply>
+//   <notary key id size><notary key id><aux fee key id size><aux fee key id>
+//   <num aux fees>[<bound><percent>,...]
+//
+//   Field                 Type     Size
+//   precision             uint8    1
+//   capability flags      uint8    1
+//   max supply            VLQ      variable
+//   total supply          VLQ      variable
+//   notary key id size    VLQ      variable
+//   notary key id         []byte   notary key id size
+//   aux fee key id size   VLQ      variable
+//   aux fee key id        []byte   aux fee key id size
+//   num aux fees          VLQ      variable
+//   bound                 VLQ      variable
+//   percent               VLQ      variable
+//
+// The asset state bucket also houses an entry for every Ethereum transaction
+// whose burn has been minted in the main chain, so it can't be minted again.
+// Those entries are told apart from the asset entries by the size of their key.
+//
+// The serialized key format is:
+//
+//   <Ethereum tx hash>
+//
+//   Field              Type             Size
+//   Ethereum tx hash   chainhash.Hash   chainhash.HashSize
+//
+// The serialized value format is:
+//
+//   <guid>
+//
+//   Field      Type     Size
+//   guid       uint64   8
+//
+// The guid is the one of the asset the burn was minted as.  The entries of the
+// mints of a block are removed when it is disconnected, so the undo data only
+// covers the asset entries.
+//
+// The asset undo bucket houses an entry for every block in the main chain
+// keyed by block hash.  The serialized value format is:
+//
+//   <num assets>[<guid><entry size><entry>,...]
+//
+//   Field          Type     Size
+//   num assets     VLQ      variable
+//   guid           uint64   8
+//   entry size     VLQ      variable
+//   entry          []byte   entry size
+//
+// Every record holds the state the asset had before the block was connected
+// in the format of the asset state bucket, or an empty entry when the asset
+// was activated by the block.  All integers are encoded in little endian.
+// -----------------------------------------------------------------------------
+
+// assetStateKey returns the key of the asset with the passed guid in the asset
+// state bucket.
+func assetStateKey(guid uint64) []byte {
+	var key [8]byte
+	byteOrder.PutUint64(key[:], guid)
+	return key[:]
+}
+
+// serializeAssetEntry returns the serialization of the passed asset entry.
+func serializeAssetEntry(entry *AssetEntry) []byte {
+	var scratch [10]byte
+	putVarInt := func(serialized []byte, n uint64) []byte {
+		return append(serialized, scratch[:putVLQ(scratch[:], n)]...)
+	}
+	putVarBytes := func(serialized []byte, data []byte) []byte {
+		serialized = putVarInt(serialized, uint64(len(data)))
+		return append(serialized, data...)
+	}
+
+	serialized := []byte{entry.precision, entry.capabilityFlags}
+	serialized = putVarInt(serialized, uint64(entry.maxSupply))
+	serialized = putVarInt(serialized, uint64(entry.totalSupply))
+	serialized = putVarBytes(serialized, entry.notaryKeyID)
+	serialized = putVarBytes(serialized, entry.auxFeeDetails.AuxFeeKeyID)
+	serialized = putVarInt(serialized, uint64(len(entry.auxFeeDetails.AuxFees)))
+	for _, auxFee := range entry.auxFeeDetails.AuxFees {
+		serialized = putVarInt(serialized, uint64(auxFee.Bound))
+		serialized = putVarInt(serialized, uint64(auxFee.Percent))
+	}
+	return serialized
+}
+
+// deserializeAssetEntry decodes an asset entry from the passed serialized
+// byte slice.
+func deserializeAssetEntry(serialized []byte) (*AssetEntry, error) {
+	if len(serialized) < 2 {
+		return nil, errDeserialize("unexpected end of data for " +
+			"asset flags")
+	}
+	entry := &AssetEntry{
+		precision:       serialized[0],
+		capabilityFlags: serialized[1],
+	}
+
+	offset := 2
+	readVarInt := func(field string) (uint64, error) {
+		if offset >= len(serialized) {
+			return 0, errDeserialize("unexpected end of data " +
+				"for " + field)
+		}
+		n, bytesRead := deserializeVLQ(serialized[offset:])
+		offset += bytesRead
+		if serialized[offset-1]&0x80 != 0 {
+			return 0, errDeserialize("unexpected end of data " +
+				"within " + field)
+		}
+		return n, nil
+	}
+	readVarBytes := func(field string) ([]byte, error) {
+		size, err := readVarInt(field + " size")
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(serialized)-offset) < size {
+			return nil, errDeserialize("unexpected end of data " +
+				"for " + field)
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		data := append([]byte(nil), serialized[offset:offset+int(size)]...)
+		offset += int(size)
+		return data, nil
+	}
+
+	maxSupply, err := readVarInt("max supply")
+	if err != nil {
+		return nil, err
+	}
+	totalSupply, err := readVarInt("total supply")
+	if err != nil {
+		return nil, err
+	}
+	if maxSupply > math.MaxInt64 || totalSupply > math.MaxInt64 {
+		return nil, errDeserialize(fmt.Sprintf("asset supply %d or max "+
+			"supply %d overflows int64", totalSupply, maxSupply))
+	}
+	entry.maxSupply = int64(maxSupply)
+	entry.totalSupply = int64(totalSupply)
+
+	entry.notaryKeyID, err = readVarBytes("notary key id")
+	if err != nil {
+		return nil, err
+	}
+	entry.auxFeeDetails.AuxFeeKeyID, err = readVarBytes("aux fee key id")
+	if err != nil {
+		return nil, err
+	}
+	numAuxFees, err := readVarInt("number of aux fees")
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < numAuxFees; i++ {
+		bound, err := readVarInt("aux fee bound")
+		if err != nil {
+			return nil, err
+		}
+		percent, err := readVarInt("aux fee percent")
+		if err != nil {
+			return nil, err
+		}
+		if percent > math.MaxUint16 {
+			return nil, errDeserialize(fmt.Sprintf("aux fee "+
+				"percent %d overflows uint16", percent))
+		}
+		entry.auxFeeDetails.AuxFees = append(
+			entry.auxFeeDetails.AuxFees, wire.AuxFeesType{
+				Bound:   int64(bound),
+				Percent: uint16(percent),
+			})
+	}
+	if offset != len(serialized) {
+		return nil, errDeserialize("unexpected data after aux fees")
+	}
+	return entry, nil
+}
+
+// dbFetchAssetEntry fetches the entry of the asset with the passed guid from
+// the passed asset state bucket.  Nil is returned when the asset does not
+// exist.
+func dbFetchAssetEntry(assetBucket database.Bucket, guid uint64) (*AssetEntry, error) {
+	serialized := assetBucket.Get(assetStateKey(guid))
+	if serialized == nil {
+		return nil, nil
+	}
+	entry, err := deserializeAssetEntry(serialized)
+	if err != nil {
+		if isDeserializeErr(err) {
+			return nil, database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt asset entry "+
+					"for %d: %v", guid, err),
+			}
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// dbPutViewAssets uses an existing database transaction to update the asset
+// state in the database based on the assets and mints the passed view changed
+// since it was last committed.
+func dbPutViewAssets(dbTx database.Tx, view *UtxoViewpoint) error {
+	assetBucket := dbTx.Metadata().Bucket(view.assetBucketName)
+	for ethTxHash := range view.changedMints {
+		guid := view.mints[ethTxHash]
+		if guid == 0 {
+			if err := assetBucket.Delete(ethTxHash[:]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := assetBucket.Put(ethTxHash[:], assetStateKey(guid))
+		if err != nil {
+			return err
+		}
+	}
+	for guid := range view.prevAssets {
+		entry := view.assets[guid]
+		if entry == nil {
+			if err := assetBucket.Delete(assetStateKey(guid)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := assetBucket.Put(assetStateKey(guid),
+			serializeAssetEntry(entry))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serializeAssetUndo returns the serialization of the passed states the assets
+// had before a block was connected.
+func serializeAssetUndo(prevAssets map[uint64]*AssetEntry) []byte {
+	var serialized []byte
+	var scratch [10]byte
+	n := putVLQ(scratch[:], uint64(len(prevAssets)))
+	serialized = append(serialized, scratch[:n]...)
+	for guid, entry := range prevAssets {
+		serialized = append(serialized, assetStateKey(guid)...)
+		var serializedEntry []byte
+		if entry != nil {
+			serializedEntry = serializeAssetEntry(entry)
+		}
+		n := putVLQ(scratch[:], uint64(len(serializedEntry)))
+		serialized = append(serialized, scratch[:n]...)
+		serialized = append(serialized, serializedEntry...)
+	}
+	return serialized
+}
+
+// deserializeAssetUndo decodes the states assets had before a block was
+// connected from the passed serialized byte slice.  Assets which did not exist
+// are included with a nil entry.
+func deserializeAssetUndo(serialized []byte) (map[uint64]*AssetEntry, error) {
+	numAssets, offset := deserializeVLQ(serialized)
+	if offset == 0 {
+		return nil, errDeserialize("unexpected end of data for the " +
+			"number of assets")
+	}
+	prevAssets := make(map[uint64]*AssetEntry)
+	for i := uint64(0); i < numAssets; i++ {
+		if offset+8 > len(serialized) {
+			return nil, errDeserialize("unexpected end of data " +
+				"for asset guid")
+		}
+		guid := byteOrder.Uint64(serialized[offset:])
+		offset += 8
+
+		size, bytesRead := deserializeVLQ(serialized[offset:])
+		offset += bytesRead
+		if bytesRead == 0 || uint64(len(serialized)-offset) < size {
+			return nil, errDeserialize("unexpected end of data " +
+				"for asset entry")
+		}
+		if size == 0 {
+			prevAssets[guid] = nil
+			continue
+		}
+		entry, err := deserializeAssetEntry(
+			serialized[offset : offset+int(size)])
+		if err != nil {
+			return nil, err
+		}
+		prevAssets[guid] = entry
+		offset += int(size)
+	}
+	if offset != len(serialized) {
+		return nil, errDeserialize("unexpected data after asset undo " +
+			"entries")
+	}
+	return prevAssets, nil
+}
+
+// dbPutAssetUndo uses an existing database transaction to store the states the
+// assets changed by the block with the passed hash had before it, which are
+// the ones the passed view tracks since it was last committed.
+func dbPutAssetUndo(dbTx database.Tx, blockHash *chainhash.Hash, view *UtxoViewpoint) error {
+	undoBucket := dbTx.Metadata().Bucket(assetUndoBucketName)
+	return undoBucket.Put(blockHash[:], serializeAssetUndo(view.prevAssets))
+}
+
+// dbFetchAssetUndo uses an existing database transaction to fetch the states
+// the assets changed by the block with the passed hash had before it.
+func dbFetchAssetUndo(dbTx database.Tx, blockHash *chainhash.Hash) (map[uint64]*AssetEntry, error) {
+	undoBucket := dbTx.Metadata().Bucket(assetUndoBucketName)
+	serialized := undoBucket.Get(blockHash[:])
+	if serialized == nil {
+		return nil, AssertError(fmt.Sprintf("missing asset undo data "+
+			"for block %v", blockHash))
+	}
+	prevAssets, err := deserializeAssetUndo(serialized)
+	if err != nil {
+		if isDeserializeErr(err) {
+			return nil, database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt asset undo "+
+					"data for block %v: %v", blockHash, err),
+			}
+		}
+		return nil, err
+	}
+	return prevAssets, nil
+}
+
+// dbRemoveAssetUndo uses an existing database transaction to remove the asset
+// undo data of the block with the passed hash.
+func dbRemoveAssetUndo(dbTx database.Tx, blockHash *chainhash.Hash) error {
+	undoBucket := dbTx.Metadata().Bucket(assetUndoBucketName)
+	return undoBucket.Delete(blockHash[:])
+}
+
+// txAssetGuids returns the guids of the assets the Syscoin payload of the passed
+// transaction references.  Nil is returned for transactions which are not
+// Syscoin transactions or whose payload can't be decoded.
+func txAssetGuids(msgTx *wire.MsgTx) []uint64 {
+	if !wire.IsSyscoinTx(msgTx.Version) {
+		return nil
+	}
+	allocation, err := msgTx.AssetAllocation()
+	if err != nil {
+		return nil
+	}
+
+	guids := make([]uint64, 0, len(allocation.VoutAssets))
+	for _, voutAsset := range allocation.VoutAssets {
+		guids = append(guids, voutAsset.AssetGuid)
+	}
+	return guids
+}
+
+// txMint returns the mint payload of the passed transaction.  Nil is returned
+// for transactions which are not mints or whose payload can't be decoded.
+func txMint(msgTx *wire.MsgTx) *wire.MintSyscoinType {
+	if msgTx.Version != wire.SyscoinTxVersionAllocationMint {
+		return nil
+	}
+	payload, err := msgTx.SyscoinPayload()
+	if err != nil {
+		return nil
+	}
+	mint, ok := payload.(*wire.MintSyscoinType)
+	if !ok || len(mint.TxHash) != chainhash.HashSize ||
+		len(mint.BlockHash) != chainhash.HashSize {
+
+		return nil
+	}
+	return mint
+}
+
+// LookupAsset returns the state of the asset with the passed guid according to
+// the current state of the view.  It returns nil if the asset does not exist or
+// has not been loaded into the view.
+func (view *UtxoViewpoint) LookupAsset(guid uint64) *AssetEntry {
+	return view.assets[guid]
+}
+
+// Assets returns the underlying map that stores the state of all assets loaded
+// into the view keyed by guid.  Assets which are known not to exist have a nil
+// entry.
+func (view *UtxoViewpoint) Assets() map[uint64]*AssetEntry {
+	return view.assets
+}
+
+// LookupMint returns the guid of the asset the burn of the Ethereum transaction
+// with the passed hash was minted as according to the current state of the
+// view.  It returns zero if the burn has not been minted or its state has not
+// been loaded into the view.
+func (view *UtxoViewpoint) LookupMint(ethTxHash *chainhash.Hash) uint64 {
+	return view.mints[*ethTxHash]
+}
+
+// Mints returns the underlying map that stores the guids of the assets the
+// burns of the Ethereum transactions loaded into the view were minted as keyed
+// by Ethereum transaction hash.  Burns which have not been minted have a zero
+// guid.
+func (view *UtxoViewpoint) Mints() map[chainhash.Hash]uint64 {
+	return view.mints
+}
+
+// setMint sets the guid of the asset the burn of the Ethereum transaction with
+// the passed hash was minted as, or marks it as not minted when the guid is
+// zero, while tracking it changed since the view was last committed.
+func (view *UtxoViewpoint) setMint(ethTxHash chainhash.Hash, guid uint64) {
+	view.changedMints[ethTxHash] = struct{}{}
+	view.mints[ethTxHash] = guid
+}
+
+// setAsset sets the state of the asset with the passed guid, or removes the
+// asset when the entry is nil, while tracking the state the asset had when the
+// view was last committed.  Entries are never modified once they have been
+// added to the view, so the tracked states remain intact.
+func (view *UtxoViewpoint) setAsset(guid uint64, entry *AssetEntry) {
+	if _, ok := view.prevAssets[guid]; !ok {
+		view.prevAssets[guid] = view.assets[guid]
+	}
+	view.assets[guid] = entry
+}
+
+// UpdateAssets updates the state of the asset activated, updated or issued by
+// the passed transaction in the view, and marks the burn minted by it as
+// minted.  It must be called before the outputs spent by the transaction are
+// marked spent since the value issued by an asset send is the value of the
+// asset it assigns beyond the value it spends.
+//
+// The transaction is expected to have passed the checks of
+// CheckTransactionInputs, so transactions which don't reference a known asset
+// are ignored.
+func (view *UtxoViewpoint) UpdateAssets(tx *btcutil.Tx) {
+	msgTx := tx.MsgTx()
+	if mint := txMint(msgTx); mint != nil &&
+		len(mint.Allocation.VoutAssets) != 0 {
+
+		var ethTxHash chainhash.Hash
+		copy(ethTxHash[:], mint.TxHash)
+		view.setMint(ethTxHash, mint.Allocation.VoutAssets[0].AssetGuid)
+		return
+	}
+
+	switch msgTx.Version {
+	case wire.SyscoinTxVersionAssetActivate,
+		wire.SyscoinTxVersionAssetUpdate,
+		wire.SyscoinTxVersionAssetSend:
+	default:
+		return
+	}
+	payload, err := msgTx.SyscoinPayload()
+	if err != nil {
+		return
+	}
+	asset, ok := payload.(*wire.AssetType)
+	if !ok || len(asset.Allocation.VoutAssets) == 0 {
+		return
+	}
+	guid := asset.Allocation.VoutAssets[0].AssetGuid
+
+	if msgTx.Version == wire.SyscoinTxVersionAssetActivate {
+		entry := &AssetEntry{
+			precision:       asset.Precision,
+			capabilityFlags: asset.UpdateCapabilityFlags,
+			maxSupply:       asset.MaxSupply,
+			notaryKeyID:     asset.NotaryKeyID,
+			auxFeeDetails:   asset.AuxFeeDetails,
+		}
+		if asset.UpdateFlags&wire.ASSET_UPDATE_SUPPLY != 0 {
+			entry.totalSupply = asset.TotalSupply
+		}
+		view.setAsset(guid, entry)
+		return
+	}
+
+	stored := view.assets[guid]
+	if stored == nil {
+		return
+	}
+	entry := *stored
+	if msgTx.Version == wire.SyscoinTxVersionAssetUpdate {
+		if asset.UpdateFlags&wire.ASSET_UPDATE_SUPPLY != 0 {
+			entry.totalSupply += asset.TotalSupply
+		}
+		if asset.UpdateFlags&wire.ASSET_UPDATE_NOTARY_KEY != 0 {
+			entry.notaryKeyID = asset.NotaryKeyID
+		}
+		if asset.UpdateFlags&wire.ASSET_UPDATE_AUXFEE != 0 {
+			entry.auxFeeDetails = asset.AuxFeeDetails
+		}
+		if asset.UpdateFlags&wire.ASSET_UPDATE_CAPABILITYFLAGS != 0 {
+			entry.capabilityFlags = asset.UpdateCapabilityFlags
+		}
+		view.setAsset(guid, &entry)
+		return
+	}
+
+	// The value issued by an asset send is the value of the asset the
+	// outputs are assigned beyond the value spent by the inputs.
+	var issued int64
+	for _, value := range asset.Allocation.VoutAssets[0].Values {
+		issued += value.ValueSat
+	}
+	for _, txIn := range msgTx.TxIn {
+		utxo := view.LookupEntry(txIn.PreviousOutPoint)
+		if utxo != nil && utxo.AssetGuid() == guid {
+			issued -= utxo.AssetValue()
+		}
+	}
+	if issued > 0 {
+		entry.totalSupply += issued
+		view.setAsset(guid, &entry)
+	}
+}
+
+// disconnectAssets restores the state the assets changed by the passed block
+// had before it was connected using the undo data stored for it and marks the
+// burns minted by the block as not minted.
+func (view *UtxoViewpoint) disconnectAssets(db database.DB, block *btcutil.Block) error {
+	var prevAssets map[uint64]*AssetEntry
+	err := db.View(func(dbTx database.Tx) error {
+		var err error
+		prevAssets, err = dbFetchAssetUndo(dbTx, block.Hash())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for guid, entry := range prevAssets {
+		view.setAsset(guid, entry)
+	}
+	for _, tx := range block.Transactions() {
+		if mint := txMint(tx.MsgTx()); mint != nil {
+			var ethTxHash chainhash.Hash
+			copy(ethTxHash[:], mint.TxHash)
+			view.setMint(ethTxHash, 0)
+		}
+	}
+	return nil
+}
+
+// neededAssetState houses the state of the assets a set of transactions
+// depends on which needs to be loaded into a view.
+type neededAssetState struct {
+	guids map[uint64]struct{}
+	mints map[chainhash.Hash]struct{}
+}
+
+// newNeededAssetState returns an empty set of needed asset state.
+func newNeededAssetState() *neededAssetState {
+	return &neededAssetState{
+		guids: make(map[uint64]struct{}),
+		mints: make(map[chainhash.Hash]struct{}),
+	}
+}
+
+// addTx adds the assets referenced by the passed transaction along with the
+// minted burn for mints.
+func (needed *neededAssetState) addTx(msgTx *wire.MsgTx) {
+	for _, guid := range txAssetGuids(msgTx) {
+		needed.guids[guid] = struct{}{}
+	}
+	if mint := txMint(msgTx); mint != nil {
+		var hash chainhash.Hash
+		copy(hash[:], mint.TxHash)
+		needed.mints[hash] = struct{}{}
+	}
+}
+
+// fetchAssets loads the passed needed state of assets and mints into the view
+// from the database unless they already exist in the view.  Assets which don't
+// exist result in a nil entry in the view, while burns which have not been
+// minted have a zero guid.
+func (view *UtxoViewpoint) fetchAssets(db database.DB, needed *neededAssetState) error {
+	var guids []uint64
+	for guid := range needed.guids {
+		if _, ok := view.assets[guid]; !ok {
+			guids = append(guids, guid)
+		}
+	}
+	var mints []chainhash.Hash
+	for hash := range needed.mints {
+		if _, ok := view.mints[hash]; !ok {
+			mints = append(mints, hash)
+		}
+	}
+	if len(guids) == 0 && len(mints) == 0 {
+		return nil
+	}
+
+	return db.View(func(dbTx database.Tx) error {
+		assetBucket := dbTx.Metadata().Bucket(view.assetBucketName)
+		for _, guid := range guids {
+			entry, err := dbFetchAssetEntry(assetBucket, guid)
+			if err != nil {
+				return err
+			}
+			view.assets[guid] = entry
+		}
+		for _, hash := range mints {
+			var guid uint64
+			if serialized := assetBucket.Get(hash[:]); len(serialized) == 8 {
+				guid = byteOrder.Uint64(serialized)
+			}
+			view.mints[hash] = guid
+		}
+		return nil
+	})
+}
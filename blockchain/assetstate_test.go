@@ -0,0 +1,159 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// TestAssetEntrySerialization ensures asset entries round trip through their
+// serialization and malformed serializations are rejected.
+func TestAssetEntrySerialization(t *testing.T) {
+	t.Parallel()
+
+	entries := []struct {
+		name  string
+		entry *AssetEntry
+	}{
+		{"no notary", &AssetEntry{
+			precision:       8,
+			capabilityFlags: assetCapabilityFlagsMask,
+			maxSupply:       MaxAssetValue,
+			totalSupply:     1000,
+		}},
+		{"notary", &AssetEntry{
+			precision:   2,
+			maxSupply:   100000,
+			notaryKeyID: bytes.Repeat([]byte{0x01}, 20),
+		}},
+		{"aux fees", &AssetEntry{
+			precision: 8,
+			maxSupply: MaxAssetValue,
+			auxFeeDetails: wire.AuxFeeDetailsType{
+				AuxFeeKeyID: bytes.Repeat([]byte{0x02}, 20),
+				AuxFees: []wire.AuxFeesType{
+					{Bound: 0, Percent: 1000},
+					{Bound: 1e10, Percent: 65535},
+				},
+			},
+		}},
+	}
+	for _, test := range entries {
+		serialized := serializeAssetEntry(test.entry)
+		got, err := deserializeAssetEntry(serialized)
+		if err != nil {
+			t.Errorf("%s: deserializeAssetEntry: unexpected error: %v",
+				test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.entry) {
+			t.Errorf("%s: mismatched entry - got %v, want %v",
+				test.name, spew.Sdump(got), spew.Sdump(test.entry))
+		}
+
+		// Truncated and padded serializations are rejected.
+		for _, bad := range [][]byte{
+			serialized[:len(serialized)-1],
+			append(serialized, 0x00),
+		} {
+			_, err := deserializeAssetEntry(bad)
+			if !isDeserializeErr(err) {
+				t.Errorf("%s: did not get expected deserialize "+
+					"error for %x - got %v", test.name, bad, err)
+			}
+		}
+	}
+}
+
+// TestUpdateAssetsPolicyState ensures the notary key and aux fees of an asset
+// are set by its activation and only replaced by updates which update them.
+func TestUpdateAssetsPolicyState(t *testing.T) {
+	t.Parallel()
+
+	const guid = 1234
+	notaryKeyID := bytes.Repeat([]byte{0x01}, 20)
+	otherKeyID := bytes.Repeat([]byte{0x02}, 20)
+	auxFees := wire.AuxFeeDetailsType{
+		AuxFeeKeyID: notaryKeyID,
+		AuxFees:     []wire.AuxFeesType{{Bound: 0, Percent: 1000}},
+	}
+	otherAuxFees := wire.AuxFeeDetailsType{
+		AuxFeeKeyID: otherKeyID,
+		AuxFees:     []wire.AuxFeesType{{Bound: 100, Percent: 10}},
+	}
+
+	// assetTx returns a transaction of the passed version carrying the
+	// passed asset payload for the asset.
+	assetTx := func(version int32, asset *wire.AssetType) *btcutil.Tx {
+		asset.Allocation = wire.AssetAllocationType{
+			VoutAssets: []wire.AssetOutType{{AssetGuid: guid}},
+		}
+		var buf bytes.Buffer
+		if err := asset.Serialize(&buf); err != nil {
+			t.Fatalf("Serialize: unexpected error: %v", err)
+		}
+		script, err := txscript.NullDataScript(buf.Bytes())
+		if err != nil {
+			t.Fatalf("NullDataScript: unexpected error: %v", err)
+		}
+		tx := wire.NewMsgTx(version)
+		tx.AddTxOut(wire.NewTxOut(0, script))
+		return btcutil.NewTx(tx)
+	}
+
+	view := NewUtxoViewpoint()
+	checkState := func(desc string, wantKeyID []byte, wantAuxFees wire.AuxFeeDetailsType) {
+		t.Helper()
+		entry := view.LookupAsset(guid)
+		if got := entry.NotaryKeyID(); !bytes.Equal(got, wantKeyID) {
+			t.Fatalf("%s: got notary key %x, want %x", desc, got,
+				wantKeyID)
+		}
+		if got := entry.AuxFeeDetails(); !reflect.DeepEqual(got, wantAuxFees) {
+			t.Fatalf("%s: got aux fees %v, want %v", desc,
+				spew.Sdump(got), spew.Sdump(wantAuxFees))
+		}
+	}
+
+	view.UpdateAssets(assetTx(wire.SyscoinTxVersionAssetActivate,
+		&wire.AssetType{
+			Precision: 8,
+			MaxSupply: 1000,
+			UpdateFlags: wire.ASSET_INIT | wire.ASSET_UPDATE_NOTARY_KEY |
+				wire.ASSET_UPDATE_AUXFEE,
+			NotaryKeyID:   notaryKeyID,
+			AuxFeeDetails: auxFees,
+		}))
+	checkState("activation", notaryKeyID, auxFees)
+
+	view.UpdateAssets(assetTx(wire.SyscoinTxVersionAssetUpdate,
+		&wire.AssetType{
+			UpdateFlags:   wire.ASSET_UPDATE_DATA,
+			NotaryKeyID:   otherKeyID,
+			AuxFeeDetails: otherAuxFees,
+		}))
+	checkState("update of other fields", notaryKeyID, auxFees)
+
+	view.UpdateAssets(assetTx(wire.SyscoinTxVersionAssetUpdate,
+		&wire.AssetType{
+			UpdateFlags: wire.ASSET_UPDATE_NOTARY_KEY,
+			NotaryKeyID: otherKeyID,
+		}))
+	checkState("update of notary key", otherKeyID, auxFees)
+
+	view.UpdateAssets(assetTx(wire.SyscoinTxVersionAssetUpdate,
+		&wire.AssetType{
+			UpdateFlags:   wire.ASSET_UPDATE_AUXFEE,
+			AuxFeeDetails: otherAuxFees,
+		}))
+	checkState("update of aux fees", otherKeyID, otherAuxFees)
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+)
+
+const (
+	// assumeValidMinWorkTime is the amount of time the proof of work which
+	// has been performed on top of a block must be equivalent to before
+	// its scripts are assumed to be valid.  This ensures the scripts of
+	// recent blocks are always validated even when the assumed valid block
+	// is recent.
+	assumeValidMinWorkTime = time.Hour * 24 * 14
+)
+
+// ScriptValidation describes whether the scripts of a block are validated when
+// it is connected to the main chain.
+type ScriptValidation byte
+
+// These constants are used to identify the script validation modes.
+const (
+	// ScriptValidationFull indicates all scripts of the block are
+	// validated.
+	ScriptValidationFull ScriptValidation = iota
+
+	// ScriptValidationCheckpoint indicates the scripts of the block are not
+	// validated since the block is before the latest checkpoint.
+	ScriptValidationCheckpoint
+
+	// ScriptValidationAssumeValid indicates the scripts of the block are
+	// not validated since the block is an ancestor of the assumed valid
+	// block.
+	ScriptValidationAssumeValid
+)
+
+// scriptValidationStrings is a map of ScriptValidation values back to their
+// descriptions for pretty printing.
+var scriptValidationStrings = map[ScriptValidation]string{
+	ScriptValidationFull:        "validated",
+	ScriptValidationCheckpoint:  "skipped below checkpoint",
+	ScriptValidationAssumeValid: "assumed valid",
+}
+
+// String returns the ScriptValidation as a human-readable description.
+func (v ScriptValidation) String() string {
+	if str := scriptValidationStrings[v]; str != "" {
+		return str
+	}
+	return fmt.Sprintf("Unknown ScriptValidation (%d)", int(v))
+}
+
+// isAssumedValid returns whether the scripts of the passed block are assumed to
+// be valid.  This is the case when the block is an ancestor of the assumed
+// valid block, the assumed valid block is part of the best header chain, the
+// best header chain has at least the minimum chain work, and the proof of work
+// performed on top of the block is equivalent to at least
+// assumeValidMinWorkTime at the current difficulty.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) isAssumedValid(node *blockNode) bool {
+	if b.assumeValid == nil {
+		return false
+	}
+
+	// Since both the assumed valid block and the block must be part of the
+	// best header chain, the block is its ancestor when it isn't higher.
+	avNode := b.index.LookupNode(b.assumeValid)
+	if avNode == nil || node.height > avNode.height ||
+		!b.bestHeader.Contains(avNode) || !b.bestHeader.Contains(node) {
+
+		return false
+	}
+
+	// A best header chain with less than the minimum chain work might be
+	// one of an attacker, which is not necessarily the case for the chain
+	// the assumed valid block has been chosen from.
+	tip := b.bestHeader.Tip()
+	if b.minimumChainWork != nil &&
+		tip.workSum.Cmp(b.minimumChainWork) < 0 {
+
+		return false
+	}
+
+	blocks := int64(assumeValidMinWorkTime / b.chainParams.TargetTimePerBlock)
+	minWork := new(big.Int).Mul(CalcWork(tip.bits), big.NewInt(blocks))
+	work := new(big.Int).Sub(tip.workSum, node.workSum)
+	return work.Cmp(minWork) >= 0
+}
+
+// scriptValidation returns how the scripts of the passed block are validated
+// when it is connected to the main chain.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) scriptValidation(node *blockNode) ScriptValidation {
+	// Don't run scripts if this node is before the latest known good
+	// checkpoint since the validity is verified via the checkpoints (all
+	// transactions are included in the merkle root hash and any changes
+	// will therefore be detected by the next checkpoint).
+	checkpoint := b.LatestCheckpoint()
+	if checkpoint != nil && node.height <= checkpoint.Height {
+		return ScriptValidationCheckpoint
+	}
+
+	// Don't run scripts for the ancestors of the assumed valid block once
+	// it is buried deeply enough in the best header chain.  All other
+	// checks are still performed.
+	if b.isAssumedValid(node) {
+		return ScriptValidationAssumeValid
+	}
+
+	return ScriptValidationFull
+}
+
+// ScriptValidation returns how the scripts of the block with the passed hash
+// are validated when it is connected to the main chain.  Blocks which are not
+// known are reported as fully validated.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ScriptValidation(hash *chainhash.Hash) ScriptValidation {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return ScriptValidationFull
+	}
+	return b.scriptValidation(node)
+}
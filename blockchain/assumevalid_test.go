@@ -0,0 +1,180 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+)
+
+// TestProcessBlockHeader ensures headers are added to the block index ahead of
+// their blocks, advance the best header, and are replaced by their blocks once
+// those are processed.
+func TestProcessBlockHeader(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	chain, teardownFunc, err := chainSetup("processblockheader", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	block1 := newTestBlock(params.GenesisBlock, 1, 0, params)
+	block2 := newTestBlock(block1.MsgBlock(), 2, 0, params)
+	orphan := newTestBlock(newTestBlock(block2.MsgBlock(), 3, 0,
+		params).MsgBlock(), 4, 0, params)
+
+	// Headers which don't connect to a known header are rejected.
+	err = chain.ProcessBlockHeader(&orphan.MsgBlock().Header, BFNone)
+	if rerr, ok := err.(RuleError); !ok ||
+		rerr.ErrorCode != ErrPreviousBlockUnknown {
+
+		t.Fatalf("ProcessBlockHeader: unexpected error for orphan "+
+			"header - got %v, want %v", err, ErrPreviousBlockUnknown)
+	}
+
+	for _, block := range []*btcutil.Block{block1, block2} {
+		err := chain.ProcessBlockHeader(&block.MsgBlock().Header, BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlockHeader: unexpected error: %v", err)
+		}
+	}
+	if hash, height := chain.BestHeader(); height != 2 ||
+		hash != *block2.Hash() {
+
+		t.Fatalf("BestHeader: unexpected best header - got %v (%d), "+
+			"want %v (2)", hash, height, block2.Hash())
+	}
+
+	// Known headers are ignored.
+	err = chain.ProcessBlockHeader(&block2.MsgBlock().Header, BFNone)
+	if err != nil {
+		t.Fatalf("ProcessBlockHeader: unexpected error for known "+
+			"header: %v", err)
+	}
+
+	// A header doesn't make its block known, so the block is still
+	// requested and processed.
+	if have, _ := chain.HaveBlock(block1.Hash()); have {
+		t.Fatal("HaveBlock: block reported for header only")
+	}
+	for i, block := range []*btcutil.Block{block1, block2} {
+		isMainChain, isOrphan, err := chain.ProcessBlock(block, BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock #%d: unexpected error: %v", i, err)
+		}
+		if !isMainChain || isOrphan {
+			t.Fatalf("ProcessBlock #%d: unexpected result - main "+
+				"chain %v, orphan %v", i, isMainChain, isOrphan)
+		}
+	}
+	if have, _ := chain.HaveBlock(block2.Hash()); !have {
+		t.Fatal("HaveBlock: processed block not reported")
+	}
+	if best := chain.BestSnapshot(); best.Hash != *block2.Hash() {
+		t.Fatalf("BestSnapshot: unexpected best block - got %v, want %v",
+			best.Hash, block2.Hash())
+	}
+}
+
+// TestAssumeValid ensures scripts are only assumed to be valid for ancestors of
+// the assumed valid block when it is part of the best header chain, the best
+// header chain has the minimum chain work, and enough work has been performed
+// on top of them.
+func TestAssumeValid(t *testing.T) {
+	// Construct a synthetic header chain with enough blocks for the work
+	// on top of its first blocks to exceed the required minimum along with
+	// a shorter side chain.
+	params := &chaincfg.RegressionNetParams
+	chain := newFakeChain(params)
+	minBlocks := int(assumeValidMinWorkTime / params.TargetTimePerBlock)
+	addNodes := func(parent *blockNode, numNodes int) []*blockNode {
+		nodes := make([]*blockNode, 0, numNodes)
+		timestamp := time.Unix(parent.timestamp, 0)
+		for i := 0; i < numNodes; i++ {
+			timestamp = timestamp.Add(params.TargetTimePerBlock)
+			node := newFakeNode(parent, 1, params.PowLimitBits,
+				timestamp)
+			chain.index.AddNode(node)
+			chain.maybeUpdateBestHeader(node)
+			nodes = append(nodes, node)
+			parent = node
+		}
+		return nodes
+	}
+	nodes := addNodes(chain.bestChain.Tip(), minBlocks+100)
+	sideNodes := addNodes(nodes[9], 10)
+
+	tipWork := chain.bestHeader.Tip().workSum
+	tests := []struct {
+		name        string
+		assumeValid *blockNode
+		node        *blockNode
+		minWork     *big.Int
+		want        ScriptValidation
+	}{{
+		name: "no assumed valid block",
+		node: nodes[0],
+		want: ScriptValidationFull,
+	}, {
+		name:        "ancestor of assumed valid block",
+		assumeValid: nodes[50],
+		node:        nodes[10],
+		want:        ScriptValidationAssumeValid,
+	}, {
+		name:        "assumed valid block",
+		assumeValid: nodes[50],
+		node:        nodes[50],
+		want:        ScriptValidationAssumeValid,
+	}, {
+		name:        "descendant of assumed valid block",
+		assumeValid: nodes[50],
+		node:        nodes[51],
+		want:        ScriptValidationFull,
+	}, {
+		name:        "best header chain with minimum chain work",
+		assumeValid: nodes[50],
+		node:        nodes[10],
+		minWork:     tipWork,
+		want:        ScriptValidationAssumeValid,
+	}, {
+		name:        "best header chain below minimum chain work",
+		assumeValid: nodes[50],
+		node:        nodes[10],
+		minWork:     new(big.Int).Add(tipWork, big.NewInt(1)),
+		want:        ScriptValidationFull,
+	}, {
+		name:        "not enough work on top",
+		assumeValid: nodes[len(nodes)-1],
+		node:        nodes[len(nodes)-minBlocks],
+		want:        ScriptValidationFull,
+	}, {
+		name:        "assumed valid block not in best header chain",
+		assumeValid: sideNodes[5],
+		node:        sideNodes[0],
+		want:        ScriptValidationFull,
+	}, {
+		name:        "block not in best header chain",
+		assumeValid: nodes[50],
+		node:        sideNodes[0],
+		want:        ScriptValidationFull,
+	}}
+
+	for _, test := range tests {
+		chain.assumeValid = nil
+		chain.minimumChainWork = test.minWork
+		if test.assumeValid != nil {
+			chain.assumeValid = &test.assumeValid.hash
+		}
+		got := chain.scriptValidation(test.node)
+		if got != test.want {
+			t.Errorf("%s: unexpected script validation - got %v, "+
+				"want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"github.com/vpubchain/btcd/wire"
+)
+
+// AuxFeePercentDivisor is the divisor of the percent of an aux fee tier, which
+// is expressed in thousandths of a percent.  For example, a percent of 250
+// denotes a rate of 0.25%.
+const AuxFeePercentDivisor = 100000
+
+// CalcAuxFee returns the aux fee, in the smallest unit of the asset, an asset
+// with the passed aux fee details requires for sending the passed amount of
+// the asset.
+//
+// The tiers of the fee schedule are marginal: each tier charges its percent
+// on the part of the amount between its bound and the bound of the next tier,
+// while the last tier charges its percent on the part of the amount above its
+// bound.  The part of the amount below the bound of the first tier is free, as
+// is any part covered by a tier whose bound is not below the bound of the next
+// tier.  The fee of each tier is rounded down.
+func CalcAuxFee(details *wire.AuxFeeDetailsType, amount int64) int64 {
+	var fee int64
+	for i, tier := range details.AuxFees {
+		// Determine the part of the amount the tier applies to.
+		upper := amount
+		if i < len(details.AuxFees)-1 {
+			next := details.AuxFees[i+1].Bound
+			if next < upper {
+				upper = next
+			}
+		}
+		if upper <= tier.Bound {
+			continue
+		}
+		part := upper - tier.Bound
+
+		// Split the part to avoid overflowing when multiplying it by
+		// the percent.
+		percent := int64(tier.Percent)
+		fee += part/AuxFeePercentDivisor*percent +
+			part%AuxFeePercentDivisor*percent/AuxFeePercentDivisor
+	}
+	return fee
+}
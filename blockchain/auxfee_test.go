@@ -0,0 +1,81 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/vpubchain/btcd/wire"
+)
+
+// TestCalcAuxFee ensures aux fees are calculated tier by tier as expected.
+func TestCalcAuxFee(t *testing.T) {
+	t.Parallel()
+
+	// The tiers charge 1% up to 1000, 0.5% from 1000 up to 10000 and
+	// 0.1% above 10000.
+	tiered := &wire.AuxFeeDetailsType{
+		AuxFees: []wire.AuxFeesType{
+			{Bound: 0, Percent: 1000},
+			{Bound: 1000, Percent: 500},
+			{Bound: 10000, Percent: 100},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		details *wire.AuxFeeDetailsType
+		amount  int64
+		want    int64
+	}{
+		{"no tiers", &wire.AuxFeeDetailsType{}, 1000000, 0},
+		{"zero amount", tiered, 0, 0},
+		{"rounded down", tiered, 99, 0},
+		{"first tier", tiered, 500, 5},
+		{"first bound", tiered, 1000, 10},
+		{"second tier", tiered, 5000, 10 + 20},
+		{"last tier", tiered, 110000, 10 + 45 + 100},
+		{
+			name: "below first bound",
+			details: &wire.AuxFeeDetailsType{
+				AuxFees: []wire.AuxFeesType{
+					{Bound: 1000, Percent: 1000},
+				},
+			},
+			amount: 3000,
+			want:   20,
+		},
+		{
+			name: "unordered tier",
+			details: &wire.AuxFeeDetailsType{
+				AuxFees: []wire.AuxFeesType{
+					{Bound: 0, Percent: 1000},
+					{Bound: 2000, Percent: 500},
+					{Bound: 1000, Percent: 100},
+				},
+			},
+			amount: 3000,
+			want:   20 + 2,
+		},
+		{
+			name: "max value at max percent",
+			details: &wire.AuxFeeDetailsType{
+				AuxFees: []wire.AuxFeesType{
+					{Bound: 0, Percent: 65535},
+				},
+			},
+			amount: MaxAssetValue,
+			want:   655349999999999999,
+		},
+	}
+
+	for _, test := range tests {
+		got := CalcAuxFee(test.details, test.amount)
+		if got != test.want {
+			t.Errorf("CalcAuxFee(%s): unexpected fee - got %d, want %d",
+				test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// MaxAuxPowChainBranchLen is the maximum number of hashes the chain
+	// merkle branch of an auxpow may contain.
+	MaxAuxPowChainBranchLen = 30
+
+	// auxPowMaxRootOffset is the maximum offset of the chain merkle root
+	// within the signature script of the parent coinbase when the root is
+	// not preceded by the merged mining header.
+	auxPowMaxRootOffset = 20
+
+	// auxPowLegacyBlockVersion is the block version which is exempt from
+	// carrying the chain ID on chains which support merged mining.
+	auxPowLegacyBlockVersion = 1
+)
+
+// auxPowBucketName is the name of the db bucket used to house the auxpows of
+// merged mined blocks keyed by their block hash.
+var auxPowBucketName = []byte("auxpow")
+
+// MergedMiningHeader is the magic which precedes the chain merkle root in the
+// signature script of the coinbase of a parent block.
+var MergedMiningHeader = []byte{0xfa, 0xbe, 'm', 'm'}
+
+// CheckAuxPowMerkleBranch returns the merkle root which results from hashing
+// the passed hash up the passed merkle branch, with the passed index of the
+// hash within the tree determining the side of each branch hash.
+func CheckAuxPowMerkleBranch(hash chainhash.Hash, branch []chainhash.Hash, index int32) chainhash.Hash {
+	if index == -1 {
+		return chainhash.Hash{}
+	}
+	for i := range branch {
+		if index&1 != 0 {
+			hash = *HashMerkleBranches(&branch[i], &hash)
+		} else {
+			hash = *HashMerkleBranches(&hash, &branch[i])
+		}
+		index >>= 1
+	}
+	return hash
+}
+
+// AuxPowExpectedIndex returns the slot of the chain merkle tree with the
+// passed height a chain with the passed chain ID must occupy for the passed
+// nonce.  This prevents a parent block from committing to several blocks of
+// the same chain.
+func AuxPowExpectedIndex(nonce uint32, chainID int32, height uint) uint32 {
+	rand := nonce
+	rand = rand*1103515245 + 12345
+	rand += uint32(chainID)
+	rand = rand*1103515245 + 12345
+	return rand % (1 << height)
+}
+
+// reverseHash returns the bytes of the passed hash in reverse order, which is
+// the order the chain merkle root appears in within the parent coinbase.
+func reverseHash(hash *chainhash.Hash) []byte {
+	reversed := make([]byte, chainhash.HashSize)
+	for i := range hash {
+		reversed[chainhash.HashSize-1-i] = hash[i]
+	}
+	return reversed
+}
+
+// CheckAuxPow ensures the auxpow of the passed merged mined block header
+// commits to the header in the coinbase of its parent block and that the
+// parent block commits to that coinbase.  It does not check the proof of work
+// of the parent block.
+func CheckAuxPow(header *wire.BlockHeader) error {
+	auxPow := header.AuxPow
+	if auxPow == nil {
+		str := fmt.Sprintf("merged mined block %v has no auxpow",
+			header.BlockHash())
+		return ruleError(ErrBadAuxPow, str)
+	}
+
+	// The parent coinbase must be the first transaction of the parent
+	// block, which must belong to a different chain.
+	chainID := header.ChainID()
+	if auxPow.CoinbaseIndex != 0 {
+		str := fmt.Sprintf("auxpow coinbase index of %d is not the "+
+			"index of a coinbase", auxPow.CoinbaseIndex)
+		return ruleError(ErrBadAuxPow, str)
+	}
+	if auxPow.ParentBlock.ChainID() == chainID {
+		str := fmt.Sprintf("auxpow parent block has the chain ID %d "+
+			"of the block", chainID)
+		return ruleError(ErrBadAuxPow, str)
+	}
+	if len(auxPow.ChainBranch) > MaxAuxPowChainBranchLen {
+		str := fmt.Sprintf("auxpow chain merkle branch of %d hashes "+
+			"is longer than the max of %d", len(auxPow.ChainBranch),
+			MaxAuxPowChainBranchLen)
+		return ruleError(ErrBadAuxPow, str)
+	}
+	if len(auxPow.CoinbaseTx.TxIn) == 0 {
+		str := "auxpow parent coinbase has no inputs"
+		return ruleError(ErrBadAuxPow, str)
+	}
+
+	// The parent block must commit to the parent coinbase.
+	coinbaseHash := auxPow.CoinbaseTx.TxHash()
+	merkleRoot := CheckAuxPowMerkleBranch(coinbaseHash,
+		auxPow.CoinbaseBranch, auxPow.CoinbaseIndex)
+	if merkleRoot != auxPow.ParentBlock.MerkleRoot {
+		str := fmt.Sprintf("auxpow parent block merkle root %v does "+
+			"not commit to the parent coinbase %v",
+			auxPow.ParentBlock.MerkleRoot, coinbaseHash)
+		return ruleError(ErrBadAuxPow, str)
+	}
+
+	// Locate the chain merkle root within the signature script of the
+	// parent coinbase.  It must either directly follow the single merged
+	// mining header or, for compatibility with old miners, start within
+	// the first bytes of the script.
+	blockHash := header.BlockHash()
+	chainRoot := CheckAuxPowMerkleBranch(blockHash, auxPow.ChainBranch,
+		auxPow.ChainIndex)
+	rootBytes := reverseHash(&chainRoot)
+	script := auxPow.CoinbaseTx.TxIn[0].SignatureScript
+	rootPos := bytes.Index(script, rootBytes)
+	if rootPos == -1 {
+		str := fmt.Sprintf("auxpow parent coinbase does not commit to "+
+			"the chain merkle root %v", chainRoot)
+		return ruleError(ErrBadAuxPow, str)
+	}
+	headerPos := bytes.Index(script, MergedMiningHeader)
+	if headerPos != -1 {
+		if bytes.Contains(script[headerPos+1:], MergedMiningHeader) {
+			str := "auxpow parent coinbase contains multiple " +
+				"merged mining headers"
+			return ruleError(ErrBadAuxPow, str)
+		}
+		if headerPos+len(MergedMiningHeader) != rootPos {
+			str := "auxpow merged mining header does not directly " +
+				"precede the chain merkle root"
+			return ruleError(ErrBadAuxPow, str)
+		}
+	} else if rootPos > auxPowMaxRootOffset {
+		str := fmt.Sprintf("auxpow chain merkle root at offset %d of "+
+			"the parent coinbase is beyond the max of %d", rootPos,
+			auxPowMaxRootOffset)
+		return ruleError(ErrBadAuxPow, str)
+	}
+
+	// The chain merkle root must be followed by the size of the chain
+	// merkle tree and the nonce which determines the slot of the chain.
+	tail := script[rootPos+len(rootBytes):]
+	if len(tail) < 8 {
+		str := "auxpow parent coinbase is missing the chain merkle " +
+			"tree size and nonce"
+		return ruleError(ErrBadAuxPow, str)
+	}
+	height := uint(len(auxPow.ChainBranch))
+	size := binary.LittleEndian.Uint32(tail[:4])
+	if size != 1<<height {
+		str := fmt.Sprintf("auxpow chain merkle tree size of %d does "+
+			"not match the branch of %d hashes", size, height)
+		return ruleError(ErrBadAuxPow, str)
+	}
+	nonce := binary.LittleEndian.Uint32(tail[4:8])
+	expectedIndex := AuxPowExpectedIndex(nonce, chainID, height)
+	if uint32(auxPow.ChainIndex) != expectedIndex {
+		str := fmt.Sprintf("auxpow chain index of %d is not the "+
+			"expected index of %d", auxPow.ChainIndex, expectedIndex)
+		return ruleError(ErrBadAuxPow, str)
+	}
+
+	return nil
+}
+
+// isAuxPow returns whether the passed block header is merged mined on the chain
+// described by the passed parameters.  The merged mining version bit has no
+// special meaning on chains which don't support merged mining.
+func isAuxPow(header *wire.BlockHeader, params *chaincfg.Params) bool {
+	return params.AuxPowChainID != 0 && header.IsAuxPow()
+}
+
+// checkAuxPowChainID ensures the version of the passed block header carries
+// the chain ID of the chain described by the passed parameters once merged
+// mining is supported.
+func checkAuxPowChainID(header *wire.BlockHeader, params *chaincfg.Params) error {
+	if params.AuxPowChainID == 0 {
+		return nil
+	}
+
+	if header.Version == auxPowLegacyBlockVersion {
+		return nil
+	}
+	if header.ChainID() != params.AuxPowChainID {
+		str := fmt.Sprintf("block chain ID of %d is not the expected "+
+			"chain ID of %d", header.ChainID(), params.AuxPowChainID)
+		return ruleError(ErrBadAuxPowChainID, str)
+	}
+	return nil
+}
+
+// auxPowBaseVersion returns the passed block version without the merged
+// mining bit on chains which support merged mining, so the bit isn't mistaken
+// for signalling of an unknown rule change.
+func (b *BlockChain) auxPowBaseVersion(version int32) int32 {
+	if b.chainParams.AuxPowChainID == 0 {
+		return version
+	}
+	return version &^ wire.AuxPowVersionBit
+}
+
+// dbPutAuxPow stores the auxpow of the passed block header, if any, to the
+// auxpow bucket.  The auxpows are not part of the stored blocks, and are kept
+// apart from them so the headers of merged mined blocks can be served without
+// loading their blocks, which are not available when they were pruned or
+// preceded the UTXO snapshot the chain was bootstrapped from.
+func dbPutAuxPow(dbTx database.Tx, header *wire.BlockHeader) error {
+	if header.AuxPow == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(header.AuxPow.SerializeSize())
+	if err := header.AuxPow.Serialize(&buf); err != nil {
+		return err
+	}
+	blockHash := header.BlockHash()
+	bucket := dbTx.Metadata().Bucket(auxPowBucketName)
+	return bucket.Put(blockHash[:], buf.Bytes())
+}
+
+// dbFetchAuxPow loads the auxpow of the merged mined block with the passed hash
+// from the auxpow bucket.  An error is returned when it is not stored.
+func dbFetchAuxPow(dbTx database.Tx, hash *chainhash.Hash) (*wire.AuxPow, error) {
+	serialized := dbTx.Metadata().Bucket(auxPowBucketName).Get(hash[:])
+	if serialized == nil {
+		return nil, fmt.Errorf("the auxpow of merged mined block %v is "+
+			"not stored", hash)
+	}
+
+	var auxPow wire.AuxPow
+	if err := auxPow.Deserialize(bytes.NewReader(serialized)); err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt auxpow of block %v: %v",
+				hash, err),
+		}
+	}
+	return &auxPow, nil
+}
+
+// FetchAuxPow loads the auxpow of the passed block header from the database
+// when the header is merged mined.  Blocks are stored without their auxpow, so
+// it must be loaded in order to relay merged mined blocks read from the
+// database.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchAuxPow(header *wire.BlockHeader) error {
+	if !isAuxPow(header, b.chainParams) {
+		return nil
+	}
+
+	blockHash := header.BlockHash()
+	return b.db.View(func(dbTx database.Tx) error {
+		auxPow, err := dbFetchAuxPow(dbTx, &blockHash)
+		if err != nil {
+			return err
+		}
+		header.AuxPow = auxPow
+		return nil
+	})
+}
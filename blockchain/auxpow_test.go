@@ -0,0 +1,450 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// testAuxPowNonce is the nonce the test auxpows commit to in the parent
+// coinbase.
+const testAuxPowNonce = 7
+
+// testAuxPowCommitment returns the merged mining commitment to the passed
+// chain merkle root of a chain merkle tree with two leaves which test auxpows
+// place in the parent coinbase.
+func testAuxPowCommitment(root []byte) []byte {
+	var tail [8]byte
+	binary.LittleEndian.PutUint32(tail[:4], 2)
+	binary.LittleEndian.PutUint32(tail[4:], testAuxPowNonce)
+	commitment := append([]byte{}, MergedMiningHeader...)
+	commitment = append(commitment, root...)
+	return append(commitment, tail[:]...)
+}
+
+// testAuxPow returns an auxpow for the passed header whose parent coinbase has
+// the signature script the passed function returns for the reversed chain
+// merkle root.  The parent block is mined to meet the target of the header.
+func testAuxPow(header *wire.BlockHeader, script func(root []byte) []byte) *wire.AuxPow {
+	chainBranch := []chainhash.Hash{{0x11}}
+	chainIndex := AuxPowExpectedIndex(testAuxPowNonce, header.ChainID(), 1)
+	root := CheckAuxPowMerkleBranch(header.BlockHash(), chainBranch,
+		int32(chainIndex))
+
+	coinbase := wire.NewMsgTx(1)
+	prevOut := wire.NewOutPoint(&chainhash.Hash{}, math.MaxUint32)
+	coinbase.AddTxIn(wire.NewTxIn(prevOut, script(reverseHash(&root)), nil))
+	coinbase.AddTxOut(wire.NewTxOut(0, nil))
+	coinbaseBranch := []chainhash.Hash{{0x22}}
+
+	auxPow := &wire.AuxPow{
+		CoinbaseTx:     *coinbase,
+		CoinbaseBranch: coinbaseBranch,
+		ChainBranch:    chainBranch,
+		ChainIndex:     int32(chainIndex),
+		ParentBlock: wire.BlockHeader{
+			Version: 0x20000000,
+			MerkleRoot: CheckAuxPowMerkleBranch(coinbase.TxHash(),
+				coinbaseBranch, 0),
+			Bits: header.Bits,
+		},
+	}
+	target := CompactToBig(header.Bits)
+	for {
+		hash := auxPow.ParentBlock.BlockHash()
+		if HashToBig(&hash).Cmp(target) <= 0 {
+			return auxPow
+		}
+		auxPow.ParentBlock.Nonce++
+	}
+}
+
+// testAuxPowHeader returns a merged mined header of the passed chain.
+func testAuxPowHeader(params *chaincfg.Params) *wire.BlockHeader {
+	return &wire.BlockHeader{
+		Version: wire.AuxPowVersionBit | 0x20000000 |
+			params.AuxPowChainID<<wire.AuxPowChainIDShift,
+		PrevBlock: *params.GenesisHash,
+		Bits:      params.PowLimitBits,
+	}
+}
+
+// newTestAuxPowBlock returns a merged mined block at the passed height building
+// on the passed block.  See newTestBlock for its contents.
+func newTestAuxPowBlock(parent *wire.MsgBlock, height int32, params *chaincfg.Params) *btcutil.Block {
+	block := newTestBlock(parent, height, 0, params).MsgBlock()
+	block.Header.Version = testAuxPowHeader(params).Version
+	block.Header.AuxPow = testAuxPow(&block.Header, testAuxPowCommitment)
+	return btcutil.NewBlock(block)
+}
+
+// TestCheckAuxPow ensures auxpows are only accepted when they commit to the
+// block in the parent coinbase and the parent block commits to that coinbase.
+func TestCheckAuxPow(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.SysRegressionNetParams
+	tests := []struct {
+		name    string
+		script  func(root []byte) []byte
+		mutate  func(a *wire.AuxPow)
+		wantErr bool
+	}{{
+		name:   "valid",
+		script: testAuxPowCommitment,
+	}, {
+		name: "valid with prefix",
+		script: func(root []byte) []byte {
+			prefix := bytes.Repeat([]byte{0x01}, 40)
+			return append(prefix, testAuxPowCommitment(root)...)
+		},
+	}, {
+		name: "valid without header",
+		script: func(root []byte) []byte {
+			return append([]byte{0x01, 0x02},
+				testAuxPowCommitment(root)[len(MergedMiningHeader):]...)
+		},
+	}, {
+		name: "root too late without header",
+		script: func(root []byte) []byte {
+			prefix := bytes.Repeat([]byte{0x01}, auxPowMaxRootOffset+1)
+			return append(prefix,
+				testAuxPowCommitment(root)[len(MergedMiningHeader):]...)
+		},
+		wantErr: true,
+	}, {
+		name: "multiple headers",
+		script: func(root []byte) []byte {
+			return append(testAuxPowCommitment(root),
+				MergedMiningHeader...)
+		},
+		wantErr: true,
+	}, {
+		name: "header not before root",
+		script: func(root []byte) []byte {
+			script := append([]byte{}, MergedMiningHeader...)
+			script = append(script, 0x01)
+			return append(script,
+				testAuxPowCommitment(root)[len(MergedMiningHeader):]...)
+		},
+		wantErr: true,
+	}, {
+		name: "missing root",
+		script: func(root []byte) []byte {
+			return testAuxPowCommitment(make([]byte, len(root)))
+		},
+		wantErr: true,
+	}, {
+		name: "missing nonce",
+		script: func(root []byte) []byte {
+			commitment := testAuxPowCommitment(root)
+			return commitment[:len(commitment)-1]
+		},
+		wantErr: true,
+	}, {
+		name: "wrong tree size",
+		script: func(root []byte) []byte {
+			commitment := testAuxPowCommitment(root)
+			commitment[len(commitment)-8] = 4
+			return commitment
+		},
+		wantErr: true,
+	}, {
+		name:   "wrong chain index",
+		script: testAuxPowCommitment,
+		mutate: func(a *wire.AuxPow) {
+			a.ChainIndex ^= 1
+		},
+		wantErr: true,
+	}, {
+		name:   "coinbase not first",
+		script: testAuxPowCommitment,
+		mutate: func(a *wire.AuxPow) {
+			a.CoinbaseIndex = 1
+		},
+		wantErr: true,
+	}, {
+		name:   "parent with our chain ID",
+		script: testAuxPowCommitment,
+		mutate: func(a *wire.AuxPow) {
+			a.ParentBlock.Version |= params.AuxPowChainID <<
+				wire.AuxPowChainIDShift
+		},
+		wantErr: true,
+	}, {
+		name:   "parent not committing to coinbase",
+		script: testAuxPowCommitment,
+		mutate: func(a *wire.AuxPow) {
+			a.ParentBlock.MerkleRoot = chainhash.Hash{0x33}
+		},
+		wantErr: true,
+	}, {
+		name:   "chain branch too long",
+		script: testAuxPowCommitment,
+		mutate: func(a *wire.AuxPow) {
+			a.ChainBranch = make([]chainhash.Hash,
+				MaxAuxPowChainBranchLen+1)
+		},
+		wantErr: true,
+	}, {
+		name:   "parent coinbase without inputs",
+		script: testAuxPowCommitment,
+		mutate: func(a *wire.AuxPow) {
+			a.CoinbaseTx.TxIn = nil
+		},
+		wantErr: true,
+	}, {
+		name:    "missing auxpow",
+		script:  testAuxPowCommitment,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		header := testAuxPowHeader(params)
+		header.AuxPow = testAuxPow(header, test.script)
+		if test.mutate != nil {
+			test.mutate(header.AuxPow)
+		}
+		if test.name == "missing auxpow" {
+			header.AuxPow = nil
+		}
+		err := CheckAuxPow(header)
+		if !test.wantErr {
+			if err != nil {
+				t.Errorf("CheckAuxPow(%s): unexpected error: %v",
+					test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != ErrBadAuxPow {
+			t.Errorf("CheckAuxPow(%s): did not get expected error "+
+				"%v - got %v", test.name, ErrBadAuxPow, err)
+		}
+	}
+}
+
+// TestAuxPowProofOfWork ensures the proof of work of merged mined blocks is
+// checked against the hash of the parent block.
+func TestAuxPowProofOfWork(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.SysRegressionNetParams
+	header := testAuxPowHeader(params)
+	header.AuxPow = testAuxPow(header, testAuxPowCommitment)
+	err := checkProofOfWork(header, params, BFNone)
+	if err != nil {
+		t.Fatalf("checkProofOfWork: unexpected error: %v", err)
+	}
+
+	// Mine the parent block until its hash exceeds the target.
+	target := CompactToBig(header.Bits)
+	for {
+		hash := header.AuxPow.ParentBlock.BlockHash()
+		if HashToBig(&hash).Cmp(target) > 0 {
+			break
+		}
+		header.AuxPow.ParentBlock.Nonce++
+	}
+	err = checkProofOfWork(header, params, BFNone)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrHighHash {
+		t.Errorf("checkProofOfWork: did not get expected error %v - "+
+			"got %v", ErrHighHash, err)
+	}
+
+	// Merged mined blocks without an auxpow are rejected.
+	header.AuxPow = nil
+	err = checkProofOfWork(header, params, BFNone)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrBadAuxPow {
+		t.Errorf("checkProofOfWork: did not get expected error %v - "+
+			"got %v", ErrBadAuxPow, err)
+	}
+
+	// The merged mining version bit has no meaning on chains which don't
+	// support merged mining, so the block provides its own proof of work.
+	for {
+		hash := header.BlockHash()
+		if HashToBig(&hash).Cmp(target) <= 0 {
+			break
+		}
+		header.Nonce++
+	}
+	err = checkProofOfWork(header, &chaincfg.RegressionNetParams, BFNone)
+	if err != nil {
+		t.Errorf("checkProofOfWork: unexpected error on chain without "+
+			"merged mining: %v", err)
+	}
+}
+
+// TestCheckAuxPowChainID ensures blocks must carry the chain ID of chains which
+// support merged mining while the version of blocks of other chains is not
+// interpreted.
+func TestCheckAuxPowChainID(t *testing.T) {
+	t.Parallel()
+
+	sysParams := &chaincfg.SysRegressionNetParams
+	chainID := sysParams.AuxPowChainID << wire.AuxPowChainIDShift
+	tests := []struct {
+		name    string
+		params  *chaincfg.Params
+		version int32
+		wantErr bool
+	}{
+		{"legacy version", sysParams, 1, false},
+		{"chain ID", sysParams, 0x20000000 | chainID, false},
+		{"merged mined", sysParams, 0x20000000 | chainID |
+			wire.AuxPowVersionBit, false},
+		{"missing chain ID", sysParams, 0x20000000, true},
+		{"other chain ID", sysParams, 0x20000000 | 0x2000<<
+			wire.AuxPowChainIDShift, true},
+		{"unsupported regular", &chaincfg.RegressionNetParams,
+			0x20000000, false},
+		{"unsupported merged mining bit", &chaincfg.RegressionNetParams,
+			0x20000000 | wire.AuxPowVersionBit, false},
+	}
+
+	for _, test := range tests {
+		header := &wire.BlockHeader{Version: test.version}
+		err := checkAuxPowChainID(header, test.params)
+		if !test.wantErr {
+			if err != nil {
+				t.Errorf("checkAuxPowChainID(%s): unexpected "+
+					"error: %v", test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != ErrBadAuxPowChainID {
+			t.Errorf("checkAuxPowChainID(%s): did not get expected "+
+				"error %v - got %v", test.name,
+				ErrBadAuxPowChainID, err)
+		}
+	}
+}
+
+// TestLocateAuxPowHeaders ensures merged mined headers are located along with
+// their auxpow without their blocks, including when the chain was bootstrapped
+// from a UTXO snapshot, and that an error is returned when an auxpow is
+// missing.
+func TestLocateAuxPowHeaders(t *testing.T) {
+	params := chaincfg.RegressionNetParams
+	params.AuxPowChainID = 0x1000
+	chain, teardownFunc, err := chainSetup("locateauxpowheaders", &params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+
+	var blocks []*btcutil.Block
+	parent := params.GenesisBlock
+	for height := int32(1); height <= 3; height++ {
+		block := newTestAuxPowBlock(parent, height, &params)
+		if _, _, err := chain.ProcessBlock(block, BFNone); err != nil {
+			teardownFunc()
+			t.Fatalf("ProcessBlock: unexpected error: %v", err)
+		}
+		blocks = append(blocks, block)
+		parent = block.MsgBlock()
+	}
+
+	// checkHeaders ensures the passed chain locates the headers of all
+	// blocks along with their auxpow.
+	serializeAuxPow := func(auxPow *wire.AuxPow) []byte {
+		var buf bytes.Buffer
+		if err := auxPow.Serialize(&buf); err != nil {
+			t.Fatalf("Serialize: unexpected error: %v", err)
+		}
+		return buf.Bytes()
+	}
+	checkHeaders := func(chain *BlockChain) {
+		t.Helper()
+		headers, err := chain.LocateHeaders(
+			BlockLocator{params.GenesisHash}, &chainhash.Hash{})
+		if err != nil {
+			t.Fatalf("LocateHeaders: unexpected error: %v", err)
+		}
+		if len(headers) != len(blocks) {
+			t.Fatalf("LocateHeaders: got %d headers, want %d",
+				len(headers), len(blocks))
+		}
+		for i, block := range blocks {
+			want := &block.MsgBlock().Header
+			if headers[i].BlockHash() != want.BlockHash() ||
+				headers[i].AuxPow == nil ||
+				!bytes.Equal(serializeAuxPow(headers[i].AuxPow),
+					serializeAuxPow(want.AuxPow)) {
+
+				t.Fatalf("LocateHeaders: unexpected header %d",
+					i)
+			}
+		}
+	}
+	checkHeaders(chain)
+
+	var buf bytes.Buffer
+	info, err := chain.DumpUTXOSnapshot(&buf)
+	teardownFunc()
+	if err != nil {
+		t.Fatalf("DumpUTXOSnapshot: unexpected error: %v", err)
+	}
+	snapshot := buf.Bytes()
+
+	// The headers of a chain bootstrapped from the snapshot are located
+	// along with their auxpow although their blocks are not available.
+	newChain, newTeardownFunc, err := chainSetup("locateauxpowheadersload",
+		&params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer newTeardownFunc()
+	newChain.chainParams.UTXOSnapshots = []chaincfg.UTXOSnapshot{{
+		Height:    info.Height,
+		BlockHash: &info.BlockHash,
+		UTXOHash:  &info.UTXOHash,
+	}}
+	loadSnapshot := func(snapshot []byte) error {
+		newChain.chainLock.Lock()
+		defer newChain.chainLock.Unlock()
+		return newChain.loadUTXOSnapshot(bytes.NewReader(snapshot))
+	}
+
+	// Auxpows aren't committed to by the pinned hash, so snapshots with an
+	// auxpow which doesn't commit to its block are rejected.
+	forged := append([]byte(nil), snapshot...)
+	rootOffset := bytes.Index(forged, MergedMiningHeader) +
+		len(MergedMiningHeader)
+	forged[rootOffset] ^= 0xff
+	if err := loadSnapshot(forged); err == nil {
+		t.Fatal("loadUTXOSnapshot: loaded snapshot with forged auxpow")
+	}
+
+	if err := loadSnapshot(snapshot); err != nil {
+		t.Fatalf("loadUTXOSnapshot: unexpected error: %v", err)
+	}
+	checkHeaders(newChain)
+
+	// Headers are not returned when an auxpow is missing.
+	err = newChain.db.Update(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(auxPowBucketName)
+		return bucket.Delete(blocks[1].Hash()[:])
+	})
+	if err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	headers, err := newChain.LocateHeaders(BlockLocator{params.GenesisHash},
+		&chainhash.Hash{})
+	if err == nil || headers != nil {
+		t.Fatalf("LocateHeaders: did not get expected error for missing " +
+			"auxpow")
+	}
+}
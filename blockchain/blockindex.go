@@ -94,6 +94,13 @@ type blockNode struct {
 	timestamp  int64
 	merkleRoot chainhash.Hash
 
+	// sequenceID is the order in which the block data was received, which
+	// breaks ties between chain tips with the same cumulative proof of
+	// work in favor of the earlier one.  It is zero for blocks loaded from
+	// the database and negative for blocks marked precious.  It may only
+	// be accessed with the chain state lock held.
+	sequenceID int32
+
 	// status is a bitfield representing the validation state of the block. The
 	// status field, unlike the other fields, may be written to and so should
 	// only be accessed using the concurrent-safe NodeStatus method on
@@ -232,6 +239,11 @@ type blockIndex struct {
 	sync.RWMutex
 	index map[chainhash.Hash]*blockNode
 	dirty map[*blockNode]struct{}
+
+	// tips houses the nodes no other node in the index builds on.  Every
+	// branch of the block tree, including the main chain, ends in one of
+	// them.
+	tips map[*blockNode]struct{}
 }
 
 // newBlockIndex returns a new empty instance of a block index.  The index will
@@ -243,6 +255,7 @@ func newBlockIndex(db database.DB, chainParams *chaincfg.Params) *blockIndex {
 		chainParams: chainParams,
 		index:       make(map[chainhash.Hash]*blockNode),
 		dirty:       make(map[*blockNode]struct{}),
+		tips:        make(map[*blockNode]struct{}),
 	}
 }
 
@@ -279,11 +292,30 @@ func (bi *blockIndex) AddNode(node *blockNode) {
 }
 
 // addNode adds the provided node to the block index, but does not mark it as
-// dirty. This can be used while initializing the block index.
+// dirty. This can be used while initializing the block index.  The node
+// replaces its parent as a chain tip.
 //
 // This function is NOT safe for concurrent access.
 func (bi *blockIndex) addNode(node *blockNode) {
 	bi.index[node.hash] = node
+	if node.parent != nil {
+		delete(bi.tips, node.parent)
+	}
+	bi.tips[node] = struct{}{}
+}
+
+// chainTips returns all nodes in the block index which no other node in the
+// index builds on.  Every branch of the block tree ends in one of them.
+//
+// This function is safe for concurrent access.
+func (bi *blockIndex) chainTips() []*blockNode {
+	bi.RLock()
+	tips := make([]*blockNode, 0, len(bi.tips))
+	for node := range bi.tips {
+		tips = append(tips, node)
+	}
+	bi.RUnlock()
+	return tips
 }
 
 // NodeStatus provides concurrent-safe access to the status field of a node.
@@ -8,6 +8,9 @@ package blockchain
 import (
 	"container/list"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"sync"
 	"time"
 
@@ -101,6 +104,10 @@ type BlockChain struct {
 	sigCache            *txscript.SigCache
 	indexManager        IndexManager
 	hashCache           *txscript.HashCache
+	nevmPruneDepth      int32
+	pruneTarget         uint64
+	assumeValid         *chainhash.Hash
+	minimumChainWork    *big.Int
 
 	// The following fields are calculated based upon the provided chain
 	// parameters.  They are also set when the instance is created and
@@ -123,8 +130,23 @@ type BlockChain struct {
 	//
 	// bestChain tracks the current active chain by making use of an
 	// efficient chain view into the block index.
-	index     *blockIndex
-	bestChain *chainView
+	//
+	// bestHeader tracks the chain of headers with the most cumulative
+	// proof of work which are not known to be invalid.  It leads the
+	// best chain while the blocks of known headers are downloaded.
+	index      *blockIndex
+	bestChain  *chainView
+	bestHeader *chainView
+
+	// snapshot houses the state of the UTXO snapshot the chain was
+	// bootstrapped from.  It is nil when the chain was not bootstrapped
+	// from a snapshot.
+	snapshot *utxoSnapshotState
+
+	// utxoCache caches the unspent transaction outputs as of the end of the
+	// main chain and writes them to the database in batches.  It has its
+	// own lock.
+	utxoCache *utxoCache
 
 	// These fields are related to handling of orphan blocks.  They are
 	// protected by a combination of the chain lock and the orphan lock.
@@ -138,6 +160,28 @@ type BlockChain struct {
 	nextCheckpoint *chaincfg.Checkpoint
 	checkpointNode *blockNode
 
+	// pruneHeight is the height of the first block which has not been
+	// pruned.  It is protected by the chain lock.
+	pruneHeight int32
+
+	// These fields are related to the order in which block data was
+	// received, which breaks ties between chain tips with the same
+	// cumulative proof of work.  They are protected by the chain lock.
+	//
+	// nextSequenceID is the sequence ID of the next block whose data is
+	// received.
+	//
+	// nextPreciousID is the sequence ID of the next block marked precious.
+	// It counts down from -1, so blocks marked precious are preferred over
+	// all others, and the later ones over the earlier ones.
+	//
+	// lastPreciousWork is the cumulative proof of work of the best chain
+	// when a block was last marked precious.  The precious sequence IDs
+	// start over once the best chain has more work.
+	nextSequenceID   int32
+	nextPreciousID   int32
+	lastPreciousWork *big.Int
+
 	// The state is used as a fairly efficient way to cache information
 	// about the current best chain state that is returned to callers when
 	// requested.  It operates on the principle of MVCC such that any time a
@@ -608,6 +652,7 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 		curTotalTxns+numTxns, node.CalcPastMedianTime())
 
 	// Atomically insert info into the database.
+	var pruneHeight int32
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -622,17 +667,42 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails removing all of the utxos spent and adding the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
+		// Update the transaction spend journal by adding a record for
+		// the block that contains all txos spent by it.
+		err = dbPutSpendJournalEntry(dbTx, block.Hash(), stxos)
 		if err != nil {
 			return err
 		}
 
-		// Update the transaction spend journal by adding a record for
-		// the block that contains all txos spent by it.
-		err = dbPutSpendJournalEntry(dbTx, block.Hash(), stxos)
+		// Update the asset state with the assets changed by the block
+		// and record the state they had before it to allow it to be
+		// disconnected again.
+		if err := dbPutViewAssets(dbTx, view); err != nil {
+			return err
+		}
+		err = dbPutAssetUndo(dbTx, block.Hash(), view)
+		if err != nil {
+			return err
+		}
+
+		// Update the statistics of the utxo set with the outputs
+		// created and spent by the block.
+		err = dbUpdateUtxoSetState(dbTx, node, block, stxos,
+			state.TotalTxns, false)
+		if err != nil {
+			return err
+		}
+
+		// Prune the data of the NEVM block connected to the block
+		// which is now buried at the prune depth.
+		err = b.pruneNEVMBlockData(dbTx, node)
+		if err != nil {
+			return err
+		}
+
+		// Prune the oldest blocks when the stored blocks exceed the
+		// prune target.
+		pruneHeight, err = b.pruneBlocks(dbTx, node)
 		if err != nil {
 			return err
 		}
@@ -653,12 +723,19 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 		return err
 	}
 
+	// Update the utxo cache using the state of the utxo view.  This
+	// entails removing all of the utxos spent and adding the new ones
+	// created by the block.  The utxo set in the database is updated once
+	// the cache is flushed.
+	b.utxoCache.commit(view)
+
 	// Prune fully spent entries and mark all entries in the view unmodified
-	// now that the modifications have been committed to the database.
+	// now that the modifications have been committed to the utxo cache.
 	view.commit()
 
 	// This node is now the end of the best chain.
 	b.bestChain.SetTip(node)
+	b.pruneHeight = pruneHeight
 
 	// Update the state for the best block.  Notice how this replaces the
 	// entire struct instead of updating the existing one.  This effectively
@@ -669,6 +746,12 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 	b.stateSnapshot = state
 	b.stateLock.Unlock()
 
+	// Write the utxo cache to the database when it has grown too large or
+	// has not been written for a while.
+	if err := b.utxoCache.maybeFlush(node); err != nil {
+		return err
+	}
+
 	// Notify the caller that the block was connected to the main chain.
 	// The caller would typically want to react with actions such as
 	// updating wallets.
@@ -720,6 +803,17 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 	state := newBestState(prevNode, blockSize, blockWeight, numTxns,
 		newTotalTxns, prevNode.CalcPastMedianTime())
 
+	// Update the utxo set using the state of the utxo view.  This entails
+	// restoring all of the utxos spent and removing the new ones created
+	// by the block.  The utxo set in the database is updated right away
+	// before the best state so it never reflects a block which is no
+	// longer stored in case of an unclean shutdown.  The block is simply
+	// connected to it again after restarting instead.
+	b.utxoCache.commit(view)
+	if err := b.utxoCache.flush(prevNode, false); err != nil {
+		return err
+	}
+
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -734,14 +828,6 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails restoring all of the utxos spent and removing the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
-		if err != nil {
-			return err
-		}
-
 		// Before we delete the spend journal entry for this back,
 		// we'll fetch it as is so the indexers can utilize if needed.
 		stxos, err := dbFetchSpendJournalEntry(dbTx, block)
@@ -756,6 +842,24 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 			return err
 		}
 
+		// Restore the state the assets changed by the block had before
+		// it and remove the record of it.
+		if err := dbPutViewAssets(dbTx, view); err != nil {
+			return err
+		}
+		err = dbRemoveAssetUndo(dbTx, block.Hash())
+		if err != nil {
+			return err
+		}
+
+		// Revert the changes the block made to the statistics of the
+		// utxo set.
+		err = dbUpdateUtxoSetState(dbTx, node, block, stxos,
+			state.TotalTxns, true)
+		if err != nil {
+			return err
+		}
+
 		// Allow the index manager to call each of the currently active
 		// optional indexes with the block being disconnected so they
 		// can update themselves accordingly.
@@ -773,7 +877,7 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 	}
 
 	// Prune fully spent entries and mark all entries in the view unmodified
-	// now that the modifications have been committed to the database.
+	// now that the modifications have been committed to the utxo cache.
 	view.commit()
 
 	// This node's parent is now the end of the best chain.
@@ -852,6 +956,16 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 	oldBest := tip
 	newBest := tip
 
+	// Write the utxo cache to the database before disconnecting blocks.
+	// The spend journal entries of old databases lack the details of some
+	// spent outputs which are then looked up by their transaction hash in
+	// the database, so it needs to contain all of them.
+	if detachNodes.Len() != 0 {
+		if err := b.utxoCache.flush(tip, false); err != nil {
+			return err
+		}
+	}
+
 	// All of the blocks to detach and related spend journal entries needed
 	// to unspend transaction outputs in the blocks being disconnected must
 	// be loaded from the database during the reorg check phase below and
@@ -865,10 +979,15 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 	// entails loading the blocks and their associated spent txos from the
 	// database and using that information to unspend all of the spent txos
 	// and remove the utxos created by the blocks.
-	view := NewUtxoViewpoint()
+	view := b.newUtxoViewpoint()
 	view.SetBestHash(&oldBest.hash)
 	for e := detachNodes.Front(); e != nil; e = e.Next() {
 		n := e.Value.(*blockNode)
+		if !b.index.NodeStatus(n).HaveData() {
+			return fmt.Errorf("unable to disconnect block %v (height "+
+				"%d) since it has been pruned", &n.hash, n.height)
+		}
+
 		var block *btcutil.Block
 		err := b.db.View(func(dbTx database.Tx) error {
 			var err error
@@ -995,7 +1114,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 	// the reorg would be successful and the connection code requires the
 	// view to be valid from the viewpoint of each block being connected or
 	// disconnected.
-	view = NewUtxoViewpoint()
+	view = b.newUtxoViewpoint()
 	view.SetBestHash(&b.bestChain.Tip().hash)
 
 	// Disconnect blocks from the main chain.
@@ -1106,7 +1225,7 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *btcutil.Block, fla
 		// Perform several checks to verify the block can be connected
 		// to the main chain without violating any rules and without
 		// actually connecting the block.
-		view := NewUtxoViewpoint()
+		view := b.newUtxoViewpoint()
 		view.SetBestHash(parentHash)
 		stxos := make([]SpentTxOut, 0, countSpentOutputs(block))
 		if !fastAdd {
@@ -1215,6 +1334,290 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *btcutil.Block, fla
 	return err == nil, err
 }
 
+// forEachDescendant calls the passed function with every node in the block
+// index which descends from the passed node.  Each descendant is visited once.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) forEachDescendant(node *blockNode, fn func(*blockNode)) {
+	visited := make(map[*blockNode]struct{})
+	for _, tip := range b.index.chainTips() {
+		if tip.height <= node.height || tip.Ancestor(node.height) != node {
+			continue
+		}
+		for n := tip; n != node; n = n.parent {
+			if _, ok := visited[n]; ok {
+				break
+			}
+			visited[n] = struct{}{}
+			fn(n)
+		}
+	}
+}
+
+// findBestChainTip returns the node with the most cumulative proof of work
+// which is not known to be invalid and has its block data available.  Ties are
+// broken in favor of the node whose block data was received first, or which
+// was marked precious last, and then in favor of nodes on the current best
+// chain to avoid needless reorganizations.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) findBestChainTip() *blockNode {
+	var best *blockNode
+	for _, tip := range b.index.chainTips() {
+		// Walk back to the most recent ancestor which may be connected.
+		// Descendants of invalid blocks that are not marked as such yet
+		// are caught when attempting to reorganize to them.
+		n := tip
+		for n != nil {
+			status := b.index.NodeStatus(n)
+			if status.HaveData() && !status.KnownInvalid() {
+				break
+			}
+			n = n.parent
+		}
+		if n == nil {
+			continue
+		}
+
+		if best == nil {
+			best = n
+			continue
+		}
+		cmp := n.workSum.Cmp(best.workSum)
+		if cmp > 0 || (cmp == 0 && n.sequenceID < best.sequenceID) {
+			best = n
+			continue
+		}
+		if cmp == 0 && n.sequenceID == best.sequenceID &&
+			b.bestChain.Contains(n) && !b.bestChain.Contains(best) {
+
+			best = n
+		}
+	}
+	return best
+}
+
+// setReceived assigns the next sequence ID to the passed node whose block data
+// was just received.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) setReceived(node *blockNode) {
+	node.sequenceID = b.nextSequenceID
+	if b.nextSequenceID < math.MaxInt32 {
+		b.nextSequenceID++
+	}
+}
+
+// findBestHeader returns the header with the most cumulative proof of work
+// which is neither known to be invalid nor descends from a block known to be
+// invalid.  Ties are broken in favor of the tip of the current best chain.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) findBestHeader() *blockNode {
+	best := b.bestChain.Tip()
+	for _, tip := range b.index.chainTips() {
+		// The blocks of the best chain are valid, so only the branch
+		// the tip ends needs to be searched for invalid blocks.
+		n := tip
+		fork := b.bestChain.FindFork(tip)
+		for iter := tip; iter != fork; iter = iter.parent {
+			if b.index.NodeStatus(iter).KnownInvalid() {
+				n = iter.parent
+			}
+		}
+
+		if n.workSum.Cmp(best.workSum) > 0 {
+			best = n
+		}
+	}
+	return best
+}
+
+// maybeUpdateBestHeader makes the passed node, which must not be known to be
+// invalid, the tip of the best header chain when it has more cumulative proof
+// of work than the current tip.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) maybeUpdateBestHeader(node *blockNode) {
+	if node.workSum.Cmp(b.bestHeader.Tip().workSum) > 0 {
+		b.bestHeader.SetTip(node)
+	}
+}
+
+// activateBestChain reorganizes the chain to the valid chain with the most
+// cumulative proof of work, which may mean disconnecting blocks without
+// attaching any when the current best chain has been invalidated.  Chains which
+// fail to connect are marked invalid and the next best chain is tried instead.
+//
+// This function may modify node statuses in the block index without flushing.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) activateBestChain() error {
+	var failedTip *blockNode
+	var failedErr error
+	for {
+		tip := b.findBestChainTip()
+		if tip == nil {
+			return AssertError("no valid chain tip is known")
+		}
+		if tip == b.bestChain.Tip() {
+			return nil
+		}
+
+		// Give up when a chain failed to connect without any of its
+		// blocks being marked invalid to avoid retrying it forever.
+		if tip == failedTip {
+			return failedErr
+		}
+
+		// The reorganize nodes are empty when the tip descends from
+		// an invalid block, in which case getReorganizeNodes marked
+		// the affected nodes and the search is simply repeated.
+		detachNodes, attachNodes := b.getReorganizeNodes(tip)
+		if detachNodes.Len() == 0 && attachNodes.Len() == 0 {
+			continue
+		}
+
+		err := b.reorganizeChain(detachNodes, attachNodes)
+		if err != nil {
+			if _, ok := err.(RuleError); ok {
+				log.Warnf("Unable to reorganize to chain tip %v: %v",
+					tip.hash, err)
+				failedTip, failedErr = tip, err
+				continue
+			}
+			return err
+		}
+	}
+}
+
+// InvalidateBlock marks the block with the passed hash and all of its
+// descendants as invalid.  When the block is part of the main chain, the chain
+// is reorganized to the valid chain with the most cumulative proof of work,
+// which means at least the block and its descendants are disconnected.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) InvalidateBlock(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return fmt.Errorf("block %s is not known", hash)
+	}
+	if node.parent == nil {
+		return fmt.Errorf("the genesis block %s can't be invalidated",
+			hash)
+	}
+
+	// The block is no longer considered fully validated so it is checked
+	// again should it be reconsidered later.
+	b.index.UnsetStatusFlags(node, statusValid)
+	b.index.SetStatusFlags(node, statusValidateFailed)
+	b.forEachDescendant(node, func(n *blockNode) {
+		if b.index.NodeStatus(n)&statusInvalidAncestor == 0 {
+			b.index.SetStatusFlags(n, statusInvalidAncestor)
+		}
+	})
+
+	err := b.activateBestChain()
+	b.bestHeader.SetTip(b.findBestHeader())
+
+	// Flush the status changes regardless of whether the reorganize
+	// succeeded since the block is invalid either way.
+	if writeErr := b.index.flushToDB(); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// ReconsiderBlock removes the invalid status from the block with the passed
+// hash, its ancestors and its descendants, and reorganizes the chain to the
+// valid chain with the most cumulative proof of work.  This undoes
+// InvalidateBlock as well as validation failures, so blocks which are really
+// invalid are marked invalid again when the chain attempts to connect them.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReconsiderBlock(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return fmt.Errorf("block %s is not known", hash)
+	}
+
+	const invalidFlags = statusValidateFailed | statusInvalidAncestor
+	reconsider := func(n *blockNode) {
+		if b.index.NodeStatus(n)&invalidFlags != 0 {
+			b.index.UnsetStatusFlags(n, invalidFlags)
+		}
+	}
+	for n := node; n != nil; n = n.parent {
+		reconsider(n)
+	}
+	b.forEachDescendant(node, reconsider)
+
+	err := b.activateBestChain()
+	b.bestHeader.SetTip(b.findBestHeader())
+	if writeErr := b.index.flushToDB(); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// PreciousBlock treats the block with the passed hash as if it was received
+// before any other block with the same cumulative proof of work.  When the
+// block is not on the main chain and its chain has as much work as the main
+// chain, the chain is reorganized to end in it.  Since the main chain is only
+// replaced by chains with more work, the block remains the best chain tip
+// until such a chain is found.  Blocks with less work are left alone.  When
+// several blocks are marked precious, the last one is preferred.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) PreciousBlock(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return fmt.Errorf("block %s is not known", hash)
+	}
+	status := b.index.NodeStatus(node)
+	if status.KnownInvalid() {
+		return fmt.Errorf("block %s is known to be invalid", hash)
+	}
+	if !status.HaveData() {
+		return fmt.Errorf("block %s is not available", hash)
+	}
+	tipWork := b.bestChain.Tip().workSum
+	if node.workSum.Cmp(tipWork) < 0 {
+		return nil
+	}
+
+	// Start over with the precious sequence IDs once the best chain has
+	// more work than when a block was last marked precious since the
+	// blocks marked before can't tie with the best chain anymore.
+	if b.lastPreciousWork == nil || tipWork.Cmp(b.lastPreciousWork) > 0 {
+		b.nextPreciousID = -1
+	}
+	b.lastPreciousWork = tipWork
+	node.sequenceID = b.nextPreciousID
+	if b.nextPreciousID > math.MinInt32 {
+		b.nextPreciousID--
+	}
+	if b.bestChain.Contains(node) {
+		return nil
+	}
+
+	log.Infof("REORGANIZE: Block %v is precious", node.hash)
+	err := b.activateBestChain()
+	if writeErr := b.index.flushToDB(); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
 // isCurrent returns whether or not the chain believes it is current.  Several
 // factors are used to guess, but the key factors that allow the chain to
 // believe it is current are:
@@ -1253,6 +1656,51 @@ func (b *BlockChain) IsCurrent() bool {
 	return b.isCurrent()
 }
 
+// BestHeader returns the hash and height of the tip of the chain of headers with
+// the most cumulative proof of work which are not known to be invalid.  The
+// blocks of the chain might not all be available yet, so it can be ahead of the
+// best chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BestHeader() (chainhash.Hash, int32) {
+	b.chainLock.RLock()
+	tip := b.bestHeader.Tip()
+	b.chainLock.RUnlock()
+	return tip.hash, tip.height
+}
+
+// BestHeaderHashes returns the hashes of up to maxHashes blocks of the best
+// header chain following the block with the passed hash along with the height
+// of the first one.  When the block is not part of the best header chain, the
+// hashes of the blocks following the point where its chain forks from the best
+// header chain are returned instead.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BestHeaderHashes(hash *chainhash.Hash, maxHashes int) (int32, []chainhash.Hash, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return 0, nil, fmt.Errorf("no known block header with hash %v",
+			hash)
+	}
+	fork := b.bestHeader.FindFork(node)
+	if fork == nil {
+		return 0, nil, fmt.Errorf("block %v does not connect to the "+
+			"best header chain", hash)
+	}
+
+	hashes := make([]chainhash.Hash, 0, maxHashes)
+	for n := b.bestHeader.Next(fork); n != nil; n = b.bestHeader.Next(n) {
+		if len(hashes) == maxHashes {
+			break
+		}
+		hashes = append(hashes, n.hash)
+	}
+	return fork.height + 1, hashes, nil
+}
+
 // BestSnapshot returns information about the current best chain block and
 // related state as of the current point in time.  The returned instance must be
 // treated as immutable since it is shared by all callers.
@@ -1582,27 +2030,54 @@ func (b *BlockChain) LocateBlocks(locator BlockLocator, hashStop *chainhash.Hash
 
 // locateHeaders returns the headers of the blocks after the first known block
 // in the locator until the provided stop hash is reached, or up to the provided
-// max number of block headers.
+// max number of block headers.  Merged mined headers are returned along with
+// their auxpow.
 //
 // See the comment on the exported function for more details on special cases.
 //
 // This function MUST be called with the chain state lock held (for reads).
-func (b *BlockChain) locateHeaders(locator BlockLocator, hashStop *chainhash.Hash, maxHeaders uint32) []wire.BlockHeader {
+func (b *BlockChain) locateHeaders(locator BlockLocator, hashStop *chainhash.Hash, maxHeaders uint32) ([]wire.BlockHeader, error) {
 	// Find the node after the first known block in the locator and the
 	// total number of nodes after it needed while respecting the stop hash
 	// and max entries.
 	node, total := b.locateInventory(locator, hashStop, maxHeaders)
 	if total == 0 {
-		return nil
+		return nil, nil
 	}
 
-	// Populate and return the found headers.
+	// Populate the found headers.
 	headers := make([]wire.BlockHeader, 0, total)
+	var auxPowHeaders []int
 	for i := uint32(0); i < total; i++ {
-		headers = append(headers, node.Header())
+		header := node.Header()
+		if isAuxPow(&header, b.chainParams) {
+			auxPowHeaders = append(auxPowHeaders, len(headers))
+		}
+		headers = append(headers, header)
 		node = b.bestChain.Next(node)
 	}
-	return headers
+	if len(auxPowHeaders) == 0 {
+		return headers, nil
+	}
+
+	// Merged mined headers are only valid along with their auxpow, which
+	// the nodes don't keep.
+	err := b.db.View(func(dbTx database.Tx) error {
+		for _, i := range auxPowHeaders {
+			header := &headers[i]
+			blockHash := header.BlockHash()
+			auxPow, err := dbFetchAuxPow(dbTx, &blockHash)
+			if err != nil {
+				return err
+			}
+			header.AuxPow = auxPow
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return headers, nil
 }
 
 // LocateHeaders returns the headers of the blocks after the first known block
@@ -1617,12 +2092,16 @@ func (b *BlockChain) locateHeaders(locator BlockLocator, hashStop *chainhash.Has
 // - When locators are provided, but none of them are known, headers starting
 //   after the genesis block will be returned
 //
+// An error is returned when the auxpow of a merged mined header can't be
+// loaded.
+//
 // This function is safe for concurrent access.
-func (b *BlockChain) LocateHeaders(locator BlockLocator, hashStop *chainhash.Hash) []wire.BlockHeader {
+func (b *BlockChain) LocateHeaders(locator BlockLocator, hashStop *chainhash.Hash) ([]wire.BlockHeader, error) {
 	b.chainLock.RLock()
-	headers := b.locateHeaders(locator, hashStop, wire.MaxBlockHeadersPerMsg)
+	headers, err := b.locateHeaders(locator, hashStop,
+		wire.MaxBlockHeadersPerMsg)
 	b.chainLock.RUnlock()
-	return headers
+	return headers, err
 }
 
 // IndexManager provides a generic interface that the is called when blocks are
@@ -1711,6 +2190,63 @@ type Config struct {
 	// This field can be nil if the caller is not interested in using a
 	// signature cache.
 	HashCache *txscript.HashCache
+
+	// NEVMPruneDepth is the number of blocks a block must be buried by
+	// before the data of the NEVM block connected to it is pruned.  The
+	// hash and roots of the NEVM block are kept.
+	//
+	// This field can be zero to keep the data of all NEVM blocks.
+	NEVMPruneDepth int32
+
+	// Prune is the target size in bytes of the stored blocks.  The oldest
+	// blocks are pruned once the target is exceeded, however, the most
+	// recent blocks needed to handle reorganizations are always kept.  A
+	// database with pruned blocks can only be used with pruning enabled.
+	//
+	// This field can be zero to keep all blocks.
+	Prune uint64
+
+	// AssumeValid is the hash of a block whose ancestors are assumed to
+	// have valid scripts.  Their scripts are not validated once the block
+	// is part of the best header chain and buried deeply enough.  This is
+	// typically the value of the chain parameters.
+	//
+	// This field can be nil to validate the scripts of all blocks.
+	AssumeValid *chainhash.Hash
+
+	// MinimumChainWork is the minimum cumulative proof of work a chain of
+	// headers received from a peer must have before the headers are added
+	// to the block index.  See HeadersPresync.  This is typically the value
+	// of the chain parameters.
+	//
+	// This field can be nil to add the headers of any chain right away.
+	MinimumChainWork *big.Int
+
+	// UTXOSnapshot is read to bootstrap the chain from a snapshot of the
+	// unspent transaction outputs as created by DumpUTXOSnapshot.  The
+	// snapshot must be pinned by the chain parameters and can only be
+	// loaded into a new database.  The blocks before it are validated as
+	// they are processed.
+	//
+	// This field can be nil to not load a snapshot.
+	UTXOSnapshot io.Reader
+
+	// UtxoCacheMaxSize is the approximate number of bytes the cache of the
+	// unspent transaction outputs may use before it is written to the
+	// database and emptied.
+	//
+	// This field can be zero to write the changes of every block to the
+	// database right away.
+	UtxoCacheMaxSize uint64
+
+	// UtxoCacheFlushInterval is the interval the cache of the unspent
+	// transaction outputs is written to the database at even when it has
+	// not reached its maximum size.  This limits the number of blocks
+	// which need to be connected again after an unclean shutdown.
+	//
+	// This field can be zero to only write the cache once it is full or
+	// the chain is shut down.
+	UtxoCacheFlushInterval time.Duration
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -1725,6 +2261,12 @@ func New(config *Config) (*BlockChain, error) {
 	if config.TimeSource == nil {
 		return nil, AssertError("blockchain.New timesource is nil")
 	}
+	if err := checkRetargetParams(config.ChainParams); err != nil {
+		return nil, err
+	}
+	if err := checkPowHashParams(config.ChainParams); err != nil {
+		return nil, err
+	}
 
 	// Generate a checkpoint by height map from the provided checkpoints
 	// and assert the provided checkpoints are sorted by height as required.
@@ -1761,11 +2303,20 @@ func New(config *Config) (*BlockChain, error) {
 		blocksPerRetarget:   int32(targetTimespan / targetTimePerBlock),
 		index:               newBlockIndex(config.DB, params),
 		hashCache:           config.HashCache,
+		nevmPruneDepth:      config.NEVMPruneDepth,
+		pruneTarget:         config.Prune,
+		assumeValid:         config.AssumeValid,
+		minimumChainWork:    config.MinimumChainWork,
 		bestChain:           newChainView(nil),
+		bestHeader:          newChainView(nil),
+		nextSequenceID:      1,
+		nextPreciousID:      -1,
 		orphans:             make(map[chainhash.Hash]*orphanBlock),
 		prevOrphans:         make(map[chainhash.Hash][]*orphanBlock),
 		warningCaches:       newThresholdCaches(vbNumBits),
 		deploymentCaches:    newThresholdCaches(chaincfg.DefinedDeployments),
+		utxoCache: newUtxoCache(config.DB, config.UtxoCacheMaxSize,
+			config.UtxoCacheFlushInterval),
 	}
 
 	// Initialize the chain state from the passed database.  When the db
@@ -1775,6 +2326,33 @@ func New(config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
+	// Bring the utxo set up to date with the best chain in case the utxo
+	// cache was not flushed before the last shutdown.
+	if err := b.initConsistentState(config.Interrupt); err != nil {
+		return nil, err
+	}
+
+	// Compute the statistics of the utxo set when they are not available
+	// yet.
+	if err := b.initUtxoSetStats(); err != nil {
+		return nil, err
+	}
+
+	// The blocks which have been pruned can't be served once pruning is
+	// disabled, so refuse to continue without it.
+	if b.pruneHeight > 0 && b.pruneTarget == 0 {
+		return nil, fmt.Errorf("blocks below height %d have been pruned "+
+			"from the database, so pruning can't be disabled",
+			b.pruneHeight)
+	}
+
+	// Bootstrap the chain from the UTXO snapshot when one is provided.
+	if config.UTXOSnapshot != nil {
+		if err := b.loadUTXOSnapshot(config.UTXOSnapshot); err != nil {
+			return nil, err
+		}
+	}
+
 	// Perform any upgrades to the various chain-specific buckets as needed.
 	if err := b.maybeUpgradeDbBuckets(config.Interrupt); err != nil {
 		return nil, err
@@ -1798,6 +2376,10 @@ func New(config *Config) (*BlockChain, error) {
 	log.Infof("Chain state (height %d, hash %v, totaltx %d, work %v)",
 		bestNode.height, bestNode.hash, b.stateSnapshot.TotalTxns,
 		bestNode.workSum)
+	if b.assumeValid != nil {
+		log.Infof("Assuming valid scripts for the ancestors of block %v",
+			b.assumeValid)
+	}
 
 	return &b, nil
 }
@@ -769,17 +769,22 @@ func TestLocateInventory(t *testing.T) {
 	for _, test := range tests {
 		// Ensure the expected headers are located.
 		var headers []wire.BlockHeader
+		var err error
 		if test.maxAllowed != 0 {
 			// Need to use the unexported function to override the
 			// max allowed for headers.
 			chain.chainLock.RLock()
-			headers = chain.locateHeaders(test.locator,
+			headers, err = chain.locateHeaders(test.locator,
 				&test.hashStop, test.maxAllowed)
 			chain.chainLock.RUnlock()
 		} else {
-			headers = chain.LocateHeaders(test.locator,
+			headers, err = chain.LocateHeaders(test.locator,
 				&test.hashStop)
 		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
 		if !reflect.DeepEqual(headers, test.headers) {
 			t.Errorf("%s: unxpected headers -- got %v, want %v",
 				test.name, headers, test.headers)
@@ -964,3 +969,167 @@ func TestIntervalBlockHashes(t *testing.T) {
 		}
 	}
 }
+
+// TestInvalidateBlock ensures invalidating, reconsidering and prioritizing
+// blocks reorganizes the chain to the expected tip, and that chain tips with
+// the same work are chosen by the order their blocks were received in unless
+// one is marked precious.
+func TestInvalidateBlock(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	params := &chaincfg.RegressionNetParams
+	chain, teardownFunc, err := chainSetup("invalidateblock", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Construct a block chain with the following structure.
+	// 	genesis -> 1 -> 2 -> 3 -> 4
+	// 	                \-> 3a -> 4a
+	blocks := make(map[string]*btcutil.Block)
+	genesis := params.GenesisBlock
+	blocks["1"] = newTestBlock(genesis, 1, 0, params)
+	blocks["2"] = newTestBlock(blocks["1"].MsgBlock(), 2, 0, params)
+	blocks["3"] = newTestBlock(blocks["2"].MsgBlock(), 3, 0, params)
+	blocks["4"] = newTestBlock(blocks["3"].MsgBlock(), 4, 0, params)
+	blocks["3a"] = newTestBlock(blocks["2"].MsgBlock(), 3, 1, params)
+	blocks["4a"] = newTestBlock(blocks["3a"].MsgBlock(), 4, 1, params)
+	for _, name := range []string{"1", "2", "3", "4", "3a", "4a"} {
+		_, isOrphan, err := chain.ProcessBlock(blocks[name], BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock(%s): unexpected error: %v", name,
+				err)
+		}
+		if isOrphan {
+			t.Fatalf("ProcessBlock(%s): unexpected orphan", name)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		fn      func(*chainhash.Hash) error
+		block   string
+		wantTip string
+	}{
+		{"invalidate first received", chain.InvalidateBlock, "4", "4a"},
+		{"reconsider first received", chain.ReconsiderBlock, "4", "4"},
+		{"precious equal work", chain.PreciousBlock, "4a", "4a"},
+		{"precious back", chain.PreciousBlock, "4", "4"},
+		{"precious less work", chain.PreciousBlock, "3a", "4"},
+		{"invalidate last precious", chain.InvalidateBlock, "4", "4a"},
+		{"reconsider last precious", chain.ReconsiderBlock, "4", "4"},
+		{"invalidate main chain", chain.InvalidateBlock, "3", "4a"},
+		{"invalidate only chain", chain.InvalidateBlock, "4a", "3a"},
+		{"invalidate fork point", chain.InvalidateBlock, "2", "1"},
+		{"reconsider descendant", chain.ReconsiderBlock, "4a", "4a"},
+		{"reconsider side chain", chain.ReconsiderBlock, "3", "4"},
+		{"precious reconsidered", chain.PreciousBlock, "4a", "4a"},
+		{"invalidate side chain", chain.InvalidateBlock, "3a", "4"},
+	}
+	for _, test := range tests {
+		err := test.fn(blocks[test.block].Hash())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		tip := chain.BestSnapshot()
+		want := blocks[test.wantTip]
+		if tip.Hash != *want.Hash() {
+			t.Fatalf("%s: unexpected tip -- got %v, want %v",
+				test.name, tip.Hash, want.Hash())
+		}
+	}
+
+	// Blocks building on invalid blocks must be rejected.
+	block5a := newTestBlock(blocks["4a"].MsgBlock(), 5, 1, params)
+	_, _, err = chain.ProcessBlock(block5a, BFNone)
+	if rerr, ok := err.(RuleError); !ok ||
+		rerr.ErrorCode != ErrInvalidAncestorBlock {
+
+		t.Fatalf("ProcessBlock: did not get expected error %v - got %v",
+			ErrInvalidAncestorBlock, err)
+	}
+
+	// The genesis block and unknown blocks can't be invalidated.
+	if err := chain.InvalidateBlock(params.GenesisHash); err == nil {
+		t.Fatal("InvalidateBlock: invalidated the genesis block")
+	}
+	if err := chain.InvalidateBlock(&chainhash.Hash{0x01}); err == nil {
+		t.Fatal("InvalidateBlock: invalidated an unknown block")
+	}
+	if err := chain.PreciousBlock(blocks["4a"].Hash()); err == nil {
+		t.Fatal("PreciousBlock: prioritized an invalid block")
+	}
+}
+
+// TestChainTips ensures the chain tips of all branches of the block tree are
+// reported with the expected status and branch length.
+func TestChainTips(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	params := &chaincfg.RegressionNetParams
+	chain, teardownFunc, err := chainSetup("chaintips", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Construct a block chain with the following structure where 3f and
+	// 4f are the main chain for a while and 3i is invalidated.
+	// 	genesis -> 1 -> 2  -> 3  -> 4 -> 5
+	// 	           |     \-> 3f -> 4f
+	// 	           |     \-> 3v
+	// 	           |     \-> 3i
+	// 	           \-> 2h (header only)
+	blocks := make(map[string]*btcutil.Block)
+	addBlock := func(name, parent string, height int32, extraNonce int64) {
+		parentBlock := params.GenesisBlock
+		if parent != "" {
+			parentBlock = blocks[parent].MsgBlock()
+		}
+		blocks[name] = newTestBlock(parentBlock, height, extraNonce,
+			params)
+		_, _, err := chain.ProcessBlock(blocks[name], BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock(%s): unexpected error: %v", name,
+				err)
+		}
+	}
+	addBlock("1", "", 1, 0)
+	addBlock("2", "1", 2, 0)
+	addBlock("3", "2", 3, 0)
+	addBlock("3f", "2", 3, 1)
+	addBlock("4f", "3f", 4, 1)
+	addBlock("4", "3", 4, 0)
+	addBlock("5", "4", 5, 0)
+	addBlock("3v", "2", 3, 2)
+	addBlock("3i", "2", 3, 3)
+	if err := chain.InvalidateBlock(blocks["3i"].Hash()); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	header := newTestBlock(blocks["1"].MsgBlock(), 2, 4, params).
+		MsgBlock().Header
+	chain.index.AddNode(newBlockNode(&header,
+		chain.index.LookupNode(blocks["1"].Hash())))
+
+	want := map[chainhash.Hash]ChainTip{
+		*blocks["5"].Hash():  {5, *blocks["5"].Hash(), 0, ChainTipActive},
+		*blocks["4f"].Hash(): {4, *blocks["4f"].Hash(), 2, ChainTipValidFork},
+		*blocks["3v"].Hash(): {3, *blocks["3v"].Hash(), 1, ChainTipValidHeaders},
+		*blocks["3i"].Hash(): {3, *blocks["3i"].Hash(), 1, ChainTipInvalid},
+		header.BlockHash():   {2, header.BlockHash(), 1, ChainTipHeadersOnly},
+	}
+	tips := chain.ChainTips()
+	if len(tips) != len(want) {
+		t.Fatalf("ChainTips: unexpected number of tips -- got %d, want "+
+			"%d", len(tips), len(want))
+	}
+	for i, tip := range tips {
+		if i > 0 && tip.Height > tips[i-1].Height {
+			t.Errorf("ChainTips: tip %v is not ordered by height",
+				tip.Hash)
+		}
+		if !reflect.DeepEqual(tip, want[tip.Hash]) {
+			t.Errorf("ChainTips: unexpected tip -- got %+v, want %+v",
+				tip, want[tip.Hash])
+		}
+	}
+}
@@ -25,13 +25,14 @@ const (
 	blockHdrSize = wire.MaxBlockHeaderPayload
 
 	// latestUtxoSetBucketVersion is the current version of the utxo set
-	// bucket that is used to track all unspent outputs.
-	latestUtxoSetBucketVersion = 2
+	// bucket that is used to track all unspent outputs.  Version 3 added
+	// the assets carried by the outputs.
+	latestUtxoSetBucketVersion = 3
 
 	// latestSpendJournalBucketVersion is the current version of the spend
 	// journal bucket that is used to track all spent transactions for use
-	// in reorgs.
-	latestSpendJournalBucketVersion = 1
+	// in reorgs.  Version 2 added the assets carried by the outputs.
+	latestSpendJournalBucketVersion = 2
 )
 
 var (
@@ -174,11 +175,14 @@ func dbFetchOrCreateVersion(dbTx database.Tx, key []byte, defaultVersion uint32)
 // The reserved field below used to keep track of the version of the containing
 // transaction when the height in the header code was non-zero, however the
 // height is always non-zero now, but keeping the extra reserved field allows
-// backwards compatibility.
+// backwards compatibility.  It is now used to flag whether the spent txout
+// carried an asset, in which case the asset details follow the compressed
+// txout.  Entries written before assets were tracked always have a zero
+// reserved field and thus remain valid.
 //
 // The serialized format is:
 //
-//   [<header code><reserved><compressed txout>],...
+//   [<header code><reserved><compressed txout>[<asset guid><asset value>]],...
 //
 //   Field                Type     Size
 //   header code          VLQ      variable
@@ -186,6 +190,10 @@ func dbFetchOrCreateVersion(dbTx database.Tx, key []byte, defaultVersion uint32)
 //   compressed txout
 //     compressed amount  VLQ      variable
 //     compressed script  []byte   variable
+//   asset guid           VLQ      variable (only when reserved is 1)
+//   asset value          VLQ      variable (only when reserved is 1)
+//
+// The asset value is compressed with the same scheme as the txout amount.
 //
 // The serialized header code format is:
 //   bit 0 - containing transaction is a coinbase
@@ -251,6 +259,13 @@ type SpentTxOut struct {
 
 	// Denotes if the creating tx is a coinbase.
 	IsCoinBase bool
+
+	// AssetGuid is the guid of the asset carried by the output or 0 when
+	// the output does not carry an asset.
+	AssetGuid uint64
+
+	// AssetValue is the amount of the asset carried by the output.
+	AssetValue int64
 }
 
 // FetchSpendJournal attempts to retrieve the spend journal, or the set of
@@ -291,6 +306,15 @@ func spentTxOutHeaderCode(stxo *SpentTxOut) uint64 {
 	return headerCode
 }
 
+// spentTxOutReserved returns the value of the reserved field to be used when
+// serializing the provided stxo entry.
+func spentTxOutReserved(stxo *SpentTxOut) uint64 {
+	if stxo.AssetGuid != 0 {
+		return 1
+	}
+	return 0
+}
+
 // spentTxOutSerializeSize returns the number of bytes it would take to
 // serialize the passed stxo according to the format described above.
 func spentTxOutSerializeSize(stxo *SpentTxOut) int {
@@ -299,7 +323,13 @@ func spentTxOutSerializeSize(stxo *SpentTxOut) int {
 		// The legacy v1 spend journal format conditionally tracked the
 		// containing transaction version when the height was non-zero,
 		// so this is required for backwards compat.
-		size += serializeSizeVLQ(0)
+		reserved := spentTxOutReserved(stxo)
+		size += serializeSizeVLQ(reserved)
+		if reserved != 0 {
+			size += serializeSizeVLQ(stxo.AssetGuid)
+			size += serializeSizeVLQ(compressTxOutAmount(
+				uint64(stxo.AssetValue)))
+		}
 	}
 	return size + compressedTxOutSize(uint64(stxo.Amount), stxo.PkScript)
 }
@@ -311,14 +341,22 @@ func spentTxOutSerializeSize(stxo *SpentTxOut) int {
 func putSpentTxOut(target []byte, stxo *SpentTxOut) int {
 	headerCode := spentTxOutHeaderCode(stxo)
 	offset := putVLQ(target, headerCode)
+	var reserved uint64
 	if stxo.Height > 0 {
 		// The legacy v1 spend journal format conditionally tracked the
 		// containing transaction version when the height was non-zero,
 		// so this is required for backwards compat.
-		offset += putVLQ(target[offset:], 0)
+		reserved = spentTxOutReserved(stxo)
+		offset += putVLQ(target[offset:], reserved)
 	}
-	return offset + putCompressedTxOut(target[offset:], uint64(stxo.Amount),
+	offset += putCompressedTxOut(target[offset:], uint64(stxo.Amount),
 		stxo.PkScript)
+	if reserved != 0 {
+		offset += putVLQ(target[offset:], stxo.AssetGuid)
+		offset += putVLQ(target[offset:], compressTxOutAmount(
+			uint64(stxo.AssetValue)))
+	}
+	return offset
 }
 
 // decodeSpentTxOut decodes the passed serialized stxo entry, possibly followed
@@ -343,11 +381,13 @@ func decodeSpentTxOut(serialized []byte, stxo *SpentTxOut) (int, error) {
 	// Bits 1-x encode height of containing transaction.
 	stxo.IsCoinBase = code&0x01 != 0
 	stxo.Height = int32(code >> 1)
+	var reserved uint64
 	if stxo.Height > 0 {
 		// The legacy v1 spend journal format conditionally tracked the
 		// containing transaction version when the height was non-zero,
 		// so this is required for backwards compat.
-		_, bytesRead := deserializeVLQ(serialized[offset:])
+		var bytesRead int
+		reserved, bytesRead = deserializeVLQ(serialized[offset:])
 		offset += bytesRead
 		if offset >= len(serialized) {
 			return offset, errDeserialize("unexpected end of data " +
@@ -365,6 +405,24 @@ func decodeSpentTxOut(serialized []byte, stxo *SpentTxOut) (int, error) {
 	}
 	stxo.Amount = int64(amount)
 	stxo.PkScript = pkScript
+
+	// Decode the asset details when the reserved field flags them.
+	if reserved != 0 {
+		if offset >= len(serialized) {
+			return offset, errDeserialize("unexpected end of data " +
+				"before asset guid")
+		}
+		guid, bytesRead := deserializeVLQ(serialized[offset:])
+		offset += bytesRead
+		if offset >= len(serialized) {
+			return offset, errDeserialize("unexpected end of data " +
+				"after asset guid")
+		}
+		value, bytesRead := deserializeVLQ(serialized[offset:])
+		offset += bytesRead
+		stxo.AssetGuid = guid
+		stxo.AssetValue = int64(decompressTxOutAmount(value))
+	}
 	return offset, nil
 }
 
@@ -514,13 +572,18 @@ func dbRemoveSpendJournalEntry(dbTx database.Tx, blockHash *chainhash.Hash) erro
 //
 // The serialized value format is:
 //
-//   <header code><compressed txout>
+//   <header code><compressed txout>[<asset guid><asset value>]
 //
 //   Field                Type     Size
 //   header code          VLQ      variable
 //   compressed txout
 //     compressed amount  VLQ      variable
 //     compressed script  []byte   variable
+//   asset guid           VLQ      variable (only for asset outputs)
+//   asset value          VLQ      variable (only for asset outputs)
+//
+// The asset details are only present for outputs which carry an asset.  The
+// asset value is compressed with the same scheme as the txout amount.
 //
 // The serialized header code format is:
 //   bit 0 - containing transaction is a coinbase
@@ -644,13 +707,22 @@ func serializeUtxoEntry(entry *UtxoEntry) ([]byte, error) {
 	// Calculate the size needed to serialize the entry.
 	size := serializeSizeVLQ(headerCode) +
 		compressedTxOutSize(uint64(entry.Amount()), entry.PkScript())
+	compressedAssetValue := compressTxOutAmount(uint64(entry.AssetValue()))
+	if entry.AssetGuid() != 0 {
+		size += serializeSizeVLQ(entry.AssetGuid()) +
+			serializeSizeVLQ(compressedAssetValue)
+	}
 
 	// Serialize the header code followed by the compressed unspent
-	// transaction output.
+	// transaction output and the asset details, if any.
 	serialized := make([]byte, size)
 	offset := putVLQ(serialized, headerCode)
 	offset += putCompressedTxOut(serialized[offset:], uint64(entry.Amount()),
 		entry.PkScript())
+	if entry.AssetGuid() != 0 {
+		offset += putVLQ(serialized[offset:], entry.AssetGuid())
+		putVLQ(serialized[offset:], compressedAssetValue)
+	}
 
 	return serialized, nil
 }
@@ -673,11 +745,13 @@ func deserializeUtxoEntry(serialized []byte) (*UtxoEntry, error) {
 	blockHeight := int32(code >> 1)
 
 	// Decode the compressed unspent transaction output.
-	amount, pkScript, _, err := decodeCompressedTxOut(serialized[offset:])
+	amount, pkScript, bytesRead, err := decodeCompressedTxOut(
+		serialized[offset:])
 	if err != nil {
 		return nil, errDeserialize(fmt.Sprintf("unable to decode "+
 			"utxo: %v", err))
 	}
+	offset += bytesRead
 
 	entry := &UtxoEntry{
 		amount:      int64(amount),
@@ -689,6 +763,19 @@ func deserializeUtxoEntry(serialized []byte) (*UtxoEntry, error) {
 		entry.packedFlags |= tfCoinBase
 	}
 
+	// Decode the asset details when there is any remaining data.
+	if offset < len(serialized) {
+		guid, bytesRead := deserializeVLQ(serialized[offset:])
+		offset += bytesRead
+		if offset >= len(serialized) {
+			return nil, errDeserialize("unexpected end of data " +
+				"after asset guid")
+		}
+		value, _ := deserializeVLQ(serialized[offset:])
+		entry.assetGuid = guid
+		entry.assetValue = int64(decompressTxOutAmount(value))
+	}
+
 	return entry, nil
 }
 
@@ -730,10 +817,19 @@ func dbFetchUtxoEntryByHash(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry,
 // When there is no entry for the provided output, nil will be returned for both
 // the entry and the error.
 func dbFetchUtxoEntry(dbTx database.Tx, outpoint wire.OutPoint) (*UtxoEntry, error) {
+	utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+	return dbFetchUtxoEntryFromBucket(utxoBucket, outpoint)
+}
+
+// dbFetchUtxoEntryFromBucket fetches the specified transaction output from the
+// utxo set housed in the passed bucket.
+//
+// When there is no entry for the provided output, nil will be returned for both
+// the entry and the error.
+func dbFetchUtxoEntryFromBucket(utxoBucket database.Bucket, outpoint wire.OutPoint) (*UtxoEntry, error) {
 	// Fetch the unspent transaction output information for the passed
 	// transaction output.  Return now when there is no entry.
 	key := outpointKey(outpoint)
-	utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
 	serializedUtxo := utxoBucket.Get(*key)
 	recycleOutpointKey(key)
 	if serializedUtxo == nil {
@@ -771,37 +867,14 @@ func dbFetchUtxoEntry(dbTx database.Tx, outpoint wire.OutPoint) (*UtxoEntry, err
 // particular, only the entries that have been marked as modified are written
 // to the database.
 func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
-	utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+	utxoBucket := dbTx.Metadata().Bucket(view.utxoBucketName)
 	for outpoint, entry := range view.entries {
 		// No need to update the database if the entry was not modified.
 		if entry == nil || !entry.isModified() {
 			continue
 		}
 
-		// Remove the utxo entry if it is spent.
-		if entry.IsSpent() {
-			key := outpointKey(outpoint)
-			err := utxoBucket.Delete(*key)
-			recycleOutpointKey(key)
-			if err != nil {
-				return err
-			}
-
-			continue
-		}
-
-		// Serialize and store the utxo entry.
-		serialized, err := serializeUtxoEntry(entry)
-		if err != nil {
-			return err
-		}
-		key := outpointKey(outpoint)
-		err = utxoBucket.Put(*key, serialized)
-		// NOTE: The key is intentionally not recycled here since the
-		// database interface contract prohibits modifications.  It will
-		// be garbage collected normally when the database is done with
-		// it.
-		if err != nil {
+		if err := dbPutUtxoEntry(utxoBucket, outpoint, entry); err != nil {
 			return err
 		}
 	}
@@ -809,6 +882,29 @@ func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 	return nil
 }
 
+// dbPutUtxoEntry stores the passed utxo entry in the utxo set housed in the
+// passed bucket, or removes it from the set when it is spent.
+func dbPutUtxoEntry(utxoBucket database.Bucket, outpoint wire.OutPoint, entry *UtxoEntry) error {
+	// Remove the utxo entry if it is spent.
+	if entry.IsSpent() {
+		key := outpointKey(outpoint)
+		err := utxoBucket.Delete(*key)
+		recycleOutpointKey(key)
+		return err
+	}
+
+	// Serialize and store the utxo entry.
+	serialized, err := serializeUtxoEntry(entry)
+	if err != nil {
+		return err
+	}
+	key := outpointKey(outpoint)
+	// NOTE: The key is intentionally not recycled here since the database
+	// interface contract prohibits modifications.  It will be garbage
+	// collected normally when the database is done with it.
+	return utxoBucket.Put(*key, serialized)
+}
+
 // -----------------------------------------------------------------------------
 // The block index consists of two buckets with an entry for every block in the
 // main chain.  One bucket is for the hash to height mapping and the other is
@@ -1010,6 +1106,7 @@ func (b *BlockChain) createChainState() error {
 	node := newBlockNode(header, nil)
 	node.status = statusDataStored | statusValid
 	b.bestChain.SetTip(node)
+	b.bestHeader.SetTip(node)
 
 	// Add the new node to the index which is used for faster lookups.
 	b.index.addNode(node)
@@ -1073,6 +1170,31 @@ func (b *BlockChain) createChainState() error {
 			return err
 		}
 
+		// Create the bucket that houses the NEVM blocks connected to
+		// Syscoin blocks.
+		_, err = meta.CreateBucket(nevmBlockBucketName)
+		if err != nil {
+			return err
+		}
+
+		// Create the bucket that houses the auxpows of merged mined
+		// blocks.
+		_, err = meta.CreateBucket(auxPowBucketName)
+		if err != nil {
+			return err
+		}
+
+		// Create the buckets that house the asset state and the data
+		// needed to undo the changes blocks made to it.
+		_, err = meta.CreateBucket(assetStateBucketName)
+		if err != nil {
+			return err
+		}
+		_, err = meta.CreateBucket(assetUndoBucketName)
+		if err != nil {
+			return err
+		}
+
 		// Save the genesis block to the block index database.
 		err = dbStoreBlockNode(dbTx, node)
 		if err != nil {
@@ -1127,6 +1249,27 @@ func (b *BlockChain) initChainState() error {
 		}
 	}
 
+	// Databases created before the assets carried by outputs were stored
+	// can only be used when they don't contain any blocks yet.
+	if err := maybeUpgradeAssetState(b.db); err != nil {
+		return err
+	}
+
+	// Create the NEVM block and auxpow buckets for databases created
+	// before they were stored.
+	err = b.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		_, err := meta.CreateBucketIfNotExists(nevmBlockBucketName)
+		if err != nil {
+			return err
+		}
+		_, err = meta.CreateBucketIfNotExists(auxPowBucketName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
 	// Attempt to load the chain state from the database.
 	err = b.db.View(func(dbTx database.Tx) error {
 		// Fetch the stored chain state from the database metadata.
@@ -1209,16 +1352,37 @@ func (b *BlockChain) initChainState() error {
 				"chain tip %s in block index", state.hash))
 		}
 		b.bestChain.SetTip(tip)
+		b.bestHeader.SetTip(b.findBestHeader())
+		b.pruneHeight = dbFetchPruneHeight(dbTx)
 
-		// Load the raw block bytes for the best block.
-		blockBytes, err := dbTx.FetchBlock(&state.hash)
+		// Load the state of the UTXO snapshot the chain was bootstrapped
+		// from, if any.  The chain can't be used once the snapshot turned
+		// out to be invalid.
+		b.snapshot, err = dbFetchUtxoSnapshotState(dbTx, b.index)
 		if err != nil {
 			return err
 		}
+		if b.snapshot != nil && b.snapshot.status == utxoSnapshotInvalid {
+			return fmt.Errorf("the utxo snapshot of block %v the "+
+				"chain was bootstrapped from is invalid, the "+
+				"block database must be removed",
+				b.snapshot.node.hash)
+		}
+
+		// Load the raw block bytes for the best block.  The best block
+		// is not available when it is the block the chain was
+		// bootstrapped from.
 		var block wire.MsgBlock
-		err = block.Deserialize(bytes.NewReader(blockBytes))
-		if err != nil {
-			return err
+		var blockBytes []byte
+		if tip.status.HaveData() {
+			blockBytes, err = dbTx.FetchBlock(&state.hash)
+			if err != nil {
+				return err
+			}
+			err = block.Deserialize(bytes.NewReader(blockBytes))
+			if err != nil {
+				return err
+			}
 		}
 
 		// As a final consistency check, we'll run through all the
@@ -1256,7 +1420,13 @@ func (b *BlockChain) initChainState() error {
 	// As we might have updated the index after it was loaded, we'll
 	// attempt to flush the index to the DB. This will only result in a
 	// write if the elements are dirty, so it'll usually be a noop.
-	return b.index.flushToDB()
+	if err := b.index.flushToDB(); err != nil {
+		return err
+	}
+
+	// Resume validating the blocks before the UTXO snapshot the chain was
+	// bootstrapped from.
+	return b.validateSnapshotHistory()
 }
 
 // deserializeBlockRow parses a value in the block index bucket into a block
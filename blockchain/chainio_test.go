@@ -78,6 +78,19 @@ func TestStxoSerialization(t *testing.T) {
 			},
 			serialized: hexToBytes("0091f20f006edbc6c4d31bae9f1ccc38538a114bf42de65e86"),
 		},
+		// Adapted from block 100025 in main blockchain.
+		{
+			name: "Spends asset output",
+			stxo: SpentTxOut{
+				Amount:     13761000000,
+				PkScript:   hexToBytes("76a914b2fb57eadf61e106a100a7445a8c3f67898841ec88ac"),
+				IsCoinBase: false,
+				Height:     100024,
+				AssetGuid:  1234,
+				AssetValue: 5000,
+			},
+			serialized: hexToBytes("8b99700186c64700b2fb57eadf61e106a100a7445a8c3f67898841ec88522c"),
+		},
 	}
 
 	for _, test := range tests {
@@ -448,6 +461,20 @@ func TestUtxoSerialization(t *testing.T) {
 			},
 			serialized: hexToBytes("8b99420700ee8bd501094a7d5ca318da2506de35e1cb025ddc"),
 		},
+		// Adapted from tx in main blockchain:
+		// 8131ffb0a2c945ecaf9b9063e59558784f9c3a74741ce6ae2a18d0571dac15bb:1
+		{
+			name: "height 100001, not coinbase, asset",
+			entry: &UtxoEntry{
+				amount:      1000000,
+				assetGuid:   1234,
+				assetValue:  5000,
+				pkScript:    hexToBytes("76a914ee8bd501094a7d5ca318da2506de35e1cb025ddc88ac"),
+				blockHeight: 100001,
+				packedFlags: 0,
+			},
+			serialized: hexToBytes("8b99420700ee8bd501094a7d5ca318da2506de35e1cb025ddc88522c"),
+		},
 		// From tx in main blockchain:
 		// 8131ffb0a2c945ecaf9b9063e59558784f9c3a74741ce6ae2a18d0571dac15bb:1
 		{
@@ -526,6 +553,15 @@ func TestUtxoSerialization(t *testing.T) {
 				utxoEntry.IsCoinBase(), test.entry.IsCoinBase())
 			continue
 		}
+		if utxoEntry.AssetGuid() != test.entry.AssetGuid() ||
+			utxoEntry.AssetValue() != test.entry.AssetValue() {
+
+			t.Errorf("deserializeUtxoEntry #%d (%s) mismatched "+
+				"asset: got %d:%d, want %d:%d", i, test.name,
+				utxoEntry.AssetGuid(), utxoEntry.AssetValue(),
+				test.entry.AssetGuid(), test.entry.AssetValue())
+			continue
+		}
 	}
 }
 
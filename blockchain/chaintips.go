@@ -0,0 +1,135 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+)
+
+// ChainTipStatus describes the validation state of the branch of the block
+// tree which ends in a chain tip.
+type ChainTipStatus byte
+
+// These constants are used to identify specific chain tip states.
+const (
+	// ChainTipActive is the state of the tip of the main chain.
+	ChainTipActive ChainTipStatus = iota
+
+	// ChainTipValidFork is the state of a tip of a side chain whose blocks
+	// have all been fully validated.
+	ChainTipValidFork
+
+	// ChainTipValidHeaders is the state of a tip of a side chain whose
+	// blocks are all available, but have not all been fully validated.
+	ChainTipValidHeaders
+
+	// ChainTipHeadersOnly is the state of a tip of a side chain for which
+	// only the headers of some of the blocks are available.
+	ChainTipHeadersOnly
+
+	// ChainTipInvalid is the state of a tip of a side chain which contains
+	// at least one block that is known to be invalid.
+	ChainTipInvalid
+)
+
+// chainTipStatusStrings is a map of ChainTipStatus values back to their
+// constant names for pretty printing.
+var chainTipStatusStrings = map[ChainTipStatus]string{
+	ChainTipActive:       "ChainTipActive",
+	ChainTipValidFork:    "ChainTipValidFork",
+	ChainTipValidHeaders: "ChainTipValidHeaders",
+	ChainTipHeadersOnly:  "ChainTipHeadersOnly",
+	ChainTipInvalid:      "ChainTipInvalid",
+}
+
+// String returns the ChainTipStatus as a human-readable name.
+func (s ChainTipStatus) String() string {
+	if str := chainTipStatusStrings[s]; str != "" {
+		return str
+	}
+	return fmt.Sprintf("Unknown ChainTipStatus (%d)", int(s))
+}
+
+// ChainTip describes a block which no other known block builds on along with
+// the branch of the block tree it ends.
+type ChainTip struct {
+	// Height is the height of the tip.
+	Height int32
+
+	// Hash is the hash of the tip.
+	Hash chainhash.Hash
+
+	// BranchLen is the number of blocks between the tip and the point the
+	// branch forks from the main chain.  It is zero for the main chain.
+	BranchLen int32
+
+	// Status is the validation state of the branch.
+	Status ChainTipStatus
+}
+
+// chainTipStatus returns the validation state of the branch which ends in the
+// passed tip and forks from the main chain at the passed fork node.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) chainTipStatus(tip, fork *blockNode) ChainTipStatus {
+	if tip == b.bestChain.Tip() {
+		return ChainTipActive
+	}
+
+	// Descendants of invalid blocks are not necessarily marked invalid
+	// until the chain attempts to connect them, so the whole branch is
+	// checked.
+	status := ChainTipValidFork
+	for n := tip; n != nil && n != fork; n = n.parent {
+		nodeStatus := b.index.NodeStatus(n)
+		switch {
+		case nodeStatus.KnownInvalid():
+			return ChainTipInvalid
+		case !nodeStatus.HaveData():
+			status = ChainTipHeadersOnly
+		case !nodeStatus.KnownValid() && status == ChainTipValidFork:
+			status = ChainTipValidHeaders
+		}
+	}
+	return status
+}
+
+// ChainTips returns all chain tips in the block tree, which includes the tip of
+// the main chain and the tips of all known side chains.  The tips are ordered
+// by descending height.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ChainTips() []ChainTip {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	nodes := b.index.chainTips()
+	tips := make([]ChainTip, 0, len(nodes))
+	for _, node := range nodes {
+		fork := b.bestChain.FindFork(node)
+		branchLen := node.height
+		if fork != nil {
+			branchLen -= fork.height
+		}
+		tips = append(tips, ChainTip{
+			Height:    node.height,
+			Hash:      node.hash,
+			BranchLen: branchLen,
+			Status:    b.chainTipStatus(node, fork),
+		})
+	}
+
+	sort.Slice(tips, func(i, j int) bool {
+		if tips[i].Height != tips[j].Height {
+			return tips[i].Height > tips[j].Height
+		}
+		return bytes.Compare(tips[i].Hash[:], tips[j].Hash[:]) < 0
+	})
+	return tips
+}
@@ -365,6 +365,7 @@ func newFakeChain(params *chaincfg.Params) *BlockChain {
 		blocksPerRetarget:   int32(targetTimespan / targetTimePerBlock),
 		index:               index,
 		bestChain:           newChainView(node),
+		bestHeader:          newChainView(node),
 		warningCaches:       newThresholdCaches(vbNumBits),
 		deploymentCaches:    newThresholdCaches(chaincfg.DefinedDeployments),
 	}
@@ -382,3 +383,70 @@ func newFakeNode(parent *blockNode, blockVersion int32, bits uint32, timestamp t
 	}
 	return newBlockNode(header, parent)
 }
+
+// newTestBlock returns a block at the passed height building on the passed
+// block which only contains a coinbase paying the subsidy to an OP_TRUE script.
+// The extra nonce is included in the coinbase so blocks at the same height are
+// distinct.  The block is solved with the proof of work limit of the passed
+// parameters.
+func newTestBlock(parent *wire.MsgBlock, height int32, extraNonce int64, params *chaincfg.Params) *btcutil.Block {
+	coinbaseScript, err := txscript.NewScriptBuilder().
+		AddInt64(int64(height)).AddInt64(extraNonce).Script()
+	if err != nil {
+		panic(err)
+	}
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex),
+		Sequence:        wire.MaxTxInSequenceNum,
+		SignatureScript: coinbaseScript,
+	})
+	coinbase.AddTxOut(&wire.TxOut{
+		Value:    CalcBlockSubsidy(height, params),
+		PkScript: []byte{txscript.OP_TRUE},
+	})
+
+	block := &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:    1,
+			PrevBlock:  parent.BlockHash(),
+			MerkleRoot: coinbase.TxHash(),
+			Timestamp:  parent.Header.Timestamp.Add(time.Second),
+			Bits:       params.PowLimitBits,
+		},
+		Transactions: []*wire.MsgTx{coinbase},
+	}
+	target := CompactToBig(block.Header.Bits)
+	for {
+		hash := block.BlockHash()
+		if HashToBig(&hash).Cmp(target) <= 0 {
+			break
+		}
+		block.Header.Nonce++
+	}
+	return btcutil.NewBlock(block)
+}
+
+// newTestBlockWithTxns returns a block building on the passed parent which
+// contains the passed transactions after the coinbase.  The coinbase collects
+// the passed fees paid by the transactions.
+func newTestBlockWithTxns(parent *wire.MsgBlock, height int32, fees int64, params *chaincfg.Params, txns ...*wire.MsgTx) *btcutil.Block {
+	block := newTestBlock(parent, height, 0, params).MsgBlock()
+	block.Transactions[0].TxOut[0].Value += fees
+	for _, tx := range txns {
+		block.AddTransaction(tx)
+	}
+	merkles := BuildMerkleTreeStore(btcutil.NewBlock(block).Transactions(),
+		false)
+	block.Header.MerkleRoot = *merkles[len(merkles)-1]
+	target := CompactToBig(block.Header.Bits)
+	for {
+		hash := block.BlockHash()
+		if HashToBig(&hash).Cmp(target) <= 0 {
+			break
+		}
+		block.Header.Nonce++
+	}
+	return btcutil.NewBlock(block)
+}
@@ -5,9 +5,11 @@
 package blockchain
 
 import (
+	"fmt"
 	"math/big"
 	"time"
 
+	"github.com/vpubchain/btcd/chaincfg"
 	"github.com/vpubchain/btcd/chaincfg/chainhash"
 )
 
@@ -161,6 +163,13 @@ func (b *BlockChain) calcEasiestDifficulty(bits uint32, duration time.Duration)
 	durationVal := int64(duration / time.Second)
 	adjustmentFactor := big.NewInt(b.chainParams.RetargetAdjustmentFactor)
 
+	// The per-block retarget algorithms can lower the difficulty with
+	// every block, so any number of blocks with timestamps in the future
+	// may lower it all the way to the proof of work limit.
+	if b.chainParams.RetargetAlgorithm != chaincfg.RetargetClassic {
+		return b.chainParams.PowLimitBits
+	}
+
 	// The test network rules allow minimum difficulty blocks after more
 	// than twice the desired amount of time needed to generate a block has
 	// elapsed.
@@ -213,12 +222,229 @@ func (b *BlockChain) findPrevTestNetDifficulty(startNode *blockNode) uint32 {
 	return lastBits
 }
 
+// checkRetargetParams ensures the retarget algorithm selected by the passed
+// parameters is known and has the parameters it requires.
+func checkRetargetParams(params *chaincfg.Params) error {
+	switch params.RetargetAlgorithm {
+	case chaincfg.RetargetClassic:
+		return nil
+
+	case chaincfg.RetargetDarkGravityWave, chaincfg.RetargetLWMA:
+		if params.RetargetWindow < 2 {
+			return AssertError(fmt.Sprintf("retarget window of %d "+
+				"blocks is too small for %v",
+				params.RetargetWindow, params.RetargetAlgorithm))
+		}
+		return nil
+
+	case chaincfg.RetargetCustom:
+		if params.CustomRetarget == nil {
+			return AssertError("custom retarget algorithm selected " +
+				"without a retarget function")
+		}
+		return nil
+	}
+
+	return AssertError(fmt.Sprintf("unknown retarget algorithm %v",
+		params.RetargetAlgorithm))
+}
+
 // calcNextRequiredDifficulty calculates the required difficulty for the block
-// after the passed previous block node based on the difficulty retarget rules.
-// This function differs from the exported CalcNextRequiredDifficulty in that
-// the exported version uses the current best chain as the previous block node
-// while this function accepts any block node.
+// after the passed previous block node based on the difficulty retarget rules
+// selected by the chain parameters.  This function differs from the exported
+// CalcNextRequiredDifficulty in that the exported version uses the current best
+// chain as the previous block node while this function accepts any block node.
 func (b *BlockChain) calcNextRequiredDifficulty(lastNode *blockNode, newBlockTime time.Time) (uint32, error) {
+	params := b.chainParams
+	switch params.RetargetAlgorithm {
+	case chaincfg.RetargetClassic:
+		return b.calcClassicRequiredDifficulty(lastNode, newBlockTime)
+
+	case chaincfg.RetargetCustom:
+		if params.CustomRetarget == nil {
+			return 0, AssertError("custom retarget algorithm " +
+				"selected without a retarget function")
+		}
+
+		// Avoid passing a typed nil as the last block of the genesis
+		// block.
+		var node chaincfg.RetargetNode
+		if lastNode != nil {
+			node = retargetNode{lastNode}
+		}
+		return params.CustomRetarget(params, node, newBlockTime)
+	}
+
+	// Genesis block.
+	if lastNode == nil {
+		return params.PowLimitBits, nil
+	}
+
+	// For networks that support it, allow special reduction of the
+	// required difficulty once too much time has elapsed without mining a
+	// block.
+	if params.ReduceMinDifficulty {
+		reductionTime := int64(params.MinDiffReductionTime / time.Second)
+		if newBlockTime.Unix() > lastNode.timestamp+reductionTime {
+			return params.PowLimitBits, nil
+		}
+	}
+
+	switch params.RetargetAlgorithm {
+	case chaincfg.RetargetDarkGravityWave:
+		return b.calcDarkGravityWaveDifficulty(lastNode), nil
+	case chaincfg.RetargetLWMA:
+		return b.calcLWMADifficulty(lastNode), nil
+	}
+
+	return 0, AssertError(fmt.Sprintf("unknown retarget algorithm %v",
+		params.RetargetAlgorithm))
+}
+
+// calcDarkGravityWaveDifficulty calculates the required difficulty for the
+// block after the passed previous block node using version 3 of the Dark
+// Gravity Wave algorithm.  The average target of the last RetargetWindow blocks
+// is scaled by the ratio of the time it took to generate them to the desired
+// time, which is limited to between a third and three times the desired time.
+// The first blocks of the chain, which lack a full window, require the minimum
+// difficulty.
+func (b *BlockChain) calcDarkGravityWaveDifficulty(lastNode *blockNode) uint32 {
+	params := b.chainParams
+	pastBlocks := params.RetargetWindow
+	if lastNode.height < pastBlocks {
+		return params.PowLimitBits
+	}
+
+	// Calculate the running average of the targets of the window, which
+	// is rounded down at every step as done by the reference
+	// implementation.
+	node := lastNode
+	pastTargetAvg := new(big.Int)
+	for count := int64(1); count <= int64(pastBlocks); count++ {
+		target := CompactToBig(node.bits)
+		if count == 1 {
+			pastTargetAvg.Set(target)
+		} else {
+			pastTargetAvg.Mul(pastTargetAvg, big.NewInt(count))
+			pastTargetAvg.Add(pastTargetAvg, target)
+			pastTargetAvg.Div(pastTargetAvg, big.NewInt(count+1))
+		}
+		if count != int64(pastBlocks) {
+			node = node.parent
+		}
+	}
+
+	// Limit the timespan of the window to between a third and three times
+	// the desired timespan.
+	targetTimespan := int64(pastBlocks) *
+		int64(params.TargetTimePerBlock/time.Second)
+	actualTimespan := lastNode.timestamp - node.timestamp
+	if actualTimespan < targetTimespan/3 {
+		actualTimespan = targetTimespan / 3
+	} else if actualTimespan > targetTimespan*3 {
+		actualTimespan = targetTimespan * 3
+	}
+
+	// Calculate the new target as:
+	//  pastTargetAvg * (actualTimespan / targetTimespan)
+	newTarget := pastTargetAvg.Mul(pastTargetAvg, big.NewInt(actualTimespan))
+	newTarget.Div(newTarget, big.NewInt(targetTimespan))
+	if newTarget.Cmp(params.PowLimit) > 0 {
+		newTarget.Set(params.PowLimit)
+	}
+	return BigToCompact(newTarget)
+}
+
+// calcLWMADifficulty calculates the required difficulty for the block after the
+// passed previous block node using the linearly weighted moving average
+// algorithm.  The block times of the last RetargetWindow blocks are weighted by
+// their position within the window so recent blocks count the most, and each
+// is limited to six times the desired block time.  Block times are measured
+// against the latest previous timestamp, so out of order timestamps count as
+// one second.  The first blocks of the chain, which lack a full window, require
+// the minimum difficulty.
+func (b *BlockChain) calcLWMADifficulty(lastNode *blockNode) uint32 {
+	params := b.chainParams
+	window := int64(params.RetargetWindow)
+	if int64(lastNode.height) < window {
+		return params.PowLimitBits
+	}
+
+	// Collect the window in ascending height order along with the block
+	// before it, whose timestamp the first block time is measured from.
+	nodes := make([]*blockNode, window+1)
+	node := lastNode
+	for i := window; i >= 0; i-- {
+		nodes[i] = node
+		node = node.parent
+	}
+
+	targetSpacing := int64(params.TargetTimePerBlock / time.Second)
+	k := big.NewInt(window * (window + 1) * targetSpacing / 2)
+	divisor := new(big.Int).Mul(k, big.NewInt(window))
+	var weightedTimes int64
+	sumTarget := new(big.Int)
+	prevTimestamp := nodes[0].timestamp
+	for i := int64(1); i <= window; i++ {
+		timestamp := nodes[i].timestamp
+		if timestamp <= prevTimestamp {
+			timestamp = prevTimestamp + 1
+		}
+		solveTime := timestamp - prevTimestamp
+		if solveTime > 6*targetSpacing {
+			solveTime = 6 * targetSpacing
+		}
+		prevTimestamp = timestamp
+		weightedTimes += solveTime * i
+
+		// Each target is divided individually as done by the
+		// reference implementation.
+		target := CompactToBig(nodes[i].bits)
+		sumTarget.Add(sumTarget, target.Div(target, divisor))
+	}
+
+	newTarget := sumTarget.Mul(sumTarget, big.NewInt(weightedTimes))
+	if newTarget.Cmp(params.PowLimit) > 0 {
+		newTarget.Set(params.PowLimit)
+	}
+	return BigToCompact(newTarget)
+}
+
+// retargetNode provides custom retarget algorithms access to a block node.  It
+// implements the chaincfg.RetargetNode interface.
+type retargetNode struct {
+	node *blockNode
+}
+
+// Height returns the height of the block.
+func (n retargetNode) Height() int32 {
+	return n.node.height
+}
+
+// Timestamp returns the time of the block as a unix timestamp.
+func (n retargetNode) Timestamp() int64 {
+	return n.node.timestamp
+}
+
+// Bits returns the difficulty bits of the block.
+func (n retargetNode) Bits() uint32 {
+	return n.node.bits
+}
+
+// RelativeAncestor returns the ancestor of the block the passed number of
+// blocks before it, or nil when there is no such block.
+func (n retargetNode) RelativeAncestor(distance int32) chaincfg.RetargetNode {
+	ancestor := n.node.RelativeAncestor(distance)
+	if ancestor == nil {
+		return nil
+	}
+	return retargetNode{ancestor}
+}
+
+// calcClassicRequiredDifficulty calculates the required difficulty for the
+// block after the passed previous block node using the classic Bitcoin
+// algorithm, which retargets every TargetTimespan worth of blocks.
+func (b *BlockChain) calcClassicRequiredDifficulty(lastNode *blockNode, newBlockTime time.Time) (uint32, error) {
 	// Genesis block.
 	if lastNode == nil {
 		return b.chainParams.PowLimitBits, nil
@@ -7,6 +7,9 @@ package blockchain
 import (
 	"math/big"
 	"testing"
+	"time"
+
+	"github.com/vpubchain/btcd/chaincfg"
 )
 
 // TestBigToCompact ensures BigToCompact converts big integers to the expected
@@ -73,3 +76,204 @@ func TestCalcWork(t *testing.T) {
 		}
 	}
 }
+
+// retargetTestChain returns a fake chain with the passed parameters along with
+// the nodes of 40 blocks after its genesis block, indexed by height.  The
+// blocks have varying difficulty bits and block times, including a timestamp
+// before the one of the previous block.
+func retargetTestChain(params *chaincfg.Params) (*BlockChain, []*blockNode) {
+	steps := []int64{45, 75, -30, 120, 60, 5, 400}
+	bits := []uint32{0x1e0fffff, 0x1e07ffff, 0x1d3fffff, 0x1e00ffff}
+
+	chain := newFakeChain(params)
+	nodes := []*blockNode{chain.bestChain.Genesis()}
+	for i := 1; i <= 40; i++ {
+		parent := nodes[i-1]
+		timestamp := time.Unix(parent.timestamp+steps[i%len(steps)], 0)
+		nodes = append(nodes, newFakeNode(parent, 1, bits[i%len(bits)],
+			timestamp))
+	}
+	return chain, nodes
+}
+
+// retargetTestParams returns a copy of the Syscoin regression test network
+// parameters with a proof of work limit of 0x1e0fffff, which is low enough for
+// the difficulty of the blocks of retargetTestChain to be retargeted.
+func retargetTestParams() chaincfg.Params {
+	params := chaincfg.SysRegressionNetParams
+	params.PowLimitBits = 0x1e0fffff
+	params.PowLimit = CompactToBig(params.PowLimitBits)
+	params.ReduceMinDifficulty = false
+	return params
+}
+
+// TestCalcNextRequiredDifficulty ensures each retarget algorithm calculates
+// the expected difficulty.
+func TestCalcNextRequiredDifficulty(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		algorithm chaincfg.RetargetAlgorithm
+		window    int32
+		height    int32
+		want      uint32
+	}{
+		{"classic between retargets", chaincfg.RetargetClassic, 0, 8, 0x1e0fffff},
+		{"classic retarget 1", chaincfg.RetargetClassic, 0, 9, 0x1e099998},
+		{"classic retarget 2", chaincfg.RetargetClassic, 0, 19, 0x1e013bba},
+		{"classic retarget 3", chaincfg.RetargetClassic, 0, 29, 0x1e0a9998},
+		{"classic retarget 4", chaincfg.RetargetClassic, 0, 39, 0x1e016ccb},
+		{"dgw partial window", chaincfg.RetargetDarkGravityWave, 24, 23, 0x1e0fffff},
+		{"dgw full window", chaincfg.RetargetDarkGravityWave, 24, 24, 0x1e09d732},
+		{"dgw 30", chaincfg.RetargetDarkGravityWave, 24, 30, 0x1e08b9c1},
+		{"dgw 33", chaincfg.RetargetDarkGravityWave, 24, 33, 0x1e094285},
+		{"dgw 40", chaincfg.RetargetDarkGravityWave, 24, 40, 0x1e09b96a},
+		{"lwma partial window", chaincfg.RetargetLWMA, 20, 19, 0x1e0fffff},
+		{"lwma full window", chaincfg.RetargetLWMA, 20, 20, 0x1e0ad7aa},
+		{"lwma 23", chaincfg.RetargetLWMA, 20, 23, 0x1e094097},
+		{"lwma 24", chaincfg.RetargetLWMA, 20, 24, 0x1e093bf9},
+		{"lwma 33", chaincfg.RetargetLWMA, 20, 33, 0x1e080264},
+	}
+
+	for _, test := range tests {
+		params := retargetTestParams()
+		params.TargetTimespan = 10 * time.Minute
+		params.TargetTimePerBlock = time.Minute
+		params.RetargetAdjustmentFactor = 4
+		params.RetargetAlgorithm = test.algorithm
+		params.RetargetWindow = test.window
+		chain, nodes := retargetTestChain(&params)
+
+		lastNode := nodes[test.height]
+		newBlockTime := time.Unix(lastNode.timestamp+60, 0)
+		got, err := chain.calcNextRequiredDifficulty(lastNode,
+			newBlockTime)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: wrong difficulty - got %08x, want %08x",
+				test.name, got, test.want)
+		}
+
+		// The genesis block requires the minimum difficulty.
+		got, err = chain.calcNextRequiredDifficulty(nil, newBlockTime)
+		if err != nil || got != params.PowLimitBits {
+			t.Errorf("%s: unexpected genesis difficulty - got %08x "+
+				"%v, want %08x", test.name, got, err,
+				params.PowLimitBits)
+		}
+	}
+}
+
+// TestPerBlockMinDifficulty ensures the per-block retarget algorithms allow
+// minimum difficulty blocks once too much time has elapsed on networks which
+// support it.
+func TestPerBlockMinDifficulty(t *testing.T) {
+	t.Parallel()
+
+	params := retargetTestParams()
+	params.ReduceMinDifficulty = true
+	params.TargetTimePerBlock = time.Minute
+	params.RetargetAlgorithm = chaincfg.RetargetDarkGravityWave
+	params.RetargetWindow = 24
+	chain, nodes := retargetTestChain(&params)
+
+	lastNode := nodes[24]
+	reductionTime := int64(params.MinDiffReductionTime / time.Second)
+	got, err := chain.calcNextRequiredDifficulty(lastNode,
+		time.Unix(lastNode.timestamp+reductionTime, 0))
+	if err != nil || got != 0x1e09d732 {
+		t.Errorf("calcNextRequiredDifficulty: unexpected difficulty - "+
+			"got %08x %v, want %08x", got, err, 0x1e09d732)
+	}
+	got, err = chain.calcNextRequiredDifficulty(lastNode,
+		time.Unix(lastNode.timestamp+reductionTime+1, 0))
+	if err != nil || got != params.PowLimitBits {
+		t.Errorf("calcNextRequiredDifficulty: unexpected difficulty - "+
+			"got %08x %v, want %08x", got, err, params.PowLimitBits)
+	}
+}
+
+// TestCustomRetarget ensures custom retarget functions are passed the previous
+// block along with access to its ancestors.
+func TestCustomRetarget(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.SysRegressionNetParams
+	params.RetargetAlgorithm = chaincfg.RetargetCustom
+	params.CustomRetarget = func(p *chaincfg.Params, lastNode chaincfg.RetargetNode, newBlockTime time.Time) (uint32, error) {
+		if lastNode == nil {
+			return p.PowLimitBits, nil
+		}
+
+		// Require the bits of the block five blocks back, or of the
+		// genesis block when there is none.
+		ancestor := lastNode.RelativeAncestor(5)
+		if ancestor == nil {
+			ancestor = lastNode.RelativeAncestor(lastNode.Height())
+		}
+		return ancestor.Bits(), nil
+	}
+	chain, nodes := retargetTestChain(&params)
+
+	tests := []struct {
+		lastNode *blockNode
+		want     uint32
+	}{
+		{nil, params.PowLimitBits},
+		{nodes[3], nodes[0].bits},
+		{nodes[10], nodes[5].bits},
+		{nodes[11], nodes[6].bits},
+	}
+	for i, test := range tests {
+		got, err := chain.calcNextRequiredDifficulty(test.lastNode,
+			time.Now())
+		if err != nil || got != test.want {
+			t.Errorf("calcNextRequiredDifficulty #%d: unexpected "+
+				"difficulty - got %08x %v, want %08x", i, got, err,
+				test.want)
+		}
+	}
+}
+
+// TestCheckRetargetParams ensures retarget algorithms are only accepted along
+// with the parameters they require.
+func TestCheckRetargetParams(t *testing.T) {
+	t.Parallel()
+
+	customRetarget := func(*chaincfg.Params, chaincfg.RetargetNode, time.Time) (uint32, error) {
+		return 0, nil
+	}
+	tests := []struct {
+		name      string
+		algorithm chaincfg.RetargetAlgorithm
+		window    int32
+		custom    chaincfg.RetargetFunc
+		wantErr   bool
+	}{
+		{"classic", chaincfg.RetargetClassic, 0, nil, false},
+		{"dgw", chaincfg.RetargetDarkGravityWave, 24, nil, false},
+		{"dgw without window", chaincfg.RetargetDarkGravityWave, 0, nil, true},
+		{"lwma", chaincfg.RetargetLWMA, 45, nil, false},
+		{"lwma small window", chaincfg.RetargetLWMA, 1, nil, true},
+		{"custom", chaincfg.RetargetCustom, 0, customRetarget, false},
+		{"custom without function", chaincfg.RetargetCustom, 0, nil, true},
+		{"unknown", chaincfg.RetargetCustom + 1, 0, nil, true},
+	}
+
+	for _, test := range tests {
+		params := chaincfg.SysRegressionNetParams
+		params.RetargetAlgorithm = test.algorithm
+		params.RetargetWindow = test.window
+		params.CustomRetarget = test.custom
+		err := checkRetargetParams(&params)
+		if test.wantErr != (err != nil) {
+			t.Errorf("checkRetargetParams(%s): unexpected result - "+
+				"got %v, want error %v", test.name, err,
+				test.wantErr)
+		}
+	}
+}
@@ -220,6 +220,84 @@ const (
 	// current chain tip. This is not a block validation rule, but is required
 	// for block proposals submitted via getblocktemplate RPC.
 	ErrPrevBlockNotBest
+
+	// ErrBadAssetPayload indicates the Syscoin payload of an asset
+	// transaction could not be decoded or references its assets in an
+	// invalid way.
+	ErrBadAssetPayload
+
+	// ErrBadAssetOutputIndex indicates the Syscoin payload of a transaction
+	// assigns asset value to an output which does not exist, to the null
+	// data output of a transaction that is not a burn, or to the same
+	// output more than once.
+	ErrBadAssetOutputIndex
+
+	// ErrBadAssetValue indicates an asset value assigned to or spent by a
+	// transaction is negative or more than the max allowed value.
+	ErrBadAssetValue
+
+	// ErrBadAssetSupply indicates the precision, max supply, or supply of
+	// an asset is outside of the allowed range.
+	ErrBadAssetSupply
+
+	// ErrBadAssetUpdateFlags indicates the update flags of an asset
+	// transaction are not allowed by the transaction type or by the
+	// update capability flags of the asset.
+	ErrBadAssetUpdateFlags
+
+	// ErrAssetNotConserved indicates the value of an asset spent by the
+	// inputs of a transaction does not match the value assigned to its
+	// outputs beyond what the transaction type allows to be issued.
+	ErrAssetNotConserved
+
+	// ErrUnknownAsset indicates a transaction references an asset which
+	// has not been activated.
+	ErrUnknownAsset
+
+	// ErrAssetExists indicates an asset activation activates an asset
+	// which has already been activated.
+	ErrAssetExists
+
+	// ErrBadMintProof indicates the Ethereum transaction or receipt
+	// inclusion proof carried by a mint transaction does not verify
+	// against the roots it carries, or mints are not enabled by the chain
+	// parameters.
+	ErrBadMintProof
+
+	// ErrBadMintBurn indicates the burn proven by a mint transaction is
+	// not a burn by the ERC20 manager contract of the value and asset the
+	// mint issues.
+	ErrBadMintBurn
+
+	// ErrDuplicateMint indicates a mint transaction mints the burn of an
+	// Ethereum transaction which has already been minted.
+	ErrDuplicateMint
+
+	// ErrBadNEVMBlock indicates an NEVM block is malformed, does not hash
+	// to its NEVM block hash, holds roots other than the ones it carries,
+	// or is connected to an unknown Syscoin block or one which does not
+	// commit to it.
+	ErrBadNEVMBlock
+
+	// ErrBadNotarySig indicates an asset output of a transaction moving
+	// an asset that has a notary configured does not carry a valid
+	// signature of the notary.
+	ErrBadNotarySig
+
+	// ErrBadAuxPow indicates the auxpow of a merged mined block is missing,
+	// does not commit to the block in the coinbase of its parent block, or
+	// the parent block does not commit to that coinbase.
+	ErrBadAuxPow
+
+	// ErrBadAuxPowChainID indicates a block does not carry the chain ID of
+	// the chain in its version, or is merged mined on a chain which does
+	// not support merged mining.
+	ErrBadAuxPowChainID
+
+	// ErrPresyncMismatch indicates a block header which is downloaded
+	// again after the headers have been presynced does not match the one
+	// which was presynced.
+	ErrPresyncMismatch
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
@@ -267,6 +345,22 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrPreviousBlockUnknown:      "ErrPreviousBlockUnknown",
 	ErrInvalidAncestorBlock:      "ErrInvalidAncestorBlock",
 	ErrPrevBlockNotBest:          "ErrPrevBlockNotBest",
+	ErrBadAssetPayload:           "ErrBadAssetPayload",
+	ErrBadAssetOutputIndex:       "ErrBadAssetOutputIndex",
+	ErrBadAssetValue:             "ErrBadAssetValue",
+	ErrBadAssetSupply:            "ErrBadAssetSupply",
+	ErrBadAssetUpdateFlags:       "ErrBadAssetUpdateFlags",
+	ErrAssetNotConserved:         "ErrAssetNotConserved",
+	ErrUnknownAsset:              "ErrUnknownAsset",
+	ErrAssetExists:               "ErrAssetExists",
+	ErrBadMintProof:              "ErrBadMintProof",
+	ErrBadMintBurn:               "ErrBadMintBurn",
+	ErrDuplicateMint:             "ErrDuplicateMint",
+	ErrBadNEVMBlock:              "ErrBadNEVMBlock",
+	ErrBadNotarySig:              "ErrBadNotarySig",
+	ErrBadAuxPow:                 "ErrBadAuxPow",
+	ErrBadAuxPowChainID:          "ErrBadAuxPowChainID",
+	ErrPresyncMismatch:           "ErrPresyncMismatch",
 }
 
 // String returns the ErrorCode as a human-readable name.
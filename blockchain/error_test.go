@@ -58,6 +58,22 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrPreviousBlockUnknown, "ErrPreviousBlockUnknown"},
 		{ErrInvalidAncestorBlock, "ErrInvalidAncestorBlock"},
 		{ErrPrevBlockNotBest, "ErrPrevBlockNotBest"},
+		{ErrBadAssetPayload, "ErrBadAssetPayload"},
+		{ErrBadAssetOutputIndex, "ErrBadAssetOutputIndex"},
+		{ErrBadAssetValue, "ErrBadAssetValue"},
+		{ErrBadAssetSupply, "ErrBadAssetSupply"},
+		{ErrBadAssetUpdateFlags, "ErrBadAssetUpdateFlags"},
+		{ErrAssetNotConserved, "ErrAssetNotConserved"},
+		{ErrUnknownAsset, "ErrUnknownAsset"},
+		{ErrAssetExists, "ErrAssetExists"},
+		{ErrBadMintProof, "ErrBadMintProof"},
+		{ErrBadMintBurn, "ErrBadMintBurn"},
+		{ErrDuplicateMint, "ErrDuplicateMint"},
+		{ErrBadNEVMBlock, "ErrBadNEVMBlock"},
+		{ErrBadNotarySig, "ErrBadNotarySig"},
+		{ErrBadAuxPow, "ErrBadAuxPow"},
+		{ErrBadAuxPowChainID, "ErrBadAuxPowChainID"},
+		{ErrPresyncMismatch, "ErrPresyncMismatch"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
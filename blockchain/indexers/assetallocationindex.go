@@ -0,0 +1,631 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/blockchain"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// assetAllocationIndexName is the human-readable name for the index.
+	assetAllocationIndexName = "asset allocation index"
+
+	// assetOutPointSize is the size of a serialized outpoint used as the
+	// key of the asset utxo bucket.
+	assetOutPointSize = chainhash.HashSize + 4
+
+	// assetUtxoEntrySize is the size of a serialized asset utxo entry.
+	assetUtxoEntrySize = 8 + 8 + addrKeySize
+
+	// assetBalanceKeySize is the size of a key in the balance bucket.
+	assetBalanceKeySize = addrKeySize + 8
+
+	// assetBalanceEntrySize is the size of a serialized balance entry.
+	assetBalanceEntrySize = 8 + 4
+)
+
+var (
+	// assetAllocationIndexKey is the key of the asset allocation index and
+	// the db bucket used to house the buckets below.
+	assetAllocationIndexKey = []byte("assetallocbyaddridx")
+
+	// assetUtxoBucketName is the name of the db bucket used to house the
+	// outpoint -> asset utxo entry mapping.
+	assetUtxoBucketName = []byte("assetutxo")
+
+	// assetBalanceBucketName is the name of the db bucket used to house
+	// the address and guid -> balance mapping.
+	assetBalanceBucketName = []byte("assetbalance")
+
+	// assetAddrUtxoBucketName is the name of the db bucket used to house
+	// the address, guid and outpoint set of unspent asset outputs.
+	assetAddrUtxoBucketName = []byte("assetaddrutxo")
+
+	// assetUndoBucketName is the name of the db bucket used to house the
+	// asset utxo entries spent by each block so they can be restored when
+	// the block is disconnected.
+	assetUndoBucketName = []byte("assetundo")
+)
+
+// -----------------------------------------------------------------------------
+// The asset allocation index tracks the asset value held by every address.
+// Every Syscoin transaction assigns asset values to some of its outputs via
+// the VoutAssets of its payload.  Such an output is an asset utxo until it is
+// spent, at which point its value is removed from the balance of the address
+// it paid.
+//
+// There are four buckets nested under the index bucket.  The first maps each
+// unspent asset output to the asset and address it belongs to:
+//
+//   <outpoint> = <guid><value><addr key>
+//
+//   Field           Type              Size
+//   outpoint        wire.OutPoint     36 bytes
+//   guid            uint64            8 bytes
+//   value           int64             8 bytes
+//   addr key        [addrKeySize]byte 21 bytes
+//   -----
+//   Total: 73 bytes
+//
+// The second maps each address and asset to its aggregate balance:
+//
+//   <addr key><guid> = <balance><num utxos>
+//
+//   Field           Type              Size
+//   addr key        [addrKeySize]byte 21 bytes
+//   guid            uint64            8 bytes
+//   balance         int64             8 bytes
+//   num utxos       uint32            4 bytes
+//   -----
+//   Total: 41 bytes
+//
+// The third is a set of the unspent asset outputs of each address and asset
+// which allows them to be listed without scanning the first bucket:
+//
+//   <addr key><guid><outpoint> = <value>
+//
+// The fourth houses the asset utxo entries spent by each block in the order
+// they were spent so they can be restored on disconnect:
+//
+//   <block hash> = [<outpoint><guid><value><addr key>,...]
+//
+// All guids in keys are serialized big endian so that cursors yield them in
+// ascending order.
+// -----------------------------------------------------------------------------
+
+// assetUtxoEntry houses the details of an unspent asset output.
+type assetUtxoEntry struct {
+	guid    uint64
+	value   int64
+	addrKey [addrKeySize]byte
+}
+
+// serializeAssetOutPoint returns the passed outpoint serialized for use as a
+// key in the asset buckets.
+func serializeAssetOutPoint(op *wire.OutPoint) []byte {
+	var key [assetOutPointSize]byte
+	copy(key[:], op.Hash[:])
+	byteOrder.PutUint32(key[chainhash.HashSize:], op.Index)
+	return key[:]
+}
+
+// deserializeAssetOutPoint decodes an outpoint serialized with
+// serializeAssetOutPoint.
+func deserializeAssetOutPoint(serialized []byte) wire.OutPoint {
+	var op wire.OutPoint
+	copy(op.Hash[:], serialized[:chainhash.HashSize])
+	op.Index = byteOrder.Uint32(serialized[chainhash.HashSize:])
+	return op
+}
+
+// putAssetUtxoEntry serializes the passed entry into the target byte slice,
+// which must be at least assetUtxoEntrySize bytes.
+func putAssetUtxoEntry(target []byte, entry *assetUtxoEntry) {
+	binary.BigEndian.PutUint64(target, entry.guid)
+	byteOrder.PutUint64(target[8:], uint64(entry.value))
+	copy(target[16:], entry.addrKey[:])
+}
+
+// deserializeAssetUtxoEntry decodes an entry serialized with
+// putAssetUtxoEntry.
+func deserializeAssetUtxoEntry(serialized []byte) (*assetUtxoEntry, error) {
+	if len(serialized) < assetUtxoEntrySize {
+		return nil, errDeserialize("unexpected end of data")
+	}
+	entry := &assetUtxoEntry{
+		guid:  binary.BigEndian.Uint64(serialized),
+		value: int64(byteOrder.Uint64(serialized[8:])),
+	}
+	copy(entry.addrKey[:], serialized[16:assetUtxoEntrySize])
+	return entry, nil
+}
+
+// assetBalanceKey returns the key in the balance bucket for the passed address
+// key and guid.
+func assetBalanceKey(addrKey [addrKeySize]byte, guid uint64) []byte {
+	key := make([]byte, assetBalanceKeySize, assetBalanceKeySize+
+		assetOutPointSize)
+	copy(key, addrKey[:])
+	binary.BigEndian.PutUint64(key[addrKeySize:], guid)
+	return key
+}
+
+// dbUpdateAssetBalance uses an existing database transaction to adjust the
+// balance and number of utxos of the passed address key and guid.  The entry
+// is removed once both reach zero.
+func dbUpdateAssetBalance(dbTx database.Tx, addrKey [addrKeySize]byte,
+	guid uint64, value int64, numUtxos int32) error {
+
+	bucket := dbTx.Metadata().Bucket(assetAllocationIndexKey).
+		Bucket(assetBalanceBucketName)
+	key := assetBalanceKey(addrKey, guid)
+
+	var balance int64
+	var count uint32
+	if serialized := bucket.Get(key); serialized != nil {
+		if len(serialized) < assetBalanceEntrySize {
+			return database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt asset balance "+
+					"entry for guid %d", guid),
+			}
+		}
+		balance = int64(byteOrder.Uint64(serialized))
+		count = byteOrder.Uint32(serialized[8:])
+	}
+	balance += value
+	count = uint32(int32(count) + numUtxos)
+	if balance == 0 && count == 0 {
+		return bucket.Delete(key)
+	}
+
+	var serialized [assetBalanceEntrySize]byte
+	byteOrder.PutUint64(serialized[:], uint64(balance))
+	byteOrder.PutUint32(serialized[8:], count)
+	return bucket.Put(key, serialized[:])
+}
+
+// dbPutAssetUtxo uses an existing database transaction to add the passed
+// unspent asset output to the index and credit the address it pays.
+func dbPutAssetUtxo(dbTx database.Tx, op *wire.OutPoint, entry *assetUtxoEntry) error {
+	indexBucket := dbTx.Metadata().Bucket(assetAllocationIndexKey)
+	opKey := serializeAssetOutPoint(op)
+
+	var serialized [assetUtxoEntrySize]byte
+	putAssetUtxoEntry(serialized[:], entry)
+	err := indexBucket.Bucket(assetUtxoBucketName).Put(opKey, serialized[:])
+	if err != nil {
+		return err
+	}
+
+	var value [8]byte
+	byteOrder.PutUint64(value[:], uint64(entry.value))
+	addrUtxoKey := append(assetBalanceKey(entry.addrKey, entry.guid), opKey...)
+	err = indexBucket.Bucket(assetAddrUtxoBucketName).Put(addrUtxoKey, value[:])
+	if err != nil {
+		return err
+	}
+
+	return dbUpdateAssetBalance(dbTx, entry.addrKey, entry.guid,
+		entry.value, 1)
+}
+
+// dbRemoveAssetUtxo uses an existing database transaction to remove the asset
+// utxo for the passed outpoint from the index and debit the address it paid.
+// The removed entry is returned, or nil if the outpoint is not an unspent
+// asset output.
+func dbRemoveAssetUtxo(dbTx database.Tx, op *wire.OutPoint) (*assetUtxoEntry, error) {
+	indexBucket := dbTx.Metadata().Bucket(assetAllocationIndexKey)
+	utxoBucket := indexBucket.Bucket(assetUtxoBucketName)
+	opKey := serializeAssetOutPoint(op)
+	serialized := utxoBucket.Get(opKey)
+	if serialized == nil {
+		return nil, nil
+	}
+	entry, err := deserializeAssetUtxoEntry(serialized)
+	if err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt asset utxo entry "+
+				"for %v: %v", op, err),
+		}
+	}
+	if err := utxoBucket.Delete(opKey); err != nil {
+		return nil, err
+	}
+
+	addrUtxoKey := append(assetBalanceKey(entry.addrKey, entry.guid), opKey...)
+	err = indexBucket.Bucket(assetAddrUtxoBucketName).Delete(addrUtxoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbUpdateAssetBalance(dbTx, entry.addrKey, entry.guid,
+		-entry.value, -1)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// serializeAssetUndo returns the passed spent asset utxos serialized according
+// to the format described above.
+func serializeAssetUndo(ops []wire.OutPoint, entries []*assetUtxoEntry) []byte {
+	const recordSize = assetOutPointSize + assetUtxoEntrySize
+	serialized := make([]byte, len(ops)*recordSize)
+	for i := range ops {
+		offset := i * recordSize
+		copy(serialized[offset:], serializeAssetOutPoint(&ops[i]))
+		putAssetUtxoEntry(serialized[offset+assetOutPointSize:], entries[i])
+	}
+	return serialized
+}
+
+// deserializeAssetUndo decodes spent asset utxos serialized with
+// serializeAssetUndo into a map keyed by outpoint.
+func deserializeAssetUndo(serialized []byte) (map[wire.OutPoint]*assetUtxoEntry, error) {
+	const recordSize = assetOutPointSize + assetUtxoEntrySize
+	if len(serialized)%recordSize != 0 {
+		return nil, errDeserialize("unexpected length of asset undo data")
+	}
+
+	spent := make(map[wire.OutPoint]*assetUtxoEntry, len(serialized)/recordSize)
+	for offset := 0; offset < len(serialized); offset += recordSize {
+		op := deserializeAssetOutPoint(serialized[offset:])
+		entry, err := deserializeAssetUtxoEntry(
+			serialized[offset+assetOutPointSize:])
+		if err != nil {
+			return nil, err
+		}
+		spent[op] = entry
+	}
+	return spent, nil
+}
+
+// AssetAllocationBalance houses the aggregate balance of an asset held by an
+// address.
+type AssetAllocationBalance struct {
+	AssetGuid uint64
+	Balance   int64
+	NumUtxos  uint32
+}
+
+// AssetUtxo houses the details of an unspent output carrying asset value.
+type AssetUtxo struct {
+	OutPoint wire.OutPoint
+	Value    int64
+}
+
+// AssetAllocationIndex implements an asset balance by address index.  That is
+// to say, it tracks the unspent outputs carrying asset value along with the
+// aggregate balance of every asset held by every address.
+type AssetAllocationIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the AssetAllocationIndex type implements the Indexer interface.
+var _ Indexer = (*AssetAllocationIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *AssetAllocationIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *AssetAllocationIndex) Key() []byte {
+	return assetAllocationIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AssetAllocationIndex) Name() string {
+	return assetAllocationIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the asset
+// allocation index along with the nested buckets it uses.
+//
+// This is part of the Indexer interface.
+func (idx *AssetAllocationIndex) Create(dbTx database.Tx) error {
+	indexBucket, err := dbTx.Metadata().CreateBucket(assetAllocationIndexKey)
+	if err != nil {
+		return err
+	}
+	for _, name := range [][]byte{assetUtxoBucketName,
+		assetBalanceBucketName, assetAddrUtxoBucketName,
+		assetUndoBucketName} {
+
+		if _, err := indexBucket.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assetOutputs returns the asset utxo entries created by the passed
+// transaction keyed by output index.  Outputs that do not pay a supported
+// address, such as burns to the null data output, are not included.
+func (idx *AssetAllocationIndex) assetOutputs(tx *btcutil.Tx) map[uint32]*assetUtxoEntry {
+	msgTx := tx.MsgTx()
+	if !wire.IsSyscoinTx(msgTx.Version) {
+		return nil
+	}
+	allocation, err := msgTx.AssetAllocation()
+	if err != nil {
+		log.Debugf("Ignoring asset transaction %v: %v", tx.Hash(), err)
+		return nil
+	}
+
+	outputs := make(map[uint32]*assetUtxoEntry)
+	for _, voutAsset := range allocation.VoutAssets {
+		for _, value := range voutAsset.Values {
+			if value.N >= uint32(len(msgTx.TxOut)) {
+				continue
+			}
+			pkScript := msgTx.TxOut[value.N].PkScript
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				pkScript, idx.chainParams)
+			if err != nil || len(addrs) == 0 {
+				continue
+			}
+			addrKey, err := addrToKey(addrs[0])
+			if err != nil {
+				continue
+			}
+			outputs[value.N] = &assetUtxoEntry{
+				guid:    voutAsset.AssetGuid,
+				value:   value.ValueSat,
+				addrKey: addrKey,
+			}
+		}
+	}
+	return outputs
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer removes every asset utxo spent by
+// the passed block and adds every asset utxo it creates, adjusting the
+// balances of the affected addresses accordingly.
+//
+// This is part of the Indexer interface.
+func (idx *AssetAllocationIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []blockchain.SpentTxOut) error {
+
+	var spentOps []wire.OutPoint
+	var spentEntries []*assetUtxoEntry
+	for txIdx, tx := range block.Transactions() {
+		// Coinbases do not spend any asset utxos.
+		msgTx := tx.MsgTx()
+		if txIdx != 0 {
+			for _, txIn := range msgTx.TxIn {
+				op := txIn.PreviousOutPoint
+				entry, err := dbRemoveAssetUtxo(dbTx, &op)
+				if err != nil {
+					return err
+				}
+				if entry != nil {
+					spentOps = append(spentOps, op)
+					spentEntries = append(spentEntries, entry)
+				}
+			}
+		}
+
+		for index, entry := range idx.assetOutputs(tx) {
+			op := wire.OutPoint{Hash: *tx.Hash(), Index: index}
+			if err := dbPutAssetUtxo(dbTx, &op, entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(spentOps) == 0 {
+		return nil
+	}
+	undoBucket := dbTx.Metadata().Bucket(assetAllocationIndexKey).
+		Bucket(assetUndoBucketName)
+	return undoBucket.Put(block.Hash()[:],
+		serializeAssetUndo(spentOps, spentEntries))
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes every asset utxo
+// created by the passed block and restores every asset utxo it spent.
+//
+// This is part of the Indexer interface.
+func (idx *AssetAllocationIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []blockchain.SpentTxOut) error {
+
+	undoBucket := dbTx.Metadata().Bucket(assetAllocationIndexKey).
+		Bucket(assetUndoBucketName)
+	spent, err := deserializeAssetUndo(undoBucket.Get(block.Hash()[:]))
+	if err != nil {
+		return database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt asset undo data for "+
+				"block %v: %v", block.Hash(), err),
+		}
+	}
+
+	// Undo the transactions in reverse order so that asset utxos both
+	// created and spent within the block are handled correctly.
+	txns := block.Transactions()
+	for txIdx := len(txns) - 1; txIdx >= 0; txIdx-- {
+		tx := txns[txIdx]
+		for index := range idx.assetOutputs(tx) {
+			op := wire.OutPoint{Hash: *tx.Hash(), Index: index}
+			if _, err := dbRemoveAssetUtxo(dbTx, &op); err != nil {
+				return err
+			}
+		}
+
+		if txIdx == 0 {
+			continue
+		}
+		for _, txIn := range tx.MsgTx().TxIn {
+			entry, ok := spent[txIn.PreviousOutPoint]
+			if !ok {
+				continue
+			}
+			err := dbPutAssetUtxo(dbTx, &txIn.PreviousOutPoint, entry)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return undoBucket.Delete(block.Hash()[:])
+}
+
+// addrKey converts the passed address to the key used by the index.
+func (idx *AssetAllocationIndex) addrKey(addr btcutil.Address) ([addrKeySize]byte, error) {
+	addrKey, err := addrToKey(addr)
+	if err != nil {
+		return addrKey, fmt.Errorf("address type is not supported by "+
+			"the asset allocation index: %v", err)
+	}
+	return addrKey, nil
+}
+
+// Balance returns the aggregate balance of the asset with the provided guid
+// held by the passed address.  A zero balance is returned when the address does
+// not hold the asset.
+//
+// This function is safe for concurrent access.
+func (idx *AssetAllocationIndex) Balance(addr btcutil.Address, guid uint64) (*AssetAllocationBalance, error) {
+	addrKey, err := idx.addrKey(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &AssetAllocationBalance{AssetGuid: guid}
+	err = idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(assetAllocationIndexKey).
+			Bucket(assetBalanceBucketName)
+		serialized := bucket.Get(assetBalanceKey(addrKey, guid))
+		if serialized == nil {
+			return nil
+		}
+		if len(serialized) < assetBalanceEntrySize {
+			return database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt asset balance "+
+					"entry for guid %d", guid),
+			}
+		}
+		balance.Balance = int64(byteOrder.Uint64(serialized))
+		balance.NumUtxos = byteOrder.Uint32(serialized[8:])
+		return nil
+	})
+	return balance, err
+}
+
+// Balances returns the aggregate balance of every asset held by the passed
+// address in ascending guid order.
+//
+// This function is safe for concurrent access.
+func (idx *AssetAllocationIndex) Balances(addr btcutil.Address) ([]AssetAllocationBalance, error) {
+	addrKey, err := idx.addrKey(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var balances []AssetAllocationBalance
+	err = idx.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(assetAllocationIndexKey).
+			Bucket(assetBalanceBucketName).Cursor()
+		ok := cursor.Seek(addrKey[:])
+		for ; ok && bytes.HasPrefix(cursor.Key(), addrKey[:]); ok = cursor.Next() {
+			key, serialized := cursor.Key(), cursor.Value()
+			if len(key) < assetBalanceKeySize ||
+				len(serialized) < assetBalanceEntrySize {
+
+				return database.Error{
+					ErrorCode:   database.ErrCorruption,
+					Description: "corrupt asset balance entry",
+				}
+			}
+			balances = append(balances, AssetAllocationBalance{
+				AssetGuid: binary.BigEndian.Uint64(key[addrKeySize:]),
+				Balance:   int64(byteOrder.Uint64(serialized)),
+				NumUtxos:  byteOrder.Uint32(serialized[8:]),
+			})
+		}
+		return nil
+	})
+	return balances, err
+}
+
+// Utxos returns the unspent outputs paying the passed address which carry
+// value of the asset with the provided guid.
+//
+// This function is safe for concurrent access.
+func (idx *AssetAllocationIndex) Utxos(addr btcutil.Address, guid uint64) ([]AssetUtxo, error) {
+	addrKey, err := idx.addrKey(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var utxos []AssetUtxo
+	err = idx.db.View(func(dbTx database.Tx) error {
+		prefix := assetBalanceKey(addrKey, guid)
+		cursor := dbTx.Metadata().Bucket(assetAllocationIndexKey).
+			Bucket(assetAddrUtxoBucketName).Cursor()
+		ok := cursor.Seek(prefix)
+		for ; ok && bytes.HasPrefix(cursor.Key(), prefix); ok = cursor.Next() {
+			key, serialized := cursor.Key(), cursor.Value()
+			if len(key) < assetBalanceKeySize+assetOutPointSize ||
+				len(serialized) < 8 {
+
+				return database.Error{
+					ErrorCode:   database.ErrCorruption,
+					Description: "corrupt asset utxo set entry",
+				}
+			}
+			utxos = append(utxos, AssetUtxo{
+				OutPoint: deserializeAssetOutPoint(
+					key[assetBalanceKeySize:]),
+				Value: int64(byteOrder.Uint64(serialized)),
+			})
+		}
+		return nil
+	})
+	return utxos, err
+}
+
+// NewAssetAllocationIndex returns a new instance of an indexer that is used to
+// track the unspent asset outputs and asset balances of every address.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewAssetAllocationIndex(db database.DB, chainParams *chaincfg.Params) *AssetAllocationIndex {
+	return &AssetAllocationIndex{db: db, chainParams: chainParams}
+}
+
+// DropAssetAllocationIndex drops the asset allocation index from the provided
+// database if it exists.
+func DropAssetAllocationIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, assetAllocationIndexKey, assetAllocationIndexName,
+		interrupt)
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/database"
+	_ "github.com/vpubchain/btcd/database/ffldb"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// assetAllocationTestTx returns an allocation send transaction spending the
+// passed outpoint which assigns the passed asset values to pay-to-pubkey-hash
+// outputs paying the passed addresses.
+func assetAllocationTestTx(t *testing.T, prevOut wire.OutPoint, guid uint64,
+	addrs []btcutil.Address, values []int64) *btcutil.Tx {
+
+	tx := wire.NewMsgTx(wire.SyscoinTxVersionAllocationSend)
+	tx.AddTxIn(wire.NewTxIn(&prevOut, nil, nil))
+
+	voutAsset := wire.AssetOutType{AssetGuid: guid}
+	for i, addr := range addrs {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("PayToAddrScript: unexpected error: %v", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(1000, pkScript))
+		voutAsset.Values = append(voutAsset.Values,
+			wire.AssetOutValueType{N: uint32(i), ValueSat: values[i]})
+	}
+
+	var payload bytes.Buffer
+	allocation := wire.AssetAllocationType{
+		VoutAssets: []wire.AssetOutType{voutAsset},
+	}
+	if err := allocation.Serialize(&payload); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+	nullData, err := txscript.NullDataScript(payload.Bytes())
+	if err != nil {
+		t.Fatalf("NullDataScript: unexpected error: %v", err)
+	}
+	tx.AddTxOut(wire.NewTxOut(0, nullData))
+	return btcutil.NewTx(tx)
+}
+
+// assetAllocationTestBlock returns a block containing a dummy coinbase followed
+// by the passed transactions.
+func assetAllocationTestBlock(prevHeader *wire.BlockHeader, txns ...*btcutil.Tx) *btcutil.Block {
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		nil, nil))
+	coinbase.AddTxOut(wire.NewTxOut(0, nil))
+
+	msgBlock := wire.MsgBlock{Transactions: []*wire.MsgTx{coinbase}}
+	if prevHeader != nil {
+		msgBlock.Header.PrevBlock = prevHeader.BlockHash()
+	}
+	for _, tx := range txns {
+		msgBlock.AddTransaction(tx.MsgTx())
+	}
+	return btcutil.NewBlock(&msgBlock)
+}
+
+// TestAssetAllocationIndex ensures asset balances and utxos are updated as
+// blocks spending and creating asset outputs are connected and disconnected.
+func TestAssetAllocationIndex(t *testing.T) {
+	t.Parallel()
+
+	dbPath, err := ioutil.TempDir("", "assetallocationindex")
+	if err != nil {
+		t.Fatalf("TempDir: unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		wire.MainNet)
+	if err != nil {
+		t.Fatalf("database.Create: unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	params := &chaincfg.MainNetParams
+	idx := NewAssetAllocationIndex(db, params)
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.Create(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("Create: unexpected error: %v", err)
+	}
+
+	newAddr := func(b byte) btcutil.Address {
+		addr, err := btcutil.NewAddressPubKeyHash(
+			bytes.Repeat([]byte{b}, 20), params)
+		if err != nil {
+			t.Fatalf("NewAddressPubKeyHash: unexpected error: %v", err)
+		}
+		return addr
+	}
+	alice, bob := newAddr(0x01), newAddr(0x02)
+	const guid = 1234
+
+	checkBalance := func(desc string, addr btcutil.Address, want int64, wantUtxos uint32) {
+		t.Helper()
+		balance, err := idx.Balance(addr, guid)
+		if err != nil {
+			t.Fatalf("%s: Balance: unexpected error: %v", desc, err)
+		}
+		if balance.Balance != want || balance.NumUtxos != wantUtxos {
+			t.Fatalf("%s: wrong balance for %v - got %d (%d utxos), "+
+				"want %d (%d utxos)", desc, addr,
+				balance.Balance, balance.NumUtxos, want,
+				wantUtxos)
+		}
+		utxos, err := idx.Utxos(addr, guid)
+		if err != nil {
+			t.Fatalf("%s: Utxos: unexpected error: %v", desc, err)
+		}
+		if uint32(len(utxos)) != wantUtxos {
+			t.Fatalf("%s: wrong number of utxos for %v - got %d, "+
+				"want %d", desc, addr, len(utxos), wantUtxos)
+		}
+	}
+
+	// Block 1 sends 100 units to alice in two outputs.
+	send1 := assetAllocationTestTx(t, wire.OutPoint{Index: 7}, guid,
+		[]btcutil.Address{alice, alice}, []int64{60, 40})
+	block1 := assetAllocationTestBlock(nil, send1)
+
+	// Block 2 moves one of alice's outputs to bob and then spends bob's
+	// new output back to alice within the same block.
+	send2 := assetAllocationTestTx(t,
+		wire.OutPoint{Hash: *send1.Hash(), Index: 0}, guid,
+		[]btcutil.Address{bob}, []int64{60})
+	send3 := assetAllocationTestTx(t,
+		wire.OutPoint{Hash: *send2.Hash(), Index: 0}, guid,
+		[]btcutil.Address{bob, alice}, []int64{50, 10})
+	block2 := assetAllocationTestBlock(&block1.MsgBlock().Header, send2,
+		send3)
+
+	connect := func(block *btcutil.Block) {
+		err := db.Update(func(dbTx database.Tx) error {
+			return idx.ConnectBlock(dbTx, block, nil)
+		})
+		if err != nil {
+			t.Fatalf("ConnectBlock: unexpected error: %v", err)
+		}
+	}
+	disconnect := func(block *btcutil.Block) {
+		err := db.Update(func(dbTx database.Tx) error {
+			return idx.DisconnectBlock(dbTx, block, nil)
+		})
+		if err != nil {
+			t.Fatalf("DisconnectBlock: unexpected error: %v", err)
+		}
+	}
+
+	connect(block1)
+	checkBalance("block 1", alice, 100, 2)
+	checkBalance("block 1", bob, 0, 0)
+
+	connect(block2)
+	checkBalance("block 2", alice, 50, 2)
+	checkBalance("block 2", bob, 50, 1)
+
+	balances, err := idx.Balances(alice)
+	if err != nil {
+		t.Fatalf("Balances: unexpected error: %v", err)
+	}
+	if len(balances) != 1 || balances[0].AssetGuid != guid {
+		t.Fatalf("unexpected balances for alice: %v", balances)
+	}
+
+	disconnect(block2)
+	checkBalance("disconnect block 2", alice, 100, 2)
+	checkBalance("disconnect block 2", bob, 0, 0)
+
+	disconnect(block1)
+	checkBalance("disconnect block 1", alice, 0, 0)
+}
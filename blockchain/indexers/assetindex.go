@@ -0,0 +1,477 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/blockchain"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// assetIndexName is the human-readable name for the index.
+	assetIndexName = "asset index"
+
+	// assetStateFlags are the update flags used when serializing the
+	// stored state of an asset so that every field is included.
+	assetStateFlags = wire.ASSET_INIT | wire.ASSET_UPDATE_DATA |
+		wire.ASSET_UPDATE_CONTRACT | wire.ASSET_UPDATE_SUPPLY |
+		wire.ASSET_UPDATE_NOTARY_KEY | wire.ASSET_UPDATE_NOTARY_DETAILS |
+		wire.ASSET_UPDATE_AUXFEE | wire.ASSET_UPDATE_CAPABILITYFLAGS
+)
+
+var (
+	// assetIndexKey is the key of the asset index and the db bucket used
+	// to house it.
+	assetIndexKey = []byte("assetbyguididx")
+)
+
+// -----------------------------------------------------------------------------
+// The asset index consists of an entry for every asset that has been activated
+// in the main chain which holds the current state of the asset.  Asset updates
+// carry the previous value of every field they modify, so the state is rolled
+// back on disconnect by restoring those values rather than keeping separate
+// undo data.  The value issued by asset sends is derived from the outputs they
+// spend, which the index manager provides on both connect and disconnect.
+//
+// The serialized format for keys and values in the asset bucket is:
+//
+//   <guid> = <asset state>
+//
+//   Field           Type              Size
+//   guid            uint64            8 bytes
+//   asset state     wire.AssetType    variable
+//
+// The guid is serialized big endian, unlike the rest of the indexes, so that
+// iterating the bucket with a cursor yields assets in ascending guid order.
+// The asset state is serialized with wire.AssetType.Serialize with all update
+// flags set so every field is present.  The allocation and previous value
+// fields are always empty.
+// -----------------------------------------------------------------------------
+
+// assetIndexEntryKey returns the key for the provided asset guid.
+func assetIndexEntryKey(guid uint64) []byte {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], guid)
+	return key[:]
+}
+
+// serializeAssetState returns the passed asset state serialized according to
+// the format described above.
+func serializeAssetState(asset *wire.AssetType) ([]byte, error) {
+	state := *asset
+	state.Allocation = wire.AssetAllocationType{}
+	state.PrevContract = nil
+	state.PrevPubData = nil
+	state.PrevNotaryKeyID = nil
+	state.PrevNotaryDetails = wire.NotaryDetailsType{}
+	state.PrevAuxFeeDetails = wire.AuxFeeDetailsType{}
+	state.PrevUpdateCapabilityFlags = 0
+	state.UpdateFlags = assetStateFlags
+
+	var buf bytes.Buffer
+	if err := state.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// deserializeAssetState decodes the passed serialized asset state.
+func deserializeAssetState(serialized []byte) (*wire.AssetType, error) {
+	var asset wire.AssetType
+	if err := asset.Deserialize(bytes.NewReader(serialized)); err != nil {
+		return nil, errDeserialize(fmt.Sprintf("unable to deserialize "+
+			"asset state: %v", err))
+	}
+	return &asset, nil
+}
+
+// dbPutAssetEntry uses an existing database transaction to store the state of
+// the asset with the provided guid.
+func dbPutAssetEntry(dbTx database.Tx, guid uint64, asset *wire.AssetType) error {
+	serialized, err := serializeAssetState(asset)
+	if err != nil {
+		return err
+	}
+	assetIndex := dbTx.Metadata().Bucket(assetIndexKey)
+	return assetIndex.Put(assetIndexEntryKey(guid), serialized)
+}
+
+// dbFetchAssetEntry uses an existing database transaction to fetch the state of
+// the asset with the provided guid.  When there is no entry for the guid, nil
+// will be returned for both the asset and the error.
+func dbFetchAssetEntry(dbTx database.Tx, guid uint64) (*wire.AssetType, error) {
+	assetIndex := dbTx.Metadata().Bucket(assetIndexKey)
+	serialized := assetIndex.Get(assetIndexEntryKey(guid))
+	if serialized == nil {
+		return nil, nil
+	}
+
+	asset, err := deserializeAssetState(serialized)
+	if err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt asset index entry "+
+				"for guid %d: %v", guid, err),
+		}
+	}
+	return asset, nil
+}
+
+// dbRemoveAssetEntry uses an existing database transaction to remove the state
+// of the asset with the provided guid.
+func dbRemoveAssetEntry(dbTx database.Tx, guid uint64) error {
+	assetIndex := dbTx.Metadata().Bucket(assetIndexKey)
+	return assetIndex.Delete(assetIndexEntryKey(guid))
+}
+
+// assetTxPayload decodes the asset payload of the passed transaction along
+// with the guid of the asset it references.  False is returned when the
+// transaction does not create, update or send an asset, or when its payload can
+// not be decoded, in which case it is ignored by the index.
+func assetTxPayload(tx *btcutil.Tx) (uint64, *wire.AssetType, bool) {
+	msgTx := tx.MsgTx()
+	switch msgTx.Version {
+	case wire.SyscoinTxVersionAssetActivate,
+		wire.SyscoinTxVersionAssetUpdate,
+		wire.SyscoinTxVersionAssetSend:
+	default:
+		return 0, nil, false
+	}
+
+	payload, err := msgTx.SyscoinPayload()
+	if err != nil {
+		log.Debugf("Ignoring asset transaction %v: %v", tx.Hash(), err)
+		return 0, nil, false
+	}
+	asset := payload.(*wire.AssetType)
+	if len(asset.Allocation.VoutAssets) == 0 {
+		log.Debugf("Ignoring asset transaction %v: no asset outputs",
+			tx.Hash())
+		return 0, nil, false
+	}
+	return asset.Allocation.VoutAssets[0].AssetGuid, asset, true
+}
+
+// txSpentOutputs returns the outputs spent by each transaction of the passed
+// block according to the passed outputs spent by the block, which are ordered
+// the same way as the inputs of the transactions.
+func txSpentOutputs(block *btcutil.Block, stxos []blockchain.SpentTxOut) [][]blockchain.SpentTxOut {
+	txns := block.Transactions()
+	spent := make([][]blockchain.SpentTxOut, len(txns))
+	stxoIndex := 0
+	for txIdx, tx := range txns {
+		// Coinbases do not reference any inputs.
+		if txIdx == 0 {
+			continue
+		}
+		numInputs := len(tx.MsgTx().TxIn)
+		if stxoIndex+numInputs > len(stxos) {
+			break
+		}
+		spent[txIdx] = stxos[stxoIndex : stxoIndex+numInputs]
+		stxoIndex += numInputs
+	}
+	return spent
+}
+
+// assetSendIssued returns the value of the asset with the passed guid which is
+// issued by the passed asset send spending the passed outputs.  That is the
+// value of the asset the outputs are assigned beyond the value spent by the
+// inputs, which the consensus rules add to the total supply of the asset.
+func assetSendIssued(guid uint64, asset *wire.AssetType,
+	spent []blockchain.SpentTxOut) int64 {
+
+	var issued int64
+	for _, value := range asset.Allocation.VoutAssets[0].Values {
+		issued += value.ValueSat
+	}
+	for i := range spent {
+		if spent[i].AssetGuid == guid {
+			issued -= spent[i].AssetValue
+		}
+	}
+	return issued
+}
+
+// applyAssetUpdate modifies the passed asset state according to the fields
+// flagged in the passed update.
+func applyAssetUpdate(state, update *wire.AssetType) {
+	flags := update.UpdateFlags
+	if flags&wire.ASSET_UPDATE_DATA != 0 {
+		state.PubData = update.PubData
+	}
+	if flags&wire.ASSET_UPDATE_CONTRACT != 0 {
+		state.Contract = update.Contract
+	}
+	if flags&wire.ASSET_UPDATE_SUPPLY != 0 {
+		state.TotalSupply += update.TotalSupply
+	}
+	if flags&wire.ASSET_UPDATE_NOTARY_KEY != 0 {
+		state.NotaryKeyID = update.NotaryKeyID
+	}
+	if flags&wire.ASSET_UPDATE_NOTARY_DETAILS != 0 {
+		state.NotaryDetails = update.NotaryDetails
+	}
+	if flags&wire.ASSET_UPDATE_AUXFEE != 0 {
+		state.AuxFeeDetails = update.AuxFeeDetails
+	}
+	if flags&wire.ASSET_UPDATE_CAPABILITYFLAGS != 0 {
+		state.UpdateCapabilityFlags = update.UpdateCapabilityFlags
+	}
+}
+
+// revertAssetUpdate undoes the changes made by applyAssetUpdate for the passed
+// update by restoring the previous values it carries.
+func revertAssetUpdate(state, update *wire.AssetType) {
+	flags := update.UpdateFlags
+	if flags&wire.ASSET_UPDATE_DATA != 0 {
+		state.PubData = update.PrevPubData
+	}
+	if flags&wire.ASSET_UPDATE_CONTRACT != 0 {
+		state.Contract = update.PrevContract
+	}
+	if flags&wire.ASSET_UPDATE_SUPPLY != 0 {
+		state.TotalSupply -= update.TotalSupply
+	}
+	if flags&wire.ASSET_UPDATE_NOTARY_KEY != 0 {
+		state.NotaryKeyID = update.PrevNotaryKeyID
+	}
+	if flags&wire.ASSET_UPDATE_NOTARY_DETAILS != 0 {
+		state.NotaryDetails = update.PrevNotaryDetails
+	}
+	if flags&wire.ASSET_UPDATE_AUXFEE != 0 {
+		state.AuxFeeDetails = update.PrevAuxFeeDetails
+	}
+	if flags&wire.ASSET_UPDATE_CAPABILITYFLAGS != 0 {
+		state.UpdateCapabilityFlags = update.PrevUpdateCapabilityFlags
+	}
+}
+
+// AssetEntry houses the current state of an asset along with its guid.
+type AssetEntry struct {
+	Guid  uint64
+	Asset *wire.AssetType
+}
+
+// AssetIndex implements an asset by guid index.  That is to say, it tracks the
+// current state of every asset activated in the main chain, including the total
+// supply issued by asset updates and asset sends.
+type AssetIndex struct {
+	db database.DB
+}
+
+// Ensure the AssetIndex type implements the Indexer interface.
+var _ Indexer = (*AssetIndex)(nil)
+
+// Ensure the AssetIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*AssetIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to track the value of assets issued by asset sends.
+//
+// This implements the NeedsInputser interface.
+func (idx *AssetIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *AssetIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *AssetIndex) Key() []byte {
+	return assetIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AssetIndex) Name() string {
+	return assetIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the asset
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *AssetIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(assetIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry for every asset
+// activated in the passed block, applies every asset update to the stored
+// state and adds the value issued by every asset send to the total supply.
+//
+// This is part of the Indexer interface.
+func (idx *AssetIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []blockchain.SpentTxOut) error {
+
+	spent := txSpentOutputs(block, stxos)
+	for txIdx, tx := range block.Transactions() {
+		guid, asset, ok := assetTxPayload(tx)
+		if !ok {
+			continue
+		}
+
+		if tx.MsgTx().Version == wire.SyscoinTxVersionAssetActivate {
+			err := dbPutAssetEntry(dbTx, guid, asset)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		state, err := dbFetchAssetEntry(dbTx, guid)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			log.Debugf("Ignoring update of unknown asset %d in "+
+				"transaction %v", guid, tx.Hash())
+			continue
+		}
+		if tx.MsgTx().Version == wire.SyscoinTxVersionAssetSend {
+			issued := assetSendIssued(guid, asset, spent[txIdx])
+			if issued <= 0 {
+				continue
+			}
+			state.TotalSupply += issued
+		} else {
+			applyAssetUpdate(state, asset)
+		}
+		if err := dbPutAssetEntry(dbTx, guid, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes every asset activated
+// in the passed block and reverts every asset update and the value issued by
+// every asset send in reverse order.
+//
+// This is part of the Indexer interface.
+func (idx *AssetIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []blockchain.SpentTxOut) error {
+
+	spent := txSpentOutputs(block, stxos)
+	txns := block.Transactions()
+	for i := len(txns) - 1; i >= 0; i-- {
+		tx := txns[i]
+		guid, asset, ok := assetTxPayload(tx)
+		if !ok {
+			continue
+		}
+
+		if tx.MsgTx().Version == wire.SyscoinTxVersionAssetActivate {
+			if err := dbRemoveAssetEntry(dbTx, guid); err != nil {
+				return err
+			}
+			continue
+		}
+
+		state, err := dbFetchAssetEntry(dbTx, guid)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			continue
+		}
+		if tx.MsgTx().Version == wire.SyscoinTxVersionAssetSend {
+			issued := assetSendIssued(guid, asset, spent[i])
+			if issued <= 0 {
+				continue
+			}
+			state.TotalSupply -= issued
+		} else {
+			revertAssetUpdate(state, asset)
+		}
+		if err := dbPutAssetEntry(dbTx, guid, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Asset returns the current state of the asset with the provided guid.  When
+// there is no entry for the guid, nil will be returned for both the asset and
+// the error.
+//
+// This function is safe for concurrent access.
+func (idx *AssetIndex) Asset(guid uint64) (*wire.AssetType, error) {
+	var asset *wire.AssetType
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		asset, err = dbFetchAssetEntry(dbTx, guid)
+		return err
+	})
+	return asset, err
+}
+
+// Assets returns the current state of up to count assets in ascending guid
+// order, starting with the first asset whose guid is greater than or equal to
+// the provided guid.
+//
+// This function is safe for concurrent access.
+func (idx *AssetIndex) Assets(from uint64, count int) ([]AssetEntry, error) {
+	var entries []AssetEntry
+	err := idx.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(assetIndexKey).Cursor()
+		ok := cursor.Seek(assetIndexEntryKey(from))
+		for ; ok && len(entries) < count; ok = cursor.Next() {
+			guid := binary.BigEndian.Uint64(cursor.Key())
+			asset, err := deserializeAssetState(cursor.Value())
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt asset "+
+						"index entry for guid %d: %v",
+						guid, err),
+				}
+			}
+			entries = append(entries, AssetEntry{
+				Guid:  guid,
+				Asset: asset,
+			})
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// NewAssetIndex returns a new instance of an indexer that is used to track the
+// current state of every asset in the blockchain by its guid.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewAssetIndex(db database.DB) *AssetIndex {
+	return &AssetIndex{db: db}
+}
+
+// DropAssetIndex drops the asset index from the provided database if it
+// exists.
+func DropAssetIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, assetIndexKey, assetIndexName, interrupt)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/blockchain"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// TestAssetStateSerialization ensures the stored asset state round trips and
+// drops the allocation and previous value fields.
+func TestAssetStateSerialization(t *testing.T) {
+	t.Parallel()
+
+	asset := &wire.AssetType{
+		Allocation: wire.AssetAllocationType{
+			VoutAssets: []wire.AssetOutType{{AssetGuid: 7}},
+		},
+		Contract:      []byte{0x01, 0x02},
+		PrevContract:  []byte{0x03},
+		Symbol:        []byte("SYSX"),
+		PubData:       []byte("{}"),
+		NotaryKeyID:   []byte{0x04},
+		NotaryDetails: wire.NotaryDetailsType{EndPoint: "https://n"},
+		TotalSupply:   1000,
+		MaxSupply:     100000,
+		Precision:     8,
+		UpdateFlags:   wire.ASSET_UPDATE_CONTRACT,
+		AuxFeeDetails: wire.AuxFeeDetailsType{
+			AuxFeeKeyID: []byte{0x05},
+			AuxFees:     []wire.AuxFeesType{{Bound: 0, Percent: 10}},
+		},
+		UpdateCapabilityFlags: 127,
+	}
+
+	serialized, err := serializeAssetState(asset)
+	if err != nil {
+		t.Fatalf("serializeAssetState: unexpected error: %v", err)
+	}
+	got, err := deserializeAssetState(serialized)
+	if err != nil {
+		t.Fatalf("deserializeAssetState: unexpected error: %v", err)
+	}
+
+	want := *asset
+	want.Allocation = wire.AssetAllocationType{VoutAssets: []wire.AssetOutType{}}
+	want.PrevContract = []byte{}
+	want.PrevPubData = []byte{}
+	want.PrevNotaryKeyID = []byte{}
+	want.PrevAuxFeeDetails = wire.AuxFeeDetailsType{
+		AuxFeeKeyID: []byte{},
+		AuxFees:     []wire.AuxFeesType{},
+	}
+	want.UpdateFlags = assetStateFlags
+	if !reflect.DeepEqual(got, &want) {
+		t.Fatalf("mismatched asset state - got %v, want %v",
+			spew.Sdump(got), spew.Sdump(&want))
+	}
+}
+
+// TestAssetUpdateRevert ensures reverting an asset update restores the state
+// that existed before it was applied.
+func TestAssetUpdateRevert(t *testing.T) {
+	t.Parallel()
+
+	orig := wire.AssetType{
+		PubData:               []byte("old"),
+		Contract:              []byte{0x01},
+		NotaryKeyID:           []byte{0x02},
+		TotalSupply:           500,
+		UpdateCapabilityFlags: 127,
+	}
+	update := &wire.AssetType{
+		UpdateFlags: wire.ASSET_UPDATE_DATA | wire.ASSET_UPDATE_SUPPLY |
+			wire.ASSET_UPDATE_NOTARY_KEY |
+			wire.ASSET_UPDATE_CAPABILITYFLAGS,
+		PubData:                   []byte("new"),
+		PrevPubData:               []byte("old"),
+		TotalSupply:               250,
+		NotaryKeyID:               []byte{0x03},
+		PrevNotaryKeyID:           []byte{0x02},
+		UpdateCapabilityFlags:     1,
+		PrevUpdateCapabilityFlags: 127,
+	}
+
+	state := orig
+	applyAssetUpdate(&state, update)
+	if string(state.PubData) != "new" || state.TotalSupply != 750 ||
+		state.UpdateCapabilityFlags != 1 {
+
+		t.Fatalf("unexpected state after update: %v", spew.Sdump(state))
+	}
+
+	revertAssetUpdate(&state, update)
+	if !reflect.DeepEqual(state, orig) {
+		t.Fatalf("mismatched state after revert - got %v, want %v",
+			spew.Sdump(state), spew.Sdump(orig))
+	}
+}
+
+// TestAssetSendIssued ensures the value issued by asset sends is the value of
+// the asset they assign beyond the value of it they spend, with the spent
+// outputs of each transaction taken from those of the block.
+func TestAssetSendIssued(t *testing.T) {
+	t.Parallel()
+
+	// Build a block with a coinbase and two transactions spending one and
+	// two outputs respectively.
+	block := btcutil.NewBlock(&wire.MsgBlock{
+		Transactions: []*wire.MsgTx{
+			{TxIn: []*wire.TxIn{{}}},
+			{TxIn: []*wire.TxIn{{}}},
+			{TxIn: []*wire.TxIn{{}, {}}},
+		},
+	})
+	stxos := []blockchain.SpentTxOut{
+		{AssetGuid: 7, AssetValue: 100},
+		{AssetGuid: 7, AssetValue: 300},
+		{AssetGuid: 8, AssetValue: 500},
+	}
+	spent := txSpentOutputs(block, stxos)
+	if len(spent[0]) != 0 || len(spent[1]) != 1 || len(spent[2]) != 2 {
+		t.Fatalf("unexpected spent outputs per transaction: %v",
+			spew.Sdump(spent))
+	}
+
+	send := &wire.AssetType{
+		Allocation: wire.AssetAllocationType{
+			VoutAssets: []wire.AssetOutType{{
+				AssetGuid: 7,
+				Values: []wire.AssetOutValueType{
+					{N: 0, ValueSat: 250},
+					{N: 1, ValueSat: 150},
+				},
+			}},
+		},
+	}
+	tests := []struct {
+		name  string
+		spent []blockchain.SpentTxOut
+		want  int64
+	}{
+		{"issues beyond spent value", spent[1], 300},
+		{"ignores other assets", spent[2], 100},
+		{"issues nothing when moving", stxos[:2], 0},
+	}
+	for _, test := range tests {
+		got := assetSendIssued(7, send, test.spent)
+		if got != test.want {
+			t.Errorf("%s: got %d issued, want %d", test.name, got,
+				test.want)
+		}
+	}
+}
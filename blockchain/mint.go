@@ -0,0 +1,262 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/mpt"
+	"github.com/vpubchain/btcd/rlp"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// abiWordSize is the size of a word of the input data of an Ethereum
+	// method call and of the data of an Ethereum log.
+	abiWordSize = 32
+
+	// ethTxTypeAccessList and ethTxTypeDynamicFee are the types of the
+	// typed Ethereum transactions of EIP-2930 and EIP-1559.
+	ethTxTypeAccessList = 1
+	ethTxTypeDynamicFee = 2
+
+	// maxEthTxType is the highest type byte of a typed Ethereum
+	// transaction or receipt.  Legacy ones start with an RLP list header
+	// instead.
+	maxEthTxType = 0x7f
+)
+
+var (
+	// freezeBurnSelector is the selector of the freezeBurnERC20 method of
+	// the ERC20 manager contract which burns the value moved to Syscoin.
+	freezeBurnSelector = mpt.Keccak256(
+		[]byte("freezeBurnERC20(uint256,uint32,string)"))[:4]
+
+	// tokenFreezeTopic is the topic of the TokenFreeze event the ERC20
+	// manager contract logs for every burn.
+	tokenFreezeTopic = mpt.Keccak256(
+		[]byte("TokenFreeze(address,uint256,uint256)"))
+)
+
+// ethTxFields returns the recipient and input data of the passed encoded
+// Ethereum transaction, which is either a legacy transaction or a typed one of
+// EIP-2930 or EIP-1559.
+func ethTxFields(encoded []byte) ([]byte, []byte, error) {
+	toIndex, dataIndex := 3, 5
+	if len(encoded) > 0 && encoded[0] <= maxEthTxType {
+		switch encoded[0] {
+		case ethTxTypeAccessList:
+			toIndex, dataIndex = 4, 6
+		case ethTxTypeDynamicFee:
+			toIndex, dataIndex = 5, 7
+		default:
+			return nil, nil, fmt.Errorf("unknown transaction type %d",
+				encoded[0])
+		}
+		encoded = encoded[1:]
+	}
+
+	item, err := rlp.Decode(encoded)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !item.IsList || len(item.List) <= dataIndex {
+		return nil, nil, fmt.Errorf("transaction is not a list of at " +
+			"least its recipient and input data")
+	}
+	to, data := item.List[toIndex], item.List[dataIndex]
+	if to.IsList || data.IsList {
+		return nil, nil, fmt.Errorf("transaction recipient or input " +
+			"data is a list")
+	}
+	return to.Data, data.Data, nil
+}
+
+// receiptBurn returns the value and precisions of the TokenFreeze event the
+// ERC20 manager contract with the passed address logged according to the passed
+// encoded Ethereum receipt.  The receipt must be the one of a successful
+// transaction.
+func receiptBurn(encoded, manager []byte) (*big.Int, *big.Int, error) {
+	if len(encoded) > 0 && encoded[0] <= maxEthTxType {
+		encoded = encoded[1:]
+	}
+	item, err := rlp.Decode(encoded)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Receipts hold the status, the cumulative gas used, the bloom filter
+	// and the logs.
+	if !item.IsList || len(item.List) != 4 {
+		return nil, nil, fmt.Errorf("receipt is not a list of 4 items")
+	}
+	status, logs := item.List[0], item.List[3]
+	if status.IsList || !bytes.Equal(status.Data, []byte{0x01}) {
+		return nil, nil, fmt.Errorf("receipt is not one of a " +
+			"successful transaction")
+	}
+	if !logs.IsList {
+		return nil, nil, fmt.Errorf("receipt logs are not a list")
+	}
+
+	// Every log holds the address of the contract which logged it, the
+	// topics and the data.
+	for _, ethLog := range logs.List {
+		if !ethLog.IsList || len(ethLog.List) != 3 {
+			return nil, nil, fmt.Errorf("receipt log is not a list " +
+				"of 3 items")
+		}
+		address, topics := ethLog.List[0], ethLog.List[1]
+		data := ethLog.List[2]
+		if address.IsList || !bytes.Equal(address.Data, manager) ||
+			!topics.IsList || len(topics.List) == 0 ||
+			!bytes.Equal(topics.List[0].Data, tokenFreezeTopic) {
+
+			continue
+		}
+
+		// The data holds the freezer, the value and the precisions.
+		if data.IsList || len(data.Data) != 3*abiWordSize {
+			return nil, nil, fmt.Errorf("TokenFreeze event data is " +
+				"malformed")
+		}
+		value := new(big.Int).SetBytes(
+			data.Data[abiWordSize : 2*abiWordSize])
+		precisions := new(big.Int).SetBytes(data.Data[2*abiWordSize:])
+		return value, precisions, nil
+	}
+	return nil, nil, fmt.Errorf("receipt holds no TokenFreeze event of " +
+		"the ERC20 manager")
+}
+
+// checkMint ensures the passed mint carried by the passed transaction proves a
+// burn which issues the passed value of the asset with the passed guid and
+// stored state.
+//
+// The proofs are verified against the transaction and receipt roots carried by
+// the mint, the proven transaction must hash to the Ethereum transaction hash
+// of the mint and call the freezeBurnERC20 method of the ERC20
+// manager contract configured by the chain parameters with the value and guid,
+// and the proven receipt must hold the TokenFreeze event of the burn of the
+// value with the precision of the asset.  Each burn may only be minted once.
+//
+// NOTE: The roots are not checked against those of the NEVM block the mint
+// refers to since Syscoin blocks do not commit to NEVM blocks.
+func checkMint(tx *btcutil.Tx, mint *wire.MintSyscoinType, guid uint64, issued int64,
+	stored *AssetEntry, utxoView *UtxoViewpoint, chainParams *chaincfg.Params) error {
+
+	txHash := tx.Hash()
+	if len(chainParams.ERC20Manager) == 0 {
+		str := fmt.Sprintf("mint transaction %v is not allowed since "+
+			"no ERC20 manager is configured", txHash)
+		return ruleError(ErrBadMintProof, str)
+	}
+	if len(mint.TxHash) != chainhash.HashSize ||
+		len(mint.BlockHash) != chainhash.HashSize {
+
+		str := fmt.Sprintf("mint transaction %v carries an Ethereum "+
+			"transaction or block hash which is not a hash", txHash)
+		return ruleError(ErrBadMintProof, str)
+	}
+
+	// Each burn may only be minted once.
+	var ethTxHash chainhash.Hash
+	copy(ethTxHash[:], mint.TxHash)
+	if mintedGuid := utxoView.LookupMint(&ethTxHash); mintedGuid != 0 {
+		str := fmt.Sprintf("mint transaction %v mints the burn of "+
+			"Ethereum transaction %x which was already minted as "+
+			"asset %d", txHash, mint.TxHash, mintedGuid)
+		return ruleError(ErrDuplicateMint, str)
+	}
+
+	ethTx, receipt, err := mpt.VerifyMintProof(mint)
+	if err != nil {
+		str := fmt.Sprintf("mint transaction %v carries an invalid "+
+			"proof: %v", txHash, err)
+		return ruleError(ErrBadMintProof, str)
+	}
+	if !bytes.Equal(mpt.Keccak256(ethTx), mint.TxHash) {
+		str := fmt.Sprintf("mint transaction %v proves an Ethereum "+
+			"transaction other than %x", txHash, mint.TxHash)
+		return ruleError(ErrBadMintProof, str)
+	}
+
+	// The Ethereum transaction must burn the value of the asset with the
+	// ERC20 manager.
+	to, data, err := ethTxFields(ethTx)
+	if err != nil {
+		str := fmt.Sprintf("mint transaction %v proves a malformed "+
+			"Ethereum transaction: %v", txHash, err)
+		return ruleError(ErrBadMintBurn, str)
+	}
+	if !bytes.Equal(to, chainParams.ERC20Manager) ||
+		len(data) < len(freezeBurnSelector)+2*abiWordSize ||
+		!bytes.Equal(data[:len(freezeBurnSelector)], freezeBurnSelector) {
+
+		str := fmt.Sprintf("mint transaction %v proves an Ethereum "+
+			"transaction which is not a burn by the ERC20 manager",
+			txHash)
+		return ruleError(ErrBadMintBurn, str)
+	}
+	args := data[len(freezeBurnSelector):]
+	burnValue := new(big.Int).SetBytes(args[:abiWordSize])
+	burnGuid := new(big.Int).SetBytes(args[abiWordSize : 2*abiWordSize])
+	if !burnGuid.IsUint64() || burnGuid.Uint64() != guid {
+		str := fmt.Sprintf("mint transaction %v mints asset %d while "+
+			"the burn is of asset %v", txHash, guid, burnGuid)
+		return ruleError(ErrBadMintBurn, str)
+	}
+
+	// The receipt must hold the event of the burn, which carries the
+	// precision of the token on the NEVM chain in the upper and the one
+	// of the asset in the lower 32 bits of the precisions.
+	logValue, precisions, err := receiptBurn(receipt,
+		chainParams.ERC20Manager)
+	if err != nil {
+		str := fmt.Sprintf("mint transaction %v proves an invalid "+
+			"receipt: %v", txHash, err)
+		return ruleError(ErrBadMintBurn, str)
+	}
+	if logValue.Cmp(burnValue) != 0 || !precisions.IsUint64() {
+		str := fmt.Sprintf("mint transaction %v proves a receipt "+
+			"which does not match the burn", txHash)
+		return ruleError(ErrBadMintBurn, str)
+	}
+	tokenPrecision := precisions.Uint64() >> 32
+	assetPrecision := precisions.Uint64() & 0xffffffff
+	if assetPrecision != uint64(stored.Precision()) ||
+		tokenPrecision > math.MaxUint8 {
+
+		str := fmt.Sprintf("mint transaction %v proves a burn with "+
+			"token precision %d and asset precision %d while asset "+
+			"%d has precision %d", txHash, tokenPrecision,
+			assetPrecision, guid, stored.Precision())
+		return ruleError(ErrBadMintBurn, str)
+	}
+
+	// Convert the burned value to the precision of the asset.
+	value := new(big.Int).Set(burnValue)
+	if tokenPrecision > assetPrecision {
+		scale := new(big.Int).Exp(big.NewInt(10),
+			big.NewInt(int64(tokenPrecision-assetPrecision)), nil)
+		value.Quo(value, scale)
+	} else if tokenPrecision < assetPrecision {
+		scale := new(big.Int).Exp(big.NewInt(10),
+			big.NewInt(int64(assetPrecision-tokenPrecision)), nil)
+		value.Mul(value, scale)
+	}
+	if !value.IsInt64() || value.Int64() != issued {
+		str := fmt.Sprintf("mint transaction %v issues %d of asset %d "+
+			"while the burn is of %v", txHash, issued, guid, value)
+		return ruleError(ErrBadMintBurn, str)
+	}
+	return nil
+}
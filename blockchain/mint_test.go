@@ -0,0 +1,221 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/mpt"
+	"github.com/vpubchain/btcd/rlp"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// abiWord returns the passed value as a word of Ethereum method input or log
+// data.
+func abiWord(v int64) []byte {
+	word := make([]byte, abiWordSize)
+	big.NewInt(v).FillBytes(word)
+	return word
+}
+
+// testMintProof returns the root of a trie which only holds the passed value
+// under the key of the first transaction of a block along with a proof of it
+// and the position of the value within the proof.
+func testMintProof(t *testing.T, value []byte) ([]byte, []byte, uint16) {
+	// The key is the RLP encoding of index zero, whose nibbles 8 and 0 are
+	// hex-prefix encoded as the even length path of a leaf.
+	leaf := rlp.NewList(rlp.NewString([]byte{0x20, 0x80}),
+		rlp.NewString(value)).Bytes()
+	proof := append(rlp.AppendListHeader(nil, uint64(len(leaf))), leaf...)
+	pos := bytes.Index(proof, value)
+	if pos < 0 {
+		t.Fatal("testMintProof: value not found in proof")
+	}
+	return mpt.Keccak256(leaf), proof, uint16(pos)
+}
+
+// TestCheckMint ensures mints are only accepted when they prove a burn of the
+// value and asset they issue by the ERC20 manager against the roots they carry
+// and the burn has not been minted before.
+func TestCheckMint(t *testing.T) {
+	t.Parallel()
+
+	const guid = 1234
+	const issued = 500
+	manager := bytes.Repeat([]byte{0x11}, 20)
+	params := chaincfg.RegressionNetParams
+	params.ERC20Manager = manager
+	p2pkh := hexToBytes("76a914ee8bd501094a7d5ca318da2506de35e1cb025ddc88ac")
+
+	// The burn is of a token with 18 decimal places while the asset has 8,
+	// so the burned value is scaled accordingly.
+	burnValue := abiWord(issued * 1e10)
+	precisions := abiWord(18<<32 | 8)
+
+	// newMint returns a mint of the passed value of the passed asset
+	// proving a burn by an Ethereum transaction with the passed guid
+	// argument.
+	newMint := func(mintGuid uint64, mintValue int64, burnGuid int64) *wire.MintSyscoinType {
+		input := append([]byte(nil), freezeBurnSelector...)
+		input = append(input, burnValue...)
+		input = append(input, abiWord(burnGuid)...)
+		ethTx := rlp.NewList(rlp.NewString([]byte{0x01}),
+			rlp.NewString([]byte{0x02}), rlp.NewString([]byte{0x03}),
+			rlp.NewString(manager), rlp.NewString(nil),
+			rlp.NewString(input), rlp.NewString([]byte{0x1b}),
+			rlp.NewString(bytes.Repeat([]byte{0x04}, 32)),
+			rlp.NewString(bytes.Repeat([]byte{0x05}, 32))).Bytes()
+
+		logData := append(abiWord(0), burnValue...)
+		logData = append(logData, precisions...)
+		receipt := rlp.NewList(rlp.NewString([]byte{0x01}),
+			rlp.NewString([]byte{0x5a, 0x08}),
+			rlp.NewString(make([]byte, 256)),
+			rlp.NewList(rlp.NewList(rlp.NewString(manager),
+				rlp.NewList(rlp.NewString(tokenFreezeTopic)),
+				rlp.NewString(logData)))).Bytes()
+
+		txRoot, txProof, txPos := testMintProof(t, ethTx)
+		receiptRoot, receiptProof, receiptPos := testMintProof(t,
+			receipt)
+		return &wire.MintSyscoinType{
+			Allocation: wire.AssetAllocationType{
+				VoutAssets: []wire.AssetOutType{{
+					AssetGuid: mintGuid,
+					Values: []wire.AssetOutValueType{
+						{N: 0, ValueSat: mintValue},
+					},
+				}},
+			},
+			TxHash:             mpt.Keccak256(ethTx),
+			BlockHash:          bytes.Repeat([]byte{0x06}, 32),
+			TxPos:              txPos,
+			TxParentNodes:      txProof,
+			TxPath:             []byte{0x80},
+			TxRoot:             txRoot,
+			ReceiptRoot:        receiptRoot,
+			ReceiptPos:         receiptPos,
+			ReceiptParentNodes: receiptProof,
+		}
+	}
+
+	// mintTx returns a mint transaction carrying the passed mint.
+	mintTx := func(mint *wire.MintSyscoinType) *btcutil.Tx {
+		// Mint payloads exceed the max script element size, so the
+		// data is pushed without enforcing it.
+		var buf bytes.Buffer
+		if err := mint.Serialize(&buf); err != nil {
+			t.Fatalf("Serialize: unexpected error: %v", err)
+		}
+		script, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_RETURN).AddFullData(buf.Bytes()).Script()
+		if err != nil {
+			t.Fatalf("Script: unexpected error: %v", err)
+		}
+		tx := wire.NewMsgTx(wire.SyscoinTxVersionAllocationMint)
+		tx.AddTxOut(wire.NewTxOut(500, p2pkh))
+		tx.AddTxOut(wire.NewTxOut(0, script))
+		return btcutil.NewTx(tx)
+	}
+
+	// newView returns a view holding the state of the minted assets.
+	newView := func() *UtxoViewpoint {
+		view := NewUtxoViewpoint()
+		for _, assetGuid := range []uint64{guid, guid + 1} {
+			view.assets[assetGuid] = &AssetEntry{
+				precision:       8,
+				capabilityFlags: assetCapabilityFlagsMask,
+				maxSupply:       MaxAssetValue,
+			}
+		}
+		return view
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(tx *btcutil.Tx) (*UtxoViewpoint, *chaincfg.Params)
+		tx     func() *btcutil.Tx
+		code   ErrorCode
+		valid  bool
+	}{
+		{
+			name: "valid mint",
+			tx: func() *btcutil.Tx {
+				return mintTx(newMint(guid, issued, guid))
+			},
+			valid: true,
+		},
+		{
+			name: "no ERC20 manager",
+			tx: func() *btcutil.Tx {
+				return mintTx(newMint(guid, issued, guid))
+			},
+			mutate: func(tx *btcutil.Tx) (*UtxoViewpoint, *chaincfg.Params) {
+				return newView(), &chaincfg.RegressionNetParams
+			},
+			code: ErrBadMintProof,
+		},
+		{
+			name: "roots other than proven",
+			tx: func() *btcutil.Tx {
+				mint := newMint(guid, issued, guid)
+				mint.TxRoot = bytes.Repeat([]byte{0x07}, 32)
+				return mintTx(mint)
+			},
+			code: ErrBadMintProof,
+		},
+		{
+			name: "burn already minted",
+			tx: func() *btcutil.Tx {
+				return mintTx(newMint(guid, issued, guid))
+			},
+			mutate: func(tx *btcutil.Tx) (*UtxoViewpoint, *chaincfg.Params) {
+				view := newView()
+				view.UpdateAssets(tx)
+				return view, &params
+			},
+			code: ErrDuplicateMint,
+		},
+		{
+			name: "value other than burned",
+			tx: func() *btcutil.Tx {
+				return mintTx(newMint(guid, issued+1, guid))
+			},
+			code: ErrBadMintBurn,
+		},
+		{
+			name: "asset other than burned",
+			tx: func() *btcutil.Tx {
+				return mintTx(newMint(guid+1, issued, guid))
+			},
+			code: ErrBadMintBurn,
+		},
+	}
+
+	for _, test := range tests {
+		tx := test.tx()
+		view, chainParams := newView(), &params
+		if test.mutate != nil {
+			view, chainParams = test.mutate(tx)
+		}
+		err := checkAssetInputs(tx, view, chainParams)
+		if test.valid {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != test.code {
+			t.Errorf("%s: did not get expected error %v - got %v",
+				test.name, test.code, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+)
+
+const (
+	// muHashSize is the size of a serialized muHash.
+	muHashSize = 384
+)
+
+// muHashPrime is the prime modulus the elements of a muHash are multiplied
+// with, the largest prime below 2^3072.
+var muHashPrime = func() *big.Int {
+	p := new(big.Int).Lsh(big.NewInt(1), muHashSize*8)
+	return p.Sub(p, big.NewInt(1103717))
+}()
+
+// muHash is a rolling hash of a set of byte strings which is independent of the
+// order they are added in.  Each element is mapped to a number modulo a 3072
+// bit prime, the set is represented by the product of the numbers of its
+// elements, and elements are removed by dividing by their number.  Divisions
+// are accumulated separately and only carried out when the hash is
+// serialized, since they are much more expensive than multiplications.
+type muHash struct {
+	numerator   *big.Int
+	denominator *big.Int
+}
+
+// newMuHash returns a muHash of the empty set.
+func newMuHash() *muHash {
+	return &muHash{numerator: big.NewInt(1), denominator: big.NewInt(1)}
+}
+
+// muHashElement maps the passed byte string to a number modulo the prime by
+// expanding its sha256 hash to 3072 bits.
+func muHashElement(data []byte) *big.Int {
+	seed := sha256.Sum256(data)
+	var buf [chainhash.HashSize + 1]byte
+	copy(buf[:], seed[:])
+	var expanded [muHashSize]byte
+	for i := 0; i < muHashSize/sha256.Size; i++ {
+		buf[chainhash.HashSize] = byte(i)
+		block := sha256.Sum256(buf[:])
+		copy(expanded[i*sha256.Size:], block[:])
+	}
+	n := new(big.Int).SetBytes(expanded[:])
+	return n.Mod(n, muHashPrime)
+}
+
+// add adds the passed byte string to the set.
+func (h *muHash) add(data []byte) {
+	h.numerator.Mul(h.numerator, muHashElement(data))
+	h.numerator.Mod(h.numerator, muHashPrime)
+}
+
+// remove removes the passed byte string from the set.  It must have been added
+// before.
+func (h *muHash) remove(data []byte) {
+	h.denominator.Mul(h.denominator, muHashElement(data))
+	h.denominator.Mod(h.denominator, muHashPrime)
+}
+
+// normalize carries out the accumulated divisions.
+func (h *muHash) normalize() {
+	if h.denominator.Cmp(big.NewInt(1)) == 0 {
+		return
+	}
+	inverse := new(big.Int).ModInverse(h.denominator, muHashPrime)
+	h.numerator.Mul(h.numerator, inverse)
+	h.numerator.Mod(h.numerator, muHashPrime)
+	h.denominator.SetInt64(1)
+}
+
+// serialize returns the serialization of the muHash, the big-endian encoding
+// of the product of its elements.
+func (h *muHash) serialize() []byte {
+	h.normalize()
+	serialized := make([]byte, muHashSize)
+	numerator := h.numerator.Bytes()
+	copy(serialized[muHashSize-len(numerator):], numerator)
+	return serialized
+}
+
+// deserializeMuHash decodes a muHash from the passed serialization.
+func deserializeMuHash(serialized []byte) *muHash {
+	h := newMuHash()
+	h.numerator.SetBytes(serialized)
+	return h
+}
+
+// hash returns the hash of the set.
+func (h *muHash) hash() chainhash.Hash {
+	return chainhash.Hash(sha256.Sum256(h.serialize()))
+}
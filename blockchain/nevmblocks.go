@@ -0,0 +1,326 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/mpt"
+	"github.com/vpubchain/btcd/rlp"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// nevmHeaderTxRootIndex and nevmHeaderReceiptRootIndex are the indices
+	// of the transaction and receipt roots among the fields of an NEVM
+	// block header.
+	nevmHeaderTxRootIndex      = 4
+	nevmHeaderReceiptRootIndex = 5
+
+	// nevmCommitmentLen is the length of the commitment to an NEVM block
+	// made of its NEVM block hash followed by its transaction and receipt
+	// roots.
+	nevmCommitmentLen = 3 * chainhash.HashSize
+)
+
+var (
+	// nevmBlockBucketName is the name of the db bucket used to house the
+	// NEVM blocks connected to Syscoin blocks keyed by the hash of the
+	// Syscoin block.
+	nevmBlockBucketName = []byte("nevmblocks")
+
+	// nevmCommitmentMagicBytes is the prefix marker within the public key
+	// script of a coinbase output to indicate that this output holds the
+	// commitment to the NEVM block connected to the block.  It is made of
+	// an OP_RETURN, the push of the marker and the commitment, and the
+	// marker itself.
+	nevmCommitmentMagicBytes = []byte{
+		txscript.OP_RETURN,
+		txscript.OP_PUSHDATA1,
+		4 + nevmCommitmentLen,
+		'n',
+		'e',
+		'v',
+		'm',
+	}
+)
+
+// -----------------------------------------------------------------------------
+// The NEVM block bucket houses the NEVM block connected to each Syscoin block
+// for which one was received and matched the commitment of the Syscoin block.
+//
+// The serialized key format is:
+//
+//   <Syscoin block hash>
+//
+//   Field                 Type             Size
+//   Syscoin block hash    chainhash.Hash   chainhash.HashSize
+//
+// The serialized value format is the serialization of wire.NEVMBlockWire.
+// Once a block is buried deeper than the configured prune depth, the NEVM
+// block data is dropped from the value while its hash and roots remain.
+// -----------------------------------------------------------------------------
+
+// dbPutNEVMBlock uses an existing database transaction to store the passed
+// NEVM block keyed by the hash of the Syscoin block it is connected to.
+func dbPutNEVMBlock(dbTx database.Tx, nevmBlock *wire.NEVMBlockWire) error {
+	var buf bytes.Buffer
+	if err := nevmBlock.Serialize(&buf); err != nil {
+		return err
+	}
+	bucket := dbTx.Metadata().Bucket(nevmBlockBucketName)
+	return bucket.Put(nevmBlock.SYSBlockHash, buf.Bytes())
+}
+
+// dbFetchNEVMBlock uses an existing database transaction to fetch the NEVM
+// block connected to the Syscoin block with the passed hash.  It returns nil
+// when no NEVM block is stored for the block.
+func dbFetchNEVMBlock(dbTx database.Tx, hash *chainhash.Hash) (*wire.NEVMBlockWire, error) {
+	bucket := dbTx.Metadata().Bucket(nevmBlockBucketName)
+	serialized := bucket.Get(hash[:])
+	if serialized == nil {
+		return nil, nil
+	}
+
+	var nevmBlock wire.NEVMBlockWire
+	err := nevmBlock.Deserialize(bytes.NewReader(serialized))
+	if err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt NEVM block for "+
+				"block %v: %v", hash, err),
+		}
+	}
+	return &nevmBlock, nil
+}
+
+// dbPruneNEVMBlockData uses an existing database transaction to drop the data
+// of the NEVM block connected to the Syscoin block with the passed hash while
+// keeping its hash and roots.  Nothing is done when no NEVM block is stored
+// for the block or its data has already been pruned.
+func dbPruneNEVMBlockData(dbTx database.Tx, hash *chainhash.Hash) error {
+	nevmBlock, err := dbFetchNEVMBlock(dbTx, hash)
+	if err != nil || nevmBlock == nil || len(nevmBlock.NEVMBlockData) == 0 {
+		return err
+	}
+	nevmBlock.NEVMBlockData = nil
+	return dbPutNEVMBlock(dbTx, nevmBlock)
+}
+
+// checkNEVMBlock ensures the passed NEVM block is internally consistent.  The
+// hashes must be the size of a hash and, unless the data has been pruned, the
+// data must be an RLP list whose first element is a block header that hashes
+// to the NEVM block hash and holds the transaction and receipt roots.
+//
+// NOTE: The NEVM block hash itself is not committed to by the Syscoin block,
+// so this does not prove the NEVM block belongs to the Syscoin block.
+func checkNEVMBlock(nevmBlock *wire.NEVMBlockWire) error {
+	hashes := []struct {
+		name string
+		hash []byte
+	}{
+		{"NEVM block hash", nevmBlock.NEVMBlockHash},
+		{"transaction root", nevmBlock.TxRoot},
+		{"receipt root", nevmBlock.ReceiptRoot},
+		{"Syscoin block hash", nevmBlock.SYSBlockHash},
+	}
+	for _, h := range hashes {
+		if len(h.hash) != chainhash.HashSize {
+			str := fmt.Sprintf("%s of NEVM block is %d bytes "+
+				"instead of %d", h.name, len(h.hash),
+				chainhash.HashSize)
+			return ruleError(ErrBadNEVMBlock, str)
+		}
+	}
+	if len(nevmBlock.NEVMBlockData) == 0 {
+		return nil
+	}
+
+	// Locate the encoded header at the start of the block.
+	kind, content, rest, err := rlp.Split(nevmBlock.NEVMBlockData)
+	if err == nil && (kind != rlp.List || len(rest) != 0) {
+		err = fmt.Errorf("data is not a single list")
+	}
+	var header, fields []byte
+	if err == nil {
+		kind, fields, rest, err = rlp.Split(content)
+		header = content[:len(content)-len(rest)]
+		if err == nil && kind != rlp.List {
+			err = fmt.Errorf("header is not a list")
+		}
+	}
+	if err != nil {
+		str := fmt.Sprintf("NEVM block %x is malformed: %v",
+			nevmBlock.NEVMBlockHash, err)
+		return ruleError(ErrBadNEVMBlock, str)
+	}
+
+	if !bytes.Equal(mpt.Keccak256(header), nevmBlock.NEVMBlockHash) {
+		str := fmt.Sprintf("NEVM block header hashes to %x instead "+
+			"of %x", mpt.Keccak256(header), nevmBlock.NEVMBlockHash)
+		return ruleError(ErrBadNEVMBlock, str)
+	}
+
+	// Ensure the roots match the ones held by the header.
+	for i := 0; i <= nevmHeaderReceiptRootIndex; i++ {
+		var field []byte
+		kind, field, fields, err = rlp.Split(fields)
+		if err != nil || kind == rlp.List {
+			str := fmt.Sprintf("NEVM block %x header field %d is "+
+				"malformed", nevmBlock.NEVMBlockHash, i)
+			return ruleError(ErrBadNEVMBlock, str)
+		}
+
+		var root []byte
+		var name string
+		switch i {
+		case nevmHeaderTxRootIndex:
+			root, name = nevmBlock.TxRoot, "transaction"
+		case nevmHeaderReceiptRootIndex:
+			root, name = nevmBlock.ReceiptRoot, "receipt"
+		default:
+			continue
+		}
+		if !bytes.Equal(field, root) {
+			str := fmt.Sprintf("NEVM block %x header holds %s "+
+				"root %x instead of %x", nevmBlock.NEVMBlockHash,
+				name, field, root)
+			return ruleError(ErrBadNEVMBlock, str)
+		}
+	}
+	return nil
+}
+
+// extractNEVMCommitment attempts to locate and return the commitment to an
+// NEVM block within the passed coinbase transaction along with a boolean
+// indicating if it was located.  As with the witness commitment, the last
+// output holding a commitment is the one which counts.
+func extractNEVMCommitment(tx *btcutil.Tx) ([]byte, bool) {
+	if !IsCoinBase(tx) {
+		return nil, false
+	}
+
+	msgTx := tx.MsgTx()
+	for i := len(msgTx.TxOut) - 1; i >= 0; i-- {
+		pkScript := msgTx.TxOut[i].PkScript
+		if len(pkScript) == len(nevmCommitmentMagicBytes)+nevmCommitmentLen &&
+			bytes.HasPrefix(pkScript, nevmCommitmentMagicBytes) {
+
+			return pkScript[len(nevmCommitmentMagicBytes):], true
+		}
+	}
+	return nil, false
+}
+
+// checkNEVMCommitment ensures the passed block commits to the NEVM block hash
+// and roots of the passed NEVM block.
+func checkNEVMCommitment(block *btcutil.Block, nevmBlock *wire.NEVMBlockWire) error {
+	commitment, ok := extractNEVMCommitment(block.Transactions()[0])
+	if !ok {
+		str := fmt.Sprintf("block %v does not commit to an NEVM block",
+			block.Hash())
+		return ruleError(ErrBadNEVMBlock, str)
+	}
+
+	want := make([]byte, 0, nevmCommitmentLen)
+	want = append(want, nevmBlock.NEVMBlockHash...)
+	want = append(want, nevmBlock.TxRoot...)
+	want = append(want, nevmBlock.ReceiptRoot...)
+	if !bytes.Equal(commitment, want) {
+		str := fmt.Sprintf("NEVM block %x is not the one block %v "+
+			"commits to", nevmBlock.NEVMBlockHash, block.Hash())
+		return ruleError(ErrBadNEVMBlock, str)
+	}
+	return nil
+}
+
+// StoreNEVMBlock stores the passed NEVM block keyed by the hash of the Syscoin
+// block it is connected to after ensuring it is internally consistent and the
+// one the Syscoin block commits to.  The data of the Syscoin block must be
+// known.  An NEVM block already stored for the Syscoin block is kept as is
+// unless it was stored without its data, and the data of the NEVM block is
+// pruned right away when the Syscoin block is already deeper in the main chain
+// than the prune depth.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) StoreNEVMBlock(nevmBlock *wire.NEVMBlockWire) error {
+	if err := checkNEVMBlock(nevmBlock); err != nil {
+		return err
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], nevmBlock.SYSBlockHash)
+	node := b.index.LookupNode(&hash)
+	if node == nil || !b.index.NodeStatus(node).HaveData() {
+		str := fmt.Sprintf("NEVM block %x is connected to unknown "+
+			"block %v", nevmBlock.NEVMBlockHash, hash)
+		return ruleError(ErrBadNEVMBlock, str)
+	}
+
+	b.chainLock.RLock()
+	tip := b.bestChain.Tip()
+	prune := b.nevmPruneDepth > 0 && b.bestChain.Contains(node) &&
+		tip.height-node.height >= b.nevmPruneDepth
+	b.chainLock.RUnlock()
+
+	return b.db.Update(func(dbTx database.Tx) error {
+		block, err := dbFetchBlockByNode(dbTx, node)
+		if err != nil {
+			return err
+		}
+		if err := checkNEVMCommitment(block, nevmBlock); err != nil {
+			return err
+		}
+
+		// Only the data of an NEVM block stored without it may be
+		// added, unless it would be pruned right away.
+		existing, err := dbFetchNEVMBlock(dbTx, &hash)
+		if err != nil {
+			return err
+		}
+		if existing != nil && (prune || len(existing.NEVMBlockData) != 0 ||
+			len(nevmBlock.NEVMBlockData) == 0) {
+
+			return nil
+		}
+		if prune {
+			pruned := *nevmBlock
+			pruned.NEVMBlockData = nil
+			nevmBlock = &pruned
+		}
+		return dbPutNEVMBlock(dbTx, nevmBlock)
+	})
+}
+
+// FetchNEVMBlock returns the NEVM block connected to the Syscoin block with
+// the passed hash.  It returns nil when no NEVM block is stored for the block.
+// The data of the returned NEVM block is empty when it has been pruned.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchNEVMBlock(hash *chainhash.Hash) (*wire.NEVMBlockWire, error) {
+	var nevmBlock *wire.NEVMBlockWire
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		nevmBlock, err = dbFetchNEVMBlock(dbTx, hash)
+		return err
+	})
+	return nevmBlock, err
+}
+
+// pruneNEVMBlockData uses an existing database transaction to prune the data
+// of the NEVM block connected to the main chain block that becomes buried at
+// the prune depth once the passed node is connected.
+func (b *BlockChain) pruneNEVMBlockData(dbTx database.Tx, node *blockNode) error {
+	if b.nevmPruneDepth <= 0 || node.height < b.nevmPruneDepth {
+		return nil
+	}
+	buried := node.Ancestor(node.height - b.nevmPruneDepth)
+	return dbPruneNEVMBlockData(dbTx, &buried.hash)
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/mpt"
+	"github.com/vpubchain/btcd/rlp"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// testNEVMBlock returns an NEVM block connected to the Syscoin block with the
+// passed hash whose data is a block with a header holding the passed roots.
+func testNEVMBlock(sysHash, txRoot, receiptRoot []byte) *wire.NEVMBlockWire {
+	hash := func(b byte) *rlp.Item {
+		return rlp.NewString(bytes.Repeat([]byte{b}, 32))
+	}
+	header := rlp.NewList(hash(0x01), hash(0x02),
+		rlp.NewString(bytes.Repeat([]byte{0x03}, 20)), hash(0x04),
+		rlp.NewString(txRoot), rlp.NewString(receiptRoot),
+		rlp.NewString([]byte{0x05}))
+	block := rlp.NewList(header, rlp.NewList(), rlp.NewList())
+	return &wire.NEVMBlockWire{
+		NEVMBlockHash: mpt.Keccak256(header.Bytes()),
+		TxRoot:        txRoot,
+		ReceiptRoot:   receiptRoot,
+		NEVMBlockData: block.Bytes(),
+		SYSBlockHash:  sysHash,
+	}
+}
+
+// TestCheckNEVMBlock ensures NEVM blocks are only accepted when their data
+// hashes to the NEVM block hash and holds the roots they carry.
+func TestCheckNEVMBlock(t *testing.T) {
+	t.Parallel()
+
+	sysHash := bytes.Repeat([]byte{0xaa}, 32)
+	txRoot := bytes.Repeat([]byte{0xbb}, 32)
+	receiptRoot := bytes.Repeat([]byte{0xcc}, 32)
+
+	tests := []struct {
+		name    string
+		mutate  func(nb *wire.NEVMBlockWire)
+		wantErr bool
+	}{
+		{"valid", func(nb *wire.NEVMBlockWire) {}, false},
+		{"pruned data", func(nb *wire.NEVMBlockWire) {
+			nb.NEVMBlockData = nil
+		}, false},
+		{"short Syscoin block hash", func(nb *wire.NEVMBlockWire) {
+			nb.SYSBlockHash = nb.SYSBlockHash[:31]
+		}, true},
+		{"wrong NEVM block hash", func(nb *wire.NEVMBlockWire) {
+			nb.NEVMBlockHash = bytes.Repeat([]byte{0xdd}, 32)
+		}, true},
+		{"wrong transaction root", func(nb *wire.NEVMBlockWire) {
+			nb.TxRoot = receiptRoot
+		}, true},
+		{"wrong receipt root", func(nb *wire.NEVMBlockWire) {
+			nb.ReceiptRoot = txRoot
+		}, true},
+		{"data not a list", func(nb *wire.NEVMBlockWire) {
+			nb.NEVMBlockData = rlp.NewString([]byte("dog")).Bytes()
+		}, true},
+		{"trailing data", func(nb *wire.NEVMBlockWire) {
+			nb.NEVMBlockData = append(nb.NEVMBlockData, 0x00)
+		}, true},
+		{"truncated data", func(nb *wire.NEVMBlockWire) {
+			nb.NEVMBlockData = nb.NEVMBlockData[:10]
+		}, true},
+	}
+
+	for _, test := range tests {
+		nb := testNEVMBlock(sysHash, txRoot, receiptRoot)
+		test.mutate(nb)
+		err := checkNEVMBlock(nb)
+		if !test.wantErr {
+			if err != nil {
+				t.Errorf("checkNEVMBlock(%s): unexpected error: %v",
+					test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != ErrBadNEVMBlock {
+			t.Errorf("checkNEVMBlock(%s): did not get expected "+
+				"error %v - got %v", test.name, ErrBadNEVMBlock, err)
+		}
+	}
+}
+
+// TestNEVMBlockStore ensures NEVM blocks are only stored for known blocks which
+// commit to them, only gain their data once stored and keep their hash and
+// roots when pruned.
+func TestNEVMBlockStore(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	chain, teardownFunc, err := chainSetup("nevmblocks", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	txRoot := bytes.Repeat([]byte{0xbb}, 32)
+	receiptRoot := bytes.Repeat([]byte{0xcc}, 32)
+	want := testNEVMBlock(nil, txRoot, receiptRoot)
+
+	// Connect a block whose coinbase commits to the NEVM block.
+	msgBlock := newTestBlock(params.GenesisBlock, 1, 0, params).MsgBlock()
+	commitment := append([]byte(nil), nevmCommitmentMagicBytes...)
+	commitment = append(commitment, want.NEVMBlockHash...)
+	commitment = append(commitment, txRoot...)
+	commitment = append(commitment, receiptRoot...)
+	msgBlock.Transactions[0].AddTxOut(wire.NewTxOut(0, commitment))
+	msgBlock.Header.MerkleRoot = msgBlock.Transactions[0].TxHash()
+	target := CompactToBig(msgBlock.Header.Bits)
+	for {
+		hash := msgBlock.BlockHash()
+		if HashToBig(&hash).Cmp(target) <= 0 {
+			break
+		}
+		msgBlock.Header.Nonce++
+	}
+	block := btcutil.NewBlock(msgBlock)
+	if _, _, err := chain.ProcessBlock(block, BFNone); err != nil {
+		t.Fatalf("ProcessBlock: unexpected error: %v", err)
+	}
+	hash := block.Hash()
+	want.SYSBlockHash = hash[:]
+
+	// Nothing is stored for the block yet.
+	nb, err := chain.FetchNEVMBlock(hash)
+	if err != nil || nb != nil {
+		t.Fatalf("FetchNEVMBlock: unexpected result - got %v %v, "+
+			"want nil", nb, err)
+	}
+
+	// NEVM blocks connected to unknown blocks, to blocks without a
+	// commitment or other than the committed one are rejected.
+	rejected := []struct {
+		name      string
+		nevmBlock *wire.NEVMBlockWire
+	}{
+		{"unknown block", testNEVMBlock(bytes.Repeat([]byte{0xaa}, 32),
+			txRoot, receiptRoot)},
+		{"no commitment", testNEVMBlock(params.GenesisHash[:], txRoot,
+			receiptRoot)},
+		{"other NEVM block", testNEVMBlock(hash[:], receiptRoot,
+			txRoot)},
+	}
+	for _, test := range rejected {
+		err := chain.StoreNEVMBlock(test.nevmBlock)
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != ErrBadNEVMBlock {
+			t.Fatalf("StoreNEVMBlock(%s): did not get expected "+
+				"error %v - got %v", test.name, ErrBadNEVMBlock,
+				err)
+		}
+	}
+
+	checkStored := func(desc string, want *wire.NEVMBlockWire) {
+		t.Helper()
+		nb, err := chain.FetchNEVMBlock(hash)
+		if err != nil || nb == nil {
+			t.Fatalf("%s: unexpected result - got %v %v", desc, nb,
+				err)
+		}
+		var buf, wantBuf bytes.Buffer
+		if err := nb.Serialize(&buf); err != nil {
+			t.Fatalf("%s: Serialize: unexpected error: %v", desc, err)
+		}
+		if err := want.Serialize(&wantBuf); err != nil {
+			t.Fatalf("%s: Serialize: unexpected error: %v", desc, err)
+		}
+		if !bytes.Equal(buf.Bytes(), wantBuf.Bytes()) {
+			t.Fatalf("%s: unexpected NEVM block - got %x, want %x",
+				desc, buf.Bytes(), wantBuf.Bytes())
+		}
+	}
+	store := func(desc string, nevmBlock *wire.NEVMBlockWire) {
+		t.Helper()
+		if err := chain.StoreNEVMBlock(nevmBlock); err != nil {
+			t.Fatalf("%s: StoreNEVMBlock: unexpected error: %v",
+				desc, err)
+		}
+	}
+
+	// An NEVM block stored without its data gains it once received with
+	// it, and keeps it afterwards.
+	pruned := *want
+	pruned.NEVMBlockData = nil
+	store("without data", &pruned)
+	checkStored("FetchNEVMBlock without data", &pruned)
+	store("with data", want)
+	checkStored("FetchNEVMBlock with data", want)
+	store("without data again", &pruned)
+	checkStored("FetchNEVMBlock without data again", want)
+
+	// Pruning drops the data while keeping the hash and roots.
+	err = chain.db.Update(func(dbTx database.Tx) error {
+		return dbPruneNEVMBlockData(dbTx, hash)
+	})
+	if err != nil {
+		t.Fatalf("dbPruneNEVMBlockData: unexpected error: %v", err)
+	}
+	checkStored("FetchNEVMBlock after prune", &pruned)
+}
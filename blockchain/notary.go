@@ -0,0 +1,126 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/btcec"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// NotarySigHash returns the hash a notary signs to approve the passed asset
+// output of the passed transaction.  It commits to the outpoints spent by the
+// transaction, the guid of the asset and, for every output the asset is
+// assigned to, the output index, the asset value, the amount and the public
+// key script of the output.  The notary signatures themselves are not
+// committed to, so the signatures of every asset of a transaction may be
+// produced independently.
+func NotarySigHash(msgTx *wire.MsgTx, assetOut *wire.AssetOutType) chainhash.Hash {
+	var buf bytes.Buffer
+	var scratch [8]byte
+	for _, txIn := range msgTx.TxIn {
+		buf.Write(txIn.PreviousOutPoint.Hash[:])
+		binary.LittleEndian.PutUint32(scratch[:4],
+			txIn.PreviousOutPoint.Index)
+		buf.Write(scratch[:4])
+	}
+	binary.LittleEndian.PutUint64(scratch[:], assetOut.AssetGuid)
+	buf.Write(scratch[:])
+	for _, value := range assetOut.Values {
+		binary.LittleEndian.PutUint32(scratch[:4], value.N)
+		buf.Write(scratch[:4])
+		binary.LittleEndian.PutUint64(scratch[:], uint64(value.ValueSat))
+		buf.Write(scratch[:])
+
+		// Outputs which don't exist are rejected elsewhere, so they are
+		// simply committed to as an empty output here.
+		var txOut wire.TxOut
+		if value.N < uint32(len(msgTx.TxOut)) {
+			txOut = *msgTx.TxOut[value.N]
+		}
+		binary.LittleEndian.PutUint64(scratch[:], uint64(txOut.Value))
+		buf.Write(scratch[:])
+		wire.WriteVarBytes(&buf, 0, txOut.PkScript)
+	}
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// CheckNotarySig ensures the passed asset output of the passed transaction
+// carries a compact signature of its notary sig hash by the key whose hash160
+// is the passed notary key ID.
+func CheckNotarySig(msgTx *wire.MsgTx, assetOut *wire.AssetOutType, notaryKeyID []byte) error {
+	if len(assetOut.NotarySig) == 0 {
+		str := fmt.Sprintf("output of asset %d is missing the "+
+			"signature of its notary", assetOut.AssetGuid)
+		return ruleError(ErrBadNotarySig, str)
+	}
+
+	hash := NotarySigHash(msgTx, assetOut)
+	pubKey, wasCompressed, err := btcec.RecoverCompact(btcec.S256(),
+		assetOut.NotarySig, hash[:])
+	if err != nil {
+		str := fmt.Sprintf("notary signature of output of asset %d "+
+			"is invalid: %v", assetOut.AssetGuid, err)
+		return ruleError(ErrBadNotarySig, str)
+	}
+
+	var serializedPubKey []byte
+	if wasCompressed {
+		serializedPubKey = pubKey.SerializeCompressed()
+	} else {
+		serializedPubKey = pubKey.SerializeUncompressed()
+	}
+	keyID := btcutil.Hash160(serializedPubKey)
+	if !bytes.Equal(keyID, notaryKeyID) {
+		str := fmt.Sprintf("notary signature of output of asset %d "+
+			"is signed by key %x instead of notary key %x",
+			assetOut.AssetGuid, keyID, notaryKeyID)
+		return ruleError(ErrBadNotarySig, str)
+	}
+	return nil
+}
+
+// CheckNotarySigs ensures every asset output of the passed transaction whose
+// asset has a notary configured carries a valid signature of the notary.  The
+// state of the assets is looked up in the passed view, which must hold the
+// assets the transaction references such as the one returned by FetchUtxoView.
+// Only transactions that move existing asset allocations are subject to
+// notaries, so asset activate, update and send transactions are accepted as is.
+func CheckNotarySigs(tx *btcutil.Tx, utxoView *UtxoViewpoint) error {
+	msgTx := tx.MsgTx()
+	if !wire.IsAssetAllocationTx(msgTx.Version) {
+		return nil
+	}
+	allocation, err := msgTx.AssetAllocation()
+	if err != nil {
+		str := fmt.Sprintf("unable to decode the payload of "+
+			"transaction %v: %v", tx.Hash(), err)
+		return ruleError(ErrBadAssetPayload, str)
+	}
+
+	for i := range allocation.VoutAssets {
+		assetOut := &allocation.VoutAssets[i]
+		stored := utxoView.LookupAsset(assetOut.AssetGuid)
+		if stored == nil || len(stored.NotaryKeyID()) == 0 {
+			continue
+		}
+
+		err := CheckNotarySig(msgTx, assetOut, stored.NotaryKeyID())
+		if err != nil {
+			if rerr, ok := err.(RuleError); ok {
+				rerr.Description = fmt.Sprintf("transaction "+
+					"%v: %s", tx.Hash(), rerr.Description)
+				return rerr
+			}
+			return err
+		}
+	}
+	return nil
+}
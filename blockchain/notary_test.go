@@ -0,0 +1,151 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/btcec"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// TestCheckNotarySigs ensures the outputs of assets with a notary configured
+// are only accepted when they carry a valid signature of the notary.
+func TestCheckNotarySigs(t *testing.T) {
+	t.Parallel()
+
+	const guid, otherGuid = 1234, 5678
+	p2pkh := hexToBytes("76a914ee8bd501094a7d5ca318da2506de35e1cb025ddc88ac")
+	notaryKey, _ := btcec.PrivKeyFromBytes(btcec.S256(),
+		bytes.Repeat([]byte{0x01}, 32))
+	otherKey, _ := btcec.PrivKeyFromBytes(btcec.S256(),
+		bytes.Repeat([]byte{0x02}, 32))
+	notaryKeyID := btcutil.Hash160(notaryKey.PubKey().SerializeCompressed())
+
+	// The view holds a notarized asset for guid and an asset without a
+	// notary for otherGuid.
+	view := NewUtxoViewpoint()
+	view.assets[guid] = &AssetEntry{notaryKeyID: notaryKeyID}
+	view.assets[otherGuid] = &AssetEntry{}
+
+	// notarizedTx returns an allocation send of the passed asset to the
+	// first of two outputs whose notary signature is produced by the
+	// passed function from the notary sig hash of the output.
+	notarizedTx := func(version int32, g uint64, sign func(hash []byte) []byte) *btcutil.Tx {
+		tx := wire.NewMsgTx(version)
+		prevOut := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 2}
+		tx.AddTxIn(wire.NewTxIn(&prevOut, nil, nil))
+		tx.AddTxOut(wire.NewTxOut(500, p2pkh))
+		tx.AddTxOut(wire.NewTxOut(600, p2pkh))
+		voutAsset := wire.AssetOutType{
+			AssetGuid: g,
+			Values:    []wire.AssetOutValueType{{N: 0, ValueSat: 10}},
+		}
+		hash := NotarySigHash(tx, &voutAsset)
+		voutAsset.NotarySig = sign(hash[:])
+
+		allocation := wire.AssetAllocationType{
+			VoutAssets: []wire.AssetOutType{voutAsset},
+		}
+		var buf bytes.Buffer
+		if err := allocation.Serialize(&buf); err != nil {
+			t.Fatalf("Serialize: unexpected error: %v", err)
+		}
+		script, err := txscript.NullDataScript(buf.Bytes())
+		if err != nil {
+			t.Fatalf("NullDataScript: unexpected error: %v", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(0, script))
+		return btcutil.NewTx(tx)
+	}
+	signWith := func(key *btcec.PrivateKey, compressed bool) func([]byte) []byte {
+		return func(hash []byte) []byte {
+			sig, err := btcec.SignCompact(btcec.S256(), key, hash,
+				compressed)
+			if err != nil {
+				t.Fatalf("SignCompact: unexpected error: %v", err)
+			}
+			return sig
+		}
+	}
+	noSig := func([]byte) []byte { return nil }
+
+	send := wire.SyscoinTxVersionAllocationSend
+	tests := []struct {
+		name    string
+		tx      *btcutil.Tx
+		wantErr bool
+	}{
+		{
+			name: "signed by notary",
+			tx:   notarizedTx(send, guid, signWith(notaryKey, true)),
+		},
+		{
+			name:    "signed by notary with uncompressed key",
+			tx:      notarizedTx(send, guid, signWith(notaryKey, false)),
+			wantErr: true,
+		},
+		{
+			name:    "signed by other key",
+			tx:      notarizedTx(send, guid, signWith(otherKey, true)),
+			wantErr: true,
+		},
+		{
+			name:    "missing signature",
+			tx:      notarizedTx(send, guid, noSig),
+			wantErr: true,
+		},
+		{
+			name: "malformed signature",
+			tx: notarizedTx(send, guid, func([]byte) []byte {
+				return bytes.Repeat([]byte{0xff}, 65)
+			}),
+			wantErr: true,
+		},
+		{
+			name: "asset without notary",
+			tx:   notarizedTx(send, otherGuid, noSig),
+		},
+		{
+			name: "unknown asset",
+			tx:   notarizedTx(send, guid+1, noSig),
+		},
+		{
+			name: "asset send",
+			tx: notarizedTx(wire.SyscoinTxVersionAssetSend, guid,
+				noSig),
+		},
+	}
+
+	for _, test := range tests {
+		err := CheckNotarySigs(test.tx, view)
+		if !test.wantErr {
+			if err != nil {
+				t.Errorf("CheckNotarySigs(%s): unexpected error: %v",
+					test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != ErrBadNotarySig {
+			t.Errorf("CheckNotarySigs(%s): did not get expected "+
+				"error %v - got %v", test.name, ErrBadNotarySig, err)
+		}
+	}
+
+	// Ensure the signature no longer verifies once an output the asset is
+	// assigned to is changed.
+	tx := notarizedTx(send, guid, signWith(notaryKey, true))
+	tx.MsgTx().TxOut[0].Value++
+	err := CheckNotarySigs(btcutil.NewTx(tx.MsgTx()), view)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrBadNotarySig {
+		t.Errorf("CheckNotarySigs(changed output): did not get expected "+
+			"error %v - got %v", ErrBadNotarySig, err)
+	}
+}
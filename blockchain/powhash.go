@@ -0,0 +1,77 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/wire"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// scryptN, scryptR and scryptP are the cost parameters of the scrypt
+	// proof of work hash.
+	scryptN = 1024
+	scryptR = 1
+	scryptP = 1
+)
+
+// scryptHash returns the scrypt proof of work hash of the passed block header.
+func scryptHash(header *wire.BlockHeader) chainhash.Hash {
+	var buf bytes.Buffer
+	buf.Grow(wire.MaxBlockHeaderPayload)
+	_ = header.Serialize(&buf)
+
+	// The cost parameters are constant and valid, so this can't fail.
+	headerBytes := buf.Bytes()
+	key, _ := scrypt.Key(headerBytes, headerBytes, scryptN, scryptR,
+		scryptP, chainhash.HashSize)
+
+	var hash chainhash.Hash
+	copy(hash[:], key)
+	return hash
+}
+
+// PowHash returns the hash of the passed block header which must not exceed
+// its target difficulty, calculated with the proof of work hash function
+// selected by the passed chain parameters.  It is the block hash itself for
+// chains using the double SHA-256 of Bitcoin.
+//
+// Note that the proof of work of merged mined blocks is provided by the parent
+// block of their auxpow, so the parent block header has to be passed for them.
+func PowHash(header *wire.BlockHeader, params *chaincfg.Params) chainhash.Hash {
+	switch params.PowHashAlgorithm {
+	case chaincfg.PowHashScrypt:
+		return scryptHash(header)
+
+	case chaincfg.PowHashCustom:
+		return params.CustomPowHash(header)
+	}
+
+	return header.BlockHash()
+}
+
+// checkPowHashParams ensures the proof of work hash function selected by the
+// passed chain parameters is known and has what it requires.
+func checkPowHashParams(params *chaincfg.Params) error {
+	switch params.PowHashAlgorithm {
+	case chaincfg.PowHashSHA256d, chaincfg.PowHashScrypt:
+		return nil
+
+	case chaincfg.PowHashCustom:
+		if params.CustomPowHash == nil {
+			return AssertError("custom proof of work hash selected " +
+				"without a hash function")
+		}
+		return nil
+	}
+
+	return AssertError(fmt.Sprintf("unknown proof of work hash %v",
+		params.PowHashAlgorithm))
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// scryptGenesisHeader is the header of the Litecoin genesis block, which is
+// mined with the scrypt proof of work hash.
+var scryptGenesisHeader = wire.BlockHeader{
+	Version:    1,
+	MerkleRoot: *newHashFromStr("97ddfbbae6be97fd6cdf3e7ca13232a3afff2353e29badfab7f73011edd4ced9"),
+	Timestamp:  time.Unix(1317972665, 0),
+	Bits:       0x1e0ffff0,
+	Nonce:      2084524493,
+}
+
+// TestPowHash ensures the proof of work hash is calculated with the hash
+// function selected by the chain parameters.
+func TestPowHash(t *testing.T) {
+	t.Parallel()
+
+	blockHash := newHashFromStr("12a765e31ffd4059bada1e25190f6e98c99d9714d334efa41a195a7e7e04bfe2")
+	scryptHash := newHashFromStr("0000050c34a64b415b6b15b37f2216634b5b1669cb9a2e38d76f7213b0671e00")
+	customHash := chainhash.Hash{0x01}
+
+	tests := []struct {
+		name      string
+		algorithm chaincfg.PowHashAlgorithm
+		want      *chainhash.Hash
+	}{
+		{"sha256d", chaincfg.PowHashSHA256d, blockHash},
+		{"scrypt", chaincfg.PowHashScrypt, scryptHash},
+		{"custom", chaincfg.PowHashCustom, &customHash},
+	}
+
+	for _, test := range tests {
+		params := chaincfg.SysRegressionNetParams
+		params.PowHashAlgorithm = test.algorithm
+		params.CustomPowHash = func(*wire.BlockHeader) chainhash.Hash {
+			return customHash
+		}
+		header := scryptGenesisHeader
+		if got := PowHash(&header, &params); got != *test.want {
+			t.Errorf("PowHash(%s): wrong hash - got %v, want %v",
+				test.name, got, test.want)
+		}
+
+		// The block hash is unaffected by the proof of work hash.
+		if got := header.BlockHash(); got != *blockHash {
+			t.Errorf("BlockHash(%s): wrong hash - got %v, want %v",
+				test.name, got, blockHash)
+		}
+	}
+}
+
+// TestScryptProofOfWork ensures the proof of work of blocks is checked against
+// the proof of work hash selected by the chain parameters.
+func TestScryptProofOfWork(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.SysRegressionNetParams
+	params.PowHashAlgorithm = chaincfg.PowHashScrypt
+	header := scryptGenesisHeader
+	err := checkProofOfWork(&header, &params, BFNone)
+	if err != nil {
+		t.Fatalf("checkProofOfWork: unexpected error: %v", err)
+	}
+
+	// The block hash of the header does not meet the target.
+	params.PowHashAlgorithm = chaincfg.PowHashSHA256d
+	err = checkProofOfWork(&header, &params, BFNone)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrHighHash {
+		t.Errorf("checkProofOfWork: did not get expected error %v - "+
+			"got %v", ErrHighHash, err)
+	}
+}
+
+// TestCheckPowHashParams ensures proof of work hash functions are only
+// accepted along with the parameters they require.
+func TestCheckPowHashParams(t *testing.T) {
+	t.Parallel()
+
+	customPowHash := func(header *wire.BlockHeader) chainhash.Hash {
+		return header.BlockHash()
+	}
+	tests := []struct {
+		name      string
+		algorithm chaincfg.PowHashAlgorithm
+		custom    chaincfg.PowHashFunc
+		wantErr   bool
+	}{
+		{"sha256d", chaincfg.PowHashSHA256d, nil, false},
+		{"scrypt", chaincfg.PowHashScrypt, nil, false},
+		{"custom", chaincfg.PowHashCustom, customPowHash, false},
+		{"custom without function", chaincfg.PowHashCustom, nil, true},
+		{"unknown", chaincfg.PowHashCustom + 1, nil, true},
+	}
+
+	for _, test := range tests {
+		params := chaincfg.SysRegressionNetParams
+		params.PowHashAlgorithm = test.algorithm
+		params.CustomPowHash = test.custom
+		err := checkPowHashParams(&params)
+		if test.wantErr != (err != nil) {
+			t.Errorf("checkPowHashParams(%s): unexpected result - "+
+				"got %v, want error %v", test.name, err,
+				test.wantErr)
+		}
+	}
+}
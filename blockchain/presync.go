@@ -0,0 +1,284 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// presyncCommitmentInterval is the number of block headers between the
+	// headers a presync keeps a commitment to.  It is also the maximum
+	// number of headers which are held while they are downloaded again
+	// until they have been verified against a commitment.
+	presyncCommitmentInterval = 2016
+)
+
+// presyncCommitment returns the commitment a presync keeps to the block header
+// with the passed hash.  Part of the hash suffices since a different header
+// with the same commitment would need to be found with valid proof of work.
+func presyncCommitment(hash *chainhash.Hash) uint64 {
+	return byteOrder.Uint64(hash[:8])
+}
+
+// HeadersPresync verifies that a chain of block headers received from a peer
+// has at least the minimum chain work before any of them are added to the
+// block index.  This prevents peers from exhausting the memory of the node by
+// sending long chains of headers with little proof of work.
+//
+// The headers are presynced first.  They are verified to link together, to
+// have valid proof of work, difficulty, timestamps and chain IDs, and to match
+// the checkpoints, but only a commitment to every presyncCommitmentInterval-th
+// header is kept along with the last headers the difficulty and median time of
+// the next one are calculated from.  The timestamp rules limit the number of headers a peer can
+// send, and therefore the memory used by the commitments, to a few for every
+// second since the block the headers connect to.
+//
+// Once the presynced headers have the minimum chain work, they need to be
+// downloaded again.  These headers are held until they have been verified
+// against the next commitment and are then added to the block index along
+// with all of the checks of ProcessBlockHeader.
+//
+// A HeadersPresync is not safe for concurrent access.
+type HeadersPresync struct {
+	chain       *BlockChain
+	startNode   *blockNode
+	tip         *blockNode
+	recent      []*blockNode
+	nextRecent  int
+	commitments []uint64
+
+	// These fields are set once the presynced headers have the minimum
+	// chain work and are downloaded again.
+	target         *blockNode
+	lastHash       chainhash.Hash
+	lastHeight     int32
+	numVerified    int
+	pendingHeaders []*wire.BlockHeader
+}
+
+// NewHeadersPresync returns a presync for the block headers following the known
+// block with the passed hash.  Nil is returned when the chain ending with the
+// block already has the minimum chain work, so the headers following it can be
+// passed to ProcessBlockHeader right away.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) NewHeadersPresync(hash *chainhash.Hash) (*HeadersPresync, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		str := fmt.Sprintf("previous block %s is unknown", hash)
+		return nil, ruleError(ErrPreviousBlockUnknown, str)
+	} else if b.index.NodeStatus(node).KnownInvalid() {
+		str := fmt.Sprintf("previous block %s is known to be invalid",
+			hash)
+		return nil, ruleError(ErrInvalidAncestorBlock, str)
+	}
+
+	if b.minimumChainWork == nil ||
+		node.workSum.Cmp(b.minimumChainWork) >= 0 {
+
+		return nil, nil
+	}
+	// The difficulty retarget rules access the ancestors of the previous
+	// header up to the retarget interval or window.
+	numRecent := b.chainParams.RetargetWindow
+	if b.chainParams.RetargetAlgorithm == chaincfg.RetargetClassic {
+		numRecent = b.blocksPerRetarget
+	}
+	if numRecent < medianTimeBlocks {
+		numRecent = medianTimeBlocks
+	}
+	return &HeadersPresync{
+		chain:     b,
+		startNode: node,
+		tip:       node,
+		recent:    make([]*blockNode, numRecent),
+	}, nil
+}
+
+// StartHash returns the hash of the known block the headers connect to.
+func (p *HeadersPresync) StartHash() *chainhash.Hash {
+	return &p.startNode.hash
+}
+
+// Height returns the height of the last header which has been presynced.
+func (p *HeadersPresync) Height() int32 {
+	return p.tip.height
+}
+
+// Redownloading returns whether or not the presynced headers have the minimum
+// chain work and need to be downloaded again.
+func (p *HeadersPresync) Redownloading() bool {
+	return p.target != nil
+}
+
+// TargetHash returns the hash of the first presynced header at which the chain
+// has the minimum chain work.  It is nil until the headers are downloaded again.
+func (p *HeadersPresync) TargetHash() *chainhash.Hash {
+	if p.target == nil {
+		return nil
+	}
+	return &p.target.hash
+}
+
+// Done returns whether or not all of the presynced headers up to the target
+// have been downloaded again and added to the block index.  The headers after
+// it can be passed to ProcessBlockHeader right away then.
+func (p *HeadersPresync) Done() bool {
+	return p.target != nil && p.lastHeight == p.target.height
+}
+
+// ProcessHeader processes the passed block header, which must follow the one
+// processed before or the start block.  While presyncing, the header is only
+// verified.  Once the headers are downloaded again, it is verified against the
+// presynced ones and added to the block index along with the headers before it
+// once they match a commitment.
+func (p *HeadersPresync) ProcessHeader(header *wire.BlockHeader) error {
+	if p.target == nil {
+		return p.presyncHeader(header)
+	}
+	return p.redownloadHeader(header)
+}
+
+// presyncHeader verifies the passed block header follows the last presynced
+// one and passes the checks which can be performed without adding it to the
+// block index.
+func (p *HeadersPresync) presyncHeader(header *wire.BlockHeader) error {
+	b := p.chain
+	blockHash := header.BlockHash()
+	if !header.PrevBlock.IsEqual(&p.tip.hash) {
+		str := fmt.Sprintf("block header %v does not connect to the "+
+			"previous header %v", blockHash, p.tip.hash)
+		return ruleError(ErrPreviousBlockUnknown, str)
+	}
+
+	err := checkBlockHeaderSanity(header, b.chainParams, b.timeSource,
+		BFNone)
+	if err != nil {
+		return err
+	}
+	err = checkAuxPowChainID(header, b.chainParams)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the difficulty specified in the block header matches the
+	// calculated difficulty based on the previous header and difficulty
+	// retarget rules.
+	expectedDifficulty, err := b.calcNextRequiredDifficulty(p.tip,
+		header.Timestamp)
+	if err != nil {
+		return err
+	}
+	if header.Bits != expectedDifficulty {
+		str := "block difficulty of %d is not the expected value of %d"
+		str = fmt.Sprintf(str, header.Bits, expectedDifficulty)
+		return ruleError(ErrUnexpectedDifficulty, str)
+	}
+
+	// Ensure the timestamp for the block header is after the median time
+	// of the last several blocks (medianTimeBlocks).
+	medianTime := p.tip.CalcPastMedianTime()
+	if !header.Timestamp.After(medianTime) {
+		str := "block timestamp of %v is not after expected %v"
+		str = fmt.Sprintf(str, header.Timestamp, medianTime)
+		return ruleError(ErrTimeTooOld, str)
+	}
+
+	// Ensure chain matches up to predetermined checkpoints.
+	blockHeight := p.tip.height + 1
+	if !b.verifyCheckpoint(blockHeight, &blockHash) {
+		str := fmt.Sprintf("block at height %d does not match "+
+			"checkpoint hash", blockHeight)
+		return ruleError(ErrBadCheckpoint, str)
+	}
+
+	// Only the headers needed to calculate the difficulty and median time
+	// remain linked to the new one, so the ones before them can be freed.
+	// The ancestors of the first header are part of the block index
+	// anyways.
+	node := newBlockNode(header, p.tip)
+	if oldest := p.recent[p.nextRecent]; oldest != nil {
+		oldest.parent = nil
+	}
+	p.recent[p.nextRecent] = node
+	p.nextRecent = (p.nextRecent + 1) % len(p.recent)
+	if blockHeight%presyncCommitmentInterval == 0 {
+		p.commitments = append(p.commitments,
+			presyncCommitment(&blockHash))
+	}
+	p.tip = node
+
+	// The headers need to be downloaded again once they have the minimum
+	// chain work.
+	if node.workSum.Cmp(b.minimumChainWork) >= 0 {
+		p.target = node
+		p.lastHash = p.startNode.hash
+		p.lastHeight = p.startNode.height
+	}
+	return nil
+}
+
+// redownloadHeader verifies the passed block header which is downloaded again
+// matches the presynced one.  The header is held until it has been verified
+// against the next commitment, or the target, and is then added to the block
+// index along with the headers before it.
+func (p *HeadersPresync) redownloadHeader(header *wire.BlockHeader) error {
+	blockHash := header.BlockHash()
+	if p.lastHeight == p.target.height {
+		str := fmt.Sprintf("block header %v follows the last presynced "+
+			"header %v", blockHash, p.target.hash)
+		return ruleError(ErrPresyncMismatch, str)
+	}
+	if !header.PrevBlock.IsEqual(&p.lastHash) {
+		str := fmt.Sprintf("block header %v does not connect to the "+
+			"previous header %v", blockHash, p.lastHash)
+		return ruleError(ErrPreviousBlockUnknown, str)
+	}
+	p.pendingHeaders = append(p.pendingHeaders, header)
+	p.lastHash = blockHash
+	p.lastHeight++
+
+	// Nothing more to do until the header at the next commitment or the
+	// target has been downloaded.
+	blockHeight := p.lastHeight
+	isCommitment := blockHeight%presyncCommitmentInterval == 0
+	if !isCommitment && blockHeight != p.target.height {
+		return nil
+	}
+	if isCommitment {
+		if presyncCommitment(&blockHash) != p.commitments[p.numVerified] {
+			str := fmt.Sprintf("block header %v at height %d does "+
+				"not match the presynced one", blockHash,
+				blockHeight)
+			return ruleError(ErrPresyncMismatch, str)
+		}
+		p.numVerified++
+	}
+	if blockHeight == p.target.height && blockHash != p.target.hash {
+		str := fmt.Sprintf("block header %v at height %d does not "+
+			"match the presynced one %v", blockHash, blockHeight,
+			p.target.hash)
+		return ruleError(ErrPresyncMismatch, str)
+	}
+
+	// The pending headers are part of the presynced chain, so they are
+	// added to the block index now.
+	for _, pending := range p.pendingHeaders {
+		err := p.chain.ProcessBlockHeader(pending, BFNone)
+		if err != nil {
+			return err
+		}
+	}
+	p.pendingHeaders = nil
+	return nil
+}
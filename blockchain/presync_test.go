@@ -0,0 +1,166 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// TestHeadersPresync ensures block headers are only added to the block index
+// once they have been presynced with the minimum chain work and downloaded
+// again, and headers which don't match the presynced ones are rejected.
+func TestHeadersPresync(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	chain, teardownFunc, err := chainSetup("headerspresync", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// newHeaders returns a chain of the passed number of version 4 block
+	// headers building on the genesis block which are spaced by twice the
+	// target time per block, so the difficulty remains at the proof of work
+	// limit at the retarget.  The extra nonce makes the headers of different
+	// chains distinct.
+	newHeaders := func(numHeaders int32, extraNonce int64) []*wire.BlockHeader {
+		headers := make([]*wire.BlockHeader, 0, numHeaders)
+		parent := params.GenesisBlock
+		for height := int32(1); height <= numHeaders; height++ {
+			block := newTestBlock(parent, height, extraNonce,
+				params).MsgBlock()
+			block.Header.Version = 4
+			block.Header.Timestamp = parent.Header.Timestamp.Add(
+				params.TargetTimePerBlock * 2)
+			target := CompactToBig(block.Header.Bits)
+			for {
+				hash := block.BlockHash()
+				if HashToBig(&hash).Cmp(target) <= 0 {
+					break
+				}
+				block.Header.Nonce++
+			}
+			headers = append(headers, &block.Header)
+			parent = block
+		}
+		return headers
+	}
+
+	// Create enough headers to span a commitment and require the work of
+	// all of them.
+	const numHeaders = presyncCommitmentInterval + 10
+	headers := newHeaders(numHeaders, 0)
+	chain.minimumChainWork = new(big.Int).Mul(CalcWork(params.PowLimitBits),
+		big.NewInt(numHeaders+1))
+	target := headers[numHeaders-1].BlockHash()
+
+	// processHeaders presyncs the passed headers and then downloads the
+	// passed alternate headers again.  The error of the first header which
+	// is rejected is returned.
+	processHeaders := func(presync *HeadersPresync, headers, redownload []*wire.BlockHeader) error {
+		t.Helper()
+		for i, header := range headers {
+			if presync.Redownloading() {
+				t.Fatalf("Redownloading: minimum chain work "+
+					"reached after %d headers", i)
+			}
+			if err := presync.ProcessHeader(header); err != nil {
+				t.Fatalf("ProcessHeader #%d: unexpected error "+
+					"while presyncing: %v", i, err)
+			}
+		}
+		if !presync.Redownloading() || *presync.TargetHash() != target {
+			t.Fatalf("TargetHash: unexpected target %v, want %v",
+				presync.TargetHash(), target)
+		}
+		for _, header := range redownload {
+			if err := presync.ProcessHeader(header); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Headers which differ from the presynced ones are rejected once they
+	// reach the commitment, and none of them are added to the block index.
+	presync, err := chain.NewHeadersPresync(params.GenesisHash)
+	if err != nil || presync == nil {
+		t.Fatalf("NewHeadersPresync: unexpected result %v (err %v)",
+			presync, err)
+	}
+	alternate := newHeaders(presyncCommitmentInterval, 1)
+	err = processHeaders(presync, headers, alternate)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrPresyncMismatch {
+		t.Fatalf("ProcessHeader: unexpected error for mismatching "+
+			"header - got %v, want %v", err, ErrPresyncMismatch)
+	}
+	if _, height := chain.BestHeader(); height != 0 {
+		t.Fatalf("BestHeader: unexpected height %d before headers "+
+			"match the presynced ones", height)
+	}
+
+	// Headers which match the presynced ones are added to the block index
+	// once they reach the commitment and target.
+	presync, err = chain.NewHeadersPresync(params.GenesisHash)
+	if err != nil || presync == nil {
+		t.Fatalf("NewHeadersPresync: unexpected result %v (err %v)",
+			presync, err)
+	}
+	err = processHeaders(presync, headers, headers[:numHeaders-1])
+	if err != nil {
+		t.Fatalf("ProcessHeader: unexpected error: %v", err)
+	}
+	if _, height := chain.BestHeader(); height != presyncCommitmentInterval {
+		t.Fatalf("BestHeader: unexpected height %d, want %d", height,
+			presyncCommitmentInterval)
+	}
+	if presync.Done() {
+		t.Fatal("Done: presync done before the target was downloaded")
+	}
+	if err := presync.ProcessHeader(headers[numHeaders-1]); err != nil {
+		t.Fatalf("ProcessHeader: unexpected error for target: %v", err)
+	}
+	if hash, height := chain.BestHeader(); !presync.Done() ||
+		hash != target || height != numHeaders {
+
+		t.Fatalf("BestHeader: unexpected best header %v (%d), want "+
+			"%v (%d)", hash, height, target, numHeaders)
+	}
+
+	// Headers which claim a difficulty other than the one required by the
+	// retarget rules are rejected while presyncing.
+	presync, err = chain.NewHeadersPresync(params.GenesisHash)
+	if err != nil || presync == nil {
+		t.Fatalf("NewHeadersPresync: unexpected result %v (err %v)",
+			presync, err)
+	}
+	forged := *headers[0]
+	forged.Bits = params.PowLimitBits - 1
+	forgedTarget := CompactToBig(forged.Bits)
+	for {
+		hash := forged.BlockHash()
+		if HashToBig(&hash).Cmp(forgedTarget) <= 0 {
+			break
+		}
+		forged.Nonce++
+	}
+	err = presync.ProcessHeader(&forged)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrUnexpectedDifficulty {
+		t.Fatalf("ProcessHeader: unexpected error for forged "+
+			"difficulty - got %v, want %v", err,
+			ErrUnexpectedDifficulty)
+	}
+
+	// No presync is needed for headers following a chain with the minimum
+	// chain work.
+	presync, err = chain.NewHeadersPresync(&target)
+	if err != nil || presync != nil {
+		t.Fatalf("NewHeadersPresync: unexpected presync %v (err %v) "+
+			"for chain with minimum chain work", presync, err)
+	}
+}
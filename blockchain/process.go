@@ -11,6 +11,7 @@ import (
 	"github.com/martinboehm/btcutil"
 	"github.com/vpubchain/btcd/chaincfg/chainhash"
 	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/wire"
 )
 
 // BehaviorFlags is a bitmask defining tweaks to the normal behavior when
@@ -39,8 +40,12 @@ const (
 // This function is safe for concurrent access.
 func (b *BlockChain) blockExists(hash *chainhash.Hash) (bool, error) {
 	// Check block index first (could be main chain or side chain blocks).
-	if b.index.HaveBlock(hash) {
-		return true, nil
+	// Blocks which have been validated count even when they have been
+	// pruned since then, while blocks for which only the header is known
+	// don't.
+	if node := b.index.LookupNode(hash); node != nil {
+		status := b.index.NodeStatus(node)
+		return status.HaveData() || status.KnownValid(), nil
 	}
 
 	// Check in the database.
@@ -71,6 +76,54 @@ func (b *BlockChain) blockExists(hash *chainhash.Hash) (bool, error) {
 	return exists, err
 }
 
+// checkPreviousCheckpoint finds the previous checkpoint and performs some
+// additional checks on the passed block header based on the checkpoint.  This
+// provides a few nice properties such as preventing old side chain blocks before
+// the last checkpoint, rejecting easy to mine, but otherwise bogus, blocks that
+// could be used to eat memory, and ensuring expected (versus claimed) proof of
+// work requirements since the previous checkpoint are met.
+//
+// The flags modify the behavior of this function as follows:
+//  - BFFastAdd: The proof of work is not compared against the minimum expected
+//    since the previous checkpoint.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkPreviousCheckpoint(header *wire.BlockHeader, flags BehaviorFlags) error {
+	checkpointNode, err := b.findPreviousCheckpoint()
+	if err != nil || checkpointNode == nil {
+		return err
+	}
+
+	// Ensure the block timestamp is after the checkpoint timestamp.
+	checkpointTime := time.Unix(checkpointNode.timestamp, 0)
+	if header.Timestamp.Before(checkpointTime) {
+		str := fmt.Sprintf("block %v has timestamp %v before "+
+			"last checkpoint timestamp %v", header.BlockHash(),
+			header.Timestamp, checkpointTime)
+		return ruleError(ErrCheckpointTimeTooOld, str)
+	}
+	if flags&BFFastAdd != BFFastAdd {
+		// Even though the checks prior to now have already ensured the
+		// proof of work exceeds the claimed amount, the claimed amount
+		// is a field in the block header which could be forged.  This
+		// check ensures the proof of work is at least the minimum
+		// expected based on elapsed time since the last checkpoint and
+		// maximum adjustment allowed by the retarget rules.
+		duration := header.Timestamp.Sub(checkpointTime)
+		requiredTarget := CompactToBig(b.calcEasiestDifficulty(
+			checkpointNode.bits, duration))
+		currentTarget := CompactToBig(header.Bits)
+		if currentTarget.Cmp(requiredTarget) > 0 {
+			str := fmt.Sprintf("block target difficulty of %064x "+
+				"is too low when compared to the previous "+
+				"checkpoint", currentTarget)
+			return ruleError(ErrDifficultyTooLow, str)
+		}
+	}
+
+	return nil
+}
+
 // processOrphans determines if there are any orphans which depend on the passed
 // block hash (they are no longer orphans if true) and potentially accepts them.
 // It repeats the process for the newly accepted blocks (to detect further
@@ -143,11 +196,19 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 	b.chainLock.Lock()
 	defer b.chainLock.Unlock()
 
-	fastAdd := flags&BFFastAdd == BFFastAdd
-
 	blockHash := block.Hash()
 	log.Tracef("Processing block %v", blockHash)
 
+	// The blocks before the UTXO snapshot the chain was bootstrapped from
+	// are already part of the main chain, so they are only validated
+	// against the separate utxo set of the snapshot.
+	if node := b.index.LookupNode(blockHash); node != nil &&
+		b.needsSnapshotHistory(node) {
+
+		err := b.processSnapshotHistoryBlock(node, block, flags)
+		return false, false, err
+	}
+
 	// The block must not already exist in the main chain or side chains.
 	exists, err := b.blockExists(blockHash)
 	if err != nil {
@@ -165,50 +226,17 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 	}
 
 	// Perform preliminary sanity checks on the block and its transactions.
-	err = checkBlockSanity(block, b.chainParams.PowLimit, b.timeSource, flags)
+	err = checkBlockSanity(block, b.chainParams, b.timeSource, flags)
 	if err != nil {
 		return false, false, err
 	}
 
-	// Find the previous checkpoint and perform some additional checks based
-	// on the checkpoint.  This provides a few nice properties such as
-	// preventing old side chain blocks before the last checkpoint,
-	// rejecting easy to mine, but otherwise bogus, blocks that could be
-	// used to eat memory, and ensuring expected (versus claimed) proof of
-	// work requirements since the previous checkpoint are met.
+	// Perform additional checks based on the previous checkpoint.
 	blockHeader := &block.MsgBlock().Header
-	checkpointNode, err := b.findPreviousCheckpoint()
+	err = b.checkPreviousCheckpoint(blockHeader, flags)
 	if err != nil {
 		return false, false, err
 	}
-	if checkpointNode != nil {
-		// Ensure the block timestamp is after the checkpoint timestamp.
-		checkpointTime := time.Unix(checkpointNode.timestamp, 0)
-		if blockHeader.Timestamp.Before(checkpointTime) {
-			str := fmt.Sprintf("block %v has timestamp %v before "+
-				"last checkpoint timestamp %v", blockHash,
-				blockHeader.Timestamp, checkpointTime)
-			return false, false, ruleError(ErrCheckpointTimeTooOld, str)
-		}
-		if !fastAdd {
-			// Even though the checks prior to now have already ensured the
-			// proof of work exceeds the claimed amount, the claimed amount
-			// is a field in the block header which could be forged.  This
-			// check ensures the proof of work is at least the minimum
-			// expected based on elapsed time since the last checkpoint and
-			// maximum adjustment allowed by the retarget rules.
-			duration := blockHeader.Timestamp.Sub(checkpointTime)
-			requiredTarget := CompactToBig(b.calcEasiestDifficulty(
-				checkpointNode.bits, duration))
-			currentTarget := CompactToBig(blockHeader.Bits)
-			if currentTarget.Cmp(requiredTarget) > 0 {
-				str := fmt.Sprintf("block target difficulty of %064x "+
-					"is too low when compared to the previous "+
-					"checkpoint", currentTarget)
-				return false, false, ruleError(ErrDifficultyTooLow, str)
-			}
-		}
-	}
 
 	// Handle orphan blocks.
 	prevHash := &blockHeader.PrevBlock
@@ -242,3 +270,73 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 
 	return isMainChain, false, nil
 }
+
+// ProcessBlockHeader adds the passed block header to the block index when it
+// properly connects to a known block and passes all of the checks which can be
+// performed without the rest of the block.  This allows the chain to learn
+// about the best known header chain ahead of downloading its blocks.  Headers
+// which are already known are ignored.
+//
+// The flags are passed to the header checks.  See checkBlockHeaderContext for
+// how they modify its behavior.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ProcessBlockHeader(header *wire.BlockHeader, flags BehaviorFlags) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	blockHash := header.BlockHash()
+	if node := b.index.LookupNode(&blockHash); node != nil {
+		if b.index.NodeStatus(node).KnownInvalid() {
+			str := fmt.Sprintf("block %v is known to be invalid",
+				blockHash)
+			return ruleError(ErrDuplicateBlock, str)
+		}
+		return nil
+	}
+
+	// Perform the context free header checks along with the checks against
+	// the previous checkpoint.
+	err := checkBlockHeaderSanity(header, b.chainParams, b.timeSource, flags)
+	if err != nil {
+		return err
+	}
+	err = b.checkPreviousCheckpoint(header, flags)
+	if err != nil {
+		return err
+	}
+
+	// The header must connect to a known valid header.
+	prevNode := b.index.LookupNode(&header.PrevBlock)
+	if prevNode == nil {
+		str := fmt.Sprintf("previous block %s is unknown",
+			header.PrevBlock)
+		return ruleError(ErrPreviousBlockUnknown, str)
+	} else if b.index.NodeStatus(prevNode).KnownInvalid() {
+		str := fmt.Sprintf("previous block %s is known to be invalid",
+			header.PrevBlock)
+		return ruleError(ErrInvalidAncestorBlock, str)
+	}
+
+	err = b.checkBlockHeaderContext(header, prevNode, flags)
+	if err != nil {
+		return err
+	}
+
+	// Add a node without any block data to the block index.  It is written
+	// to the database along with the next change to the block index, while
+	// the auxpow of a merged mined header is stored right away since the
+	// node doesn't keep it.
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutAuxPow(dbTx, header)
+	})
+	if err != nil {
+		return err
+	}
+	node := newBlockNode(header, prevNode)
+	b.index.AddNode(node)
+	b.maybeUpdateBestHeader(node)
+
+	log.Tracef("Accepted block header %v", blockHash)
+	return nil
+}
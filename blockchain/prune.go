@@ -0,0 +1,118 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+)
+
+const (
+	// minBlocksToKeep is the number of most recent main chain blocks which
+	// are never pruned along with their spend journal entries.  This keeps
+	// the data needed to disconnect blocks during reorganizations and
+	// matches the number of blocks nodes which only serve recent blocks
+	// are expected to serve to their peers (BIP0159).
+	minBlocksToKeep = 288
+)
+
+var (
+	// pruneHeightKeyName is the name of the db key used to store the height
+	// of the first main chain block which has not been pruned.
+	pruneHeightKeyName = []byte("pruneheight")
+)
+
+// dbFetchPruneHeight uses an existing database transaction to fetch the height
+// of the first block which has not been pruned.  Zero is returned when no
+// blocks have been pruned.
+func dbFetchPruneHeight(dbTx database.Tx) int32 {
+	serialized := dbTx.Metadata().Get(pruneHeightKeyName)
+	if serialized == nil {
+		return 0
+	}
+
+	return int32(byteOrder.Uint32(serialized))
+}
+
+// dbPutPruneHeight uses an existing database transaction to store the height of
+// the first block which has not been pruned.
+func dbPutPruneHeight(dbTx database.Tx, height int32) error {
+	var serialized [4]byte
+	byteOrder.PutUint32(serialized[:], uint32(height))
+	return dbTx.Metadata().Put(pruneHeightKeyName, serialized[:])
+}
+
+// pruneBlocks uses an existing database transaction to prune the oldest blocks
+// along with their spend journal entries when the stored blocks exceed the
+// prune target once the passed node is connected.  The minBlocksToKeep most
+// recent blocks of the chain ending at the node and the blocks after the one
+// the utxo set in the database is consistent with are always kept.  The new
+// prune height is returned.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) pruneBlocks(dbTx database.Tx, node *blockNode) (int32, error) {
+	if b.pruneTarget == 0 {
+		return b.pruneHeight, nil
+	}
+
+	// The blocks connected since the utxo cache was last flushed are kept
+	// as well since they are needed to bring the utxo set in the database
+	// up to date after an unclean shutdown.
+	flushHeight := b.utxoCache.lastFlushHeight()
+	retain := make([]chainhash.Hash, 0, minBlocksToKeep)
+	for n := node; n != nil && (len(retain) < minBlocksToKeep ||
+		n.height > flushHeight); n = n.parent {
+
+		retain = append(retain, n.hash)
+	}
+	pruned, err := dbTx.PruneBlocks(b.pruneTarget, retain)
+	if err != nil || len(pruned) == 0 {
+		return b.pruneHeight, err
+	}
+
+	// The block data of the pruned blocks is no longer available, so their
+	// spend journal entries and asset undo data are of no use either.  The
+	// status of the nodes is stored along with the pruning so the block
+	// index never claims to have the data of a pruned block.
+	pruneHeight := b.pruneHeight
+	for i := range pruned {
+		hash := &pruned[i]
+		if err := dbRemoveSpendJournalEntry(dbTx, hash); err != nil {
+			return 0, err
+		}
+		if err := dbRemoveAssetUndo(dbTx, hash); err != nil {
+			return 0, err
+		}
+
+		n := b.index.LookupNode(hash)
+		if n == nil {
+			continue
+		}
+		b.index.UnsetStatusFlags(n, statusDataStored)
+		if err := dbStoreBlockNode(dbTx, n); err != nil {
+			return 0, err
+		}
+		if n.height >= pruneHeight {
+			pruneHeight = n.height + 1
+		}
+	}
+
+	log.Debugf("Pruned %d blocks, prune height is now %d", len(pruned),
+		pruneHeight)
+	return pruneHeight, dbPutPruneHeight(dbTx, pruneHeight)
+}
+
+// PruneHeight returns the height of the first block which has not been pruned.
+// No block at or above it has been pruned, though some blocks below it may
+// still be stored when they were stored later than their successors.  Zero is
+// returned when no blocks have been pruned.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) PruneHeight() int32 {
+	b.chainLock.RLock()
+	pruneHeight := b.pruneHeight
+	b.chainLock.RUnlock()
+	return pruneHeight
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/database"
+)
+
+// TestPruneHeight ensures the prune height is loaded from the database and a
+// database with pruned blocks is only accepted with pruning enabled.
+func TestPruneHeight(t *testing.T) {
+	chain, teardownFunc, err := chainSetup("pruneheight",
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	if height := chain.PruneHeight(); height != 0 {
+		t.Fatalf("PruneHeight: unexpected height %d for unpruned chain",
+			height)
+	}
+
+	err = chain.db.Update(func(dbTx database.Tx) error {
+		return dbPutPruneHeight(dbTx, 5)
+	})
+	if err != nil {
+		t.Fatalf("Failed to store prune height: %v", err)
+	}
+
+	config := Config{
+		DB:          chain.db,
+		ChainParams: chain.chainParams,
+		TimeSource:  NewMedianTime(),
+	}
+	if _, err := New(&config); err == nil {
+		t.Fatal("New: did not fail for pruned database without " +
+			"pruning enabled")
+	}
+
+	config.Prune = 550 * 1024 * 1024
+	prunedChain, err := New(&config)
+	if err != nil {
+		t.Fatalf("New: unexpected error: %v", err)
+	}
+	if height := prunedChain.PruneHeight(); height != 5 {
+		t.Fatalf("PruneHeight: unexpected height - got %d, want 5",
+			height)
+	}
+}
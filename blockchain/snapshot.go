@@ -0,0 +1,912 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// -----------------------------------------------------------------------------
+// A UTXO snapshot houses the unspent transaction outputs and the state of the
+// assets as of a block along with the headers of the chain which ends in the
+// block, so a new node can be bootstrapped from it without downloading and
+// connecting all of the blocks.
+//
+// The serialized format is:
+//
+//   <magic><version><block hash><height><total txns><headers><num utxos><utxos>
+//   <num assets><assets>
+//
+//   Field          Type               Size
+//   magic          [4]byte            4
+//   version        uint32             4
+//   block hash     chainhash.Hash     chainhash.HashSize
+//   height         uint32             4
+//   total txns     uint64             8
+//   headers        []wire.BlockHeader variable
+//   num utxos      uint64             8
+//   utxos          []utxoRecord       variable
+//   num assets     uint64             8
+//   assets         []assetRecord      variable
+//
+// The headers are those of the blocks after the genesis block up to and
+// including the block, each followed by its auxpow when it is merged mined,
+// while each utxo record is serialized as:
+//
+//   <outpoint key><entry size><entry>
+//
+//   Field          Type     Size
+//   outpoint key   []byte   variable (see outpointKey)
+//   entry size     VLQ      variable
+//   entry          []byte   entry size (see serializeUtxoEntry)
+//
+// Each asset record holds an entry of the asset state bucket, which is either
+// the state of an asset or a minted Ethereum transaction, and is serialized as:
+//
+//   <key size><key><entry size><entry>
+//
+//   Field          Type     Size
+//   key size       uint8    1
+//   key            []byte   key size (8 for a guid or 32 for a tx hash)
+//   entry size     VLQ      variable
+//   entry          []byte   entry size (see the asset state bucket)
+//
+// The records are ordered by their keys, the same as in the utxo set and asset
+// state buckets, and the hash of the snapshot is the double sha256 of the
+// serialized utxo records followed by the asset records.  All integers are
+// encoded in little endian.
+// -----------------------------------------------------------------------------
+
+const (
+	// utxoSnapshotVersion is the version of the serialized UTXO snapshot
+	// format.  Version 3 added the auxpows of merged mined headers.
+	utxoSnapshotVersion = 3
+
+	// utxoSnapshotHeaderSize is the size of the fields which precede the
+	// headers in a serialized UTXO snapshot.
+	utxoSnapshotHeaderSize = 4 + 4 + chainhash.HashSize + 4 + 8
+
+	// utxoSnapshotBatchSize is the number of unspent transaction outputs
+	// which are written to the database per transaction while loading a
+	// UTXO snapshot.
+	utxoSnapshotBatchSize = 100000
+
+	// maxUtxoEntrySize is the maximum size of a serialized utxo entry
+	// which is accepted from a UTXO snapshot.
+	maxUtxoEntrySize = wire.MaxBlockPayload
+
+	// maxAssetEntrySize is the maximum size of a serialized asset entry
+	// which is accepted from a UTXO snapshot.
+	maxAssetEntrySize = 2 + 2*10
+)
+
+// utxoSnapshotStatus describes the validation state of the UTXO snapshot the
+// chain was bootstrapped from.
+type utxoSnapshotStatus byte
+
+const (
+	// utxoSnapshotPending indicates the blocks before the snapshot are
+	// still being validated.
+	utxoSnapshotPending utxoSnapshotStatus = iota
+
+	// utxoSnapshotValid indicates the unspent transaction outputs which
+	// result from validating the blocks before the snapshot match it.
+	utxoSnapshotValid
+
+	// utxoSnapshotInvalid indicates either one of the blocks before the
+	// snapshot is invalid or the unspent transaction outputs which result
+	// from validating them don't match the snapshot.
+	utxoSnapshotInvalid
+)
+
+var (
+	// utxoSnapshotMagic identifies serialized UTXO snapshots.
+	utxoSnapshotMagic = [4]byte{'u', 't', 'x', 'o'}
+
+	// utxoSnapshotKeyName is the name of the db key used to store the state
+	// of the UTXO snapshot the chain was bootstrapped from.
+	utxoSnapshotKeyName = []byte("utxosnapshot")
+
+	// snapshotUtxoSetBucketName is the name of the db bucket used to house
+	// the utxo set which results from validating the blocks before the UTXO
+	// snapshot the chain was bootstrapped from.
+	snapshotUtxoSetBucketName = []byte("snapshotutxoset")
+)
+
+// UTXOSnapshotInfo describes a snapshot of the unspent transaction outputs.
+type UTXOSnapshotInfo struct {
+	// BlockHash is the hash of the block the snapshot was created at.
+	BlockHash chainhash.Hash
+
+	// Height is the height of the block the snapshot was created at.
+	Height int32
+
+	// NumUTXOs is the number of unspent transaction outputs in the
+	// snapshot.
+	NumUTXOs uint64
+
+	// UTXOHash is the hash of the unspent transaction outputs and asset
+	// states in the snapshot which must be pinned by the chain parameters
+	// for it to be loaded.
+	UTXOHash chainhash.Hash
+}
+
+// utxoSnapshotState houses the state of the UTXO snapshot the chain was
+// bootstrapped from.  The blocks before the snapshot are validated against a
+// separate utxo set as they are downloaded.
+type utxoSnapshotState struct {
+	// node is the block the snapshot was created at.
+	node *blockNode
+
+	// utxoHash is the hash of the unspent transaction outputs of the
+	// snapshot.
+	utxoHash chainhash.Hash
+
+	// validatedTip is the most recent block before the snapshot which has
+	// been validated.
+	validatedTip *blockNode
+
+	// status is the validation state of the snapshot.
+	status utxoSnapshotStatus
+}
+
+// serializeUtxoSnapshotState returns the serialization of the passed UTXO
+// snapshot state.  It is serialized as the hash of the block the snapshot was
+// created at, the hash of its unspent transaction outputs, the hash of the most
+// recent validated block and the status byte.
+func serializeUtxoSnapshotState(state *utxoSnapshotState) []byte {
+	serialized := make([]byte, chainhash.HashSize*3+1)
+	copy(serialized, state.node.hash[:])
+	copy(serialized[chainhash.HashSize:], state.utxoHash[:])
+	copy(serialized[chainhash.HashSize*2:], state.validatedTip.hash[:])
+	serialized[chainhash.HashSize*3] = byte(state.status)
+	return serialized
+}
+
+// dbPutUtxoSnapshotState uses an existing database transaction to store the
+// state of the UTXO snapshot the chain was bootstrapped from.
+func dbPutUtxoSnapshotState(dbTx database.Tx, state *utxoSnapshotState) error {
+	serialized := serializeUtxoSnapshotState(state)
+	return dbTx.Metadata().Put(utxoSnapshotKeyName, serialized)
+}
+
+// dbFetchUtxoSnapshotState uses an existing database transaction to load the
+// state of the UTXO snapshot the chain was bootstrapped from.  The referenced
+// blocks are looked up in the passed block index.  Nil is returned when the
+// chain was not bootstrapped from a snapshot.
+func dbFetchUtxoSnapshotState(dbTx database.Tx, index *blockIndex) (*utxoSnapshotState, error) {
+	serialized := dbTx.Metadata().Get(utxoSnapshotKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	if len(serialized) != chainhash.HashSize*3+1 {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt utxo snapshot state",
+		}
+	}
+
+	var state utxoSnapshotState
+	var nodeHash, tipHash chainhash.Hash
+	copy(nodeHash[:], serialized)
+	copy(state.utxoHash[:], serialized[chainhash.HashSize:])
+	copy(tipHash[:], serialized[chainhash.HashSize*2:])
+	state.status = utxoSnapshotStatus(serialized[chainhash.HashSize*3])
+	state.node = index.LookupNode(&nodeHash)
+	state.validatedTip = index.LookupNode(&tipHash)
+	if state.node == nil || state.validatedTip == nil {
+		return nil, AssertError(fmt.Sprintf("utxo snapshot blocks %v "+
+			"and %v are not in the block index", nodeHash, tipHash))
+	}
+	return &state, nil
+}
+
+// readVLQ reads a variable length quantity as serialized by putVLQ from the
+// passed reader.
+func readVLQ(r io.ByteReader) (uint64, error) {
+	var n uint64
+	for i := 0; ; i++ {
+		if i == 10 {
+			return 0, errDeserialize("variable length quantity " +
+				"overflows uint64")
+		}
+		val, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n = (n << 7) | uint64(val&0x7f)
+		if val&0x80 != 0x80 {
+			return n, nil
+		}
+		n++
+	}
+}
+
+// writeUtxoRecord writes the passed outpoint key and serialized utxo entry to
+// the writer in the format of the records of a UTXO snapshot.
+func writeUtxoRecord(w io.Writer, key, serializedEntry []byte) error {
+	var size [10]byte
+	n := putVLQ(size[:], uint64(len(serializedEntry)))
+	if _, err := w.Write(key); err != nil {
+		return err
+	}
+	if _, err := w.Write(size[:n]); err != nil {
+		return err
+	}
+	_, err := w.Write(serializedEntry)
+	return err
+}
+
+// readUtxoRecord reads a record of a UTXO snapshot from the passed reader and
+// returns the outpoint key and serialized utxo entry.
+func readUtxoRecord(r *bufio.Reader) ([]byte, []byte, error) {
+	var hash chainhash.Hash
+	if _, err := io.ReadFull(r, hash[:]); err != nil {
+		return nil, nil, err
+	}
+	index, err := readVLQ(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if index > math.MaxUint32 {
+		return nil, nil, errDeserialize("output index overflows uint32")
+	}
+	key := make([]byte, chainhash.HashSize+serializeSizeVLQ(index))
+	copy(key, hash[:])
+	putVLQ(key[chainhash.HashSize:], index)
+
+	size, err := readVLQ(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if size == 0 || size > maxUtxoEntrySize {
+		return nil, nil, errDeserialize(fmt.Sprintf("utxo entry size "+
+			"%d is out of range", size))
+	}
+	serializedEntry := make([]byte, size)
+	if _, err := io.ReadFull(r, serializedEntry); err != nil {
+		return nil, nil, err
+	}
+	return key, serializedEntry, nil
+}
+
+// writeAssetRecord writes the passed asset state key and serialized entry to the
+// writer in the format of the asset records of a UTXO snapshot.
+func writeAssetRecord(w io.Writer, key, serializedEntry []byte) error {
+	if _, err := w.Write([]byte{byte(len(key))}); err != nil {
+		return err
+	}
+	return writeUtxoRecord(w, key, serializedEntry)
+}
+
+// readAssetRecord reads an asset record of a UTXO snapshot from the passed
+// reader and returns the asset state key and serialized entry after ensuring
+// the entry is valid for the kind of key.
+func readAssetRecord(r *bufio.Reader) ([]byte, []byte, error) {
+	keySize, err := r.ReadByte()
+	if err != nil {
+		return nil, nil, err
+	}
+	if keySize != 8 && keySize != chainhash.HashSize {
+		return nil, nil, errDeserialize(fmt.Sprintf("asset state key "+
+			"size %d is invalid", keySize))
+	}
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return nil, nil, err
+	}
+	size, err := readVLQ(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if size == 0 || size > maxAssetEntrySize {
+		return nil, nil, errDeserialize(fmt.Sprintf("asset entry size "+
+			"%d is out of range", size))
+	}
+	serializedEntry := make([]byte, size)
+	if _, err := io.ReadFull(r, serializedEntry); err != nil {
+		return nil, nil, err
+	}
+
+	// Minted Ethereum transactions hold the guid of the asset they were
+	// minted as while assets hold their state.
+	if keySize == chainhash.HashSize {
+		if size != 8 || byteOrder.Uint64(serializedEntry) == 0 {
+			return nil, nil, errDeserialize("invalid asset of " +
+				"minted Ethereum transaction")
+		}
+		return key, serializedEntry, nil
+	}
+	if _, err := deserializeAssetEntry(serializedEntry); err != nil {
+		return nil, nil, err
+	}
+	return key, serializedEntry, nil
+}
+
+// finishUtxoHash returns the hash of the utxo records which were written to the
+// passed sha256 hasher.
+func finishUtxoHash(hasher hash.Hash) chainhash.Hash {
+	return chainhash.HashH(hasher.Sum(nil))
+}
+
+// writeUtxoSet writes the records of all unspent transaction outputs in the
+// passed utxo set bucket to the writer.
+func writeUtxoSet(w io.Writer, utxoBucket database.Bucket) error {
+	cursor := utxoBucket.Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		err := writeUtxoRecord(w, cursor.Key(), cursor.Value())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAssetSet writes the records of all assets in the passed asset state
+// bucket to the writer.
+func writeAssetSet(w io.Writer, assetBucket database.Bucket) error {
+	cursor := assetBucket.Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		err := writeAssetRecord(w, cursor.Key(), cursor.Value())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countBucketEntries returns the number of entries in the passed bucket.
+func countBucketEntries(bucket database.Bucket) uint64 {
+	var count uint64
+	cursor := bucket.Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		count++
+	}
+	return count
+}
+
+// DumpUTXOSnapshot writes a snapshot of the unspent transaction outputs as of
+// the end of the current best chain to the passed writer.  It can be loaded to
+// bootstrap new nodes once its hash is pinned by the chain parameters.  The
+// chain is locked while the snapshot is written.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) DumpUTXOSnapshot(w io.Writer) (*UTXOSnapshotInfo, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	// The snapshot is written from the utxo set in the database, so it
+	// needs to include the outputs which are only cached so far.
+	tip := b.bestChain.Tip()
+	if err := b.utxoCache.flush(tip, false); err != nil {
+		return nil, err
+	}
+
+	info := &UTXOSnapshotInfo{BlockHash: tip.hash, Height: tip.height}
+	err := b.db.View(func(dbTx database.Tx) error {
+		utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+		assetBucket := dbTx.Metadata().Bucket(assetStateBucketName)
+		info.NumUTXOs = countBucketEntries(utxoBucket)
+
+		var header [utxoSnapshotHeaderSize]byte
+		copy(header[:], utxoSnapshotMagic[:])
+		byteOrder.PutUint32(header[4:], utxoSnapshotVersion)
+		copy(header[8:], tip.hash[:])
+		offset := 8 + chainhash.HashSize
+		byteOrder.PutUint32(header[offset:], uint32(tip.height))
+		byteOrder.PutUint64(header[offset+4:], b.stateSnapshot.TotalTxns)
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+
+		for height := int32(1); height <= tip.height; height++ {
+			node := b.bestChain.nodeByHeight(height)
+			header := node.Header()
+			if err := header.Serialize(w); err != nil {
+				return err
+			}
+			if !isAuxPow(&header, b.chainParams) {
+				continue
+			}
+			auxPow, err := dbFetchAuxPow(dbTx, &node.hash)
+			if err != nil {
+				return err
+			}
+			if err := auxPow.Serialize(w); err != nil {
+				return err
+			}
+		}
+
+		hasher := sha256.New()
+		mw := io.MultiWriter(w, hasher)
+		var count [8]byte
+		byteOrder.PutUint64(count[:], info.NumUTXOs)
+		if _, err := w.Write(count[:]); err != nil {
+			return err
+		}
+		if err := writeUtxoSet(mw, utxoBucket); err != nil {
+			return err
+		}
+		byteOrder.PutUint64(count[:], countBucketEntries(assetBucket))
+		if _, err := w.Write(count[:]); err != nil {
+			return err
+		}
+		if err := writeAssetSet(mw, assetBucket); err != nil {
+			return err
+		}
+		info.UTXOHash = finishUtxoHash(hasher)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// findUTXOSnapshot returns the UTXO snapshot pinned by the chain parameters
+// which was created at the passed block, or nil if there is none.
+func findUTXOSnapshot(params *chaincfg.Params, hash *chainhash.Hash, height int32) *chaincfg.UTXOSnapshot {
+	for i := range params.UTXOSnapshots {
+		snapshot := &params.UTXOSnapshots[i]
+		if snapshot.Height == height && snapshot.BlockHash.IsEqual(hash) {
+			return snapshot
+		}
+	}
+	return nil
+}
+
+// loadUTXOSnapshot initializes the chain state from the UTXO snapshot read
+// from the passed reader.  The chain must not contain any blocks other than
+// the genesis block, unless the same snapshot has been loaded before in which
+// case it is ignored.  The snapshot must be pinned by the chain parameters.
+//
+// The blocks before the snapshot are considered valid, however, they are
+// validated as they are downloaded and the chain refuses to load once they
+// turn out to not match the snapshot.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) loadUTXOSnapshot(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var header [utxoSnapshotHeaderSize]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return fmt.Errorf("unable to read utxo snapshot: %v", err)
+	}
+	if string(header[:4]) != string(utxoSnapshotMagic[:]) {
+		return fmt.Errorf("the file is not a utxo snapshot")
+	}
+	if version := byteOrder.Uint32(header[4:]); version != utxoSnapshotVersion {
+		return fmt.Errorf("unsupported utxo snapshot version %d", version)
+	}
+	var snapshotHash chainhash.Hash
+	copy(snapshotHash[:], header[8:])
+	offset := 8 + chainhash.HashSize
+	height := int32(byteOrder.Uint32(header[offset:]))
+	totalTxns := byteOrder.Uint64(header[offset+4:])
+
+	// Nothing to do when the snapshot has already been loaded.
+	if b.snapshot != nil && b.snapshot.node.hash == snapshotHash {
+		log.Infof("UTXO snapshot of block %v has already been loaded",
+			snapshotHash)
+		return nil
+	}
+	if b.bestChain.Tip().height != 0 {
+		return fmt.Errorf("a utxo snapshot can only be loaded into a " +
+			"new database")
+	}
+	pinned := findUTXOSnapshot(b.chainParams, &snapshotHash, height)
+	if pinned == nil || height <= 0 {
+		return fmt.Errorf("the utxo snapshot of block %v (height %d) "+
+			"is not known for the %s network", snapshotHash, height,
+			b.chainParams.Name)
+	}
+
+	log.Infof("Loading UTXO snapshot of block %v (height %d)...",
+		snapshotHash, height)
+
+	// Load the headers of the chain which ends in the snapshot block.  The
+	// blocks are considered valid since the pinned block hash commits to
+	// all of them.  That isn't the case for the auxpows of merged mined
+	// headers, so they are checked to provide the proof of work.
+	nodes := make([]blockNode, height)
+	var auxPowHeaders []wire.BlockHeader
+	parent := b.bestChain.Genesis()
+	for i := range nodes {
+		var header wire.BlockHeader
+		if err := header.Deserialize(br); err != nil {
+			return fmt.Errorf("unable to read utxo snapshot: %v", err)
+		}
+		if isAuxPow(&header, b.chainParams) {
+			header.AuxPow = new(wire.AuxPow)
+			if err := header.AuxPow.Deserialize(br); err != nil {
+				return fmt.Errorf("unable to read utxo "+
+					"snapshot: %v", err)
+			}
+			err := checkProofOfWork(&header, b.chainParams, BFNone)
+			if err != nil {
+				return fmt.Errorf("header %d of the utxo "+
+					"snapshot has an invalid auxpow: %v",
+					i+1, err)
+			}
+			auxPowHeaders = append(auxPowHeaders, header)
+		}
+		if header.PrevBlock != parent.hash {
+			return fmt.Errorf("header %d of the utxo snapshot does "+
+				"not connect to the previous one", i+1)
+		}
+		node := &nodes[i]
+		initBlockNode(node, &header, parent)
+		node.status = statusValid
+		parent = node
+	}
+	tip := parent
+	if tip.hash != snapshotHash {
+		return fmt.Errorf("the headers of the utxo snapshot end in "+
+			"block %v instead of %v", tip.hash, snapshotHash)
+	}
+
+	// Clear the utxo set and asset state in case an earlier attempt to load
+	// a snapshot was interrupted.  They are empty otherwise since the
+	// outputs of the genesis block are not spendable.
+	err := b.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		for _, bucketName := range [][]byte{utxoSetBucketName,
+			assetStateBucketName} {
+
+			if err := meta.DeleteBucket(bucketName); err != nil {
+				return err
+			}
+			if _, err := meta.CreateBucket(bucketName); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Load the unspent transaction outputs in batches while hashing them.
+	var numUTXOsBytes [8]byte
+	if _, err := io.ReadFull(br, numUTXOsBytes[:]); err != nil {
+		return fmt.Errorf("unable to read utxo snapshot: %v", err)
+	}
+	numUTXOs := byteOrder.Uint64(numUTXOsBytes[:])
+	hasher := sha256.New()
+	utxoSetState := newUtxoSetState()
+	for loaded := uint64(0); loaded < numUTXOs; {
+		err := b.db.Update(func(dbTx database.Tx) error {
+			utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+			for i := 0; i < utxoSnapshotBatchSize && loaded < numUTXOs; i++ {
+				key, serialized, err := readUtxoRecord(br)
+				if err != nil {
+					return fmt.Errorf("unable to read utxo "+
+						"snapshot: %v", err)
+				}
+				entry, err := deserializeUtxoEntry(serialized)
+				if err != nil {
+					return fmt.Errorf("utxo snapshot contains "+
+						"corrupt utxo entry: %v", err)
+				}
+				utxoSetState.update(key, serialized,
+					entry.Amount(), false)
+				err = writeUtxoRecord(hasher, key, serialized)
+				if err != nil {
+					return err
+				}
+				if err := utxoBucket.Put(key, serialized); err != nil {
+					return err
+				}
+				loaded++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Load the state of the assets while hashing them along with the
+	// unspent transaction outputs.
+	var numAssetsBytes [8]byte
+	if _, err := io.ReadFull(br, numAssetsBytes[:]); err != nil {
+		return fmt.Errorf("unable to read utxo snapshot: %v", err)
+	}
+	numAssets := byteOrder.Uint64(numAssetsBytes[:])
+	for loaded := uint64(0); loaded < numAssets; {
+		err := b.db.Update(func(dbTx database.Tx) error {
+			assetBucket := dbTx.Metadata().Bucket(assetStateBucketName)
+			for i := 0; i < utxoSnapshotBatchSize && loaded < numAssets; i++ {
+				key, serialized, err := readAssetRecord(br)
+				if err != nil {
+					return fmt.Errorf("unable to read utxo "+
+						"snapshot: %v", err)
+				}
+				err = writeAssetRecord(hasher, key, serialized)
+				if err != nil {
+					return err
+				}
+				if err := assetBucket.Put(key, serialized); err != nil {
+					return err
+				}
+				loaded++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if utxoHash := finishUtxoHash(hasher); utxoHash != *pinned.UTXOHash {
+		return fmt.Errorf("the hash %v of the utxo snapshot does not "+
+			"match the expected hash %v", utxoHash, pinned.UTXOHash)
+	}
+
+	// Store the headers of the chain along with the new best state and the
+	// state of the snapshot.  The size of the snapshot block isn't known
+	// since it isn't available.
+	state := newBestState(tip, 0, 0, 0, totalTxns, tip.CalcPastMedianTime())
+	snapshot := &utxoSnapshotState{
+		node:         tip,
+		utxoHash:     *pinned.UTXOHash,
+		validatedTip: b.bestChain.Genesis(),
+		status:       utxoSnapshotPending,
+	}
+	err = b.db.Update(func(dbTx database.Tx) error {
+		for _, bucketName := range [][]byte{snapshotUtxoSetBucketName,
+			snapshotAssetStateBucketName} {
+
+			_, err := dbTx.Metadata().CreateBucketIfNotExists(
+				bucketName)
+			if err != nil {
+				return err
+			}
+		}
+		for i := range nodes {
+			node := &nodes[i]
+			if err := dbStoreBlockNode(dbTx, node); err != nil {
+				return err
+			}
+			err := dbPutBlockIndex(dbTx, &node.hash, node.height)
+			if err != nil {
+				return err
+			}
+		}
+		for i := range auxPowHeaders {
+			if err := dbPutAuxPow(dbTx, &auxPowHeaders[i]); err != nil {
+				return err
+			}
+		}
+		if err := dbPutBestState(dbTx, state, tip.workSum); err != nil {
+			return err
+		}
+		err = dbPutUtxoSetState(dbTx, utxoSetState, tip.height,
+			totalTxns)
+		if err != nil {
+			return err
+		}
+		err = dbPutUtxoStateConsistency(dbTx, &tip.hash)
+		if err != nil {
+			return err
+		}
+		return dbPutUtxoSnapshotState(dbTx, snapshot)
+	})
+	if err != nil {
+		return err
+	}
+	b.utxoCache.reset(tip)
+
+	for i := range nodes {
+		b.index.AddNode(&nodes[i])
+	}
+	b.index.dirty = make(map[*blockNode]struct{})
+	b.bestChain.SetTip(tip)
+	b.bestHeader.SetTip(tip)
+	b.stateSnapshot = state
+	b.snapshot = snapshot
+
+	log.Infof("Loaded UTXO snapshot with %d unspent transaction outputs, "+
+		"the blocks before it are validated as they are downloaded",
+		numUTXOs)
+	return nil
+}
+
+// needsSnapshotHistory returns whether the passed node is one of the blocks
+// before the UTXO snapshot the chain was bootstrapped from whose data is needed
+// to validate the snapshot and isn't available yet.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) needsSnapshotHistory(node *blockNode) bool {
+	return b.snapshot != nil && b.snapshot.status == utxoSnapshotPending &&
+		node.height <= b.snapshot.node.height &&
+		!b.index.NodeStatus(node).HaveData() && b.bestChain.Contains(node)
+}
+
+// processSnapshotHistoryBlock stores the passed block, which must be one of the
+// blocks before the UTXO snapshot the chain was bootstrapped from, and
+// validates the blocks before the snapshot which are available.  Blocks which
+// fail the checks that don't depend on the utxo set are rejected without being
+// stored since their data might have been altered.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) processSnapshotHistoryBlock(node *blockNode, block *btcutil.Block, flags BehaviorFlags) error {
+	err := checkBlockSanity(block, b.chainParams, b.timeSource, flags)
+	if err != nil {
+		return err
+	}
+	if err := b.checkBlockContext(block, node.parent, flags); err != nil {
+		return err
+	}
+
+	block.SetHeight(node.height)
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbStoreBlock(dbTx, block)
+	})
+	if err != nil {
+		return err
+	}
+	b.index.SetStatusFlags(node, statusDataStored)
+	b.setReceived(node)
+	if err := b.index.flushToDB(); err != nil {
+		return err
+	}
+
+	return b.validateSnapshotHistory()
+}
+
+// validateSnapshotHistory connects the blocks before the UTXO snapshot the chain
+// was bootstrapped from which are available to the separate utxo set they are
+// validated against, starting with the block after the most recent validated
+// one.  Once the snapshot block has been connected, the resulting unspent
+// transaction outputs are compared against the snapshot.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) validateSnapshotHistory() error {
+	snapshot := b.snapshot
+	if snapshot == nil || snapshot.status != utxoSnapshotPending {
+		return nil
+	}
+
+	for snapshot.validatedTip != snapshot.node {
+		node := b.bestChain.NodeByHeight(snapshot.validatedTip.height + 1)
+		if !b.index.NodeStatus(node).HaveData() {
+			return nil
+		}
+
+		var block *btcutil.Block
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			block, err = dbFetchBlockByNode(dbTx, node)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		view := NewUtxoViewpoint()
+		view.utxoBucketName = snapshotUtxoSetBucketName
+		view.assetBucketName = snapshotAssetStateBucketName
+		view.SetBestHash(&snapshot.validatedTip.hash)
+		err = b.checkConnectBlock(node, block, view, nil)
+		if err != nil {
+			if _, ok := err.(RuleError); ok {
+				log.Errorf("Block %v (height %d) before the UTXO "+
+					"snapshot is invalid: %v", node.hash,
+					node.height, err)
+				return b.invalidateUTXOSnapshot()
+			}
+			return err
+		}
+
+		snapshot.validatedTip = node
+		err = b.db.Update(func(dbTx database.Tx) error {
+			if err := dbPutUtxoView(dbTx, view); err != nil {
+				return err
+			}
+			if err := dbPutViewAssets(dbTx, view); err != nil {
+				return err
+			}
+			return dbPutUtxoSnapshotState(dbTx, snapshot)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// All blocks before the snapshot have been connected, so compare the
+	// resulting unspent transaction outputs and asset state against the
+	// snapshot.
+	hasher := sha256.New()
+	err := b.db.View(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		utxoBucket := meta.Bucket(snapshotUtxoSetBucketName)
+		if err := writeUtxoSet(hasher, utxoBucket); err != nil {
+			return err
+		}
+		return writeAssetSet(hasher,
+			meta.Bucket(snapshotAssetStateBucketName))
+	})
+	if err != nil {
+		return err
+	}
+	if utxoHash := finishUtxoHash(hasher); utxoHash != snapshot.utxoHash {
+		log.Errorf("The unspent transaction outputs as of block %v "+
+			"have the hash %v, which does not match the hash %v of "+
+			"the UTXO snapshot", snapshot.node.hash, utxoHash,
+			snapshot.utxoHash)
+		return b.invalidateUTXOSnapshot()
+	}
+
+	snapshot.status = utxoSnapshotValid
+	err = b.db.Update(func(dbTx database.Tx) error {
+		for _, bucketName := range [][]byte{snapshotUtxoSetBucketName,
+			snapshotAssetStateBucketName} {
+
+			err := dbTx.Metadata().DeleteBucket(bucketName)
+			if err != nil {
+				return err
+			}
+		}
+		return dbPutUtxoSnapshotState(dbTx, snapshot)
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Validated the UTXO snapshot of block %v (height %d)",
+		snapshot.node.hash, snapshot.node.height)
+	return nil
+}
+
+// invalidateUTXOSnapshot marks the UTXO snapshot the chain was bootstrapped from
+// as invalid.  The chain refuses to load once this is the case.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) invalidateUTXOSnapshot() error {
+	b.snapshot.status = utxoSnapshotInvalid
+	log.Criticalf("The UTXO snapshot of block %v is invalid, the block "+
+		"database must be removed and the chain downloaded again",
+		b.snapshot.node.hash)
+	return b.db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoSnapshotState(dbTx, b.snapshot)
+	})
+}
+
+// SnapshotHistoryBlocks returns the hashes of up to the passed number of blocks
+// before the UTXO snapshot the chain was bootstrapped from which are needed to
+// validate the snapshot and are not available yet, starting with the oldest.
+// Nothing is returned when the chain was not bootstrapped from a snapshot or
+// the snapshot has been validated already.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) SnapshotHistoryBlocks(maxHashes int) []chainhash.Hash {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	snapshot := b.snapshot
+	if snapshot == nil || snapshot.status != utxoSnapshotPending {
+		return nil
+	}
+
+	var hashes []chainhash.Hash
+	height := snapshot.validatedTip.height + 1
+	for ; height <= snapshot.node.height && len(hashes) < maxHashes; height++ {
+		node := b.bestChain.nodeByHeight(height)
+		if !b.index.NodeStatus(node).HaveData() {
+			hashes = append(hashes, node.hash)
+		}
+	}
+	return hashes
+}
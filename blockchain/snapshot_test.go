@@ -0,0 +1,146 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// TestUTXOSnapshot ensures a chain can be bootstrapped from a dumped UTXO
+// snapshot once it is pinned by the chain parameters, and that the blocks
+// before the snapshot are validated against it as they are processed.
+func TestUTXOSnapshot(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	chain, teardownFunc, err := chainSetup("utxosnapshot", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+
+	// Create a chain of blocks and dump a snapshot at its tip.
+	var blocks []*btcutil.Block
+	parent := params.GenesisBlock
+	for height := int32(1); height <= 6; height++ {
+		block := newTestBlock(parent, height, 0, params)
+		blocks = append(blocks, block)
+		parent = block.MsgBlock()
+	}
+	for _, block := range blocks[:5] {
+		if _, _, err := chain.ProcessBlock(block, BFNone); err != nil {
+			teardownFunc()
+			t.Fatalf("ProcessBlock: unexpected error: %v", err)
+		}
+	}
+	var buf bytes.Buffer
+	info, err := chain.DumpUTXOSnapshot(&buf)
+	if err != nil {
+		teardownFunc()
+		t.Fatalf("DumpUTXOSnapshot: unexpected error: %v", err)
+	}
+	stats, err := chain.UtxoSetStats(info.Height)
+	teardownFunc()
+	if err != nil {
+		t.Fatalf("UtxoSetStats: unexpected error: %v", err)
+	}
+	if info.BlockHash != *blocks[4].Hash() || info.Height != 5 ||
+		info.NumUTXOs != 5 {
+
+		t.Fatalf("DumpUTXOSnapshot: unexpected snapshot - got %v (%d) "+
+			"with %d utxos, want %v (5) with 5 utxos", info.BlockHash,
+			info.Height, info.NumUTXOs, blocks[4].Hash())
+	}
+	snapshot := buf.Bytes()
+
+	// Create a new chain to load the snapshot into.
+	newChain, newTeardownFunc, err := chainSetup("utxosnapshotload", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer newTeardownFunc()
+	loadSnapshot := func() error {
+		newChain.chainLock.Lock()
+		defer newChain.chainLock.Unlock()
+		return newChain.loadUTXOSnapshot(bytes.NewReader(snapshot))
+	}
+
+	// Snapshots which are not pinned or don't match the pinned hash are
+	// rejected.
+	if err := loadSnapshot(); err == nil {
+		t.Fatal("loadUTXOSnapshot: loaded snapshot which is not pinned")
+	}
+	newChain.chainParams.UTXOSnapshots = []chaincfg.UTXOSnapshot{{
+		Height:    info.Height,
+		BlockHash: &info.BlockHash,
+		UTXOHash:  &chainhash.Hash{},
+	}}
+	if err := loadSnapshot(); err == nil {
+		t.Fatal("loadUTXOSnapshot: loaded snapshot with mismatched hash")
+	}
+	if best := newChain.BestSnapshot(); best.Height != 0 {
+		t.Fatalf("BestSnapshot: unexpected height %d after rejected "+
+			"snapshot", best.Height)
+	}
+
+	newChain.chainParams.UTXOSnapshots[0].UTXOHash = &info.UTXOHash
+	if err := loadSnapshot(); err != nil {
+		t.Fatalf("loadUTXOSnapshot: unexpected error: %v", err)
+	}
+	if best := newChain.BestSnapshot(); best.Hash != *blocks[4].Hash() {
+		t.Fatalf("BestSnapshot: unexpected best block - got %v, want %v",
+			best.Hash, blocks[4].Hash())
+	}
+	newStats, err := newChain.UtxoSetStats(info.Height)
+	if err != nil {
+		t.Fatalf("UtxoSetStats: unexpected error: %v", err)
+	}
+	if *newStats != *stats {
+		t.Fatalf("UtxoSetStats: unexpected stats of loaded snapshot - "+
+			"got %+v, want %+v", newStats, stats)
+	}
+	entry, err := newChain.FetchUtxoEntry(
+		*wire.NewOutPoint(blocks[2].Transactions()[0].Hash(), 0))
+	if err != nil || entry == nil {
+		t.Fatalf("FetchUtxoEntry: missing snapshot utxo (err %v)", err)
+	}
+
+	// Process the blocks before the snapshot out of order and ensure only
+	// the missing ones are reported until the snapshot is validated.
+	process := func(block *btcutil.Block) {
+		t.Helper()
+		if _, _, err := newChain.ProcessBlock(block, BFNone); err != nil {
+			t.Fatalf("ProcessBlock: unexpected error: %v", err)
+		}
+	}
+	process(blocks[1])
+	want := []chainhash.Hash{*blocks[0].Hash(), *blocks[2].Hash(),
+		*blocks[3].Hash(), *blocks[4].Hash()}
+	if got := newChain.SnapshotHistoryBlocks(10); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SnapshotHistoryBlocks: unexpected blocks - got %v, "+
+			"want %v", got, want)
+	}
+	for _, i := range []int{0, 2, 3, 4} {
+		process(blocks[i])
+	}
+	if got := newChain.SnapshotHistoryBlocks(10); len(got) != 0 {
+		t.Fatalf("SnapshotHistoryBlocks: unexpected blocks %v once "+
+			"validated", got)
+	}
+	if status := newChain.snapshot.status; status != utxoSnapshotValid {
+		t.Fatalf("unexpected snapshot status %d once validated", status)
+	}
+
+	// Blocks after the snapshot extend the chain as usual.
+	isMainChain, _, err := newChain.ProcessBlock(blocks[5], BFNone)
+	if err != nil || !isMainChain {
+		t.Fatalf("ProcessBlock: unexpected result - main chain %v, "+
+			"err %v", isMainChain, err)
+	}
+}
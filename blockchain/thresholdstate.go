@@ -302,6 +302,15 @@ func (b *BlockChain) deploymentState(prevNode *blockNode, deploymentID uint32) (
 	}
 
 	deployment := &b.chainParams.Deployments[deploymentID]
+
+	// Deployments buried at a given height are active from that height on
+	// regardless of the votes cast for them.
+	if deployment.AlwaysActiveHeight != 0 && prevNode != nil &&
+		prevNode.height+1 >= deployment.AlwaysActiveHeight {
+
+		return ThresholdActive, nil
+	}
+
 	checker := deploymentChecker{deployment: deployment, chain: b}
 	cache := &b.deploymentCaches[deploymentID]
 
@@ -573,6 +573,56 @@ func upgradeUtxoSetToV2(db database.DB, interrupt <-chan struct{}) error {
 	return nil
 }
 
+// maybeUpgradeAssetState upgrades the utxo set and spend journal of databases
+// created before they stored the assets carried by outputs to the latest
+// versions and creates the buckets which house the asset state.  This is only
+// possible when the database does not contain any blocks other than the genesis
+// block since the assets carried by the outputs of others are unknown, so an
+// error is returned for those.
+func maybeUpgradeAssetState(db database.DB) error {
+	return db.Update(func(dbTx database.Tx) error {
+		utxoSetVersion := dbFetchVersion(dbTx, utxoSetVersionKeyName)
+		spendJournalVersion := dbFetchVersion(dbTx,
+			spendJournalVersionKeyName)
+		if utxoSetVersion >= latestUtxoSetBucketVersion &&
+			spendJournalVersion >= latestSpendJournalBucketVersion {
+
+			return nil
+		}
+
+		meta := dbTx.Metadata()
+		state, err := deserializeBestChainState(meta.Get(chainStateKeyName))
+		if err != nil {
+			return err
+		}
+		if state.height > 0 {
+			return fmt.Errorf("the block database was created by an " +
+				"older version which did not store the assets " +
+				"carried by unspent transaction outputs -- it " +
+				"must be deleted and the chain downloaded again")
+		}
+
+		log.Infof("Upgrading the utxo set to v%d and the spend journal "+
+			"to v%d", latestUtxoSetBucketVersion,
+			latestSpendJournalBucketVersion)
+		buckets := [][]byte{utxoSetBucketName, spendJournalBucketName,
+			assetStateBucketName, assetUndoBucketName}
+		for _, bucketName := range buckets {
+			_, err := meta.CreateBucketIfNotExists(bucketName)
+			if err != nil {
+				return err
+			}
+		}
+		err = dbPutVersion(dbTx, utxoSetVersionKeyName,
+			latestUtxoSetBucketVersion)
+		if err != nil {
+			return err
+		}
+		return dbPutVersion(dbTx, spendJournalVersionKeyName,
+			latestSpendJournalBucketVersion)
+	})
+}
+
 // maybeUpgradeDbBuckets checks the database version of the buckets used by this
 // package and performs any needed upgrades to bring them to the latest version.
 //
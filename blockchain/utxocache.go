@@ -0,0 +1,490 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// cachedEntryOverhead is the approximate number of bytes a cached utxo
+	// entry uses besides its public key script.  It accounts for the
+	// outpoint key and entry pointer in the map along with the map
+	// overhead and the entry itself.
+	cachedEntryOverhead = 128
+)
+
+var (
+	// utxoStateConsistencyKeyName is the name of the db key used to store
+	// the hash of the block the utxo set in the database is consistent
+	// with.  The utxo cache is written to the database separately from the
+	// best chain state, so the blocks after it need to be connected to the
+	// utxo set again after an unclean shutdown.
+	utxoStateConsistencyKeyName = []byte("utxostateconsistency")
+)
+
+// UtxoCacheStats houses statistics about the utxo cache.
+type UtxoCacheStats struct {
+	// Entries is the number of cached utxo entries, including the entries
+	// of spent outputs which still need to be removed from the database.
+	Entries uint64
+
+	// DirtyEntries is the number of cached utxo entries which have been
+	// modified since they were last written to the database.
+	DirtyEntries uint64
+
+	// Size is the approximate number of bytes used by the cached entries.
+	Size uint64
+
+	// MaxSize is the number of bytes the cache is flushed at.
+	MaxSize uint64
+
+	// Hits is the number of outputs which were found in the cache.
+	Hits uint64
+
+	// Misses is the number of outputs which were loaded from the
+	// database.
+	Misses uint64
+
+	// Flushes is the number of times the cache has been written to the
+	// database.
+	Flushes uint64
+
+	// LastFlushHash is the hash of the block the utxo set in the database
+	// is consistent with.
+	LastFlushHash chainhash.Hash
+
+	// LastFlushHeight is the height of the block the utxo set in the
+	// database is consistent with.
+	LastFlushHeight int32
+
+	// LastFlushTime is the time the cache was last written to the
+	// database.
+	LastFlushTime time.Time
+}
+
+// utxoCache is a write-back cache of the unspent transaction outputs in the
+// database as of the end of the main chain.  Connected blocks modify the
+// cached entries which are only written to the database once the cache
+// exceeds its maximum size, the flush interval has elapsed or the chain is
+// shut down.  Outputs which are created and spent in between are never
+// written at all.
+//
+// The entries in the cache are marked modified when they need to be written
+// to the database and fresh when they don't exist there yet.  Spent outputs
+// which exist in the database are kept as spent entries until they are
+// removed by the next flush.
+type utxoCache struct {
+	db            database.DB
+	maxSize       uint64
+	flushInterval time.Duration
+
+	mtx           sync.Mutex
+	entries       map[wire.OutPoint]*UtxoEntry
+	size          uint64
+	dirty         uint64
+	hits          uint64
+	misses        uint64
+	flushes       uint64
+	lastFlushNode *blockNode
+	lastFlushTime time.Time
+}
+
+// newUtxoCache returns a new utxo cache for the utxo set in the passed
+// database which is flushed once it exceeds the passed size in bytes or the
+// passed interval has elapsed since the last flush.  A zero size writes the
+// changes of every block to the database right away and a zero interval
+// disables periodic flushes.
+func newUtxoCache(db database.DB, maxSize uint64, flushInterval time.Duration) *utxoCache {
+	return &utxoCache{
+		db:            db,
+		maxSize:       maxSize,
+		flushInterval: flushInterval,
+		entries:       make(map[wire.OutPoint]*UtxoEntry),
+		lastFlushTime: time.Now(),
+	}
+}
+
+// entrySize returns the approximate number of bytes the passed entry uses in
+// the cache.
+func entrySize(entry *UtxoEntry) uint64 {
+	return cachedEntryOverhead + uint64(len(entry.pkScript))
+}
+
+// addEntry adds the passed entry to the cache and updates its size.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) addEntry(outpoint wire.OutPoint, entry *UtxoEntry) {
+	c.entries[outpoint] = entry
+	c.size += entrySize(entry)
+	if entry.isModified() {
+		c.dirty++
+	}
+}
+
+// removeEntry removes the passed entry from the cache and updates its size.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) removeEntry(outpoint wire.OutPoint, entry *UtxoEntry) {
+	delete(c.entries, outpoint)
+	c.size -= entrySize(entry)
+	if entry.isModified() {
+		c.dirty--
+	}
+}
+
+// fetchEntries loads the passed outputs into the passed entries from the cache,
+// or from the database when they are not cached.  Spent outputs, or those which
+// otherwise don't exist, result in nil entries.  The loaded entries are copies
+// which can be modified freely.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) fetchEntries(outpoints map[wire.OutPoint]struct{}, entries map[wire.OutPoint]*UtxoEntry) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var missing []wire.OutPoint
+	for outpoint := range outpoints {
+		entry, ok := c.entries[outpoint]
+		if !ok {
+			missing = append(missing, outpoint)
+			continue
+		}
+
+		c.hits++
+		if entry.IsSpent() {
+			entries[outpoint] = nil
+			continue
+		}
+		entries[outpoint] = entry.Clone()
+		entries[outpoint].packedFlags &^= tfModified | tfFresh
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// Load the outputs which are not cached from the database and cache
+	// them as long as the cache has room for them.
+	c.misses += uint64(len(missing))
+	return c.db.View(func(dbTx database.Tx) error {
+		utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+		for _, outpoint := range missing {
+			entry, err := dbFetchUtxoEntryFromBucket(utxoBucket,
+				outpoint)
+			if err != nil {
+				return err
+			}
+
+			entries[outpoint] = entry
+			if entry != nil && c.size+entrySize(entry) <= c.maxSize {
+				c.addEntry(outpoint, entry.Clone())
+			}
+		}
+
+		return nil
+	})
+}
+
+// commit applies the entries of the passed view which have been modified to
+// the cache.  The view must be from the point of view of the end of the main
+// chain.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) commit(view *UtxoViewpoint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for outpoint, entry := range view.entries {
+		if entry == nil || !entry.isModified() {
+			continue
+		}
+
+		// The cached entry knows whether the output exists in the
+		// database.  Otherwise, only outputs created in the view are
+		// known not to exist there.
+		fresh := entry.isFresh()
+		if cached, ok := c.entries[outpoint]; ok {
+			fresh = cached.isFresh()
+			c.removeEntry(outpoint, cached)
+		}
+
+		// Spent outputs which don't exist in the database are simply
+		// forgotten, while the others have to be removed from it.
+		if entry.IsSpent() {
+			if !fresh {
+				c.addEntry(outpoint, &UtxoEntry{
+					packedFlags: tfSpent | tfModified,
+				})
+			}
+			continue
+		}
+
+		// The public key script is copied so the cached entry does not
+		// keep the scripts of the entire transaction it was created by
+		// in memory.
+		cached := entry.Clone()
+		cached.pkScript = make([]byte, len(entry.pkScript))
+		copy(cached.pkScript, entry.pkScript)
+		cached.packedFlags = entry.packedFlags&tfCoinBase | tfModified
+		if fresh {
+			cached.packedFlags |= tfFresh
+		}
+		c.addEntry(outpoint, cached)
+	}
+}
+
+// flush writes the modified entries to the database and records that the utxo
+// set in the database is consistent with the passed block.  All entries are
+// evicted from the cache when requested, otherwise the unspent ones are kept.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) flush(node *blockNode, evict bool) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	err := c.db.Update(func(dbTx database.Tx) error {
+		utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+		for outpoint, entry := range c.entries {
+			if !entry.isModified() {
+				continue
+			}
+
+			err := dbPutUtxoEntry(utxoBucket, outpoint, entry)
+			if err != nil {
+				return err
+			}
+		}
+
+		return dbPutUtxoStateConsistency(dbTx, &node.hash)
+	})
+	if err != nil {
+		return err
+	}
+
+	if evict {
+		c.entries = make(map[wire.OutPoint]*UtxoEntry)
+		c.size = 0
+	} else {
+		for outpoint, entry := range c.entries {
+			if entry.IsSpent() {
+				c.size -= entrySize(entry)
+				delete(c.entries, outpoint)
+				continue
+			}
+
+			entry.packedFlags &^= tfModified | tfFresh
+		}
+	}
+	c.dirty = 0
+	c.flushes++
+	c.lastFlushNode = node
+	c.lastFlushTime = time.Now()
+	log.Debugf("Flushed the utxo cache at block %v (height %d)",
+		node.hash, node.height)
+	return nil
+}
+
+// maybeFlush flushes the cache at the passed block when it exceeds its maximum
+// size, in which case all entries are evicted, or when the flush interval has
+// elapsed since the last flush.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) maybeFlush(node *blockNode) error {
+	c.mtx.Lock()
+	size := c.size
+	sinceLastFlush := time.Since(c.lastFlushTime)
+	c.mtx.Unlock()
+
+	if size > c.maxSize {
+		return c.flush(node, true)
+	}
+	if c.flushInterval > 0 && sinceLastFlush >= c.flushInterval {
+		return c.flush(node, false)
+	}
+	return nil
+}
+
+// reset evicts all entries from the cache and records that the utxo set in the
+// database is consistent with the passed block.  It is used once the utxo set
+// in the database has been replaced.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) reset(node *blockNode) {
+	c.mtx.Lock()
+	c.entries = make(map[wire.OutPoint]*UtxoEntry)
+	c.size = 0
+	c.dirty = 0
+	c.lastFlushNode = node
+	c.lastFlushTime = time.Now()
+	c.mtx.Unlock()
+}
+
+// lastFlushHeight returns the height of the block the utxo set in the database
+// is consistent with.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) lastFlushHeight() int32 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.lastFlushNode == nil {
+		return 0
+	}
+	return c.lastFlushNode.height
+}
+
+// stats returns the statistics of the cache.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) stats() *UtxoCacheStats {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	stats := &UtxoCacheStats{
+		Entries:       uint64(len(c.entries)),
+		DirtyEntries:  c.dirty,
+		Size:          c.size,
+		MaxSize:       c.maxSize,
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Flushes:       c.flushes,
+		LastFlushTime: c.lastFlushTime,
+	}
+	if c.lastFlushNode != nil {
+		stats.LastFlushHash = c.lastFlushNode.hash
+		stats.LastFlushHeight = c.lastFlushNode.height
+	}
+	return stats
+}
+
+// dbFetchUtxoStateConsistency uses an existing database transaction to fetch
+// the hash of the block the utxo set in the database is consistent with.  Nil
+// is returned when it has not been stored yet.
+func dbFetchUtxoStateConsistency(dbTx database.Tx) *chainhash.Hash {
+	serialized := dbTx.Metadata().Get(utxoStateConsistencyKeyName)
+	if len(serialized) != chainhash.HashSize {
+		return nil
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], serialized)
+	return &hash
+}
+
+// dbPutUtxoStateConsistency uses an existing database transaction to store the
+// hash of the block the utxo set in the database is consistent with.
+func dbPutUtxoStateConsistency(dbTx database.Tx, hash *chainhash.Hash) error {
+	return dbTx.Metadata().Put(utxoStateConsistencyKeyName, hash[:])
+}
+
+// initConsistentState brings the utxo set in the database up to date with the
+// best chain after an unclean shutdown.  The blocks connected after the utxo
+// cache was last flushed are connected to the utxo set again and the result
+// is flushed.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) initConsistentState(interrupt <-chan struct{}) error {
+	var hash *chainhash.Hash
+	err := b.db.View(func(dbTx database.Tx) error {
+		hash = dbFetchUtxoStateConsistency(dbTx)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The utxo set was written along with the best chain state before the
+	// consistency marker was introduced, so it is consistent with the tip
+	// when there is none.
+	tip := b.bestChain.Tip()
+	if hash == nil {
+		err := b.db.Update(func(dbTx database.Tx) error {
+			return dbPutUtxoStateConsistency(dbTx, &tip.hash)
+		})
+		if err != nil {
+			return err
+		}
+		b.utxoCache.reset(tip)
+		return nil
+	}
+
+	// Blocks are only disconnected once the utxo set in the database is
+	// consistent with them, so it is always consistent with a block in the
+	// best chain.
+	node := b.index.LookupNode(hash)
+	if node == nil || !b.bestChain.Contains(node) {
+		return database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("the utxo set is consistent "+
+				"with block %v which is not in the main chain",
+				hash),
+		}
+	}
+	b.utxoCache.reset(node)
+	if node == tip {
+		return nil
+	}
+
+	log.Infof("Reconnecting the blocks from height %d to %d to the utxo "+
+		"set after an unclean shutdown", node.height+1, tip.height)
+	for n := b.bestChain.Next(node); n != nil; n = b.bestChain.Next(n) {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		var block *btcutil.Block
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			block, err = dbFetchBlockByNode(dbTx, n)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		view := b.newUtxoViewpoint()
+		view.SetBestHash(&n.parent.hash)
+		if err := view.fetchInputUtxos(b.db, block); err != nil {
+			return err
+		}
+		if err := view.connectTransactions(block, nil); err != nil {
+			return err
+		}
+		b.utxoCache.commit(view)
+		if err := b.utxoCache.maybeFlush(n); err != nil {
+			return err
+		}
+	}
+
+	return b.utxoCache.flush(tip, false)
+}
+
+// FlushUtxoCache writes the changes to the unspent transaction outputs which
+// are only cached in memory to the database.  It should be called before the
+// database is closed, otherwise the blocks connected since the cache was last
+// flushed are connected to the utxo set again on the next start.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FlushUtxoCache() error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	return b.utxoCache.flush(b.bestChain.Tip(), false)
+}
+
+// UtxoCacheStats returns the statistics of the cache of the unspent
+// transaction outputs.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UtxoCacheStats() *UtxoCacheStats {
+	return b.utxoCache.stats()
+}
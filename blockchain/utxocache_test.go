@@ -0,0 +1,146 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// TestUtxoCache ensures the utxo cache only writes the unspent transaction
+// outputs to the database once it is flushed, outputs created and spent in
+// between are never written, and the utxo set in the database is brought up to
+// date with the best chain after an unclean shutdown.
+func TestUtxoCache(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	chain, teardownFunc, err := chainSetup("utxocache", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+	chain.utxoCache.maxSize = 1 << 30
+
+	// fetchDbEntry fetches an output from the utxo set in the database.
+	fetchDbEntry := func(outpoint wire.OutPoint) *UtxoEntry {
+		t.Helper()
+		var entry *UtxoEntry
+		err := chain.db.View(func(dbTx database.Tx) error {
+			var err error
+			entry, err = dbFetchUtxoEntry(dbTx, outpoint)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("dbFetchUtxoEntry: unexpected error: %v", err)
+		}
+		return entry
+	}
+
+	// Mine enough blocks for the first coinbase to mature and ensure their
+	// outputs are only cached.
+	var blocks []*btcutil.Block
+	parent := params.GenesisBlock
+	for height := int32(1); height <= int32(params.CoinbaseMaturity)+1; height++ {
+		block := newTestBlock(parent, height, 0, params)
+		if _, _, err := chain.ProcessBlock(block, BFNone); err != nil {
+			t.Fatalf("ProcessBlock: unexpected error: %v", err)
+		}
+		blocks = append(blocks, block)
+		parent = block.MsgBlock()
+	}
+	stats := chain.UtxoCacheStats()
+	if stats.DirtyEntries != uint64(len(blocks)) || stats.LastFlushHeight != 0 {
+		t.Fatalf("UtxoCacheStats: unexpected stats %+v, want %d dirty "+
+			"entries flushed at height 0", stats, len(blocks))
+	}
+	coinbaseOut := *wire.NewOutPoint(blocks[0].Transactions()[0].Hash(), 0)
+	if entry, err := chain.FetchUtxoEntry(coinbaseOut); err != nil || entry == nil {
+		t.Fatalf("FetchUtxoEntry: missing cached utxo (err %v)", err)
+	}
+	if entry := fetchDbEntry(coinbaseOut); entry != nil {
+		t.Fatal("utxo written to the database before flushing")
+	}
+
+	// Spend the first coinbase, which has never been written to the
+	// database, so it is simply forgotten.
+	spend := wire.NewMsgTx(1)
+	spend.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: coinbaseOut,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	spend.AddTxOut(&wire.TxOut{
+		Value:    blocks[0].MsgBlock().Transactions[0].TxOut[0].Value - 1000,
+		PkScript: []byte{txscript.OP_TRUE},
+	})
+	height := int32(len(blocks)) + 1
+	block := newTestBlockWithTxns(parent, height, 1000, params, spend)
+	if _, _, err := chain.ProcessBlock(block, BFNone); err != nil {
+		t.Fatalf("ProcessBlock: unexpected error: %v", err)
+	}
+	if _, ok := chain.utxoCache.entries[coinbaseOut]; ok {
+		t.Fatal("spent fresh utxo is still cached")
+	}
+	if entry, err := chain.FetchUtxoEntry(coinbaseOut); err != nil || entry != nil {
+		t.Fatalf("FetchUtxoEntry: unexpected spent utxo %v (err %v)",
+			entry, err)
+	}
+
+	// Create a new chain instance on the same database without flushing
+	// the cache to simulate an unclean shutdown and ensure the utxo set is
+	// brought up to date with the best chain.
+	newChain, err := New(&Config{
+		DB:          chain.db,
+		ChainParams: chain.chainParams,
+		TimeSource:  NewMedianTime(),
+		SigCache:    txscript.NewSigCache(1000),
+	})
+	if err != nil {
+		t.Fatalf("New: unexpected error: %v", err)
+	}
+	stats = newChain.UtxoCacheStats()
+	if stats.LastFlushHash != *block.Hash() || stats.DirtyEntries != 0 {
+		t.Fatalf("UtxoCacheStats: unexpected stats %+v after recovery, "+
+			"want flushed at block %v", stats, block.Hash())
+	}
+	spendHash := spend.TxHash()
+	spendOut := *wire.NewOutPoint(&spendHash, 0)
+	if entry := fetchDbEntry(spendOut); entry == nil {
+		t.Fatal("utxo of reconnected block missing from the database")
+	}
+	if entry := fetchDbEntry(coinbaseOut); entry != nil {
+		t.Fatal("utxo spent by reconnected block in the database")
+	}
+	utxoStats, err := newChain.UtxoSetStats(height)
+	if err != nil {
+		t.Fatalf("UtxoSetStats: unexpected error: %v", err)
+	}
+	var hash chainhash.Hash
+	err = newChain.db.View(func(dbTx database.Tx) error {
+		s := newUtxoSetState()
+		cursor := dbTx.Metadata().Bucket(utxoSetBucketName).Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			entry, err := deserializeUtxoEntry(cursor.Value())
+			if err != nil {
+				return err
+			}
+			s.update(cursor.Key(), cursor.Value(), entry.Amount(),
+				false)
+		}
+		hash = s.muHash.hash()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to scan utxo set: %v", err)
+	}
+	if utxoStats.Hash != hash {
+		t.Fatalf("utxo set hash %v after recovery does not match %v",
+			hash, utxoStats.Hash)
+	}
+}
@@ -0,0 +1,385 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// -----------------------------------------------------------------------------
+// The statistics of the utxo set are maintained incrementally as blocks are
+// connected and disconnected.  The state of the statistics as of the end of the
+// main chain is stored under the utxo set state key:
+//
+//   <num utxos><total amount><size><muhash>
+//
+//   Field          Type     Size
+//   num utxos      uint64   8
+//   total amount   uint64   8
+//   size           uint64   8
+//   muhash         []byte   muHashSize
+//
+// The statistics as of each main chain block are stored in the utxo set stats
+// bucket keyed by the height of the block:
+//
+//   <total txns><num utxos><total amount><size><utxo set hash>
+//
+//   Field          Type             Size
+//   total txns     uint64           8
+//   num utxos      uint64           8
+//   total amount   uint64           8
+//   size           uint64           8
+//   utxo set hash  chainhash.Hash   chainhash.HashSize
+//
+// The utxo set hash is a muHash of the utxo records in the format of UTXO
+// snapshots, so it does not depend on the order of the outputs, and the size
+// is the total size of those records.  All integers are encoded in little
+// endian.
+// -----------------------------------------------------------------------------
+
+const (
+	// utxoSetStateSize is the size of the serialized utxo set state.
+	utxoSetStateSize = 8 + 8 + 8 + muHashSize
+
+	// utxoSetStatsSize is the size of the serialized statistics of the
+	// utxo set as of a block.
+	utxoSetStatsSize = 8 + 8 + 8 + 8 + chainhash.HashSize
+)
+
+var (
+	// utxoSetStateKeyName is the name of the db key used to store the
+	// state of the statistics of the utxo set as of the end of the main
+	// chain.
+	utxoSetStateKeyName = []byte("utxosetstate")
+
+	// utxoSetStatsBucketName is the name of the db bucket used to house the
+	// statistics of the utxo set as of each main chain block.
+	utxoSetStatsBucketName = []byte("utxosetstats")
+)
+
+// UtxoSetStats houses the statistics of the unspent transaction outputs as of a
+// main chain block.
+type UtxoSetStats struct {
+	// Height is the height of the block.
+	Height int32
+
+	// BlockHash is the hash of the block.
+	BlockHash chainhash.Hash
+
+	// TotalTxns is the number of transactions in the main chain up to and
+	// including the block.
+	TotalTxns uint64
+
+	// NumUtxos is the number of unspent transaction outputs.
+	NumUtxos uint64
+
+	// TotalAmount is the total amount of the unspent transaction outputs.
+	TotalAmount btcutil.Amount
+
+	// Size is the serialized size of the unspent transaction outputs.
+	Size uint64
+
+	// Hash is the order independent hash of the unspent transaction
+	// outputs.  Nodes have identical utxo sets when their hashes match.
+	Hash chainhash.Hash
+}
+
+// utxoSetState houses the state of the statistics of the utxo set, which is
+// updated as outputs are added and removed.
+type utxoSetState struct {
+	numUtxos    uint64
+	totalAmount uint64
+	size        uint64
+	muHash      *muHash
+}
+
+// newUtxoSetState returns the state of the statistics of an empty utxo set.
+func newUtxoSetState() *utxoSetState {
+	return &utxoSetState{muHash: newMuHash()}
+}
+
+// update adds the utxo with the passed outpoint key and serialized entry to the
+// state, or removes it when remove is set.
+func (s *utxoSetState) update(key, serializedEntry []byte, amount int64, remove bool) {
+	var record bytes.Buffer
+	record.Grow(len(key) + 10 + len(serializedEntry))
+	writeUtxoRecord(&record, key, serializedEntry)
+
+	if remove {
+		s.numUtxos--
+		s.totalAmount -= uint64(amount)
+		s.size -= uint64(record.Len())
+		s.muHash.remove(record.Bytes())
+		return
+	}
+	s.numUtxos++
+	s.totalAmount += uint64(amount)
+	s.size += uint64(record.Len())
+	s.muHash.add(record.Bytes())
+}
+
+// updateEntry adds the passed utxo entry to the state, or removes it when
+// remove is set.
+func (s *utxoSetState) updateEntry(outpoint wire.OutPoint, entry *UtxoEntry, remove bool) error {
+	serialized, err := serializeUtxoEntry(entry)
+	if err != nil {
+		return err
+	}
+	key := outpointKey(outpoint)
+	s.update(*key, serialized, entry.Amount(), remove)
+	recycleOutpointKey(key)
+	return nil
+}
+
+// applyBlock updates the state with the outputs the passed block creates and
+// spends, which are described by the passed spent txouts.  The changes are
+// reverted when disconnect is set.
+func (s *utxoSetState) applyBlock(node *blockNode, block *btcutil.Block, stxos []SpentTxOut, disconnect bool) error {
+	var stxoIdx int
+	for _, tx := range block.Transactions() {
+		// Remove the outputs the transaction spends.
+		isCoinBase := IsCoinBase(tx)
+		if !isCoinBase {
+			for _, txIn := range tx.MsgTx().TxIn {
+				stxo := &stxos[stxoIdx]
+				stxoIdx++
+				entry := &UtxoEntry{
+					amount:      stxo.Amount,
+					assetGuid:   stxo.AssetGuid,
+					assetValue:  stxo.AssetValue,
+					pkScript:    stxo.PkScript,
+					blockHeight: stxo.Height,
+				}
+				if stxo.IsCoinBase {
+					entry.packedFlags |= tfCoinBase
+				}
+				err := s.updateEntry(txIn.PreviousOutPoint, entry,
+					!disconnect)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// The coinbases of the blocks which violate BIP0030 overwrote
+		// unspent outputs with identical outpoints, so they are skipped
+		// to keep the number of outputs accurate.
+		if isCoinBase && isBIP0030Node(node) {
+			continue
+		}
+
+		// Add the outputs the transaction creates, except for those
+		// which are provably unspendable and never enter the utxo set.
+		assets := txAssetOutputs(tx.MsgTx())
+		outpoint := wire.OutPoint{Hash: *tx.Hash()}
+		for txOutIdx, txOut := range tx.MsgTx().TxOut {
+			if txscript.IsUnspendable(txOut.PkScript) {
+				continue
+			}
+			entry := &UtxoEntry{
+				amount:      txOut.Value,
+				assetGuid:   assets[uint32(txOutIdx)].guid,
+				assetValue:  assets[uint32(txOutIdx)].value,
+				pkScript:    txOut.PkScript,
+				blockHeight: node.height,
+			}
+			if isCoinBase {
+				entry.packedFlags |= tfCoinBase
+			}
+			outpoint.Index = uint32(txOutIdx)
+			err := s.updateEntry(outpoint, entry, disconnect)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// serializeUtxoSetState returns the serialization of the passed state of the
+// statistics of the utxo set.
+func serializeUtxoSetState(s *utxoSetState) []byte {
+	serialized := make([]byte, utxoSetStateSize)
+	byteOrder.PutUint64(serialized[0:8], s.numUtxos)
+	byteOrder.PutUint64(serialized[8:16], s.totalAmount)
+	byteOrder.PutUint64(serialized[16:24], s.size)
+	copy(serialized[24:], s.muHash.serialize())
+	return serialized
+}
+
+// dbFetchUtxoSetState uses an existing database transaction to fetch the state
+// of the statistics of the utxo set as of the end of the main chain.  Nil is
+// returned when the statistics have not been computed yet.
+func dbFetchUtxoSetState(dbTx database.Tx) (*utxoSetState, error) {
+	serialized := dbTx.Metadata().Get(utxoSetStateKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	if len(serialized) != utxoSetStateSize {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt utxo set state",
+		}
+	}
+
+	return &utxoSetState{
+		numUtxos:    byteOrder.Uint64(serialized[0:8]),
+		totalAmount: byteOrder.Uint64(serialized[8:16]),
+		size:        byteOrder.Uint64(serialized[16:24]),
+		muHash:      deserializeMuHash(serialized[24:]),
+	}, nil
+}
+
+// dbPutUtxoSetState uses an existing database transaction to store the passed
+// state of the statistics of the utxo set as of the passed main chain block,
+// which must be the end of the main chain, along with the statistics as of the
+// block.
+func dbPutUtxoSetState(dbTx database.Tx, s *utxoSetState, height int32, totalTxns uint64) error {
+	serialized := serializeUtxoSetState(s)
+	if err := dbTx.Metadata().Put(utxoSetStateKeyName, serialized); err != nil {
+		return err
+	}
+
+	stats := make([]byte, utxoSetStatsSize)
+	byteOrder.PutUint64(stats[0:8], totalTxns)
+	copy(stats[8:32], serialized[0:24])
+	utxoSetHash := s.muHash.hash()
+	copy(stats[32:], utxoSetHash[:])
+
+	var key [4]byte
+	byteOrder.PutUint32(key[:], uint32(height))
+	bucket := dbTx.Metadata().Bucket(utxoSetStatsBucketName)
+	return bucket.Put(key[:], stats)
+}
+
+// dbRemoveUtxoSetStats uses an existing database transaction to remove the
+// statistics of the utxo set as of the main chain block at the passed height.
+func dbRemoveUtxoSetStats(dbTx database.Tx, height int32) error {
+	var key [4]byte
+	byteOrder.PutUint32(key[:], uint32(height))
+	bucket := dbTx.Metadata().Bucket(utxoSetStatsBucketName)
+	return bucket.Delete(key[:])
+}
+
+// dbUpdateUtxoSetState uses an existing database transaction to update the
+// statistics of the utxo set with the passed block, which is either connected
+// to the end of the main chain or disconnected from it.
+func dbUpdateUtxoSetState(dbTx database.Tx, node *blockNode, block *btcutil.Block, stxos []SpentTxOut, totalTxns uint64, disconnect bool) error {
+	s, err := dbFetchUtxoSetState(dbTx)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return AssertError("utxo set statistics have not been computed")
+	}
+	if err := s.applyBlock(node, block, stxos, disconnect); err != nil {
+		return err
+	}
+
+	if disconnect {
+		if err := dbRemoveUtxoSetStats(dbTx, node.height); err != nil {
+			return err
+		}
+		return dbPutUtxoSetState(dbTx, s, node.height-1, totalTxns)
+	}
+	return dbPutUtxoSetState(dbTx, s, node.height, totalTxns)
+}
+
+// initUtxoSetStats computes the statistics of the utxo set as of the end of the
+// main chain when they have not been computed yet, which is the case for new
+// databases and databases created before the statistics were maintained.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) initUtxoSetStats() error {
+	var initialized bool
+	err := b.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		_, err := meta.CreateBucketIfNotExists(utxoSetStatsBucketName)
+		if err != nil {
+			return err
+		}
+		initialized = meta.Get(utxoSetStateKeyName) != nil
+		return nil
+	})
+	if err != nil || initialized {
+		return err
+	}
+
+	tip := b.bestChain.Tip()
+	if tip.height > 0 {
+		log.Infof("Computing the statistics of the utxo set...")
+	}
+	return b.db.Update(func(dbTx database.Tx) error {
+		s := newUtxoSetState()
+		cursor := dbTx.Metadata().Bucket(utxoSetBucketName).Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			entry, err := deserializeUtxoEntry(cursor.Value())
+			if err != nil {
+				return err
+			}
+			s.update(cursor.Key(), cursor.Value(), entry.Amount(), false)
+		}
+		return dbPutUtxoSetState(dbTx, s, tip.height,
+			b.stateSnapshot.TotalTxns)
+	})
+}
+
+// UtxoSetStats returns the statistics of the unspent transaction outputs as of
+// the main chain block at the passed height.  The statistics are not available
+// for the blocks before the statistics were first computed, which happens when
+// a database created by an earlier version is upgraded or a UTXO snapshot is
+// loaded.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UtxoSetStats(height int32) (*UtxoSetStats, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	node := b.bestChain.NodeByHeight(height)
+	if node == nil {
+		str := fmt.Sprintf("no block at height %d exists", height)
+		return nil, errNotInMainChain(str)
+	}
+
+	var serialized []byte
+	err := b.db.View(func(dbTx database.Tx) error {
+		var key [4]byte
+		byteOrder.PutUint32(key[:], uint32(height))
+		bucket := dbTx.Metadata().Bucket(utxoSetStatsBucketName)
+		serialized = bucket.Get(key[:])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if serialized == nil {
+		return nil, fmt.Errorf("the statistics of the utxo set are not "+
+			"available for height %d", height)
+	}
+	if len(serialized) != utxoSetStatsSize {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt utxo set statistics",
+		}
+	}
+
+	stats := &UtxoSetStats{
+		Height:      height,
+		BlockHash:   node.hash,
+		TotalTxns:   byteOrder.Uint64(serialized[0:8]),
+		NumUtxos:    byteOrder.Uint64(serialized[8:16]),
+		TotalAmount: btcutil.Amount(byteOrder.Uint64(serialized[16:24])),
+		Size:        byteOrder.Uint64(serialized[24:32]),
+	}
+	copy(stats.Hash[:], serialized[32:])
+	return stats, nil
+}
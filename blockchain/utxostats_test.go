@@ -0,0 +1,167 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/database"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// TestMuHash ensures the hash of a set does not depend on the order of its
+// elements and that removing elements reverts adding them.
+func TestMuHash(t *testing.T) {
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+
+	h1 := newMuHash()
+	h1.add(a)
+	h1.add(b)
+	h1.add(c)
+
+	h2 := newMuHash()
+	h2.add(c)
+	h2.add(a)
+	h2.add([]byte("d"))
+	h2.add(b)
+	h2.remove([]byte("d"))
+	if h1.hash() != h2.hash() {
+		t.Fatalf("hash depends on the order of elements - got %v, "+
+			"want %v", h2.hash(), h1.hash())
+	}
+
+	h3 := deserializeMuHash(h1.serialize())
+	if h3.hash() != h1.hash() {
+		t.Fatalf("hash changed by serialization - got %v, want %v",
+			h3.hash(), h1.hash())
+	}
+
+	h1.remove(a)
+	h1.remove(b)
+	h1.remove(c)
+	if h1.hash() != newMuHash().hash() {
+		t.Fatalf("removing all elements does not result in the hash " +
+			"of the empty set")
+	}
+	h2.remove(a)
+	if h2.hash() == newMuHash().hash() {
+		t.Fatal("hash of non-empty set matches the empty set")
+	}
+}
+
+// TestUtxoSetStats ensures the statistics of the utxo set are maintained as
+// blocks are connected and disconnected and match the statistics computed from
+// the utxo set.
+func TestUtxoSetStats(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	chain, teardownFunc, err := chainSetup("utxosetstats", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// scanStats computes the statistics from the utxo set.
+	scanStats := func() *utxoSetState {
+		if err := chain.FlushUtxoCache(); err != nil {
+			t.Fatalf("FlushUtxoCache: unexpected error: %v", err)
+		}
+		s := newUtxoSetState()
+		err := chain.db.View(func(dbTx database.Tx) error {
+			bucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+			cursor := bucket.Cursor()
+			for ok := cursor.First(); ok; ok = cursor.Next() {
+				entry, err := deserializeUtxoEntry(cursor.Value())
+				if err != nil {
+					return err
+				}
+				s.update(cursor.Key(), cursor.Value(),
+					entry.Amount(), false)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to scan utxo set: %v", err)
+		}
+		return s
+	}
+	checkStats := func(height int32) *UtxoSetStats {
+		t.Helper()
+		stats, err := chain.UtxoSetStats(height)
+		if err != nil {
+			t.Fatalf("UtxoSetStats: unexpected error: %v", err)
+		}
+		want := scanStats()
+		if stats.NumUtxos != want.numUtxos ||
+			uint64(stats.TotalAmount) != want.totalAmount ||
+			stats.Size != want.size || stats.Hash != want.muHash.hash() {
+
+			t.Fatalf("UtxoSetStats: stats at height %d do not match "+
+				"utxo set - got %d utxos, %v, size %d, hash %v, "+
+				"want %d utxos, %d, size %d, hash %v", height,
+				stats.NumUtxos, stats.TotalAmount, stats.Size,
+				stats.Hash, want.numUtxos, want.totalAmount,
+				want.size, want.muHash.hash())
+		}
+		return stats
+	}
+	checkStats(0)
+
+	// Mine enough blocks for the first coinbase to mature.
+	var blocks []*btcutil.Block
+	parent := params.GenesisBlock
+	for height := int32(1); height <= int32(params.CoinbaseMaturity)+1; height++ {
+		block := newTestBlock(parent, height, 0, params)
+		if _, _, err := chain.ProcessBlock(block, BFNone); err != nil {
+			t.Fatalf("ProcessBlock: unexpected error: %v", err)
+		}
+		blocks = append(blocks, block)
+		parent = block.MsgBlock()
+	}
+	beforeSpend := checkStats(int32(len(blocks)))
+
+	// Connect a block which spends the first coinbase into two outputs,
+	// one of which is provably unspendable.
+	spend := wire.NewMsgTx(1)
+	spend.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{
+			Hash: *blocks[0].Transactions()[0].Hash(),
+		},
+		Sequence: wire.MaxTxInSequenceNum,
+	})
+	spend.AddTxOut(&wire.TxOut{
+		Value:    blocks[0].MsgBlock().Transactions[0].TxOut[0].Value - 1000,
+		PkScript: []byte{txscript.OP_TRUE},
+	})
+	spend.AddTxOut(&wire.TxOut{PkScript: []byte{txscript.OP_RETURN}})
+	height := int32(len(blocks)) + 1
+	spendBlock := newTestBlockWithTxns(parent, height, 1000, params, spend)
+	if _, _, err := chain.ProcessBlock(spendBlock, BFNone); err != nil {
+		t.Fatalf("ProcessBlock: unexpected error: %v", err)
+	}
+	stats := checkStats(height)
+	if stats.NumUtxos != beforeSpend.NumUtxos+1 ||
+		stats.TotalTxns != beforeSpend.TotalTxns+2 {
+
+		t.Fatalf("UtxoSetStats: unexpected counts - got %d utxos and "+
+			"%d txns, want %d and %d", stats.NumUtxos,
+			stats.TotalTxns, beforeSpend.NumUtxos+1,
+			beforeSpend.TotalTxns+2)
+	}
+
+	// Disconnect the block and ensure the statistics are reverted.
+	if err := chain.InvalidateBlock(spendBlock.Hash()); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	if got := checkStats(height - 1); *got != *beforeSpend {
+		t.Fatalf("UtxoSetStats: unexpected stats after disconnect - "+
+			"got %+v, want %+v", got, beforeSpend)
+	}
+	if _, err := chain.UtxoSetStats(height); err == nil {
+		t.Fatal("UtxoSetStats: returned stats of disconnected block")
+	}
+}
@@ -28,12 +28,16 @@ const (
 	// tfModified indicates that a txout has been modified since it was
 	// loaded.
 	tfModified
+
+	// tfFresh indicates that a txout has been created since it was last
+	// written to the database, so it does not exist there yet.
+	tfFresh
 )
 
 // UtxoEntry houses details about an individual transaction output in a utxo
 // view such as whether or not it was contained in a coinbase tx, the height of
 // the block that contains the tx, whether or not it is spent, its public key
-// script, and how much it pays.
+// script, how much it pays, and the asset value assigned to it, if any.
 type UtxoEntry struct {
 	// NOTE: Additions, deletions, or modifications to the order of the
 	// definitions in this struct should not be changed without considering
@@ -42,6 +46,8 @@ type UtxoEntry struct {
 	// lot of these in memory, so a few extra bytes of padding adds up.
 
 	amount      int64
+	assetGuid   uint64 // Guid of the asset carried by the output or 0.
+	assetValue  int64  // Asset value carried by the output.
 	pkScript    []byte // The public key script for the output.
 	blockHeight int32  // Height of block containing tx.
 
@@ -58,6 +64,12 @@ func (entry *UtxoEntry) isModified() bool {
 	return entry.packedFlags&tfModified == tfModified
 }
 
+// isFresh returns whether or not the output has been created since the utxo
+// set was last written to the database.
+func (entry *UtxoEntry) isFresh() bool {
+	return entry.packedFlags&tfFresh == tfFresh
+}
+
 // IsCoinBase returns whether or not the output was contained in a coinbase
 // transaction.
 func (entry *UtxoEntry) IsCoinBase() bool {
@@ -97,6 +109,18 @@ func (entry *UtxoEntry) PkScript() []byte {
 	return entry.pkScript
 }
 
+// AssetGuid returns the guid of the asset carried by the output.  Outputs
+// which do not carry any asset return 0.
+func (entry *UtxoEntry) AssetGuid() uint64 {
+	return entry.assetGuid
+}
+
+// AssetValue returns the amount of the asset carried by the output in the
+// smallest unit of the asset.
+func (entry *UtxoEntry) AssetValue() int64 {
+	return entry.assetValue
+}
+
 // Clone returns a shallow copy of the utxo entry.
 func (entry *UtxoEntry) Clone() *UtxoEntry {
 	if entry == nil {
@@ -105,6 +129,8 @@ func (entry *UtxoEntry) Clone() *UtxoEntry {
 
 	return &UtxoEntry{
 		amount:      entry.amount,
+		assetGuid:   entry.assetGuid,
+		assetValue:  entry.assetValue,
 		pkScript:    entry.pkScript,
 		blockHeight: entry.blockHeight,
 		packedFlags: entry.packedFlags,
@@ -121,6 +147,33 @@ func (entry *UtxoEntry) Clone() *UtxoEntry {
 type UtxoViewpoint struct {
 	entries  map[wire.OutPoint]*UtxoEntry
 	bestHash chainhash.Hash
+
+	// utxoBucketName is the name of the db bucket which houses the utxo
+	// set the view loads entries from and is stored to.
+	utxoBucketName []byte
+
+	// cache is the utxo cache the view loads entries from instead of the
+	// database when it is set.
+	cache *utxoCache
+
+	// assets houses the state of the assets loaded into the view keyed by
+	// guid, while prevAssets houses the state the assets changed since the
+	// view was last committed had then.  Assets which don't exist have a
+	// nil entry.
+	assets     map[uint64]*AssetEntry
+	prevAssets map[uint64]*AssetEntry
+
+	// mints houses the guids of the assets the burns of the Ethereum
+	// transactions loaded into the view were minted as keyed by Ethereum
+	// transaction hash, while changedMints houses the hashes whose state
+	// changed since the view was last committed.  Burns which have not
+	// been minted have a zero guid.
+	mints        map[chainhash.Hash]uint64
+	changedMints map[chainhash.Hash]struct{}
+
+	// assetBucketName is the name of the db bucket which houses the asset
+	// state the view loads assets from and is stored to.
+	assetBucketName []byte
 }
 
 // BestHash returns the hash of the best block in the chain the view currently
@@ -143,11 +196,12 @@ func (view *UtxoViewpoint) LookupEntry(outpoint wire.OutPoint) *UtxoEntry {
 	return view.entries[outpoint]
 }
 
-// addTxOut adds the specified output to the view if it is not provably
-// unspendable.  When the view already has an entry for the output, it will be
-// marked unspent.  All fields will be updated for existing entries since it's
-// possible it has changed during a reorg.
-func (view *UtxoViewpoint) addTxOut(outpoint wire.OutPoint, txOut *wire.TxOut, isCoinBase bool, blockHeight int32) {
+// addTxOut adds the specified output, along with the asset value assigned to
+// it, to the view if it is not provably unspendable.  When the view already has
+// an entry for the output, it will be marked unspent.  All fields will be
+// updated for existing entries since it's possible it has changed during a
+// reorg.
+func (view *UtxoViewpoint) addTxOut(outpoint wire.OutPoint, txOut *wire.TxOut, asset assetOutput, isCoinBase bool, blockHeight int32) {
 	// Don't add provably unspendable outputs.
 	if txscript.IsUnspendable(txOut.PkScript) {
 		return
@@ -157,16 +211,24 @@ func (view *UtxoViewpoint) addTxOut(outpoint wire.OutPoint, txOut *wire.TxOut, i
 	// possible (although extremely unlikely) that the existing entry is
 	// being replaced by a different transaction with the same hash.  This
 	// is allowed so long as the previous transaction is fully spent.
+	//
+	// Outputs which are not in the view yet are new, so they are marked
+	// fresh to avoid writing them to the database at all when they are
+	// spent before the utxo cache is flushed.
+	packedFlags := tfModified
 	entry := view.LookupEntry(outpoint)
 	if entry == nil {
 		entry = new(UtxoEntry)
 		view.entries[outpoint] = entry
+		packedFlags |= tfFresh
 	}
 
 	entry.amount = txOut.Value
+	entry.assetGuid = asset.guid
+	entry.assetValue = asset.value
 	entry.pkScript = txOut.PkScript
 	entry.blockHeight = blockHeight
-	entry.packedFlags = tfModified
+	entry.packedFlags = packedFlags
 	if isCoinBase {
 		entry.packedFlags |= tfCoinBase
 	}
@@ -188,7 +250,8 @@ func (view *UtxoViewpoint) AddTxOut(tx *btcutil.Tx, txOutIdx uint32, blockHeight
 	// is allowed so long as the previous transaction is fully spent.
 	prevOut := wire.OutPoint{Hash: *tx.Hash(), Index: txOutIdx}
 	txOut := tx.MsgTx().TxOut[txOutIdx]
-	view.addTxOut(prevOut, txOut, IsCoinBase(tx), blockHeight)
+	asset := txAssetOutputs(tx.MsgTx())[txOutIdx]
+	view.addTxOut(prevOut, txOut, asset, IsCoinBase(tx), blockHeight)
 }
 
 // AddTxOuts adds all outputs in the passed transaction which are not provably
@@ -199,6 +262,7 @@ func (view *UtxoViewpoint) AddTxOuts(tx *btcutil.Tx, blockHeight int32) {
 	// Loop all of the transaction outputs and add those which are not
 	// provably unspendable.
 	isCoinBase := IsCoinBase(tx)
+	assets := txAssetOutputs(tx.MsgTx())
 	prevOut := wire.OutPoint{Hash: *tx.Hash()}
 	for txOutIdx, txOut := range tx.MsgTx().TxOut {
 		// Update existing entries.  All fields are updated because it's
@@ -207,7 +271,8 @@ func (view *UtxoViewpoint) AddTxOuts(tx *btcutil.Tx, blockHeight int32) {
 		// same hash.  This is allowed so long as the previous
 		// transaction is fully spent.
 		prevOut.Index = uint32(txOutIdx)
-		view.addTxOut(prevOut, txOut, isCoinBase, blockHeight)
+		view.addTxOut(prevOut, txOut, assets[prevOut.Index], isCoinBase,
+			blockHeight)
 	}
 }
 
@@ -224,6 +289,10 @@ func (view *UtxoViewpoint) connectTransaction(tx *btcutil.Tx, blockHeight int32,
 		return nil
 	}
 
+	// Update the state of the asset the transaction activates, updates or
+	// issues before its inputs are spent.
+	view.UpdateAssets(tx)
+
 	// Spend the referenced utxos by marking them spent in the view and,
 	// if a slice was provided for the spent txout details, append an entry
 	// to it.
@@ -244,6 +313,8 @@ func (view *UtxoViewpoint) connectTransaction(tx *btcutil.Tx, blockHeight int32,
 				PkScript:   entry.PkScript(),
 				Height:     entry.BlockHeight(),
 				IsCoinBase: entry.IsCoinBase(),
+				AssetGuid:  entry.AssetGuid(),
+				AssetValue: entry.AssetValue(),
 			}
 			*stxos = append(*stxos, stxo)
 		}
@@ -342,6 +413,7 @@ func (view *UtxoViewpoint) disconnectTransactions(db database.DB, block *btcutil
 		// the code relies on its existence in the view in order to
 		// signal modifications have happened.
 		txHash := tx.Hash()
+		assets := txAssetOutputs(tx.MsgTx())
 		prevOut := wire.OutPoint{Hash: *txHash}
 		for txOutIdx, txOut := range tx.MsgTx().TxOut {
 			if txscript.IsUnspendable(txOut.PkScript) {
@@ -351,8 +423,11 @@ func (view *UtxoViewpoint) disconnectTransactions(db database.DB, block *btcutil
 			prevOut.Index = uint32(txOutIdx)
 			entry := view.entries[prevOut]
 			if entry == nil {
+				asset := assets[prevOut.Index]
 				entry = &UtxoEntry{
 					amount:      txOut.Value,
+					assetGuid:   asset.guid,
+					assetValue:  asset.value,
 					pkScript:    txOut.PkScript,
 					blockHeight: block.Height(),
 					packedFlags: packedFlags,
@@ -423,6 +498,8 @@ func (view *UtxoViewpoint) disconnectTransactions(db database.DB, block *btcutil
 			// Restore the utxo using the stxo data from the spend
 			// journal and mark it as modified.
 			entry.amount = stxo.Amount
+			entry.assetGuid = stxo.AssetGuid
+			entry.assetValue = stxo.AssetValue
 			entry.pkScript = stxo.PkScript
 			entry.blockHeight = stxo.Height
 			entry.packedFlags = tfModified
@@ -432,6 +509,11 @@ func (view *UtxoViewpoint) disconnectTransactions(db database.DB, block *btcutil
 		}
 	}
 
+	// Restore the state of the assets changed by the block.
+	if err := view.disconnectAssets(db, block); err != nil {
+		return err
+	}
+
 	// Update the best hash for view to the previous block since all of the
 	// transactions for the current block have been disconnected.
 	view.SetBestHash(&block.MsgBlock().Header.PrevBlock)
@@ -451,7 +533,10 @@ func (view *UtxoViewpoint) Entries() map[wire.OutPoint]*UtxoEntry {
 }
 
 // commit prunes all entries marked modified that are now fully spent and marks
-// all entries as unmodified.
+// all entries as unmodified.  Entries are no longer considered fresh either
+// since they have been committed to the utxo cache, which tracks whether they
+// exist in the database from then on.  The changed assets and mints are no
+// longer tracked either since they have been written to the database.
 func (view *UtxoViewpoint) commit() {
 	for outpoint, entry := range view.entries {
 		if entry == nil || (entry.isModified() && entry.IsSpent()) {
@@ -459,8 +544,10 @@ func (view *UtxoViewpoint) commit() {
 			continue
 		}
 
-		entry.packedFlags ^= tfModified
+		entry.packedFlags &^= tfModified | tfFresh
 	}
+	view.prevAssets = make(map[uint64]*AssetEntry)
+	view.changedMints = make(map[chainhash.Hash]struct{})
 }
 
 // fetchUtxosMain fetches unspent transaction output data about the provided
@@ -476,6 +563,13 @@ func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, outpoints map[wire.Out
 		return nil
 	}
 
+	// Load the requested outputs through the utxo cache when the view is
+	// backed by it since it holds the outputs which have not been written
+	// to the database yet.
+	if view.cache != nil {
+		return view.cache.fetchEntries(outpoints, view.entries)
+	}
+
 	// Load the requested set of unspent transaction outputs from the point
 	// of view of the end of the main chain.
 	//
@@ -484,8 +578,10 @@ func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, outpoints map[wire.Out
 	// so other code can use the presence of an entry in the store as a way
 	// to unnecessarily avoid attempting to reload it from the database.
 	return db.View(func(dbTx database.Tx) error {
+		utxoBucket := dbTx.Metadata().Bucket(view.utxoBucketName)
 		for outpoint := range outpoints {
-			entry, err := dbFetchUtxoEntry(dbTx, outpoint)
+			entry, err := dbFetchUtxoEntryFromBucket(utxoBucket,
+				outpoint)
 			if err != nil {
 				return err
 			}
@@ -538,9 +634,13 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block *btcutil.Block)
 
 	// Loop through all of the transaction inputs (except for the coinbase
 	// which has no inputs) collecting them into sets of what is needed and
-	// what is already known (in-flight).
+	// what is already known (in-flight).  The assets and mints referenced by
+	// the transactions are collected as well.
 	neededSet := make(map[wire.OutPoint]struct{})
+	neededAssets := newNeededAssetState()
 	for i, tx := range transactions[1:] {
+		neededAssets.addTx(tx.MsgTx())
+
 		for _, txIn := range tx.MsgTx().TxIn {
 			// It is acceptable for a transaction input to reference
 			// the output of another transaction in this block only
@@ -572,21 +672,40 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block *btcutil.Block)
 		}
 	}
 
-	// Request the input utxos from the database.
-	return view.fetchUtxosMain(db, neededSet)
+	// Request the input utxos and assets from the database.
+	if err := view.fetchUtxosMain(db, neededSet); err != nil {
+		return err
+	}
+	return view.fetchAssets(db, neededAssets)
 }
 
 // NewUtxoViewpoint returns a new empty unspent transaction output view.
 func NewUtxoViewpoint() *UtxoViewpoint {
 	return &UtxoViewpoint{
-		entries: make(map[wire.OutPoint]*UtxoEntry),
+		entries:         make(map[wire.OutPoint]*UtxoEntry),
+		utxoBucketName:  utxoSetBucketName,
+		assets:          make(map[uint64]*AssetEntry),
+		prevAssets:      make(map[uint64]*AssetEntry),
+		mints:           make(map[chainhash.Hash]uint64),
+		changedMints:    make(map[chainhash.Hash]struct{}),
+		assetBucketName: assetStateBucketName,
 	}
 }
 
+// newUtxoViewpoint returns a new empty unspent transaction output view which
+// loads entries from the point of view of the end of the main chain through
+// the utxo cache.
+func (b *BlockChain) newUtxoViewpoint() *UtxoViewpoint {
+	view := NewUtxoViewpoint()
+	view.cache = b.utxoCache
+	return view
+}
+
 // FetchUtxoView loads unspent transaction outputs for the inputs referenced by
-// the passed transaction from the point of view of the end of the main chain.
-// It also attempts to fetch the utxos for the outputs of the transaction itself
-// so the returned view can be examined for duplicate transactions.
+// the passed transaction, along with the state of the assets and mint it
+// references, from the point of view of the end of the main chain.  It also
+// attempts to fetch the utxos for the outputs of the transaction itself so the
+// returned view can be examined for duplicate transactions.
 //
 // This function is safe for concurrent access however the returned view is NOT.
 func (b *BlockChain) FetchUtxoView(tx *btcutil.Tx) (*UtxoViewpoint, error) {
@@ -605,13 +724,18 @@ func (b *BlockChain) FetchUtxoView(tx *btcutil.Tx) (*UtxoViewpoint, error) {
 		}
 	}
 
-	// Request the utxos from the point of view of the end of the main
-	// chain.
-	view := NewUtxoViewpoint()
+	neededAssets := newNeededAssetState()
+	neededAssets.addTx(tx.MsgTx())
+
+	// Request the utxos and assets from the point of view of the end of
+	// the main chain.
+	view := b.newUtxoViewpoint()
 	b.chainLock.RLock()
-	err := view.fetchUtxosMain(b.db, neededSet)
-	b.chainLock.RUnlock()
-	return view, err
+	defer b.chainLock.RUnlock()
+	if err := view.fetchUtxosMain(b.db, neededSet); err != nil {
+		return view, err
+	}
+	return view, view.fetchAssets(b.db, neededAssets)
 }
 
 // FetchUtxoEntry loads and returns the requested unspent transaction output
@@ -628,15 +752,13 @@ func (b *BlockChain) FetchUtxoEntry(outpoint wire.OutPoint) (*UtxoEntry, error)
 	b.chainLock.RLock()
 	defer b.chainLock.RUnlock()
 
-	var entry *UtxoEntry
-	err := b.db.View(func(dbTx database.Tx) error {
-		var err error
-		entry, err = dbFetchUtxoEntry(dbTx, outpoint)
-		return err
-	})
+	entries := make(map[wire.OutPoint]*UtxoEntry, 1)
+	err := b.utxoCache.fetchEntries(map[wire.OutPoint]struct{}{
+		outpoint: {},
+	}, entries)
 	if err != nil {
 		return nil, err
 	}
 
-	return entry, nil
+	return entries[outpoint], nil
 }
@@ -191,13 +191,28 @@ func isBIP0030Node(node *blockNode) bool {
 //
 // At the target block generation rate for the main network, this is
 // approximately every 4 years.
+//
+// Networks which define a SubsidyReductionPercent instead reduce the subsidy
+// by that percentage every SubsidyReductionInterval blocks, starting from
+// their BaseSubsidy when it is set.
 func CalcBlockSubsidy(height int32, chainParams *chaincfg.Params) int64 {
+	subsidy := chainParams.BaseSubsidy
+	if subsidy == 0 {
+		subsidy = baseSubsidy
+	}
 	if chainParams.SubsidyReductionInterval == 0 {
-		return baseSubsidy
+		return subsidy
 	}
 
-	// Equivalent to: baseSubsidy / 2^(height/subsidyHalvingInterval)
-	return baseSubsidy >> uint(height/chainParams.SubsidyReductionInterval)
+	reductions := height / chainParams.SubsidyReductionInterval
+	if chainParams.SubsidyReductionPercent == 0 {
+		// Equivalent to: subsidy / 2^(height/subsidyHalvingInterval)
+		return subsidy >> uint(reductions)
+	}
+	for i := int32(0); i < reductions && subsidy > 0; i++ {
+		subsidy -= subsidy * chainParams.SubsidyReductionPercent / 100
+	}
+	return subsidy
 }
 
 // CheckTransactionSanity performs some preliminary checks on a transaction to
@@ -298,13 +313,14 @@ func CheckTransactionSanity(tx *btcutil.Tx) error {
 }
 
 // checkProofOfWork ensures the block header bits which indicate the target
-// difficulty is in min/max range and that the block hash is less than the
-// target difficulty as claimed.
+// difficulty is in min/max range and that the proof of work hash of the block
+// selected by the chain parameters is less than the target difficulty as
+// claimed.
 //
 // The flags modify the behavior of this function as follows:
-//  - BFNoPoWCheck: The check to ensure the block hash is less than the target
-//    difficulty is not performed.
-func checkProofOfWork(header *wire.BlockHeader, powLimit *big.Int, flags BehaviorFlags) error {
+//  - BFNoPoWCheck: The check to ensure the proof of work hash is less than the
+//    target difficulty is not performed.
+func checkProofOfWork(header *wire.BlockHeader, params *chaincfg.Params, flags BehaviorFlags) error {
 	// The target difficulty must be larger than zero.
 	target := CompactToBig(header.Bits)
 	if target.Sign() <= 0 {
@@ -314,17 +330,27 @@ func checkProofOfWork(header *wire.BlockHeader, powLimit *big.Int, flags Behavio
 	}
 
 	// The target difficulty must be less than the maximum allowed.
-	if target.Cmp(powLimit) > 0 {
+	if target.Cmp(params.PowLimit) > 0 {
 		str := fmt.Sprintf("block target difficulty of %064x is "+
-			"higher than max of %064x", target, powLimit)
+			"higher than max of %064x", target, params.PowLimit)
 		return ruleError(ErrUnexpectedDifficulty, str)
 	}
 
-	// The block hash must be less than the claimed target unless the flag
-	// to avoid proof of work checks is set.
+	// The proof of work hash must be less than the claimed target unless
+	// the flag to avoid proof of work checks is set.
 	if flags&BFNoPoWCheck != BFNoPoWCheck {
-		// The block hash must be less than the claimed target.
-		hash := header.BlockHash()
+		// The proof of work of merged mined blocks is provided by the
+		// parent block their auxpow commits them to.
+		powHeader := header
+		if isAuxPow(header, params) {
+			if err := CheckAuxPow(header); err != nil {
+				return err
+			}
+			powHeader = &header.AuxPow.ParentBlock
+		}
+
+		// The proof of work hash must be less than the claimed target.
+		hash := PowHash(powHeader, params)
 		hashNum := HashToBig(&hash)
 		if hashNum.Cmp(target) > 0 {
 			str := fmt.Sprintf("block hash of %064x is higher than "+
@@ -339,8 +365,20 @@ func checkProofOfWork(header *wire.BlockHeader, powLimit *big.Int, flags Behavio
 // CheckProofOfWork ensures the block header bits which indicate the target
 // difficulty is in min/max range and that the block hash is less than the
 // target difficulty as claimed.
+//
+// The block hash is the double SHA-256 proof of work hash of Bitcoin.  Use
+// CheckProofOfWorkWithParams for chains with another proof of work hash.
 func CheckProofOfWork(block *btcutil.Block, powLimit *big.Int) error {
-	return checkProofOfWork(&block.MsgBlock().Header, powLimit, BFNone)
+	params := chaincfg.Params{PowLimit: powLimit}
+	return checkProofOfWork(&block.MsgBlock().Header, &params, BFNone)
+}
+
+// CheckProofOfWorkWithParams ensures the block header bits which indicate the
+// target difficulty is in min/max range and that the proof of work hash of the
+// block selected by the passed chain parameters is less than the target
+// difficulty as claimed.
+func CheckProofOfWorkWithParams(block *btcutil.Block, params *chaincfg.Params) error {
+	return checkProofOfWork(&block.MsgBlock().Header, params, BFNone)
 }
 
 // CountSigOps returns the number of signature operations for all transaction
@@ -427,11 +465,11 @@ func CountP2SHSigOps(tx *btcutil.Tx, isCoinBaseTx bool, utxoView *UtxoViewpoint)
 //
 // The flags do not modify the behavior of this function directly, however they
 // are needed to pass along to checkProofOfWork.
-func checkBlockHeaderSanity(header *wire.BlockHeader, powLimit *big.Int, timeSource MedianTimeSource, flags BehaviorFlags) error {
+func checkBlockHeaderSanity(header *wire.BlockHeader, params *chaincfg.Params, timeSource MedianTimeSource, flags BehaviorFlags) error {
 	// Ensure the proof of work bits in the block header is in min/max range
-	// and the block hash is less than the target value described by the
-	// bits.
-	err := checkProofOfWork(header, powLimit, flags)
+	// and the proof of work hash is less than the target value described
+	// by the bits.
+	err := checkProofOfWork(header, params, flags)
 	if err != nil {
 		return err
 	}
@@ -464,10 +502,10 @@ func checkBlockHeaderSanity(header *wire.BlockHeader, powLimit *big.Int, timeSou
 //
 // The flags do not modify the behavior of this function directly, however they
 // are needed to pass along to checkBlockHeaderSanity.
-func checkBlockSanity(block *btcutil.Block, powLimit *big.Int, timeSource MedianTimeSource, flags BehaviorFlags) error {
+func checkBlockSanity(block *btcutil.Block, params *chaincfg.Params, timeSource MedianTimeSource, flags BehaviorFlags) error {
 	msgBlock := block.MsgBlock()
 	header := &msgBlock.Header
-	err := checkBlockHeaderSanity(header, powLimit, timeSource, flags)
+	err := checkBlockHeaderSanity(header, params, timeSource, flags)
 	if err != nil {
 		return err
 	}
@@ -571,8 +609,21 @@ func checkBlockSanity(block *btcutil.Block, powLimit *big.Int, timeSource Median
 
 // CheckBlockSanity performs some preliminary checks on a block to ensure it is
 // sane before continuing with block processing.  These checks are context free.
+//
+// The proof of work is checked against the double SHA-256 block hash of
+// Bitcoin.  Use CheckBlockSanityWithParams for chains with another proof of
+// work hash.
 func CheckBlockSanity(block *btcutil.Block, powLimit *big.Int, timeSource MedianTimeSource) error {
-	return checkBlockSanity(block, powLimit, timeSource, BFNone)
+	params := chaincfg.Params{PowLimit: powLimit}
+	return checkBlockSanity(block, &params, timeSource, BFNone)
+}
+
+// CheckBlockSanityWithParams performs some preliminary checks on a block to
+// ensure it is sane before continuing with block processing, checking its proof
+// of work with the proof of work hash selected by the passed chain parameters.
+// These checks are context free.
+func CheckBlockSanityWithParams(block *btcutil.Block, params *chaincfg.Params, timeSource MedianTimeSource) error {
+	return checkBlockSanity(block, params, timeSource, BFNone)
 }
 
 // ExtractCoinbaseHeight attempts to extract the height of the block from the
@@ -669,6 +720,13 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 		}
 	}
 
+	// Ensure the block carries the chain ID of the chain when merged
+	// mining is supported and isn't merged mined otherwise.
+	err := checkAuxPowChainID(header, b.chainParams)
+	if err != nil {
+		return err
+	}
+
 	// The height of this block is one more than the referenced previous
 	// block.
 	blockHeight := prevNode.height + 1
@@ -865,8 +923,9 @@ func (b *BlockChain) checkBIP0030(node *blockNode, block *btcutil.Block, view *U
 // include verifying all inputs exist, ensuring the coinbase seasoning
 // requirements are met, detecting double spends, validating all values and fees
 // are in the legal range and the total output amount doesn't exceed the input
-// amount, and verifying the signatures to prove the spender was the owner of
-// the bitcoins and therefore allowed to spend them.  As it checks the inputs,
+// amount, ensuring the value of every asset is conserved, and verifying the
+// signatures to prove the spender was the owner of the bitcoins and therefore
+// allowed to spend them.  As it checks the inputs,
 // it also calculates the total fees for the transaction and returns that value.
 //
 // NOTE: The transaction MUST have already been sanity checked with the
@@ -958,6 +1017,15 @@ func CheckTransactionInputs(tx *btcutil.Tx, txHeight int32, utxoView *UtxoViewpo
 		return 0, ruleError(ErrSpendTooHigh, str)
 	}
 
+	// Ensure the asset values spent and created by the transaction are
+	// sane and conserved on networks with assets.
+	if chainParams.AssetsSupported {
+		err := checkAssetInputs(tx, utxoView, chainParams)
+		if err != nil {
+			return 0, err
+		}
+	}
+
 	// NOTE: bitcoind checks if the transaction fees are < 0 here, but that
 	// is an impossible condition because of the check above that ensures
 	// the inputs are >= the outputs.
@@ -1142,16 +1210,10 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *btcutil.Block, vi
 	}
 
 	// Don't run scripts if this node is before the latest known good
-	// checkpoint since the validity is verified via the checkpoints (all
-	// transactions are included in the merkle root hash and any changes
-	// will therefore be detected by the next checkpoint).  This is a huge
+	// checkpoint or an ancestor of the assumed valid block.  This is a huge
 	// optimization because running the scripts is the most time consuming
 	// portion of block handling.
-	checkpoint := b.LatestCheckpoint()
-	runScripts := true
-	if checkpoint != nil && node.height <= checkpoint.Height {
-		runScripts = false
-	}
+	runScripts := b.scriptValidation(node) == ScriptValidationFull
 
 	// Blocks created after the BIP0016 activation time need to have the
 	// pay-to-script-hash checks enabled.
@@ -1260,7 +1322,7 @@ func (b *BlockChain) CheckConnectBlockTemplate(block *btcutil.Block) error {
 		return ruleError(ErrPrevBlockNotBest, str)
 	}
 
-	err := checkBlockSanity(block, b.chainParams.PowLimit, b.timeSource, flags)
+	err := checkBlockSanity(block, b.chainParams, b.timeSource, flags)
 	if err != nil {
 		return err
 	}
@@ -1272,7 +1334,7 @@ func (b *BlockChain) CheckConnectBlockTemplate(block *btcutil.Block) error {
 
 	// Leave the spent txouts entry nil in the state since the information
 	// is not needed and thus extra work can be avoided.
-	view := NewUtxoViewpoint()
+	view := b.newUtxoViewpoint()
 	view.SetBestHash(&tip.hash)
 	newNode := newBlockNode(&header, tip)
 	return b.checkConnectBlock(newNode, block, view, nil)
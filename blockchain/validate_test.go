@@ -63,6 +63,35 @@ func TestSequenceLocksActive(t *testing.T) {
 	}
 }
 
+// TestCalcBlockSubsidy ensures the subsidy is halved on networks without a
+// subsidy reduction percent and reduced by the percent on networks with one.
+func TestCalcBlockSubsidy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		params *chaincfg.Params
+		height int32
+		want   int64
+	}{
+		{"mainnet genesis", &chaincfg.MainNetParams, 0, 5000000000},
+		{"mainnet first halving", &chaincfg.MainNetParams, 210000, 2500000000},
+		{"mainnet second halving", &chaincfg.MainNetParams, 420000, 1250000000},
+		{"syscoin start", &chaincfg.SysRegressionNetParams, 1, 3850000000},
+		{"syscoin before reduction", &chaincfg.SysRegressionNetParams, 149, 3850000000},
+		{"syscoin first reduction", &chaincfg.SysRegressionNetParams, 150, 3657500000},
+		{"syscoin second reduction", &chaincfg.SysRegressionNetParams, 300, 3474625000},
+	}
+
+	for _, test := range tests {
+		got := CalcBlockSubsidy(test.height, test.params)
+		if got != test.want {
+			t.Errorf("CalcBlockSubsidy(%s): unexpected subsidy - got "+
+				"%d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
 // TestCheckConnectBlockTemplate tests the CheckConnectBlockTemplate function to
 // ensure it fails.
 func TestCheckConnectBlockTemplate(t *testing.T) {
@@ -158,6 +187,21 @@ func TestCheckBlockSanity(t *testing.T) {
 	if err != nil {
 		t.Errorf("CheckBlockSanity: %v", err)
 	}
+	err = CheckBlockSanityWithParams(block, &chaincfg.MainNetParams,
+		timeSource)
+	if err != nil {
+		t.Errorf("CheckBlockSanityWithParams: %v", err)
+	}
+
+	// Ensure the proof of work is checked with the proof of work hash
+	// selected by the chain parameters.
+	scryptParams := chaincfg.MainNetParams
+	scryptParams.PowHashAlgorithm = chaincfg.PowHashScrypt
+	err = CheckBlockSanityWithParams(block, &scryptParams, timeSource)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrHighHash {
+		t.Errorf("CheckBlockSanityWithParams: did not get expected "+
+			"error %v - got %v", ErrHighHash, err)
+	}
 
 	// Ensure a block that has a timestamp with a precision higher than one
 	// second fails.
@@ -8,6 +8,7 @@ import (
 	"math"
 
 	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/wire"
 )
 
 const (
@@ -105,7 +106,7 @@ func (c bitConditionChecker) MinerConfirmationWindow() uint32 {
 // This is part of the thresholdConditionChecker interface implementation.
 func (c bitConditionChecker) Condition(node *blockNode) (bool, error) {
 	conditionMask := uint32(1) << c.bit
-	version := uint32(node.version)
+	version := uint32(c.chain.auxPowBaseVersion(node.version))
 	if version&vbTopMask != vbTopBits {
 		return false, nil
 	}
@@ -214,6 +215,10 @@ func (b *BlockChain) calcNextBlockVersion(prevNode *blockNode) (int32, error) {
 			expectedVersion |= uint32(1) << deployment.BitNumber
 		}
 	}
+
+	// Blocks of chains which support merged mining carry the chain ID.
+	expectedVersion |= uint32(b.chainParams.AuxPowChainID) <<
+		wire.AuxPowChainIDShift
 	return int32(expectedVersion), nil
 }
 
@@ -282,8 +287,9 @@ func (b *BlockChain) warnUnknownVersions(node *blockNode) error {
 		if err != nil {
 			return err
 		}
+		version := b.auxPowBaseVersion(node.version)
 		if expectedVersion > vbLegacyBlockVersion &&
-			(node.version & ^expectedVersion) != 0 {
+			(version & ^expectedVersion) != 0 {
 
 			numUpgraded++
 		}
@@ -135,6 +135,23 @@ func btcdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropAssetIndex {
+		if err := indexers.DropAssetIndex(db, interrupt); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropAssetAllocIndex {
+		err := indexers.DropAssetAllocationIndex(db, interrupt)
+		if err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 	if cfg.DropCfIndex {
 		if err := indexers.DropCfIndex(db, interrupt); err != nil {
 			btcdLog.Errorf("%v", err)
@@ -175,7 +192,7 @@ func btcdMain(serverChan chan<- *server) error {
 // in regression test mode and it already exists.
 func removeRegressionDB(dbPath string) error {
 	// Don't do anything if not in regression test mode.
-	if !cfg.RegressionTest {
+	if !(cfg.RegressionTest || cfg.SysRegressionTest) {
 		return nil
 	}
 
@@ -153,6 +153,17 @@ func TestBtcdExtCmds(t *testing.T) {
 				HashStop:      "",
 			},
 		},
+		{
+			name: "getutxocacheinfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getutxocacheinfo")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetUtxoCacheInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getutxocacheinfo","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetUtxoCacheInfoCmd{},
+		},
 		{
 			name: "getheaders - with arguments",
 			newCmd: func() (interface{}, error) {
@@ -18,3 +18,17 @@ type VersionResult struct {
 	Prerelease    string `json:"prerelease"`
 	BuildMetadata string `json:"buildmetadata"`
 }
+
+// GetUtxoCacheInfoResult models the data from the getutxocacheinfo command.
+type GetUtxoCacheInfoResult struct {
+	Entries         uint64 `json:"entries"`
+	DirtyEntries    uint64 `json:"dirtyentries"`
+	Size            uint64 `json:"size"`
+	MaxSize         uint64 `json:"maxsize"`
+	Hits            uint64 `json:"hits"`
+	Misses          uint64 `json:"misses"`
+	Flushes         uint64 `json:"flushes"`
+	LastFlushHash   string `json:"lastflushhash"`
+	LastFlushHeight int32  `json:"lastflushheight"`
+	LastFlushTime   int64  `json:"lastflushtime"`
+}
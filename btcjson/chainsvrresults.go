@@ -63,6 +63,15 @@ type DecodeScriptResult struct {
 	P2sh      string   `json:"p2sh,omitempty"`
 }
 
+// DumpTxOutSetResult models the data returned from the dumptxoutset command.
+type DumpTxOutSetResult struct {
+	CoinsWritten uint64 `json:"coins_written"`
+	BaseHash     string `json:"base_hash"`
+	BaseHeight   int32  `json:"base_height"`
+	Path         string `json:"path"`
+	TxOutSetHash string `json:"txoutset_hash"`
+}
+
 // GetAddedNodeInfoResultAddr models the data of the addresses portion of the
 // getaddednodeinfo command.
 type GetAddedNodeInfoResultAddr struct {
@@ -114,6 +123,14 @@ type GetBlockChainInfoResult struct {
 	Bip9SoftForks        map[string]*Bip9SoftForkDescription `json:"bip9_softforks"`
 }
 
+// GetChainTipsResult models the data returned from the getchaintips command.
+type GetChainTipsResult struct {
+	Height    int32  `json:"height"`
+	Hash      string `json:"hash"`
+	BranchLen int32  `json:"branchlen"`
+	Status    string `json:"status"`
+}
+
 // GetBlockTemplateResultTx models the transactions field of the
 // getblocktemplate command.
 type GetBlockTemplateResultTx struct {
@@ -162,6 +179,10 @@ type GetBlockTemplateResult struct {
 	Target  string `json:"target,omitempty"`
 	Expires int64  `json:"expires,omitempty"`
 
+	// Proof of work hash function the target applies to when it is not
+	// the double SHA-256 of the block header.
+	PowAlgorithm string `json:"powalgorithm,omitempty"`
+
 	// Mutations from BIP 0023.
 	MaxTime    int64    `json:"maxtime,omitempty"`
 	MinTime    int64    `json:"mintime,omitempty"`
@@ -292,6 +313,17 @@ type GetTxOutResult struct {
 	Coinbase      bool               `json:"coinbase"`
 }
 
+// GetTxOutSetInfoResult models the data from the gettxoutsetinfo command.
+type GetTxOutSetInfoResult struct {
+	Height       int32   `json:"height"`
+	BestBlock    string  `json:"bestblock"`
+	Transactions uint64  `json:"transactions"`
+	TxOuts       uint64  `json:"txouts"`
+	Size         uint64  `json:"size"`
+	MuHash       string  `json:"muhash"`
+	TotalAmount  float64 `json:"total_amount"`
+}
+
 // GetNetTotalsResult models the data returned from the getnettotals command.
 type GetNetTotalsResult struct {
 	TotalBytesRecv uint64 `json:"totalbytesrecv"`
@@ -535,11 +567,12 @@ type SearchRawTransactionsResult struct {
 
 // TxRawDecodeResult models the data from the decoderawtransaction command.
 type TxRawDecodeResult struct {
-	Txid     string `json:"txid"`
-	Version  int32  `json:"version"`
-	Locktime uint32 `json:"locktime"`
-	Vin      []Vin  `json:"vin"`
-	Vout     []Vout `json:"vout"`
+	Txid     string           `json:"txid"`
+	Version  int32            `json:"version"`
+	Locktime uint32           `json:"locktime"`
+	Vin      []Vin            `json:"vin"`
+	Vout     []Vout           `json:"vout"`
+	Syscoin  *SyscoinTxResult `json:"syscoin,omitempty"`
 }
 
 // ValidateAddressChainResult models the data returned by the chain server
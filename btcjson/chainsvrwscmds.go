@@ -41,6 +41,24 @@ func NewStopNotifyBlocksCmd() *StopNotifyBlocksCmd {
 	return &StopNotifyBlocksCmd{}
 }
 
+// NotifyBurnsCmd defines the notifyburns JSON-RPC command.
+type NotifyBurnsCmd struct{}
+
+// NewNotifyBurnsCmd returns a new instance which can be used to issue a
+// notifyburns JSON-RPC command.
+func NewNotifyBurnsCmd() *NotifyBurnsCmd {
+	return &NotifyBurnsCmd{}
+}
+
+// StopNotifyBurnsCmd defines the stopnotifyburns JSON-RPC command.
+type StopNotifyBurnsCmd struct{}
+
+// NewStopNotifyBurnsCmd returns a new instance which can be used to issue a
+// stopnotifyburns JSON-RPC command.
+func NewStopNotifyBurnsCmd() *StopNotifyBurnsCmd {
+	return &StopNotifyBurnsCmd{}
+}
+
 // NotifyNewTransactionsCmd defines the notifynewtransactions JSON-RPC command.
 type NotifyNewTransactionsCmd struct {
 	Verbose *bool `jsonrpcdefault:"false"`
@@ -228,11 +246,13 @@ func init() {
 	MustRegisterCmd("authenticate", (*AuthenticateCmd)(nil), flags)
 	MustRegisterCmd("loadtxfilter", (*LoadTxFilterCmd)(nil), flags)
 	MustRegisterCmd("notifyblocks", (*NotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("notifyburns", (*NotifyBurnsCmd)(nil), flags)
 	MustRegisterCmd("notifynewtransactions", (*NotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("notifyreceived", (*NotifyReceivedCmd)(nil), flags)
 	MustRegisterCmd("notifyspent", (*NotifySpentCmd)(nil), flags)
 	MustRegisterCmd("session", (*SessionCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyblocks", (*StopNotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("stopnotifyburns", (*StopNotifyBurnsCmd)(nil), flags)
 	MustRegisterCmd("stopnotifynewtransactions", (*StopNotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyspent", (*StopNotifySpentCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyreceived", (*StopNotifyReceivedCmd)(nil), flags)
@@ -63,6 +63,28 @@ func TestChainSvrWsCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifyblocks","params":[],"id":1}`,
 			unmarshalled: &btcjson.StopNotifyBlocksCmd{},
 		},
+		{
+			name: "notifyburns",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("notifyburns")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewNotifyBurnsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"notifyburns","params":[],"id":1}`,
+			unmarshalled: &btcjson.NotifyBurnsCmd{},
+		},
+		{
+			name: "stopnotifyburns",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("stopnotifyburns")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewStopNotifyBurnsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifyburns","params":[],"id":1}`,
+			unmarshalled: &btcjson.StopNotifyBurnsCmd{},
+		},
 		{
 			name: "notifynewtransactions",
 			newCmd: func() (interface{}, error) {
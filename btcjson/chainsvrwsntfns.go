@@ -31,6 +31,16 @@ const (
 	// disconnected.
 	FilteredBlockDisconnectedNtfnMethod = "filteredblockdisconnected"
 
+	// BurnConnectedNtfnMethod is the method used for notifications from
+	// the chain server that a transaction burning assets to Ethereum has
+	// been connected to the main chain.
+	BurnConnectedNtfnMethod = "burnconnected"
+
+	// BurnDisconnectedNtfnMethod is the method used for notifications from
+	// the chain server that a transaction burning assets to Ethereum has
+	// been disconnected from the main chain.
+	BurnDisconnectedNtfnMethod = "burndisconnected"
+
 	// RecvTxNtfnMethod is the legacy, deprecated method used for
 	// notifications from the chain server that a transaction which pays to
 	// a registered address has been processed.
@@ -161,6 +171,32 @@ type BlockDetails struct {
 	Time   int64  `json:"time"`
 }
 
+// BurnConnectedNtfn defines the burnconnected JSON-RPC notification.
+type BurnConnectedNtfn struct {
+	Burn BurnResult
+}
+
+// NewBurnConnectedNtfn returns a new instance which can be used to issue a
+// burnconnected JSON-RPC notification.
+func NewBurnConnectedNtfn(burn BurnResult) *BurnConnectedNtfn {
+	return &BurnConnectedNtfn{
+		Burn: burn,
+	}
+}
+
+// BurnDisconnectedNtfn defines the burndisconnected JSON-RPC notification.
+type BurnDisconnectedNtfn struct {
+	Burn BurnResult
+}
+
+// NewBurnDisconnectedNtfn returns a new instance which can be used to issue a
+// burndisconnected JSON-RPC notification.
+func NewBurnDisconnectedNtfn(burn BurnResult) *BurnDisconnectedNtfn {
+	return &BurnDisconnectedNtfn{
+		Burn: burn,
+	}
+}
+
 // RecvTxNtfn defines the recvtx JSON-RPC notification.
 //
 // NOTE: Deprecated. Use RelevantTxAcceptedNtfn and FilteredBlockConnectedNtfn
@@ -294,6 +330,8 @@ func init() {
 	MustRegisterCmd(BlockDisconnectedNtfnMethod, (*BlockDisconnectedNtfn)(nil), flags)
 	MustRegisterCmd(FilteredBlockConnectedNtfnMethod, (*FilteredBlockConnectedNtfn)(nil), flags)
 	MustRegisterCmd(FilteredBlockDisconnectedNtfnMethod, (*FilteredBlockDisconnectedNtfn)(nil), flags)
+	MustRegisterCmd(BurnConnectedNtfnMethod, (*BurnConnectedNtfn)(nil), flags)
+	MustRegisterCmd(BurnDisconnectedNtfnMethod, (*BurnDisconnectedNtfn)(nil), flags)
 	MustRegisterCmd(RecvTxNtfnMethod, (*RecvTxNtfn)(nil), flags)
 	MustRegisterCmd(RedeemingTxNtfnMethod, (*RedeemingTxNtfn)(nil), flags)
 	MustRegisterCmd(RescanFinishedNtfnMethod, (*RescanFinishedNtfn)(nil), flags)
@@ -88,6 +88,66 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				Header: "header",
 			},
 		},
+		{
+			name: "burnconnected",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("burnconnected", `{"txid":"123","blockhash":"456","height":100000,"ethaddress":"aabb","assets":[{"asset_guid":1234,"amount":500}]}`)
+			},
+			staticNtfn: func() interface{} {
+				burn := btcjson.BurnResult{
+					TxID:       "123",
+					BlockHash:  "456",
+					Height:     100000,
+					EthAddress: "aabb",
+					Assets: []btcjson.BurnedAssetResult{
+						{AssetGuid: 1234, Amount: 500},
+					},
+				}
+				return btcjson.NewBurnConnectedNtfn(burn)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"burnconnected","params":[{"txid":"123","blockhash":"456","height":100000,"ethaddress":"aabb","assets":[{"asset_guid":1234,"amount":500}]}],"id":null}`,
+			unmarshalled: &btcjson.BurnConnectedNtfn{
+				Burn: btcjson.BurnResult{
+					TxID:       "123",
+					BlockHash:  "456",
+					Height:     100000,
+					EthAddress: "aabb",
+					Assets: []btcjson.BurnedAssetResult{
+						{AssetGuid: 1234, Amount: 500},
+					},
+				},
+			},
+		},
+		{
+			name: "burndisconnected",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("burndisconnected", `{"txid":"123","blockhash":"456","height":100000,"ethaddress":"aabb","assets":[{"asset_guid":1234,"amount":500}]}`)
+			},
+			staticNtfn: func() interface{} {
+				burn := btcjson.BurnResult{
+					TxID:       "123",
+					BlockHash:  "456",
+					Height:     100000,
+					EthAddress: "aabb",
+					Assets: []btcjson.BurnedAssetResult{
+						{AssetGuid: 1234, Amount: 500},
+					},
+				}
+				return btcjson.NewBurnDisconnectedNtfn(burn)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"burndisconnected","params":[{"txid":"123","blockhash":"456","height":100000,"ethaddress":"aabb","assets":[{"asset_guid":1234,"amount":500}]}],"id":null}`,
+			unmarshalled: &btcjson.BurnDisconnectedNtfn{
+				Burn: btcjson.BurnResult{
+					TxID:       "123",
+					BlockHash:  "456",
+					Height:     100000,
+					EthAddress: "aabb",
+					Assets: []btcjson.BurnedAssetResult{
+						{AssetGuid: 1234, Amount: 500},
+					},
+				},
+			},
+		},
 		{
 			name: "recvtx",
 			newNtfn: func() (interface{}, error) {
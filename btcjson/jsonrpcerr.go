@@ -72,6 +72,10 @@ const (
 	ErrRPCOutOfRange        RPCErrorCode = -1
 	ErrRPCNoTxInfo          RPCErrorCode = -5
 	ErrRPCNoCFIndex         RPCErrorCode = -5
+	ErrRPCNoAssetIndex      RPCErrorCode = -5
+	ErrRPCAssetNotFound     RPCErrorCode = -5
+	ErrRPCNoAssetAllocIndex RPCErrorCode = -5
+	ErrRPCNEVMBlockNotFound RPCErrorCode = -5
 	ErrRPCNoNewestBlockInfo RPCErrorCode = -5
 	ErrRPCInvalidTxVout     RPCErrorCode = -5
 	ErrRPCRawTxString       RPCErrorCode = -32602
@@ -0,0 +1,171 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// NOTE: This file is intended to house the RPC commands that are supported by
+// a chain server with Syscoin asset extensions.
+
+package btcjson
+
+// GetAssetCmd defines the getasset JSON-RPC command.
+type GetAssetCmd struct {
+	AssetGuid uint64
+}
+
+// NewGetAssetCmd returns a new instance which can be used to issue a getasset
+// JSON-RPC command.
+func NewGetAssetCmd(assetGuid uint64) *GetAssetCmd {
+	return &GetAssetCmd{
+		AssetGuid: assetGuid,
+	}
+}
+
+// GetAuxFeeCmd defines the getauxfee JSON-RPC command.
+type GetAuxFeeCmd struct {
+	AssetGuid uint64
+	Amount    int64
+}
+
+// NewGetAuxFeeCmd returns a new instance which can be used to issue a
+// getauxfee JSON-RPC command.
+func NewGetAuxFeeCmd(assetGuid uint64, amount int64) *GetAuxFeeCmd {
+	return &GetAuxFeeCmd{
+		AssetGuid: assetGuid,
+		Amount:    amount,
+	}
+}
+
+// ListAssetsCmd defines the listassets JSON-RPC command.
+type ListAssetsCmd struct {
+	Count *int    `jsonrpcdefault:"100"`
+	From  *uint64 `jsonrpcdefault:"0"`
+}
+
+// NewListAssetsCmd returns a new instance which can be used to issue a
+// listassets JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewListAssetsCmd(count *int, from *uint64) *ListAssetsCmd {
+	return &ListAssetsCmd{
+		Count: count,
+		From:  from,
+	}
+}
+
+// GetAssetAllocationBalanceCmd defines the getassetallocationbalance JSON-RPC
+// command.
+type GetAssetAllocationBalanceCmd struct {
+	Address   string
+	AssetGuid uint64
+}
+
+// NewGetAssetAllocationBalanceCmd returns a new instance which can be used to
+// issue a getassetallocationbalance JSON-RPC command.
+func NewGetAssetAllocationBalanceCmd(address string, assetGuid uint64) *GetAssetAllocationBalanceCmd {
+	return &GetAssetAllocationBalanceCmd{
+		Address:   address,
+		AssetGuid: assetGuid,
+	}
+}
+
+// GetAuxBlockCmd defines the getauxblock JSON-RPC command.  Without arguments
+// it requests a new block to merged mine, while with both arguments it submits
+// the auxpow of a block previously returned by it.
+type GetAuxBlockCmd struct {
+	Hash   *string
+	AuxPow *string
+}
+
+// NewGetAuxBlockCmd returns a new instance which can be used to issue a
+// getauxblock JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAuxBlockCmd(hash, auxPow *string) *GetAuxBlockCmd {
+	return &GetAuxBlockCmd{
+		Hash:   hash,
+		AuxPow: auxPow,
+	}
+}
+
+// GetNEVMBlockCmd defines the getnevmblock JSON-RPC command.
+type GetNEVMBlockCmd struct {
+	BlockHash string
+	Verbose   *bool `jsonrpcdefault:"true"`
+}
+
+// NewGetNEVMBlockCmd returns a new instance which can be used to issue a
+// getnevmblock JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetNEVMBlockCmd(blockHash string, verbose *bool) *GetNEVMBlockCmd {
+	return &GetNEVMBlockCmd{
+		BlockHash: blockHash,
+		Verbose:   verbose,
+	}
+}
+
+// ListAssetAllocationsCmd defines the listassetallocations JSON-RPC command.
+type ListAssetAllocationsCmd struct {
+	Address string
+	Verbose *bool `jsonrpcdefault:"false"`
+}
+
+// NewListAssetAllocationsCmd returns a new instance which can be used to issue
+// a listassetallocations JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewListAssetAllocationsCmd(address string, verbose *bool) *ListAssetAllocationsCmd {
+	return &ListAssetAllocationsCmd{
+		Address: address,
+		Verbose: verbose,
+	}
+}
+
+// SubmitAuxBlockCmd defines the submitauxblock JSON-RPC command.
+type SubmitAuxBlockCmd struct {
+	Hash   string
+	AuxPow string
+}
+
+// NewSubmitAuxBlockCmd returns a new instance which can be used to issue a
+// submitauxblock JSON-RPC command.
+func NewSubmitAuxBlockCmd(hash, auxPow string) *SubmitAuxBlockCmd {
+	return &SubmitAuxBlockCmd{
+		Hash:   hash,
+		AuxPow: auxPow,
+	}
+}
+
+// VerifyMintProofCmd defines the verifymintproof JSON-RPC command.
+type VerifyMintProofCmd struct {
+	HexTx string
+}
+
+// NewVerifyMintProofCmd returns a new instance which can be used to issue a
+// verifymintproof JSON-RPC command.
+func NewVerifyMintProofCmd(hexTx string) *VerifyMintProofCmd {
+	return &VerifyMintProofCmd{
+		HexTx: hexTx,
+	}
+}
+
+func init() {
+	// No special flags for commands in this file.
+	flags := UsageFlag(0)
+
+	MustRegisterCmd("getasset", (*GetAssetCmd)(nil), flags)
+	MustRegisterCmd("getassetallocationbalance",
+		(*GetAssetAllocationBalanceCmd)(nil), flags)
+	MustRegisterCmd("getauxblock", (*GetAuxBlockCmd)(nil), flags)
+	MustRegisterCmd("getauxfee", (*GetAuxFeeCmd)(nil), flags)
+	MustRegisterCmd("getnevmblock", (*GetNEVMBlockCmd)(nil), flags)
+	MustRegisterCmd("listassetallocations", (*ListAssetAllocationsCmd)(nil),
+		flags)
+	MustRegisterCmd("listassets", (*ListAssetsCmd)(nil), flags)
+	MustRegisterCmd("submitauxblock", (*SubmitAuxBlockCmd)(nil), flags)
+	MustRegisterCmd("verifymintproof", (*VerifyMintProofCmd)(nil), flags)
+}
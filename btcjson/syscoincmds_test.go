@@ -0,0 +1,288 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcjson_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/vpubchain/btcd/btcjson"
+)
+
+// TestSyscoinCmds tests all of the Syscoin asset commands marshal and unmarshal
+// into valid results include handling of optional fields being omitted in the
+// marshalled command, while optional fields with defaults have the default
+// assigned on unmarshalled commands.
+func TestSyscoinCmds(t *testing.T) {
+	t.Parallel()
+
+	testID := int(1)
+	tests := []struct {
+		name         string
+		newCmd       func() (interface{}, error)
+		staticCmd    func() interface{}
+		marshalled   string
+		unmarshalled interface{}
+	}{
+		{
+			name: "getasset",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getasset", 1234567)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAssetCmd(1234567)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getasset","params":[1234567],"id":1}`,
+			unmarshalled: &btcjson.GetAssetCmd{
+				AssetGuid: 1234567,
+			},
+		},
+		{
+			name: "getassetallocationbalance",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getassetallocationbalance",
+					"1Address", 1234567)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAssetAllocationBalanceCmd(
+					"1Address", 1234567)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getassetallocationbalance","params":["1Address",1234567],"id":1}`,
+			unmarshalled: &btcjson.GetAssetAllocationBalanceCmd{
+				Address:   "1Address",
+				AssetGuid: 1234567,
+			},
+		},
+		{
+			name: "getauxblock",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getauxblock")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAuxBlockCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getauxblock","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetAuxBlockCmd{
+				Hash:   nil,
+				AuxPow: nil,
+			},
+		},
+		{
+			name: "getauxblock submit",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getauxblock", "123", "abcd")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAuxBlockCmd(btcjson.String("123"),
+					btcjson.String("abcd"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getauxblock","params":["123","abcd"],"id":1}`,
+			unmarshalled: &btcjson.GetAuxBlockCmd{
+				Hash:   btcjson.String("123"),
+				AuxPow: btcjson.String("abcd"),
+			},
+		},
+		{
+			name: "getauxfee",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getauxfee", 1234, 100000)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAuxFeeCmd(1234, 100000)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getauxfee","params":[1234,100000],"id":1}`,
+			unmarshalled: &btcjson.GetAuxFeeCmd{
+				AssetGuid: 1234,
+				Amount:    100000,
+			},
+		},
+		{
+			name: "getnevmblock",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getnevmblock", "123")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetNEVMBlockCmd("123", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getnevmblock","params":["123"],"id":1}`,
+			unmarshalled: &btcjson.GetNEVMBlockCmd{
+				BlockHash: "123",
+				Verbose:   btcjson.Bool(true),
+			},
+		},
+		{
+			name: "getnevmblock not verbose",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getnevmblock", "123", false)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetNEVMBlockCmd("123",
+					btcjson.Bool(false))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getnevmblock","params":["123",false],"id":1}`,
+			unmarshalled: &btcjson.GetNEVMBlockCmd{
+				BlockHash: "123",
+				Verbose:   btcjson.Bool(false),
+			},
+		},
+		{
+			name: "listassetallocations",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("listassetallocations",
+					"1Address")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewListAssetAllocationsCmd("1Address",
+					nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"listassetallocations","params":["1Address"],"id":1}`,
+			unmarshalled: &btcjson.ListAssetAllocationsCmd{
+				Address: "1Address",
+				Verbose: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "listassetallocations verbose",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("listassetallocations",
+					"1Address", true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewListAssetAllocationsCmd("1Address",
+					btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"listassetallocations","params":["1Address",true],"id":1}`,
+			unmarshalled: &btcjson.ListAssetAllocationsCmd{
+				Address: "1Address",
+				Verbose: btcjson.Bool(true),
+			},
+		},
+		{
+			name: "listassets",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("listassets")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewListAssetsCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"listassets","params":[],"id":1}`,
+			unmarshalled: &btcjson.ListAssetsCmd{
+				Count: btcjson.Int(100),
+				From:  btcjson.Uint64(0),
+			},
+		},
+		{
+			name: "listassets optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("listassets", 10, 500)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewListAssetsCmd(btcjson.Int(10),
+					btcjson.Uint64(500))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"listassets","params":[10,500],"id":1}`,
+			unmarshalled: &btcjson.ListAssetsCmd{
+				Count: btcjson.Int(10),
+				From:  btcjson.Uint64(500),
+			},
+		},
+		{
+			name: "submitauxblock",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("submitauxblock", "123", "abcd")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSubmitAuxBlockCmd("123", "abcd")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"submitauxblock","params":["123","abcd"],"id":1}`,
+			unmarshalled: &btcjson.SubmitAuxBlockCmd{
+				Hash:   "123",
+				AuxPow: "abcd",
+			},
+		},
+		{
+			name: "verifymintproof",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("verifymintproof", "0102")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewVerifyMintProofCmd("0102")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"verifymintproof","params":["0102"],"id":1}`,
+			unmarshalled: &btcjson.VerifyMintProofCmd{
+				HexTx: "0102",
+			},
+		},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Marshal the command as created by the new static command
+		// creation function.
+		marshalled, err := btcjson.MarshalCmd(testID, test.staticCmd())
+		if err != nil {
+			t.Errorf("MarshalCmd #%d (%s) unexpected error: %v", i,
+				test.name, err)
+			continue
+		}
+
+		if !bytes.Equal(marshalled, []byte(test.marshalled)) {
+			t.Errorf("Test #%d (%s) unexpected marshalled data - "+
+				"got %s, want %s", i, test.name, marshalled,
+				test.marshalled)
+			continue
+		}
+
+		// Ensure the command is created without error via the generic
+		// new command creation function.
+		cmd, err := test.newCmd()
+		if err != nil {
+			t.Errorf("Test #%d (%s) unexpected NewCmd error: %v ",
+				i, test.name, err)
+		}
+
+		// Marshal the command as created by the generic new command
+		// creation function.
+		marshalled, err = btcjson.MarshalCmd(testID, cmd)
+		if err != nil {
+			t.Errorf("MarshalCmd #%d (%s) unexpected error: %v", i,
+				test.name, err)
+			continue
+		}
+
+		if !bytes.Equal(marshalled, []byte(test.marshalled)) {
+			t.Errorf("Test #%d (%s) unexpected marshalled data - "+
+				"got %s, want %s", i, test.name, marshalled,
+				test.marshalled)
+			continue
+		}
+
+		var request btcjson.Request
+		if err := json.Unmarshal(marshalled, &request); err != nil {
+			t.Errorf("Test #%d (%s) unexpected error while "+
+				"unmarshalling JSON-RPC request: %v", i,
+				test.name, err)
+			continue
+		}
+
+		cmd, err = btcjson.UnmarshalCmd(&request)
+		if err != nil {
+			t.Errorf("UnmarshalCmd #%d (%s) unexpected error: %v", i,
+				test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(cmd, test.unmarshalled) {
+			t.Errorf("Test #%d (%s) unexpected unmarshalled command "+
+				"- got %s, want %s", i, test.name,
+				fmt.Sprintf("(%T) %+[1]v", cmd),
+				fmt.Sprintf("(%T) %+[1]v\n", test.unmarshalled))
+			continue
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcjson
+
+// NotaryDetailsResult models the notary details of an asset.
+type NotaryDetailsResult struct {
+	EndPoint         string `json:"endpoint"`
+	InstantTransfers bool   `json:"instant_transfers"`
+	HDRequired       bool   `json:"hd_required"`
+}
+
+// AuxFeeResult models a single tier of the auxiliary fee schedule of an
+// asset.
+type AuxFeeResult struct {
+	Bound   int64  `json:"bound"`
+	Percent uint16 `json:"percent"`
+}
+
+// AuxFeeDetailsResult models the auxiliary fee details of an asset.
+type AuxFeeDetailsResult struct {
+	AuxFeeKeyID string         `json:"auxfee_keyid"`
+	AuxFees     []AuxFeeResult `json:"fee_struct"`
+}
+
+// GetAssetResult models the data from the getasset command as well as the
+// entries returned by the listassets command.
+type GetAssetResult struct {
+	AssetGuid             uint64               `json:"asset_guid"`
+	Symbol                string               `json:"symbol"`
+	PubData               string               `json:"public_value,omitempty"`
+	Contract              string               `json:"contract,omitempty"`
+	NotaryKeyID           string               `json:"notary_keyid,omitempty"`
+	NotaryDetails         *NotaryDetailsResult `json:"notary_details,omitempty"`
+	AuxFeeDetails         *AuxFeeDetailsResult `json:"auxfee,omitempty"`
+	TotalSupply           int64                `json:"total_supply"`
+	MaxSupply             int64                `json:"max_supply"`
+	Precision             uint8                `json:"precision"`
+	UpdateCapabilityFlags uint8                `json:"updatecapability_flags"`
+}
+
+// AssetAllocationUtxoResult models an unspent output carrying asset value as
+// returned by the listassetallocations command.
+type AssetAllocationUtxoResult struct {
+	TxID  string `json:"txid"`
+	Vout  uint32 `json:"vout"`
+	Value int64  `json:"value"`
+}
+
+// AssetAllocationBalanceResult models the data from the
+// getassetallocationbalance command as well as the entries returned by the
+// listassetallocations command.
+type AssetAllocationBalanceResult struct {
+	Address   string                      `json:"address"`
+	AssetGuid uint64                      `json:"asset_guid"`
+	Balance   int64                       `json:"balance"`
+	NumUtxos  uint32                      `json:"numutxos"`
+	Utxos     []AssetAllocationUtxoResult `json:"utxos,omitempty"`
+}
+
+// GetAuxBlockResult models the data from the getauxblock command when it is
+// called without arguments.  When called to submit an auxpow, getauxblock
+// returns whether the block was accepted.
+type GetAuxBlockResult struct {
+	Hash              string `json:"hash"`
+	ChainID           int32  `json:"chainid"`
+	PreviousBlockHash string `json:"previousblockhash"`
+	CoinbaseValue     int64  `json:"coinbasevalue"`
+	Bits              string `json:"bits"`
+	Height            int64  `json:"height"`
+	Target            string `json:"target"`
+}
+
+// GetAuxFeeResult models the data from the getauxfee command.
+type GetAuxFeeResult struct {
+	AssetGuid     uint64 `json:"asset_guid"`
+	Amount        int64  `json:"amount"`
+	AuxFee        int64  `json:"auxfee"`
+	AuxFeeKeyID   string `json:"auxfee_keyid,omitempty"`
+	AuxFeeAddress string `json:"auxfee_address,omitempty"`
+}
+
+// GetNEVMBlockResult models the data from the getnevmblock command when the
+// verbose flag is set.  When the verbose flag is not set, getnevmblock returns
+// a hex-encoded string.
+type GetNEVMBlockResult struct {
+	NEVMBlockHash string      `json:"nevmblockhash"`
+	TxRoot        string      `json:"txroot"`
+	ReceiptRoot   string      `json:"receiptroot"`
+	SYSBlockHash  string      `json:"sysblockhash"`
+	Pruned        bool        `json:"pruned"`
+	DataSize      int         `json:"datasize"`
+	Header        interface{} `json:"header,omitempty"`
+}
+
+// VerifyMintProofResult models the data from the verifymintproof command.
+type VerifyMintProofResult struct {
+	Valid       bool   `json:"valid"`
+	Reason      string `json:"reason,omitempty"`
+	EthTxID     string `json:"eth_txid,omitempty"`
+	TxRoot      string `json:"txroot"`
+	ReceiptRoot string `json:"receiptroot"`
+}
+
+// AssetOutValueResult models the value of an asset assigned to a transaction
+// output.
+type AssetOutValueResult struct {
+	N     uint32 `json:"n"`
+	Value int64  `json:"value"`
+}
+
+// AssetOutResult models the values of an asset assigned to the outputs of a
+// transaction.
+type AssetOutResult struct {
+	AssetGuid uint64                `json:"asset_guid"`
+	Values    []AssetOutValueResult `json:"values"`
+	NotarySig string                `json:"notarysig,omitempty"`
+}
+
+// MintResult models the Ethereum proofs carried by a mint transaction.  The
+// RLP encoded proof nodes are decoded into nested arrays of hex-encoded byte
+// strings, or hex-encoded as is when they are not valid RLP.
+type MintResult struct {
+	EthTxID            string      `json:"eth_txid"`
+	EthBlockHash       string      `json:"eth_blockhash"`
+	TxPos              uint16      `json:"txpos"`
+	TxParentNodes      interface{} `json:"txparentnodes"`
+	TxPath             string      `json:"txpath"`
+	TxRoot             string      `json:"txroot"`
+	ReceiptPos         uint16      `json:"receiptpos"`
+	ReceiptParentNodes interface{} `json:"receiptparentnodes"`
+	ReceiptRoot        string      `json:"receiptroot"`
+}
+
+// SyscoinTxResult models the payload of a Syscoin transaction as returned by
+// the decoderawtransaction command.
+type SyscoinTxResult struct {
+	TxType        string               `json:"txtype"`
+	Allocations   []AssetOutResult     `json:"allocations"`
+	EthAddress    string               `json:"ethaddress,omitempty"`
+	Mint          *MintResult          `json:"mint,omitempty"`
+	NotaryKeyID   string               `json:"notary_keyid,omitempty"`
+	NotaryDetails *NotaryDetailsResult `json:"notary_details,omitempty"`
+}
+
+// BurnedAssetResult models the amount of a single asset burned by a
+// transaction.
+type BurnedAssetResult struct {
+	AssetGuid uint64 `json:"asset_guid"`
+	Amount    int64  `json:"amount"`
+}
+
+// BurnResult models a transaction burning assets to Ethereum as carried by the
+// burnconnected and burndisconnected notifications.
+type BurnResult struct {
+	TxID       string              `json:"txid"`
+	BlockHash  string              `json:"blockhash"`
+	Height     int32               `json:"height"`
+	EthAddress string              `json:"ethaddress"`
+	Assets     []BurnedAssetResult `json:"assets"`
+}
@@ -12,7 +12,8 @@ import (
 )
 
 // genesisCoinbaseTx is the coinbase transaction for the genesis blocks for
-// the main network, regression test network, and test network (version 3).
+// the main network, regression test network, and test network (version 3) as
+// well as the Syscoin networks.
 var genesisCoinbaseTx = wire.MsgTx{
 	Version: 1,
 	TxIn: []*wire.TxIn{
@@ -170,3 +171,32 @@ var simNetGenesisBlock = wire.MsgBlock{
 	},
 	Transactions: []*wire.MsgTx{&genesisCoinbaseTx},
 }
+
+// sysRegTestGenesisHash is the hash of the first block in the block chain for
+// the Syscoin regression test network (genesis block).
+var sysRegTestGenesisHash = chainhash.Hash([chainhash.HashSize]byte{ // Make go vet happy.
+	0x94, 0x11, 0x52, 0x00, 0x8c, 0x34, 0x0a, 0xb4,
+	0xc7, 0xf1, 0xf1, 0x24, 0xea, 0x67, 0xec, 0xe4,
+	0x2a, 0xcb, 0xcb, 0x85, 0x90, 0xe7, 0xad, 0x05,
+	0xac, 0x6f, 0xf4, 0x51, 0xd2, 0xc2, 0xa2, 0x28,
+})
+
+// sysRegTestGenesisMerkleRoot is the hash of the first transaction in the
+// genesis block for the Syscoin regression test network.  It is the same as the
+// merkle root for the Bitcoin main network.
+var sysRegTestGenesisMerkleRoot = genesisMerkleRoot
+
+// sysRegTestGenesisBlock defines the genesis block of the block chain which
+// serves as the public transaction ledger for the Syscoin regression test
+// network.
+var sysRegTestGenesisBlock = wire.MsgBlock{
+	Header: wire.BlockHeader{
+		Version:    1,
+		PrevBlock:  chainhash.Hash{},            // 0000000000000000000000000000000000000000000000000000000000000000
+		MerkleRoot: sysRegTestGenesisMerkleRoot, // 4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b
+		Timestamp:  time.Unix(1553040331, 0),    // 2019-03-20 00:05:31 +0000 UTC
+		Bits:       0x207fffff,                  // 545259519 [7fffff0000000000000000000000000000000000000000000000000000000000]
+		Nonce:      3,
+	},
+	Transactions: []*wire.MsgTx{&genesisCoinbaseTx},
+}
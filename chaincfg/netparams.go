@@ -0,0 +1,572 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chaincfg
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// maxDeploymentBitNumber is the highest bit of the block version a
+	// consensus rule change deployment may use.  The top three bits are
+	// reserved by BIP0009.
+	maxDeploymentBitNumber = 28
+
+	// minCoinbaseScriptLen and maxCoinbaseScriptLen are the consensus
+	// limits of the length of the signature script of a coinbase.
+	minCoinbaseScriptLen = 2
+	maxCoinbaseScriptLen = 100
+
+	// erc20ManagerSize is the size of the address of the ERC20 manager
+	// contract on the NEVM chain.
+	erc20ManagerSize = 20
+)
+
+// FileParams houses network parameters loaded from a file along with the
+// settings of the network which are not part of the chain parameters.
+type FileParams struct {
+	*Params
+
+	// RPCPort is the default port of the RPC server for the network.
+	RPCPort string
+}
+
+// deploymentNames maps the names consensus rule change deployments have in
+// network parameter files to their deployment IDs.
+var deploymentNames = map[string]int{
+	"testdummy": DeploymentTestDummy,
+	"csv":       DeploymentCSV,
+	"segwit":    DeploymentSegwit,
+}
+
+// powHashNames maps the names proof of work hash functions have in network
+// parameter files to the functions.
+var powHashNames = map[string]PowHashAlgorithm{
+	"":        PowHashSHA256d,
+	"sha256d": PowHashSHA256d,
+	"scrypt":  PowHashScrypt,
+}
+
+// retargetNames maps the names retarget algorithms have in network parameter
+// files to the algorithms.
+var retargetNames = map[string]RetargetAlgorithm{
+	"":                RetargetClassic,
+	"classic":         RetargetClassic,
+	"darkgravitywave": RetargetDarkGravityWave,
+	"lwma":            RetargetLWMA,
+}
+
+// hexUint32 is a uint32 which may either be given as a JSON number or as a
+// string holding a hex number, which suits magic values such as the network
+// magic and difficulty bits.
+type hexUint32 uint32
+
+// UnmarshalJSON decodes a JSON number or hex string into the receiver.
+func (h *hexUint32) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		var n uint32
+		if err := json.Unmarshal(data, &n); err != nil {
+			return err
+		}
+		*h = hexUint32(n)
+		return nil
+	}
+
+	str = strings.TrimPrefix(strings.ToLower(str), "0x")
+	n, err := strconv.ParseUint(str, 16, 32)
+	if err != nil {
+		return err
+	}
+	*h = hexUint32(n)
+	return nil
+}
+
+// jsonDNSSeed is the representation of a DNS seed in network parameter files.
+type jsonDNSSeed struct {
+	Host         string `json:"host"`
+	HasFiltering bool   `json:"hasfiltering"`
+}
+
+// jsonGenesis is the representation of the genesis block in network parameter
+// files.
+type jsonGenesis struct {
+	Version        int32     `json:"version"`
+	Timestamp      int64     `json:"timestamp"`
+	Bits           hexUint32 `json:"bits"`
+	Nonce          uint32    `json:"nonce"`
+	CoinbaseScript string    `json:"coinbasescript"`
+	CoinbaseValue  int64     `json:"coinbasevalue"`
+	PkScript       string    `json:"pkscript"`
+	Hash           string    `json:"hash"`
+}
+
+// jsonCheckpoint is the representation of a checkpoint in network parameter
+// files.
+type jsonCheckpoint struct {
+	Height int32  `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// jsonUTXOSnapshot is the representation of a UTXO snapshot in network
+// parameter files.
+type jsonUTXOSnapshot struct {
+	Height    int32  `json:"height"`
+	BlockHash string `json:"blockhash"`
+	UTXOHash  string `json:"utxohash"`
+}
+
+// jsonDeployment is the representation of a consensus rule change deployment
+// in network parameter files.
+type jsonDeployment struct {
+	BitNumber  uint8  `json:"bitnumber"`
+	StartTime  uint64 `json:"starttime"`
+	ExpireTime uint64 `json:"expiretime"`
+}
+
+// jsonParams is the representation of network parameters in network parameter
+// files.  Durations are given as strings accepted by time.ParseDuration.
+type jsonParams struct {
+	Name                          string                    `json:"name"`
+	Net                           hexUint32                 `json:"net"`
+	DefaultPort                   string                    `json:"defaultport"`
+	RPCPort                       string                    `json:"rpcport"`
+	DNSSeeds                      []jsonDNSSeed             `json:"dnsseeds"`
+	Genesis                       jsonGenesis               `json:"genesis"`
+	PowLimit                      string                    `json:"powlimit"`
+	PowLimitBits                  hexUint32                 `json:"powlimitbits"`
+	AuxPowChainID                 int32                     `json:"auxpowchainid"`
+	PowHash                       string                    `json:"powhash"`
+	BIP0034Height                 int32                     `json:"bip0034height"`
+	BIP0065Height                 int32                     `json:"bip0065height"`
+	BIP0066Height                 int32                     `json:"bip0066height"`
+	CoinbaseMaturity              uint16                    `json:"coinbasematurity"`
+	SubsidyReductionInterval      int32                     `json:"subsidyreductioninterval"`
+	BaseSubsidy                   int64                     `json:"basesubsidy"`
+	SubsidyReductionPercent       int64                     `json:"subsidyreductionpercent"`
+	TargetTimespan                string                    `json:"targettimespan"`
+	TargetTimePerBlock            string                    `json:"targettimeperblock"`
+	RetargetAdjustmentFactor      int64                     `json:"retargetadjustmentfactor"`
+	Retarget                      string                    `json:"retarget"`
+	RetargetWindow                int32                     `json:"retargetwindow"`
+	ReduceMinDifficulty           bool                      `json:"reducemindifficulty"`
+	MinDiffReductionTime          string                    `json:"mindiffreductiontime"`
+	GenerateSupported             bool                      `json:"generatesupported"`
+	Checkpoints                   []jsonCheckpoint          `json:"checkpoints"`
+	AssumeValid                   string                    `json:"assumevalid"`
+	MinimumChainWork              string                    `json:"minimumchainwork"`
+	UTXOSnapshots                 []jsonUTXOSnapshot        `json:"utxosnapshots"`
+	AssetsSupported               bool                      `json:"assetssupported"`
+	SysXAssetGuid                 uint64                    `json:"sysxassetguid"`
+	ERC20Manager                  string                    `json:"erc20manager"`
+	NEVMSupported                 bool                      `json:"nevmsupported"`
+	RuleChangeActivationThreshold uint32                    `json:"rulechangeactivationthreshold"`
+	MinerConfirmationWindow       uint32                    `json:"minerconfirmationwindow"`
+	Deployments                   map[string]jsonDeployment `json:"deployments"`
+	RelayNonStdTxs                bool                      `json:"relaynonstdtxs"`
+	Bech32HRPSegwit               string                    `json:"bech32hrpsegwit"`
+	PubKeyHashAddrID              byte                      `json:"pubkeyhashaddrid"`
+	ScriptHashAddrID              byte                      `json:"scripthashaddrid"`
+	PrivateKeyID                  byte                      `json:"privatekeyid"`
+	WitnessPubKeyHashAddrID       byte                      `json:"witnesspubkeyhashaddrid"`
+	WitnessScriptHashAddrID       byte                      `json:"witnessscripthashaddrid"`
+	HDPrivateKeyID                string                    `json:"hdprivatekeyid"`
+	HDPublicKeyID                 string                    `json:"hdpublickeyid"`
+	HDCoinType                    uint32                    `json:"hdcointype"`
+}
+
+// compactToBig converts the compact representation of a whole number used for
+// difficulty bits to a big integer.  See CompactToBig in the blockchain
+// package for details of the encoding.
+func compactToBig(compact uint32) *big.Int {
+	mantissa := compact & 0x007fffff
+	isNegative := compact&0x00800000 != 0
+	exponent := uint(compact >> 24)
+
+	var bn *big.Int
+	if exponent <= 3 {
+		mantissa >>= 8 * (3 - exponent)
+		bn = big.NewInt(int64(mantissa))
+	} else {
+		bn = big.NewInt(int64(mantissa))
+		bn.Lsh(bn, 8*(exponent-3))
+	}
+	if isNegative {
+		bn = bn.Neg(bn)
+	}
+	return bn
+}
+
+// NewGenesisBlock returns a genesis block with the passed header fields whose
+// only transaction is a coinbase with the passed signature script paying the
+// passed value to the passed public key script.
+func NewGenesisBlock(version int32, timestamp time.Time, bits, nonce uint32,
+	coinbaseScript []byte, value int64, pkScript []byte) *wire.MsgBlock {
+
+	coinbase := wire.NewMsgTx(1)
+	prevOut := wire.NewOutPoint(&chainhash.Hash{}, 0xffffffff)
+	coinbase.AddTxIn(wire.NewTxIn(prevOut, coinbaseScript, nil))
+	coinbase.AddTxOut(wire.NewTxOut(value, pkScript))
+
+	// The merkle root of a block with a single transaction is the hash of
+	// that transaction.
+	block := wire.NewMsgBlock(&wire.BlockHeader{
+		Version:    version,
+		MerkleRoot: coinbase.TxHash(),
+		Timestamp:  timestamp,
+		Bits:       bits,
+		Nonce:      nonce,
+	})
+	block.AddTransaction(coinbase)
+	return block
+}
+
+// parseDuration parses the duration of the passed parameter, which defaults
+// to zero when it is not set.
+func parseDuration(name, str string) (time.Duration, error) {
+	if str == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", name, err)
+	}
+	return d, nil
+}
+
+// parseHDKeyID parses the hex-encoded hierarchical deterministic extended key
+// magic of the passed parameter.
+func parseHDKeyID(name, str string) ([4]byte, error) {
+	var id [4]byte
+	b, err := hex.DecodeString(str)
+	if err != nil || len(b) != len(id) {
+		return id, fmt.Errorf("%s must be %d hex-encoded bytes",
+			name, len(id))
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// genesisBlock builds and validates the genesis block described by the
+// receiver.
+func (g *jsonGenesis) genesisBlock() (*wire.MsgBlock, error) {
+	coinbaseScript, err := hex.DecodeString(g.CoinbaseScript)
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis coinbasescript: %v", err)
+	}
+	if len(coinbaseScript) < minCoinbaseScriptLen ||
+		len(coinbaseScript) > maxCoinbaseScriptLen {
+
+		return nil, fmt.Errorf("genesis coinbasescript length of %d "+
+			"is out of range [%d, %d]", len(coinbaseScript),
+			minCoinbaseScriptLen, maxCoinbaseScriptLen)
+	}
+	pkScript, err := hex.DecodeString(g.PkScript)
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis pkscript: %v", err)
+	}
+	if g.Bits == 0 {
+		return nil, fmt.Errorf("genesis bits must be set")
+	}
+
+	block := NewGenesisBlock(g.Version, time.Unix(g.Timestamp, 0),
+		uint32(g.Bits), g.Nonce, coinbaseScript, g.CoinbaseValue, pkScript)
+
+	// Ensure the genesis block is the expected one when its hash is given.
+	if g.Hash != "" {
+		want, err := chainhash.NewHashFromStr(g.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid genesis hash: %v", err)
+		}
+		if hash := block.BlockHash(); hash != *want {
+			return nil, fmt.Errorf("genesis block hash %v does not "+
+				"match the given hash %v", hash, want)
+		}
+	}
+	return block, nil
+}
+
+// params converts the receiver to the network parameters it describes while
+// ensuring they are sane.
+func (p *jsonParams) params() (*FileParams, error) {
+	if p.Name == "" || strings.ContainsAny(p.Name, `/\`) ||
+		p.Name == "." || p.Name == ".." {
+
+		return nil, fmt.Errorf("invalid network name %q", p.Name)
+	}
+	if p.Net == 0 {
+		return nil, fmt.Errorf("network magic must be set")
+	}
+	if p.DefaultPort == "" || p.RPCPort == "" {
+		return nil, fmt.Errorf("defaultport and rpcport must be set")
+	}
+
+	params := &Params{
+		Name:                          p.Name,
+		Net:                           wire.BitcoinNet(p.Net),
+		DefaultPort:                   p.DefaultPort,
+		PowLimitBits:                  uint32(p.PowLimitBits),
+		AuxPowChainID:                 p.AuxPowChainID,
+		BIP0034Height:                 p.BIP0034Height,
+		BIP0065Height:                 p.BIP0065Height,
+		BIP0066Height:                 p.BIP0066Height,
+		CoinbaseMaturity:              p.CoinbaseMaturity,
+		SubsidyReductionInterval:      p.SubsidyReductionInterval,
+		BaseSubsidy:                   p.BaseSubsidy,
+		SubsidyReductionPercent:       p.SubsidyReductionPercent,
+		RetargetAdjustmentFactor:      p.RetargetAdjustmentFactor,
+		RetargetWindow:                p.RetargetWindow,
+		ReduceMinDifficulty:           p.ReduceMinDifficulty,
+		GenerateSupported:             p.GenerateSupported,
+		RuleChangeActivationThreshold: p.RuleChangeActivationThreshold,
+		AssetsSupported:               p.AssetsSupported,
+		SysXAssetGuid:                 p.SysXAssetGuid,
+		NEVMSupported:                 p.NEVMSupported,
+		MinerConfirmationWindow:       p.MinerConfirmationWindow,
+		RelayNonStdTxs:                p.RelayNonStdTxs,
+		Bech32HRPSegwit:               p.Bech32HRPSegwit,
+		PubKeyHashAddrID:              p.PubKeyHashAddrID,
+		ScriptHashAddrID:              p.ScriptHashAddrID,
+		PrivateKeyID:                  p.PrivateKeyID,
+		WitnessPubKeyHashAddrID:       p.WitnessPubKeyHashAddrID,
+		WitnessScriptHashAddrID:       p.WitnessScriptHashAddrID,
+		HDCoinType:                    p.HDCoinType,
+	}
+	for _, seed := range p.DNSSeeds {
+		params.DNSSeeds = append(params.DNSSeeds,
+			DNSSeed{seed.Host, seed.HasFiltering})
+	}
+
+	// Build the genesis block.
+	genesisBlock, err := p.Genesis.genesisBlock()
+	if err != nil {
+		return nil, err
+	}
+	genesisHash := genesisBlock.BlockHash()
+	params.GenesisBlock = genesisBlock
+	params.GenesisHash = &genesisHash
+
+	// The proof of work limit defaults to the one described by its compact
+	// form.
+	if params.PowLimitBits == 0 {
+		return nil, fmt.Errorf("powlimitbits must be set")
+	}
+	params.PowLimit = compactToBig(params.PowLimitBits)
+	if p.PowLimit != "" {
+		powLimit, ok := new(big.Int).SetString(p.PowLimit, 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid powlimit %q", p.PowLimit)
+		}
+		params.PowLimit = powLimit
+	}
+	if params.PowLimit.Sign() <= 0 {
+		return nil, fmt.Errorf("powlimit must be positive")
+	}
+	if compactToBig(uint32(p.Genesis.Bits)).Cmp(params.PowLimit) > 0 {
+		return nil, fmt.Errorf("genesis bits %08x exceed the powlimit",
+			uint32(p.Genesis.Bits))
+	}
+
+	powHash, ok := powHashNames[p.PowHash]
+	if !ok {
+		return nil, fmt.Errorf("unknown powhash %q", p.PowHash)
+	}
+	params.PowHashAlgorithm = powHash
+
+	// Ensure the difficulty retarget parameters are usable by the selected
+	// algorithm.
+	retarget, ok := retargetNames[p.Retarget]
+	if !ok {
+		return nil, fmt.Errorf("unknown retarget algorithm %q",
+			p.Retarget)
+	}
+	params.RetargetAlgorithm = retarget
+	params.TargetTimespan, err = parseDuration("targettimespan",
+		p.TargetTimespan)
+	if err != nil {
+		return nil, err
+	}
+	params.TargetTimePerBlock, err = parseDuration("targettimeperblock",
+		p.TargetTimePerBlock)
+	if err != nil {
+		return nil, err
+	}
+	params.MinDiffReductionTime, err = parseDuration("mindiffreductiontime",
+		p.MinDiffReductionTime)
+	if err != nil {
+		return nil, err
+	}
+	if params.TargetTimePerBlock <= 0 {
+		return nil, fmt.Errorf("targettimeperblock must be positive")
+	}
+	switch retarget {
+	case RetargetClassic:
+		if params.TargetTimespan < params.TargetTimePerBlock {
+			return nil, fmt.Errorf("targettimespan must be at least " +
+				"targettimeperblock")
+		}
+		if params.RetargetAdjustmentFactor <= 0 {
+			return nil, fmt.Errorf("retargetadjustmentfactor must " +
+				"be positive")
+		}
+	default:
+		if params.RetargetWindow < 2 {
+			return nil, fmt.Errorf("retargetwindow must be at least "+
+				"2 for %s", p.Retarget)
+		}
+	}
+	if params.ReduceMinDifficulty && params.MinDiffReductionTime <= 0 {
+		return nil, fmt.Errorf("mindiffreductiontime must be positive " +
+			"when reducemindifficulty is set")
+	}
+	if params.SubsidyReductionInterval <= 0 {
+		return nil, fmt.Errorf("subsidyreductioninterval must be positive")
+	}
+
+	// Checkpoints must be ordered from oldest to newest.
+	for i, checkpoint := range p.Checkpoints {
+		hash, err := chainhash.NewHashFromStr(checkpoint.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkpoint hash: %v", err)
+		}
+		if checkpoint.Height <= 0 || (i > 0 &&
+			checkpoint.Height <= p.Checkpoints[i-1].Height) {
+
+			return nil, fmt.Errorf("checkpoint at height %d is not "+
+				"after the previous one", checkpoint.Height)
+		}
+		params.Checkpoints = append(params.Checkpoints,
+			Checkpoint{checkpoint.Height, hash})
+	}
+
+	if p.AssumeValid != "" {
+		hash, err := chainhash.NewHashFromStr(p.AssumeValid)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assumevalid hash: %v", err)
+		}
+		params.AssumeValid = hash
+	}
+	if p.MinimumChainWork != "" {
+		work, ok := new(big.Int).SetString(p.MinimumChainWork, 16)
+		if !ok || work.Sign() < 0 {
+			return nil, fmt.Errorf("invalid minimumchainwork %q",
+				p.MinimumChainWork)
+		}
+		params.MinimumChainWork = work
+	}
+
+	// UTXO snapshots must be ordered from oldest to newest.
+	for i, snapshot := range p.UTXOSnapshots {
+		blockHash, err := chainhash.NewHashFromStr(snapshot.BlockHash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid utxo snapshot block hash: %v",
+				err)
+		}
+		utxoHash, err := chainhash.NewHashFromStr(snapshot.UTXOHash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid utxo snapshot hash: %v", err)
+		}
+		if snapshot.Height <= 0 || (i > 0 &&
+			snapshot.Height <= p.UTXOSnapshots[i-1].Height) {
+
+			return nil, fmt.Errorf("utxo snapshot at height %d is not "+
+				"after the previous one", snapshot.Height)
+		}
+		params.UTXOSnapshots = append(params.UTXOSnapshots,
+			UTXOSnapshot{snapshot.Height, blockHash, utxoHash})
+	}
+
+	if p.ERC20Manager != "" {
+		manager, err := hex.DecodeString(p.ERC20Manager)
+		if err != nil || len(manager) != erc20ManagerSize {
+			return nil, fmt.Errorf("invalid erc20manager address %q",
+				p.ERC20Manager)
+		}
+		params.ERC20Manager = manager
+	}
+
+	// Consensus rule change deployments which are not given never start.
+	if params.MinerConfirmationWindow == 0 ||
+		params.RuleChangeActivationThreshold > params.MinerConfirmationWindow {
+
+		return nil, fmt.Errorf("rulechangeactivationthreshold must not " +
+			"exceed a positive minerconfirmationwindow")
+	}
+	for name, deployment := range p.Deployments {
+		id, ok := deploymentNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown deployment %q", name)
+		}
+		if deployment.BitNumber > maxDeploymentBitNumber {
+			return nil, fmt.Errorf("deployment %s bit number %d is "+
+				"above the max of %d", name, deployment.BitNumber,
+				maxDeploymentBitNumber)
+		}
+		params.Deployments[id] = ConsensusDeployment{
+			BitNumber:  deployment.BitNumber,
+			StartTime:  deployment.StartTime,
+			ExpireTime: deployment.ExpireTime,
+		}
+	}
+
+	// Addresses and keys of the network must be distinguishable.
+	if params.Bech32HRPSegwit == "" {
+		return nil, fmt.Errorf("bech32hrpsegwit must be set")
+	}
+	params.HDPrivateKeyID, err = parseHDKeyID("hdprivatekeyid",
+		p.HDPrivateKeyID)
+	if err != nil {
+		return nil, err
+	}
+	params.HDPublicKeyID, err = parseHDKeyID("hdpublickeyid",
+		p.HDPublicKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileParams{Params: params, RPCPort: p.RPCPort}, nil
+}
+
+// LoadParams decodes and validates the network parameters in the JSON format
+// of network parameter files read from r.  The fields of the format mirror the
+// ones of Params with lowercase names, except that the genesis block is
+// described by its header fields and coinbase.  The parameters are not
+// registered.
+func LoadParams(r io.Reader) (*FileParams, error) {
+	var p jsonParams
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("malformed network parameters: %v", err)
+	}
+	return p.params()
+}
+
+// LoadParamsFile decodes and validates the network parameters in the network
+// parameter file at the passed path.  The parameters are not registered.
+func LoadParamsFile(path string) (*FileParams, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	params, err := LoadParams(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return params, nil
+}
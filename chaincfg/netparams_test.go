@@ -0,0 +1,174 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chaincfg
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// simNetParamsJSON describes the simulation test network in the format of
+// network parameter files.
+const simNetParamsJSON = `{
+	"name": "simnet",
+	"net": "0x12141c16",
+	"defaultport": "18555",
+	"rpcport": "18556",
+	"genesis": {
+		"version": 1,
+		"timestamp": 1401292357,
+		"bits": "207fffff",
+		"nonce": 2,
+		"coinbasescript": "04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73",
+		"coinbasevalue": 5000000000,
+		"pkscript": "4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac",
+		"hash": "683e86bd5c6d110d91b94b97137ba6bfe02dbbdb8e3dff722a669b5d69d77af6"
+	},
+	"powlimit": "7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	"powlimitbits": 545259519,
+	"coinbasematurity": 100,
+	"subsidyreductioninterval": 210000,
+	"targettimespan": "336h",
+	"targettimeperblock": "10m",
+	"retargetadjustmentfactor": 4,
+	"reducemindifficulty": true,
+	"mindiffreductiontime": "20m",
+	"generatesupported": true,
+	"rulechangeactivationthreshold": 75,
+	"minerconfirmationwindow": 100,
+	"deployments": {
+		"testdummy": {"bitnumber": 28, "starttime": 0, "expiretime": 9223372036854775807},
+		"csv": {"bitnumber": 0, "starttime": 0, "expiretime": 9223372036854775807},
+		"segwit": {"bitnumber": 1, "starttime": 0, "expiretime": 9223372036854775807}
+	},
+	"relaynonstdtxs": true,
+	"bech32hrpsegwit": "sb",
+	"pubkeyhashaddrid": 63,
+	"scripthashaddrid": 123,
+	"privatekeyid": 100,
+	"witnesspubkeyhashaddrid": 25,
+	"witnessscripthashaddrid": 40,
+	"hdprivatekeyid": "0420b900",
+	"hdpublickeyid": "0420bd3a",
+	"hdcointype": 115
+}`
+
+// TestLoadParams ensures network parameter files are decoded into the network
+// parameters they describe.
+func TestLoadParams(t *testing.T) {
+	fileParams, err := LoadParams(strings.NewReader(simNetParamsJSON))
+	if err != nil {
+		t.Fatalf("LoadParams: unexpected error: %v", err)
+	}
+	if fileParams.RPCPort != "18556" {
+		t.Errorf("LoadParams: wrong rpc port - got %s, want %s",
+			fileParams.RPCPort, "18556")
+	}
+
+	// The simulation test network has no DNS seeds either way.
+	want := SimNetParams
+	want.DNSSeeds = nil
+	if !reflect.DeepEqual(fileParams.Params, &want) {
+		t.Errorf("LoadParams: mismatched params - got %v, want %v",
+			spew.Sdump(fileParams.Params), spew.Sdump(&want))
+	}
+}
+
+// TestLoadParamsErrors ensures invalid network parameter files are rejected.
+func TestLoadParamsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(p map[string]interface{})
+	}{
+		{"unknown field", func(p map[string]interface{}) {
+			p["bogus"] = true
+		}},
+		{"missing name", func(p map[string]interface{}) {
+			delete(p, "name")
+		}},
+		{"name with path", func(p map[string]interface{}) {
+			p["name"] = "../simnet"
+		}},
+		{"missing magic", func(p map[string]interface{}) {
+			delete(p, "net")
+		}},
+		{"missing rpc port", func(p map[string]interface{}) {
+			delete(p, "rpcport")
+		}},
+		{"wrong genesis hash", func(p map[string]interface{}) {
+			p["genesis"].(map[string]interface{})["nonce"] = 3
+		}},
+		{"short coinbase script", func(p map[string]interface{}) {
+			p["genesis"].(map[string]interface{})["coinbasescript"] = "00"
+		}},
+		{"genesis above pow limit", func(p map[string]interface{}) {
+			p["powlimit"] = "ff"
+		}},
+		{"unknown pow hash", func(p map[string]interface{}) {
+			p["powhash"] = "x11"
+		}},
+		{"unknown retarget", func(p map[string]interface{}) {
+			p["retarget"] = "asert"
+		}},
+		{"missing retarget window", func(p map[string]interface{}) {
+			p["retarget"] = "lwma"
+		}},
+		{"bad duration", func(p map[string]interface{}) {
+			p["targettimespan"] = "two weeks"
+		}},
+		{"unordered checkpoints", func(p map[string]interface{}) {
+			p["checkpoints"] = []map[string]interface{}{
+				{"height": 2, "hash": "683e86bd5c6d110d91b94b97137ba6bfe02dbbdb8e3dff722a669b5d69d77af6"},
+				{"height": 1, "hash": "683e86bd5c6d110d91b94b97137ba6bfe02dbbdb8e3dff722a669b5d69d77af6"},
+			}
+		}},
+		{"bad assumevalid hash", func(p map[string]interface{}) {
+			p["assumevalid"] = "xyz"
+		}},
+		{"bad minimumchainwork", func(p map[string]interface{}) {
+			p["minimumchainwork"] = "xyz"
+		}},
+		{"bad utxo snapshot hash", func(p map[string]interface{}) {
+			p["utxosnapshots"] = []map[string]interface{}{
+				{"height": 1, "blockhash": "683e86bd5c6d110d91b94b97137ba6bfe02dbbdb8e3dff722a669b5d69d77af6", "utxohash": "xyz"},
+			}
+		}},
+		{"threshold above window", func(p map[string]interface{}) {
+			p["rulechangeactivationthreshold"] = 101
+		}},
+		{"unknown deployment", func(p map[string]interface{}) {
+			p["deployments"].(map[string]interface{})["taproot"] =
+				map[string]interface{}{"bitnumber": 2}
+		}},
+		{"reserved deployment bit", func(p map[string]interface{}) {
+			p["deployments"].(map[string]interface{})["csv"] =
+				map[string]interface{}{"bitnumber": 29}
+		}},
+		{"short hd key id", func(p map[string]interface{}) {
+			p["hdpublickeyid"] = "0420bd"
+		}},
+	}
+
+	for _, test := range tests {
+		var p map[string]interface{}
+		if err := json.Unmarshal([]byte(simNetParamsJSON), &p); err != nil {
+			t.Fatalf("Unmarshal: unexpected error: %v", err)
+		}
+		test.mutate(p)
+		paramsJSON, err := json.Marshal(p)
+		if err != nil {
+			t.Fatalf("Marshal: unexpected error: %v", err)
+		}
+		_, err = LoadParams(strings.NewReader(string(paramsJSON)))
+		if err == nil {
+			t.Errorf("LoadParams(%s): did not get expected error",
+				test.name)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package chaincfg
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"strings"
@@ -38,6 +39,11 @@ var (
 	// simNetPowLimit is the highest proof of work value a Bitcoin block
 	// can have for the simulation test network.  It is the value 2^255 - 1.
 	simNetPowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 255), bigOne)
+
+	// sysRegressionPowLimit is the highest proof of work value a Syscoin
+	// block can have for the regression test network.  It is the value
+	// 2^255 - 1.
+	sysRegressionPowLimit = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 255), bigOne)
 )
 
 // Checkpoint identifies a known good point in the block chain.  Using
@@ -52,6 +58,16 @@ type Checkpoint struct {
 	Hash   *chainhash.Hash
 }
 
+// UTXOSnapshot identifies a snapshot of the unspent transaction outputs as of a
+// block which new nodes may be bootstrapped from.  The hash of the serialized
+// unspent transaction outputs and asset states must match for a snapshot to be
+// loaded.
+type UTXOSnapshot struct {
+	Height    int32
+	BlockHash *chainhash.Hash
+	UTXOHash  *chainhash.Hash
+}
+
 // DNSSeed identifies a DNS seed.
 type DNSSeed struct {
 	// Host defines the hostname of the seed.
@@ -62,6 +78,113 @@ type DNSSeed struct {
 	HasFiltering bool
 }
 
+// RetargetAlgorithm identifies the algorithm used to calculate the required
+// difficulty of blocks.
+type RetargetAlgorithm uint8
+
+const (
+	// RetargetClassic is the Bitcoin algorithm which adjusts the difficulty
+	// every TargetTimespan worth of blocks based on the time it took to
+	// generate them, limited by RetargetAdjustmentFactor.
+	RetargetClassic RetargetAlgorithm = iota
+
+	// RetargetDarkGravityWave is version 3 of the Dark Gravity Wave
+	// algorithm which adjusts the difficulty every block based on the
+	// average target of the last RetargetWindow blocks and the time it
+	// took to generate them, limited to a third or three times the
+	// desired time.
+	RetargetDarkGravityWave
+
+	// RetargetLWMA is the linearly weighted moving average algorithm which
+	// adjusts the difficulty every block based on the average target of
+	// the last RetargetWindow blocks and their block times weighted
+	// towards the most recent ones.
+	RetargetLWMA
+
+	// RetargetCustom calculates the difficulty with the CustomRetarget
+	// function of the parameters.
+	RetargetCustom
+)
+
+// retargetAlgorithmStrings is a map of retarget algorithms back to their
+// constant names for pretty printing.
+var retargetAlgorithmStrings = map[RetargetAlgorithm]string{
+	RetargetClassic:         "RetargetClassic",
+	RetargetDarkGravityWave: "RetargetDarkGravityWave",
+	RetargetLWMA:            "RetargetLWMA",
+	RetargetCustom:          "RetargetCustom",
+}
+
+// String returns the RetargetAlgorithm as a human-readable name.
+func (a RetargetAlgorithm) String() string {
+	if s := retargetAlgorithmStrings[a]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown RetargetAlgorithm (%d)", uint8(a))
+}
+
+// RetargetNode provides the details of a block a custom retarget algorithm
+// bases the required difficulty of the next block on, along with access to
+// its ancestors.
+type RetargetNode interface {
+	// Height returns the height of the block.
+	Height() int32
+
+	// Timestamp returns the time of the block as a unix timestamp.
+	Timestamp() int64
+
+	// Bits returns the difficulty bits of the block.
+	Bits() uint32
+
+	// RelativeAncestor returns the ancestor of the block the passed number
+	// of blocks before it, or nil when there is no such block.
+	RelativeAncestor(distance int32) RetargetNode
+}
+
+// RetargetFunc calculates the difficulty bits required for the block after the
+// passed last block of a chain with the passed parameters, which is to be
+// generated at the passed time.  The last block is nil for the genesis block.
+type RetargetFunc func(params *Params, lastNode RetargetNode, newBlockTime time.Time) (uint32, error)
+
+// PowHashAlgorithm identifies the hash function used to calculate the proof of
+// work hash of block headers.  This is independent of the block hash, which
+// identifies blocks and is always the double SHA-256 of the header.
+type PowHashAlgorithm uint8
+
+const (
+	// PowHashSHA256d is the Bitcoin proof of work hash, which is the
+	// double SHA-256 of the header and therefore the block hash itself.
+	PowHashSHA256d PowHashAlgorithm = iota
+
+	// PowHashScrypt is the scrypt hash of the header using the parameters
+	// of Litecoin, which are N = 1024, r = 1 and p = 1 with the header as
+	// both the password and the salt.
+	PowHashScrypt
+
+	// PowHashCustom calculates the proof of work hash with the
+	// CustomPowHash function of the parameters.
+	PowHashCustom
+)
+
+// powHashAlgorithmStrings is a map of proof of work hash algorithms back to
+// their constant names for pretty printing.
+var powHashAlgorithmStrings = map[PowHashAlgorithm]string{
+	PowHashSHA256d: "PowHashSHA256d",
+	PowHashScrypt:  "PowHashScrypt",
+	PowHashCustom:  "PowHashCustom",
+}
+
+// String returns the PowHashAlgorithm as a human-readable name.
+func (a PowHashAlgorithm) String() string {
+	if s := powHashAlgorithmStrings[a]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown PowHashAlgorithm (%d)", uint8(a))
+}
+
+// PowHashFunc calculates the proof of work hash of the passed block header.
+type PowHashFunc func(header *wire.BlockHeader) chainhash.Hash
+
 // ConsensusDeployment defines details related to a specific consensus rule
 // change that is voted in.  This is part of BIP0009.
 type ConsensusDeployment struct {
@@ -76,6 +199,12 @@ type ConsensusDeployment struct {
 	// ExpireTime is the median block time after which the attempted
 	// deployment expires.
 	ExpireTime uint64
+
+	// AlwaysActiveHeight is the block height from which the deployment is
+	// active regardless of the votes cast for it.  This is used for
+	// networks which buried the deployment instead of voting it in.  A
+	// value of zero means the deployment is always voted on.
+	AlwaysActiveHeight int32
 }
 
 // Constants that define the deployment offset in the deployments field of the
@@ -134,6 +263,22 @@ type Params struct {
 	// block in compact form.
 	PowLimitBits uint32
 
+	// AuxPowChainID is the chain ID merged mined blocks must carry in their
+	// version.  Once it is set, every block with a version above the legacy
+	// version 1 must carry it.  A value of zero means merged mining is not
+	// supported.
+	AuxPowChainID int32
+
+	// PowHashAlgorithm selects the hash function used to calculate the
+	// proof of work hash of block headers, which must not exceed the
+	// target difficulty.  The zero value is the double SHA-256 of Bitcoin.
+	PowHashAlgorithm PowHashAlgorithm
+
+	// CustomPowHash calculates the proof of work hash of block headers.
+	//
+	// NOTE: This only applies if PowHashAlgorithm is PowHashCustom.
+	CustomPowHash PowHashFunc
+
 	// These fields define the block heights at which the specified softfork
 	// BIP became active.
 	BIP0034Height int32
@@ -148,6 +293,15 @@ type Params struct {
 	// is reduced.
 	SubsidyReductionInterval int32
 
+	// BaseSubsidy is the starting subsidy amount for mined blocks.  A value
+	// of zero means the Bitcoin subsidy of 50 coins.
+	BaseSubsidy int64
+
+	// SubsidyReductionPercent is the percentage by which the subsidy is
+	// reduced every SubsidyReductionInterval blocks.  A value of zero means
+	// the subsidy is halved.
+	SubsidyReductionPercent int64
+
 	// TargetTimespan is the desired amount of time that should elapse
 	// before the block difficulty requirement is examined to determine how
 	// it should be changed in order to maintain the desired block
@@ -163,6 +317,25 @@ type Params struct {
 	// difficulty retargets.
 	RetargetAdjustmentFactor int64
 
+	// RetargetAlgorithm selects the algorithm used to calculate the
+	// required difficulty of blocks.  The zero value selects the classic
+	// Bitcoin algorithm.
+	RetargetAlgorithm RetargetAlgorithm
+
+	// RetargetWindow is the number of previous blocks the per-block
+	// retarget algorithms base the required difficulty on.
+	//
+	// NOTE: This only applies to RetargetDarkGravityWave, RetargetLWMA and
+	// RetargetCustom.  Custom retarget algorithms must not access ancestors
+	// further back than it since those of presynced block headers are not
+	// kept.
+	RetargetWindow int32
+
+	// CustomRetarget calculates the required difficulty of blocks.
+	//
+	// NOTE: This only applies if RetargetAlgorithm is RetargetCustom.
+	CustomRetarget RetargetFunc
+
 	// ReduceMinDifficulty defines whether the network should reduce the
 	// minimum required difficulty after a long enough period of time has
 	// passed without finding a block.  This is really only useful for test
@@ -181,6 +354,49 @@ type Params struct {
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints []Checkpoint
 
+	// AssumeValid is the hash of a block whose ancestors are assumed to
+	// have valid scripts.  The scripts of its ancestors are not validated
+	// once it is part of the best known header chain and buried deeply
+	// enough.  All other consensus rules are still enforced.
+	//
+	// It can be nil to validate the scripts of all blocks.
+	AssumeValid *chainhash.Hash
+
+	// MinimumChainWork is the minimum cumulative proof of work a chain of
+	// headers received from a peer must have before the headers are added
+	// to the block index.  Until then, the headers are only verified
+	// without storing them, so peers can't exhaust the memory of the node
+	// by sending long chains of headers with little proof of work.
+	//
+	// It can be nil to add the headers of any chain right away.
+	MinimumChainWork *big.Int
+
+	// UTXOSnapshots are the snapshots of the unspent transaction outputs
+	// new nodes may be bootstrapped from, ordered from oldest to newest.
+	UTXOSnapshots []UTXOSnapshot
+
+	// AssetsSupported specifies whether or not the Syscoin asset rules
+	// apply to transactions.  When it is not set, transactions with the
+	// version of a Syscoin transaction are not subject to any additional
+	// rules, as is the case on the Bitcoin networks.
+	AssetsSupported bool
+
+	// SysXAssetGuid is the guid of the SYSX asset which represents SYS
+	// among the assets.  Burns of SYS to an asset allocation may only
+	// create value of it.  A value of zero means SYS can't be burned to an
+	// asset allocation.
+	SysXAssetGuid uint64
+
+	// ERC20Manager is the address of the ERC20 manager contract on the
+	// NEVM chain whose burns are moved to Syscoin by mint transactions.
+	// Mints are rejected when it is not set.
+	ERC20Manager []byte
+
+	// NEVMSupported specifies whether or not blocks of the network commit
+	// to the NEVM blocks connected to them, which are then requested from
+	// and served to peers which advertise support for them.
+	NEVMSupported bool
+
 	// These fields are related to voting on consensus rule changes as
 	// defined by BIP0009.
 	//
@@ -273,6 +489,8 @@ var MainNetParams = Params{
 		{352940, newHashFromStr("000000000000000010755df42dba556bb72be6a32f3ce0b6941ce4430152c9ff")},
 		{382320, newHashFromStr("00000000000000000a8dc6ed5b133d0eb2fd6af56203e4159789b092defd8ab2")},
 	},
+	AssumeValid:      newHashFromStr("0000000000000000005214481d2d96f898e3d5416e43359c145944a909d242e0"), // 506067
+	MinimumChainWork: hexToBig("f91c579d57cad4bc5278cc"),                                                 // 506067
 
 	// Consensus rule change deployments.
 	//
@@ -440,6 +658,8 @@ var TestNet3Params = Params{
 		{900000, newHashFromStr("0000000000356f8d8924556e765b7a94aaebc6b5c8685dcfa2b1ee8b41acd89b")},
 		{1000007, newHashFromStr("00000000001ccb893d8a1f25b70ad173ce955e5f50124261bbbc50379a612ddf")},
 	},
+	AssumeValid:      newHashFromStr("0000000002e9e7b00e1f6dc5123a04aad68dd0f0968d8c7aa45f6640795c37b1"), // 1135275
+	MinimumChainWork: hexToBig("2830dab7f76dbb7d63"),                                                     // 1135275
 
 	// Consensus rule change deployments.
 	//
@@ -568,6 +788,87 @@ var SimNetParams = Params{
 	HDCoinType: 115, // ASCII for s
 }
 
+// SysRegressionNetParams defines the network parameters for the Syscoin
+// regression test network.  It shares its magic bytes with the Bitcoin
+// regression test network, so only its address encoding magics are registered
+// by default.
+var SysRegressionNetParams = Params{
+	Name:        "sysregtest",
+	Net:         wire.TestNet,
+	DefaultPort: "18444",
+	DNSSeeds:    []DNSSeed{},
+
+	// Chain parameters
+	GenesisBlock:             &sysRegTestGenesisBlock,
+	GenesisHash:              &sysRegTestGenesisHash,
+	PowLimit:                 sysRegressionPowLimit,
+	PowLimitBits:             0x207fffff,
+	AuxPowChainID:            0x1000,
+	CoinbaseMaturity:         100,
+	BIP0034Height:            100000000, // Not active - Permit ver 1 blocks
+	BIP0065Height:            1351,      // Used by regression tests
+	BIP0066Height:            1251,      // Used by regression tests
+	SubsidyReductionInterval: 150,
+	BaseSubsidy:              3850000000, // 38.5 SYS
+	SubsidyReductionPercent:  5,
+	TargetTimespan:           time.Hour * 6, // 6 hours
+	TargetTimePerBlock:       time.Minute,   // 1 minute
+	RetargetAdjustmentFactor: 4,             // 25% less, 400% more
+	ReduceMinDifficulty:      true,
+	MinDiffReductionTime:     time.Minute * 2, // TargetTimePerBlock * 2
+	GenerateSupported:        true,
+
+	// Checkpoints ordered from oldest to newest.
+	Checkpoints: nil,
+
+	// Syscoin asset and NEVM parameters.
+	AssetsSupported: true,
+	SysXAssetGuid:   123456,
+	NEVMSupported:   true,
+
+	// Consensus rule change deployments.
+	//
+	// The miner confirmation window is defined as:
+	//   target proof of work timespan / target proof of work spacing
+	RuleChangeActivationThreshold: 108, // 75%  of MinerConfirmationWindow
+	MinerConfirmationWindow:       144,
+	Deployments: [DefinedDeployments]ConsensusDeployment{
+		DeploymentTestDummy: {
+			BitNumber:  28,
+			StartTime:  0,             // Always available for vote
+			ExpireTime: math.MaxInt64, // Never expires
+		},
+		DeploymentCSV: {
+			BitNumber:          0,
+			AlwaysActiveHeight: 1, // Always active after genesis
+		},
+		DeploymentSegwit: {
+			BitNumber:          1,
+			AlwaysActiveHeight: 1, // Always active after genesis
+		},
+	},
+
+	// Mempool parameters
+	RelayNonStdTxs: true,
+
+	// Human-readable part for Bech32 encoded segwit addresses, as defined in
+	// BIP 173.
+	Bech32HRPSegwit: "scrt", // always scrt for Syscoin reg test net
+
+	// Address encoding magics
+	PubKeyHashAddrID: 0x41, // starts with T
+	ScriptHashAddrID: 0xc4, // starts with 2
+	PrivateKeyID:     0xef, // starts with 9 (uncompressed) or c (compressed)
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
+	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 1,
+}
+
 var (
 	// ErrDuplicateNet describes an error where the parameters for a Bitcoin
 	// network could not be set due to the network already being a standard
@@ -607,6 +908,14 @@ func Register(params *Params) error {
 		return ErrDuplicateNet
 	}
 	registeredNets[params.Net] = struct{}{}
+	registerAddrIDs(params)
+	return nil
+}
+
+// registerAddrIDs registers the address and extended key encoding magics of
+// the passed network parameters so addresses and keys for the network can be
+// decoded.
+func registerAddrIDs(params *Params) {
 	pubKeyHashAddrIDs[params.PubKeyHashAddrID] = struct{}{}
 	scriptHashAddrIDs[params.ScriptHashAddrID] = struct{}{}
 	hdPrivToPubKeyIDs[params.HDPrivateKeyID] = params.HDPublicKeyID[:]
@@ -614,7 +923,6 @@ func Register(params *Params) error {
 	// A valid Bech32 encoded segwit address always has as prefix the
 	// human-readable part for the given net followed by '1'.
 	bech32SegwitPrefixes[params.Bech32HRPSegwit+"1"] = struct{}{}
-	return nil
 }
 
 // mustRegister performs the same function as Register except it panics if there
@@ -693,10 +1001,25 @@ func newHashFromStr(hexStr string) *chainhash.Hash {
 	return hash
 }
 
+// hexToBig converts the passed hex string into a big integer.  It panics on an
+// error since it will only (and must only) be called with hard-coded, and
+// therefore known good, values.
+func hexToBig(hexStr string) *big.Int {
+	n, ok := new(big.Int).SetString(hexStr, 16)
+	if !ok {
+		panic(fmt.Sprintf("invalid hex number %q", hexStr))
+	}
+	return n
+}
+
 func init() {
 	// Register all default networks when the package is initialized.
 	mustRegister(&MainNetParams)
 	mustRegister(&TestNet3Params)
 	mustRegister(&RegressionNetParams)
 	mustRegister(&SimNetParams)
+
+	// The Syscoin regression test network shares its magic bytes with the
+	// Bitcoin one, so it can't be registered as a distinct network.
+	registerAddrIDs(&SysRegressionNetParams)
 }
@@ -73,6 +73,11 @@ func TestRegister(t *testing.T) {
 					params: &SimNetParams,
 					err:    ErrDuplicateNet,
 				},
+				{
+					name:   "duplicate sysregtest",
+					params: &SysRegressionNetParams,
+					err:    ErrDuplicateNet,
+				},
 			},
 			p2pkhMagics: []magicTest{
 				{
@@ -91,6 +96,10 @@ func TestRegister(t *testing.T) {
 					magic: SimNetParams.PubKeyHashAddrID,
 					valid: true,
 				},
+				{
+					magic: SysRegressionNetParams.PubKeyHashAddrID,
+					valid: true,
+				},
 				{
 					magic: mockNetParams.PubKeyHashAddrID,
 					valid: false,
@@ -117,6 +126,10 @@ func TestRegister(t *testing.T) {
 					magic: SimNetParams.ScriptHashAddrID,
 					valid: true,
 				},
+				{
+					magic: SysRegressionNetParams.ScriptHashAddrID,
+					valid: true,
+				},
 				{
 					magic: mockNetParams.ScriptHashAddrID,
 					valid: false,
@@ -143,6 +156,10 @@ func TestRegister(t *testing.T) {
 					prefix: SimNetParams.Bech32HRPSegwit + "1",
 					valid:  true,
 				},
+				{
+					prefix: SysRegressionNetParams.Bech32HRPSegwit + "1",
+					valid:  true,
+				},
 				{
 					prefix: strings.ToUpper(MainNetParams.Bech32HRPSegwit + "1"),
 					valid:  true,
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -94,10 +95,18 @@ func (bi *blockImporter) readBlock() ([]byte, error) {
 // with any potential errors.
 func (bi *blockImporter) processBlock(serializedBlock []byte) (bool, error) {
 	// Deserialize the block which includes checks for malformed blocks.
-	block, err := btcutil.NewBlockFromBytes(serializedBlock)
+	// Merged mined blocks carry their auxpow on chains which support merged
+	// mining.
+	enc := wire.WitnessEncoding
+	if activeNetParams.AuxPowChainID != 0 {
+		enc |= wire.AuxPowEncoding
+	}
+	var msgBlock wire.MsgBlock
+	err := msgBlock.BtcDecode(bytes.NewReader(serializedBlock), 0, enc)
 	if err != nil {
 		return false, err
 	}
+	block := btcutil.NewBlock(&msgBlock)
 
 	// update progress statistics
 	bi.lastBlockTime = block.MsgBlock().Header.Timestamp
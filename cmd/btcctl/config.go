@@ -16,6 +16,7 @@ import (
 	flags "github.com/jessevdk/go-flags"
 	"github.com/martinboehm/btcutil"
 	"github.com/vpubchain/btcd/btcjson"
+	"github.com/vpubchain/btcd/chaincfg"
 )
 
 const (
@@ -105,17 +106,21 @@ type config struct {
 	ProxyPass     string `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
 	TestNet3      bool   `long:"testnet" description:"Connect to testnet"`
 	SimNet        bool   `long:"simnet" description:"Connect to the simulation test network"`
+	NetParams     string `long:"netparams" description:"Connect to the custom network defined by the specified network parameters file"`
 	TLSSkipVerify bool   `long:"skipverify" description:"Do not verify tls certificates (not recommended!)"`
 	Wallet        bool   `long:"wallet" description:"Connect to wallet"`
 }
 
 // normalizeAddress returns addr with the passed default port appended if
-// there is not already a port specified.
-func normalizeAddress(addr string, useTestNet3, useSimNet, useWallet bool) string {
+// there is not already a port specified.  The passed RPC port of a custom
+// network takes precedence over the ports of the standard networks.
+func normalizeAddress(addr string, useTestNet3, useSimNet, useWallet bool, netRPCPort string) string {
 	_, _, err := net.SplitHostPort(addr)
 	if err != nil {
 		var defaultPort string
 		switch {
+		case netRPCPort != "":
+			defaultPort = netRPCPort
 		case useTestNet3:
 			if useWallet {
 				defaultPort = "18332"
@@ -254,14 +259,42 @@ func loadConfig() (*config, []string, error) {
 	if cfg.SimNet {
 		numNets++
 	}
+	if cfg.NetParams != "" {
+		numNets++
+	}
 	if numNets > 1 {
-		str := "%s: The testnet and simnet params can't be used " +
-			"together -- choose one of the two"
+		str := "%s: The testnet, simnet, and netparams params can't be " +
+			"used together -- choose one of the three"
 		err := fmt.Errorf(str, "loadConfig")
 		fmt.Fprintln(os.Stderr, err)
 		return nil, nil, err
 	}
 
+	// Load the custom network parameters to find the default RPC port of
+	// the network.  The RPC port of the wallet is not part of them, so it
+	// must be specified along with the RPC server in that case.
+	var netRPCPort string
+	if cfg.NetParams != "" {
+		fileParams, err := chaincfg.LoadParamsFile(
+			cleanAndExpandPath(cfg.NetParams))
+		if err != nil {
+			str := "%s: Unable to load network parameters: %v"
+			err := fmt.Errorf(str, "loadConfig", err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		netRPCPort = fileParams.RPCPort
+
+		_, _, err = net.SplitHostPort(cfg.RPCServer)
+		if cfg.Wallet && err != nil {
+			str := "%s: The wallet RPC server port must be " +
+				"specified when using netparams"
+			err := fmt.Errorf(str, "loadConfig")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
 	// Override the RPC certificate if the --wallet flag was specified and
 	// the user did not specify one.
 	if cfg.Wallet && cfg.RPCCert == defaultRPCCertFile {
@@ -271,10 +304,10 @@ func loadConfig() (*config, []string, error) {
 	// Handle environment variable expansion in the RPC certificate path.
 	cfg.RPCCert = cleanAndExpandPath(cfg.RPCCert)
 
-	// Add default port to RPC server based on --testnet and --wallet flags
-	// if needed.
+	// Add default port to RPC server based on --testnet, --netparams and
+	// --wallet flags if needed.
 	cfg.RPCServer = normalizeAddress(cfg.RPCServer, cfg.TestNet3,
-		cfg.SimNet, cfg.Wallet)
+		cfg.SimNet, cfg.Wallet, netRPCPort)
 
 	return &cfg, remainingArgs, nil
 }
@@ -0,0 +1,151 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/chaincfg"
+)
+
+const (
+	defaultBits    = "207fffff"
+	defaultReward  = 50
+	defaultVersion = 1
+	defaultName    = "privNet"
+	defaultPowHash = "sha256d"
+
+	// defaultPkScript is the public key script the coinbase of the genesis
+	// block of the standard networks pays to.
+	defaultPkScript = "4104678afdb0fe5548271967f1a67130b7105cd6a828e0" +
+		"3909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7" +
+		"ba0b8d578a4c702b6bf11d5fac"
+)
+
+// powHashAlgorithms maps the names of the supported proof of work hash
+// functions to the functions.
+var powHashAlgorithms = map[string]chaincfg.PowHashAlgorithm{
+	"sha256d": chaincfg.PowHashSHA256d,
+	"scrypt":  chaincfg.PowHashScrypt,
+}
+
+// config defines the configuration options for gengenesis.
+//
+// See loadConfig for details on the configuration load process.
+type config struct {
+	Message   string  `short:"m" long:"message" description:"Message to embed in the signature script of the coinbase"`
+	Timestamp int64   `short:"t" long:"timestamp" description:"Unix time of the genesis block -- Defaults to the current time"`
+	Bits      string  `short:"b" long:"bits" description:"Hex-encoded difficulty bits of the genesis block"`
+	Reward    float64 `short:"r" long:"reward" description:"Amount of coins the coinbase pays"`
+	PkScript  string  `short:"p" long:"pkscript" description:"Hex-encoded public key script the coinbase pays to"`
+	Version   int32   `long:"blockversion" description:"Version of the genesis block"`
+	Nonce     uint32  `short:"n" long:"nonce" description:"Nonce to start searching from"`
+	PowHash   string  `long:"powhash" description:"Proof of work hash function {sha256d, scrypt}"`
+	Name      string  `long:"name" description:"Prefix of the names of the generated Go variables"`
+
+	// These fields are set from the options above once they have been
+	// validated.
+	bits     uint32
+	reward   btcutil.Amount
+	pkScript []byte
+	powHash  chaincfg.PowHashAlgorithm
+	time     time.Time
+}
+
+// loadConfig initializes and parses the config using command line options.
+func loadConfig() (*config, []string, error) {
+	// Default config.
+	cfg := config{
+		Bits:     defaultBits,
+		Reward:   defaultReward,
+		PkScript: defaultPkScript,
+		Version:  defaultVersion,
+		PowHash:  defaultPowHash,
+		Name:     defaultName,
+	}
+
+	// Parse command line options.
+	parser := flags.NewParser(&cfg, flags.Default)
+	remainingArgs, err := parser.Parse()
+	if err != nil {
+		if e, ok := err.(*flags.Error); !ok || e.Type != flags.ErrHelp {
+			parser.WriteHelp(os.Stderr)
+		}
+		return nil, nil, err
+	}
+
+	funcName := "loadConfig"
+	if cfg.Message == "" {
+		str := "%s: A coinbase message must be specified"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	// Validate the difficulty bits.
+	bits, err := strconv.ParseUint(strings.TrimPrefix(cfg.Bits, "0x"), 16,
+		32)
+	if err != nil || bits == 0 {
+		str := "%s: The specified bits [%v] are invalid"
+		err := fmt.Errorf(str, funcName, cfg.Bits)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+	cfg.bits = uint32(bits)
+
+	// Validate the reward and the script it is paid to.
+	cfg.reward, err = btcutil.NewAmount(cfg.Reward)
+	if err != nil || cfg.reward < 0 {
+		str := "%s: The specified reward [%v] is invalid"
+		err := fmt.Errorf(str, funcName, cfg.Reward)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+	cfg.pkScript, err = hex.DecodeString(cfg.PkScript)
+	if err != nil {
+		str := "%s: The specified pkscript is invalid: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	// Validate the proof of work hash function.
+	powHash, ok := powHashAlgorithms[cfg.PowHash]
+	if !ok {
+		str := "%s: The specified proof of work hash [%v] is " +
+			"unsupported"
+		err := fmt.Errorf(str, funcName, cfg.PowHash)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+	cfg.powHash = powHash
+
+	if cfg.Name == "" {
+		str := "%s: The variable name prefix must not be empty"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	cfg.time = time.Unix(cfg.Timestamp, 0)
+	if cfg.Timestamp == 0 {
+		cfg.time = time.Unix(time.Now().Unix(), 0)
+	}
+
+	return &cfg, remainingArgs, nil
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"math"
+	"math/big"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vpubchain/btcd/blockchain"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/txscript"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// coinbaseScriptBits is the number the signature script of the coinbase
+	// starts with.  It is the difficulty bits of the Bitcoin genesis block,
+	// which every genesis block derived from it carries regardless of its
+	// own difficulty.
+	coinbaseScriptBits = 0x1d00ffff
+
+	// maxCoinbaseScriptLen is the maximum length of the signature script
+	// of a coinbase allowed by consensus.
+	maxCoinbaseScriptLen = 100
+
+	// nonceBatchSize is the number of nonces each worker tests per round
+	// of the search.
+	nonceBatchSize = 1 << 16
+)
+
+// coinbaseScript returns the signature script of the genesis coinbase with the
+// passed message in the format of the Bitcoin genesis block.  The number 4
+// preceding the message is pushed as data rather than as OP_4 to match it.
+func coinbaseScript(message string) ([]byte, error) {
+	return txscript.NewScriptBuilder().AddInt64(coinbaseScriptBits).
+		AddOps([]byte{txscript.OP_DATA_1, 4}).
+		AddData([]byte(message)).Script()
+}
+
+// searchNonces tests the nonceBatchSize nonces of each of the passed number of
+// workers starting at the passed nonce and returns the lowest nonce which
+// makes the proof of work hash of the passed header meet the passed target,
+// if any.
+func searchNonces(header wire.BlockHeader, start uint32, target *big.Int,
+	params *chaincfg.Params, numWorkers int) (uint32, bool) {
+
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	var best uint32
+	found := false
+	for i := 0; i < numWorkers; i++ {
+		first := uint64(start) + uint64(i)*nonceBatchSize
+		if first > math.MaxUint32 {
+			break
+		}
+		last := first + nonceBatchSize - 1
+		if last > math.MaxUint32 {
+			last = math.MaxUint32
+		}
+
+		wg.Add(1)
+		go func(hdr wire.BlockHeader, first, last uint64) {
+			defer wg.Done()
+			for nonce := first; nonce <= last; nonce++ {
+				hdr.Nonce = uint32(nonce)
+				hash := blockchain.PowHash(&hdr, params)
+				if blockchain.HashToBig(&hash).Cmp(target) <= 0 {
+					mtx.Lock()
+					if !found || hdr.Nonce < best {
+						best = hdr.Nonce
+						found = true
+					}
+					mtx.Unlock()
+					return
+				}
+			}
+		}(header, first, last)
+	}
+	wg.Wait()
+	return best, found
+}
+
+// solveGenesis finds the lowest nonce starting from the nonce of the passed
+// header which makes the proof of work hash of the header meet its target
+// difficulty.  The timestamp is increased when the nonce space is exhausted.
+func solveGenesis(header *wire.BlockHeader, params *chaincfg.Params) {
+	target := blockchain.CompactToBig(header.Bits)
+	numWorkers := runtime.NumCPU()
+	start := header.Nonce
+	for {
+		nonce, found := searchNonces(*header, start, target, params,
+			numWorkers)
+		if found {
+			header.Nonce = nonce
+			return
+		}
+
+		next := uint64(start) + uint64(numWorkers)*nonceBatchSize
+		if next > math.MaxUint32 {
+			header.Timestamp = header.Timestamp.Add(time.Second)
+			next = 0
+		}
+		start = uint32(next)
+	}
+}
+
+// writeBytes writes the passed bytes as the elements of a Go byte slice or
+// array literal with 8 bytes per line followed by their printable characters.
+func writeBytes(buf *bytes.Buffer, b []byte, indent string) {
+	for i := 0; i < len(b); i += 8 {
+		end := i + 8
+		if end > len(b) {
+			end = len(b)
+		}
+
+		buf.WriteString(indent)
+		ascii := make([]byte, 0, 8)
+		for _, c := range b[i:end] {
+			fmt.Fprintf(buf, "0x%02x, ", c)
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			ascii = append(ascii, c)
+		}
+		fmt.Fprintf(buf, "/* |%s| */\n", ascii)
+	}
+}
+
+// writeHash writes the passed hash as a chainhash.Hash variable with the
+// passed name and documentation.
+func writeHash(buf *bytes.Buffer, name, doc string, hash *chainhash.Hash) {
+	fmt.Fprintf(buf, "// %s %s\n", name, doc)
+	fmt.Fprintf(buf, "var %s = chainhash.Hash([chainhash.HashSize]byte{ "+
+		"// Make go vet happy.\n", name)
+	for i := 0; i < chainhash.HashSize; i += 8 {
+		buf.WriteString("\t")
+		for _, c := range hash[i : i+8] {
+			fmt.Fprintf(buf, "0x%02x, ", c)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("})\n\n")
+}
+
+// goSnippet returns the declarations of the passed genesis block in the style
+// of chaincfg/genesis.go using the passed prefix for the variable names.
+func goSnippet(block *wire.MsgBlock, prefix string) ([]byte, error) {
+	coinbase := block.Transactions[0]
+	header := &block.Header
+	blockHash := block.BlockHash()
+	target := blockchain.CompactToBig(header.Bits)
+
+	var buf bytes.Buffer
+	coinbaseName := prefix + "GenesisCoinbaseTx"
+	fmt.Fprintf(&buf, "// %s is the coinbase transaction for the "+
+		"genesis block for the %s network.\n", coinbaseName, prefix)
+	fmt.Fprintf(&buf, "var %s = wire.MsgTx{\n", coinbaseName)
+	fmt.Fprintf(&buf, "Version: %d,\n", coinbase.Version)
+	buf.WriteString("TxIn: []*wire.TxIn{\n{\n")
+	buf.WriteString("PreviousOutPoint: wire.OutPoint{\n")
+	buf.WriteString("Hash: chainhash.Hash{},\nIndex: 0xffffffff,\n},\n")
+	buf.WriteString("SignatureScript: []byte{\n")
+	writeBytes(&buf, coinbase.TxIn[0].SignatureScript, "")
+	buf.WriteString("},\nSequence: 0xffffffff,\n},\n},\n")
+	buf.WriteString("TxOut: []*wire.TxOut{\n{\n")
+	fmt.Fprintf(&buf, "Value: 0x%x,\n", coinbase.TxOut[0].Value)
+	buf.WriteString("PkScript: []byte{\n")
+	writeBytes(&buf, coinbase.TxOut[0].PkScript, "")
+	buf.WriteString("},\n},\n},\n")
+	fmt.Fprintf(&buf, "LockTime: %d,\n}\n\n", coinbase.LockTime)
+
+	hashName := prefix + "GenesisHash"
+	writeHash(&buf, hashName, fmt.Sprintf("is the hash of the first "+
+		"block in the block chain for the %s network (genesis block).",
+		prefix), &blockHash)
+	merkleName := prefix + "GenesisMerkleRoot"
+	writeHash(&buf, merkleName, fmt.Sprintf("is the hash of the first "+
+		"transaction in the genesis block for the %s network.", prefix),
+		&header.MerkleRoot)
+
+	blockName := prefix + "GenesisBlock"
+	fmt.Fprintf(&buf, "// %s defines the genesis block of the block "+
+		"chain which serves as the public transaction ledger for the "+
+		"%s network.\n", blockName, prefix)
+	fmt.Fprintf(&buf, "var %s = wire.MsgBlock{\n", blockName)
+	buf.WriteString("Header: wire.BlockHeader{\n")
+	fmt.Fprintf(&buf, "Version: %d,\n", header.Version)
+	fmt.Fprintf(&buf, "PrevBlock: chainhash.Hash{}, // %v\n",
+		header.PrevBlock)
+	fmt.Fprintf(&buf, "MerkleRoot: %s, // %v\n", merkleName,
+		header.MerkleRoot)
+	fmt.Fprintf(&buf, "Timestamp: time.Unix(%d, 0), // %v\n",
+		header.Timestamp.Unix(), header.Timestamp.UTC())
+	fmt.Fprintf(&buf, "Bits: 0x%08x, // %d [%064x]\n", header.Bits,
+		header.Bits, target)
+	fmt.Fprintf(&buf, "Nonce: 0x%08x, // %d\n", header.Nonce,
+		header.Nonce)
+	buf.WriteString("},\n")
+	fmt.Fprintf(&buf, "Transactions: []*wire.MsgTx{&%s},\n}\n",
+		coinbaseName)
+
+	return format.Source(buf.Bytes())
+}
+
+// genesisJSON returns the genesis object of network parameter files which
+// describes the passed genesis block.
+func genesisJSON(block *wire.MsgBlock) ([]byte, error) {
+	coinbase := block.Transactions[0]
+	header := &block.Header
+	genesis := struct {
+		Version        int32  `json:"version"`
+		Timestamp      int64  `json:"timestamp"`
+		Bits           string `json:"bits"`
+		Nonce          uint32 `json:"nonce"`
+		CoinbaseScript string `json:"coinbasescript"`
+		CoinbaseValue  int64  `json:"coinbasevalue"`
+		PkScript       string `json:"pkscript"`
+		Hash           string `json:"hash"`
+	}{
+		Version:        header.Version,
+		Timestamp:      header.Timestamp.Unix(),
+		Bits:           fmt.Sprintf("%08x", header.Bits),
+		Nonce:          header.Nonce,
+		CoinbaseScript: hex.EncodeToString(coinbase.TxIn[0].SignatureScript),
+		CoinbaseValue:  coinbase.TxOut[0].Value,
+		PkScript:       hex.EncodeToString(coinbase.TxOut[0].PkScript),
+		Hash:           block.BlockHash().String(),
+	}
+	return json.MarshalIndent(map[string]interface{}{"genesis": genesis},
+		"", "\t")
+}
+
+func main() {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		os.Exit(1)
+	}
+
+	script, err := coinbaseScript(cfg.Message)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to build coinbase script: %v\n", err)
+		os.Exit(1)
+	}
+	if len(script) > maxCoinbaseScriptLen {
+		fmt.Fprintf(os.Stderr, "Coinbase message is too long: the "+
+			"coinbase script of %d bytes exceeds the max of %d\n",
+			len(script), maxCoinbaseScriptLen)
+		os.Exit(1)
+	}
+
+	// Mine the genesis block using the selected proof of work hash.
+	block := chaincfg.NewGenesisBlock(cfg.Version, cfg.time, cfg.bits,
+		cfg.Nonce, script, int64(cfg.reward), cfg.pkScript)
+	params := &chaincfg.Params{PowHashAlgorithm: cfg.powHash}
+	fmt.Fprintf(os.Stderr, "Mining genesis block with target %064x...\n",
+		blockchain.CompactToBig(cfg.bits))
+	solveGenesis(&block.Header, params)
+
+	snippet, err := goSnippet(block, cfg.Name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to format Go snippet: %v\n", err)
+		os.Exit(1)
+	}
+	genesis, err := genesisJSON(block)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to encode genesis: %v\n", err)
+		os.Exit(1)
+	}
+
+	powHash := blockchain.PowHash(&block.Header, params)
+	fmt.Printf("Block hash:  %v\n", block.BlockHash())
+	if cfg.powHash != chaincfg.PowHashSHA256d {
+		fmt.Printf("PoW hash:    %v\n", powHash)
+	}
+	fmt.Printf("Merkle root: %v\n", block.Header.MerkleRoot)
+	fmt.Printf("Timestamp:   %d\n", block.Header.Timestamp.Unix())
+	fmt.Printf("Nonce:       %d\n", block.Header.Nonce)
+	fmt.Printf("\n// chaincfg/genesis.go\n\n%s\n", snippet)
+	fmt.Printf("// Network parameters file\n\n%s\n",
+		strings.TrimSpace(string(genesis)))
+}
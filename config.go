@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net"
 	"os"
 	"path/filepath"
@@ -61,9 +62,12 @@ const (
 	defaultMaxOrphanTransactions = 100
 	defaultMaxOrphanTxSize       = 100000
 	defaultSigCacheMaxSize       = 100000
+	defaultUtxoCacheMaxSizeMiB   = 250
+	defaultUtxoFlushInterval     = time.Minute * 5
 	sampleConfigFilename         = "sample-btcd.conf"
 	defaultTxIndex               = false
 	defaultAddrIndex             = false
+	minPruneTargetMiB            = 550
 )
 
 var (
@@ -132,8 +136,13 @@ type config struct {
 	TestNet3             bool          `long:"testnet" description:"Use the test network"`
 	RegressionTest       bool          `long:"regtest" description:"Use the regression test network"`
 	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
+	SysRegressionTest    bool          `long:"sysregtest" description:"Use the Syscoin regression test network"`
+	NetParams            string        `long:"netparams" description:"Use the custom network defined by the specified network parameters file"`
 	AddCheckpoints       []string      `long:"addcheckpoint" description:"Add a custom checkpoint.  Format: '<height>:<hash>'"`
 	DisableCheckpoints   bool          `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing."`
+	AssumeValid          string        `long:"assumevalid" description:"Hash of a block whose ancestors are assumed to have valid scripts once it is buried in the best header chain -- 0 to validate all scripts (default: network specific)"`
+	MinimumChainWork     string        `long:"minimumchainwork" description:"Minimum cumulative proof of work in hex of a peer's chain of headers before they are stored -- 0 to store the headers of any chain right away (default: network specific)"`
+	LoadSnapshot         string        `long:"loadsnapshot" description:"Bootstrap a new database from the UTXO snapshot in this file, which must be pinned by the network parameters -- the blocks before it are validated in the background and it is incompatible with the optional indexes"`
 	DbType               string        `long:"dbtype" description:"Database backend to use for the Block Chain"`
 	Profile              string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
@@ -156,17 +165,27 @@ type config struct {
 	NoCFilters           bool          `long:"nocfilters" description:"Disable committed filtering (CF) support"`
 	DropCfIndex          bool          `long:"dropcfindex" description:"Deletes the index used for committed filtering (CF) support from the database on start up and then exits."`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
+	UtxoCacheMaxSize     uint64        `long:"utxocachemaxsize" description:"The maximum size in MiB of the cache of unspent transaction outputs, which is written to the database once it is full -- 0 to write the changes of every block right away"`
+	UtxoFlushInterval    time.Duration `long:"utxoflushinterval" description:"How often to write the cache of unspent transaction outputs to the database when it is not full, which limits the blocks to reconnect after a crash.  Valid time units are {s, m, h} -- 0 to only write it once full or on shutdown"`
 	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
 	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
 	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
 	AddrIndex            bool          `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
 	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
+	AssetIndex           bool          `long:"assetindex" description:"Maintain an index of the current state of every asset which makes the getasset and listassets RPCs available"`
+	DropAssetIndex       bool          `long:"dropassetindex" description:"Deletes the asset index from the database on start up and then exits."`
+	AssetAllocIndex      bool          `long:"assetallocindex" description:"Maintain an index of the asset balances of every address which makes the getassetallocationbalance and listassetallocations RPCs available"`
+	DropAssetAllocIndex  bool          `long:"dropassetallocindex" description:"Deletes the asset allocation index from the database on start up and then exits."`
+	Prune                uint64        `long:"prune" description:"Prune old blocks once the stored blocks exceed this target size in MiB, which makes the node unable to serve old blocks to peers and incompatible with --txindex and --addrindex -- 0 to keep all blocks, otherwise at least 550"`
+	NEVMPruneDepth       int32         `long:"nevmprunedepth" description:"Prune the data of NEVM blocks connected to blocks buried by more than this number of blocks -- 0 to keep all NEVM block data"`
 	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
 	lookup               func(string) ([]net.IP, error)
 	oniondial            func(string, string, time.Duration) (net.Conn, error)
 	dial                 func(string, string, time.Duration) (net.Conn, error)
 	addCheckpoints       []chaincfg.Checkpoint
+	assumeValid          *chainhash.Hash
+	minimumChainWork     *big.Int
 	miningAddrs          []btcutil.Address
 	minRelayTxFee        btcutil.Amount
 	whitelists           []*net.IPNet
@@ -426,6 +445,8 @@ func loadConfig() (*config, []string, error) {
 		BlockPrioritySize:    mempool.DefaultBlockPrioritySize,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
+		UtxoCacheMaxSize:     defaultUtxoCacheMaxSizeMiB,
+		UtxoFlushInterval:    defaultUtxoFlushInterval,
 		Generate:             defaultGenerate,
 		TxIndex:              defaultTxIndex,
 		AddrIndex:            defaultAddrIndex,
@@ -471,7 +492,8 @@ func loadConfig() (*config, []string, error) {
 	// Load additional config from file.
 	var configFileError error
 	parser := newConfigParser(&cfg, &serviceOpts, flags.Default)
-	if !(preCfg.RegressionTest || preCfg.SimNet) || preCfg.ConfigFile !=
+	if !(preCfg.RegressionTest || preCfg.SimNet ||
+		preCfg.SysRegressionTest) || preCfg.ConfigFile !=
 		defaultConfigFile {
 
 		if _, err := os.Stat(preCfg.ConfigFile); os.IsNotExist(err) {
@@ -495,7 +517,8 @@ func loadConfig() (*config, []string, error) {
 	}
 
 	// Don't add peers from the config file when in regression test mode.
-	if preCfg.RegressionTest && len(cfg.AddPeers) > 0 {
+	if (preCfg.RegressionTest || preCfg.SysRegressionTest) &&
+		len(cfg.AddPeers) > 0 {
 		cfg.AddPeers = nil
 	}
 
@@ -546,9 +569,36 @@ func loadConfig() (*config, []string, error) {
 		activeNetParams = &simNetParams
 		cfg.DisableDNSSeed = true
 	}
+	if cfg.SysRegressionTest {
+		numNets++
+		activeNetParams = &sysRegressionNetParams
+	}
+	if cfg.NetParams != "" {
+		numNets++
+
+		// Load the custom network parameters and register them so
+		// addresses and keys for the network can be decoded.
+		fileParams, err := chaincfg.LoadParamsFile(
+			cleanAndExpandPath(cfg.NetParams))
+		if err == nil {
+			err = chaincfg.Register(fileParams.Params)
+		}
+		if err != nil {
+			str := "%s: Unable to load network parameters: %v"
+			err := fmt.Errorf(str, funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		activeNetParams = &params{
+			Params:  fileParams.Params,
+			rpcPort: fileParams.RPCPort,
+		}
+	}
 	if numNets > 1 {
-		str := "%s: The testnet, regtest, segnet, and simnet params " +
-			"can't be used together -- choose one of the four"
+		str := "%s: The testnet, regtest, simnet, sysregtest, and " +
+			"netparams params can't be used together -- choose one " +
+			"of the five"
 		err := fmt.Errorf(str, funcName)
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, usageMessage)
@@ -864,6 +914,68 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --assetindex and --dropassetindex do not mix.
+	if cfg.AssetIndex && cfg.DropAssetIndex {
+		err := fmt.Errorf("%s: the --assetindex and --dropassetindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --assetallocindex and --dropassetallocindex do not mix.
+	if cfg.AssetAllocIndex && cfg.DropAssetAllocIndex {
+		err := fmt.Errorf("%s: the --assetallocindex and "+
+			"--dropassetallocindex options may not be activated at "+
+			"the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The prune target must leave room for the most recent blocks which
+	// are always kept.
+	if cfg.Prune != 0 && cfg.Prune < minPruneTargetMiB {
+		str := "%s: The prune option may not be less than %d MiB " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, minPruneTargetMiB, cfg.Prune)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --prune does not mix with the indexes which serve transactions from
+	// the stored blocks.
+	if cfg.Prune != 0 && (cfg.TxIndex || cfg.AddrIndex) {
+		err := fmt.Errorf("%s: the --prune option may not be activated "+
+			"together with the --txindex or --addrindex options "+
+			"because they rely on all blocks being stored", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The utxo cache flush interval may not be negative.
+	if cfg.UtxoFlushInterval < 0 {
+		str := "%s: The utxoflushinterval option may not be less " +
+			"than 0 -- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.UtxoFlushInterval)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The NEVM prune depth may not be negative.
+	if cfg.NEVMPruneDepth < 0 {
+		str := "%s: The nevmprunedepth option may not be less than 0 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.NEVMPruneDepth)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Check mining addresses are valid and saved parsed versions.
 	cfg.miningAddrs = make([]btcutil.Address, 0, len(cfg.MiningAddrs))
 	for _, strAddr := range cfg.MiningAddrs {
@@ -962,6 +1074,61 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Parse the assumed valid block, which defaults to the one of the
+	// active network.
+	switch cfg.AssumeValid {
+	case "":
+		cfg.assumeValid = activeNetParams.AssumeValid
+	case "0":
+	default:
+		cfg.assumeValid, err = chainhash.NewHashFromStr(cfg.AssumeValid)
+		if err != nil {
+			str := "%s: Error parsing assumed valid block: %v"
+			err := fmt.Errorf(str, funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// Parse the minimum chain work, which defaults to the one of the active
+	// network.
+	switch cfg.MinimumChainWork {
+	case "":
+		cfg.minimumChainWork = activeNetParams.MinimumChainWork
+	case "0":
+	default:
+		work, ok := new(big.Int).SetString(cfg.MinimumChainWork, 16)
+		if !ok || work.Sign() < 0 {
+			str := "%s: Error parsing minimum chain work: %q is " +
+				"not a hex number"
+			err := fmt.Errorf(str, funcName, cfg.MinimumChainWork)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.minimumChainWork = work
+	}
+
+	// --loadsnapshot does not mix with the indexes which are built from all
+	// blocks since the blocks before the snapshot are not available yet.
+	if cfg.LoadSnapshot != "" {
+		if cfg.TxIndex || cfg.AddrIndex || cfg.AssetIndex ||
+			cfg.AssetAllocIndex || !cfg.NoCFilters {
+
+			err := fmt.Errorf("%s: the --loadsnapshot option may "+
+				"not be activated together with the --txindex, "+
+				"--addrindex, --assetindex or --assetallocindex "+
+				"options and requires the --nocfilters option "+
+				"because the indexes rely on all blocks being "+
+				"available", funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.LoadSnapshot = cleanAndExpandPath(cfg.LoadSnapshot)
+	}
+
 	// Tor stream isolation requires either proxy or onion proxy to be set.
 	if cfg.TorIsolation && cfg.Proxy == "" && cfg.OnionProxy == "" {
 		str := "%s: Tor stream isolation requires either proxy or " +
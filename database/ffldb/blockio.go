@@ -15,6 +15,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/vpubchain/btcd/chaincfg/chainhash"
@@ -168,6 +170,10 @@ type blockStore struct {
 	// new blocks are written to.
 	writeCursor *writeCursor
 
+	// firstFileNum is the number of the oldest block file which has not
+	// been pruned.  It is only accessed with the database write lock held.
+	firstFileNum uint32
+
 	// These functions are set to openFile, openWriteFile, and deleteFile by
 	// default, but are exposed here to allow the whitebox tests to replace
 	// them when working with mock files.
@@ -307,6 +313,51 @@ func (s *blockStore) deleteFile(fileNum uint32) error {
 	return nil
 }
 
+// fileSize returns the size of the flat file for the passed file number.
+func (s *blockStore) fileSize(fileNum uint32) (uint64, error) {
+	filePath := blockFilePath(s.basePath, fileNum)
+	st, err := os.Stat(filePath)
+	if err != nil {
+		return 0, makeDbErr(database.ErrDriverSpecific, err.Error(), err)
+	}
+
+	return uint64(st.Size()), nil
+}
+
+// pruneFiles closes and removes the flat files for the passed file numbers,
+// which must be the oldest files of the store in ascending order and must not
+// include the current write file.  Files which fail to be removed are only
+// logged since the blocks they contain are no longer referenced and they will
+// be removed again the next time blocks are pruned.
+//
+// This function MUST be called with the database write lock held.
+func (s *blockStore) pruneFiles(fileNums []uint32) {
+	for _, fileNum := range fileNums {
+		// Close the file when it is open under the write lock for the
+		// file in case any readers are currently reading from it so it's
+		// not closed out from under them.
+		s.obfMutex.Lock()
+		if blockFile, ok := s.openBlockFiles[fileNum]; ok {
+			s.lruMutex.Lock()
+			s.openBlocksLRU.Remove(s.fileNumToLRUElem[fileNum])
+			delete(s.fileNumToLRUElem, fileNum)
+			s.lruMutex.Unlock()
+
+			blockFile.Lock()
+			_ = blockFile.file.Close()
+			blockFile.Unlock()
+			delete(s.openBlockFiles, fileNum)
+		}
+		s.obfMutex.Unlock()
+
+		if err := s.deleteFileFunc(fileNum); err != nil {
+			log.Warnf("Failed to remove pruned block file %d: %v",
+				fileNum, err)
+		}
+		s.firstFileNum = fileNum + 1
+	}
+}
+
 // blockFile attempts to return an existing file handle for the passed flat file
 // number if it is already open as well as marking it as most recently used.  It
 // will also open the file when it's not already open subject to the rules
@@ -713,14 +764,30 @@ func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
 }
 
 // scanBlockFiles searches the database directory for all flat block files to
-// find the end of the most recent file.  This position is considered the
-// current write cursor which is also stored in the metadata.  Thus, it is used
-// to detect unexpected shutdowns in the middle of writes so the block files
-// can be reconciled.
-func scanBlockFiles(dbPath string) (int, uint32) {
+// find the oldest file and the end of the most recent file.  The oldest file is
+// not the first one when blocks have been pruned.  The end position is
+// considered the current write cursor which is also stored in the metadata.
+// Thus, it is used to detect unexpected shutdowns in the middle of writes so
+// the block files can be reconciled.
+func scanBlockFiles(dbPath string) (int, int, uint32) {
+	// Find the oldest block file.  The pattern is well formed, so there is
+	// no need to check for an error.
+	firstFile := -1
+	filePaths, _ := filepath.Glob(filepath.Join(dbPath, "*.fdb"))
+	for _, filePath := range filePaths {
+		name := strings.TrimSuffix(filepath.Base(filePath), ".fdb")
+		fileNum, err := strconv.ParseUint(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		if firstFile == -1 || int(fileNum) < firstFile {
+			firstFile = int(fileNum)
+		}
+	}
+
 	lastFile := -1
 	fileLen := uint32(0)
-	for i := 0; ; i++ {
+	for i := firstFile; i != -1; i++ {
 		filePath := blockFilePath(dbPath, uint32(i))
 		st, err := os.Stat(filePath)
 		if err != nil {
@@ -731,9 +798,9 @@ func scanBlockFiles(dbPath string) (int, uint32) {
 		fileLen = uint32(st.Size())
 	}
 
-	log.Tracef("Scan found latest block file #%d with length %d", lastFile,
-		fileLen)
-	return lastFile, fileLen
+	log.Tracef("Scan found block files #%d to #%d with latest length %d",
+		firstFile, lastFile, fileLen)
+	return firstFile, lastFile, fileLen
 }
 
 // newBlockStore returns a new block store with the current block file number
@@ -742,8 +809,9 @@ func newBlockStore(basePath string, network wire.BitcoinNet) *blockStore {
 	// Look for the end of the latest block to file to determine what the
 	// write cursor position is from the viewpoing of the block files on
 	// disk.
-	fileNum, fileOff := scanBlockFiles(basePath)
+	firstFileNum, fileNum, fileOff := scanBlockFiles(basePath)
 	if fileNum == -1 {
+		firstFileNum = 0
 		fileNum = 0
 		fileOff = 0
 	}
@@ -761,6 +829,7 @@ func newBlockStore(basePath string, network wire.BitcoinNet) *blockStore {
 			curFileNum: uint32(fileNum),
 			curOffset:  fileOff,
 		},
+		firstFileNum: uint32(firstFileNum),
 	}
 	store.openFileFunc = store.openFile
 	store.openWriteFileFunc = store.openWriteFile
@@ -966,6 +966,10 @@ type transaction struct {
 	pendingBlocks    map[chainhash.Hash]int
 	pendingBlockData []pendingBlock
 
+	// Block files that need to be removed on commit since all of the blocks
+	// they contain have been pruned.
+	pendingPrunedFiles []uint32
+
 	// Keys that need to be stored or deleted on commit.
 	pendingKeys   *treap.Mutable
 	pendingRemove *treap.Mutable
@@ -1583,6 +1587,122 @@ func (tx *transaction) FetchBlockRegions(regions []database.BlockRegion) ([][]by
 	return blockRegions, nil
 }
 
+// PruneBlocks removes the oldest blocks from the database until the total size
+// of the stored blocks no longer exceeds the provided target size in bytes or
+// no more blocks can be removed.  Blocks are removed a whole flat file at a
+// time, so the total size can end up below the target by up to the size of a
+// file.  The current write file, the files which contain any of the provided
+// retained blocks, and all files after them are never removed.  The hashes of
+// the removed blocks are returned.
+//
+// The blocks are removed from the block index immediately while the files
+// which contain them are removed when the transaction is committed.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// In addition, returns ErrDriverSpecific if any failures occur when determining
+// the size of the block files.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) PruneBlocks(targetSize uint64, retain []chainhash.Hash) ([]chainhash.Hash, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	// Ensure the transaction is writable.
+	if !tx.writable {
+		str := "prune blocks requires a writable database transaction"
+		return nil, makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	store := tx.db.store
+	wc := store.writeCursor
+	wc.RLock()
+	curFileNum := wc.curFileNum
+	curOffset := wc.curOffset
+	wc.RUnlock()
+
+	// Nothing to do when the block files can't possibly exceed the target
+	// size.  This avoids touching the file system in the common case.
+	firstFileNum := store.firstFileNum + uint32(len(tx.pendingPrunedFiles))
+	maxSize := uint64(curFileNum-firstFileNum)*uint64(store.maxBlockFileSize) +
+		uint64(curOffset)
+	if maxSize <= targetSize {
+		return nil, nil
+	}
+
+	// Determine the actual size of the block files.
+	totalSize := uint64(curOffset)
+	fileSizes := make([]uint64, 0, curFileNum-firstFileNum)
+	for fileNum := firstFileNum; fileNum < curFileNum; fileNum++ {
+		fileSize, err := store.fileSize(fileNum)
+		if err != nil {
+			return nil, err
+		}
+		fileSizes = append(fileSizes, fileSize)
+		totalSize += fileSize
+	}
+	if totalSize <= targetSize {
+		return nil, nil
+	}
+
+	// Files from the oldest one which contains a retained block onwards
+	// are kept.  Retained blocks which are pending to be written on commit
+	// end up in the current write file or later.
+	endFileNum := curFileNum
+	for i := range retain {
+		if _, exists := tx.pendingBlocks[retain[i]]; exists {
+			continue
+		}
+		blockRow := tx.blockIdxBucket.Get(retain[i][:])
+		if blockRow == nil {
+			continue
+		}
+		location := deserializeBlockLoc(blockRow)
+		if location.blockFileNum < endFileNum {
+			endFileNum = location.blockFileNum
+		}
+	}
+
+	// Remove the oldest files until the target size is reached.
+	pruneEnd := firstFileNum
+	for pruneEnd < endFileNum && totalSize > targetSize {
+		totalSize -= fileSizes[pruneEnd-firstFileNum]
+		tx.pendingPrunedFiles = append(tx.pendingPrunedFiles, pruneEnd)
+		pruneEnd++
+	}
+	if pruneEnd == firstFileNum {
+		return nil, nil
+	}
+
+	// Remove all blocks stored in the removed files from the block index.
+	var pruned []chainhash.Hash
+	err := tx.blockIdxBucket.ForEach(func(k, v []byte) error {
+		location := deserializeBlockLoc(v)
+		if location.blockFileNum < pruneEnd {
+			var hash chainhash.Hash
+			copy(hash[:], k)
+			pruned = append(pruned, hash)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pruned {
+		if err := tx.blockIdxBucket.Delete(pruned[i][:]); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Debugf("Pruned %d blocks in block files %d to %d", len(pruned),
+		firstFileNum, pruneEnd-1)
+	return pruned, nil
+}
+
 // close marks the transaction closed then releases any pending data, the
 // underlying snapshot, the transaction read lock, and the write lock when the
 // transaction is writable.
@@ -1593,6 +1713,9 @@ func (tx *transaction) close() {
 	tx.pendingBlocks = nil
 	tx.pendingBlockData = nil
 
+	// Clear pending block files that would have been removed on commit.
+	tx.pendingPrunedFiles = nil
+
 	// Clear pending keys that would have been written or deleted on commit.
 	tx.pendingKeys = nil
 	tx.pendingRemove = nil
@@ -1666,7 +1789,21 @@ func (tx *transaction) writePendingAndCommit() error {
 
 	// Atomically update the database cache.  The cache automatically
 	// handles flushing to the underlying persistent storage database.
-	return tx.db.cache.commitTx(tx)
+	if err := tx.db.cache.commitTx(tx); err != nil {
+		return err
+	}
+
+	// Remove the block files of pruned blocks.  The cache is flushed first
+	// so the persistent storage database never references blocks in files
+	// which no longer exist, even in unexpected shutdown scenarios.
+	if len(tx.pendingPrunedFiles) > 0 {
+		if err := tx.db.cache.flush(); err != nil {
+			return err
+		}
+		tx.db.store.pruneFiles(tx.pendingPrunedFiles)
+	}
+
+	return nil
 }
 
 // Commit commits all changes that have been made to the root metadata bucket
@@ -6,6 +6,7 @@ package ffldb_test
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/martinboehm/btcutil"
 	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
 	"github.com/vpubchain/btcd/database"
 	"github.com/vpubchain/btcd/database/ffldb"
 )
@@ -286,3 +288,124 @@ func TestInterface(t *testing.T) {
 		testInterface(t, db)
 	})
 }
+
+// TestPruneBlocks ensures pruning blocks removes the oldest block files while
+// keeping the files with retained blocks and that the pruned database can be
+// reopened.
+func TestPruneBlocks(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-prunetest")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	defer func() { db.Close() }()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+
+	// Store all of the blocks in small flat files and prune all but the
+	// files with the most recent blocks.
+	retained := blocks[200:]
+	var pruned []chainhash.Hash
+	ffldb.TstRunWithMaxBlockFileSize(db, 2048, func() {
+		err = db.Update(func(tx database.Tx) error {
+			for _, block := range blocks {
+				if err := tx.StoreBlock(block); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+
+		err = db.Update(func(tx database.Tx) error {
+			// Nothing is pruned when the target is not exceeded.
+			hashes, err := tx.PruneBlocks(math.MaxUint64, nil)
+			if err != nil {
+				return err
+			}
+			if len(hashes) != 0 {
+				return fmt.Errorf("PruneBlocks: unexpected pruned "+
+					"blocks %v", hashes)
+			}
+
+			retain := []chainhash.Hash{*retained[0].Hash()}
+			pruned, err = tx.PruneBlocks(0, retain)
+			return err
+		})
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+
+	// Ensure the genesis block and all blocks up to the first retained one
+	// are pruned along with their files.
+	if len(pruned) == 0 || len(pruned) > len(blocks)-len(retained) {
+		t.Errorf("PruneBlocks: unexpected number of pruned blocks %d",
+			len(pruned))
+		return
+	}
+	err = db.View(func(tx database.Tx) error {
+		exists, err := tx.HasBlock(blocks[0].Hash())
+		if err == nil && exists {
+			err = fmt.Errorf("genesis block was not pruned")
+		}
+		return err
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, "000000000.fdb")); !os.IsNotExist(err) {
+		t.Errorf("first block file was not removed: %v", err)
+	}
+
+	// checkBlocks ensures the pruned blocks no longer exist while all of
+	// the retained blocks can still be fetched.
+	checkBlocks := func() {
+		err := db.View(func(tx database.Tx) error {
+			for i := range pruned {
+				exists, err := tx.HasBlock(&pruned[i])
+				if err != nil {
+					return err
+				}
+				if exists {
+					return fmt.Errorf("pruned block %v exists",
+						pruned[i])
+				}
+			}
+			for _, block := range retained {
+				if _, err := tx.FetchBlock(block.Hash()); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	checkBlocks()
+
+	// Ensure the pruned database can be reopened and still contains the
+	// retained blocks.
+	db.Close()
+	db, err = database.Open(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to reopen test database (%s) %v", dbType, err)
+		return
+	}
+	checkBlocks()
+}
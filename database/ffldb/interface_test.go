@@ -1519,6 +1519,13 @@ func testBlockIOTxInterface(tc *testContext) bool {
 			}
 		}
 
+		// Ensure attempting to prune blocks with a read-only
+		// transaction fails with the expected error.
+		_, err := tx.PruneBlocks(0, nil)
+		if !checkDbError(tc.t, "PruneBlocks on ro tx", err, wantErrCode) {
+			return errSubTestFail
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -1923,6 +1930,13 @@ func testClosedTxInterface(tc *testContext, tx database.Tx) bool {
 		return false
 	}
 
+	// Ensure PruneBlocks returns expected error.
+	testName = "PruneBlocks on closed tx"
+	_, err = tx.PruneBlocks(0, nil)
+	if !checkDbError(tc.t, testName, err, wantErrCode) {
+		return false
+	}
+
 	// ---------------
 	// Commit/Rollback
 	// ---------------
@@ -389,6 +389,26 @@ type Tx interface {
 	// implementations.
 	FetchBlockRegions(regions []BlockRegion) ([][]byte, error)
 
+	// PruneBlocks removes the oldest blocks from the database until the
+	// total size of the stored blocks no longer exceeds the provided target
+	// size in bytes or no more blocks can be removed.  Blocks are removed
+	// in the order they were stored, possibly in batches depending on the
+	// backend implementation, and none of the provided retained blocks nor
+	// any block stored after them is removed.  The hashes of the removed
+	// blocks are returned.
+	//
+	// The removed blocks are no longer available to the transaction once
+	// this function returns, however, the backend implementation may defer
+	// freeing the storage they occupy until the transaction is committed.
+	//
+	// The interface contract guarantees at least the following errors will
+	// be returned (other implementation-specific errors are possible):
+	//   - ErrTxNotWritable if attempted against a read-only transaction
+	//   - ErrTxClosed if the transaction has already been closed
+	//
+	// Other errors are possible depending on the implementation.
+	PruneBlocks(targetSize uint64, retain []chainhash.Hash) ([]chainhash.Hash, error)
+
 	// ******************************************************************
 	// Methods related to both atomic metadata storage and block storage.
 	// ******************************************************************
@@ -76,12 +76,33 @@ Application Options:
       --testnet             Use the test network
       --regtest             Use the regression test network
       --simnet              Use the simulation test network
+      --sysregtest          Use the Syscoin regression test network
+      --netparams=          Use the custom network defined by the specified
+                            network parameters file
       --addcheckpoint=      Add a custom checkpoint.  Format: '<height>:<hash>'
       --nocheckpoints       Disable built-in checkpoints.  Don't do this unless
                             you know what you're doing.
+      --assumevalid=        Hash of a block whose ancestors are assumed to have
+                            valid scripts once it is buried in the best header
+                            chain -- 0 to validate all scripts (default: network
+                            specific)
+      --minimumchainwork=   Minimum cumulative proof of work in hex of a peer's
+                            chain of headers before they are stored -- 0 to
+                            store the headers of any chain right away (default:
+                            network specific)
+      --loadsnapshot=       Bootstrap a new database from the UTXO snapshot in
+                            this file, which must be pinned by the network
+                            parameters -- the blocks before it are validated in
+                            the background and it is incompatible with the
+                            optional indexes
       --uacomment=          Comment to add to the user agent --
                             See BIP 14 for more information.
       --dbtype=             Database backend to use for the Block Chain (ffldb)
+      --prune=              Prune old blocks once the stored blocks exceed this
+                            target size in MiB, which makes the node unable to
+                            serve old blocks to peers and incompatible with
+                            --txindex and --addrindex -- 0 to keep all blocks,
+                            otherwise at least 550 (0)
       --profile=            Enable HTTP profiling on given port -- NOTE port
                             must be between 1024 and 65536
       --cpuprofile=         Write CPU profile to the specified file
@@ -115,6 +136,15 @@ Application Options:
       --nocfilters          Disable committed filtering (CF) support.
       --sigcachemaxsize=    The maximum number of entries in the signature
                             verification cache.
+      --utxocachemaxsize=   The maximum size in MiB of the cache of unspent
+                            transaction outputs, which is written to the
+                            database once it is full -- 0 to write the changes
+                            of every block right away (250)
+      --utxoflushinterval=  How often to write the cache of unspent transaction
+                            outputs to the database when it is not full, which
+                            limits the blocks to reconnect after a crash.
+                            Valid time units are {s, m, h} -- 0 to only write
+                            it once full or on shutdown (5m)
       --blocksonly          Do not accept transactions from remote peers.
       --relaynonstd         Relay non-standard transactions regardless of the
                             default settings for the active network.
@@ -19,11 +19,12 @@ import (
 	"github.com/vpubchain/btcd/wire"
 )
 
-// solveBlock attempts to find a nonce which makes the passed block header hash
-// to a value less than the target difficulty. When a successful solution is
-// found true is returned and the nonce field of the passed header is updated
-// with the solution. False is returned if no solution exists.
-func solveBlock(header *wire.BlockHeader, targetDifficulty *big.Int) bool {
+// solveBlock attempts to find a nonce which makes the proof of work hash of the
+// passed block header selected by the passed chain parameters a value less
+// than the target difficulty. When a successful solution is found true is
+// returned and the nonce field of the passed header is updated with the
+// solution. False is returned if no solution exists.
+func solveBlock(header *wire.BlockHeader, targetDifficulty *big.Int, net *chaincfg.Params) bool {
 	// sbResult is used by the solver goroutines to send results.
 	type sbResult struct {
 		found bool
@@ -43,7 +44,7 @@ func solveBlock(header *wire.BlockHeader, targetDifficulty *big.Int) bool {
 				return
 			default:
 				hdr.Nonce = i
-				hash := hdr.BlockHash()
+				hash := blockchain.PowHash(&hdr, net)
 				if blockchain.HashToBig(&hash).Cmp(targetDifficulty) <= 0 {
 					select {
 					case results <- sbResult{true, i}:
@@ -196,7 +197,7 @@ func CreateBlock(prevBlock *btcutil.Block, inclusionTxs []*btcutil.Tx,
 		}
 	}
 
-	found := solveBlock(&block.Header, net.PowLimit)
+	found := solveBlock(&block.Header, net.PowLimit, net)
 	if !found {
 		return nil, errors.New("Unable to solve block")
 	}
@@ -798,6 +798,27 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 		return nil, nil, err
 	}
 
+	// Don't allow transactions moving assets which have a notary
+	// configured unless the notary signed off on them.
+	if mp.cfg.ChainParams.AssetsSupported {
+		err := blockchain.CheckNotarySigs(tx, utxoView)
+		if err != nil {
+			if cerr, ok := err.(blockchain.RuleError); ok {
+				return nil, nil, chainRuleError(cerr)
+			}
+			return nil, nil, err
+		}
+	}
+
+	// Don't allow transactions sending assets which have aux fees
+	// configured unless they pay the aux fee to its recipient.
+	if mp.cfg.ChainParams.AssetsSupported {
+		err := checkAuxFees(tx, utxoView)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Don't allow transactions with non-standard inputs if the network
 	// parameters forbid their acceptance.
 	if !mp.cfg.Policy.AcceptNonStd {
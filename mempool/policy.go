@@ -5,6 +5,7 @@
 package mempool
 
 import (
+	"bytes"
 	"fmt"
 	"time"
 
@@ -368,6 +369,76 @@ func checkTransactionStandard(tx *btcutil.Tx, height int32,
 	return nil
 }
 
+// isAuxFeeScript returns whether or not the passed public key script pays to
+// the aux fee recipient with the passed key ID, either as a pay-to-pubkey-hash
+// or a pay-to-witness-pubkey-hash script.
+func isAuxFeeScript(pkScript []byte, auxFeeKeyID []byte) bool {
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.PubKeyHashTy:
+		return bytes.Equal(pkScript[3:23], auxFeeKeyID)
+	case txscript.WitnessV0PubKeyHashTy:
+		return bytes.Equal(pkScript[2:22], auxFeeKeyID)
+	}
+	return false
+}
+
+// checkAuxFees ensures every asset sent by the passed transaction which has
+// aux fees configured is sent along with its aux fee.  The state of the assets
+// is looked up in the passed view, which must hold the assets the transaction
+// references.
+//
+// Only asset allocation sends are subject to aux fees.  The aux fee of an
+// asset is calculated with blockchain.CalcAuxFee from the value of the asset
+// assigned to outputs which don't pay to the aux fee recipient, and the value
+// of the asset assigned to outputs paying to the aux fee recipient must be at
+// least that fee.
+func checkAuxFees(tx *btcutil.Tx, utxoView *blockchain.UtxoViewpoint) error {
+	msgTx := tx.MsgTx()
+	if msgTx.Version != wire.SyscoinTxVersionAllocationSend {
+		return nil
+	}
+	allocation, err := msgTx.AssetAllocation()
+	if err != nil {
+		str := fmt.Sprintf("unable to decode the payload of "+
+			"transaction %v: %v", tx.Hash(), err)
+		return txRuleError(wire.RejectInvalid, str)
+	}
+
+	for _, voutAsset := range allocation.VoutAssets {
+		stored := utxoView.LookupAsset(voutAsset.AssetGuid)
+		if stored == nil {
+			continue
+		}
+		details := stored.AuxFeeDetails()
+		if len(details.AuxFees) == 0 {
+			continue
+		}
+
+		// Tally the value of the asset sent and paid as aux fee.
+		keyID := details.AuxFeeKeyID
+		var sent, paid int64
+		for _, value := range voutAsset.Values {
+			if value.N < uint32(len(msgTx.TxOut)) &&
+				isAuxFeeScript(msgTx.TxOut[value.N].PkScript, keyID) {
+
+				paid += value.ValueSat
+				continue
+			}
+			sent += value.ValueSat
+		}
+
+		auxFee := blockchain.CalcAuxFee(&details, sent)
+		if paid < auxFee {
+			str := fmt.Sprintf("transaction %v pays an aux fee of "+
+				"%d for sending %d of asset %d which is under "+
+				"the required aux fee of %d", tx.Hash(), paid,
+				sent, voutAsset.AssetGuid, auxFee)
+			return txRuleError(wire.RejectInsufficientFee, str)
+		}
+	}
+	return nil
+}
+
 // GetTxVirtualSize computes the virtual size of a given transaction. A
 // transaction's virtual size is based off its weight, creating a discount for
 // any witness data it contains, proportional to the current
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/blockchain"
 	"github.com/vpubchain/btcd/btcec"
 	"github.com/vpubchain/btcd/chaincfg"
 	"github.com/vpubchain/btcd/chaincfg/chainhash"
@@ -510,3 +511,165 @@ func TestCheckTransactionStandard(t *testing.T) {
 		}
 	}
 }
+
+// TestCheckAuxFees ensures asset allocation sends are only accepted when they
+// pay the aux fee of the assets they send to the aux fee recipient.
+func TestCheckAuxFees(t *testing.T) {
+	const guid, freeGuid = 1234, 5678
+	keyID := bytes.Repeat([]byte{0x01}, 20)
+	otherKeyID := bytes.Repeat([]byte{0x02}, 20)
+
+	p2pkh := func(keyID []byte) []byte {
+		script, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+			AddData(keyID).AddOp(txscript.OP_EQUALVERIFY).
+			AddOp(txscript.OP_CHECKSIG).Script()
+		if err != nil {
+			t.Fatalf("Script: unexpected error: %v", err)
+		}
+		return script
+	}
+	p2wpkh := func(keyID []byte) []byte {
+		script, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_0).AddData(keyID).Script()
+		if err != nil {
+			t.Fatalf("Script: unexpected error: %v", err)
+		}
+		return script
+	}
+
+	// sendTx returns a transaction of the passed version sending 10000 of
+	// the passed asset to its first output and the passed aux fee to its
+	// second output paying to the passed script.
+	sendTx := func(version int32, g uint64, feeScript []byte, fee int64) *btcutil.Tx {
+		tx := wire.NewMsgTx(version)
+		prevOut := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 0}
+		tx.AddTxIn(wire.NewTxIn(&prevOut, nil, nil))
+		tx.AddTxOut(wire.NewTxOut(500, p2pkh(otherKeyID)))
+		tx.AddTxOut(wire.NewTxOut(500, feeScript))
+		allocation := wire.AssetAllocationType{
+			VoutAssets: []wire.AssetOutType{{
+				AssetGuid: g,
+				Values: []wire.AssetOutValueType{
+					{N: 0, ValueSat: 10000},
+					{N: 1, ValueSat: fee},
+				},
+			}},
+		}
+		var buf bytes.Buffer
+		if err := allocation.Serialize(&buf); err != nil {
+			t.Fatalf("Serialize: unexpected error: %v", err)
+		}
+		script, err := txscript.NullDataScript(buf.Bytes())
+		if err != nil {
+			t.Fatalf("NullDataScript: unexpected error: %v", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(0, script))
+		return btcutil.NewTx(tx)
+	}
+
+	// The view holds an asset charging 1% for guid and an asset without
+	// aux fees for freeGuid as activated by the passed aux fee details.
+	view := blockchain.NewUtxoViewpoint()
+	activate := func(g uint64, details wire.AuxFeeDetailsType) {
+		asset := wire.AssetType{
+			Allocation: wire.AssetAllocationType{
+				VoutAssets: []wire.AssetOutType{{AssetGuid: g}},
+			},
+			UpdateFlags:   wire.ASSET_INIT | wire.ASSET_UPDATE_AUXFEE,
+			MaxSupply:     1000000,
+			AuxFeeDetails: details,
+		}
+		var buf bytes.Buffer
+		if err := asset.Serialize(&buf); err != nil {
+			t.Fatalf("Serialize: unexpected error: %v", err)
+		}
+		script, err := txscript.NullDataScript(buf.Bytes())
+		if err != nil {
+			t.Fatalf("NullDataScript: unexpected error: %v", err)
+		}
+		tx := wire.NewMsgTx(wire.SyscoinTxVersionAssetActivate)
+		tx.AddTxOut(wire.NewTxOut(0, script))
+		view.UpdateAssets(btcutil.NewTx(tx))
+	}
+	activate(guid, wire.AuxFeeDetailsType{
+		AuxFeeKeyID: keyID,
+		AuxFees:     []wire.AuxFeesType{{Bound: 0, Percent: 1000}},
+	})
+	activate(freeGuid, wire.AuxFeeDetailsType{})
+
+	send := wire.SyscoinTxVersionAllocationSend
+	tests := []struct {
+		name    string
+		tx      *btcutil.Tx
+		isValid bool
+	}{
+		{
+			name:    "aux fee paid to p2pkh",
+			tx:      sendTx(send, guid, p2pkh(keyID), 100),
+			isValid: true,
+		},
+		{
+			name:    "aux fee paid to p2wpkh",
+			tx:      sendTx(send, guid, p2wpkh(keyID), 100),
+			isValid: true,
+		},
+		{
+			name:    "aux fee too low",
+			tx:      sendTx(send, guid, p2pkh(keyID), 99),
+			isValid: false,
+		},
+		{
+			name:    "aux fee paid to other recipient",
+			tx:      sendTx(send, guid, p2pkh(otherKeyID), 100),
+			isValid: false,
+		},
+		{
+			name:    "asset without aux fees",
+			tx:      sendTx(send, freeGuid, p2pkh(otherKeyID), 0),
+			isValid: true,
+		},
+		{
+			name:    "unknown asset",
+			tx:      sendTx(send, guid+1, p2pkh(otherKeyID), 0),
+			isValid: true,
+		},
+		{
+			name: "burn to ethereum",
+			tx: sendTx(wire.SyscoinTxVersionAllocationBurnToEthereum,
+				guid, p2pkh(otherKeyID), 0),
+			isValid: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := checkAuxFees(test.tx, view)
+		if err == nil && test.isValid {
+			continue
+		}
+		if err == nil {
+			t.Errorf("checkAuxFees (%s): did not get expected error",
+				test.name)
+			continue
+		}
+		if test.isValid {
+			t.Errorf("checkAuxFees (%s): unexpected error: %v",
+				test.name, err)
+			continue
+		}
+
+		// Ensure error type is a TxRuleError inside of a RuleError.
+		rerr, ok := err.(RuleError)
+		if !ok {
+			t.Errorf("checkAuxFees (%s): unexpected error type - got "+
+				"%T", test.name, err)
+			continue
+		}
+		txrerr, ok := rerr.Err.(TxRuleError)
+		if !ok || txrerr.RejectCode != wire.RejectInsufficientFee {
+			t.Errorf("checkAuxFees (%s): unexpected error - got %v, "+
+				"want reject code %v", test.name, rerr.Err,
+				wire.RejectInsufficientFee)
+		}
+	}
+}
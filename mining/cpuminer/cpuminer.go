@@ -220,6 +220,13 @@ func (m *CPUMiner) solveBlock(msgBlock *wire.MsgBlock, blockHeight int32,
 	header := &msgBlock.Header
 	targetDifficulty := blockchain.CompactToBig(header.Bits)
 
+	// The double sha256 proof of work hash involves two hashes, so count
+	// two hashes for each attempt in that case.
+	hashesPerAttempt := uint64(1)
+	if m.cfg.ChainParams.PowHashAlgorithm == chaincfg.PowHashSHA256d {
+		hashesPerAttempt = 2
+	}
+
 	// Initial state.
 	lastGenerated := time.Now()
 	lastTxUpdate := m.g.TxSource().LastUpdated()
@@ -269,16 +276,15 @@ func (m *CPUMiner) solveBlock(msgBlock *wire.MsgBlock, blockHeight int32,
 				// Non-blocking select to fall through
 			}
 
-			// Update the nonce and hash the block header.  Each
-			// hash is actually a double sha256 (two hashes), so
-			// increment the number of hashes completed for each
-			// attempt accordingly.
+			// Update the nonce and calculate the proof of work
+			// hash of the block header with the hash function of
+			// the chain.
 			header.Nonce = i
-			hash := header.BlockHash()
-			hashesCompleted += 2
+			hash := blockchain.PowHash(header, m.cfg.ChainParams)
+			hashesCompleted += hashesPerAttempt
 
-			// The block is solved when the new block hash is less
-			// than the target difficulty.  Yay!
+			// The block is solved when the new proof of work hash
+			// is less than the target difficulty.  Yay!
 			if blockchain.HashToBig(&hash).Cmp(targetDifficulty) <= 0 {
 				m.updateHashes <- hashesCompleted
 				return true
@@ -222,7 +222,9 @@ type BlockTemplate struct {
 // mergeUtxoView adds all of the entries in viewB to viewA.  The result is that
 // viewA will contain all of its original entries plus all of the entries
 // in viewB.  It will replace any entries in viewB which also exist in viewA
-// if the entry in viewA is spent.
+// if the entry in viewA is spent.  The state of the assets and mints in viewB
+// is only added for those which are not already in viewA since the state in
+// viewA includes the changes made by the transactions already spent into it.
 func mergeUtxoView(viewA *blockchain.UtxoViewpoint, viewB *blockchain.UtxoViewpoint) {
 	viewAEntries := viewA.Entries()
 	for outpoint, entryB := range viewB.Entries() {
@@ -232,6 +234,19 @@ func mergeUtxoView(viewA *blockchain.UtxoViewpoint, viewB *blockchain.UtxoViewpo
 			viewAEntries[outpoint] = entryB
 		}
 	}
+
+	viewAAssets := viewA.Assets()
+	for guid, assetB := range viewB.Assets() {
+		if _, exists := viewAAssets[guid]; !exists {
+			viewAAssets[guid] = assetB
+		}
+	}
+	viewAMints := viewA.Mints()
+	for ethTxHash, guidB := range viewB.Mints() {
+		if _, exists := viewAMints[ethTxHash]; !exists {
+			viewAMints[ethTxHash] = guidB
+		}
+	}
 }
 
 // standardCoinbaseScript returns a standard script suitable for use as the
@@ -288,8 +303,10 @@ func createCoinbaseTx(params *chaincfg.Params, coinbaseScript []byte, nextBlockH
 
 // spendTransaction updates the passed view by marking the inputs to the passed
 // transaction as spent.  It also adds all outputs in the passed transaction
-// which are not provably unspendable as available unspent transaction outputs.
+// which are not provably unspendable as available unspent transaction outputs
+// and updates the state of the asset the transaction changes.
 func spendTransaction(utxoView *blockchain.UtxoViewpoint, tx *btcutil.Tx, height int32) error {
+	utxoView.UpdateAssets(tx)
 	for _, txIn := range tx.MsgTx().TxIn {
 		entry := utxoView.LookupEntry(txIn.PreviousOutPoint)
 		if entry != nil {
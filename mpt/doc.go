@@ -0,0 +1,28 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package mpt implements verification of Ethereum Merkle-Patricia trie inclusion
+proofs.
+
+Syscoin mint transactions move assets from the Ethereum network by carrying
+proofs that an Ethereum transaction and its receipt are included in the
+transaction and receipt tries of an Ethereum block.  This package verifies such
+proofs so they can be checked both by the block chain validation rules and via
+RPC.
+
+A proof is the RLP encoded list of the trie nodes on the path from the root to
+the value of a key.  Every node is referenced by its parent, or by the root for
+the first node, with the keccak256 hash of its encoding unless the encoding is
+shorter than a hash, in which case the node is embedded in its parent.  The
+path through the trie is given by the nibbles of the key while extension and
+leaf nodes hold hex-prefix encoded partial paths.
+
+Errors
+
+Errors returned by this package are of type mpt.ProofError.  This allows the
+caller to differentiate between the kinds of invalid proofs by examining the
+ErrorCode field.
+*/
+package mpt
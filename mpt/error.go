@@ -0,0 +1,74 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mpt
+
+import (
+	"fmt"
+)
+
+// ErrorCode identifies a kind of error encountered while verifying a proof.
+type ErrorCode int
+
+// These constants are used to identify a specific ProofError.
+const (
+	// ErrMalformedProof indicates the proof or one of its nodes is not
+	// valid RLP or a node is neither a branch, extension nor leaf node.
+	ErrMalformedProof ErrorCode = iota
+
+	// ErrInvalidRoot indicates the supplied root is not a keccak256 hash.
+	ErrInvalidRoot
+
+	// ErrNodeHashMismatch indicates a proof node does not hash to the
+	// reference held by its parent or, for the first node, to the root.
+	ErrNodeHashMismatch
+
+	// ErrKeyNotFound indicates the path of the key diverges from the
+	// proof or the proof ends before reaching the value of the key.
+	ErrKeyNotFound
+
+	// ErrTrailingNodes indicates the proof contains nodes after the node
+	// holding the value of the key.
+	ErrTrailingNodes
+
+	// ErrValueMismatch indicates the proven value does not match the value
+	// expected by the caller.
+	ErrValueMismatch
+)
+
+// Map of ErrorCode values back to their constant names for pretty printing.
+var errorCodeStrings = map[ErrorCode]string{
+	ErrMalformedProof:   "ErrMalformedProof",
+	ErrInvalidRoot:      "ErrInvalidRoot",
+	ErrNodeHashMismatch: "ErrNodeHashMismatch",
+	ErrKeyNotFound:      "ErrKeyNotFound",
+	ErrTrailingNodes:    "ErrTrailingNodes",
+	ErrValueMismatch:    "ErrValueMismatch",
+}
+
+// String returns the ErrorCode as a human-readable name.
+func (e ErrorCode) String() string {
+	if s := errorCodeStrings[e]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown ErrorCode (%d)", int(e))
+}
+
+// ProofError describes an issue with a Merkle-Patricia trie proof.  The caller
+// can use type assertions to determine the specific error by examining the
+// ErrorCode field.
+type ProofError struct {
+	ErrorCode   ErrorCode // Describes the kind of error
+	Description string    // Human readable description of the issue
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e ProofError) Error() string {
+	return e.Description
+}
+
+// proofError creates a ProofError given a set of arguments.
+func proofError(c ErrorCode, desc string) ProofError {
+	return ProofError{ErrorCode: c, Description: desc}
+}
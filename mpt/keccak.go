@@ -0,0 +1,123 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mpt
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	// HashSize is the size in bytes of a keccak256 hash and thus of every
+	// trie root and node reference.
+	HashSize = 32
+
+	// keccakRate is the number of bytes absorbed per permutation by the
+	// keccak256 sponge.
+	keccakRate = 136
+)
+
+// keccakRoundConstants are the constants xored into the first lane by the
+// iota step of each of the 24 rounds of keccak-f[1600].
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a,
+	0x8000000080008000, 0x000000000000808b, 0x0000000080000001,
+	0x8000000080008081, 0x8000000000008009, 0x000000000000008a,
+	0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089,
+	0x8000000000008003, 0x8000000000008002, 0x8000000000000080,
+	0x000000000000800a, 0x800000008000000a, 0x8000000080008081,
+	0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRotations are the rotation offsets of the rho step indexed by lane
+// x+5y.
+var keccakRotations = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// keccakF1600 applies the keccak-f[1600] permutation to the passed state.
+func keccakF1600(a *[25]uint64) {
+	var b [25]uint64
+	var c, d [5]uint64
+	for round := 0; round < 24; round++ {
+		// Theta.
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d[x] = c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+		}
+		for i := 0; i < 25; i++ {
+			a[i] ^= d[i%5]
+		}
+
+		// Rho and pi.
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y],
+					keccakRotations[x+5*y])
+			}
+		}
+
+		// Chi.
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				a[y+x] = b[y+x] ^ (^b[y+(x+1)%5] & b[y+(x+2)%5])
+			}
+		}
+
+		// Iota.
+		a[0] ^= keccakRoundConstants[round]
+	}
+}
+
+// keccakAbsorb xors a full block of keccakRate bytes into the passed state
+// and permutes it.
+func keccakAbsorb(a *[25]uint64, block []byte) {
+	for i := 0; i < keccakRate/8; i++ {
+		a[i] ^= binary.LittleEndian.Uint64(block[i*8:])
+	}
+	keccakF1600(a)
+}
+
+// Keccak256 returns the keccak256 hash of the concatenation of the passed
+// byte slices as used throughout Ethereum.  Note this is the original keccak
+// padding rule and therefore differs from the standardized SHA3-256.
+func Keccak256(data ...[]byte) []byte {
+	var state [25]uint64
+	var block [keccakRate]byte
+	var buffered int
+	for _, d := range data {
+		for len(d) > 0 {
+			n := copy(block[buffered:], d)
+			buffered += n
+			d = d[n:]
+			if buffered == keccakRate {
+				keccakAbsorb(&state, block[:])
+				buffered = 0
+			}
+		}
+	}
+
+	// Pad the final block with the keccak domain byte followed by zeros
+	// and a final set bit.
+	for i := buffered; i < keccakRate; i++ {
+		block[i] = 0
+	}
+	block[buffered] ^= 0x01
+	block[keccakRate-1] ^= 0x80
+	keccakAbsorb(&state, block[:])
+
+	hash := make([]byte, HashSize)
+	for i := 0; i < HashSize/8; i++ {
+		binary.LittleEndian.PutUint64(hash[i*8:], state[i])
+	}
+	return hash
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mpt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestKeccak256 ensures the keccak256 hash function produces the expected
+// digests including across the block boundaries of the sponge.
+func TestKeccak256(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			name: "empty",
+			data: nil,
+			want: "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+		},
+		{
+			name: "abc",
+			data: []byte("abc"),
+			want: "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45",
+		},
+		{
+			// The root of an empty Ethereum trie is the hash of the
+			// RLP encoding of an empty string.
+			name: "empty trie root",
+			data: []byte{0x80},
+			want: "56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+		},
+		{
+			// The hash of the RLP encoding of an empty list is the
+			// uncle hash of blocks without uncles.
+			name: "empty uncles hash",
+			data: []byte{0xc0},
+			want: "1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		},
+	}
+
+	for _, test := range tests {
+		got := hex.EncodeToString(Keccak256(test.data))
+		if got != test.want {
+			t.Errorf("%s: unexpected hash - got %s, want %s",
+				test.name, got, test.want)
+		}
+	}
+
+	// Ensure hashing data split into several slices and data spanning
+	// multiple blocks of the sponge is consistent.
+	for _, n := range []int{keccakRate - 1, keccakRate, keccakRate + 1,
+		3 * keccakRate} {
+
+		data := bytes.Repeat([]byte{0xab}, n)
+		whole := Keccak256(data)
+		split := Keccak256(data[:n/3], data[n/3:2*n/3], data[2*n/3:])
+		if !bytes.Equal(whole, split) {
+			t.Errorf("%d bytes: split hash %x does not match %x", n,
+				split, whole)
+		}
+	}
+}
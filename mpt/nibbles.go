@@ -0,0 +1,48 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mpt
+
+// KeyToNibbles returns the path through a trie described by the passed key.
+// Every byte of the key is split into two nibbles, high nibble first.
+func KeyToNibbles(key []byte) []byte {
+	nibbles := make([]byte, len(key)*2)
+	for i, b := range key {
+		nibbles[i*2] = b >> 4
+		nibbles[i*2+1] = b & 0x0f
+	}
+	return nibbles
+}
+
+// DecodeHexPrefix decodes the hex-prefix encoded partial path held by the
+// first item of an extension or leaf node.  It returns the nibbles of the
+// partial path and whether the node is a leaf.
+//
+// The high nibble of the first byte is a flag where bit 1 marks a leaf and bit
+// 0 marks an odd number of nibbles, in which case the low nibble of the first
+// byte is the first nibble of the path.  Otherwise the low nibble must be zero.
+func DecodeHexPrefix(encoded []byte) ([]byte, bool, error) {
+	if len(encoded) == 0 {
+		str := "empty hex-prefix encoded path"
+		return nil, false, proofError(ErrMalformedProof, str)
+	}
+
+	flag := encoded[0] >> 4
+	if flag > 3 {
+		str := "invalid hex-prefix flag"
+		return nil, false, proofError(ErrMalformedProof, str)
+	}
+	isLeaf := flag&0x02 != 0
+	isOdd := flag&0x01 != 0
+
+	nibbles := KeyToNibbles(encoded)
+	if isOdd {
+		return nibbles[1:], isLeaf, nil
+	}
+	if nibbles[1] != 0 {
+		str := "non-zero padding nibble in even hex-prefix path"
+		return nil, false, proofError(ErrMalformedProof, str)
+	}
+	return nibbles[2:], isLeaf, nil
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mpt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/vpubchain/btcd/rlp"
+	"github.com/vpubchain/btcd/wire"
+)
+
+const (
+	// branchNodeItems is the number of items in a branch node: one child
+	// reference per nibble followed by the value.
+	branchNodeItems = 17
+
+	// shortNodeItems is the number of items in an extension or leaf node:
+	// the hex-prefix encoded partial path followed by the child reference
+	// or value.
+	shortNodeItems = 2
+)
+
+// splitList decodes the passed encoding of an RLP list and returns the
+// encodings of its elements.  The returned encodings reference the passed
+// buffer.
+func splitList(encoded []byte) ([][]byte, error) {
+	kind, content, rest, err := rlp.Split(encoded)
+	if err != nil {
+		return nil, proofError(ErrMalformedProof, err.Error())
+	}
+	if kind != rlp.List {
+		str := "RLP item is a string where a list was expected"
+		return nil, proofError(ErrMalformedProof, str)
+	}
+	if len(rest) != 0 {
+		str := fmt.Sprintf("RLP list is followed by %d bytes", len(rest))
+		return nil, proofError(ErrMalformedProof, str)
+	}
+
+	var elems [][]byte
+	for len(content) > 0 {
+		_, _, rest, err := rlp.Split(content)
+		if err != nil {
+			return nil, proofError(ErrMalformedProof, err.Error())
+		}
+		elems = append(elems, content[:len(content)-len(rest)])
+		content = rest
+	}
+	return elems, nil
+}
+
+// splitString decodes the passed encoding of an RLP item and returns whether it
+// is a list along with its content.
+func splitString(encoded []byte) (bool, []byte) {
+	// The encoding was already split from its enclosing list, so it is
+	// known to be valid.
+	kind, content, _, _ := rlp.Split(encoded)
+	return kind == rlp.List, content
+}
+
+// verifyProof verifies the passed proof shows the key is present in the trie
+// with the passed root.  It returns the content of the value stored under the
+// key, which references the proof so the caller can inspect where it is
+// located.
+func verifyProof(root, key, proof []byte) ([]byte, error) {
+	if len(root) != HashSize {
+		str := fmt.Sprintf("root is %d bytes instead of %d", len(root),
+			HashSize)
+		return nil, proofError(ErrInvalidRoot, str)
+	}
+
+	// The proof is an RLP list of the nodes on the path to the value.
+	nodes, err := splitList(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk the path of the key starting from the root.  Every node must
+	// hash to the reference held by its parent unless it is small enough
+	// to be embedded in its parent, in which case it is not part of the
+	// proof list.
+	path := KeyToNibbles(key)
+	wantHash := root
+	var embedded []byte
+	var nextNode int
+	for {
+		var node []byte
+		if embedded != nil {
+			node, embedded = embedded, nil
+		} else {
+			if nextNode >= len(nodes) {
+				str := "proof ends before reaching the value"
+				return nil, proofError(ErrKeyNotFound, str)
+			}
+			node = nodes[nextNode]
+			nextNode++
+
+			if !bytes.Equal(Keccak256(node), wantHash) {
+				str := fmt.Sprintf("proof node %d does not "+
+					"match its reference %x", nextNode-1,
+					wantHash)
+				return nil, proofError(ErrNodeHashMismatch, str)
+			}
+		}
+
+		items, err := splitList(node)
+		if err != nil {
+			return nil, err
+		}
+
+		var ref []byte
+		switch len(items) {
+		case branchNodeItems:
+			// The value is held by the branch itself once the
+			// entire path has been consumed.
+			if len(path) == 0 {
+				isList, value := splitString(items[branchNodeItems-1])
+				if isList || len(value) == 0 {
+					str := "branch node holds no value for key"
+					return nil, proofError(ErrKeyNotFound,
+						str)
+				}
+				if nextNode != len(nodes) {
+					str := fmt.Sprintf("proof has %d nodes "+
+						"after the value",
+						len(nodes)-nextNode)
+					return nil, proofError(ErrTrailingNodes,
+						str)
+				}
+				return value, nil
+			}
+			ref = items[path[0]]
+			path = path[1:]
+
+		case shortNodeItems:
+			isList, encodedPath := splitString(items[0])
+			if isList {
+				str := "node path is a list"
+				return nil, proofError(ErrMalformedProof, str)
+			}
+			partial, isLeaf, err := DecodeHexPrefix(encodedPath)
+			if err != nil {
+				return nil, err
+			}
+			if len(partial) > len(path) ||
+				!bytes.Equal(path[:len(partial)], partial) {
+
+				str := "key path diverges from the proof"
+				return nil, proofError(ErrKeyNotFound, str)
+			}
+			path = path[len(partial):]
+
+			if isLeaf {
+				if len(path) != 0 {
+					str := "proof ends in a leaf for a " +
+						"longer key"
+					return nil, proofError(ErrKeyNotFound,
+						str)
+				}
+				if nextNode != len(nodes) {
+					str := fmt.Sprintf("proof has %d nodes "+
+						"after the value",
+						len(nodes)-nextNode)
+					return nil, proofError(ErrTrailingNodes,
+						str)
+				}
+				_, value := splitString(items[1])
+				return value, nil
+			}
+			ref = items[1]
+
+		default:
+			str := fmt.Sprintf("node has %d items", len(items))
+			return nil, proofError(ErrMalformedProof, str)
+		}
+
+		// Follow the reference to the next node on the path.
+		isList, refContent := splitString(ref)
+		switch {
+		case isList:
+			embedded = ref
+
+		case len(refContent) == HashSize:
+			wantHash = refContent
+
+		case len(refContent) == 0:
+			str := "key path leads to an empty branch slot"
+			return nil, proofError(ErrKeyNotFound, str)
+
+		default:
+			str := fmt.Sprintf("node reference is %d bytes",
+				len(refContent))
+			return nil, proofError(ErrMalformedProof, str)
+		}
+	}
+}
+
+// VerifyProof verifies the passed proof shows the key is present in the
+// Merkle-Patricia trie with the passed root and returns the value stored under
+// the key.
+//
+// The proof must be an RLP list of the trie nodes on the path from the root to
+// the value, in order, as returned by the eth_getProof family of APIs.  Nodes
+// whose encoding is shorter than a hash are embedded in their parent and must
+// not be listed separately.
+func VerifyProof(root, key, proof []byte) ([]byte, error) {
+	return verifyProof(root, key, proof)
+}
+
+// verifyMintValue verifies a single proof of a mint and ensures the proven
+// value starts at the passed position within the proof.
+func verifyMintValue(desc string, root, key, proof []byte, pos uint16) ([]byte, error) {
+	value, err := verifyProof(root, key, proof)
+	if err != nil {
+		return nil, proofError(err.(ProofError).ErrorCode,
+			fmt.Sprintf("%s proof: %v", desc, err))
+	}
+
+	// The value references the proof, so its position is the difference
+	// of their capacities.
+	offset := cap(proof) - cap(value)
+	if offset != int(pos) {
+		str := fmt.Sprintf("%s proof holds the value at position %d "+
+			"instead of %d", desc, offset, pos)
+		return nil, proofError(ErrValueMismatch, str)
+	}
+	return value, nil
+}
+
+// VerifyMintProof verifies the Ethereum transaction and receipt inclusion
+// proofs carried by the passed mint against its transaction and receipt roots.
+// Both are proven under the key given by TxPath, and the proven values must
+// start at TxPos and ReceiptPos within their respective proofs.  It returns the
+// proven transaction and receipt.
+func VerifyMintProof(mint *wire.MintSyscoinType) ([]byte, []byte, error) {
+	tx, err := verifyMintValue("transaction", mint.TxRoot, mint.TxPath,
+		mint.TxParentNodes, mint.TxPos)
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt, err := verifyMintValue("receipt", mint.ReceiptRoot,
+		mint.TxPath, mint.ReceiptParentNodes, mint.ReceiptPos)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, receipt, nil
+}
@@ -0,0 +1,399 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mpt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/vpubchain/btcd/wire"
+)
+
+// testRLPHeader returns the RLP header for an item of the passed length using
+// the passed base of 0x80 for strings or 0xc0 for lists.
+func testRLPHeader(base byte, n int) []byte {
+	if n < 56 {
+		return []byte{base + byte(n)}
+	}
+	var lenBytes []byte
+	for ; n > 0; n >>= 8 {
+		lenBytes = append([]byte{byte(n)}, lenBytes...)
+	}
+	return append([]byte{base + 55 + byte(len(lenBytes))}, lenBytes...)
+}
+
+// testRLPString returns the RLP encoding of the passed byte string.
+func testRLPString(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return b
+	}
+	return append(testRLPHeader(0x80, len(b)), b...)
+}
+
+// testRLPList returns the RLP encoding of a list of the passed already encoded
+// items.
+func testRLPList(items ...[]byte) []byte {
+	content := bytes.Join(items, nil)
+	return append(testRLPHeader(0xc0, len(content)), content...)
+}
+
+// testHexPrefix returns the hex-prefix encoding of the passed nibbles.
+func testHexPrefix(nibbles []byte, isLeaf bool) []byte {
+	var flag byte
+	if isLeaf {
+		flag = 2
+	}
+	if len(nibbles)%2 == 1 {
+		flag |= 1
+		nibbles = append([]byte{flag}, nibbles...)
+	} else {
+		nibbles = append([]byte{flag, 0}, nibbles...)
+	}
+	encoded := make([]byte, len(nibbles)/2)
+	for i := range encoded {
+		encoded[i] = nibbles[i*2]<<4 | nibbles[i*2+1]
+	}
+	return encoded
+}
+
+// testNode is a node of a trie built by the tests.
+type testNode struct {
+	enc      []byte
+	partial  []byte
+	isLeaf   bool
+	child    *testNode
+	children [16]*testNode
+}
+
+// ref returns the encoding of a reference to the node as held by its parent.
+func (n *testNode) ref() []byte {
+	if len(n.enc) < HashSize {
+		return n.enc
+	}
+	return testRLPString(Keccak256(n.enc))
+}
+
+// testKeyValue is a key, as nibbles, and its value stored in a test trie.
+type testKeyValue struct {
+	path  []byte
+	value []byte
+}
+
+// buildTestTrie builds the trie node holding the passed pairs, all of which
+// share the first depth nibbles of their path.
+func buildTestTrie(pairs []testKeyValue, depth int) *testNode {
+	if len(pairs) == 1 && len(pairs[0].path) > depth {
+		partial := pairs[0].path[depth:]
+		return &testNode{
+			enc: testRLPList(testRLPString(testHexPrefix(partial, true)),
+				testRLPString(pairs[0].value)),
+			partial: partial,
+			isLeaf:  true,
+		}
+	}
+
+	// Find the length of the path shared by all pairs beyond the depth.
+	common := len(pairs[0].path) - depth
+	for _, pair := range pairs[1:] {
+		n := 0
+		for n < common && depth+n < len(pair.path) &&
+			pair.path[depth+n] == pairs[0].path[depth+n] {
+			n++
+		}
+		common = n
+	}
+	if common > 0 {
+		partial := pairs[0].path[depth : depth+common]
+		child := buildTestTrie(pairs, depth+common)
+		return &testNode{
+			enc: testRLPList(testRLPString(testHexPrefix(partial,
+				false)), child.ref()),
+			partial: partial,
+			child:   child,
+		}
+	}
+
+	node := &testNode{}
+	items := make([][]byte, branchNodeItems)
+	items[branchNodeItems-1] = testRLPString(nil)
+	for nibble := byte(0); nibble < 16; nibble++ {
+		var group []testKeyValue
+		for _, pair := range pairs {
+			if len(pair.path) == depth {
+				items[branchNodeItems-1] = testRLPString(pair.value)
+				continue
+			}
+			if pair.path[depth] == nibble {
+				group = append(group, pair)
+			}
+		}
+		items[nibble] = testRLPString(nil)
+		if len(group) > 0 {
+			node.children[nibble] = buildTestTrie(group, depth+1)
+			items[nibble] = node.children[nibble].ref()
+		}
+	}
+	node.enc = testRLPList(items...)
+	return node
+}
+
+// testTrie builds a trie holding the passed key value pairs and returns its
+// root node.
+func testTrie(kvs map[string]string) *testNode {
+	var pairs []testKeyValue
+	for k, v := range kvs {
+		pairs = append(pairs, testKeyValue{KeyToNibbles([]byte(k)),
+			[]byte(v)})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return bytes.Compare(pairs[i].path, pairs[j].path) < 0
+	})
+	return buildTestTrie(pairs, 0)
+}
+
+// testProof returns the proof for the passed key in the trie with the passed
+// root node.
+func testProof(root *testNode, key []byte) []byte {
+	var nodes [][]byte
+	path := KeyToNibbles(key)
+	node := root
+	for node != nil {
+		if node == root || len(node.enc) >= HashSize {
+			nodes = append(nodes, node.enc)
+		}
+		switch {
+		case node.isLeaf:
+			node = nil
+		case node.child != nil:
+			path = path[len(node.partial):]
+			node = node.child
+		case len(path) == 0:
+			node = nil
+		default:
+			node, path = node.children[path[0]], path[1:]
+		}
+	}
+	return testRLPList(nodes...)
+}
+
+// TestVerifyProof ensures proofs of keys present in a trie verify and return
+// their values.
+func TestVerifyProof(t *testing.T) {
+	t.Parallel()
+
+	// This is the "dogs" trie of the Ethereum trie tests which involves
+	// values held by branches and nodes embedded in their parents.
+	kvs := map[string]string{
+		"do":    "verb",
+		"dog":   "puppy",
+		"doge":  "coin",
+		"horse": "stallion",
+	}
+	root := testTrie(kvs)
+	rootHash := Keccak256(root.enc)
+	wantRoot := "5991bb8c6514148a29db676a14ac506cd2cd5775ace63c30a4fe457715e9ac84"
+	if hex.EncodeToString(rootHash) != wantRoot {
+		t.Fatalf("unexpected test trie root - got %x, want %s",
+			rootHash, wantRoot)
+	}
+
+	// Add a trie keyed like a transaction trie with values large enough to
+	// require every node to be hashed.
+	txKVs := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		key := string(testRLPString([]byte{byte(i)}))
+		if i == 0 {
+			key = string(testRLPString(nil))
+		}
+		txKVs[key] = fmt.Sprintf("%064d", i)
+	}
+	txRoot := testTrie(txKVs)
+	txRootHash := Keccak256(txRoot.enc)
+
+	tries := []struct {
+		root     *testNode
+		rootHash []byte
+		kvs      map[string]string
+	}{
+		{root, rootHash, kvs},
+		{txRoot, txRootHash, txKVs},
+	}
+	for _, trie := range tries {
+		for k, v := range trie.kvs {
+			proof := testProof(trie.root, []byte(k))
+			value, err := VerifyProof(trie.rootHash, []byte(k), proof)
+			if err != nil {
+				t.Errorf("VerifyProof(%x): unexpected error: %v",
+					k, err)
+				continue
+			}
+			if string(value) != v {
+				t.Errorf("VerifyProof(%x): unexpected value - "+
+					"got %q, want %q", k, value, v)
+			}
+		}
+	}
+}
+
+// TestVerifyProofErrors ensures invalid proofs are rejected with the expected
+// error codes.
+func TestVerifyProofErrors(t *testing.T) {
+	t.Parallel()
+
+	txKVs := make(map[string]string)
+	for i := 1; i < 40; i++ {
+		txKVs[string([]byte{byte(i)})] = fmt.Sprintf("%064d", i)
+	}
+	root := testTrie(txKVs)
+	rootHash := Keccak256(root.enc)
+	key := []byte{0x05}
+	proof := testProof(root, key)
+
+	// Decode the proof nodes to build variations of the proof.
+	rawNodes, err := splitList(proof)
+	if err != nil {
+		t.Fatalf("splitList: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		root  []byte
+		key   []byte
+		proof []byte
+		code  ErrorCode
+	}{
+		{
+			name:  "short root",
+			root:  rootHash[:31],
+			key:   key,
+			proof: proof,
+			code:  ErrInvalidRoot,
+		},
+		{
+			name:  "wrong root",
+			root:  Keccak256([]byte("wrong")),
+			key:   key,
+			proof: proof,
+			code:  ErrNodeHashMismatch,
+		},
+		{
+			name:  "absent key",
+			root:  rootHash,
+			key:   []byte{0xee},
+			proof: proof,
+			code:  ErrKeyNotFound,
+		},
+		{
+			name:  "missing node",
+			root:  rootHash,
+			key:   key,
+			proof: testRLPList(rawNodes[:len(rawNodes)-1]...),
+			code:  ErrKeyNotFound,
+		},
+		{
+			name: "trailing node",
+			root: rootHash,
+			key:  key,
+			proof: testRLPList(append(rawNodes,
+				rawNodes[len(rawNodes)-1])...),
+			code: ErrTrailingNodes,
+		},
+		{
+			name:  "trailing bytes",
+			root:  rootHash,
+			key:   key,
+			proof: append(proof, 0x00),
+			code:  ErrMalformedProof,
+		},
+		{
+			name:  "truncated",
+			root:  rootHash,
+			key:   key,
+			proof: proof[:len(proof)-1],
+			code:  ErrMalformedProof,
+		},
+		{
+			name:  "not a list",
+			root:  rootHash,
+			key:   key,
+			proof: testRLPString([]byte("proof")),
+			code:  ErrMalformedProof,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := VerifyProof(test.root, test.key, test.proof)
+		perr, ok := err.(ProofError)
+		if !ok {
+			t.Errorf("%s: did not get expected error %v - got %v",
+				test.name, test.code, err)
+			continue
+		}
+		if perr.ErrorCode != test.code {
+			t.Errorf("%s: unexpected error code - got %v, want %v",
+				test.name, perr.ErrorCode, test.code)
+		}
+	}
+}
+
+// TestVerifyMintProof ensures the transaction and receipt proofs of a mint are
+// verified along with the position of the proven values.
+func TestVerifyMintProof(t *testing.T) {
+	t.Parallel()
+
+	txKVs := make(map[string]string)
+	receiptKVs := make(map[string]string)
+	for i := 1; i < 20; i++ {
+		key := string(testRLPString([]byte{byte(i)}))
+		txKVs[key] = fmt.Sprintf("tx%062d", i)
+		receiptKVs[key] = fmt.Sprintf("receipt%057d", i)
+	}
+	txRoot := testTrie(txKVs)
+	receiptRoot := testTrie(receiptKVs)
+
+	path := testRLPString([]byte{0x07})
+	txProof := testProof(txRoot, path)
+	receiptProof := testProof(receiptRoot, path)
+	wantTx := []byte(txKVs[string(path)])
+	wantReceipt := []byte(receiptKVs[string(path)])
+
+	mint := &wire.MintSyscoinType{
+		TxPos:              uint16(bytes.Index(txProof, wantTx)),
+		TxParentNodes:      txProof,
+		TxPath:             path,
+		TxRoot:             Keccak256(txRoot.enc),
+		ReceiptPos:         uint16(bytes.Index(receiptProof, wantReceipt)),
+		ReceiptParentNodes: receiptProof,
+		ReceiptRoot:        Keccak256(receiptRoot.enc),
+	}
+	tx, receipt, err := VerifyMintProof(mint)
+	if err != nil {
+		t.Fatalf("VerifyMintProof: unexpected error: %v", err)
+	}
+	if !bytes.Equal(tx, wantTx) || !bytes.Equal(receipt, wantReceipt) {
+		t.Fatalf("VerifyMintProof: unexpected values - got %q and %q",
+			tx, receipt)
+	}
+
+	// Ensure a position which does not match the proven value is rejected.
+	mint.ReceiptPos++
+	_, _, err = VerifyMintProof(mint)
+	if perr, ok := err.(ProofError); !ok || perr.ErrorCode != ErrValueMismatch {
+		t.Fatalf("VerifyMintProof: did not get expected error %v - "+
+			"got %v", ErrValueMismatch, err)
+	}
+
+	// Ensure a receipt proof against the wrong root is rejected.
+	mint.ReceiptPos--
+	mint.ReceiptRoot = mint.TxRoot
+	_, _, err = VerifyMintProof(mint)
+	if perr, ok := err.(ProofError); !ok || perr.ErrorCode != ErrNodeHashMismatch {
+		t.Fatalf("VerifyMintProof: did not get expected error %v - "+
+			"got %v", ErrNodeHashMismatch, err)
+	}
+}
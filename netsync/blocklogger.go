@@ -10,6 +10,7 @@ import (
 
 	"github.com/btcsuite/btclog"
 	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/blockchain"
 )
 
 // blockProgressLogger provides periodic logging for other services in order
@@ -28,7 +29,8 @@ type blockProgressLogger struct {
 // newBlockProgressLogger returns a new block progress logger.
 // The progress message is templated as follows:
 //  {progressAction} {numProcessed} {blocks|block} in the last {timePeriod}
-//  ({numTxs}, height {lastBlockHeight}, {lastBlockTimeStamp})
+//  ({numTxs}, height {lastBlockHeight}, {lastBlockTimeStamp}, scripts
+//  {scriptValidation})
 func newBlockProgressLogger(progressMessage string, logger btclog.Logger) *blockProgressLogger {
 	return &blockProgressLogger{
 		lastBlockLogTime: time.Now(),
@@ -39,8 +41,10 @@ func newBlockProgressLogger(progressMessage string, logger btclog.Logger) *block
 
 // LogBlockHeight logs a new block height as an information message to show
 // progress to the user. In order to prevent spam, it limits logging to one
-// message every 10 seconds with duration and totals included.
-func (b *blockProgressLogger) LogBlockHeight(block *btcutil.Block) {
+// message every 10 seconds with duration and totals included.  The script
+// validation mode of the block is included so users can tell whether scripts
+// are being validated.
+func (b *blockProgressLogger) LogBlockHeight(block *btcutil.Block, scriptValidation blockchain.ScriptValidation) {
 	b.Lock()
 	defer b.Unlock()
 
@@ -66,9 +70,10 @@ func (b *blockProgressLogger) LogBlockHeight(block *btcutil.Block) {
 	if b.receivedLogTx == 1 {
 		txStr = "transaction"
 	}
-	b.subsystemLogger.Infof("%s %d %s in the last %s (%d %s, height %d, %s)",
-		b.progressAction, b.receivedLogBlocks, blockStr, tDuration, b.receivedLogTx,
-		txStr, block.Height(), block.MsgBlock().Header.Timestamp)
+	b.subsystemLogger.Infof("%s %d %s in the last %s (%d %s, height %d, %s, "+
+		"scripts %s)", b.progressAction, b.receivedLogBlocks, blockStr,
+		tDuration, b.receivedLogTx, txStr, block.Height(),
+		block.MsgBlock().Header.Timestamp, scriptValidation)
 
 	b.receivedLogBlocks = 0
 	b.receivedLogTx = 0
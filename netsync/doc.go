@@ -7,7 +7,10 @@ Package netsync implements a concurrency safe block syncing protocol. The
 SyncManager communicates with connected peers to perform an initial block
 download, keep the chain and unconfirmed transaction pool in sync, and announce
 new blocks connected to the chain. Currently the sync manager selects a single
-sync peer that it downloads all blocks from until it is up to date with the
-longest chain the sync peer is aware of.
+sync peer that it downloads the headers and blocks from until it is up to date
+with the longest chain the sync peer is aware of. The headers are downloaded up
+to the tip of its chain, after presyncing them until they are known to have the
+minimum chain work, while the blocks of the downloaded headers are requested
+from all capable peers at once and processed in order.
 */
 package netsync
@@ -25,6 +25,8 @@ type PeerNotifier interface {
 	RelayInventory(invVect *wire.InvVect, data interface{})
 
 	TransactionConfirmed(tx *btcutil.Tx)
+
+	BanMisbehavingPeer(peer *peer.Peer, reason string)
 }
 
 // Config is a configuration struct used to initialize a new SyncManager.
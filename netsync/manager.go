@@ -6,6 +6,7 @@ package netsync
 
 import (
 	"container/list"
+	"fmt"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -23,10 +24,34 @@ import (
 
 const (
 	// minInFlightBlocks is the minimum number of blocks that should be
-	// in the request queue for headers-first mode before requesting
-	// more.
+	// in the request queue of the sync peer for the blocks before the UTXO
+	// snapshot the chain was bootstrapped from before requesting more.
 	minInFlightBlocks = 10
 
+	// maxInFlightBlocksPerPeer is the maximum number of blocks which are
+	// requested from a single peer at once in headers-first mode.
+	maxInFlightBlocksPerPeer = 16
+
+	// blockDownloadWindow is the maximum number of blocks starting with the
+	// next one to be processed which are downloaded in headers-first mode.
+	// Blocks arriving out of order are held in memory until all of the
+	// blocks before them have arrived, so this bounds the memory they use.
+	blockDownloadWindow = 256
+
+	// stallSampleInterval is the interval at which the download of the
+	// blocks in headers-first mode is checked for stalling peers.
+	stallSampleInterval = time.Second * 2
+
+	// blockStallTimeout is the duration after which a peer which has not
+	// delivered the next block to be processed in headers-first mode is
+	// considered to stall the download of all of the blocks after it.
+	blockStallTimeout = time.Second * 10
+
+	// maxInFlightHistoryBlocks is the maximum number of blocks before the
+	// UTXO snapshot the chain was bootstrapped from which are requested
+	// from the sync peer at once.
+	maxInFlightHistoryBlocks = 64
+
 	// maxRejectedTxns is the maximum number of rejected transactions
 	// hashes to store in memory.
 	maxRejectedTxns = 1000
@@ -122,17 +147,25 @@ type pauseMsg struct {
 	unpause <-chan struct{}
 }
 
-// headerNode is used as a node in a list of headers that are linked together
-// between checkpoints.
+// headerNode is used as a node in a list of the headers of the best header
+// chain whose blocks are downloaded in headers-first mode.  The peer is the one
+// the block of the header was requested from and the block is set once it has
+// been received until it is processed.  Blocks which are ancestors of a
+// verified checkpoint are eligible for less validation.
 type headerNode struct {
-	height int32
-	hash   *chainhash.Hash
+	height      int32
+	hash        *chainhash.Hash
+	fastAdd     bool
+	peer        *peerpkg.Peer
+	requestTime time.Time
+	block       *btcutil.Block
 }
 
 // peerSyncState stores additional information that the SyncManager tracks
 // about a peer.
 type peerSyncState struct {
 	syncCandidate   bool
+	dropped         bool
 	requestQueue    []*wire.InvVect
 	requestedTxns   map[chainhash.Hash]struct{}
 	requestedBlocks map[chainhash.Hash]struct{}
@@ -165,8 +198,10 @@ type SyncManager struct {
 	// The following fields are used for headers-first mode.
 	headersFirstMode bool
 	headerList       *list.List
-	startHeader      *list.Element
-	nextCheckpoint   *chaincfg.Checkpoint
+	blocksInFlight   map[chainhash.Hash]*list.Element
+	presync          *blockchain.HeadersPresync
+	lastHeader       *chainhash.Hash
+	headersSynced    bool
 
 	// An optional fee estimator.
 	feeEstimator *mempool.FeeEstimator
@@ -174,46 +209,17 @@ type SyncManager struct {
 
 // resetHeaderState sets the headers-first mode state to values appropriate for
 // syncing from a new peer.
-func (sm *SyncManager) resetHeaderState(newestHash *chainhash.Hash, newestHeight int32) {
+func (sm *SyncManager) resetHeaderState() {
 	sm.headersFirstMode = false
 	sm.headerList.Init()
-	sm.startHeader = nil
-
-	// When there is a next checkpoint, add an entry for the latest known
-	// block into the header pool.  This allows the next downloaded header
-	// to prove it links to the chain properly.
-	if sm.nextCheckpoint != nil {
-		node := headerNode{height: newestHeight, hash: newestHash}
-		sm.headerList.PushBack(&node)
-	}
-}
-
-// findNextHeaderCheckpoint returns the next checkpoint after the passed height.
-// It returns nil when there is not one either because the height is already
-// later than the final checkpoint or some other reason such as disabled
-// checkpoints.
-func (sm *SyncManager) findNextHeaderCheckpoint(height int32) *chaincfg.Checkpoint {
-	checkpoints := sm.chain.Checkpoints()
-	if len(checkpoints) == 0 {
-		return nil
-	}
-
-	// There is no next checkpoint if the height is already after the final
-	// checkpoint.
-	finalCheckpoint := &checkpoints[len(checkpoints)-1]
-	if height >= finalCheckpoint.Height {
-		return nil
-	}
-
-	// Find the next checkpoint.
-	nextCheckpoint := finalCheckpoint
-	for i := len(checkpoints) - 2; i >= 0; i-- {
-		if height >= checkpoints[i].Height {
-			break
-		}
-		nextCheckpoint = &checkpoints[i]
-	}
-	return nextCheckpoint
+	sm.presync = nil
+	sm.lastHeader = nil
+	sm.headersSynced = false
+
+	// Any blocks of the headers which are still in flight remain requested
+	// from their peers, so they are simply processed like other blocks in
+	// case they arrive.
+	sm.blocksInFlight = make(map[chainhash.Hash]*list.Element)
 }
 
 // startSync will choose the best peer among the available candidate peers to
@@ -270,46 +276,43 @@ func (sm *SyncManager) startSync() {
 		// to send.
 		sm.requestedBlocks = make(map[chainhash.Hash]struct{})
 
-		locator, err := sm.chain.LatestBlockLocator()
-		if err != nil {
-			log.Errorf("Failed to get block locator for the "+
-				"latest block: %v", err)
-			return
-		}
+		// Download the headers starting from the best known header.
+		bestHeader, _ := sm.chain.BestHeader()
+		locator := sm.chain.BlockLocatorFromHash(&bestHeader)
 
 		log.Infof("Syncing to block height %d from peer %v",
 			bestPeer.LastBlock(), bestPeer.Addr())
 
-		// When the current height is less than a known checkpoint we
-		// can use block headers to learn about which blocks comprise
-		// the chain up to the checkpoint and perform less validation
-		// for them.  This is possible since each header contains the
-		// hash of the previous header and a merkle root.  Therefore if
-		// we validate all of the received headers link together
-		// properly and the checkpoint hashes match, we can be sure the
-		// hashes for the blocks in between are accurate.  Further, once
-		// the full blocks are downloaded, the merkle root is computed
-		// and compared against the value in the header which proves the
-		// full block hasn't been tampered with.
+		// Use block headers to learn about which blocks comprise the
+		// chain up to the tip before downloading the blocks.  This is
+		// possible since each header contains the hash of the previous
+		// header and a merkle root.  Therefore the blocks can be
+		// downloaded from several peers at once while the headers are
+		// still being downloaded, and the blocks up to a checkpoint
+		// receive less validation once the header chain is verified to
+		// match it.  Further, once the full blocks are downloaded, the
+		// merkle root is computed and compared against the value in
+		// the header which proves the full block hasn't been tampered
+		// with.
 		//
-		// Once we have passed the final checkpoint, or checkpoints are
-		// disabled, use standard inv messages learn about the blocks
-		// and fully validate them.  Finally, regression test mode does
-		// not support the headers-first approach so do normal block
-		// downloads when in regression test mode.
-		if sm.nextCheckpoint != nil &&
-			best.Height < sm.nextCheckpoint.Height &&
-			sm.chainParams != &chaincfg.RegressionNetParams {
-
-			bestPeer.PushGetHeadersMsg(locator, sm.nextCheckpoint.Hash)
-			sm.headersFirstMode = true
-			log.Infof("Downloading headers for blocks %d to "+
-				"%d from peer %s", best.Height+1,
-				sm.nextCheckpoint.Height, bestPeer.Addr())
-		} else {
-			bestPeer.PushGetBlocksMsg(locator, &zeroHash)
+		// The headers are presynced first unless the best header chain
+		// already has the minimum chain work, so peers can't exhaust
+		// memory with low-work header chains.  Once all of the blocks
+		// of the headers have been downloaded, use standard inv
+		// messages to learn about new blocks.
+		sm.resetHeaderState()
+		err := bestPeer.PushGetHeadersMsg(locator, &zeroHash)
+		if err != nil {
+			log.Warnf("Failed to send getheaders message to "+
+				"peer %s: %v", bestPeer.Addr(), err)
+			return
 		}
+		sm.headersFirstMode = true
+		sm.progressLogger.SetLastLogTime(time.Now())
+		log.Infof("Downloading headers from peer %s", bestPeer.Addr())
 		sm.syncPeer = bestPeer
+		sm.fetchSnapshotHistory()
+		sm.processHeaderBlocks()
 	} else {
 		log.Warnf("No sync peer candidates available")
 	}
@@ -400,24 +403,26 @@ func (sm *SyncManager) handleDonePeerMsg(peer *peerpkg.Peer) {
 	}
 
 	// Remove requested blocks from the global map so that they will be
-	// fetched from elsewhere next time we get an inv.
-	// TODO: we could possibly here check which peers have these blocks
-	// and request them now to speed things up a little.
+	// fetched from elsewhere next time we get an inv.  The blocks of the
+	// headers being fetched in headers-first mode are requested from the
+	// other peers right away.
+	sm.releaseHeaderBlocks(state)
 	for blockHash := range state.requestedBlocks {
 		delete(sm.requestedBlocks, blockHash)
 	}
 
 	// Attempt to find a new peer to sync from if the quitting peer is the
 	// sync peer.  Also, reset the headers-first state if in headers-first
-	// mode so
+	// mode so the headers are downloaded from the new sync peer.
 	if sm.syncPeer == peer {
 		sm.syncPeer = nil
 		if sm.headersFirstMode {
-			best := sm.chain.BestSnapshot()
-			sm.resetHeaderState(&best.Hash, best.Height)
+			sm.resetHeaderState()
 		}
 		sm.startSync()
+		return
 	}
+	sm.processHeaderBlocks()
 }
 
 // handleTxMsg handles transaction messages from all peers.
@@ -534,39 +539,55 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		}
 	}
 
-	// When in headers-first mode, if the block matches the hash of the
-	// first header in the list of headers that are being fetched, it's
-	// eligible for less validation since the headers have already been
-	// verified to link together and are valid up to the next checkpoint.
-	// Also, remove the list entry for all blocks except the checkpoint
-	// since it is needed to verify the next round of headers links
-	// properly.
-	isCheckpointBlock := false
-	behaviorFlags := blockchain.BFNone
-	if sm.headersFirstMode {
-		firstNodeEl := sm.headerList.Front()
-		if firstNodeEl != nil {
-			firstNode := firstNodeEl.Value.(*headerNode)
-			if blockHash.IsEqual(firstNode.hash) {
-				behaviorFlags |= blockchain.BFFastAdd
-				if firstNode.hash.IsEqual(sm.nextCheckpoint.Hash) {
-					isCheckpointBlock = true
-				} else {
-					sm.headerList.Remove(firstNodeEl)
-				}
-			}
-		}
-	}
-
 	// Remove block from request maps. Either chain will know about it and
 	// so we shouldn't have any more instances of trying to fetch it, or we
 	// will fail the insert and thus we'll retry next time we get an inv.
 	delete(state.requestedBlocks, *blockHash)
 	delete(sm.requestedBlocks, *blockHash)
 
+	// When in headers-first mode, the blocks of the headers being fetched
+	// are downloaded from several peers at once, so they may arrive out of
+	// order.  Hold on to the block until all of the blocks before it have
+	// arrived to hand them to the chain in order.
+	if e, exists := sm.blocksInFlight[*blockHash]; exists {
+		delete(sm.blocksInFlight, *blockHash)
+		node := e.Value.(*headerNode)
+		node.peer = peer
+		node.block = bmsg.block
+		sm.processHeaderBlocks()
+
+		// Request more of the blocks before the UTXO snapshot the chain
+		// was bootstrapped from while they are being validated.
+		sm.fetchSnapshotHistory()
+		return
+	}
+
+	// Process the block to include validation, best chain selection, orphan
+	// handling, etc.
+	if err := sm.processBlock(bmsg.block, peer, blockchain.BFNone); err != nil {
+		return
+	}
+
+	// Request more of the blocks before the UTXO snapshot the chain was
+	// bootstrapped from while they are being validated.
+	sm.fetchSnapshotHistory()
+
+	// The block might be one of the headers being fetched in headers-first
+	// mode, and the peer might be able to take more requests for them now.
+	sm.processHeaderBlocks()
+}
+
+// processBlock hands the passed block received from the passed peer to the
+// chain and updates the block heights of the peers accordingly.  A reject
+// message is sent to the peer when the block is rejected.  It returns the error
+// the block was rejected with, if any.
+func (sm *SyncManager) processBlock(block *btcutil.Block, peer *peerpkg.Peer,
+	behaviorFlags blockchain.BehaviorFlags) error {
+
 	// Process the block to include validation, best chain selection, orphan
 	// handling, etc.
-	_, isOrphan, err := sm.chain.ProcessBlock(bmsg.block, behaviorFlags)
+	blockHash := block.Hash()
+	_, isOrphan, err := sm.chain.ProcessBlock(block, behaviorFlags)
 	if err != nil {
 		// When the error is a rule error, it means the block was simply
 		// rejected as opposed to something actually going wrong, so log
@@ -588,7 +609,7 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		// send it.
 		code, reason := mempool.ErrToRejectErr(err)
 		peer.PushRejectMsg(wire.CmdBlock, code, reason, blockHash, false)
-		return
+		return err
 	}
 
 	// Meta-data about the new block this peer is reporting. We use this
@@ -610,9 +631,9 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		// block height from the scriptSig of the coinbase transaction.
 		// Extraction is only attempted if the block's version is
 		// high enough (ver 2+).
-		header := &bmsg.block.MsgBlock().Header
+		header := &block.MsgBlock().Header
 		if blockchain.ShouldHaveSerializedBlockHeight(header) {
-			coinbaseTx := bmsg.block.Transactions()[0]
+			coinbaseTx := block.Transactions()[0]
 			cbHeight, err := blockchain.ExtractCoinbaseHeight(coinbaseTx)
 			if err != nil {
 				log.Warnf("Unable to extract height from "+
@@ -636,7 +657,8 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 	} else {
 		// When the block is not an orphan, log information about it and
 		// update the chain state.
-		sm.progressLogger.LogBlockHeight(bmsg.block)
+		sm.progressLogger.LogBlockHeight(block,
+			sm.chain.ScriptValidation(blockHash))
 
 		// Update this peer's latest block height, for future
 		// potential sync node candidacy.
@@ -659,77 +681,243 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 				peer)
 		}
 	}
+	return nil
+}
 
-	// Nothing more to do if we aren't in headers-first mode.
-	if !sm.headersFirstMode {
-		return
-	}
+// processHeaderBlocks hands the blocks of the headers at the front of the
+// header list which have been received to the chain in order and requests more
+// of them.  The blocks which are ancestors of a verified checkpoint are eligible
+// for less validation since the headers have already been verified to link
+// together up to it.
+func (sm *SyncManager) processHeaderBlocks() {
+	for sm.headersFirstMode {
+		e := sm.headerList.Front()
+		if e == nil {
+			break
+		}
+		node := e.Value.(*headerNode)
 
-	// This is headers-first mode, so if the block is not a checkpoint
-	// request more blocks using the header list when the request queue is
-	// getting short.
-	if !isCheckpointBlock {
-		if sm.startHeader != nil &&
-			len(state.requestedBlocks) < minInFlightBlocks {
-			sm.fetchHeaderBlocks()
+		// Blocks which have been connected to the main chain some
+		// other way in the meantime are done as well.
+		if node.block == nil {
+			if !sm.chain.MainChainHasBlock(node.hash) {
+				break
+			}
+		} else {
+			block := node.block
+			node.block = nil
+			flags := blockchain.BFNone
+			if node.fastAdd {
+				flags |= blockchain.BFFastAdd
+			}
+			err := sm.processBlock(block, node.peer, flags)
+			if rerr, ok := err.(blockchain.RuleError); ok {
+				// The block is known already when it was
+				// processed after being received some other
+				// way.
+				if rerr.ErrorCode != blockchain.ErrDuplicateBlock {
+					sm.rejectHeaderBlock(e, rerr)
+					continue
+				}
+			} else if err != nil {
+				// Request the block again, possibly from
+				// another peer.
+				node.peer = nil
+				break
+			}
 		}
-		return
+		node.peer = nil
+		sm.headerList.Remove(e)
 	}
 
-	// This is headers-first mode and the block is a checkpoint.  When
-	// there is a next checkpoint, get the next round of headers by asking
-	// for headers starting from the block after this one up to the next
-	// checkpoint.
-	prevHeight := sm.nextCheckpoint.Height
-	prevHash := sm.nextCheckpoint.Hash
-	sm.nextCheckpoint = sm.findNextHeaderCheckpoint(prevHeight)
-	if sm.nextCheckpoint != nil {
-		locator := blockchain.BlockLocator([]*chainhash.Hash{prevHash})
-		err := peer.PushGetHeadersMsg(locator, sm.nextCheckpoint.Hash)
-		if err != nil {
-			log.Warnf("Failed to send getheaders message to "+
-				"peer %s: %v", peer.Addr(), err)
+	// Request more blocks now that the download window has moved on.  The
+	// headers of the blocks which turn out to be known already release
+	// their slots in the window meanwhile, so the blocks after them are
+	// processed when they reach the front.
+	front := sm.headerList.Front()
+	sm.fetchHeaderBlocks()
+	if sm.headerList.Front() != front {
+		sm.processHeaderBlocks()
+	}
+}
+
+// blockMutated returns whether or not the passed rule error a block was
+// rejected with might be caused by the peer it was received from altering the
+// block, such as its transactions, witness data or auxpow, without altering the
+// block hash.  The header of the block is not known to be invalid then.
+func blockMutated(rerr blockchain.RuleError) bool {
+	switch rerr.ErrorCode {
+	case blockchain.ErrBadMerkleRoot, blockchain.ErrDuplicateTx,
+		blockchain.ErrUnexpectedWitness,
+		blockchain.ErrInvalidWitnessCommitment,
+		blockchain.ErrWitnessCommitmentMismatch,
+		blockchain.ErrBlockWeightTooHigh, blockchain.ErrHighHash,
+		blockchain.ErrBadAuxPow, blockchain.ErrBadAuxPowChainID:
+
+		return true
+	}
+	return false
+}
+
+// rejectHeaderBlock handles the rejection of the block of the header in the
+// passed element at the front of the header list with the passed rule error in
+// headers-first mode.  The peer the block was received from is banned, and the
+// header is marked invalid so neither it nor the headers after it, which
+// descend from it, are scheduled anymore.  A block which might have been
+// mutated by the peer is requested again from another peer instead.
+func (sm *SyncManager) rejectHeaderBlock(e *list.Element, rerr blockchain.RuleError) {
+	node := e.Value.(*headerNode)
+	peer := node.peer
+	node.peer = nil
+
+	// The peer is not at fault when the block descends from one which has
+	// been found invalid since it was requested.
+	if rerr.ErrorCode != blockchain.ErrInvalidAncestorBlock {
+		sm.dropHeaderBlocksPeer(peer)
+		reason := fmt.Sprintf("sent invalid block %v", node.hash)
+		go sm.peerNotifier.BanMisbehavingPeer(peer, reason)
+
+		if blockMutated(rerr) {
 			return
 		}
-		log.Infof("Downloading headers for blocks %d to %d from "+
-			"peer %s", prevHeight+1, sm.nextCheckpoint.Height,
-			sm.syncPeer.Addr())
+		if err := sm.chain.InvalidateBlock(node.hash); err != nil {
+			log.Errorf("Failed to mark block %v invalid: %v",
+				node.hash, err)
+		}
+	}
+
+	// Any blocks of the removed headers which are still in flight remain
+	// requested from their peers, so they are simply processed like other
+	// blocks in case they arrive.
+	for e != nil {
+		next := e.Next()
+		delete(sm.blocksInFlight, *e.Value.(*headerNode).hash)
+		sm.headerList.Remove(e)
+		e = next
+	}
+}
+
+// extendHeaderList adds the headers of the best header chain following the
+// back of the header list, or the best block when it is empty, to the list
+// until it covers the download window.
+func (sm *SyncManager) extendHeaderList() {
+	numHeaders := blockDownloadWindow - sm.headerList.Len()
+	if numHeaders <= 0 {
 		return
 	}
 
-	// This is headers-first mode, the block is a checkpoint, and there are
-	// no more checkpoints, so switch to normal mode by requesting blocks
-	// from the block after this one up to the end of the chain (zero hash).
-	sm.headersFirstMode = false
-	sm.headerList.Init()
-	log.Infof("Reached the final checkpoint -- switching to normal mode")
-	locator := blockchain.BlockLocator([]*chainhash.Hash{blockHash})
-	err = peer.PushGetBlocksMsg(locator, &zeroHash)
+	// The blocks up to the final checkpoint are eligible for less
+	// validation once the best header chain has been verified to match it.
+	var fastAddHeight int32 = -1
+	_, bestHeaderHeight := sm.chain.BestHeader()
+	if checkpoints := sm.chain.Checkpoints(); len(checkpoints) > 0 {
+		finalCheckpoint := &checkpoints[len(checkpoints)-1]
+		if bestHeaderHeight >= finalCheckpoint.Height {
+			fastAddHeight = finalCheckpoint.Height
+		}
+	}
+
+	var prevNode *headerNode
+	prevHash := sm.chain.BestSnapshot().Hash
+	if back := sm.headerList.Back(); back != nil {
+		prevNode = back.Value.(*headerNode)
+		prevHash = *prevNode.hash
+	}
+	height, hashes, err := sm.chain.BestHeaderHashes(&prevHash, numHeaders)
 	if err != nil {
-		log.Warnf("Failed to send getblocks message to peer %s: %v",
-			peer.Addr(), err)
+		log.Warnf("Failed to get the headers following block %v: %v",
+			prevHash, err)
 		return
 	}
+
+	// The headers at the back of the list are no longer part of the best
+	// header chain when it has been reorganized, so the list is only
+	// extended again once their blocks have been processed.
+	if prevNode != nil && height != prevNode.height+1 {
+		return
+	}
+	for i := range hashes {
+		node := headerNode{
+			height:  height,
+			hash:    &hashes[i],
+			fastAdd: height <= fastAddHeight,
+		}
+		sm.headerList.PushBack(&node)
+		height++
+	}
+}
+
+// blockDownloadPeers returns the peers the blocks of the headers being fetched
+// in headers-first mode may be requested from.  These are the sync peer and all
+// other sync candidates which are able to provide witness data once segwit is
+// active, except for the peers which have been dropped.
+func (sm *SyncManager) blockDownloadPeers() []*peerpkg.Peer {
+	segwitActive, err := sm.chain.IsDeploymentActive(chaincfg.DeploymentSegwit)
+	if err != nil {
+		log.Errorf("Unable to query for segwit soft-fork state: %v", err)
+		return nil
+	}
+
+	var peers []*peerpkg.Peer
+	for peer, state := range sm.peerStates {
+		if state.dropped || (peer != sm.syncPeer && !state.syncCandidate) {
+			continue
+		}
+		if segwitActive && !peer.IsWitnessEnabled() {
+			continue
+		}
+		peers = append(peers, peer)
+	}
+	return peers
 }
 
-// fetchHeaderBlocks creates and sends a request to the syncPeer for the next
-// list of blocks to be downloaded based on the current list of headers.
+// fetchHeaderBlocks requests the blocks of the headers being fetched in
+// headers-first mode which have not been requested yet within the download
+// window.  The requests are spread across all of the peers which are known to
+// have the blocks, each getting the block from the peer with the fewest blocks
+// in flight until all of them have reached the limit.  The headers of blocks
+// which are known already are removed from the header list instead.  Once the
+// blocks of all of the headers of the sync peer have been processed, it switches
+// to normal mode.
+//
+// This function should only be called by processHeaderBlocks, which processes
+// the blocks which reach the front of the header list that way.
 func (sm *SyncManager) fetchHeaderBlocks() {
-	// Nothing to do if there is no start header.
-	if sm.startHeader == nil {
-		log.Warnf("fetchHeaderBlocks called with no start header")
+	// Nothing to do unless the blocks of the headers are being fetched.
+	if !sm.headersFirstMode || sm.syncPeer == nil {
+		return
+	}
+	sm.extendHeaderList()
+	front := sm.headerList.Front()
+	if front == nil {
+		if sm.headersSynced {
+			sm.switchToNormalMode()
+		}
 		return
 	}
 
-	// Build up a getdata request for the list of blocks the headers
-	// describe.  The size hint will be limited to wire.MaxInvPerMsg by
-	// the function, so no need to double check it here.
-	gdmsg := wire.NewMsgGetDataSizeHint(uint(sm.headerList.Len()))
-	numRequested := 0
-	for e := sm.startHeader; e != nil; e = e.Next() {
-		node, ok := e.Value.(*headerNode)
-		if !ok {
-			log.Warn("Header list node type is not a headerNode")
+	// Gather the peers which can take more requests.
+	var peers []*peerpkg.Peer
+	for _, peer := range sm.blockDownloadPeers() {
+		state := sm.peerStates[peer]
+		if len(state.requestedBlocks) < maxInFlightBlocksPerPeer {
+			peers = append(peers, peer)
+		}
+	}
+
+	// Build up a getdata request for each peer for the blocks of the
+	// headers in the download window which have neither been requested
+	// nor received yet.
+	gdmsgs := make(map[*peerpkg.Peer]*wire.MsgGetData)
+	windowEnd := front.Value.(*headerNode).height + blockDownloadWindow
+	var next *list.Element
+	for e := front; e != nil && len(peers) > 0; e = next {
+		next = e.Next()
+		node := e.Value.(*headerNode)
+		if node.height >= windowEnd {
+			break
+		}
+		if node.peer != nil || node.block != nil {
 			continue
 		}
 
@@ -740,85 +928,234 @@ func (sm *SyncManager) fetchHeaderBlocks() {
 				"existing inventory during header block "+
 				"fetch: %v", err)
 		}
-		if !haveInv {
-			syncPeerState := sm.peerStates[sm.syncPeer]
-
-			sm.requestedBlocks[*node.hash] = struct{}{}
-			syncPeerState.requestedBlocks[*node.hash] = struct{}{}
+		if haveInv {
+			// The chain connects blocks which are known already,
+			// such as orphans, along with their parents, so their
+			// headers release their slots in the window.
+			sm.headerList.Remove(e)
+			continue
+		}
 
-			// If we're fetching from a witness enabled peer
-			// post-fork, then ensure that we receive all the
-			// witness data in the blocks.
-			if sm.syncPeer.IsWitnessEnabled() {
-				iv.Type = wire.InvTypeWitnessBlock
+		// Choose the peer with the fewest blocks in flight which has
+		// the block.  The sync peer provided the headers, so it is
+		// known to have all of them.  None of the peers has the block
+		// when none of them has any of the later ones either.
+		var peerIdx, numInFlight int
+		var peer *peerpkg.Peer
+		for i, p := range peers {
+			if p != sm.syncPeer && p.LastBlock() < node.height {
+				continue
+			}
+			n := len(sm.peerStates[p].requestedBlocks)
+			if peer == nil || n < numInFlight {
+				peerIdx, peer, numInFlight = i, p, n
 			}
-
-			gdmsg.AddInvVect(iv)
-			numRequested++
 		}
-		sm.startHeader = e.Next()
-		if numRequested >= wire.MaxInvPerMsg {
+		if peer == nil {
 			break
 		}
+
+		state := sm.peerStates[peer]
+		sm.requestedBlocks[*node.hash] = struct{}{}
+		state.requestedBlocks[*node.hash] = struct{}{}
+		sm.blocksInFlight[*node.hash] = e
+		node.peer = peer
+		node.requestTime = time.Now()
+
+		// If we're fetching from a witness enabled peer post-fork, then
+		// ensure that we receive all the witness data in the blocks.
+		if peer.IsWitnessEnabled() {
+			iv.Type = wire.InvTypeWitnessBlock
+		}
+
+		gdmsg, exists := gdmsgs[peer]
+		if !exists {
+			gdmsg = wire.NewMsgGetDataSizeHint(maxInFlightBlocksPerPeer)
+			gdmsgs[peer] = gdmsg
+		}
+		gdmsg.AddInvVect(iv)
+
+		// Stop requesting blocks from the peer once it has reached
+		// the limit.
+		if len(state.requestedBlocks) >= maxInFlightBlocksPerPeer {
+			peers = append(peers[:peerIdx], peers[peerIdx+1:]...)
+		}
+	}
+	for peer, gdmsg := range gdmsgs {
+		peer.QueueMessage(gdmsg, nil)
+	}
+}
+
+// releaseHeaderBlocks removes the requests for the blocks of the headers being
+// fetched in headers-first mode which are in flight from the peer with the
+// passed state so they are requested from other peers instead.
+func (sm *SyncManager) releaseHeaderBlocks(state *peerSyncState) {
+	for blockHash := range state.requestedBlocks {
+		e, exists := sm.blocksInFlight[blockHash]
+		if !exists {
+			continue
+		}
+		e.Value.(*headerNode).peer = nil
+		delete(sm.blocksInFlight, blockHash)
+		delete(sm.requestedBlocks, blockHash)
+		delete(state.requestedBlocks, blockHash)
+	}
+}
+
+// dropHeaderBlocksPeer stops requesting the blocks of the headers being fetched
+// in headers-first mode from the passed peer, which is about to be
+// disconnected, and requests the blocks in flight from it from the other peers
+// instead.
+func (sm *SyncManager) dropHeaderBlocksPeer(peer *peerpkg.Peer) {
+	state, exists := sm.peerStates[peer]
+	if !exists {
+		return
+	}
+	state.dropped = true
+	sm.releaseHeaderBlocks(state)
+}
+
+// handleStallSample checks whether the next block to be processed in
+// headers-first mode has been in flight for too long.  The peer it was
+// requested from stalls the download of all of the blocks after it then, so it
+// is disconnected and the blocks requested from it are requested from the
+// other peers instead.  It is invoked from the syncHandler goroutine.
+func (sm *SyncManager) handleStallSample() {
+	if !sm.headersFirstMode {
+		return
+	}
+	front := sm.headerList.Front()
+	if front == nil {
+		return
+	}
+	node := front.Value.(*headerNode)
+	if node.peer == nil || node.block != nil ||
+		time.Since(node.requestTime) < blockStallTimeout {
+
+		return
+	}
+
+	// Keep waiting for the block when there is no other peer to request it
+	// from anyways.
+	staller := node.peer
+	if len(sm.blockDownloadPeers()) < 2 {
+		log.Debugf("Peer %s is stalling the download of block %v, "+
+			"but there is no other peer to download it from",
+			staller, node.hash)
+		return
+	}
+
+	log.Infof("Peer %s stalled the download of block %v for %v -- "+
+		"disconnecting", staller, node.hash, blockStallTimeout)
+	sm.dropHeaderBlocksPeer(staller)
+	staller.Disconnect()
+	sm.processHeaderBlocks()
+}
+
+// fetchSnapshotHistory requests the blocks before the UTXO snapshot the chain
+// was bootstrapped from which are needed to validate the snapshot from the sync
+// peer.  Nothing is requested when the chain was not bootstrapped from a
+// snapshot or the snapshot has been validated already.
+func (sm *SyncManager) fetchSnapshotHistory() {
+	if sm.syncPeer == nil {
+		return
+	}
+	state, exists := sm.peerStates[sm.syncPeer]
+	if !exists || len(state.requestedBlocks) >= minInFlightBlocks {
+		return
+	}
+
+	hashes := sm.chain.SnapshotHistoryBlocks(maxInFlightHistoryBlocks)
+	gdmsg := wire.NewMsgGetDataSizeHint(uint(len(hashes)))
+	for i := range hashes {
+		hash := &hashes[i]
+		if _, exists := sm.requestedBlocks[*hash]; exists {
+			continue
+		}
+		sm.requestedBlocks[*hash] = struct{}{}
+		state.requestedBlocks[*hash] = struct{}{}
+
+		iv := wire.NewInvVect(wire.InvTypeBlock, hash)
+		if sm.syncPeer.IsWitnessEnabled() {
+			iv.Type = wire.InvTypeWitnessBlock
+		}
+		gdmsg.AddInvVect(iv)
 	}
 	if len(gdmsg.InvList) > 0 {
 		sm.syncPeer.QueueMessage(gdmsg, nil)
 	}
 }
 
+// switchToNormalMode leaves headers-first mode once the blocks of all of the
+// headers of the sync peer have been processed by requesting the blocks after
+// the best block from the sync peer.  New blocks are learned about via standard
+// inv messages from then on.
+func (sm *SyncManager) switchToNormalMode() {
+	sm.resetHeaderState()
+	log.Infof("Processed the blocks of all headers -- switching to " +
+		"normal mode")
+
+	locator, err := sm.chain.LatestBlockLocator()
+	if err != nil {
+		log.Errorf("Failed to get block locator for the latest "+
+			"block: %v", err)
+		return
+	}
+	err = sm.syncPeer.PushGetBlocksMsg(locator, &zeroHash)
+	if err != nil {
+		log.Warnf("Failed to send getblocks message to peer %s: %v",
+			sm.syncPeer.Addr(), err)
+	}
+}
+
+// fetchHeaders requests the headers following the passed block from the sync
+// peer.  While the presynced headers are downloaded again, only the headers up
+// to the presync target are requested.
+func (sm *SyncManager) fetchHeaders(hash *chainhash.Hash) {
+	stopHash := &zeroHash
+	if sm.presync != nil && sm.presync.Redownloading() {
+		stopHash = sm.presync.TargetHash()
+	}
+	locator := blockchain.BlockLocator([]*chainhash.Hash{hash})
+	err := sm.syncPeer.PushGetHeadersMsg(locator, stopHash)
+	if err != nil {
+		log.Warnf("Failed to send getheaders message to peer %s: %v",
+			sm.syncPeer.Addr(), err)
+	}
+}
+
 // handleHeadersMsg handles block header messages from all peers.  Headers are
-// requested when performing a headers-first sync.
+// requested from the sync peer when performing a headers-first sync.
 func (sm *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
 	peer := hmsg.peer
-	_, exists := sm.peerStates[peer]
+	state, exists := sm.peerStates[peer]
 	if !exists {
 		log.Warnf("Received headers message from unknown peer %s", peer)
 		return
 	}
 
-	// The remote peer is misbehaving if we didn't request headers.
+	// Ignore the headers unless they were requested from the sync peer.
+	// They might still answer a request sent before the sync peer changed
+	// or all of its headers were downloaded.  Unrequested headers are not
+	// added to the block index either since they would bypass the presync.
 	msg := hmsg.headers
 	numHeaders := len(msg.Headers)
-	if !sm.headersFirstMode {
-		log.Warnf("Got %d unrequested headers from %s -- "+
-			"disconnecting", numHeaders, peer.Addr())
-		peer.Disconnect()
-		return
-	}
-
-	// Nothing to do for an empty headers message.
-	if numHeaders == 0 {
+	if !sm.headersFirstMode || peer != sm.syncPeer || sm.headersSynced {
+		log.Debugf("Ignoring %d unrequested headers from %s",
+			numHeaders, peer.Addr())
 		return
 	}
 
 	// Process all of the received headers ensuring each one connects to the
-	// previous and that checkpoints match.
-	receivedCheckpoint := false
-	var finalHash *chainhash.Hash
-	for _, blockHeader := range msg.Headers {
+	// previous.  The headers are presynced first when the chain they
+	// connect to lacks the minimum chain work, so they are only added to
+	// the block index once they are known to have it.
+	var redownloaded bool
+	for i, blockHeader := range msg.Headers {
 		blockHash := blockHeader.BlockHash()
-		finalHash = &blockHash
+		if sm.lastHeader != nil &&
+			!blockHeader.PrevBlock.IsEqual(sm.lastHeader) {
 
-		// Ensure there is a previous header to compare against.
-		prevNodeEl := sm.headerList.Back()
-		if prevNodeEl == nil {
-			log.Warnf("Header list does not contain a previous" +
-				"element as expected -- disconnecting peer")
-			peer.Disconnect()
-			return
-		}
-
-		// Ensure the header properly connects to the previous one and
-		// add it to the list of headers.
-		node := headerNode{hash: &blockHash}
-		prevNode := prevNodeEl.Value.(*headerNode)
-		if prevNode.hash.IsEqual(&blockHeader.PrevBlock) {
-			node.height = prevNode.height + 1
-			e := sm.headerList.PushBack(&node)
-			if sm.startHeader == nil {
-				sm.startHeader = e
-			}
-		} else {
 			log.Warnf("Received block header that does not "+
 				"properly connect to the chain from peer %s "+
 				"-- disconnecting", peer.Addr())
@@ -826,51 +1163,96 @@ func (sm *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
 			return
 		}
 
-		// Verify the header at the next checkpoint height matches.
-		if node.height == sm.nextCheckpoint.Height {
-			if node.hash.IsEqual(sm.nextCheckpoint.Hash) {
-				receivedCheckpoint = true
-				log.Infof("Verified downloaded block "+
-					"header against checkpoint at height "+
-					"%d/hash %s", node.height, node.hash)
-			} else {
-				log.Warnf("Block header at height %d/hash "+
-					"%s from peer %s does NOT match "+
-					"expected checkpoint hash of %s -- "+
-					"disconnecting", node.height,
-					node.hash, peer.Addr(),
-					sm.nextCheckpoint.Hash)
+		if i == 0 && sm.presync == nil {
+			presync, err := sm.chain.NewHeadersPresync(
+				&blockHeader.PrevBlock)
+			if err != nil {
+				log.Warnf("Rejected block header %v from peer "+
+					"%s: %v -- disconnecting", blockHash,
+					peer.Addr(), err)
 				peer.Disconnect()
 				return
 			}
-			break
+			sm.presync = presync
+		}
+
+		var err error
+		wasPresyncing := sm.presync != nil && !sm.presync.Redownloading()
+		if sm.presync != nil {
+			err = sm.presync.ProcessHeader(blockHeader)
+		} else {
+			// Add the header to the block index so the chain knows
+			// about the best header chain ahead of downloading its
+			// blocks.
+			err = sm.chain.ProcessBlockHeader(blockHeader,
+				blockchain.BFNone)
+		}
+		if err != nil {
+			log.Warnf("Rejected block header %v from peer %s: %v "+
+				"-- disconnecting", blockHash, peer.Addr(), err)
+			peer.Disconnect()
+			return
+		}
+		sm.lastHeader = &blockHash
+
+		// Download the presynced headers again once they have the
+		// minimum chain work.
+		if wasPresyncing && sm.presync.Redownloading() {
+			log.Infof("Presynced headers to height %d with the "+
+				"minimum chain work -- downloading them again "+
+				"from peer %s", sm.presync.Height(), peer.Addr())
+			sm.lastHeader = sm.presync.StartHash()
+			sm.fetchHeaders(sm.lastHeader)
+			return
+		}
+
+		// The remaining headers are added to the block index right away
+		// once all of the presynced ones have been.
+		if sm.presync != nil && sm.presync.Done() {
+			sm.presync = nil
+			redownloaded = true
 		}
 	}
 
-	// When this header is a checkpoint, switch to fetching the blocks for
-	// all of the headers since the last checkpoint.
-	if receivedCheckpoint {
-		// Since the first entry of the list is always the final block
-		// that is already in the database and is only used to ensure
-		// the next header links properly, it must be removed before
-		// fetching the blocks.
-		sm.headerList.Remove(sm.headerList.Front())
-		log.Infof("Received %v block headers: Fetching blocks",
-			sm.headerList.Len())
-		sm.progressLogger.SetLastLogTime(time.Now())
-		sm.fetchHeaderBlocks()
+	// Download the blocks of the headers added to the block index while
+	// the following headers are downloaded.
+	sm.processHeaderBlocks()
+
+	// Request the next batch of headers when the message is full or the
+	// headers were only requested up to the presync target.
+	if numHeaders == wire.MaxBlockHeadersPerMsg || redownloaded {
+		sm.fetchHeaders(sm.lastHeader)
 		return
 	}
 
-	// This header is not a checkpoint, so request the next batch of
-	// headers starting from the latest known header and ending with the
-	// next checkpoint.
-	locator := blockchain.BlockLocator([]*chainhash.Hash{finalHash})
-	err := peer.PushGetHeadersMsg(locator, sm.nextCheckpoint.Hash)
-	if err != nil {
-		log.Warnf("Failed to send getheaders message to "+
-			"peer %s: %v", peer.Addr(), err)
-		return
+	switch {
+	// The peer has no more headers even though its chain lacks the minimum
+	// chain work, so it is not a candidate to sync from.
+	case sm.presync != nil && !sm.presync.Redownloading():
+		log.Infof("Peer %s has headers to height %d which lack the "+
+			"minimum chain work -- choosing another sync peer",
+			peer.Addr(), sm.presync.Height())
+		state.syncCandidate = false
+		sm.syncPeer = nil
+		sm.resetHeaderState()
+		sm.startSync()
+
+	// The peer stopped sending the presynced headers again before the
+	// target was reached.
+	case sm.presync != nil:
+		log.Warnf("Peer %s stopped sending the presynced headers "+
+			"before height %d -- disconnecting", peer.Addr(),
+			sm.presync.Height())
+		peer.Disconnect()
+
+	// All of the headers of the peer have been downloaded, so the blocks
+	// are downloaded until they have all been processed.
+	default:
+		_, height := sm.chain.BestHeader()
+		log.Infof("Downloaded headers to height %d from peer %s",
+			height, peer.Addr())
+		sm.headersSynced = true
+		sm.processHeaderBlocks()
 	}
 }
 
@@ -1150,6 +1532,9 @@ func (sm *SyncManager) limitMap(m map[chainhash.Hash]struct{}, limit int) {
 // important because the sync manager controls which blocks are needed and how
 // the fetching should proceed.
 func (sm *SyncManager) blockHandler() {
+	stallTicker := time.NewTicker(stallSampleInterval)
+	defer stallTicker.Stop()
+
 out:
 	for {
 		select {
@@ -1209,6 +1594,9 @@ out:
 					"handler: %T", msg)
 			}
 
+		case <-stallTicker.C:
+			sm.handleStallSample()
+
 		case <-sm.quit:
 			break out
 		}
@@ -1452,18 +1840,12 @@ func New(config *Config) (*SyncManager, error) {
 		progressLogger:  newBlockProgressLogger("Processed", log),
 		msgChan:         make(chan interface{}, config.MaxPeers*3),
 		headerList:      list.New(),
+		blocksInFlight:  make(map[chainhash.Hash]*list.Element),
 		quit:            make(chan struct{}),
 		feeEstimator:    config.FeeEstimator,
 	}
 
-	best := sm.chain.BestSnapshot()
-	if !config.DisableCheckpoints {
-		// Initialize the next checkpoint based on the current height.
-		sm.nextCheckpoint = sm.findNextHeaderCheckpoint(best.Height)
-		if sm.nextCheckpoint != nil {
-			sm.resetHeaderState(&best.Hash, best.Height)
-		}
-	} else {
+	if config.DisableCheckpoints {
 		log.Info("Checkpoints are disabled")
 	}
 
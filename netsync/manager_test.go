@@ -0,0 +1,596 @@
+// Copyright (c) 2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/blockchain"
+	"github.com/vpubchain/btcd/blockchain/fullblocktests"
+	"github.com/vpubchain/btcd/chaincfg"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/database"
+	_ "github.com/vpubchain/btcd/database/ffldb"
+	"github.com/vpubchain/btcd/mempool"
+	peerpkg "github.com/vpubchain/btcd/peer"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// testPeerNotifier implements the PeerNotifier interface for the tests.  It
+// only records the peers which are banned.
+type testPeerNotifier struct {
+	banned chan *peerpkg.Peer
+}
+
+func (n *testPeerNotifier) AnnounceNewTransactions(newTxs []*mempool.TxDesc) {}
+
+func (n *testPeerNotifier) UpdatePeerHeights(latestBlkHash *chainhash.Hash,
+	latestHeight int32, updateSource *peerpkg.Peer) {
+}
+
+func (n *testPeerNotifier) RelayInventory(invVect *wire.InvVect, data interface{}) {}
+
+func (n *testPeerNotifier) TransactionConfirmed(tx *btcutil.Tx) {}
+
+func (n *testPeerNotifier) BanMisbehavingPeer(peer *peerpkg.Peer, reason string) {
+	n.banned <- peer
+}
+
+var (
+	// testBlocks holds the blocks of the main chain of the full block tests
+	// on the regression test network following the genesis block.
+	testBlocks     []*btcutil.Block
+	testBlocksErr  error
+	testBlocksOnce sync.Once
+)
+
+// chainBlocks returns the blocks of the main chain of the full block tests on
+// the regression test network following the genesis block, which consist of
+// the coinbase transaction only.
+func chainBlocks(t *testing.T) []*btcutil.Block {
+	testBlocksOnce.Do(func() {
+		tests, err := fullblocktests.Generate(false)
+		if err != nil {
+			testBlocksErr = err
+			return
+		}
+		prevHash := chaincfg.RegressionNetParams.GenesisHash
+		for _, instances := range tests {
+			for _, instance := range instances {
+				accepted, ok := instance.(fullblocktests.AcceptedBlock)
+				if !ok || !accepted.IsMainChain {
+					continue
+				}
+				msgBlock := accepted.Block
+				if msgBlock.Header.PrevBlock != *prevHash ||
+					len(msgBlock.Transactions) != 1 {
+
+					return
+				}
+				block := btcutil.NewBlock(msgBlock)
+				testBlocks = append(testBlocks, block)
+				prevHash = block.Hash()
+			}
+		}
+	})
+	if testBlocksErr != nil {
+		t.Fatalf("failed to generate blocks: %v", testBlocksErr)
+	}
+	return testBlocks
+}
+
+// solveHeader increments the nonce of the passed header until its proof of
+// work hash meets the target it claims.
+func solveHeader(header *wire.BlockHeader) {
+	target := blockchain.CompactToBig(header.Bits)
+	for {
+		hash := blockchain.PowHash(header, &chaincfg.RegressionNetParams)
+		if blockchain.HashToBig(&hash).Cmp(target) <= 0 {
+			return
+		}
+		header.Nonce++
+	}
+}
+
+// nextHeaders returns the passed number of headers following the passed one,
+// which do not have blocks.
+func nextHeaders(prev *wire.BlockHeader, numHeaders int) []*wire.BlockHeader {
+	headers := make([]*wire.BlockHeader, 0, numHeaders)
+	for i := 0; i < numHeaders; i++ {
+		header := *prev
+		header.PrevBlock = prev.BlockHash()
+		header.Timestamp = prev.Timestamp.Add(time.Second)
+		solveHeader(&header)
+		headers = append(headers, &header)
+		prev = &header
+	}
+	return headers
+}
+
+// blockHeaders returns the headers of the passed blocks.
+func blockHeaders(blocks []*btcutil.Block) []*wire.BlockHeader {
+	headers := make([]*wire.BlockHeader, 0, len(blocks))
+	for _, block := range blocks {
+		headers = append(headers, &block.MsgBlock().Header)
+	}
+	return headers
+}
+
+// syncManagerSetup returns a sync manager of a new chain on the regression
+// test network which requires the passed minimum chain work, if any, along with
+// the peer notifier it uses and a teardown function which removes the chain
+// database.
+func syncManagerSetup(t *testing.T, minChainWork *big.Int) (*SyncManager, *testPeerNotifier, func()) {
+	DisableLog()
+	dbPath, err := ioutil.TempDir("", "netsync")
+	if err != nil {
+		t.Fatalf("failed to create db dir: %v", err)
+	}
+	db, err := database.Create("ffldb", dbPath, wire.TestNet)
+	if err != nil {
+		os.RemoveAll(dbPath)
+		t.Fatalf("failed to create db: %v", err)
+	}
+	teardown := func() {
+		db.Close()
+		os.RemoveAll(dbPath)
+	}
+
+	params := &chaincfg.RegressionNetParams
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:               db,
+		ChainParams:      params,
+		TimeSource:       blockchain.NewMedianTime(),
+		MinimumChainWork: minChainWork,
+	})
+	if err != nil {
+		teardown()
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	notifier := &testPeerNotifier{banned: make(chan *peerpkg.Peer, 10)}
+	sm, err := New(&Config{
+		PeerNotifier: notifier,
+		Chain:        chain,
+		ChainParams:  params,
+		MaxPeers:     8,
+	})
+	if err != nil {
+		teardown()
+		t.Fatalf("failed to create sync manager: %v", err)
+	}
+	return sm, notifier, teardown
+}
+
+// addPeers adds the passed number of new peers which claim to have blocks up
+// to the passed height to the passed sync manager.  The first peer becomes the
+// sync peer when there is none yet.
+func addPeers(t *testing.T, sm *SyncManager, numPeers int, lastBlock int32) []*peerpkg.Peer {
+	peers := make([]*peerpkg.Peer, 0, numPeers)
+	for i := 0; i < numPeers; i++ {
+		peer, err := peerpkg.NewOutboundPeer(&peerpkg.Config{
+			ChainParams: &chaincfg.RegressionNetParams,
+		}, "127.0.0.1:18444")
+		if err != nil {
+			t.Fatalf("failed to create peer: %v", err)
+		}
+		peer.UpdateLastBlockHeight(lastBlock)
+		sm.handleNewPeerMsg(peer)
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+// sendHeaders has the sync peer of the passed sync manager send the passed
+// headers, which are all of the headers it has.
+func sendHeaders(t *testing.T, sm *SyncManager, headers []*wire.BlockHeader) {
+	msg := wire.NewMsgHeaders()
+	for _, header := range headers {
+		if err := msg.AddBlockHeader(header); err != nil {
+			t.Fatalf("AddBlockHeader: unexpected error: %v", err)
+		}
+	}
+	sm.handleHeadersMsg(&headersMsg{headers: msg, peer: sm.syncPeer})
+}
+
+// sendBlock has the peer the passed block was requested from send it to the
+// passed sync manager.
+func sendBlock(t *testing.T, sm *SyncManager, block *btcutil.Block) {
+	e, exists := sm.blocksInFlight[*block.Hash()]
+	if !exists {
+		t.Fatalf("block %v is not in flight", block.Hash())
+	}
+	peer := e.Value.(*headerNode).peer
+	sm.handleBlockMsg(&blockMsg{block: block, peer: peer})
+}
+
+// disconnected returns whether or not the passed peer has been disconnected.
+func disconnected(peer *peerpkg.Peer) bool {
+	done := make(chan struct{})
+	go func() {
+		peer.WaitForDisconnect()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(time.Millisecond * 100):
+		return false
+	}
+}
+
+// inFlightHeights returns the heights of the headers whose blocks are in flight
+// from the passed peer, or from any peer when it is nil.
+func inFlightHeights(sm *SyncManager, peer *peerpkg.Peer) map[int32]struct{} {
+	heights := make(map[int32]struct{})
+	for e := sm.headerList.Front(); e != nil; e = e.Next() {
+		node := e.Value.(*headerNode)
+		if node.peer == nil || node.block != nil ||
+			(peer != nil && node.peer != peer) {
+
+			continue
+		}
+		heights[node.height] = struct{}{}
+	}
+	return heights
+}
+
+// TestHeaderBlocksInFlightLimit ensures the blocks of the headers are requested
+// from all of the peers which have them, in order and up to the limit of blocks
+// in flight per peer.
+func TestHeaderBlocksInFlightLimit(t *testing.T) {
+	blocks := chainBlocks(t)
+	sm, _, teardown := syncManagerSetup(t, nil)
+	defer teardown()
+
+	// The last peer does not have any of the blocks, so it is not asked for
+	// them.
+	peers := addPeers(t, sm, 2, int32(len(blocks)))
+	peers = append(peers, addPeers(t, sm, 1, 0)...)
+	sendHeaders(t, sm, blockHeaders(blocks))
+
+	for i, peer := range peers {
+		want := maxInFlightBlocksPerPeer
+		if i == 2 {
+			want = 0
+		}
+		if got := len(sm.peerStates[peer].requestedBlocks); got != want {
+			t.Errorf("peer %d: got %d blocks in flight, want %d", i,
+				got, want)
+		}
+	}
+	heights := inFlightHeights(sm, nil)
+	if len(heights) != 2*maxInFlightBlocksPerPeer {
+		t.Fatalf("got %d blocks in flight, want %d", len(heights),
+			2*maxInFlightBlocksPerPeer)
+	}
+	for height := int32(1); height <= 2*maxInFlightBlocksPerPeer; height++ {
+		if _, exists := heights[height]; !exists {
+			t.Errorf("block at height %d is not in flight", height)
+		}
+	}
+}
+
+// TestHeaderBlocksWindow ensures the blocks of the headers are only requested
+// within the download window starting with the next block to be processed, and
+// that blocks arriving out of order are only processed once all of the blocks
+// before them have arrived, which moves the window on.
+func TestHeaderBlocksWindow(t *testing.T) {
+	blocks := chainBlocks(t)
+	sm, _, teardown := syncManagerSetup(t, nil)
+	defer teardown()
+
+	// Provide enough headers and peers to fill more than the window.
+	headers := blockHeaders(blocks)
+	headers = append(headers, nextHeaders(headers[len(headers)-1],
+		blockDownloadWindow)...)
+	numPeers := blockDownloadWindow/maxInFlightBlocksPerPeer + 2
+	addPeers(t, sm, numPeers, int32(len(headers)))
+	sendHeaders(t, sm, headers)
+
+	// checkWindow ensures the blocks of the window starting at the passed
+	// height which have not been received are in flight.
+	checkWindow := func(start int32, received int) {
+		t.Helper()
+		heights := inFlightHeights(sm, nil)
+		if len(heights) != blockDownloadWindow-received {
+			t.Fatalf("got %d blocks in flight, want %d",
+				len(heights), blockDownloadWindow-received)
+		}
+		for height := range heights {
+			if height < start || height >= start+blockDownloadWindow {
+				t.Fatalf("block at height %d outside of the "+
+					"window starting at %d is in flight",
+					height, start)
+			}
+		}
+	}
+	checkWindow(1, 0)
+
+	// The blocks after the first one are held until it arrives.
+	const numBlocks = maxInFlightBlocksPerPeer
+	for _, block := range blocks[1:numBlocks] {
+		sendBlock(t, sm, block)
+	}
+	if height := sm.chain.BestSnapshot().Height; height != 0 {
+		t.Fatalf("got best height %d before the first block arrived",
+			height)
+	}
+	checkWindow(1, numBlocks-1)
+
+	sendBlock(t, sm, blocks[0])
+	if height := sm.chain.BestSnapshot().Height; height != numBlocks {
+		t.Fatalf("got best height %d, want %d", height, numBlocks)
+	}
+	checkWindow(numBlocks+1, 0)
+}
+
+// TestHeaderBlocksStall ensures the peer the next block to be processed is in
+// flight from is dropped once it stalls the download, and that the blocks in
+// flight from it are requested from the other peers instead.
+func TestHeaderBlocksStall(t *testing.T) {
+	blocks := chainBlocks(t)
+	sm, _, teardown := syncManagerSetup(t, nil)
+	defer teardown()
+
+	const numBlocks = maxInFlightBlocksPerPeer + 4
+	addPeers(t, sm, 2, numBlocks)
+	sendHeaders(t, sm, blockHeaders(blocks[:numBlocks]))
+
+	node := sm.headerList.Front().Value.(*headerNode)
+	staller := node.peer
+	if staller == nil {
+		t.Fatal("first block is not in flight")
+	}
+
+	// The peer does not stall the download before the timeout.
+	sm.handleStallSample()
+	if node.peer != staller {
+		t.Fatal("first block was requested again before the timeout")
+	}
+
+	node.requestTime = time.Now().Add(-blockStallTimeout)
+	sm.handleStallSample()
+	if !sm.peerStates[staller].dropped {
+		t.Fatal("stalling peer was not dropped")
+	}
+	if n := len(inFlightHeights(sm, staller)); n != 0 {
+		t.Fatalf("got %d blocks in flight from the stalling peer", n)
+	}
+	if node.peer == nil || node.peer == staller {
+		t.Fatal("first block was not requested from the other peer")
+	}
+	if n := len(inFlightHeights(sm, nil)); n != maxInFlightBlocksPerPeer {
+		t.Fatalf("got %d blocks in flight, want %d", n,
+			maxInFlightBlocksPerPeer)
+	}
+}
+
+// TestHeaderBlocksKnown ensures the blocks of the headers which are known
+// already are not requested and are connected along with their parents.
+func TestHeaderBlocksKnown(t *testing.T) {
+	blocks := chainBlocks(t)
+	sm, _, teardown := syncManagerSetup(t, nil)
+	defer teardown()
+
+	// The second block is an orphan until the first one arrives.
+	_, isOrphan, err := sm.chain.ProcessBlock(blocks[1], blockchain.BFNone)
+	if err != nil || !isOrphan {
+		t.Fatalf("ProcessBlock: got orphan %v, err %v", isOrphan, err)
+	}
+
+	addPeers(t, sm, 1, 3)
+	sendHeaders(t, sm, blockHeaders(blocks[:3]))
+	if _, exists := sm.blocksInFlight[*blocks[1].Hash()]; exists {
+		t.Fatal("known block was requested")
+	}
+
+	sendBlock(t, sm, blocks[0])
+	if height := sm.chain.BestSnapshot().Height; height != 2 {
+		t.Fatalf("got best height %d, want 2", height)
+	}
+	sendBlock(t, sm, blocks[2])
+	if height := sm.chain.BestSnapshot().Height; height != 3 {
+		t.Fatalf("got best height %d, want 3", height)
+	}
+	if sm.headersFirstMode {
+		t.Fatal("still in headers-first mode after the last block")
+	}
+}
+
+// TestHeaderBlocksRejected ensures the peer a block of the headers which is
+// rejected was received from is banned, and that the header is marked invalid
+// and not scheduled anymore unless the block might have been mutated by the
+// peer, in which case it is requested from another peer.
+func TestHeaderBlocksRejected(t *testing.T) {
+	blocks := chainBlocks(t)
+
+	// waitBanned ensures the passed peer is banned.
+	waitBanned := func(notifier *testPeerNotifier, peer *peerpkg.Peer) {
+		t.Helper()
+		select {
+		case banned := <-notifier.banned:
+			if banned != peer {
+				t.Fatal("banned another peer than the sender")
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatal("peer was not banned")
+		}
+	}
+
+	// A block whose transactions do not match its header is requested
+	// again from the other peer.
+	sm, notifier, teardown := syncManagerSetup(t, nil)
+	addPeers(t, sm, 2, 3)
+	sendHeaders(t, sm, blockHeaders(blocks[:3]))
+	node := sm.headerList.Front().Value.(*headerNode)
+	sender := node.peer
+	mutated := *blocks[0].MsgBlock()
+	coinbase := mutated.Transactions[0].Copy()
+	coinbase.LockTime++
+	mutated.Transactions = []*wire.MsgTx{coinbase}
+	sendBlock(t, sm, btcutil.NewBlock(&mutated))
+	waitBanned(notifier, sender)
+	if sm.headerList.Front().Value.(*headerNode) != node {
+		t.Fatal("header of the mutated block is not scheduled")
+	}
+	if node.peer == nil || node.peer == sender {
+		t.Fatal("mutated block was not requested from the other peer")
+	}
+	sendBlock(t, sm, blocks[0])
+	if height := sm.chain.BestSnapshot().Height; height != 1 {
+		t.Fatalf("got best height %d, want 1", height)
+	}
+	teardown()
+
+	// A block which breaks the rules is invalid along with its header and
+	// all of the headers after it.
+	invalid := *blocks[0].MsgBlock()
+	coinbase = invalid.Transactions[0].Copy()
+	coinbase.TxOut[0].Value++
+	invalid.Transactions = []*wire.MsgTx{coinbase}
+	merkles := blockchain.BuildMerkleTreeStore(
+		btcutil.NewBlock(&invalid).Transactions(), false)
+	invalid.Header.MerkleRoot = *merkles[len(merkles)-1]
+	solveHeader(&invalid.Header)
+	headers := []*wire.BlockHeader{&invalid.Header}
+	headers = append(headers, nextHeaders(&invalid.Header, 2)...)
+
+	sm, notifier, teardown = syncManagerSetup(t, nil)
+	defer teardown()
+	addPeers(t, sm, 2, 3)
+	sendHeaders(t, sm, headers)
+	sender = sm.headerList.Front().Value.(*headerNode).peer
+	sendBlock(t, sm, btcutil.NewBlock(&invalid))
+	waitBanned(notifier, sender)
+	if sm.headerList.Len() != 0 || len(sm.blocksInFlight) != 0 {
+		t.Fatal("headers after the invalid block are still scheduled")
+	}
+	if hash, height := sm.chain.BestHeader(); height != 0 {
+		t.Fatalf("got best header %v at height %d after the invalid "+
+			"block", hash, height)
+	}
+}
+
+// TestHeadersPresync ensures the headers of a sync peer are presynced until they
+// have the minimum chain work and are only added to the block index once they
+// have been downloaded again, and that peers whose headers lack the minimum
+// chain work are not synced from.  No checkpoints are involved, so the headers
+// are downloaded up to the tip of the sync peer either way.
+func TestHeadersPresync(t *testing.T) {
+	blocks := chainBlocks(t)
+	headers := blockHeaders(blocks)
+	numHeaders := int64(len(headers))
+	minChainWork := new(big.Int).Mul(blockchain.CalcWork(
+		chaincfg.RegressionNetParams.PowLimitBits), big.NewInt(numHeaders+1))
+
+	// The headers of a peer which lack the minimum chain work are not added
+	// to the block index, and another peer is synced from instead.
+	sm, _, teardown := syncManagerSetup(t, minChainWork)
+	peers := addPeers(t, sm, 2, int32(numHeaders))
+	sendHeaders(t, sm, headers[:numHeaders-1])
+	if _, height := sm.chain.BestHeader(); height != 0 {
+		t.Fatalf("got best header height %d for headers lacking the "+
+			"minimum chain work", height)
+	}
+	if sm.peerStates[peers[0]].syncCandidate || sm.syncPeer != peers[1] {
+		t.Fatal("peer with headers lacking the minimum chain work is " +
+			"still synced from")
+	}
+	if sm.presync != nil || !sm.headersFirstMode {
+		t.Fatal("headers are not synced from the next peer")
+	}
+	teardown()
+
+	// The headers are downloaded again once they have the minimum chain
+	// work, and their blocks are only requested once they have been.
+	sm, _, teardown = syncManagerSetup(t, minChainWork)
+	defer teardown()
+	peers = addPeers(t, sm, 2, int32(numHeaders))
+	sendHeaders(t, sm, headers)
+	if sm.presync == nil || !sm.presync.Redownloading() {
+		t.Fatal("headers with the minimum chain work are not " +
+			"downloaded again")
+	}
+	if *sm.lastHeader != *chaincfg.RegressionNetParams.GenesisHash {
+		t.Fatalf("headers are downloaded again from %v", sm.lastHeader)
+	}
+	if _, height := sm.chain.BestHeader(); height != 0 ||
+		sm.headerList.Len() != 0 {
+
+		t.Fatal("presynced headers were added to the block index")
+	}
+
+	// The sync peer is disconnected when it stops sending the presynced
+	// headers before the target.
+	sendHeaders(t, sm, headers[:numHeaders-1])
+	if !disconnected(peers[0]) {
+		t.Fatal("sync peer stopping before the target is still " +
+			"connected")
+	}
+	sm.handleDonePeerMsg(peers[0])
+	if sm.syncPeer != peers[1] {
+		t.Fatal("headers are not synced from the next peer")
+	}
+
+	sendHeaders(t, sm, headers)
+	sendHeaders(t, sm, headers)
+	if sm.presync != nil {
+		t.Fatal("presync not done after the target")
+	}
+	if _, height := sm.chain.BestHeader(); height != int32(numHeaders) {
+		t.Fatalf("got best header height %d, want %d", height,
+			numHeaders)
+	}
+	if sm.headersSynced || len(sm.blocksInFlight) == 0 {
+		t.Fatal("blocks of the headers are not downloaded while the " +
+			"following headers are")
+	}
+
+	// All of the headers of the sync peer have been downloaded once it
+	// has no more.
+	sendHeaders(t, sm, nil)
+	if !sm.headersSynced {
+		t.Fatal("headers not synced after the last one")
+	}
+}
+
+// TestUnrequestedHeaders ensures headers which were not requested from the sync
+// peer are ignored without disconnecting the peer which sent them.
+func TestUnrequestedHeaders(t *testing.T) {
+	blocks := chainBlocks(t)
+	sm, _, teardown := syncManagerSetup(t, nil)
+	defer teardown()
+
+	peers := addPeers(t, sm, 2, int32(len(blocks)))
+	msg := wire.NewMsgHeaders()
+	if err := msg.AddBlockHeader(&blocks[0].MsgBlock().Header); err != nil {
+		t.Fatalf("AddBlockHeader: unexpected error: %v", err)
+	}
+	sm.handleHeadersMsg(&headersMsg{headers: msg, peer: peers[1]})
+	if _, height := sm.chain.BestHeader(); height != 0 {
+		t.Fatal("unrequested header was added to the block index")
+	}
+	if disconnected(peers[1]) {
+		t.Fatal("peer sending unrequested headers was disconnected")
+	}
+
+	// Headers of the sync peer after all of its headers were downloaded
+	// are ignored as well.
+	sendHeaders(t, sm, blockHeaders(blocks[:1]))
+	if !sm.headersSynced {
+		t.Fatal("headers not synced after the last one")
+	}
+	sm.handleHeadersMsg(&headersMsg{headers: msg, peer: peers[0]})
+	if disconnected(peers[0]) {
+		t.Fatal("sync peer sending unrequested headers was " +
+			"disconnected")
+	}
+}
@@ -55,6 +55,15 @@ var simNetParams = params{
 	rpcPort: "18556",
 }
 
+// sysRegressionNetParams contains parameters specific to the Syscoin
+// regression test network (wire.TestNet).  NOTE: The RPC port is intentionally
+// different than the reference implementation - see the mainNetParams comment
+// for details.
+var sysRegressionNetParams = params{
+	Params:  &chaincfg.SysRegressionNetParams,
+	rpcPort: "18372",
+}
+
 // netName returns the name used when referring to a bitcoin network.  At the
 // time of writing, btcd currently places blocks for testnet version 3 in the
 // data and log directory "testnet", which does not match the Name field of the
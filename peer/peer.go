@@ -186,6 +186,9 @@ type MessageListeners struct {
 	// message.
 	OnMerkleBlock func(p *Peer, msg *wire.MsgMerkleBlock)
 
+	// OnNEVMBlock is invoked when a peer receives a nevmblock message.
+	OnNEVMBlock func(p *Peer, msg *wire.MsgNEVMBlock)
+
 	// OnVersion is invoked when a peer receives a version bitcoin message.
 	// The caller may return a reject message in which case the message will
 	// be sent to the peer and the peer will be disconnected.
@@ -489,11 +492,16 @@ func (p *Peer) String() string {
 	return fmt.Sprintf("%s (%s)", p.addr, directionString(p.inbound))
 }
 
-// UpdateLastBlockHeight updates the last known block for the peer.
+// UpdateLastBlockHeight updates the last known block for the peer.  The height
+// is never lowered since the peer is still known to have the blocks up to it.
 //
 // This function is safe for concurrent access.
 func (p *Peer) UpdateLastBlockHeight(newHeight int32) {
 	p.statsMtx.Lock()
+	if newHeight <= p.lastBlock {
+		p.statsMtx.Unlock()
+		return
+	}
 	log.Tracef("Updating last block height of peer %v from %v to %v",
 		p.addr, p.lastBlock, newHeight)
 	p.lastBlock = newHeight
@@ -1004,10 +1012,21 @@ func (p *Peer) handlePongMsg(msg *wire.MsgPong) {
 	}
 }
 
+// auxPowEncoding returns the passed encoding combined with the auxpow encoding
+// when the chain of the peer supports merged mining, so merged mined headers
+// are exchanged along with their auxpow.
+func (p *Peer) auxPowEncoding(enc wire.MessageEncoding) wire.MessageEncoding {
+	if p.cfg.ChainParams.AuxPowChainID == 0 {
+		return enc
+	}
+	return enc | wire.AuxPowEncoding
+}
+
 // readMessage reads the next bitcoin message from the peer with logging.
 func (p *Peer) readMessage(encoding wire.MessageEncoding) (wire.Message, []byte, error) {
 	n, msg, buf, err := wire.ReadMessageWithEncodingN(p.conn,
-		p.ProtocolVersion(), p.cfg.ChainParams.Net, encoding)
+		p.ProtocolVersion(), p.cfg.ChainParams.Net,
+		p.auxPowEncoding(encoding))
 	atomic.AddUint64(&p.bytesReceived, uint64(n))
 	if p.cfg.Listeners.OnRead != nil {
 		p.cfg.Listeners.OnRead(p, n, msg, err)
@@ -1043,6 +1062,7 @@ func (p *Peer) writeMessage(msg wire.Message, enc wire.MessageEncoding) error {
 	if atomic.LoadInt32(&p.disconnect) != 0 {
 		return nil
 	}
+	enc = p.auxPowEncoding(enc)
 
 	// Use closures to log expensive operations so they are only run when
 	// the logging level requires it.
@@ -1514,6 +1534,11 @@ out:
 				p.cfg.Listeners.OnMerkleBlock(p, msg)
 			}
 
+		case *wire.MsgNEVMBlock:
+			if p.cfg.Listeners.OnNEVMBlock != nil {
+				p.cfg.Listeners.OnNEVMBlock(p, msg)
+			}
+
 		case *wire.MsgReject:
 			if p.cfg.Listeners.OnReject != nil {
 				p.cfg.Listeners.OnReject(p, msg)
@@ -432,6 +432,9 @@ func TestPeerListeners(t *testing.T) {
 			OnMerkleBlock: func(p *peer.Peer, msg *wire.MsgMerkleBlock) {
 				ok <- msg
 			},
+			OnNEVMBlock: func(p *peer.Peer, msg *wire.MsgNEVMBlock) {
+				ok <- msg
+			},
 			OnVersion: func(p *peer.Peer, msg *wire.MsgVersion) *wire.MsgReject {
 				ok <- msg
 				return nil
@@ -584,6 +587,15 @@ func TestPeerListeners(t *testing.T) {
 			wire.NewMsgMerkleBlock(wire.NewBlockHeader(1,
 				&chainhash.Hash{}, &chainhash.Hash{}, 1, 1)),
 		},
+		{
+			"OnNEVMBlock",
+			wire.NewMsgNEVMBlock(&wire.NEVMBlockWire{
+				NEVMBlockHash: make([]byte, wire.HASH_SIZE),
+				TxRoot:        make([]byte, wire.HASH_SIZE),
+				ReceiptRoot:   make([]byte, wire.HASH_SIZE),
+				SYSBlockHash:  make([]byte, wire.HASH_SIZE),
+			}),
+		},
 		// only one version message is allowed
 		// only one verack message is allowed
 		{
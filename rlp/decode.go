@@ -0,0 +1,426 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rlp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// MaxDepth is the maximum nesting of lists decoded into an Item.  It bounds
+// the recursion needed to decode untrusted data.
+const MaxDepth = 64
+
+// Kind identifies the kind of an RLP item.
+type Kind int
+
+// These constants define the kinds of RLP items.
+const (
+	// Byte is a single byte below 0x80 which is its own encoding.
+	Byte Kind = iota
+
+	// String is a byte string preceded by its size.
+	String
+
+	// List is a list of items preceded by the size of their encodings.
+	List
+)
+
+// Map of Kind values back to their names for pretty printing.
+var kindStrings = map[Kind]string{
+	Byte:   "Byte",
+	String: "String",
+	List:   "List",
+}
+
+// String returns the Kind as a human-readable name.
+func (k Kind) String() string {
+	if s := kindStrings[k]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown Kind (%d)", int(k))
+}
+
+// readHeader decodes the header of the next item using the passed function to
+// read its bytes and ensures the size is canonically encoded.  It returns the
+// kind of the item and the size of its content.  The content of a Byte is the
+// header itself, so its size is reported as 1 and the byte is returned.
+func readHeader(readByte func() (byte, error)) (Kind, uint64, byte, error) {
+	b, err := readByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	var kind Kind
+	var size uint64
+	switch {
+	case b < 0x80:
+		return Byte, 1, b, nil
+
+	case b < 0xb8:
+		kind, size = String, uint64(b-0x80)
+		if size == 1 {
+			// A single byte below 0x80 must be encoded as itself.
+			next, err := readByte()
+			if err != nil {
+				return 0, 0, 0, err
+			}
+			if next < 0x80 {
+				str := fmt.Sprintf("single byte %#02x is not "+
+					"encoded as itself", next)
+				return 0, 0, 0, rlpError(ErrNonCanonicalSize, str)
+			}
+			return String, 1, next, nil
+		}
+		return kind, size, 0, nil
+
+	case b < 0xc0:
+		kind, size = String, uint64(b-0xb7)
+
+	case b < 0xf8:
+		return List, uint64(b - 0xc0), 0, nil
+
+	default:
+		kind, size = List, uint64(b-0xf7)
+	}
+
+	// The header holds the big endian size of the content in the number
+	// of bytes computed above.
+	lenOfLen := size
+	size = 0
+	for i := uint64(0); i < lenOfLen; i++ {
+		b, err := readByte()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if i == 0 && b == 0 {
+			str := "item size has leading zero bytes"
+			return 0, 0, 0, rlpError(ErrNonCanonicalSize, str)
+		}
+		size = size<<8 | uint64(b)
+	}
+	if size < 56 {
+		str := fmt.Sprintf("item size %d uses the long form", size)
+		return 0, 0, 0, rlpError(ErrNonCanonicalSize, str)
+	}
+	return kind, size, 0, nil
+}
+
+// Stream decodes RLP items from a reader without reading more than a limit of
+// bytes.  Sizes read from the input are checked against both the limit and the
+// remaining content of the enclosing lists before anything is allocated, so
+// the memory used to decode untrusted input is bounded by the limit.
+//
+// Items are read one at a time: Kind peeks at the next item, Bytes and Uint64
+// consume byte strings, and List and ListEnd enter and leave lists.
+type Stream struct {
+	r io.Reader
+
+	// remaining is the number of bytes that may still be read from r.
+	remaining uint64
+
+	// stack holds the number of content bytes left to read in each open
+	// list, innermost last.
+	stack []uint64
+
+	// These fields cache the header of the next item once it has been
+	// peeked by Kind.
+	peeked  bool
+	kind    Kind
+	size    uint64
+	byteval byte
+}
+
+// NewStream returns a stream which decodes RLP items from the passed reader
+// while reading at most limit bytes from it.
+func NewStream(r io.Reader, limit uint64) *Stream {
+	if _, ok := r.(io.ByteReader); !ok {
+		r = bufio.NewReader(r)
+	}
+	return &Stream{r: r, remaining: limit}
+}
+
+// willRead accounts for n bytes about to be read from the input and ensures
+// they are within the limit and the innermost open list.
+func (s *Stream) willRead(n uint64) error {
+	if len(s.stack) > 0 {
+		top := &s.stack[len(s.stack)-1]
+		if n > *top {
+			str := fmt.Sprintf("element of %d bytes exceeds the %d "+
+				"bytes remaining in its list", n, *top)
+			return rlpError(ErrElemTooLarge, str)
+		}
+		*top -= n
+	}
+	if n > s.remaining {
+		str := fmt.Sprintf("item of %d bytes exceeds the %d bytes "+
+			"remaining in the input", n, s.remaining)
+		return rlpError(ErrSizeLimit, str)
+	}
+	s.remaining -= n
+	return nil
+}
+
+// readByte reads a single byte of the input.
+func (s *Stream) readByte() (byte, error) {
+	if err := s.willRead(1); err != nil {
+		return 0, err
+	}
+	b, err := s.r.(io.ByteReader).ReadByte()
+	if err == io.EOF {
+		str := "unexpected end of RLP data"
+		return 0, rlpError(ErrUnexpectedEnd, str)
+	}
+	return b, err
+}
+
+// readFull reads len(buf) bytes of the input.
+func (s *Stream) readFull(buf []byte) error {
+	if err := s.willRead(uint64(len(buf))); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(s.r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		str := fmt.Sprintf("unexpected end of RLP data reading %d "+
+			"bytes", len(buf))
+		return rlpError(ErrUnexpectedEnd, str)
+	}
+	return err
+}
+
+// Kind returns the kind and content size of the next item without consuming
+// it.  The size is checked against the remaining input and enclosing list, so
+// it is safe to allocate.  ErrEndOfList is returned once every element of the
+// innermost open list has been consumed.
+func (s *Stream) Kind() (Kind, uint64, error) {
+	if s.peeked {
+		return s.kind, s.size, nil
+	}
+	if len(s.stack) > 0 && s.stack[len(s.stack)-1] == 0 {
+		str := "no elements remain in the list"
+		return 0, 0, rlpError(ErrEndOfList, str)
+	}
+
+	kind, size, b, err := readHeader(s.readByte)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The content of a byte and of a single byte string has already been
+	// read along with the header.
+	if kind != Byte && !(kind == String && size == 1) {
+		if len(s.stack) > 0 && size > s.stack[len(s.stack)-1] {
+			str := fmt.Sprintf("element of %d bytes exceeds the "+
+				"%d bytes remaining in its list", size,
+				s.stack[len(s.stack)-1])
+			return 0, 0, rlpError(ErrElemTooLarge, str)
+		}
+		if size > s.remaining {
+			str := fmt.Sprintf("item of %d bytes exceeds the %d "+
+				"bytes remaining in the input", size,
+				s.remaining)
+			return 0, 0, rlpError(ErrSizeLimit, str)
+		}
+	}
+
+	s.peeked, s.kind, s.size, s.byteval = true, kind, size, b
+	return kind, size, nil
+}
+
+// Bytes consumes the next item, which must be a byte string or a single byte,
+// and returns its content.
+func (s *Stream) Bytes() ([]byte, error) {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return nil, err
+	}
+	if kind == List {
+		str := "expected a byte string but found a list"
+		return nil, rlpError(ErrExpectedString, str)
+	}
+	s.peeked = false
+
+	if kind == Byte || size == 1 {
+		return []byte{s.byteval}, nil
+	}
+	content := make([]byte, size)
+	if err := s.readFull(content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// Uint64 consumes the next item, which must be a canonically encoded unsigned
+// integer of at most 64 bits, and returns its value.  Zero is encoded as the
+// empty byte string.
+func (s *Stream) Uint64() (uint64, error) {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return 0, err
+	}
+	if kind == List {
+		str := "expected an integer but found a list"
+		return 0, rlpError(ErrExpectedString, str)
+	}
+	if size > 8 {
+		str := fmt.Sprintf("integer of %d bytes does not fit in 64 "+
+			"bits", size)
+		return 0, rlpError(ErrIntegerTooLarge, str)
+	}
+	content, err := s.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	if len(content) > 0 && content[0] == 0 {
+		str := "integer has leading zero bytes"
+		return 0, rlpError(ErrNonCanonicalInteger, str)
+	}
+
+	var v uint64
+	for _, b := range content {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// List consumes the header of the next item, which must be a list, and enters
+// it so the following calls decode its elements.  It returns the size of the
+// content of the list.  ListEnd must be called once every element has been
+// consumed.
+func (s *Stream) List() (uint64, error) {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return 0, err
+	}
+	if kind != List {
+		str := "expected a list but found a byte string"
+		return 0, rlpError(ErrExpectedList, str)
+	}
+	s.peeked = false
+
+	// The content of the list is accounted for in the enclosing list now
+	// while the input limit is accounted for as it is read.
+	if len(s.stack) > 0 {
+		s.stack[len(s.stack)-1] -= size
+	}
+	s.stack = append(s.stack, size)
+	return size, nil
+}
+
+// ListEnd leaves the innermost open list, which must have had every element
+// consumed.
+func (s *Stream) ListEnd() error {
+	if len(s.stack) == 0 {
+		str := "no list is being decoded"
+		return rlpError(ErrNoOpenList, str)
+	}
+	if left := s.stack[len(s.stack)-1]; left != 0 {
+		str := fmt.Sprintf("%d bytes remain in the list", left)
+		return rlpError(ErrNotAtEndOfList, str)
+	}
+	s.stack = s.stack[:len(s.stack)-1]
+	return nil
+}
+
+// More returns whether elements remain to be consumed in the innermost open
+// list.  It returns false when no list is being decoded.
+func (s *Stream) More() bool {
+	return len(s.stack) > 0 && (s.peeked || s.stack[len(s.stack)-1] > 0)
+}
+
+// Item consumes the next item along with any elements it holds.
+func (s *Stream) Item() (*Item, error) {
+	return s.item(0)
+}
+
+// item consumes the next item which is nested in depth lists.
+func (s *Stream) item(depth int) (*Item, error) {
+	kind, _, err := s.Kind()
+	if err != nil {
+		return nil, err
+	}
+	if kind != List {
+		data, err := s.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		return &Item{Data: data}, nil
+	}
+
+	if depth >= MaxDepth {
+		str := fmt.Sprintf("lists are nested deeper than the max "+
+			"allowed depth of %d", MaxDepth)
+		return nil, rlpError(ErrMaxDepth, str)
+	}
+	if _, err := s.List(); err != nil {
+		return nil, err
+	}
+	item := &Item{IsList: true, List: []*Item{}}
+	for s.More() {
+		elem, err := s.item(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		item.List = append(item.List, elem)
+	}
+	if err := s.ListEnd(); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Decode decodes the passed buffer which must hold exactly one RLP item.
+func Decode(b []byte) (*Item, error) {
+	s := NewStream(bytes.NewReader(b), uint64(len(b)))
+	item, err := s.Item()
+	if err != nil {
+		// The limit of the stream is the end of the buffer.
+		if rerr, ok := err.(Error); ok && rerr.ErrorCode == ErrSizeLimit {
+			rerr.ErrorCode = ErrUnexpectedEnd
+			return nil, rerr
+		}
+		return nil, err
+	}
+	if s.remaining != 0 {
+		str := fmt.Sprintf("%d bytes follow the item", s.remaining)
+		return nil, rlpError(ErrTrailingData, str)
+	}
+	return item, nil
+}
+
+// Split decodes the header of the item at the start of the passed buffer and
+// returns its kind, its content and the bytes following it.  Unlike a Stream,
+// it does not copy the content, which makes it suitable to walk already
+// buffered data in place.
+func Split(b []byte) (Kind, []byte, []byte, error) {
+	var pos int
+	readByte := func() (byte, error) {
+		if pos >= len(b) {
+			str := "unexpected end of RLP data"
+			return 0, rlpError(ErrUnexpectedEnd, str)
+		}
+		pos++
+		return b[pos-1], nil
+	}
+	kind, size, _, err := readHeader(readByte)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// Single bytes and single byte strings were read entirely with their
+	// header.
+	if kind == Byte || (kind == String && size == 1) {
+		return kind, b[pos-1 : pos], b[pos:], nil
+	}
+	if size > uint64(len(b)-pos) {
+		str := fmt.Sprintf("item of %d bytes exceeds the %d remaining "+
+			"bytes", size, len(b)-pos)
+		return 0, nil, nil, rlpError(ErrUnexpectedEnd, str)
+	}
+	end := pos + int(size)
+	return kind, b[pos:end], b[end:], nil
+}
@@ -0,0 +1,312 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rlp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// hexToBytes converts the passed hex string into bytes and will panic if there
+// is an error.  This is only provided for the hard-coded constants so errors in
+// the source code can be detected.  It will only (and must only) be called with
+// hard-coded values.
+func hexToBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex in source file: " + s)
+	}
+	return b
+}
+
+// nestedLists returns an item of depth lists nested in each other.
+func nestedLists(depth int) *Item {
+	item := NewList()
+	for i := 1; i < depth; i++ {
+		item = NewList(item)
+	}
+	return item
+}
+
+// canonicalTests houses canonical encodings along with the items they encode.
+// Most are taken from the RLP tests of the Ethereum reference implementation.
+var canonicalTests = []struct {
+	name string
+	item *Item
+	enc  string
+}{
+	{"empty string", NewString(nil), "80"},
+	{"byte zero", NewString([]byte{0x00}), "00"},
+	{"byte 0x7f", NewString([]byte{0x7f}), "7f"},
+	{"byte 0x80", NewString([]byte{0x80}), "8180"},
+	{"short string", NewString([]byte("dog")), "83646f67"},
+	{
+		"string of 55 bytes",
+		NewString([]byte("Lorem ipsum dolor sit amet, consectetur adipisicing eli")),
+		"b74c6f72656d20697073756d20646f6c6f722073697420616d65742c20636f6e7365637465747572206164697069736963696e6720656c69",
+	},
+	{
+		"string of 56 bytes",
+		NewString([]byte("Lorem ipsum dolor sit amet, consectetur adipisicing elit")),
+		"b8384c6f72656d20697073756d20646f6c6f722073697420616d65742c20636f6e7365637465747572206164697069736963696e6720656c6974",
+	},
+	{
+		"string of 1024 bytes",
+		NewString(bytes.Repeat([]byte{0xaa}, 1024)),
+		"b90400" + strings.Repeat("aa", 1024),
+	},
+	{"empty list", NewList(), "c0"},
+	{
+		"string list",
+		NewList(NewString([]byte("cat")), NewString([]byte("dog"))),
+		"c88363617483646f67",
+	},
+	{
+		"set theoretical representation of three",
+		NewList(NewList(), NewList(NewList()),
+			NewList(NewList(), NewList(NewList()))),
+		"c7c0c1c0c3c0c1c0",
+	},
+	{
+		"long list",
+		NewList(NewString(bytes.Repeat([]byte{0xbb}, 60))),
+		"f83eb83c" + strings.Repeat("bb", 60),
+	},
+}
+
+// TestDecode ensures decoding canonical encodings produces the expected items
+// and that encoding the items again produces the original encodings.
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range canonicalTests {
+		enc := hexToBytes(test.enc)
+		item, err := Decode(enc)
+		if err != nil {
+			t.Errorf("Decode(%s): unexpected error: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(item.Bytes(), test.item.Bytes()) {
+			t.Errorf("Decode(%s): unexpected item - got %x, want %x",
+				test.name, item.Bytes(), test.item.Bytes())
+		}
+		if !bytes.Equal(item.Bytes(), enc) {
+			t.Errorf("Decode(%s): unexpected re-encoding - got %x, "+
+				"want %x", test.name, item.Bytes(), enc)
+		}
+	}
+}
+
+// TestDecodeErrors ensures decoding non-canonical or malformed encodings fails
+// with the expected error codes.
+func TestDecodeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		enc  []byte
+		code ErrorCode
+	}{
+		{"empty input", nil, ErrUnexpectedEnd},
+		{"truncated string", hexToBytes("83646f"), ErrUnexpectedEnd},
+		{"truncated size", hexToBytes("b904"), ErrUnexpectedEnd},
+		{"truncated list", hexToBytes("c883636174"), ErrUnexpectedEnd},
+		{"single byte as string", hexToBytes("8100"), ErrNonCanonicalSize},
+		{"size with leading zero", hexToBytes("b80038"), ErrNonCanonicalSize},
+		{"short size in long form", hexToBytes("b80100"), ErrNonCanonicalSize},
+		{"short list size in long form", hexToBytes("f800"), ErrNonCanonicalSize},
+		{"element overflowing list", hexToBytes("c383646f67"), ErrElemTooLarge},
+		{"trailing data", hexToBytes("8000"), ErrTrailingData},
+		{"nesting too deep", nestedLists(MaxDepth + 1).Bytes(), ErrMaxDepth},
+		{"huge string", hexToBytes("bfffffffffffffffff00"), ErrUnexpectedEnd},
+	}
+
+	for _, test := range tests {
+		_, err := Decode(test.enc)
+		rerr, ok := err.(Error)
+		if !ok {
+			t.Errorf("Decode(%s): did not get expected error %v - "+
+				"got %v", test.name, test.code, err)
+			continue
+		}
+		if rerr.ErrorCode != test.code {
+			t.Errorf("Decode(%s): unexpected error code - got %v, "+
+				"want %v", test.name, rerr.ErrorCode, test.code)
+		}
+	}
+
+	// Nesting up to the max depth is allowed.
+	if _, err := Decode(nestedLists(MaxDepth).Bytes()); err != nil {
+		t.Errorf("Decode(max depth): unexpected error: %v", err)
+	}
+}
+
+// TestStream ensures decoding items one at a time with a stream works as
+// expected including enforcing its limit and list boundaries.
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	// checkCode ensures the passed error is an Error with the passed code.
+	checkCode := func(desc string, err error, code ErrorCode) {
+		t.Helper()
+		rerr, ok := err.(Error)
+		if !ok {
+			t.Errorf("%s: did not get expected error %v - got %v",
+				desc, code, err)
+			return
+		}
+		if rerr.ErrorCode != code {
+			t.Errorf("%s: unexpected error code - got %v, want %v",
+				desc, rerr.ErrorCode, code)
+		}
+	}
+
+	// Decode the list [1024, "dog", []] followed by a byte.
+	enc := hexToBytes("c882040083646f67c07f")
+	s := NewStream(bytes.NewReader(enc), uint64(len(enc)))
+	if kind, size, err := s.Kind(); err != nil || kind != List || size != 8 {
+		t.Fatalf("Kind: unexpected result - got %v %d %v, want List 8",
+			kind, size, err)
+	}
+	_, err := s.Bytes()
+	checkCode("Bytes of list", err, ErrExpectedString)
+	if _, err := s.List(); err != nil {
+		t.Fatalf("List: unexpected error: %v", err)
+	}
+	v, err := s.Uint64()
+	if err != nil || v != 1024 {
+		t.Fatalf("Uint64: unexpected result - got %d %v, want 1024",
+			v, err)
+	}
+	checkCode("ListEnd with elements left", s.ListEnd(), ErrNotAtEndOfList)
+	b, err := s.Bytes()
+	if err != nil || string(b) != "dog" {
+		t.Fatalf("Bytes: unexpected result - got %q %v, want dog", b,
+			err)
+	}
+	_, err = s.Bytes()
+	checkCode("Bytes of inner list", err, ErrExpectedString)
+	if size, err := s.List(); err != nil || size != 0 {
+		t.Fatalf("List: unexpected result - got %d %v, want 0", size,
+			err)
+	}
+	if s.More() {
+		t.Fatal("More: unexpected elements in empty list")
+	}
+	_, _, err = s.Kind()
+	checkCode("Kind past end of list", err, ErrEndOfList)
+	if err := s.ListEnd(); err != nil {
+		t.Fatalf("ListEnd: unexpected error: %v", err)
+	}
+	if s.More() {
+		t.Fatal("More: unexpected elements in outer list")
+	}
+	if err := s.ListEnd(); err != nil {
+		t.Fatalf("ListEnd: unexpected error: %v", err)
+	}
+	checkCode("ListEnd without list", s.ListEnd(), ErrNoOpenList)
+	_, err = s.List()
+	checkCode("List of byte", err, ErrExpectedList)
+	b, err = s.Bytes()
+	if err != nil || !bytes.Equal(b, []byte{0x7f}) {
+		t.Fatalf("Bytes: unexpected result - got %x %v, want 7f", b,
+			err)
+	}
+
+	// Ensure the limit of a stream is enforced both for the content and
+	// the header of items.
+	s = NewStream(bytes.NewReader(hexToBytes("83646f67")), 3)
+	_, _, err = s.Kind()
+	checkCode("Kind beyond limit", err, ErrSizeLimit)
+	s = NewStream(bytes.NewReader(hexToBytes("b90400")), 2)
+	_, _, err = s.Kind()
+	checkCode("Kind header beyond limit", err, ErrSizeLimit)
+
+	// The size of this string claims far more data than the limit so it
+	// must be rejected before the content is allocated.
+	s = NewStream(bytes.NewReader(hexToBytes("bfffffffffffffffff00")), 100)
+	_, err = s.Bytes()
+	checkCode("Bytes of huge string", err, ErrSizeLimit)
+
+	// Ensure integers must be canonically encoded and fit in 64 bits.
+	intTests := []struct {
+		enc  string
+		want uint64
+		code ErrorCode
+	}{
+		{"80", 0, -1},
+		{"0f", 15, -1},
+		{"820400", 1024, -1},
+		{"88ffffffffffffffff", 1<<64 - 1, -1},
+		{"00", 0, ErrNonCanonicalInteger},
+		{"820004", 0, ErrNonCanonicalInteger},
+		{"89010000000000000000", 0, ErrIntegerTooLarge},
+		{"c0", 0, ErrExpectedString},
+	}
+	for _, test := range intTests {
+		enc := hexToBytes(test.enc)
+		s := NewStream(bytes.NewReader(enc), uint64(len(enc)))
+		v, err := s.Uint64()
+		if test.code != -1 {
+			checkCode("Uint64("+test.enc+")", err, test.code)
+			continue
+		}
+		if err != nil || v != test.want {
+			t.Errorf("Uint64(%s): unexpected result - got %d %v, "+
+				"want %d", test.enc, v, err, test.want)
+		}
+	}
+}
+
+// TestSplit ensures splitting items in place returns the expected kinds,
+// contents and remaining data.
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		enc     string
+		kind    Kind
+		content string
+		rest    string
+		wantErr bool
+		code    ErrorCode
+	}{
+		{enc: "7f01", kind: Byte, content: "7f", rest: "01"},
+		{enc: "8180", kind: String, content: "80", rest: ""},
+		{enc: "83646f6701", kind: String, content: "646f67", rest: "01"},
+		{enc: "c0c0", kind: List, content: "", rest: "c0"},
+		{enc: "c88363617483646f67", kind: List,
+			content: "8363617483646f67", rest: ""},
+		{enc: "", wantErr: true, code: ErrUnexpectedEnd},
+		{enc: "83646f", wantErr: true, code: ErrUnexpectedEnd},
+		{enc: "8101", wantErr: true, code: ErrNonCanonicalSize},
+	}
+
+	for _, test := range tests {
+		kind, content, rest, err := Split(hexToBytes(test.enc))
+		if test.wantErr {
+			rerr, ok := err.(Error)
+			if !ok || rerr.ErrorCode != test.code {
+				t.Errorf("Split(%s): unexpected error - got %v, "+
+					"want %v", test.enc, err, test.code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Split(%s): unexpected error: %v", test.enc, err)
+			continue
+		}
+		if kind != test.kind ||
+			hex.EncodeToString(content) != test.content ||
+			hex.EncodeToString(rest) != test.rest {
+
+			t.Errorf("Split(%s): unexpected result - got %v %x %x, "+
+				"want %v %s %s", test.enc, kind, content, rest,
+				test.kind, test.content, test.rest)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package rlp implements the Recursive Length Prefix encoding used by Ethereum and
+the NEVM.
+
+Several Syscoin structures carry RLP encoded data such as the Merkle-Patricia
+proofs of mint transactions and the data of NEVM blocks.  This package allows
+such data to be inspected, validated and pretty-printed.
+
+An RLP item is either a byte string or a list of items.  A single byte below
+0x80 is its own encoding.  Otherwise, the content of an item is preceded by a
+header holding its size, with the size itself held in up to eight big endian
+bytes for content of 56 bytes or more.
+
+Decoding
+
+Only the canonical encoding of an item is accepted.  That is, sizes must be
+encoded in their shortest form, single bytes below 0x80 must be encoded as
+themselves and integers must not have leading zero bytes.
+
+A Stream decodes items one at a time from an io.Reader while reading at most a
+given limit of bytes.  Every size read from the input is checked against the
+limit and the remaining content of the enclosing lists before any memory is
+allocated for it, so decoding untrusted input uses memory bounded by the limit.
+Decode and Stream.Item decode entire items into an Item tree while Split walks
+already buffered data in place without copying it.
+
+Encoding
+
+An Encoder streams items to an io.Writer where lists are written as their
+header followed by their elements.  The Append functions build encodings in a
+buffer instead and Item.Serialize encodes an entire Item tree.
+
+Errors
+
+Errors returned by this package are of type rlp.Error.  This allows the caller
+to differentiate between the kinds of malformed data by examining the ErrorCode
+field.
+*/
+package rlp
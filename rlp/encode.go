@@ -0,0 +1,196 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rlp
+
+import (
+	"io"
+)
+
+// Item is a decoded RLP item which is either a byte string or a list of
+// items.
+type Item struct {
+	// IsList is whether the item is a list as opposed to a byte string.
+	IsList bool
+
+	// Data is the content of a byte string.
+	Data []byte
+
+	// List holds the elements of a list.
+	List []*Item
+}
+
+// NewString returns an item holding the passed byte string.
+func NewString(data []byte) *Item {
+	return &Item{Data: data}
+}
+
+// NewList returns an item holding a list of the passed elements.
+func NewList(elems ...*Item) *Item {
+	if elems == nil {
+		elems = []*Item{}
+	}
+	return &Item{IsList: true, List: elems}
+}
+
+// contentSize returns the number of bytes the content of the item occupies
+// once encoded.
+func (item *Item) contentSize() uint64 {
+	if !item.IsList {
+		return uint64(len(item.Data))
+	}
+	var n uint64
+	for _, elem := range item.List {
+		n += uint64(elem.SerializeSize())
+	}
+	return n
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// item.
+func (item *Item) SerializeSize() int {
+	if !item.IsList && len(item.Data) == 1 && item.Data[0] < 0x80 {
+		return 1
+	}
+	size := item.contentSize()
+	return headerSize(size) + int(size)
+}
+
+// Serialize encodes the item to the passed writer.  The headers of lists are
+// computed from the sizes of their elements, so the encoding is streamed
+// without being assembled in memory.
+func (item *Item) Serialize(w io.Writer) error {
+	e := NewEncoder(w)
+	return e.item(item)
+}
+
+// Bytes returns the encoding of the item.
+func (item *Item) Bytes() []byte {
+	buf := make([]byte, 0, item.SerializeSize())
+	return item.appendTo(buf)
+}
+
+// appendTo appends the encoding of the item to the passed buffer.
+func (item *Item) appendTo(dst []byte) []byte {
+	if !item.IsList {
+		return AppendString(dst, item.Data)
+	}
+	dst = AppendListHeader(dst, item.contentSize())
+	for _, elem := range item.List {
+		dst = elem.appendTo(dst)
+	}
+	return dst
+}
+
+// headerSize returns the number of bytes the header of a byte string or list
+// with content of the passed size occupies.
+func headerSize(size uint64) int {
+	if size < 56 {
+		return 1
+	}
+	n := 1
+	for ; size > 0; size >>= 8 {
+		n++
+	}
+	return n
+}
+
+// appendHeader appends the header of an item with content of the passed size
+// using the passed base of 0x80 for byte strings or 0xc0 for lists.
+func appendHeader(dst []byte, base byte, size uint64) []byte {
+	if size < 56 {
+		return append(dst, base+byte(size))
+	}
+	lenOfLen := headerSize(size) - 1
+	dst = append(dst, base+55+byte(lenOfLen))
+	for i := lenOfLen - 1; i >= 0; i-- {
+		dst = append(dst, byte(size>>(uint(i)*8)))
+	}
+	return dst
+}
+
+// AppendString appends the encoding of the passed byte string to dst.
+func AppendString(dst, data []byte) []byte {
+	if len(data) == 1 && data[0] < 0x80 {
+		return append(dst, data[0])
+	}
+	dst = appendHeader(dst, 0x80, uint64(len(data)))
+	return append(dst, data...)
+}
+
+// AppendUint64 appends the canonical encoding of the passed unsigned integer
+// to dst, which is the byte string of its big endian representation without
+// leading zero bytes.
+func AppendUint64(dst []byte, v uint64) []byte {
+	var buf [8]byte
+	n := 8
+	for ; v > 0; v >>= 8 {
+		n--
+		buf[n] = byte(v)
+	}
+	return AppendString(dst, buf[n:])
+}
+
+// AppendListHeader appends the header of a list whose elements occupy size
+// bytes once encoded to dst.  The encoded elements must follow.
+func AppendListHeader(dst []byte, size uint64) []byte {
+	return appendHeader(dst, 0xc0, size)
+}
+
+// Encoder streams RLP encoded items to a writer.  Lists are written as their
+// header, via ListHeader, followed by their elements.
+type Encoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewEncoder returns an encoder which writes to the passed writer.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, buf: make([]byte, 0, 9)}
+}
+
+// String writes the encoding of the passed byte string.
+func (e *Encoder) String(data []byte) error {
+	if len(data) == 1 && data[0] < 0x80 {
+		_, err := e.w.Write(data)
+		return err
+	}
+	e.buf = appendHeader(e.buf[:0], 0x80, uint64(len(data)))
+	if _, err := e.w.Write(e.buf); err != nil {
+		return err
+	}
+	_, err := e.w.Write(data)
+	return err
+}
+
+// Uint64 writes the canonical encoding of the passed unsigned integer.
+func (e *Encoder) Uint64(v uint64) error {
+	e.buf = AppendUint64(e.buf[:0], v)
+	_, err := e.w.Write(e.buf)
+	return err
+}
+
+// ListHeader writes the header of a list whose elements occupy size bytes once
+// encoded.  The caller must write the elements next.
+func (e *Encoder) ListHeader(size uint64) error {
+	e.buf = AppendListHeader(e.buf[:0], size)
+	_, err := e.w.Write(e.buf)
+	return err
+}
+
+// item writes the encoding of the passed item along with its elements.
+func (e *Encoder) item(item *Item) error {
+	if !item.IsList {
+		return e.String(item.Data)
+	}
+	if err := e.ListHeader(item.contentSize()); err != nil {
+		return err
+	}
+	for _, elem := range item.List {
+		if err := e.item(elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rlp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestItemSerialize ensures items serialize to their canonical encodings
+// whether streamed to a writer or appended to a buffer.
+func TestItemSerialize(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range canonicalTests {
+		want := hexToBytes(test.enc)
+		if size := test.item.SerializeSize(); size != len(want) {
+			t.Errorf("SerializeSize(%s): unexpected size - got %d, "+
+				"want %d", test.name, size, len(want))
+		}
+		if got := test.item.Bytes(); !bytes.Equal(got, want) {
+			t.Errorf("Bytes(%s): unexpected encoding - got %x, "+
+				"want %x", test.name, got, want)
+		}
+		var buf bytes.Buffer
+		if err := test.item.Serialize(&buf); err != nil {
+			t.Errorf("Serialize(%s): unexpected error: %v",
+				test.name, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("Serialize(%s): unexpected encoding - got %x, "+
+				"want %x", test.name, buf.Bytes(), want)
+		}
+	}
+}
+
+// TestEncoder ensures streaming items with an encoder produces the expected
+// encodings.
+func TestEncoder(t *testing.T) {
+	t.Parallel()
+
+	uintTests := []struct {
+		v    uint64
+		want string
+	}{
+		{0, "80"},
+		{1, "01"},
+		{0x7f, "7f"},
+		{0x80, "8180"},
+		{1024, "820400"},
+		{0xffffff, "83ffffff"},
+		{1<<64 - 1, "88ffffffffffffffff"},
+	}
+	for _, test := range uintTests {
+		if got := hex.EncodeToString(AppendUint64(nil, test.v)); got != test.want {
+			t.Errorf("AppendUint64(%d): unexpected encoding - got "+
+				"%s, want %s", test.v, got, test.want)
+		}
+	}
+
+	// Stream the list [1024, "dog", []] by writing its header first.
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	content := uint64(len(AppendUint64(nil, 1024)) +
+		len(AppendString(nil, []byte("dog"))) + 1)
+	if err := e.ListHeader(content); err != nil {
+		t.Fatalf("ListHeader: unexpected error: %v", err)
+	}
+	if err := e.Uint64(1024); err != nil {
+		t.Fatalf("Uint64: unexpected error: %v", err)
+	}
+	if err := e.String([]byte("dog")); err != nil {
+		t.Fatalf("String: unexpected error: %v", err)
+	}
+	if err := e.ListHeader(0); err != nil {
+		t.Fatalf("ListHeader: unexpected error: %v", err)
+	}
+	want := "c882040083646f67c0"
+	if got := hex.EncodeToString(buf.Bytes()); got != want {
+		t.Errorf("Encoder: unexpected encoding - got %s, want %s", got,
+			want)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rlp
+
+import (
+	"fmt"
+)
+
+// ErrorCode identifies a kind of error encountered while decoding RLP data.
+type ErrorCode int
+
+// These constants are used to identify a specific Error.
+const (
+	// ErrUnexpectedEnd indicates the input ends before the end of the
+	// item being decoded.
+	ErrUnexpectedEnd ErrorCode = iota
+
+	// ErrNonCanonicalSize indicates the size of an item is not encoded in
+	// its shortest form or a single byte below 0x80 is not encoded as
+	// itself.
+	ErrNonCanonicalSize
+
+	// ErrNonCanonicalInteger indicates an integer is encoded with leading
+	// zero bytes.
+	ErrNonCanonicalInteger
+
+	// ErrIntegerTooLarge indicates an integer does not fit in 64 bits.
+	ErrIntegerTooLarge
+
+	// ErrSizeLimit indicates an item is larger than the remaining input
+	// the decoder is allowed to read.
+	ErrSizeLimit
+
+	// ErrElemTooLarge indicates an element of a list is larger than the
+	// remaining content of the list.
+	ErrElemTooLarge
+
+	// ErrExpectedString indicates a list was found where a byte string
+	// was expected.
+	ErrExpectedString
+
+	// ErrExpectedList indicates a byte string was found where a list was
+	// expected.
+	ErrExpectedList
+
+	// ErrEndOfList indicates an attempt to decode past the end of the
+	// innermost list.
+	ErrEndOfList
+
+	// ErrNotAtEndOfList indicates the end of a list was requested while
+	// elements remain in it.
+	ErrNotAtEndOfList
+
+	// ErrNoOpenList indicates the end of a list was requested while no
+	// list is being decoded.
+	ErrNoOpenList
+
+	// ErrMaxDepth indicates lists are nested deeper than allowed.
+	ErrMaxDepth
+
+	// ErrTrailingData indicates the input holds data after the item it
+	// was expected to hold.
+	ErrTrailingData
+)
+
+// Map of ErrorCode values back to their constant names for pretty printing.
+var errorCodeStrings = map[ErrorCode]string{
+	ErrUnexpectedEnd:       "ErrUnexpectedEnd",
+	ErrNonCanonicalSize:    "ErrNonCanonicalSize",
+	ErrNonCanonicalInteger: "ErrNonCanonicalInteger",
+	ErrIntegerTooLarge:     "ErrIntegerTooLarge",
+	ErrSizeLimit:           "ErrSizeLimit",
+	ErrElemTooLarge:        "ErrElemTooLarge",
+	ErrExpectedString:      "ErrExpectedString",
+	ErrExpectedList:        "ErrExpectedList",
+	ErrEndOfList:           "ErrEndOfList",
+	ErrNotAtEndOfList:      "ErrNotAtEndOfList",
+	ErrNoOpenList:          "ErrNoOpenList",
+	ErrMaxDepth:            "ErrMaxDepth",
+	ErrTrailingData:        "ErrTrailingData",
+}
+
+// String returns the ErrorCode as a human-readable name.
+func (e ErrorCode) String() string {
+	if s := errorCodeStrings[e]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown ErrorCode (%d)", int(e))
+}
+
+// Error identifies an issue with RLP encoded data.  The caller can use type
+// assertions to determine the specific error by examining the ErrorCode field.
+type Error struct {
+	ErrorCode   ErrorCode // Describes the kind of error
+	Description string    // Human readable description of the issue
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e Error) Error() string {
+	return e.Description
+}
+
+// rlpError creates an Error given a set of arguments.
+func rlpError(c ErrorCode, desc string) Error {
+	return Error{ErrorCode: c, Description: desc}
+}
@@ -235,6 +235,48 @@ func (c *Client) GetCurrentNet() (wire.BitcoinNet, error) {
 	return c.GetCurrentNetAsync().Receive()
 }
 
+// FutureGetUtxoCacheInfoResult is a future promise to deliver the result of a
+// GetUtxoCacheInfoAsync RPC invocation (or an applicable error).
+type FutureGetUtxoCacheInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// statistics of the cache of unspent transaction outputs.
+func (r FutureGetUtxoCacheInfoResult) Receive() (*btcjson.GetUtxoCacheInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getutxocacheinfo result object.
+	var info btcjson.GetUtxoCacheInfoResult
+	err = json.Unmarshal(res, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// GetUtxoCacheInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetUtxoCacheInfo for the blocking version and more details.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) GetUtxoCacheInfoAsync() FutureGetUtxoCacheInfoResult {
+	cmd := btcjson.NewGetUtxoCacheInfoCmd()
+	return c.sendCmd(cmd)
+}
+
+// GetUtxoCacheInfo returns statistics about the cache of unspent transaction
+// outputs.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) GetUtxoCacheInfo() (*btcjson.GetUtxoCacheInfoResult, error) {
+	return c.GetUtxoCacheInfoAsync().Receive()
+}
+
 // FutureGetHeadersResult is a future promise to deliver the result of a
 // getheaders RPC invocation (or an applicable error).
 //
@@ -33,6 +33,7 @@ var (
 // reconnect.
 type notificationState struct {
 	notifyBlocks       bool
+	notifyBurns        bool
 	notifyNewTx        bool
 	notifyNewTxVerbose bool
 	notifyReceived     map[string]struct{}
@@ -43,6 +44,7 @@ type notificationState struct {
 func (s *notificationState) Copy() *notificationState {
 	var stateCopy notificationState
 	stateCopy.notifyBlocks = s.notifyBlocks
+	stateCopy.notifyBurns = s.notifyBurns
 	stateCopy.notifyNewTx = s.notifyNewTx
 	stateCopy.notifyNewTxVerbose = s.notifyNewTxVerbose
 	stateCopy.notifyReceived = make(map[string]struct{})
@@ -121,6 +123,18 @@ type NotificationHandlers struct {
 	// OnBlockDisconnected: it receives the block's height and header.
 	OnFilteredBlockDisconnected func(height int32, header *wire.BlockHeader)
 
+	// OnBurnConnected is invoked when a transaction burning assets to
+	// Ethereum is connected to the longest (best) chain.  It will only be
+	// invoked if a preceding call to NotifyBurns has been made to register
+	// for the notification and the function is non-nil.
+	OnBurnConnected func(burn *btcjson.BurnResult)
+
+	// OnBurnDisconnected is invoked when a transaction burning assets to
+	// Ethereum is disconnected from the longest (best) chain.  It will only
+	// be invoked if a preceding call to NotifyBurns has been made to
+	// register for the notification and the function is non-nil.
+	OnBurnDisconnected func(burn *btcjson.BurnResult)
+
 	// OnRecvTx is invoked when a transaction that receives funds to a
 	// registered address is received into the memory pool and also
 	// connected to the longest (best) chain.  It will only be invoked if a
@@ -290,6 +304,40 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 		c.ntfnHandlers.OnFilteredBlockDisconnected(blockHeight,
 			blockHeader)
 
+	// OnBurnConnected
+	case btcjson.BurnConnectedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnBurnConnected == nil {
+			return
+		}
+
+		burn, err := parseBurnNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid burn connected "+
+				"notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnBurnConnected(burn)
+
+	// OnBurnDisconnected
+	case btcjson.BurnDisconnectedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnBurnDisconnected == nil {
+			return
+		}
+
+		burn, err := parseBurnNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid burn disconnected "+
+				"notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnBurnDisconnected(burn)
+
 	// OnRecvTx
 	case btcjson.RecvTxNtfnMethod:
 		// Ignore the notification if the client is not interested in
@@ -615,6 +663,25 @@ func parseFilteredBlockDisconnectedParams(params []json.RawMessage) (int32,
 	return blockHeight, &blockHeader, nil
 }
 
+// parseBurnNtfnParams parses out the burn from the parameters of a
+// burnconnected or burndisconnected notification.
+//
+// NOTE: This is a btcd extension and requires a websocket connection.
+func parseBurnNtfnParams(params []json.RawMessage) (*btcjson.BurnResult, error) {
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a burn result object.
+	var burn btcjson.BurnResult
+	err := json.Unmarshal(params[0], &burn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &burn, nil
+}
+
 func parseHexParam(param json.RawMessage) ([]byte, error) {
 	var s string
 	err := json.Unmarshal(param, &s)
@@ -902,6 +969,55 @@ func (c *Client) NotifyBlocks() error {
 	return c.NotifyBlocksAsync().Receive()
 }
 
+// FutureNotifyBurnsResult is a future promise to deliver the result of a
+// NotifyBurnsAsync RPC invocation (or an applicable error).
+type FutureNotifyBurnsResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the registration was not successful.
+func (r FutureNotifyBurnsResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// NotifyBurnsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See NotifyBurns for the blocking version and more details.
+//
+// NOTE: This is a btcd extension and requires a websocket connection.
+func (c *Client) NotifyBurnsAsync() FutureNotifyBurnsResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := btcjson.NewNotifyBurnsCmd()
+	return c.sendCmd(cmd)
+}
+
+// NotifyBurns registers the client to receive notifications when transactions
+// burning assets to Ethereum are connected to and disconnected from the main
+// chain.  The notifications are delivered to the notification handlers
+// associated with the client.  Calling this function has no effect if there
+// are no notification handlers and will result in an error if the client is
+// configured to run in HTTP POST mode.
+//
+// The notifications delivered as a result of this call will be via one of
+// OnBurnConnected or OnBurnDisconnected.
+//
+// NOTE: This is a btcd extension and requires a websocket connection.
+func (c *Client) NotifyBurns() error {
+	return c.NotifyBurnsAsync().Receive()
+}
+
 // FutureNotifySpentResult is a future promise to deliver the result of a
 // NotifySpentAsync RPC invocation (or an applicable error).
 //
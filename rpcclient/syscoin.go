@@ -0,0 +1,385 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/vpubchain/btcd/btcjson"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+	"github.com/vpubchain/btcd/wire"
+)
+
+// FutureGetAssetAllocationBalanceResult is a future promise to deliver the
+// result of a GetAssetAllocationBalanceAsync RPC invocation (or an applicable
+// error).
+type FutureGetAssetAllocationBalanceResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// amount of the requested asset held by the requested address.
+func (r FutureGetAssetAllocationBalanceResult) Receive() (*btcjson.AssetAllocationBalanceResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an asset allocation balance result object.
+	var balance btcjson.AssetAllocationBalanceResult
+	err = json.Unmarshal(res, &balance)
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// GetAssetAllocationBalanceAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See GetAssetAllocationBalance for the blocking version and more details.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) GetAssetAllocationBalanceAsync(address btcutil.Address, assetGuid uint64) FutureGetAssetAllocationBalanceResult {
+	cmd := btcjson.NewGetAssetAllocationBalanceCmd(address.EncodeAddress(),
+		assetGuid)
+	return c.sendCmd(cmd)
+}
+
+// GetAssetAllocationBalance returns the amount of the asset identified by the
+// passed guid which is held by the passed address.
+//
+// NOTE: This is a btcd extension and requires the server to run with the
+// asset allocation index enabled.
+func (c *Client) GetAssetAllocationBalance(address btcutil.Address, assetGuid uint64) (*btcjson.AssetAllocationBalanceResult, error) {
+	return c.GetAssetAllocationBalanceAsync(address, assetGuid).Receive()
+}
+
+// FutureGetAuxBlockResult is a future promise to deliver the result of a
+// GetAuxBlockAsync RPC invocation (or an applicable error).
+type FutureGetAuxBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns the block
+// to merged mine.
+func (r FutureGetAuxBlockResult) Receive() (*btcjson.GetAuxBlockResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an aux block result object.
+	var auxBlock btcjson.GetAuxBlockResult
+	err = json.Unmarshal(res, &auxBlock)
+	if err != nil {
+		return nil, err
+	}
+	return &auxBlock, nil
+}
+
+// GetAuxBlockAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetAuxBlock for the blocking version and more details.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) GetAuxBlockAsync() FutureGetAuxBlockResult {
+	cmd := btcjson.NewGetAuxBlockCmd(nil, nil)
+	return c.sendCmd(cmd)
+}
+
+// GetAuxBlock returns a new block to merged mine.  Its auxpow is submitted
+// with SubmitAuxBlock.
+//
+// NOTE: This is a btcd extension and requires the server to be configured with
+// mining addresses.
+func (c *Client) GetAuxBlock() (*btcjson.GetAuxBlockResult, error) {
+	return c.GetAuxBlockAsync().Receive()
+}
+
+// FutureGetAuxFeeResult is a future promise to deliver the result of a
+// GetAuxFeeAsync RPC invocation (or an applicable error).
+type FutureGetAuxFeeResult chan *response
+
+// Receive waits for the response promised by the future and returns the aux
+// fee the requested asset requires for sending the requested amount.
+func (r FutureGetAuxFeeResult) Receive() (*btcjson.GetAuxFeeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an aux fee result object.
+	var auxFee btcjson.GetAuxFeeResult
+	err = json.Unmarshal(res, &auxFee)
+	if err != nil {
+		return nil, err
+	}
+	return &auxFee, nil
+}
+
+// GetAuxFeeAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetAuxFee for the blocking version and more details.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) GetAuxFeeAsync(assetGuid uint64, amount int64) FutureGetAuxFeeResult {
+	cmd := btcjson.NewGetAuxFeeCmd(assetGuid, amount)
+	return c.sendCmd(cmd)
+}
+
+// GetAuxFee returns the aux fee the asset identified by the passed guid
+// requires for sending the passed amount of it along with the recipient the
+// fee must be paid to.
+//
+// NOTE: This is a btcd extension and requires the server to run with the
+// asset index enabled.
+func (c *Client) GetAuxFee(assetGuid uint64, amount int64) (*btcjson.GetAuxFeeResult, error) {
+	return c.GetAuxFeeAsync(assetGuid, amount).Receive()
+}
+
+// FutureListAssetAllocationsResult is a future promise to deliver the result
+// of a ListAssetAllocationsAsync or ListAssetAllocationsVerboseAsync RPC
+// invocation (or an applicable error).
+type FutureListAssetAllocationsResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// balances of every asset held by the requested address.
+func (r FutureListAssetAllocationsResult) Receive() ([]btcjson.AssetAllocationBalanceResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of asset allocation balance result
+	// objects.
+	var balances []btcjson.AssetAllocationBalanceResult
+	err = json.Unmarshal(res, &balances)
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// ListAssetAllocationsAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ListAssetAllocations for the blocking version and more details.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) ListAssetAllocationsAsync(address btcutil.Address) FutureListAssetAllocationsResult {
+	cmd := btcjson.NewListAssetAllocationsCmd(address.EncodeAddress(),
+		btcjson.Bool(false))
+	return c.sendCmd(cmd)
+}
+
+// ListAssetAllocations returns the balances of every asset held by the passed
+// address.
+//
+// See ListAssetAllocationsVerbose to also retrieve the unspent outputs which
+// carry each asset.
+//
+// NOTE: This is a btcd extension and requires the server to run with the
+// asset allocation index enabled.
+func (c *Client) ListAssetAllocations(address btcutil.Address) ([]btcjson.AssetAllocationBalanceResult, error) {
+	return c.ListAssetAllocationsAsync(address).Receive()
+}
+
+// ListAssetAllocationsVerboseAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See ListAssetAllocationsVerbose for the blocking version and more details.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) ListAssetAllocationsVerboseAsync(address btcutil.Address) FutureListAssetAllocationsResult {
+	cmd := btcjson.NewListAssetAllocationsCmd(address.EncodeAddress(),
+		btcjson.Bool(true))
+	return c.sendCmd(cmd)
+}
+
+// ListAssetAllocationsVerbose returns the balances of every asset held by the
+// passed address along with the unspent outputs which carry each asset.
+//
+// NOTE: This is a btcd extension and requires the server to run with the
+// asset allocation index enabled.
+func (c *Client) ListAssetAllocationsVerbose(address btcutil.Address) ([]btcjson.AssetAllocationBalanceResult, error) {
+	return c.ListAssetAllocationsVerboseAsync(address).Receive()
+}
+
+// FutureGetNEVMBlockResult is a future promise to deliver the result of a
+// GetNEVMBlockAsync RPC invocation (or an applicable error).
+type FutureGetNEVMBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns the NEVM
+// block connected to the requested block.
+func (r FutureGetNEVMBlockResult) Receive() (*wire.NEVMBlockWire, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a string.
+	var nevmBlockHex string
+	err = json.Unmarshal(res, &nevmBlockHex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode the serialized NEVM block hex to raw bytes.
+	serializedNEVMBlock, err := hex.DecodeString(nevmBlockHex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deserialize the NEVM block and return it.
+	var nevmBlock wire.NEVMBlockWire
+	err = nevmBlock.Deserialize(bytes.NewReader(serializedNEVMBlock))
+	if err != nil {
+		return nil, err
+	}
+	return &nevmBlock, nil
+}
+
+// GetNEVMBlockAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetNEVMBlock for the blocking version and more details.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) GetNEVMBlockAsync(blockHash *chainhash.Hash) FutureGetNEVMBlockResult {
+	hash := ""
+	if blockHash != nil {
+		hash = blockHash.String()
+	}
+
+	cmd := btcjson.NewGetNEVMBlockCmd(hash, btcjson.Bool(false))
+	return c.sendCmd(cmd)
+}
+
+// GetNEVMBlock returns the NEVM block connected to the block with the passed
+// hash.  The data of the returned NEVM block is empty when it has been pruned.
+//
+// See GetNEVMBlockVerbose to retrieve a data structure with information about
+// the NEVM block instead.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) GetNEVMBlock(blockHash *chainhash.Hash) (*wire.NEVMBlockWire, error) {
+	return c.GetNEVMBlockAsync(blockHash).Receive()
+}
+
+// FutureGetNEVMBlockVerboseResult is a future promise to deliver the result of
+// a GetNEVMBlockVerboseAsync RPC invocation (or an applicable error).
+type FutureGetNEVMBlockVerboseResult chan *response
+
+// Receive waits for the response promised by the future and returns a data
+// structure from the server with information about the NEVM block connected to
+// the requested block.
+func (r FutureGetNEVMBlockVerboseResult) Receive() (*btcjson.GetNEVMBlockResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the raw result into a GetNEVMBlockResult.
+	var nevmBlockResult btcjson.GetNEVMBlockResult
+	err = json.Unmarshal(res, &nevmBlockResult)
+	if err != nil {
+		return nil, err
+	}
+	return &nevmBlockResult, nil
+}
+
+// GetNEVMBlockVerboseAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetNEVMBlockVerbose for the blocking version and more details.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) GetNEVMBlockVerboseAsync(blockHash *chainhash.Hash) FutureGetNEVMBlockVerboseResult {
+	hash := ""
+	if blockHash != nil {
+		hash = blockHash.String()
+	}
+
+	cmd := btcjson.NewGetNEVMBlockCmd(hash, btcjson.Bool(true))
+	return c.sendCmd(cmd)
+}
+
+// GetNEVMBlockVerbose returns a data structure from the server with
+// information about the NEVM block connected to the block with the passed
+// hash, including the decoded fields of its header unless its data has been
+// pruned.
+//
+// See GetNEVMBlock to retrieve the NEVM block itself instead.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) GetNEVMBlockVerbose(blockHash *chainhash.Hash) (*btcjson.GetNEVMBlockResult, error) {
+	return c.GetNEVMBlockVerboseAsync(blockHash).Receive()
+}
+
+// FutureSubmitAuxBlockResult is a future promise to deliver the result of a
+// SubmitAuxBlockAsync RPC invocation (or an applicable error).
+type FutureSubmitAuxBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns whether
+// the merged mined block was accepted.
+func (r FutureSubmitAuxBlockResult) Receive() (bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return false, err
+	}
+
+	// Unmarshal result as a boolean.
+	var accepted bool
+	err = json.Unmarshal(res, &accepted)
+	if err != nil {
+		return false, err
+	}
+	return accepted, nil
+}
+
+// SubmitAuxBlockAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See SubmitAuxBlock for the blocking version and more details.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) SubmitAuxBlockAsync(blockHash *chainhash.Hash, auxPow *wire.AuxPow) FutureSubmitAuxBlockResult {
+	hash := ""
+	if blockHash != nil {
+		hash = blockHash.String()
+	}
+
+	auxPowHex := ""
+	if auxPow != nil {
+		// Serialize the auxpow and convert to hex string.
+		buf := bytes.NewBuffer(make([]byte, 0, auxPow.SerializeSize()))
+		if err := auxPow.Serialize(buf); err != nil {
+			return newFutureError(err)
+		}
+		auxPowHex = hex.EncodeToString(buf.Bytes())
+	}
+
+	cmd := btcjson.NewSubmitAuxBlockCmd(hash, auxPowHex)
+	return c.sendCmd(cmd)
+}
+
+// SubmitAuxBlock submits the passed auxpow for the block with the passed hash
+// previously returned by GetAuxBlock and returns whether the block was
+// accepted.
+//
+// NOTE: This is a btcd extension.
+func (c *Client) SubmitAuxBlock(blockHash *chainhash.Hash, auxPow *wire.AuxPow) (bool, error) {
+	return c.SubmitAuxBlockAsync(blockHash, auxPow).Receive()
+}
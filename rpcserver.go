@@ -6,6 +6,7 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/sha256"
 	"crypto/subtle"
@@ -21,6 +22,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -39,7 +41,9 @@ import (
 	"github.com/vpubchain/btcd/mempool"
 	"github.com/vpubchain/btcd/mining"
 	"github.com/vpubchain/btcd/mining/cpuminer"
+	"github.com/vpubchain/btcd/mpt"
 	"github.com/vpubchain/btcd/peer"
+	"github.com/vpubchain/btcd/rlp"
 	"github.com/vpubchain/btcd/txscript"
 	"github.com/vpubchain/btcd/wire"
 )
@@ -101,6 +105,14 @@ var (
 	// declared here to avoid the overhead of creating the slice on every
 	// invocation for constant data.
 	gbtCapabilities = []string{"proposal"}
+
+	// gbtPowAlgorithms maps the proof of work hash functions which differ
+	// from the double SHA-256 of the block header to the names reported
+	// for them by the getblocktemplate RPC.
+	gbtPowAlgorithms = map[chaincfg.PowHashAlgorithm]string{
+		chaincfg.PowHashScrypt: "scrypt",
+		chaincfg.PowHashCustom: "custom",
+	}
 )
 
 // Errors
@@ -127,52 +139,68 @@ type commandHandler func(*rpcServer, interface{}, <-chan struct{}) (interface{},
 // a dependency loop.
 var rpcHandlers map[string]commandHandler
 var rpcHandlersBeforeInit = map[string]commandHandler{
-	"addnode":               handleAddNode,
-	"createrawtransaction":  handleCreateRawTransaction,
-	"debuglevel":            handleDebugLevel,
-	"decoderawtransaction":  handleDecodeRawTransaction,
-	"decodescript":          handleDecodeScript,
-	"estimatefee":           handleEstimateFee,
-	"generate":              handleGenerate,
-	"getaddednodeinfo":      handleGetAddedNodeInfo,
-	"getbestblock":          handleGetBestBlock,
-	"getbestblockhash":      handleGetBestBlockHash,
-	"getblock":              handleGetBlock,
-	"getblockchaininfo":     handleGetBlockChainInfo,
-	"getblockcount":         handleGetBlockCount,
-	"getblockhash":          handleGetBlockHash,
-	"getblockheader":        handleGetBlockHeader,
-	"getblocktemplate":      handleGetBlockTemplate,
-	"getcfilter":            handleGetCFilter,
-	"getcfilterheader":      handleGetCFilterHeader,
-	"getconnectioncount":    handleGetConnectionCount,
-	"getcurrentnet":         handleGetCurrentNet,
-	"getdifficulty":         handleGetDifficulty,
-	"getgenerate":           handleGetGenerate,
-	"gethashespersec":       handleGetHashesPerSec,
-	"getheaders":            handleGetHeaders,
-	"getinfo":               handleGetInfo,
-	"getmempoolinfo":        handleGetMempoolInfo,
-	"getmininginfo":         handleGetMiningInfo,
-	"getnettotals":          handleGetNetTotals,
-	"getnetworkhashps":      handleGetNetworkHashPS,
-	"getpeerinfo":           handleGetPeerInfo,
-	"getrawmempool":         handleGetRawMempool,
-	"getrawtransaction":     handleGetRawTransaction,
-	"gettxout":              handleGetTxOut,
-	"help":                  handleHelp,
-	"node":                  handleNode,
-	"ping":                  handlePing,
-	"searchrawtransactions": handleSearchRawTransactions,
-	"sendrawtransaction":    handleSendRawTransaction,
-	"setgenerate":           handleSetGenerate,
-	"stop":                  handleStop,
-	"submitblock":           handleSubmitBlock,
-	"uptime":                handleUptime,
-	"validateaddress":       handleValidateAddress,
-	"verifychain":           handleVerifyChain,
-	"verifymessage":         handleVerifyMessage,
-	"version":               handleVersion,
+	"addnode":                   handleAddNode,
+	"createrawtransaction":      handleCreateRawTransaction,
+	"debuglevel":                handleDebugLevel,
+	"decoderawtransaction":      handleDecodeRawTransaction,
+	"decodescript":              handleDecodeScript,
+	"dumptxoutset":              handleDumpTxOutSet,
+	"estimatefee":               handleEstimateFee,
+	"generate":                  handleGenerate,
+	"getaddednodeinfo":          handleGetAddedNodeInfo,
+	"getasset":                  handleGetAsset,
+	"getassetallocationbalance": handleGetAssetAllocationBalance,
+	"getauxblock":               handleGetAuxBlock,
+	"getauxfee":                 handleGetAuxFee,
+	"getbestblock":              handleGetBestBlock,
+	"getbestblockhash":          handleGetBestBlockHash,
+	"getblock":                  handleGetBlock,
+	"getblockchaininfo":         handleGetBlockChainInfo,
+	"getblockcount":             handleGetBlockCount,
+	"getblockhash":              handleGetBlockHash,
+	"getblockheader":            handleGetBlockHeader,
+	"getblocktemplate":          handleGetBlockTemplate,
+	"getcfilter":                handleGetCFilter,
+	"getcfilterheader":          handleGetCFilterHeader,
+	"getchaintips":              handleGetChainTips,
+	"getconnectioncount":        handleGetConnectionCount,
+	"getcurrentnet":             handleGetCurrentNet,
+	"getdifficulty":             handleGetDifficulty,
+	"getgenerate":               handleGetGenerate,
+	"gethashespersec":           handleGetHashesPerSec,
+	"getheaders":                handleGetHeaders,
+	"getinfo":                   handleGetInfo,
+	"getmempoolinfo":            handleGetMempoolInfo,
+	"getmininginfo":             handleGetMiningInfo,
+	"getnettotals":              handleGetNetTotals,
+	"getnetworkhashps":          handleGetNetworkHashPS,
+	"getnevmblock":              handleGetNEVMBlock,
+	"getpeerinfo":               handleGetPeerInfo,
+	"getrawmempool":             handleGetRawMempool,
+	"getrawtransaction":         handleGetRawTransaction,
+	"gettxout":                  handleGetTxOut,
+	"gettxoutsetinfo":           handleGetTxOutSetInfo,
+	"getutxocacheinfo":          handleGetUtxoCacheInfo,
+	"help":                      handleHelp,
+	"invalidateblock":           handleInvalidateBlock,
+	"listassetallocations":      handleListAssetAllocations,
+	"listassets":                handleListAssets,
+	"node":                      handleNode,
+	"ping":                      handlePing,
+	"preciousblock":             handlePreciousBlock,
+	"reconsiderblock":           handleReconsiderBlock,
+	"searchrawtransactions":     handleSearchRawTransactions,
+	"sendrawtransaction":        handleSendRawTransaction,
+	"setgenerate":               handleSetGenerate,
+	"stop":                      handleStop,
+	"submitauxblock":            handleSubmitAuxBlock,
+	"submitblock":               handleSubmitBlock,
+	"uptime":                    handleUptime,
+	"validateaddress":           handleValidateAddress,
+	"verifychain":               handleVerifyChain,
+	"verifymessage":             handleVerifyMessage,
+	"verifymintproof":           handleVerifyMintProof,
+	"version":                   handleVersion,
 }
 
 // list of commands that we recognize, but for which btcd has no support because
@@ -195,7 +223,6 @@ var rpcAskWallet = map[string]struct{}{
 	"getreceivedbyaccount":   {},
 	"getreceivedbyaddress":   {},
 	"gettransaction":         {},
-	"gettxoutsetinfo":        {},
 	"getunconfirmedbalance":  {},
 	"getwalletinfo":          {},
 	"importprivkey":          {},
@@ -226,13 +253,9 @@ var rpcAskWallet = map[string]struct{}{
 // Commands that are currently unimplemented, but should ultimately be.
 var rpcUnimplemented = map[string]struct{}{
 	"estimatepriority": {},
-	"getchaintips":     {},
 	"getmempoolentry":  {},
 	"getnetworkinfo":   {},
 	"getwork":          {},
-	"invalidateblock":  {},
-	"preciousblock":    {},
-	"reconsiderblock":  {},
 }
 
 // Commands that are available to a limited user
@@ -251,34 +274,45 @@ var rpcLimited = map[string]struct{}{
 	"help": {},
 
 	// HTTP/S-only commands
-	"createrawtransaction":  {},
-	"decoderawtransaction":  {},
-	"decodescript":          {},
-	"estimatefee":           {},
-	"getbestblock":          {},
-	"getbestblockhash":      {},
-	"getblock":              {},
-	"getblockcount":         {},
-	"getblockhash":          {},
-	"getblockheader":        {},
-	"getcfilter":            {},
-	"getcfilterheader":      {},
-	"getcurrentnet":         {},
-	"getdifficulty":         {},
-	"getheaders":            {},
-	"getinfo":               {},
-	"getnettotals":          {},
-	"getnetworkhashps":      {},
-	"getrawmempool":         {},
-	"getrawtransaction":     {},
-	"gettxout":              {},
-	"searchrawtransactions": {},
-	"sendrawtransaction":    {},
-	"submitblock":           {},
-	"uptime":                {},
-	"validateaddress":       {},
-	"verifymessage":         {},
-	"version":               {},
+	"createrawtransaction":      {},
+	"decoderawtransaction":      {},
+	"decodescript":              {},
+	"estimatefee":               {},
+	"getasset":                  {},
+	"getassetallocationbalance": {},
+	"getauxfee":                 {},
+	"getbestblock":              {},
+	"getbestblockhash":          {},
+	"getblock":                  {},
+	"getblockcount":             {},
+	"getblockhash":              {},
+	"getblockheader":            {},
+	"getcfilter":                {},
+	"getcfilterheader":          {},
+	"getchaintips":              {},
+	"getcurrentnet":             {},
+	"getdifficulty":             {},
+	"getheaders":                {},
+	"getinfo":                   {},
+	"getnettotals":              {},
+	"getnetworkhashps":          {},
+	"getnevmblock":              {},
+	"getrawmempool":             {},
+	"getrawtransaction":         {},
+	"gettxout":                  {},
+	"gettxoutsetinfo":           {},
+	"getutxocacheinfo":          {},
+	"listassetallocations":      {},
+	"listassets":                {},
+	"searchrawtransactions":     {},
+	"sendrawtransaction":        {},
+	"submitauxblock":            {},
+	"submitblock":               {},
+	"uptime":                    {},
+	"validateaddress":           {},
+	"verifymessage":             {},
+	"verifymintproof":           {},
+	"version":                   {},
 }
 
 // builderScript is a convenience function which is used for hard-coded scripts
@@ -335,14 +369,36 @@ type gbtWorkState struct {
 	template      *mining.BlockTemplate
 	notifyMap     map[chainhash.Hash]map[int64]chan struct{}
 	timeSource    blockchain.MedianTimeSource
+	powAlgorithm  string
 }
 
 // newGbtWorkState returns a new instance of a gbtWorkState with all internal
 // fields initialized and ready to use.
-func newGbtWorkState(timeSource blockchain.MedianTimeSource) *gbtWorkState {
+func newGbtWorkState(timeSource blockchain.MedianTimeSource, params *chaincfg.Params) *gbtWorkState {
 	return &gbtWorkState{
-		notifyMap:  make(map[chainhash.Hash]map[int64]chan struct{}),
-		timeSource: timeSource,
+		notifyMap:    make(map[chainhash.Hash]map[int64]chan struct{}),
+		timeSource:   timeSource,
+		powAlgorithm: gbtPowAlgorithms[params.PowHashAlgorithm],
+	}
+}
+
+// auxBlockState houses the blocks handed out to merged mining pools by the
+// getauxblock RPC, so the auxpow of any of them can be submitted until the
+// best chain changes.
+type auxBlockState struct {
+	sync.Mutex
+	lastTxUpdate  time.Time
+	lastGenerated time.Time
+	prevHash      *chainhash.Hash
+	template      *mining.BlockTemplate
+	blocks        map[chainhash.Hash]*wire.MsgBlock
+}
+
+// newAuxBlockState returns a new instance of an auxBlockState with all
+// internal fields initialized and ready to use.
+func newAuxBlockState() *auxBlockState {
+	return &auxBlockState{
+		blocks: make(map[chainhash.Hash]*wire.MsgBlock),
 	}
 }
 
@@ -739,6 +795,104 @@ func createVoutList(mtx *wire.MsgTx, chainParams *chaincfg.Params, filterAddrMap
 	return voutList
 }
 
+// syscoinTxTypes maps the Syscoin transaction versions to the names used to
+// describe them in RPC results.
+var syscoinTxTypes = map[int32]string{
+	wire.SyscoinTxVersionAllocationBurnToSyscoin:  "assetallocationburntosyscoin",
+	wire.SyscoinTxVersionSyscoinBurnToAllocation:  "syscoinburntoassetallocation",
+	wire.SyscoinTxVersionAssetActivate:            "assetactivate",
+	wire.SyscoinTxVersionAssetUpdate:              "assetupdate",
+	wire.SyscoinTxVersionAssetSend:                "assetsend",
+	wire.SyscoinTxVersionAllocationMint:           "assetallocationmint",
+	wire.SyscoinTxVersionAllocationBurnToEthereum: "assetallocationburntoethereum",
+	wire.SyscoinTxVersionAllocationSend:           "assetallocationsend",
+}
+
+// rlpToJSON returns the passed RLP encoded data as nested arrays of
+// hex-encoded byte strings suitable for JSON.  Data which is not a single
+// canonically encoded item is returned hex-encoded as is.
+func rlpToJSON(data []byte) interface{} {
+	item, err := rlp.Decode(data)
+	if err != nil {
+		return hex.EncodeToString(data)
+	}
+
+	var convert func(item *rlp.Item) interface{}
+	convert = func(item *rlp.Item) interface{} {
+		if !item.IsList {
+			return hex.EncodeToString(item.Data)
+		}
+		elems := make([]interface{}, 0, len(item.List))
+		for _, elem := range item.List {
+			elems = append(elems, convert(elem))
+		}
+		return elems
+	}
+	return convert(item)
+}
+
+// createSyscoinTxResult returns the decoded payload of the passed transaction
+// for inclusion in RPC results.  It returns nil when the transaction is not a
+// Syscoin transaction or its payload can not be decoded.
+func createSyscoinTxResult(mtx *wire.MsgTx) *btcjson.SyscoinTxResult {
+	if !wire.IsSyscoinTx(mtx.Version) {
+		return nil
+	}
+	payload, err := mtx.SyscoinPayload()
+	if err != nil {
+		return nil
+	}
+
+	allocation := payload.AssetAllocation()
+	result := &btcjson.SyscoinTxResult{
+		TxType: syscoinTxTypes[mtx.Version],
+		Allocations: make([]btcjson.AssetOutResult, 0,
+			len(allocation.VoutAssets)),
+	}
+	for _, voutAsset := range allocation.VoutAssets {
+		values := make([]btcjson.AssetOutValueResult, 0,
+			len(voutAsset.Values))
+		for _, value := range voutAsset.Values {
+			values = append(values, btcjson.AssetOutValueResult{
+				N:     value.N,
+				Value: value.ValueSat,
+			})
+		}
+		result.Allocations = append(result.Allocations,
+			btcjson.AssetOutResult{
+				AssetGuid: voutAsset.AssetGuid,
+				Values:    values,
+				NotarySig: hex.EncodeToString(voutAsset.NotarySig),
+			})
+	}
+
+	switch p := payload.(type) {
+	case *wire.AssetType:
+		if len(p.NotaryKeyID) > 0 {
+			result.NotaryKeyID = hex.EncodeToString(p.NotaryKeyID)
+			result.NotaryDetails = notaryDetailsResult(
+				&p.NotaryDetails)
+		}
+
+	case *wire.SyscoinBurnToEthereumType:
+		result.EthAddress = hex.EncodeToString(p.EthAddress)
+
+	case *wire.MintSyscoinType:
+		result.Mint = &btcjson.MintResult{
+			EthTxID:            hex.EncodeToString(p.TxHash),
+			EthBlockHash:       hex.EncodeToString(p.BlockHash),
+			TxPos:              p.TxPos,
+			TxParentNodes:      rlpToJSON(p.TxParentNodes),
+			TxPath:             hex.EncodeToString(p.TxPath),
+			TxRoot:             hex.EncodeToString(p.TxRoot),
+			ReceiptPos:         p.ReceiptPos,
+			ReceiptParentNodes: rlpToJSON(p.ReceiptParentNodes),
+			ReceiptRoot:        hex.EncodeToString(p.ReceiptRoot),
+		}
+	}
+	return result
+}
+
 // createTxRawResult converts the passed transaction and associated parameters
 // to a raw transaction JSON object.
 func createTxRawResult(chainParams *chaincfg.Params, mtx *wire.MsgTx,
@@ -802,6 +956,7 @@ func handleDecodeRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan
 		Locktime: mtx.LockTime,
 		Vin:      createVinList(&mtx),
 		Vout:     createVoutList(&mtx, s.cfg.ChainParams, nil),
+		Syscoin:  createSyscoinTxResult(&mtx),
 	}
 	return txReply, nil
 }
@@ -854,6 +1009,57 @@ func handleDecodeScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 	return reply, nil
 }
 
+// handleDumpTxOutSet handles dumptxoutset commands.
+func handleDumpTxOutSet(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.DumpTxOutSetCmd)
+
+	// Relative paths are relative to the data directory.  Existing files
+	// are never overwritten.
+	path := c.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cfg.DataDir, path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("File %s already exists", path),
+		}
+	}
+
+	// Write the snapshot to a temporary file first so an incomplete
+	// snapshot is never left at the path.
+	tempPath := path + ".incomplete"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return nil, internalRPCError(err.Error(),
+			"Unable to create snapshot file")
+	}
+	w := bufio.NewWriter(file)
+	info, err := s.cfg.Chain.DumpUTXOSnapshot(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempPath, path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return nil, internalRPCError(err.Error(),
+			"Unable to write snapshot file")
+	}
+
+	return &btcjson.DumpTxOutSetResult{
+		CoinsWritten: info.NumUTXOs,
+		BaseHash:     info.BlockHash.String(),
+		BaseHeight:   info.Height,
+		Path:         path,
+		TxOutSetHash: info.UTXOHash.String(),
+	}, nil
+}
+
 // handleEstimateFee handles estimatefee commands.
 func handleEstimateFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.EstimateFeeCmd)
@@ -1020,6 +1226,347 @@ func handleGetAddedNodeInfo(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return results, nil
 }
 
+// notaryDetailsResult returns the JSON-RPC representation of the passed notary
+// details.
+func notaryDetailsResult(details *wire.NotaryDetailsType) *btcjson.NotaryDetailsResult {
+	return &btcjson.NotaryDetailsResult{
+		EndPoint:         details.EndPoint,
+		InstantTransfers: details.InstantTransfers != 0,
+		HDRequired:       details.HDRequired != 0,
+	}
+}
+
+// assetResult returns the JSON-RPC representation of the passed asset state.
+func assetResult(guid uint64, asset *wire.AssetType) *btcjson.GetAssetResult {
+	result := &btcjson.GetAssetResult{
+		AssetGuid:             guid,
+		Symbol:                string(asset.Symbol),
+		PubData:               string(asset.PubData),
+		Contract:              hex.EncodeToString(asset.Contract),
+		NotaryKeyID:           hex.EncodeToString(asset.NotaryKeyID),
+		TotalSupply:           asset.TotalSupply,
+		MaxSupply:             asset.MaxSupply,
+		Precision:             asset.Precision,
+		UpdateCapabilityFlags: asset.UpdateCapabilityFlags,
+	}
+	if len(asset.NotaryKeyID) > 0 {
+		result.NotaryDetails = notaryDetailsResult(&asset.NotaryDetails)
+	}
+	if len(asset.AuxFeeDetails.AuxFees) > 0 {
+		auxFees := make([]btcjson.AuxFeeResult, 0,
+			len(asset.AuxFeeDetails.AuxFees))
+		for _, auxFee := range asset.AuxFeeDetails.AuxFees {
+			auxFees = append(auxFees, btcjson.AuxFeeResult{
+				Bound:   auxFee.Bound,
+				Percent: auxFee.Percent,
+			})
+		}
+		result.AuxFeeDetails = &btcjson.AuxFeeDetailsResult{
+			AuxFeeKeyID: hex.EncodeToString(
+				asset.AuxFeeDetails.AuxFeeKeyID),
+			AuxFees: auxFees,
+		}
+	}
+	return result
+}
+
+// handleGetAsset implements the getasset command.
+func handleGetAsset(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.AssetIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCNoAssetIndex,
+			Message: "The asset index must be enabled (specify --assetindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetAssetCmd)
+	asset, err := s.cfg.AssetIndex.Asset(c.AssetGuid)
+	if err != nil {
+		context := "Failed to fetch asset"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if asset == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCAssetNotFound,
+			Message: fmt.Sprintf("Asset %d not found", c.AssetGuid),
+		}
+	}
+
+	return assetResult(c.AssetGuid, asset), nil
+}
+
+// handleGetAssetAllocationBalance implements the getassetallocationbalance
+// command.
+func handleGetAssetAllocationBalance(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.AssetAllocIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCNoAssetAllocIndex,
+			Message: "The asset allocation index must be enabled " +
+				"(specify --assetallocindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetAssetAllocationBalanceCmd)
+	addr, err := btcutil.DecodeAddress(c.Address, s.cfg.ChainParams)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+
+	balance, err := s.cfg.AssetAllocIndex.Balance(addr, c.AssetGuid)
+	if err != nil {
+		context := "Failed to fetch asset allocation balance"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &btcjson.AssetAllocationBalanceResult{
+		Address:   c.Address,
+		AssetGuid: balance.AssetGuid,
+		Balance:   balance.Balance,
+		NumUtxos:  balance.NumUtxos,
+	}, nil
+}
+
+// updateAuxBlock generates a new block to merged mine when the current best
+// block has changed or the transactions in the memory pool have been updated
+// and it has been at least gbtRegenerateSeconds since the last block was
+// generated.  The generated block pays to a random mining address and carries
+// the merged mining bit in its version.
+//
+// This function MUST be called with the state locked.
+func (state *auxBlockState) updateAuxBlock(s *rpcServer) error {
+	generator := s.cfg.Generator
+	lastTxUpdate := generator.TxSource().LastUpdated()
+	if lastTxUpdate.IsZero() {
+		lastTxUpdate = time.Now()
+	}
+
+	latestHash := &s.cfg.Chain.BestSnapshot().Hash
+	if state.template != nil && state.prevHash != nil &&
+		state.prevHash.IsEqual(latestHash) &&
+		(state.lastTxUpdate == lastTxUpdate ||
+			time.Now().Before(state.lastGenerated.Add(time.Second*
+				gbtRegenerateSeconds))) {
+
+		return nil
+	}
+
+	// Blocks building on a previous best block can no longer be submitted.
+	if state.prevHash == nil || !state.prevHash.IsEqual(latestHash) {
+		state.blocks = make(map[chainhash.Hash]*wire.MsgBlock)
+	}
+
+	payAddr := cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))]
+	template, err := generator.NewBlockTemplate(payAddr)
+	if err != nil {
+		return internalRPCError("Failed to create new block "+
+			"template: "+err.Error(), "")
+	}
+	template.Block.Header.Version |= wire.AuxPowVersionBit
+	state.blocks[template.Block.BlockHash()] = template.Block
+
+	state.template = template
+	state.lastGenerated = time.Now()
+	state.lastTxUpdate = lastTxUpdate
+	state.prevHash = latestHash
+
+	rpcsLog.Debugf("Generated merged mining block %v (target %064x)",
+		template.Block.BlockHash(),
+		blockchain.CompactToBig(template.Block.Header.Bits))
+	return nil
+}
+
+// submitAuxBlock attaches the passed hex-encoded auxpow to the block with the
+// passed hash previously handed out by the getauxblock RPC and processes the
+// block.  It returns whether the block was accepted.
+func submitAuxBlock(s *rpcServer, hashStr, auxPowHex string) (bool, error) {
+	hash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return false, rpcDecodeHexError(hashStr)
+	}
+
+	state := s.auxBlockState
+	state.Lock()
+	msgBlock, ok := state.blocks[*hash]
+	state.Unlock()
+	if !ok {
+		return false, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block hash unknown or stale: " + hashStr,
+		}
+	}
+
+	if len(auxPowHex)%2 != 0 {
+		auxPowHex = "0" + auxPowHex
+	}
+	serializedAuxPow, err := hex.DecodeString(auxPowHex)
+	if err != nil {
+		return false, rpcDecodeHexError(auxPowHex)
+	}
+	var auxPow wire.AuxPow
+	err = auxPow.Deserialize(bytes.NewReader(serializedAuxPow))
+	if err != nil {
+		return false, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Auxpow decode failed: " + err.Error(),
+		}
+	}
+
+	// Attach the auxpow to a copy of the block since the same block may be
+	// submitted again with another auxpow.
+	block := *msgBlock
+	block.Header.AuxPow = &auxPow
+	_, err = s.cfg.SyncMgr.SubmitBlock(btcutil.NewBlock(&block),
+		blockchain.BFNone)
+	if err != nil {
+		rpcsLog.Infof("Rejected merged mined block %v: %v", hash, err)
+		return false, nil
+	}
+
+	rpcsLog.Infof("Accepted merged mined block %v", hash)
+	return true, nil
+}
+
+// blockEncoding returns the encoding of the serialized blocks exchanged over
+// RPC.  Merged mined blocks are serialized along with their auxpow on chains
+// which support merged mining, as they are on the wire.
+func (s *rpcServer) blockEncoding() wire.MessageEncoding {
+	if s.cfg.ChainParams.AuxPowChainID == 0 {
+		return wire.WitnessEncoding
+	}
+	return wire.WitnessEncoding | wire.AuxPowEncoding
+}
+
+// handleGetAuxBlock implements the getauxblock command.
+func handleGetAuxBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetAuxBlockCmd)
+
+	if s.cfg.ChainParams.AuxPowChainID == 0 {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: "Merged mining is not supported on " +
+				s.cfg.ChainParams.Name,
+		}
+	}
+
+	// Submit the auxpow of a previously handed out block when both the
+	// block hash and the auxpow are passed.
+	if c.Hash != nil || c.AuxPow != nil {
+		if c.Hash == nil || c.AuxPow == nil {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: "Both the block hash and the auxpow " +
+					"are required to submit a block",
+			}
+		}
+		return submitAuxBlock(s, *c.Hash, *c.AuxPow)
+	}
+
+	// Respond with an error if there are no addresses to pay the
+	// created blocks to.
+	if len(cfg.miningAddrs) == 0 {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInternal.Code,
+			Message: "No payment addresses specified " +
+				"via --miningaddr",
+		}
+	}
+
+	// Return an error if there are no peers connected since there is no
+	// way to relay a found block or receive transactions to work on.
+	// However, allow this state when running in the regression test mode.
+	if !cfg.SysRegressionTest && s.cfg.ConnMgr.ConnectedCount() == 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCClientNotConnected,
+			Message: "Syscoin is not connected",
+		}
+	}
+
+	// No point in generating work before the chain is synced.
+	currentHeight := s.cfg.Chain.BestSnapshot().Height
+	if currentHeight != 0 && !s.cfg.SyncMgr.IsCurrent() {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCClientInInitialDownload,
+			Message: "Syscoin is downloading blocks...",
+		}
+	}
+
+	state := s.auxBlockState
+	state.Lock()
+	defer state.Unlock()
+	if err := state.updateAuxBlock(s); err != nil {
+		return nil, err
+	}
+
+	// The target is encoded in little-endian byte order as expected by
+	// merged mining software.
+	template := state.template
+	header := &template.Block.Header
+	target := blockchain.CompactToBig(header.Bits).Bytes()
+	targetLE := make([]byte, chainhash.HashSize)
+	for i := range target {
+		targetLE[i] = target[len(target)-1-i]
+	}
+
+	return &btcjson.GetAuxBlockResult{
+		Hash:              header.BlockHash().String(),
+		ChainID:           header.ChainID(),
+		PreviousBlockHash: header.PrevBlock.String(),
+		CoinbaseValue:     template.Block.Transactions[0].TxOut[0].Value,
+		Bits:              strconv.FormatInt(int64(header.Bits), 16),
+		Height:            int64(template.Height),
+		Target:            hex.EncodeToString(targetLE),
+	}, nil
+}
+
+// handleGetAuxFee implements the getauxfee command.
+func handleGetAuxFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.AssetIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCNoAssetIndex,
+			Message: "The asset index must be enabled (specify --assetindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetAuxFeeCmd)
+	if c.Amount < 0 || c.Amount > blockchain.MaxAssetValue {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Amount must be between 0 and %d",
+				int64(blockchain.MaxAssetValue)),
+		}
+	}
+	asset, err := s.cfg.AssetIndex.Asset(c.AssetGuid)
+	if err != nil {
+		context := "Failed to fetch asset"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if asset == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCAssetNotFound,
+			Message: fmt.Sprintf("Asset %d not found", c.AssetGuid),
+		}
+	}
+
+	details := &asset.AuxFeeDetails
+	result := &btcjson.GetAuxFeeResult{
+		AssetGuid: c.AssetGuid,
+		Amount:    c.Amount,
+		AuxFee:    blockchain.CalcAuxFee(details, c.Amount),
+	}
+	if len(details.AuxFees) > 0 {
+		result.AuxFeeKeyID = hex.EncodeToString(details.AuxFeeKeyID)
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(
+			details.AuxFeeKeyID, s.cfg.ChainParams)
+		if err == nil {
+			result.AuxFeeAddress = addr.EncodeAddress()
+		}
+	}
+	return result, nil
+}
+
 // handleGetBestBlock implements the getbestblock command.
 func handleGetBestBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// All other "get block" commands give either the height, the
@@ -1081,6 +1628,32 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 		}
 	}
 
+	// Deserialize the block.
+	var msgBlock wire.MsgBlock
+	err = msgBlock.Deserialize(bytes.NewReader(blkBytes))
+	if err != nil {
+		context := "Failed to deserialize block"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// Merged mined blocks are stored without their auxpow, so load it and
+	// serialize the block along with it.
+	err = s.cfg.Chain.FetchAuxPow(&msgBlock.Header)
+	if err != nil {
+		context := "Failed to load auxpow"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if msgBlock.Header.AuxPow != nil {
+		var buf bytes.Buffer
+		buf.Grow(msgBlock.SerializeSize())
+		err := msgBlock.BtcEncode(&buf, 0, s.blockEncoding())
+		if err != nil {
+			context := "Failed to serialize block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		blkBytes = buf.Bytes()
+	}
+
 	// When the verbose flag isn't set, simply return the serialized block
 	// as a hex-encoded string.
 	if c.Verbose != nil && !*c.Verbose {
@@ -1088,13 +1661,7 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 	}
 
 	// The verbose flag is set, so generate the JSON object and return it.
-
-	// Deserialize the block.
-	blk, err := btcutil.NewBlockFromBytes(blkBytes)
-	if err != nil {
-		context := "Failed to deserialize block"
-		return nil, internalRPCError(err.Error(), context)
-	}
+	blk := btcutil.NewBlock(&msgBlock)
 
 	// Get the block height from chain.
 	blockHeight, err := s.cfg.Chain.BlockHeightByHash(hash)
@@ -1188,17 +1755,21 @@ func handleGetBlockChainInfo(s *rpcServer, cmd interface{}, closeChan <-chan str
 	params := s.cfg.ChainParams
 	chain := s.cfg.Chain
 	chainSnapshot := chain.BestSnapshot()
+	_, bestHeaderHeight := chain.BestHeader()
 
 	chainInfo := &btcjson.GetBlockChainInfoResult{
 		Chain:         params.Name,
 		Blocks:        chainSnapshot.Height,
-		Headers:       chainSnapshot.Height,
+		Headers:       bestHeaderHeight,
 		BestBlockHash: chainSnapshot.Hash.String(),
 		Difficulty:    getDifficultyRatio(chainSnapshot.Bits, params),
 		MedianTime:    chainSnapshot.MedianTime.Unix(),
-		Pruned:        false,
+		Pruned:        cfg.Prune != 0,
 		Bip9SoftForks: make(map[string]*btcjson.Bip9SoftForkDescription),
 	}
+	if chainInfo.Pruned {
+		chainInfo.PruneHeight = chain.PruneHeight()
+	}
 
 	// Next, populate the response with information describing the current
 	// status of soft-forks deployed via the super-majority block
@@ -1749,6 +2320,7 @@ func (state *gbtWorkState) blockTemplateResult(useCoinbaseValue bool, submitOld
 		LongPollID:   templateID,
 		SubmitOld:    submitOld,
 		Target:       targetDifficulty,
+		PowAlgorithm: state.powAlgorithm,
 		MinTime:      state.minTimestamp.Unix(),
 		MaxTime:      maxTime.Unix(),
 		Mutable:      gbtMutableFields,
@@ -1938,7 +2510,7 @@ func handleGetBlockTemplateRequest(s *rpcServer, request *btcjson.TemplateReques
 	// way to relay a found block or receive transactions to work on.
 	// However, allow this state when running in the regression test or
 	// simulation test mode.
-	if !(cfg.RegressionTest || cfg.SimNet) &&
+	if !(cfg.RegressionTest || cfg.SimNet || cfg.SysRegressionTest) &&
 		s.cfg.ConnMgr.ConnectedCount() == 0 {
 
 		return nil, &btcjson.RPCError{
@@ -2112,7 +2684,9 @@ func handleGetBlockTemplateProposal(s *rpcServer, request *btcjson.TemplateReque
 		}
 	}
 	var msgBlock wire.MsgBlock
-	if err := msgBlock.Deserialize(bytes.NewReader(dataBytes)); err != nil {
+	err = msgBlock.BtcDecode(bytes.NewReader(dataBytes), 0,
+		s.blockEncoding())
+	if err != nil {
 		return nil, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCDeserialization,
 			Message: "Block decode failed: " + err.Error(),
@@ -2231,6 +2805,44 @@ func handleGetCFilterHeader(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return hash.String(), nil
 }
 
+// chainTipStatus returns the status string of the passed chain tip status for
+// the getchaintips command.
+func chainTipStatus(status blockchain.ChainTipStatus) (string, error) {
+	switch status {
+	case blockchain.ChainTipActive:
+		return "active", nil
+	case blockchain.ChainTipValidFork:
+		return "valid-fork", nil
+	case blockchain.ChainTipValidHeaders:
+		return "valid-headers", nil
+	case blockchain.ChainTipHeadersOnly:
+		return "headers-only", nil
+	case blockchain.ChainTipInvalid:
+		return "invalid", nil
+	default:
+		return "", fmt.Errorf("unknown chain tip status: %v", status)
+	}
+}
+
+// handleGetChainTips implements the getchaintips command.
+func handleGetChainTips(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	tips := s.cfg.Chain.ChainTips()
+	results := make([]btcjson.GetChainTipsResult, 0, len(tips))
+	for _, tip := range tips {
+		status, err := chainTipStatus(tip.Status)
+		if err != nil {
+			return nil, internalRPCError(err.Error(), "")
+		}
+		results = append(results, btcjson.GetChainTipsResult{
+			Height:    tip.Height,
+			Hash:      tip.Hash.String(),
+			BranchLen: tip.BranchLen,
+			Status:    status,
+		})
+	}
+	return results, nil
+}
+
 // handleGetConnectionCount implements the getconnectioncount command.
 func handleGetConnectionCount(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	return s.cfg.ConnMgr.ConnectedCount(), nil
@@ -2281,7 +2893,11 @@ func handleGetHeaders(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 			return nil, rpcDecodeHexError(c.HashStop)
 		}
 	}
-	headers := s.cfg.SyncMgr.LocateHeaders(blockLocators, &hashStop)
+	headers, err := s.cfg.SyncMgr.LocateHeaders(blockLocators, &hashStop)
+	if err != nil {
+		return nil, internalRPCError(err.Error(),
+			"Failed to locate block headers")
+	}
 
 	// Return the serialized block headers as hex-encoded strings.
 	hexBlockHeaders := make([]string, len(headers))
@@ -2475,6 +3091,58 @@ func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return hashesPerSec.Int64(), nil
 }
 
+// handleGetNEVMBlock implements the getnevmblock command.
+func handleGetNEVMBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetNEVMBlockCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.BlockHash)
+	}
+	nevmBlock, err := s.cfg.Chain.FetchNEVMBlock(hash)
+	if err != nil {
+		context := "Failed to fetch NEVM block"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if nevmBlock == nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCNEVMBlockNotFound,
+			Message: fmt.Sprintf("No NEVM block found for block %v",
+				hash),
+		}
+	}
+
+	// When the verbose flag isn't set, simply return the serialized NEVM
+	// block as a hex-encoded string.
+	if c.Verbose != nil && !*c.Verbose {
+		var buf bytes.Buffer
+		if err := nevmBlock.Serialize(&buf); err != nil {
+			context := "Failed to serialize NEVM block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		return hex.EncodeToString(buf.Bytes()), nil
+	}
+
+	result := &btcjson.GetNEVMBlockResult{
+		NEVMBlockHash: hex.EncodeToString(nevmBlock.NEVMBlockHash),
+		TxRoot:        hex.EncodeToString(nevmBlock.TxRoot),
+		ReceiptRoot:   hex.EncodeToString(nevmBlock.ReceiptRoot),
+		SYSBlockHash:  hash.String(),
+		Pruned:        len(nevmBlock.NEVMBlockData) == 0,
+		DataSize:      len(nevmBlock.NEVMBlockData),
+	}
+
+	// The header is the first element of the list that makes up the NEVM
+	// block.
+	_, content, _, err := rlp.Split(nevmBlock.NEVMBlockData)
+	if err == nil {
+		if _, _, rest, err := rlp.Split(content); err == nil {
+			result.Header = rlpToJSON(content[:len(content)-len(rest)])
+		}
+	}
+	return result, nil
+}
+
 // handleGetPeerInfo implements the getpeerinfo command.
 func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	peers := s.cfg.ConnMgr.ConnectedPeers()
@@ -2759,6 +3427,60 @@ func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 	return txOutReply, nil
 }
 
+// handleGetTxOutSetInfo handles gettxoutsetinfo commands.
+func handleGetTxOutSetInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetTxOutSetInfoCmd)
+
+	// Default to the statistics of the utxo set as of the current best
+	// block.
+	best := s.cfg.Chain.BestSnapshot()
+	height := best.Height
+	if c.Height != nil {
+		height = *c.Height
+	}
+	if height < 0 || height > best.Height {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCOutOfRange,
+			Message: "Block number out of range",
+		}
+	}
+
+	stats, err := s.cfg.Chain.UtxoSetStats(height)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+
+	return &btcjson.GetTxOutSetInfoResult{
+		Height:       stats.Height,
+		BestBlock:    stats.BlockHash.String(),
+		Transactions: stats.TotalTxns,
+		TxOuts:       stats.NumUtxos,
+		Size:         stats.Size,
+		MuHash:       stats.Hash.String(),
+		TotalAmount:  stats.TotalAmount.ToBTC(),
+	}, nil
+}
+
+// handleGetUtxoCacheInfo implements the getutxocacheinfo command.
+func handleGetUtxoCacheInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	stats := s.cfg.Chain.UtxoCacheStats()
+	return &btcjson.GetUtxoCacheInfoResult{
+		Entries:         stats.Entries,
+		DirtyEntries:    stats.DirtyEntries,
+		Size:            stats.Size,
+		MaxSize:         stats.MaxSize,
+		Hits:            stats.Hits,
+		Misses:          stats.Misses,
+		Flushes:         stats.Flushes,
+		LastFlushHash:   stats.LastFlushHash.String(),
+		LastFlushHeight: stats.LastFlushHeight,
+		LastFlushTime:   stats.LastFlushTime.Unix(),
+	}, nil
+}
+
 // handleHelp implements the help command.
 func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.HelpCmd)
@@ -2798,6 +3520,132 @@ func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return help, nil
 }
 
+// updateBlockStatus applies the passed block chain operation, such as
+// invalidating a block, to the block with the passed hash.  It is shared by the
+// handlers of the commands which manually change the status of blocks.
+func updateBlockStatus(s *rpcServer, blockHash string, update func(*chainhash.Hash) error) (interface{}, error) {
+	hash, err := chainhash.NewHashFromStr(blockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(blockHash)
+	}
+	if _, err := s.cfg.Chain.HeaderByHash(hash); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+
+	if err := update(hash); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDatabase,
+			Message: err.Error(),
+		}
+	}
+	return nil, nil
+}
+
+// handleInvalidateBlock implements the invalidateblock command.
+func handleInvalidateBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.InvalidateBlockCmd)
+	return updateBlockStatus(s, c.BlockHash, s.cfg.Chain.InvalidateBlock)
+}
+
+// handleListAssetAllocations implements the listassetallocations command.
+func handleListAssetAllocations(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.AssetAllocIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCNoAssetAllocIndex,
+			Message: "The asset allocation index must be enabled " +
+				"(specify --assetallocindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.ListAssetAllocationsCmd)
+	addr, err := btcutil.DecodeAddress(c.Address, s.cfg.ChainParams)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+
+	balances, err := s.cfg.AssetAllocIndex.Balances(addr)
+	if err != nil {
+		context := "Failed to list asset allocations"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	results := make([]btcjson.AssetAllocationBalanceResult, 0, len(balances))
+	for _, balance := range balances {
+		result := btcjson.AssetAllocationBalanceResult{
+			Address:   c.Address,
+			AssetGuid: balance.AssetGuid,
+			Balance:   balance.Balance,
+			NumUtxos:  balance.NumUtxos,
+		}
+
+		// Include the individual unspent outputs when requested.
+		if c.Verbose != nil && *c.Verbose {
+			utxos, err := s.cfg.AssetAllocIndex.Utxos(addr,
+				balance.AssetGuid)
+			if err != nil {
+				context := "Failed to list asset allocations"
+				return nil, internalRPCError(err.Error(), context)
+			}
+			result.Utxos = make([]btcjson.AssetAllocationUtxoResult,
+				0, len(utxos))
+			for _, utxo := range utxos {
+				result.Utxos = append(result.Utxos,
+					btcjson.AssetAllocationUtxoResult{
+						TxID:  utxo.OutPoint.Hash.String(),
+						Vout:  utxo.OutPoint.Index,
+						Value: utxo.Value,
+					})
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// handleListAssets implements the listassets command.
+func handleListAssets(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.AssetIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCNoAssetIndex,
+			Message: "The asset index must be enabled (specify --assetindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.ListAssetsCmd)
+	count := 100
+	if c.Count != nil {
+		count = *c.Count
+		if count <= 0 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Count must be a positive number",
+			}
+		}
+	}
+	var from uint64
+	if c.From != nil {
+		from = *c.From
+	}
+
+	entries, err := s.cfg.AssetIndex.Assets(from, count)
+	if err != nil {
+		context := "Failed to list assets"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	results := make([]*btcjson.GetAssetResult, 0, len(entries))
+	for _, entry := range entries {
+		results = append(results, assetResult(entry.Guid, entry.Asset))
+	}
+	return results, nil
+}
+
 // handlePing implements the ping command.
 func handlePing(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// Ask server to ping \o_
@@ -2811,6 +3659,18 @@ func handlePing(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return nil, nil
 }
 
+// handlePreciousBlock implements the preciousblock command.
+func handlePreciousBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.PreciousBlockCmd)
+	return updateBlockStatus(s, c.BlockHash, s.cfg.Chain.PreciousBlock)
+}
+
+// handleReconsiderBlock implements the reconsiderblock command.
+func handleReconsiderBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ReconsiderBlockCmd)
+	return updateBlockStatus(s, c.BlockHash, s.cfg.Chain.ReconsiderBlock)
+}
+
 // retrievedTx represents a transaction that was either loaded from the
 // transaction memory pool or from the database.  When a transaction is loaded
 // from the database, it is loaded with the raw serialized bytes while the
@@ -3418,6 +4278,20 @@ func handleStop(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return "btcd stopping.", nil
 }
 
+// handleSubmitAuxBlock implements the submitauxblock command.
+func handleSubmitAuxBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SubmitAuxBlockCmd)
+
+	if s.cfg.ChainParams.AuxPowChainID == 0 {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: "Merged mining is not supported on " +
+				s.cfg.ChainParams.Name,
+		}
+	}
+	return submitAuxBlock(s, c.Hash, c.AuxPow)
+}
+
 // handleSubmitBlock implements the submitblock command.
 func handleSubmitBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.SubmitBlockCmd)
@@ -3432,13 +4306,16 @@ func handleSubmitBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		return nil, rpcDecodeHexError(hexStr)
 	}
 
-	block, err := btcutil.NewBlockFromBytes(serializedBlock)
+	var msgBlock wire.MsgBlock
+	err = msgBlock.BtcDecode(bytes.NewReader(serializedBlock), 0,
+		s.blockEncoding())
 	if err != nil {
 		return nil, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCDeserialization,
 			Message: "Block decode failed: " + err.Error(),
 		}
 	}
+	block := btcutil.NewBlock(&msgBlock)
 
 	// Process this block using the same rules as blocks coming from other
 	// nodes.  This will in turn relay it to the network like normal.
@@ -3493,8 +4370,8 @@ func verifyChain(s *rpcServer, level, depth int32) error {
 
 		// Level 1 does basic chain sanity checks.
 		if level > 0 {
-			err := blockchain.CheckBlockSanity(block,
-				s.cfg.ChainParams.PowLimit, s.cfg.TimeSource)
+			err := blockchain.CheckBlockSanityWithParams(block,
+				s.cfg.ChainParams, s.cfg.TimeSource)
 			if err != nil {
 				rpcsLog.Errorf("Verify is unable to validate "+
 					"block at hash %v height %d: %v",
@@ -3587,6 +4464,60 @@ func handleVerifyMessage(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	return address.EncodeAddress() == c.Address, nil
 }
 
+// handleVerifyMintProof implements the verifymintproof command.
+func handleVerifyMintProof(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.VerifyMintProofCmd)
+
+	// Deserialize the transaction.
+	hexStr := c.HexTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var mtx wire.MsgTx
+	err = mtx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "TX decode failed: " + err.Error(),
+		}
+	}
+	if mtx.Version != wire.SyscoinTxVersionAllocationMint {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Transaction version %d is not a "+
+				"mint", mtx.Version),
+		}
+	}
+	payload, err := mtx.SyscoinPayload()
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Mint payload decode failed: " + err.Error(),
+		}
+	}
+	mint := payload.(*wire.MintSyscoinType)
+
+	// An invalid proof is not an error of the command but rather its
+	// result, so report the reason alongside the roots it was checked
+	// against.
+	result := btcjson.VerifyMintProofResult{
+		TxRoot:      hex.EncodeToString(mint.TxRoot),
+		ReceiptRoot: hex.EncodeToString(mint.ReceiptRoot),
+	}
+	ethTx, _, err := mpt.VerifyMintProof(mint)
+	if err != nil {
+		result.Reason = err.Error()
+		return result, nil
+	}
+	result.Valid = true
+	result.EthTxID = hex.EncodeToString(mpt.Keccak256(ethTx))
+	return result, nil
+}
+
 // handleVersion implements the version command.
 //
 // NOTE: This is a btcsuite extension ported from github.com/decred/dcrd.
@@ -3615,6 +4546,7 @@ type rpcServer struct {
 	statusLock             sync.RWMutex
 	wg                     sync.WaitGroup
 	gbtWorkState           *gbtWorkState
+	auxBlockState          *auxBlockState
 	helpCacher             *helpCacher
 	requestProcessShutdown chan struct{}
 	quit                   chan int
@@ -4219,8 +5151,9 @@ type rpcserverSyncManager interface {
 	// LocateHeaders returns the headers of the blocks after the first known
 	// block in the provided locators until the provided stop hash or the
 	// current tip is reached, up to a max of wire.MaxBlockHeadersPerMsg
-	// hashes.
-	LocateHeaders(locators []*chainhash.Hash, hashStop *chainhash.Hash) []wire.BlockHeader
+	// hashes.  An error is returned when the auxpow of a merged mined header
+	// can't be loaded.
+	LocateHeaders(locators []*chainhash.Hash, hashStop *chainhash.Hash) ([]wire.BlockHeader, error)
 }
 
 // rpcserverConfig is a descriptor containing the RPC server configuration.
@@ -4264,9 +5197,12 @@ type rpcserverConfig struct {
 
 	// These fields define any optional indexes the RPC server can make use
 	// of to provide additional data when queried.
-	TxIndex   *indexers.TxIndex
-	AddrIndex *indexers.AddrIndex
-	CfIndex   *indexers.CfIndex
+	TxIndex    *indexers.TxIndex
+	AddrIndex  *indexers.AddrIndex
+	CfIndex    *indexers.CfIndex
+	AssetIndex *indexers.AssetIndex
+
+	AssetAllocIndex *indexers.AssetAllocationIndex
 
 	// The fee estimator keeps track of how long transactions are left in
 	// the mempool before they are mined into blocks.
@@ -4278,7 +5214,8 @@ func newRPCServer(config *rpcserverConfig) (*rpcServer, error) {
 	rpc := rpcServer{
 		cfg:                    *config,
 		statusLines:            make(map[int]string),
-		gbtWorkState:           newGbtWorkState(config.TimeSource),
+		gbtWorkState:           newGbtWorkState(config.TimeSource, config.ChainParams),
+		auxBlockState:          newAuxBlockState(),
 		helpCacher:             newHelpCacher(),
 		requestProcessShutdown: make(chan struct{}),
 		quit:                   make(chan int),
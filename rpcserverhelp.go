@@ -99,6 +99,35 @@ var helpDescsEnUS = map[string]string{
 	"txrawdecoderesult-locktime": "The transaction lock time",
 	"txrawdecoderesult-vin":      "The transaction inputs as JSON objects",
 	"txrawdecoderesult-vout":     "The transaction outputs as JSON objects",
+	"txrawdecoderesult-syscoin":  "The decoded payload of a Syscoin transaction (only for Syscoin transactions)",
+
+	// SyscoinTxResult help.
+	"syscointxresult-txtype":         "The type of the Syscoin transaction (e.g. 'assetallocationsend')",
+	"syscointxresult-allocations":    "The values of the assets assigned to the transaction outputs",
+	"syscointxresult-ethaddress":     "Hex-encoded Ethereum address the assets are burned to (only for burns to Ethereum)",
+	"syscointxresult-mint":           "The Ethereum proofs carried by the transaction (only for mints)",
+	"syscointxresult-notary_keyid":   "Hex-encoded key ID of the notary set by the transaction (only for asset activations and updates setting a notary)",
+	"syscointxresult-notary_details": "The notary details set by the transaction (only for asset activations and updates setting a notary)",
+
+	// AssetOutResult help.
+	"assetoutresult-asset_guid": "The guid of the asset",
+	"assetoutresult-values":     "The values of the asset assigned to outputs",
+	"assetoutresult-notarysig":  "Hex-encoded compact signature of the notary of the asset (only when present)",
+
+	// AssetOutValueResult help.
+	"assetoutvalueresult-n":     "The index of the transaction output",
+	"assetoutvalueresult-value": "The value of the asset assigned to the output in the smallest unit",
+
+	// MintResult help.
+	"mintresult-eth_txid":           "Hex-encoded hash of the Ethereum transaction",
+	"mintresult-eth_blockhash":      "Hex-encoded hash of the Ethereum block holding the transaction",
+	"mintresult-txpos":              "The position of the transaction within the transaction proof",
+	"mintresult-txparentnodes":      "The nodes of the transaction proof as nested arrays of hex-encoded RLP byte strings",
+	"mintresult-txpath":             "Hex-encoded key of the transaction and receipt in their tries",
+	"mintresult-txroot":             "Hex-encoded transaction root of the Ethereum block",
+	"mintresult-receiptpos":         "The position of the receipt within the receipt proof",
+	"mintresult-receiptparentnodes": "The nodes of the receipt proof as nested arrays of hex-encoded RLP byte strings",
+	"mintresult-receiptroot":        "Hex-encoded receipt root of the Ethereum block",
 
 	// DecodeRawTransactionCmd help.
 	"decoderawtransaction--synopsis": "Returns a JSON object representing the provided serialized, hex-encoded transaction.",
@@ -115,6 +144,18 @@ var helpDescsEnUS = map[string]string{
 	"decodescript--synopsis": "Returns a JSON object with information about the provided hex-encoded script.",
 	"decodescript-hexscript": "Hex-encoded script",
 
+	// DumpTxOutSetCmd help.
+	"dumptxoutset--synopsis": "Writes a snapshot of the unspent transaction outputs as of the current best block to a file.\n" +
+		"Nodes can be bootstrapped from the snapshot via --loadsnapshot once its hash is pinned by the network parameters.",
+	"dumptxoutset-path": "The path of the file to write, relative to the data directory unless absolute",
+
+	// DumpTxOutSetResult help.
+	"dumptxoutsetresult-coins_written": "The number of unspent transaction outputs written",
+	"dumptxoutsetresult-base_hash":     "The hash of the block the snapshot was created at",
+	"dumptxoutsetresult-base_height":   "The height of the block the snapshot was created at",
+	"dumptxoutsetresult-path":          "The path of the written file",
+	"dumptxoutsetresult-txoutset_hash": "The hash of the unspent transaction outputs in the snapshot",
+
 	// EstimateFeeCmd help.
 	"estimatefee--synopsis": "Estimate the fee per kilobyte in satoshis " +
 		"required for a transaction to be mined before a certain number of " +
@@ -147,6 +188,87 @@ var helpDescsEnUS = map[string]string{
 	"getaddednodeinfo--condition1": "dns=true",
 	"getaddednodeinfo--result0":    "List of added peers",
 
+	// GetAssetCmd help.
+	"getasset--synopsis": "Returns the current state of an asset.\n" +
+		"NOTE: This requires the asset index to be enabled via --assetindex.",
+	"getasset-assetguid": "The guid of the asset",
+
+	// GetAssetAllocationBalanceCmd help.
+	"getassetallocationbalance--synopsis": "Returns the amount of an asset held by an address.\n" +
+		"NOTE: This requires the asset allocation index to be enabled via --assetallocindex.",
+	"getassetallocationbalance-address":   "The address to query",
+	"getassetallocationbalance-assetguid": "The guid of the asset",
+
+	// GetAuxBlockCmd help.
+	"getauxblock--synopsis": "Returns a new block to merged mine when called without arguments, or submits the auxpow of a block previously returned by it.\n" +
+		"The block pays to one of the addresses specified via --miningaddr and can be submitted until the best chain changes.",
+	"getauxblock-hash":        "The hash of the block to submit the auxpow for",
+	"getauxblock-auxpow":      "Serialized, hex-encoded auxpow of the block",
+	"getauxblock--condition0": "no arguments",
+	"getauxblock--condition1": "hash and auxpow given",
+	"getauxblock--result1":    "Whether the block was accepted",
+
+	// GetAuxBlockResult help.
+	"getauxblockresult-hash":              "The hash of the block to merged mine",
+	"getauxblockresult-chainid":           "The chain ID of the chain",
+	"getauxblockresult-previousblockhash": "The hash of the previous block",
+	"getauxblockresult-coinbasevalue":     "The total amount paid by the coinbase of the block in the smallest unit",
+	"getauxblockresult-bits":              "The hex-encoded difficulty bits of the block",
+	"getauxblockresult-height":            "The height of the block",
+	"getauxblockresult-target":            "The hex-encoded target the parent block hash must meet in little-endian byte order",
+
+	// GetAuxFeeCmd help.
+	"getauxfee--synopsis": "Returns the aux fee an asset requires for sending the given amount of it in an asset allocation send.\n" +
+		"The aux fee must be assigned to an output paying to the aux fee recipient, and the amount sent excludes the value assigned to such outputs.\n" +
+		"NOTE: This requires the asset index to be enabled via --assetindex.",
+	"getauxfee-assetguid": "The guid of the asset",
+	"getauxfee-amount":    "The amount of the asset to send in the smallest unit",
+
+	// GetAuxFeeResult help.
+	"getauxfeeresult-asset_guid":     "The guid of the asset",
+	"getauxfeeresult-amount":         "The amount of the asset to send in the smallest unit",
+	"getauxfeeresult-auxfee":         "The required aux fee in the smallest unit of the asset",
+	"getauxfeeresult-auxfee_keyid":   "Hex-encoded key ID of the aux fee recipient (only when the asset has aux fees)",
+	"getauxfeeresult-auxfee_address": "The pay-to-witness-pubkey-hash address of the aux fee recipient (only when the asset has aux fees)",
+
+	// AssetAllocationUtxoResult help.
+	"assetallocationutxoresult-txid":  "The hash of the transaction that created the output",
+	"assetallocationutxoresult-vout":  "The index of the output",
+	"assetallocationutxoresult-value": "The amount of the asset carried by the output in the smallest unit",
+
+	// AssetAllocationBalanceResult help.
+	"assetallocationbalanceresult-address":    "The address holding the asset",
+	"assetallocationbalanceresult-asset_guid": "The guid of the asset",
+	"assetallocationbalanceresult-balance":    "The amount of the asset held by the address in the smallest unit",
+	"assetallocationbalanceresult-numutxos":   "The number of unspent outputs carrying the asset",
+	"assetallocationbalanceresult-utxos":      "The unspent outputs carrying the asset (only when verbose is true)",
+
+	// NotaryDetailsResult help.
+	"notarydetailsresult-endpoint":          "The notary endpoint URL",
+	"notarydetailsresult-instant_transfers": "Whether the notary guarantees instant transfers",
+	"notarydetailsresult-hd_required":       "Whether the notary requires HD wallet approval",
+
+	// AuxFeeResult help.
+	"auxfeeresult-bound":   "The amount from which this fee tier applies",
+	"auxfeeresult-percent": "The fee for this tier in thousandths of a percent",
+
+	// AuxFeeDetailsResult help.
+	"auxfeedetailsresult-auxfee_keyid": "Hex-encoded key ID of the aux fee recipient",
+	"auxfeedetailsresult-fee_struct":   "The aux fee tiers ordered by bound",
+
+	// GetAssetResult help.
+	"getassetresult-asset_guid":             "The guid of the asset",
+	"getassetresult-symbol":                 "The asset symbol",
+	"getassetresult-public_value":           "The public data of the asset",
+	"getassetresult-contract":               "Hex-encoded address of the Ethereum contract the asset is bridged to",
+	"getassetresult-notary_keyid":           "Hex-encoded key ID of the notary",
+	"getassetresult-notary_details":         "The notary details of the asset",
+	"getassetresult-auxfee":                 "The auxiliary fee details of the asset",
+	"getassetresult-total_supply":           "The amount of the asset issued so far in the smallest unit",
+	"getassetresult-max_supply":             "The maximum supply of the asset in the smallest unit",
+	"getassetresult-precision":              "The number of decimal places of the asset",
+	"getassetresult-updatecapability_flags": "The fields of the asset that may still be updated",
+
 	// GetBestBlockResult help.
 	"getbestblockresult-hash":   "Hex-encoded bytes of the best block hash",
 	"getbestblockresult-height": "Height of the best block",
@@ -314,6 +436,7 @@ var helpDescsEnUS = map[string]string{
 	"getblocktemplateresult-submitold":                  "Not applicable",
 	"getblocktemplateresult-target":                     "Hex-encoded big-endian number which valid results must be less than",
 	"getblocktemplateresult-expires":                    "Maximum number of seconds (starting from when the server sent the response) this work is valid for",
+	"getblocktemplateresult-powalgorithm":               "Proof of work hash function of the block header which must be less than the target ('scrypt' or 'custom', omitted for double SHA-256)",
 	"getblocktemplateresult-maxtime":                    "Maximum allowed time",
 	"getblocktemplateresult-mintime":                    "Minimum allowed time",
 	"getblocktemplateresult-mutable":                    "List of mutations the server explicitly allows",
@@ -344,6 +467,15 @@ var helpDescsEnUS = map[string]string{
 	"getcfilterheader-hash":       "The hash of the block",
 	"getcfilterheader--result0":   "The block's gcs filter header",
 
+	// GetChainTipsResult help.
+	"getchaintipsresult-height":    "The height of the chain tip",
+	"getchaintipsresult-hash":      "The hash of the chain tip",
+	"getchaintipsresult-branchlen": "The number of blocks between the chain tip and the main chain, zero for the main chain",
+	"getchaintipsresult-status":    "The status of the branch (active, valid-fork, valid-headers, headers-only or invalid)",
+
+	// GetChainTipsCmd help.
+	"getchaintips--synopsis": "Returns the tips of the main chain and of all known side chains.",
+
 	// GetConnectionCountCmd help.
 	"getconnectioncount--synopsis": "Returns the number of active connections to other peers.",
 	"getconnectioncount--result0":  "The number of connections",
@@ -441,6 +573,24 @@ var helpDescsEnUS = map[string]string{
 	"getnettotalsresult-totalbytessent": "Total bytes sent",
 	"getnettotalsresult-timemillis":     "Number of milliseconds since 1 Jan 1970 GMT",
 
+	// GetNEVMBlockCmd help.
+	"getnevmblock--synopsis": "Returns the NEVM block connected to the block with the given hash.\n" +
+		"The data of NEVM blocks connected to blocks buried deeper than --nevmprunedepth is pruned, in which case only the hash and roots are returned.",
+	"getnevmblock-blockhash":   "The hash of the Syscoin block the NEVM block is connected to",
+	"getnevmblock-verbose":     "Specifies the NEVM block is returned as a JSON object instead of hex-encoded string",
+	"getnevmblock--condition0": "verbose=false",
+	"getnevmblock--condition1": "verbose=true",
+	"getnevmblock--result0":    "Hex-encoded serialized NEVM block",
+
+	// GetNEVMBlockResult help.
+	"getnevmblockresult-nevmblockhash": "The keccak256 hash of the NEVM block header (hex)",
+	"getnevmblockresult-txroot":        "The transaction root of the NEVM block (hex)",
+	"getnevmblockresult-receiptroot":   "The receipt root of the NEVM block (hex)",
+	"getnevmblockresult-sysblockhash":  "The hash of the Syscoin block the NEVM block is connected to",
+	"getnevmblockresult-pruned":        "Whether or not the data of the NEVM block has been pruned",
+	"getnevmblockresult-datasize":      "The size of the RLP-encoded NEVM block data in bytes",
+	"getnevmblockresult-header":        "The RLP-decoded fields of the NEVM block header as hex strings (only when not pruned)",
+
 	// GetPeerInfoResult help.
 	"getpeerinforesult-id":             "A unique node ID",
 	"getpeerinforesult-addr":           "The ip address and port of the peer",
@@ -506,6 +656,34 @@ var helpDescsEnUS = map[string]string{
 	"gettxout-vout":           "The index of the output",
 	"gettxout-includemempool": "Include the mempool when true",
 
+	// GetUtxoCacheInfoResult help.
+	"getutxocacheinforesult-entries":         "The number of cached unspent transaction outputs, including spent outputs which still need to be removed from the database",
+	"getutxocacheinforesult-dirtyentries":    "The number of cached outputs which have been modified since the cache was last written to the database",
+	"getutxocacheinforesult-size":            "The approximate memory used by the cached outputs in bytes",
+	"getutxocacheinforesult-maxsize":         "The size in bytes at which the cache is written to the database and emptied",
+	"getutxocacheinforesult-hits":            "The number of outputs which were found in the cache",
+	"getutxocacheinforesult-misses":          "The number of outputs which were loaded from the database",
+	"getutxocacheinforesult-flushes":         "The number of times the cache has been written to the database",
+	"getutxocacheinforesult-lastflushhash":   "The hash of the block the unspent transaction outputs in the database are consistent with",
+	"getutxocacheinforesult-lastflushheight": "The height of the block the unspent transaction outputs in the database are consistent with",
+	"getutxocacheinforesult-lastflushtime":   "The time the cache was last written to the database in seconds since 1 Jan 1970 GMT",
+
+	// GetUtxoCacheInfoCmd help.
+	"getutxocacheinfo--synopsis": "Returns statistics about the cache of unspent transaction outputs.",
+
+	// GetTxOutSetInfoResult help.
+	"gettxoutsetinforesult-height":       "The height of the block the statistics are for",
+	"gettxoutsetinforesult-bestblock":    "The hash of the block the statistics are for",
+	"gettxoutsetinforesult-transactions": "The number of transactions in the main chain up to and including the block",
+	"gettxoutsetinforesult-txouts":       "The number of unspent transaction outputs",
+	"gettxoutsetinforesult-size":         "The serialized size of the unspent transaction outputs in bytes",
+	"gettxoutsetinforesult-muhash":       "The order independent hash of the set of unspent transaction outputs",
+	"gettxoutsetinforesult-total_amount": "The total amount of the unspent transaction outputs in BTC",
+
+	// GetTxOutSetInfoCmd help.
+	"gettxoutsetinfo--synopsis": "Returns statistics about the unspent transaction output set as of a block in the main chain.",
+	"gettxoutsetinfo-height":    "The height of the block (default: the best block)",
+
 	// HelpCmd help.
 	"help--synopsis":   "Returns a list of all commands or help for a specified command.",
 	"help-command":     "The command to retrieve help for",
@@ -514,10 +692,39 @@ var helpDescsEnUS = map[string]string{
 	"help--result0":    "List of commands",
 	"help--result1":    "Help for specified command",
 
+	// InvalidateBlockCmd help.
+	"invalidateblock--synopsis": "Permanently marks a block and its descendants as invalid, as if they violated a consensus rule.\n" +
+		"The chain is reorganized to the valid chain with the most work when the block is part of the main chain.",
+	"invalidateblock-blockhash": "The hash of the block to invalidate",
+
+	// ListAssetAllocationsCmd help.
+	"listassetallocations--synopsis": "Returns the balances of every asset held by an address.\n" +
+		"NOTE: This requires the asset allocation index to be enabled via --assetallocindex.",
+	"listassetallocations-address":  "The address to query",
+	"listassetallocations-verbose":  "Specifies the unspent outputs carrying each asset are included",
+	"listassetallocations--result0": "List of asset balances",
+
+	// ListAssetsCmd help.
+	"listassets--synopsis": "Returns the current state of assets in ascending guid order.\n" +
+		"NOTE: This requires the asset index to be enabled via --assetindex.",
+	"listassets-count":    "The maximum number of assets to return",
+	"listassets-from":     "The guid to start listing from",
+	"listassets--result0": "List of assets",
+
 	// PingCmd help.
 	"ping--synopsis": "Queues a ping to be sent to each connected peer.\n" +
 		"Ping times are provided by getpeerinfo via the pingtime and pingwait fields.",
 
+	// PreciousBlockCmd help.
+	"preciousblock--synopsis": "Treats a block as if it was received before any other block with the same amount of work.\n" +
+		"The chain is reorganized to end in the block when its chain has as much work as the main chain.",
+	"preciousblock-blockhash": "The hash of the block to prioritize",
+
+	// ReconsiderBlockCmd help.
+	"reconsiderblock--synopsis": "Removes the invalid status from a block, its ancestors and its descendants, undoing invalidateblock.\n" +
+		"The chain is reorganized to the valid chain with the most work afterwards.",
+	"reconsiderblock-blockhash": "The hash of the block to reconsider",
+
 	// SearchRawTransactionsCmd help.
 	"searchrawtransactions--synopsis": "Returns raw data for transactions involving the passed address.\n" +
 		"Returned transactions are pulled from both the database, and transactions currently in the mempool.\n" +
@@ -553,6 +760,12 @@ var helpDescsEnUS = map[string]string{
 	// SubmitBlockOptions help.
 	"submitblockoptions-workid": "This parameter is currently ignored",
 
+	// SubmitAuxBlockCmd help.
+	"submitauxblock--synopsis": "Submits the auxpow of a block previously returned by getauxblock.",
+	"submitauxblock-hash":      "The hash of the block to submit the auxpow for",
+	"submitauxblock-auxpow":    "Serialized, hex-encoded auxpow of the block",
+	"submitauxblock--result0":  "Whether the block was accepted",
+
 	// SubmitBlockCmd help.
 	"submitblock--synopsis":   "Attempts to submit a new serialized, hex-encoded block to the network.",
 	"submitblock-hexblock":    "Serialized, hex-encoded block",
@@ -586,6 +799,18 @@ var helpDescsEnUS = map[string]string{
 	"verifymessage-message":   "The signed message",
 	"verifymessage--result0":  "Whether or not the signature verified",
 
+	// VerifyMintProofCmd help.
+	"verifymintproof--synopsis": "Verifies the Ethereum transaction and receipt inclusion proofs carried by a mint transaction against the roots it supplies.\n" +
+		"NOTE: Unlike block validation, this does not check the burn the proofs show.",
+	"verifymintproof-hextx": "Serialized, hex-encoded mint transaction",
+
+	// VerifyMintProofResult help.
+	"verifymintproofresult-valid":       "Whether or not both proofs verified",
+	"verifymintproofresult-reason":      "The reason the proofs did not verify (only when valid is false)",
+	"verifymintproofresult-eth_txid":    "The keccak256 hash of the proven Ethereum transaction (only when valid is true)",
+	"verifymintproofresult-txroot":      "The transaction root the transaction proof was verified against",
+	"verifymintproofresult-receiptroot": "The receipt root the receipt proof was verified against",
+
 	// -------- Websocket-specific help --------
 
 	// Session help.
@@ -598,6 +823,12 @@ var helpDescsEnUS = map[string]string{
 	// StopNotifyBlocksCmd help.
 	"stopnotifyblocks--synopsis": "Cancel registered notifications for whenever a block is connected or disconnected from the main (best) chain.",
 
+	// NotifyBurnsCmd help.
+	"notifyburns--synopsis": "Request notifications for whenever a transaction burning assets to Ethereum is connected to or disconnected from the main (best) chain.",
+
+	// StopNotifyBurnsCmd help.
+	"stopnotifyburns--synopsis": "Cancel registered notifications for whenever a transaction burning assets to Ethereum is connected to or disconnected from the main (best) chain.",
+
 	// NotifyNewTransactionsCmd help.
 	"notifynewtransactions--synopsis": "Send either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
 	"notifynewtransactions-verbose":   "Specifies which type of notification to receive. If verbose is true, then the caller receives txacceptedverbose, otherwise the caller receives txaccepted",
@@ -674,58 +905,76 @@ var helpDescsEnUS = map[string]string{
 // This information is used to generate the help.  Each result type must be a
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[string][]interface{}{
-	"addnode":               nil,
-	"createrawtransaction":  {(*string)(nil)},
-	"debuglevel":            {(*string)(nil), (*string)(nil)},
-	"decoderawtransaction":  {(*btcjson.TxRawDecodeResult)(nil)},
-	"decodescript":          {(*btcjson.DecodeScriptResult)(nil)},
-	"estimatefee":           {(*float64)(nil)},
-	"generate":              {(*[]string)(nil)},
-	"getaddednodeinfo":      {(*[]string)(nil), (*[]btcjson.GetAddedNodeInfoResult)(nil)},
-	"getbestblock":          {(*btcjson.GetBestBlockResult)(nil)},
-	"getbestblockhash":      {(*string)(nil)},
-	"getblock":              {(*string)(nil), (*btcjson.GetBlockVerboseResult)(nil)},
-	"getblockcount":         {(*int64)(nil)},
-	"getblockhash":          {(*string)(nil)},
-	"getblockheader":        {(*string)(nil), (*btcjson.GetBlockHeaderVerboseResult)(nil)},
-	"getblocktemplate":      {(*btcjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
-	"getblockchaininfo":     {(*btcjson.GetBlockChainInfoResult)(nil)},
-	"getcfilter":            {(*string)(nil)},
-	"getcfilterheader":      {(*string)(nil)},
-	"getconnectioncount":    {(*int32)(nil)},
-	"getcurrentnet":         {(*uint32)(nil)},
-	"getdifficulty":         {(*float64)(nil)},
-	"getgenerate":           {(*bool)(nil)},
-	"gethashespersec":       {(*float64)(nil)},
-	"getheaders":            {(*[]string)(nil)},
-	"getinfo":               {(*btcjson.InfoChainResult)(nil)},
-	"getmempoolinfo":        {(*btcjson.GetMempoolInfoResult)(nil)},
-	"getmininginfo":         {(*btcjson.GetMiningInfoResult)(nil)},
-	"getnettotals":          {(*btcjson.GetNetTotalsResult)(nil)},
-	"getnetworkhashps":      {(*int64)(nil)},
-	"getpeerinfo":           {(*[]btcjson.GetPeerInfoResult)(nil)},
-	"getrawmempool":         {(*[]string)(nil), (*btcjson.GetRawMempoolVerboseResult)(nil)},
-	"getrawtransaction":     {(*string)(nil), (*btcjson.TxRawResult)(nil)},
-	"gettxout":              {(*btcjson.GetTxOutResult)(nil)},
-	"node":                  nil,
-	"help":                  {(*string)(nil), (*string)(nil)},
-	"ping":                  nil,
-	"searchrawtransactions": {(*string)(nil), (*[]btcjson.SearchRawTransactionsResult)(nil)},
-	"sendrawtransaction":    {(*string)(nil)},
-	"setgenerate":           nil,
-	"stop":                  {(*string)(nil)},
-	"submitblock":           {nil, (*string)(nil)},
-	"uptime":                {(*int64)(nil)},
-	"validateaddress":       {(*btcjson.ValidateAddressChainResult)(nil)},
-	"verifychain":           {(*bool)(nil)},
-	"verifymessage":         {(*bool)(nil)},
-	"version":               {(*map[string]btcjson.VersionResult)(nil)},
+	"addnode":                   nil,
+	"createrawtransaction":      {(*string)(nil)},
+	"debuglevel":                {(*string)(nil), (*string)(nil)},
+	"decoderawtransaction":      {(*btcjson.TxRawDecodeResult)(nil)},
+	"decodescript":              {(*btcjson.DecodeScriptResult)(nil)},
+	"dumptxoutset":              {(*btcjson.DumpTxOutSetResult)(nil)},
+	"estimatefee":               {(*float64)(nil)},
+	"generate":                  {(*[]string)(nil)},
+	"getaddednodeinfo":          {(*[]string)(nil), (*[]btcjson.GetAddedNodeInfoResult)(nil)},
+	"getasset":                  {(*btcjson.GetAssetResult)(nil)},
+	"getassetallocationbalance": {(*btcjson.AssetAllocationBalanceResult)(nil)},
+	"getauxblock":               {(*btcjson.GetAuxBlockResult)(nil), (*bool)(nil)},
+	"getauxfee":                 {(*btcjson.GetAuxFeeResult)(nil)},
+	"getbestblock":              {(*btcjson.GetBestBlockResult)(nil)},
+	"getbestblockhash":          {(*string)(nil)},
+	"getblock":                  {(*string)(nil), (*btcjson.GetBlockVerboseResult)(nil)},
+	"getblockcount":             {(*int64)(nil)},
+	"getblockhash":              {(*string)(nil)},
+	"getblockheader":            {(*string)(nil), (*btcjson.GetBlockHeaderVerboseResult)(nil)},
+	"getblocktemplate":          {(*btcjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
+	"getblockchaininfo":         {(*btcjson.GetBlockChainInfoResult)(nil)},
+	"getcfilter":                {(*string)(nil)},
+	"getcfilterheader":          {(*string)(nil)},
+	"getchaintips":              {(*[]btcjson.GetChainTipsResult)(nil)},
+	"getconnectioncount":        {(*int32)(nil)},
+	"getcurrentnet":             {(*uint32)(nil)},
+	"getdifficulty":             {(*float64)(nil)},
+	"getgenerate":               {(*bool)(nil)},
+	"gethashespersec":           {(*float64)(nil)},
+	"getheaders":                {(*[]string)(nil)},
+	"getinfo":                   {(*btcjson.InfoChainResult)(nil)},
+	"getmempoolinfo":            {(*btcjson.GetMempoolInfoResult)(nil)},
+	"getmininginfo":             {(*btcjson.GetMiningInfoResult)(nil)},
+	"getnettotals":              {(*btcjson.GetNetTotalsResult)(nil)},
+	"getnetworkhashps":          {(*int64)(nil)},
+	"getnevmblock":              {(*string)(nil), (*btcjson.GetNEVMBlockResult)(nil)},
+	"getpeerinfo":               {(*[]btcjson.GetPeerInfoResult)(nil)},
+	"getrawmempool":             {(*[]string)(nil), (*btcjson.GetRawMempoolVerboseResult)(nil)},
+	"getrawtransaction":         {(*string)(nil), (*btcjson.TxRawResult)(nil)},
+	"gettxout":                  {(*btcjson.GetTxOutResult)(nil)},
+	"gettxoutsetinfo":           {(*btcjson.GetTxOutSetInfoResult)(nil)},
+	"getutxocacheinfo":          {(*btcjson.GetUtxoCacheInfoResult)(nil)},
+	"node":                      nil,
+	"help":                      {(*string)(nil), (*string)(nil)},
+	"invalidateblock":           nil,
+	"listassetallocations":      {(*[]btcjson.AssetAllocationBalanceResult)(nil)},
+	"listassets":                {(*[]btcjson.GetAssetResult)(nil)},
+	"ping":                      nil,
+	"preciousblock":             nil,
+	"reconsiderblock":           nil,
+	"searchrawtransactions":     {(*string)(nil), (*[]btcjson.SearchRawTransactionsResult)(nil)},
+	"sendrawtransaction":        {(*string)(nil)},
+	"setgenerate":               nil,
+	"stop":                      {(*string)(nil)},
+	"submitauxblock":            {(*bool)(nil)},
+	"submitblock":               {nil, (*string)(nil)},
+	"uptime":                    {(*int64)(nil)},
+	"validateaddress":           {(*btcjson.ValidateAddressChainResult)(nil)},
+	"verifychain":               {(*bool)(nil)},
+	"verifymessage":             {(*bool)(nil)},
+	"verifymintproof":           {(*btcjson.VerifyMintProofResult)(nil)},
+	"version":                   {(*map[string]btcjson.VersionResult)(nil)},
 
 	// Websocket commands.
 	"loadtxfilter":              nil,
 	"session":                   {(*btcjson.SessionResult)(nil)},
 	"notifyblocks":              nil,
+	"notifyburns":               nil,
 	"stopnotifyblocks":          nil,
+	"stopnotifyburns":           nil,
 	"notifynewtransactions":     nil,
 	"stopnotifynewtransactions": nil,
 	"notifyreceived":            nil,
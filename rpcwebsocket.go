@@ -67,11 +67,13 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"loadtxfilter":              handleLoadTxFilter,
 	"help":                      handleWebsocketHelp,
 	"notifyblocks":              handleNotifyBlocks,
+	"notifyburns":               handleNotifyBurns,
 	"notifynewtransactions":     handleNotifyNewTransactions,
 	"notifyreceived":            handleNotifyReceived,
 	"notifyspent":               handleNotifySpent,
 	"session":                   handleSession,
 	"stopnotifyblocks":          handleStopNotifyBlocks,
+	"stopnotifyburns":           handleStopNotifyBurns,
 	"stopnotifynewtransactions": handleStopNotifyNewTransactions,
 	"stopnotifyspent":           handleStopNotifySpent,
 	"stopnotifyreceived":        handleStopNotifyReceived,
@@ -458,6 +460,8 @@ type notificationRegisterClient wsClient
 type notificationUnregisterClient wsClient
 type notificationRegisterBlocks wsClient
 type notificationUnregisterBlocks wsClient
+type notificationRegisterBurns wsClient
+type notificationUnregisterBurns wsClient
 type notificationRegisterNewMempoolTxs wsClient
 type notificationUnregisterNewMempoolTxs wsClient
 type notificationRegisterSpent struct {
@@ -491,6 +495,7 @@ func (m *wsNotificationManager) notificationHandler() {
 	// Where possible, the quit channel is used as the unique id for a client
 	// since it is quite a bit more efficient than using the entire struct.
 	blockNotifications := make(map[chan struct{}]*wsClient)
+	burnNotifications := make(map[chan struct{}]*wsClient)
 	txNotifications := make(map[chan struct{}]*wsClient)
 	watchedOutPoints := make(map[wire.OutPoint]map[chan struct{}]*wsClient)
 	watchedAddrs := make(map[string]map[chan struct{}]*wsClient)
@@ -523,6 +528,10 @@ out:
 						block)
 				}
 
+				if len(burnNotifications) != 0 {
+					m.notifyBurnConnected(burnNotifications, block)
+				}
+
 			case *notificationBlockDisconnected:
 				block := (*btcutil.Block)(n)
 
@@ -533,6 +542,11 @@ out:
 						block)
 				}
 
+				if len(burnNotifications) != 0 {
+					m.notifyBurnDisconnected(burnNotifications,
+						block)
+				}
+
 			case *notificationTxAcceptedByMempool:
 				if n.isNew && len(txNotifications) != 0 {
 					m.notifyForNewTx(txNotifications, n.tx)
@@ -548,6 +562,14 @@ out:
 				wsc := (*wsClient)(n)
 				delete(blockNotifications, wsc.quit)
 
+			case *notificationRegisterBurns:
+				wsc := (*wsClient)(n)
+				burnNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterBurns:
+				wsc := (*wsClient)(n)
+				delete(burnNotifications, wsc.quit)
+
 			case *notificationRegisterClient:
 				wsc := (*wsClient)(n)
 				clients[wsc.quit] = wsc
@@ -557,6 +579,7 @@ out:
 				// Remove any requests made by the client as well as
 				// the client itself.
 				delete(blockNotifications, wsc.quit)
+				delete(burnNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
 				for k := range wsc.spentRequests {
 					op := k
@@ -626,6 +649,18 @@ func (m *wsNotificationManager) UnregisterBlockUpdates(wsc *wsClient) {
 	m.queueNotification <- (*notificationUnregisterBlocks)(wsc)
 }
 
+// RegisterBurnUpdates requests notifications of burns to Ethereum to the passed
+// websocket client.
+func (m *wsNotificationManager) RegisterBurnUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterBurns)(wsc)
+}
+
+// UnregisterBurnUpdates removes notifications of burns to Ethereum for the
+// passed websocket client.
+func (m *wsNotificationManager) UnregisterBurnUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterBurns)(wsc)
+}
+
 // subscribedClients returns the set of all websocket client quit channels that
 // are registered to receive notifications regarding tx, either due to tx
 // spending a watched output or outputting to a watched address.  Matching
@@ -808,6 +843,103 @@ func (*wsNotificationManager) notifyFilteredBlockDisconnected(clients map[chan s
 	}
 }
 
+// blockBurns returns the transactions of the passed block which burn assets
+// to Ethereum along with the amount of every asset they burn.
+func blockBurns(block *btcutil.Block) []btcjson.BurnResult {
+	var burns []btcjson.BurnResult
+	for _, tx := range block.Transactions() {
+		msgTx := tx.MsgTx()
+		if msgTx.Version != wire.SyscoinTxVersionAllocationBurnToEthereum {
+			continue
+		}
+
+		// Transactions in connected blocks have valid payloads, so
+		// failing to decode one means there is nothing to report.
+		payload, err := msgTx.SyscoinPayload()
+		if err != nil {
+			continue
+		}
+		_, dataOutIdx, err := msgTx.SyscoinData()
+		if err != nil {
+			continue
+		}
+		burn, ok := payload.(*wire.SyscoinBurnToEthereumType)
+		if !ok {
+			continue
+		}
+
+		// The burned amount of an asset is the value assigned to the
+		// null data output carrying the payload.
+		result := btcjson.BurnResult{
+			TxID:       tx.Hash().String(),
+			BlockHash:  block.Hash().String(),
+			Height:     block.Height(),
+			EthAddress: hex.EncodeToString(burn.EthAddress),
+		}
+		for _, voutAsset := range burn.Allocation.VoutAssets {
+			var amount int64
+			for _, value := range voutAsset.Values {
+				if value.N == uint32(dataOutIdx) {
+					amount += value.ValueSat
+				}
+			}
+			if amount == 0 {
+				continue
+			}
+			result.Assets = append(result.Assets,
+				btcjson.BurnedAssetResult{
+					AssetGuid: voutAsset.AssetGuid,
+					Amount:    amount,
+				})
+		}
+		burns = append(burns, result)
+	}
+	return burns
+}
+
+// notifyBurnConnected notifies websocket clients that have registered for burn
+// updates of every transaction burning assets to Ethereum in a block connected
+// to the main chain.
+func (*wsNotificationManager) notifyBurnConnected(clients map[chan struct{}]*wsClient,
+	block *btcutil.Block) {
+
+	for _, burn := range blockBurns(block) {
+		ntfn := btcjson.NewBurnConnectedNtfn(burn)
+		marshalledJSON, err := btcjson.MarshalCmd(nil, ntfn)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal burn connected "+
+				"notification: %v", err)
+			return
+		}
+		for _, wsc := range clients {
+			wsc.QueueNotification(marshalledJSON)
+		}
+	}
+}
+
+// notifyBurnDisconnected notifies websocket clients that have registered for
+// burn updates of every transaction burning assets to Ethereum in a block
+// disconnected from the main chain (due to a reorganize).  The burns are
+// reported in reverse order so they are rolled back in the opposite order they
+// were connected in.
+func (*wsNotificationManager) notifyBurnDisconnected(clients map[chan struct{}]*wsClient,
+	block *btcutil.Block) {
+
+	burns := blockBurns(block)
+	for i := len(burns) - 1; i >= 0; i-- {
+		ntfn := btcjson.NewBurnDisconnectedNtfn(burns[i])
+		marshalledJSON, err := btcjson.MarshalCmd(nil, ntfn)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal burn disconnected "+
+				"notification: %v", err)
+			return
+		}
+		for _, wsc := range clients {
+			wsc.QueueNotification(marshalledJSON)
+		}
+	}
+}
+
 // RegisterNewMempoolTxsUpdates requests notifications to the passed websocket
 // client when new transactions are added to the memory pool.
 func (m *wsNotificationManager) RegisterNewMempoolTxsUpdates(wsc *wsClient) {
@@ -1833,6 +1965,13 @@ func handleNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	return nil, nil
 }
 
+// handleNotifyBurns implements the notifyburns command extension for
+// websocket connections.
+func handleNotifyBurns(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.RegisterBurnUpdates(wsc)
+	return nil, nil
+}
+
 // handleSession implements the session command extension for websocket
 // connections.
 func handleSession(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -1846,6 +1985,13 @@ func handleStopNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error
 	return nil, nil
 }
 
+// handleStopNotifyBurns implements the stopnotifyburns command extension for
+// websocket connections.
+func handleStopNotifyBurns(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterBurnUpdates(wsc)
+	return nil, nil
+}
+
 // handleNotifySpent implements the notifyspent command extension for
 // websocket connections.
 func handleNotifySpent(wsc *wsClient, icmd interface{}) (interface{}, error) {
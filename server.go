@@ -12,8 +12,10 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"net"
+	"os"
 	"runtime"
 	"sort"
 	"strconv"
@@ -234,9 +236,12 @@ type server struct {
 	// if the associated index is not enabled.  These fields are set during
 	// initial creation of the server and never changed afterwards, so they
 	// do not need to be protected for concurrent access.
-	txIndex   *indexers.TxIndex
-	addrIndex *indexers.AddrIndex
-	cfIndex   *indexers.CfIndex
+	txIndex    *indexers.TxIndex
+	addrIndex  *indexers.AddrIndex
+	cfIndex    *indexers.CfIndex
+	assetIndex *indexers.AssetIndex
+
+	assetAllocIndex *indexers.AssetAllocationIndex
 
 	// The fee estimator keeps track of how long transactions are left in
 	// the mempool before they are mined into blocks.
@@ -573,8 +578,13 @@ func (sp *serverPeer) OnTx(_ *peer.Peer, msg *wire.MsgTx) {
 // blocks until the bitcoin block has been fully processed.
 func (sp *serverPeer) OnBlock(_ *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 	// Convert the raw MsgBlock to a btcutil.Block which provides some
-	// convenience methods and things such as hash caching.
+	// convenience methods and things such as hash caching.  The raw bytes
+	// of merged mined blocks include their auxpow, which isn't part of the
+	// stored block, so they can't be reused for them.
 	block := btcutil.NewBlockFromBlockAndBytes(msg, buf)
+	if msg.Header.AuxPow != nil {
+		block = btcutil.NewBlock(msg)
+	}
 
 	// Add the block to the known inventory for the peer.
 	iv := wire.NewInvVect(wire.InvTypeBlock, block.Hash())
@@ -593,6 +603,65 @@ func (sp *serverPeer) OnBlock(_ *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 	// the bitcoin block has been fully processed.
 	sp.server.syncManager.QueueBlock(block, sp.Peer, sp.blockProcessed)
 	<-sp.blockProcessed
+
+	// Request the NEVM block connected to the block from the peer when
+	// the block was accepted and no NEVM block is stored for it yet.
+	sp.requestNEVMBlock(block.Hash())
+}
+
+// requestNEVMBlock requests the NEVM block connected to the block with the
+// passed hash from the peer unless the network has no NEVM blocks, the peer
+// does not serve them, the block is unknown or an NEVM block is already stored
+// for it.
+func (sp *serverPeer) requestNEVMBlock(hash *chainhash.Hash) {
+	if !sp.server.chainParams.NEVMSupported ||
+		!hasServices(sp.Services(), wire.SFNodeNEVM) {
+
+		return
+	}
+
+	chain := sp.server.chain
+	if known, err := chain.HaveBlock(hash); err != nil || !known {
+		return
+	}
+	nevmBlock, err := chain.FetchNEVMBlock(hash)
+	if err != nil || nevmBlock != nil {
+		return
+	}
+
+	gdmsg := wire.NewMsgGetDataSizeHint(1)
+	gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeNEVMBlock, hash))
+	sp.QueueMessage(gdmsg, nil)
+}
+
+// OnNEVMBlock is invoked when a peer receives a nevmblock Syscoin message.  The
+// NEVM block is stored alongside the Syscoin block it is connected to when the
+// Syscoin block commits to it.
+func (sp *serverPeer) OnNEVMBlock(_ *peer.Peer, msg *wire.MsgNEVMBlock) {
+	// Ignore NEVM blocks on networks which have none.
+	if !sp.server.chainParams.NEVMSupported {
+		peerLog.Debugf("Ignoring NEVM block from %s on a network "+
+			"without NEVM blocks", sp)
+		return
+	}
+
+	hash := msg.SYSHash()
+	iv := wire.NewInvVect(wire.InvTypeNEVMBlock, &hash)
+	sp.AddKnownInventory(iv)
+
+	err := sp.server.chain.StoreNEVMBlock(&msg.NEVMBlockWire)
+	if err != nil {
+		// Penalize peers sending NEVM blocks that are inconsistent,
+		// connected to unknown blocks or not committed to.
+		if _, ok := err.(blockchain.RuleError); ok {
+			peerLog.Infof("Rejected NEVM block for block %v from "+
+				"%s: %v", hash, sp, err)
+			sp.addBanScore(0, 10, "nevmblock")
+			return
+		}
+		peerLog.Errorf("Unable to store NEVM block for block %v: %v",
+			hash, err)
+	}
 }
 
 // OnInv is invoked when a peer receives an inv bitcoin message and is
@@ -684,6 +753,8 @@ func (sp *serverPeer) OnGetData(_ *peer.Peer, msg *wire.MsgGetData) {
 			err = sp.server.pushMerkleBlockMsg(sp, &iv.Hash, c, waitChan, wire.WitnessEncoding)
 		case wire.InvTypeFilteredBlock:
 			err = sp.server.pushMerkleBlockMsg(sp, &iv.Hash, c, waitChan, wire.BaseEncoding)
+		case wire.InvTypeNEVMBlock:
+			err = sp.server.pushNEVMBlockMsg(sp, &iv.Hash, c, waitChan)
 		default:
 			peerLog.Warnf("Unknown type in inventory request %d",
 				iv.Type)
@@ -776,7 +847,13 @@ func (sp *serverPeer) OnGetHeaders(_ *peer.Peer, msg *wire.MsgGetHeaders) {
 	//
 	// This mirrors the behavior in the reference implementation.
 	chain := sp.server.chain
-	headers := chain.LocateHeaders(msg.BlockLocatorHashes, &msg.HashStop)
+	headers, err := chain.LocateHeaders(msg.BlockLocatorHashes,
+		&msg.HashStop)
+	if err != nil {
+		peerLog.Errorf("Unable to locate headers requested by %s: %v",
+			sp.Peer, err)
+		return
+	}
 
 	// Send found headers to the requesting peer.
 	blockHeaders := make([]*wire.BlockHeader, len(headers))
@@ -1459,6 +1536,19 @@ func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan cha
 		return err
 	}
 
+	// Merged mined blocks are stored without their auxpow, which must be
+	// sent along with them.
+	err = sp.server.chain.FetchAuxPow(&msgBlock.Header)
+	if err != nil {
+		peerLog.Tracef("Unable to load auxpow of requested block hash "+
+			"%v: %v", hash, err)
+
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
+
 	// Once we have fetched data wait for any previous operation to finish.
 	if waitChan != nil {
 		<-waitChan
@@ -1490,6 +1580,36 @@ func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan cha
 	return nil
 }
 
+// pushNEVMBlockMsg sends a nevmblock message for the NEVM block connected to
+// the block with the provided hash to the connected peer.  An error is returned
+// if no NEVM block is stored for the block or its data has been pruned.
+func (s *server) pushNEVMBlockMsg(sp *serverPeer, hash *chainhash.Hash,
+	doneChan chan<- struct{}, waitChan <-chan struct{}) error {
+
+	nevmBlock, err := sp.server.chain.FetchNEVMBlock(hash)
+	if err == nil && (nevmBlock == nil || len(nevmBlock.NEVMBlockData) == 0) {
+		err = fmt.Errorf("no NEVM block data for block %v", hash)
+	}
+	if err != nil {
+		peerLog.Tracef("Unable to fetch requested NEVM block for block "+
+			"%v: %v", hash, err)
+
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
+
+	// Once we have fetched data wait for any previous operation to finish.
+	if waitChan != nil {
+		<-waitChan
+	}
+
+	sp.QueueMessage(wire.NewMsgNEVMBlock(nevmBlock), doneChan)
+
+	return nil
+}
+
 // pushMerkleBlockMsg sends a merkleblock message for the provided block hash to
 // the connected peer.  Since a merkle block requires the peer to have a filter
 // loaded, this call will simply be ignored if there is no filter loaded.  An
@@ -1518,8 +1638,19 @@ func (s *server) pushMerkleBlockMsg(sp *serverPeer, hash *chainhash.Hash,
 	}
 
 	// Generate a merkle block by filtering the requested block according
-	// to the filter for the peer.
+	// to the filter for the peer.  Merged mined blocks are stored without
+	// their auxpow, which must be sent along with the header.
 	merkle, matchedTxIndices := bloom.NewMerkleBlock(blk, sp.filter)
+	err = sp.server.chain.FetchAuxPow(&merkle.Header)
+	if err != nil {
+		peerLog.Tracef("Unable to load auxpow of requested block hash "+
+			"%v: %v", hash, err)
+
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
 
 	// Once we have fetched data wait for any previous operation to finish.
 	if waitChan != nil {
@@ -1952,6 +2083,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnMemPool:      sp.OnMemPool,
 			OnTx:           sp.OnTx,
 			OnBlock:        sp.OnBlock,
+			OnNEVMBlock:    sp.OnNEVMBlock,
 			OnInv:          sp.OnInv,
 			OnHeaders:      sp.OnHeaders,
 			OnGetData:      sp.OnGetData,
@@ -2123,6 +2255,12 @@ out:
 	s.syncManager.Stop()
 	s.addrManager.Stop()
 
+	// Write the unspent transaction outputs which are only cached in memory
+	// to the database now that no more blocks are processed.
+	if err := s.chain.FlushUtxoCache(); err != nil {
+		srvrLog.Errorf("Unable to flush the utxo cache: %v", err)
+	}
+
 	// Drain channels before exiting so nothing is left waiting around
 	// to send.
 cleanup:
@@ -2152,6 +2290,23 @@ func (s *server) BanPeer(sp *serverPeer) {
 	s.banPeers <- sp
 }
 
+// BanMisbehavingPeer bans the passed peer for the passed reason and disconnects
+// it.  Like for other misbehavior, the peer is only disconnected when banning is
+// disabled or the peer is whitelisted.
+func (s *server) BanMisbehavingPeer(p *peer.Peer, reason string) {
+	replyChan := make(chan []*serverPeer)
+	s.query <- getPeersMsg{reply: replyChan}
+	for _, sp := range <-replyChan {
+		if sp.Peer != p || cfg.DisableBanning || sp.isWhitelisted {
+			continue
+		}
+		peerLog.Warnf("Misbehaving peer %s: %s -- banning and "+
+			"disconnecting", sp, reason)
+		s.BanPeer(sp)
+	}
+	p.Disconnect()
+}
+
 // RelayInventory relays the passed inventory vector to all connected peers
 // that are not already known to have it.
 func (s *server) RelayInventory(invVect *wire.InvVect, data interface{}) {
@@ -2546,6 +2701,13 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	if cfg.NoCFilters {
 		services &^= wire.SFNodeCF
 	}
+	if cfg.Prune != 0 {
+		services &^= wire.SFNodeNetwork
+		services |= wire.SFNodeNetworkLimited
+	}
+	if chainParams.NEVMSupported {
+		services |= wire.SFNodeNEVM
+	}
 
 	amgr := addrmgr.New(cfg.DataDir, btcdLookup)
 
@@ -2609,6 +2771,17 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		s.addrIndex = indexers.NewAddrIndex(db, chainParams)
 		indexes = append(indexes, s.addrIndex)
 	}
+	if cfg.AssetIndex {
+		indxLog.Info("Asset index is enabled")
+		s.assetIndex = indexers.NewAssetIndex(db)
+		indexes = append(indexes, s.assetIndex)
+	}
+	if cfg.AssetAllocIndex {
+		indxLog.Info("Asset allocation index is enabled")
+		s.assetAllocIndex = indexers.NewAssetAllocationIndex(db,
+			chainParams)
+		indexes = append(indexes, s.assetAllocIndex)
+	}
 	if !cfg.NoCFilters {
 		indxLog.Info("Committed filter index is enabled")
 		s.cfIndex = indexers.NewCfIndex(db, chainParams)
@@ -2627,6 +2800,17 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		checkpoints = mergeCheckpoints(s.chainParams.Checkpoints, cfg.addCheckpoints)
 	}
 
+	// Open the UTXO snapshot to bootstrap the chain from when requested.
+	var utxoSnapshot io.Reader
+	if cfg.LoadSnapshot != "" {
+		file, err := os.Open(cfg.LoadSnapshot)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		utxoSnapshot = file
+	}
+
 	// Create a new block chain instance with the appropriate configuration.
 	var err error
 	s.chain, err = blockchain.New(&blockchain.Config{
@@ -2638,6 +2822,15 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		SigCache:     s.sigCache,
 		IndexManager: indexManager,
 		HashCache:    s.hashCache,
+
+		NEVMPruneDepth:   cfg.NEVMPruneDepth,
+		Prune:            cfg.Prune * 1024 * 1024,
+		AssumeValid:      cfg.assumeValid,
+		MinimumChainWork: cfg.minimumChainWork,
+		UTXOSnapshot:     utxoSnapshot,
+
+		UtxoCacheMaxSize:       cfg.UtxoCacheMaxSize * 1024 * 1024,
+		UtxoCacheFlushInterval: cfg.UtxoFlushInterval,
 	})
 	if err != nil {
 		return nil, err
@@ -2831,21 +3024,23 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		}
 
 		s.rpcServer, err = newRPCServer(&rpcserverConfig{
-			Listeners:    rpcListeners,
-			StartupTime:  s.startupTime,
-			ConnMgr:      &rpcConnManager{&s},
-			SyncMgr:      &rpcSyncMgr{&s, s.syncManager},
-			TimeSource:   s.timeSource,
-			Chain:        s.chain,
-			ChainParams:  chainParams,
-			DB:           db,
-			TxMemPool:    s.txMemPool,
-			Generator:    blockTemplateGenerator,
-			CPUMiner:     s.cpuMiner,
-			TxIndex:      s.txIndex,
-			AddrIndex:    s.addrIndex,
-			CfIndex:      s.cfIndex,
-			FeeEstimator: s.feeEstimator,
+			Listeners:       rpcListeners,
+			StartupTime:     s.startupTime,
+			ConnMgr:         &rpcConnManager{&s},
+			SyncMgr:         &rpcSyncMgr{&s, s.syncManager},
+			TimeSource:      s.timeSource,
+			Chain:           s.chain,
+			ChainParams:     chainParams,
+			DB:              db,
+			TxMemPool:       s.txMemPool,
+			Generator:       blockTemplateGenerator,
+			CPUMiner:        s.cpuMiner,
+			TxIndex:         s.txIndex,
+			AddrIndex:       s.addrIndex,
+			CfIndex:         s.cfIndex,
+			AssetIndex:      s.assetIndex,
+			AssetAllocIndex: s.assetAllocIndex,
+			FeeEstimator:    s.feeEstimator,
 		})
 		if err != nil {
 			return nil, err
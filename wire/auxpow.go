@@ -0,0 +1,251 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+)
+
+const (
+	// AuxPowVersionBit is the bit of the block version which indicates a
+	// block is merged mined and therefore carries an auxiliary proof of
+	// work after its header on chains which support merged mining.
+	AuxPowVersionBit = 1 << 8
+
+	// AuxPowChainIDShift is the number of bits the chain ID of a merged
+	// mined chain is shifted by within the block version.
+	AuxPowChainIDShift = 16
+
+	// AuxPowChainIDMask is the mask of the bits of the block version which
+	// hold the chain ID.  The top three bits are left to the version bits
+	// scheme defined by BIP0009.
+	AuxPowChainIDMask = 0x1fff << AuxPowChainIDShift
+
+	// MaxAuxPowBranchLen is the maximum number of hashes a merkle branch of
+	// an auxiliary proof of work may contain.  This is large enough for a
+	// coinbase branch of any block which fits into the maximum message
+	// size and for any chain branch allowed by consensus.
+	MaxAuxPowBranchLen = 32
+)
+
+// AuxPow defines the auxiliary proof of work of a merged mined block.  It ties
+// the block to the coinbase transaction of a block of a parent chain, whose
+// header hash provides the proof of work of the block.
+type AuxPow struct {
+	// CoinbaseTx is the coinbase transaction of the parent block, which
+	// commits to the root of the chain merkle tree in its signature script.
+	CoinbaseTx MsgTx
+
+	// BlockHash is the hash of the parent block.  It is a leftover of the
+	// original merged mining implementation and not used for validation.
+	BlockHash chainhash.Hash
+
+	// CoinbaseBranch is the merkle branch linking the coinbase transaction
+	// to the merkle root of the parent block.
+	CoinbaseBranch []chainhash.Hash
+
+	// CoinbaseIndex is the index of the coinbase transaction within the
+	// parent block and therefore always zero for valid proofs.
+	CoinbaseIndex int32
+
+	// ChainBranch is the merkle branch linking the hash of the block to the
+	// root of the chain merkle tree of all chains merged mined together.
+	ChainBranch []chainhash.Hash
+
+	// ChainIndex is the index of the block within the chain merkle tree.
+	ChainIndex int32
+
+	// ParentBlock is the header of the parent block.
+	ParentBlock BlockHeader
+}
+
+// IsAuxPow returns whether the version of the block header indicates the block
+// is merged mined and is therefore followed by an auxiliary proof of work.  The
+// version bit only has this meaning on chains which support merged mining.
+func (h *BlockHeader) IsAuxPow() bool {
+	return h.Version&AuxPowVersionBit != 0
+}
+
+// ChainID returns the chain ID encoded in the version of the block header.
+func (h *BlockHeader) ChainID() int32 {
+	return (h.Version & AuxPowChainIDMask) >> AuxPowChainIDShift
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// auxiliary proof of work.
+func (a *AuxPow) SerializeSize() int {
+	return a.CoinbaseTx.SerializeSize() + chainhash.HashSize +
+		VarIntSerializeSize(uint64(len(a.CoinbaseBranch))) +
+		len(a.CoinbaseBranch)*chainhash.HashSize + 4 +
+		VarIntSerializeSize(uint64(len(a.ChainBranch))) +
+		len(a.ChainBranch)*chainhash.HashSize + 4 + blockHeaderLen
+}
+
+// readAuxPowBranch reads a merkle branch of an auxiliary proof of work from r.
+func readAuxPowBranch(r io.Reader, pver uint32) ([]chainhash.Hash, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+	if count > MaxAuxPowBranchLen {
+		str := fmt.Sprintf("too many hashes in auxpow merkle branch "+
+			"[count %d, max %d]", count, MaxAuxPowBranchLen)
+		return nil, messageError("readAuxPowBranch", str)
+	}
+
+	branch := make([]chainhash.Hash, count)
+	for i := range branch {
+		err := readElement(r, &branch[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return branch, nil
+}
+
+// writeAuxPowBranch writes a merkle branch of an auxiliary proof of work to w.
+func writeAuxPowBranch(w io.Writer, pver uint32, branch []chainhash.Hash) error {
+	count := len(branch)
+	if count > MaxAuxPowBranchLen {
+		str := fmt.Sprintf("too many hashes in auxpow merkle branch "+
+			"[count %d, max %d]", count, MaxAuxPowBranchLen)
+		return messageError("writeAuxPowBranch", str)
+	}
+
+	err := WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+	for i := range branch {
+		err := writeElement(w, &branch[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readAuxPow reads an auxiliary proof of work from r.
+func readAuxPow(r io.Reader, pver uint32, a *AuxPow) error {
+	// The parent coinbase transaction is always serialized with any witness
+	// data it has.
+	err := a.CoinbaseTx.BtcDecode(r, pver, WitnessEncoding)
+	if err != nil {
+		return err
+	}
+	err = readElement(r, &a.BlockHash)
+	if err != nil {
+		return err
+	}
+	a.CoinbaseBranch, err = readAuxPowBranch(r, pver)
+	if err != nil {
+		return err
+	}
+	err = readElement(r, &a.CoinbaseIndex)
+	if err != nil {
+		return err
+	}
+	a.ChainBranch, err = readAuxPowBranch(r, pver)
+	if err != nil {
+		return err
+	}
+	err = readElement(r, &a.ChainIndex)
+	if err != nil {
+		return err
+	}
+	return readBlockHeader(r, pver, &a.ParentBlock)
+}
+
+// writeAuxPow writes an auxiliary proof of work to w.
+func writeAuxPow(w io.Writer, pver uint32, a *AuxPow) error {
+	err := a.CoinbaseTx.BtcEncode(w, pver, WitnessEncoding)
+	if err != nil {
+		return err
+	}
+	err = writeElement(w, &a.BlockHash)
+	if err != nil {
+		return err
+	}
+	err = writeAuxPowBranch(w, pver, a.CoinbaseBranch)
+	if err != nil {
+		return err
+	}
+	err = writeElement(w, a.CoinbaseIndex)
+	if err != nil {
+		return err
+	}
+	err = writeAuxPowBranch(w, pver, a.ChainBranch)
+	if err != nil {
+		return err
+	}
+	err = writeElement(w, a.ChainIndex)
+	if err != nil {
+		return err
+	}
+	return writeBlockHeader(w, pver, &a.ParentBlock)
+}
+
+// Deserialize decodes an auxiliary proof of work from r into the receiver
+// using the format it has within block messages.
+func (a *AuxPow) Deserialize(r io.Reader) error {
+	return readAuxPow(r, 0, a)
+}
+
+// Serialize encodes the auxiliary proof of work to w using the format it has
+// within block messages.
+func (a *AuxPow) Serialize(w io.Writer) error {
+	return writeAuxPow(w, 0, a)
+}
+
+// readBlockHeaderAuxPow reads a block header from r followed by its auxiliary
+// proof of work when the passed encoding includes AuxPowEncoding and the
+// version of the header indicates it is merged mined.
+func readBlockHeaderAuxPow(r io.Reader, pver uint32, enc MessageEncoding, bh *BlockHeader) error {
+	err := readBlockHeader(r, pver, bh)
+	if err != nil {
+		return err
+	}
+	if enc&AuxPowEncoding != AuxPowEncoding || !bh.IsAuxPow() {
+		bh.AuxPow = nil
+		return nil
+	}
+
+	bh.AuxPow = new(AuxPow)
+	return readAuxPow(r, pver, bh.AuxPow)
+}
+
+// writeBlockHeaderAuxPow writes a block header to w followed by its auxiliary
+// proof of work when the passed encoding includes AuxPowEncoding and the
+// version of the header indicates it is merged mined.
+func writeBlockHeaderAuxPow(w io.Writer, pver uint32, enc MessageEncoding, bh *BlockHeader) error {
+	err := writeBlockHeader(w, pver, bh)
+	if err != nil {
+		return err
+	}
+	if enc&AuxPowEncoding != AuxPowEncoding || !bh.IsAuxPow() {
+		return nil
+	}
+
+	if bh.AuxPow == nil {
+		str := fmt.Sprintf("merged mined block header %v is missing "+
+			"its auxpow", bh.BlockHash())
+		return messageError("writeBlockHeaderAuxPow", str)
+	}
+	return writeAuxPow(w, pver, bh.AuxPow)
+}
+
+// blockHeaderAuxPowSize returns the number of bytes it would take to serialize
+// a block header along with its auxiliary proof of work, if any, using
+// AuxPowEncoding.
+func blockHeaderAuxPowSize(bh *BlockHeader) int {
+	n := blockHeaderLen
+	if bh.IsAuxPow() && bh.AuxPow != nil {
+		n += bh.AuxPow.SerializeSize()
+	}
+	return n
+}
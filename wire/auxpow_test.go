@@ -0,0 +1,235 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+)
+
+// auxPowBlock returns a merged mined copy of block one whose auxpow uses the
+// coinbase of block one as the parent coinbase.
+func auxPowBlock() *MsgBlock {
+	block := NewMsgBlock(&blockOne.Header)
+	block.Header.Version |= AuxPowVersionBit | 0x1000<<AuxPowChainIDShift
+	for _, tx := range blockOne.Transactions {
+		block.AddTransaction(tx.Copy())
+	}
+	block.Header.AuxPow = &AuxPow{
+		CoinbaseTx:     *blockOne.Transactions[0].Copy(),
+		BlockHash:      chainhash.Hash{0x01},
+		CoinbaseBranch: []chainhash.Hash{{0x02}, {0x03}},
+		ChainBranch:    []chainhash.Hash{{0x04}},
+		ChainIndex:     1,
+		ParentBlock:    blockOne.Header,
+	}
+	return block
+}
+
+// TestAuxPowHeader tests the auxpow related block header API.
+func TestAuxPowHeader(t *testing.T) {
+	block := auxPowBlock()
+	header := &block.Header
+	if !header.IsAuxPow() {
+		t.Errorf("IsAuxPow: merged mined header not detected")
+	}
+	if blockOne.Header.IsAuxPow() {
+		t.Errorf("IsAuxPow: regular header detected as merged mined")
+	}
+	if id := header.ChainID(); id != 0x1000 {
+		t.Errorf("ChainID: wrong chain ID - got %#x, want %#x", id,
+			0x1000)
+	}
+
+	// The auxpow is not part of the hash nor of the serialized header.
+	withoutAuxPow := *header
+	withoutAuxPow.AuxPow = nil
+	if header.BlockHash() != withoutAuxPow.BlockHash() {
+		t.Errorf("BlockHash: auxpow changed the hash of the header")
+	}
+	var buf bytes.Buffer
+	if err := header.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+	if buf.Len() != blockHeaderLen {
+		t.Errorf("Serialize: wrong size - got %d, want %d", buf.Len(),
+			blockHeaderLen)
+	}
+}
+
+// TestAuxPowWire tests the encode and decode of merged mined blocks, merkle
+// blocks and headers.
+func TestAuxPowWire(t *testing.T) {
+	pver := ProtocolVersion
+	enc := WitnessEncoding | AuxPowEncoding
+	block := auxPowBlock()
+
+	// Encode the block and ensure its size includes the auxpow.
+	var buf bytes.Buffer
+	if err := block.BtcEncode(&buf, pver, enc); err != nil {
+		t.Fatalf("BtcEncode: unexpected error: %v", err)
+	}
+	if buf.Len() != block.SerializeSize() {
+		t.Errorf("SerializeSize: wrong size - got %d, want %d",
+			block.SerializeSize(), buf.Len())
+	}
+	auxPowLen := block.Header.AuxPow.SerializeSize()
+	if buf.Len() != auxPowLen+blockOne.SerializeSize() {
+		t.Errorf("BtcEncode: wrong size - got %d, want %d", buf.Len(),
+			auxPowLen+blockOne.SerializeSize())
+	}
+
+	// Decode the block and ensure the auxpow is read back.
+	var gotBlock MsgBlock
+	err := gotBlock.BtcDecode(bytes.NewReader(buf.Bytes()), pver, enc)
+	if err != nil {
+		t.Fatalf("BtcDecode: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(&gotBlock, block) {
+		t.Errorf("BtcDecode: mismatched block - got %v, want %v",
+			spew.Sdump(&gotBlock), spew.Sdump(block))
+	}
+
+	// The auxpow is neither part of the long-term storage format nor
+	// encoded without the auxpow encoding, so the block encodes like any
+	// other block then.
+	wantBlock := *block
+	wantBlock.Header.AuxPow = nil
+	buf.Reset()
+	if err := block.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+	if buf.Len() != blockOne.SerializeSize() {
+		t.Errorf("Serialize: wrong size - got %d, want %d", buf.Len(),
+			blockOne.SerializeSize())
+	}
+	blockBytes := buf.Bytes()
+	gotBlock = MsgBlock{}
+	if err := gotBlock.Deserialize(bytes.NewReader(blockBytes)); err != nil {
+		t.Fatalf("Deserialize: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(&gotBlock, &wantBlock) {
+		t.Errorf("Deserialize: mismatched block - got %v, want %v",
+			spew.Sdump(&gotBlock), spew.Sdump(&wantBlock))
+	}
+	txLocs, err := gotBlock.DeserializeTxLoc(bytes.NewBuffer(blockBytes))
+	if err != nil {
+		t.Fatalf("DeserializeTxLoc: unexpected error: %v", err)
+	}
+	if want := blockHeaderLen + 1; txLocs[0].TxStart != want {
+		t.Errorf("DeserializeTxLoc: wrong transaction start - got %d, "+
+			"want %d", txLocs[0].TxStart, want)
+	}
+
+	// Encode and decode the header in a headers message.
+	headers := NewMsgHeaders()
+	headers.AddBlockHeader(&block.Header)
+	buf.Reset()
+	err = headers.BtcEncode(&buf, pver, BaseEncoding|AuxPowEncoding)
+	if err != nil {
+		t.Fatalf("BtcEncode: unexpected error: %v", err)
+	}
+	var gotHeaders MsgHeaders
+	err = gotHeaders.BtcDecode(bytes.NewReader(buf.Bytes()), pver,
+		BaseEncoding|AuxPowEncoding)
+	if err != nil {
+		t.Fatalf("BtcDecode: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(&gotHeaders, headers) {
+		t.Errorf("BtcDecode: mismatched headers - got %v, want %v",
+			spew.Sdump(&gotHeaders), spew.Sdump(headers))
+	}
+
+	// Without the auxpow encoding the header is encoded on its own.
+	buf.Reset()
+	if err := headers.BtcEncode(&buf, pver, BaseEncoding); err != nil {
+		t.Fatalf("BtcEncode: unexpected error: %v", err)
+	}
+	if want := 1 + blockHeaderLen + 1; buf.Len() != want {
+		t.Errorf("BtcEncode: wrong size - got %d, want %d", buf.Len(),
+			want)
+	}
+
+	// Encode and decode the header in a merkle block message.
+	merkleBlock := NewMsgMerkleBlock(&block.Header)
+	buf.Reset()
+	err = merkleBlock.BtcEncode(&buf, pver, BaseEncoding|AuxPowEncoding)
+	if err != nil {
+		t.Fatalf("BtcEncode: unexpected error: %v", err)
+	}
+	var gotMerkleBlock MsgMerkleBlock
+	err = gotMerkleBlock.BtcDecode(bytes.NewReader(buf.Bytes()), pver,
+		BaseEncoding|AuxPowEncoding)
+	if err != nil {
+		t.Fatalf("BtcDecode: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotMerkleBlock.Header, block.Header) {
+		t.Errorf("BtcDecode: mismatched header - got %v, want %v",
+			spew.Sdump(gotMerkleBlock.Header),
+			spew.Sdump(block.Header))
+	}
+}
+
+// TestAuxPowWireErrors performs negative tests against the encode and decode
+// of merged mined blocks.
+func TestAuxPowWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+	enc := WitnessEncoding | AuxPowEncoding
+
+	// Merged mined headers can't be encoded without their auxpow.
+	block := auxPowBlock()
+	block.Header.AuxPow = nil
+	var buf bytes.Buffer
+	err := block.BtcEncode(&buf, pver, enc)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode: did not receive expected error for "+
+			"missing auxpow - got %v", err)
+	}
+
+	// Merkle branches longer than the maximum can't be encoded.
+	block = auxPowBlock()
+	block.Header.AuxPow.ChainBranch = make([]chainhash.Hash,
+		MaxAuxPowBranchLen+1)
+	buf.Reset()
+	err = block.BtcEncode(&buf, pver, enc)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode: did not receive expected error for long "+
+			"branch - got %v", err)
+	}
+
+	// Nor can they be decoded.
+	block = auxPowBlock()
+	block.Header.AuxPow.CoinbaseBranch = nil
+	buf.Reset()
+	if err := block.BtcEncode(&buf, pver, enc); err != nil {
+		t.Fatalf("BtcEncode: unexpected error: %v", err)
+	}
+	encoded := buf.Bytes()
+	branchOffset := blockHeaderLen +
+		block.Header.AuxPow.CoinbaseTx.SerializeSize() + chainhash.HashSize
+	encoded[branchOffset] = MaxAuxPowBranchLen + 1
+	var gotBlock MsgBlock
+	err = gotBlock.BtcDecode(bytes.NewReader(encoded), pver, enc)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: did not receive expected error for long "+
+			"branch - got %v", err)
+	}
+
+	// Truncated auxpows can't be decoded.
+	buf.Reset()
+	if err := auxPowBlock().BtcEncode(&buf, pver, enc); err != nil {
+		t.Fatalf("BtcEncode: unexpected error: %v", err)
+	}
+	truncated := buf.Bytes()[:blockHeaderLen+10]
+	err = gotBlock.BtcDecode(bytes.NewReader(truncated), pver, enc)
+	if err == nil {
+		t.Errorf("BtcDecode: did not receive expected error for " +
+			"truncated auxpow")
+	}
+}
@@ -38,6 +38,13 @@ type BlockHeader struct {
 
 	// Nonce used to generate the block.
 	Nonce uint32
+
+	// AuxPow is the auxiliary proof of work of a merged mined block.  It is
+	// only set when the version indicates the block is merged mined and it
+	// is not part of the header hash nor of the serialized header.  It is
+	// transmitted along with the header in the block, merkle block and
+	// headers messages.
+	AuxPow *AuxPow
 }
 
 // blockHeaderLen is a constant that represents the number of bytes for a block
@@ -59,6 +59,7 @@ const (
 	CmdCFilter      = "cfilter"
 	CmdCFHeaders    = "cfheaders"
 	CmdCFCheckpt    = "cfcheckpt"
+	CmdNEVMBlock    = "nevmblock"
 )
 
 // MessageEncoding represents the wire message encoding format to be used.
@@ -73,6 +74,12 @@ const (
 	// using the default Bitcoin wire protocol specification. For transaction
 	// messages, the new encoding format detailed in BIP0144 will be used.
 	WitnessEncoding
+
+	// AuxPowEncoding is combined with one of the other encodings on chains
+	// which support merged mining.  It encodes the headers of merged mined
+	// blocks within block, merkle block and headers messages along with
+	// their auxiliary proof of work.
+	AuxPowEncoding
 )
 
 // LatestEncoding is the most recently specified encoding for the Bitcoin wire
@@ -182,6 +189,9 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdCFCheckpt:
 		msg = &MsgCFCheckpt{}
 
+	case CmdNEVMBlock:
+		msg = &MsgNEVMBlock{}
+
 	default:
 		return nil, fmt.Errorf("unhandled command [%s]", command)
 	}
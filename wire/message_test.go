@@ -76,6 +76,13 @@ func TestMessage(t *testing.T) {
 		[]byte("payload"))
 	msgCFHeaders := NewMsgCFHeaders()
 	msgCFCheckpt := NewMsgCFCheckpt(GCSFilterRegular, &chainhash.Hash{}, 0)
+	msgNEVMBlock := NewMsgNEVMBlock(&NEVMBlockWire{
+		NEVMBlockHash: make([]byte, HASH_SIZE),
+		TxRoot:        make([]byte, HASH_SIZE),
+		ReceiptRoot:   make([]byte, HASH_SIZE),
+		NEVMBlockData: []byte("payload"),
+		SYSBlockHash:  make([]byte, HASH_SIZE),
+	})
 
 	tests := []struct {
 		in     Message    // Value to encode
@@ -111,6 +118,7 @@ func TestMessage(t *testing.T) {
 		{msgCFilter, msgCFilter, pver, MainNet, 65},
 		{msgCFHeaders, msgCFHeaders, pver, MainNet, 90},
 		{msgCFCheckpt, msgCFCheckpt, pver, MainNet, 58},
+		{msgNEVMBlock, msgNEVMBlock, pver, MainNet, 160},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -63,7 +63,7 @@ func (msg *MsgBlock) ClearTransactions() {
 // See Deserialize for decoding blocks stored to disk, such as in a database, as
 // opposed to decoding blocks from the wire.
 func (msg *MsgBlock) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
-	err := readBlockHeader(r, pver, &msg.Header)
+	err := readBlockHeaderAuxPow(r, pver, enc, &msg.Header)
 	if err != nil {
 		return err
 	}
@@ -104,6 +104,9 @@ func (msg *MsgBlock) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) er
 // all.  As of the time this comment was written, the encoded block is the same
 // in both instances, but there is a distinct difference and separating the two
 // allows the API to be flexible enough to deal with changes.
+//
+// The auxiliary proof of work of merged mined blocks is not part of the
+// long-term storage format, so it must be stored separately.
 func (msg *MsgBlock) Deserialize(r io.Reader) error {
 	// At the current time, there is no difference between the wire encoding
 	// at protocol version 0 and the stable long-term storage format.  As
@@ -175,7 +178,7 @@ func (msg *MsgBlock) DeserializeTxLoc(r *bytes.Buffer) ([]TxLoc, error) {
 // See Serialize for encoding blocks to be stored to disk, such as in a
 // database, as opposed to encoding blocks for the wire.
 func (msg *MsgBlock) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
-	err := writeBlockHeader(w, pver, &msg.Header)
+	err := writeBlockHeaderAuxPow(w, pver, enc, &msg.Header)
 	if err != nil {
 		return err
 	}
@@ -204,6 +207,9 @@ func (msg *MsgBlock) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) er
 // time this comment was written, the encoded block is the same in both
 // instances, but there is a distinct difference and separating the two allows
 // the API to be flexible enough to deal with changes.
+//
+// The auxiliary proof of work of merged mined blocks is not part of the
+// long-term storage format, so it must be stored separately.
 func (msg *MsgBlock) Serialize(w io.Writer) error {
 	// At the current time, there is no difference between the wire encoding
 	// at protocol version 0 and the stable long-term storage format.  As
@@ -225,11 +231,13 @@ func (msg *MsgBlock) SerializeNoWitness(w io.Writer) error {
 }
 
 // SerializeSize returns the number of bytes it would take to serialize the
-// block, factoring in any witness data within transaction.
+// block, factoring in any witness data within transaction and the auxiliary
+// proof of work of merged mined blocks as encoded by AuxPowEncoding.
 func (msg *MsgBlock) SerializeSize() int {
-	// Block header bytes + Serialized varint size for the number of
-	// transactions.
-	n := blockHeaderLen + VarIntSerializeSize(uint64(len(msg.Transactions)))
+	// Block header bytes + auxpow bytes, if any + Serialized varint size
+	// for the number of transactions.
+	n := blockHeaderAuxPowSize(&msg.Header) +
+		VarIntSerializeSize(uint64(len(msg.Transactions)))
 
 	for _, tx := range msg.Transactions {
 		n += tx.SerializeSize()
@@ -239,11 +247,13 @@ func (msg *MsgBlock) SerializeSize() int {
 }
 
 // SerializeSizeStripped returns the number of bytes it would take to serialize
-// the block, excluding any witness data (if any).
+// the block, excluding any witness data (if any) while factoring in the
+// auxiliary proof of work of merged mined blocks as encoded by AuxPowEncoding.
 func (msg *MsgBlock) SerializeSizeStripped() int {
-	// Block header bytes + Serialized varint size for the number of
-	// transactions.
-	n := blockHeaderLen + VarIntSerializeSize(uint64(len(msg.Transactions)))
+	// Block header bytes + auxpow bytes, if any + Serialized varint size
+	// for the number of transactions.
+	n := blockHeaderAuxPowSize(&msg.Header) +
+		VarIntSerializeSize(uint64(len(msg.Transactions)))
 
 	for _, tx := range msg.Transactions {
 		n += tx.SerializeSizeStripped()
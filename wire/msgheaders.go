@@ -55,7 +55,7 @@ func (msg *MsgHeaders) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding)
 	msg.Headers = make([]*BlockHeader, 0, count)
 	for i := uint64(0); i < count; i++ {
 		bh := &headers[i]
-		err := readBlockHeader(r, pver, bh)
+		err := readBlockHeaderAuxPow(r, pver, enc, bh)
 		if err != nil {
 			return err
 		}
@@ -94,7 +94,7 @@ func (msg *MsgHeaders) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding)
 	}
 
 	for _, bh := range msg.Headers {
-		err := writeBlockHeader(w, pver, bh)
+		err := writeBlockHeaderAuxPow(w, pver, enc, bh)
 		if err != nil {
 			return err
 		}
@@ -121,10 +121,10 @@ func (msg *MsgHeaders) Command() string {
 // MaxPayloadLength returns the maximum length the payload can be for the
 // receiver.  This is part of the Message interface implementation.
 func (msg *MsgHeaders) MaxPayloadLength(pver uint32) uint32 {
-	// Num headers (varInt) + max allowed headers (header length + 1 byte
-	// for the number of transactions which is always 0).
-	return MaxVarIntPayload + ((MaxBlockHeaderPayload + 1) *
-		MaxBlockHeadersPerMsg)
+	// The auxpow of merged mined headers includes a whole coinbase
+	// transaction of the parent block, so the size of a header is only
+	// limited by the maximum message size.
+	return MaxMessagePayload
 }
 
 // NewMsgHeaders returns a new bitcoin headers message that conforms to the
@@ -0,0 +1,89 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/vpubchain/btcd/chaincfg/chainhash"
+)
+
+// MaxNEVMBlockPayload is the maximum bytes a nevmblock message can be.  It
+// consists of the NEVM block hash, transaction root and receipt root, the
+// variable length NEVM block data and the hash of the Syscoin block.
+const MaxNEVMBlockPayload = 3*HASH_SIZE + MaxVarIntPayload +
+	MAX_NEVM_BLOCK_SIZE + HASH_SIZE
+
+// MsgNEVMBlock implements the Message interface and represents a Syscoin
+// nevmblock message.  It carries the NEVM block connected to a Syscoin block
+// and is sent in response to a getdata message for an InvTypeNEVMBlock
+// inventory vector holding the hash of the Syscoin block.
+type MsgNEVMBlock struct {
+	NEVMBlockWire
+}
+
+// checkHashes ensures the hashes of the NEVM block are all the size of a hash
+// since they are encoded without their length.
+func (msg *MsgNEVMBlock) checkHashes(funcName string) error {
+	hashes := []struct {
+		name string
+		hash []byte
+	}{
+		{"NEVM block hash", msg.NEVMBlockHash},
+		{"transaction root", msg.TxRoot},
+		{"receipt root", msg.ReceiptRoot},
+		{"Syscoin block hash", msg.SYSBlockHash},
+	}
+	for _, h := range hashes {
+		if len(h.hash) != HASH_SIZE {
+			str := fmt.Sprintf("%s is %d bytes instead of %d",
+				h.name, len(h.hash), HASH_SIZE)
+			return messageError(funcName, str)
+		}
+	}
+	return nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgNEVMBlock) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	return msg.Deserialize(r)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgNEVMBlock) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if err := msg.checkHashes("MsgNEVMBlock.BtcEncode"); err != nil {
+		return err
+	}
+	return msg.Serialize(w)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgNEVMBlock) Command() string {
+	return CmdNEVMBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgNEVMBlock) MaxPayloadLength(pver uint32) uint32 {
+	return MaxNEVMBlockPayload
+}
+
+// SYSHash returns the hash of the Syscoin block the NEVM block is connected
+// to.
+func (msg *MsgNEVMBlock) SYSHash() chainhash.Hash {
+	var hash chainhash.Hash
+	copy(hash[:], msg.SYSBlockHash)
+	return hash
+}
+
+// NewMsgNEVMBlock returns a new Syscoin nevmblock message that conforms to
+// the Message interface.  See MsgNEVMBlock for details.
+func NewMsgNEVMBlock(nevmBlock *NEVMBlockWire) *MsgNEVMBlock {
+	return &MsgNEVMBlock{NEVMBlockWire: *nevmBlock}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestNEVMBlock tests the MsgNEVMBlock API.
+func TestNEVMBlock(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := NewMsgNEVMBlock(&nevmBlock)
+
+	// Ensure the command is expected value.
+	wantCmd := "nevmblock"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgNEVMBlock: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	// Three hashes + varint for data size + max data + Syscoin block hash.
+	wantPayload := uint32(1073741961)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure the hash of the Syscoin block is returned.
+	sysHash := msg.SYSHash()
+	if !bytes.Equal(sysHash[:], nevmBlock.SYSBlockHash) {
+		t.Errorf("SYSHash: wrong hash - got %v, want %x", sysHash,
+			nevmBlock.SYSBlockHash)
+	}
+
+	// Ensure hashes of the wrong size can't be encoded.
+	badMsg := NewMsgNEVMBlock(&nevmBlock)
+	badMsg.SYSBlockHash = nil
+	var buf bytes.Buffer
+	err := badMsg.BtcEncode(&buf, pver, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode: did not receive expected error for "+
+			"missing Syscoin block hash - got %v", err)
+	}
+}
+
+// TestNEVMBlockWire tests the MsgNEVMBlock wire encode and decode.
+func TestNEVMBlockWire(t *testing.T) {
+	pver := ProtocolVersion
+
+	tests := []struct {
+		in  *MsgNEVMBlock // Message to encode
+		out *MsgNEVMBlock // Expected decoded message
+		buf []byte        // Wire encoding
+	}{
+		{
+			NewMsgNEVMBlock(&nevmBlock),
+			NewMsgNEVMBlock(&nevmBlock),
+			nevmBlockEncoded,
+		},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, pver, BaseEncoding)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgNEVMBlock
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, pver, BaseEncoding)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(&msg, test.out) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(msg), spew.Sdump(test.out))
+			continue
+		}
+	}
+}
+
+// nevmBlock is an NEVM block used in the tests.
+var nevmBlock = NEVMBlockWire{
+	NEVMBlockHash: bytes.Repeat([]byte{0x11}, HASH_SIZE),
+	TxRoot:        bytes.Repeat([]byte{0x22}, HASH_SIZE),
+	ReceiptRoot:   bytes.Repeat([]byte{0x33}, HASH_SIZE),
+	NEVMBlockData: []byte{0xc3, 0x01, 0x02, 0x03},
+	SYSBlockHash:  bytes.Repeat([]byte{0x44}, HASH_SIZE),
+}
+
+// nevmBlockEncoded is the wire encoded bytes for nevmBlock.
+var nevmBlockEncoded = bytes.Join([][]byte{
+	bytes.Repeat([]byte{0x11}, HASH_SIZE), // NEVM block hash
+	bytes.Repeat([]byte{0x22}, HASH_SIZE), // Transaction root
+	bytes.Repeat([]byte{0x33}, HASH_SIZE), // Receipt root
+	{0x04, 0xc3, 0x01, 0x02, 0x03},        // Varint for data size + data
+	bytes.Repeat([]byte{0x44}, HASH_SIZE), // Syscoin block hash
+}, nil)
@@ -244,6 +244,10 @@ func (a *NEVMBlockWire) Serialize(w io.Writer) error {
 	if err != nil {
 		return err
 	}
+	_, err = w.Write(a.SYSBlockHash[:])
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
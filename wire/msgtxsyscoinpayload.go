@@ -0,0 +1,322 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// These constants define the transaction versions that identify Syscoin
+// asset transactions.  The payload of such a transaction is carried in the
+// data push of its null data (OP_RETURN) output.
+const (
+	// SyscoinTxVersionAllocationBurnToSyscoin burns an asset allocation
+	// back to SYS.  The payload is a SyscoinBurnToEthereumType with an
+	// empty EthAddress.
+	SyscoinTxVersionAllocationBurnToSyscoin int32 = 128
+
+	// SyscoinTxVersionSyscoinBurnToAllocation burns SYS into an asset
+	// allocation.  The payload is an AssetAllocationType.
+	SyscoinTxVersionSyscoinBurnToAllocation int32 = 129
+
+	// SyscoinTxVersionAssetActivate creates a new asset.  The payload is
+	// an AssetType.
+	SyscoinTxVersionAssetActivate int32 = 130
+
+	// SyscoinTxVersionAssetUpdate updates an existing asset.  The payload
+	// is an AssetType.
+	SyscoinTxVersionAssetUpdate int32 = 131
+
+	// SyscoinTxVersionAssetSend sends newly issued asset units from the
+	// asset owner.  The payload is an AssetType.
+	SyscoinTxVersionAssetSend int32 = 132
+
+	// SyscoinTxVersionAllocationMint mints an asset allocation from a
+	// burn on Ethereum.  The payload is a MintSyscoinType.
+	SyscoinTxVersionAllocationMint int32 = 133
+
+	// SyscoinTxVersionAllocationBurnToEthereum burns an asset allocation
+	// to Ethereum.  The payload is a SyscoinBurnToEthereumType.
+	SyscoinTxVersionAllocationBurnToEthereum int32 = 134
+
+	// SyscoinTxVersionAllocationSend transfers asset allocations between
+	// outputs.  The payload is an AssetAllocationType.
+	SyscoinTxVersionAllocationSend int32 = 135
+)
+
+// These constants mirror the script opcodes needed to locate the Syscoin
+// payload.  They are duplicated here since the wire package can not depend
+// on txscript.
+const (
+	opReturn    = 0x6a
+	opPushData1 = 0x4c
+	opPushData2 = 0x4d
+	opPushData4 = 0x4e
+)
+
+// SyscoinErrorCode identifies a kind of error encountered while locating or
+// decoding the Syscoin payload of a transaction.
+type SyscoinErrorCode int
+
+// These constants are used to identify a specific SyscoinPayloadError.
+const (
+	// ErrNotSyscoinTx indicates the transaction version is not one of the
+	// Syscoin transaction versions.
+	ErrNotSyscoinTx SyscoinErrorCode = iota
+
+	// ErrNoSyscoinDataOutput indicates the transaction does not have a
+	// null data output carrying the payload.
+	ErrNoSyscoinDataOutput
+
+	// ErrMultipleSyscoinDataOutputs indicates the transaction has more
+	// than one null data output, so the payload is ambiguous.
+	ErrMultipleSyscoinDataOutputs
+
+	// ErrMalformedSyscoinScript indicates the null data output script does
+	// not consist of OP_RETURN followed by a single canonical data push.
+	ErrMalformedSyscoinScript
+
+	// ErrMalformedSyscoinData indicates the pushed data could not be
+	// decoded as the payload type for the transaction version.
+	ErrMalformedSyscoinData
+
+	// ErrTrailingSyscoinData indicates the payload decoded successfully
+	// but was followed by unexpected extra bytes.
+	ErrTrailingSyscoinData
+)
+
+// Map of SyscoinErrorCode values back to their constant names for pretty
+// printing.
+var syscoinErrorCodeStrings = map[SyscoinErrorCode]string{
+	ErrNotSyscoinTx:               "ErrNotSyscoinTx",
+	ErrNoSyscoinDataOutput:        "ErrNoSyscoinDataOutput",
+	ErrMultipleSyscoinDataOutputs: "ErrMultipleSyscoinDataOutputs",
+	ErrMalformedSyscoinScript:     "ErrMalformedSyscoinScript",
+	ErrMalformedSyscoinData:       "ErrMalformedSyscoinData",
+	ErrTrailingSyscoinData:        "ErrTrailingSyscoinData",
+}
+
+// String returns the SyscoinErrorCode as a human-readable name.
+func (e SyscoinErrorCode) String() string {
+	if s := syscoinErrorCodeStrings[e]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown SyscoinErrorCode (%d)", int(e))
+}
+
+// SyscoinPayloadError describes an issue with the Syscoin payload of a
+// transaction.  The caller can use type assertions to determine the specific
+// error by examining the ErrorCode field.
+type SyscoinPayloadError struct {
+	ErrorCode   SyscoinErrorCode // Describes the kind of error
+	Description string           // Human readable description of the issue
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e SyscoinPayloadError) Error() string {
+	return e.Description
+}
+
+// syscoinPayloadError creates a SyscoinPayloadError given a set of arguments.
+func syscoinPayloadError(c SyscoinErrorCode, desc string) SyscoinPayloadError {
+	return SyscoinPayloadError{ErrorCode: c, Description: desc}
+}
+
+// SyscoinPayload is the interface implemented by all of the decoded Syscoin
+// transaction payload types: *AssetType, *AssetAllocationType,
+// *MintSyscoinType and *SyscoinBurnToEthereumType.
+type SyscoinPayload interface {
+	// AssetAllocation returns the asset allocation carried by the
+	// payload.
+	AssetAllocation() *AssetAllocationType
+
+	// Serialize encodes the payload to w.
+	Serialize(w io.Writer) error
+
+	// Deserialize decodes the payload from r.
+	Deserialize(r io.Reader) error
+}
+
+// AssetAllocation returns the asset allocation carried by the asset payload.
+// This is part of the SyscoinPayload interface implementation.
+func (a *AssetType) AssetAllocation() *AssetAllocationType {
+	return &a.Allocation
+}
+
+// AssetAllocation returns the allocation itself.  This is part of the
+// SyscoinPayload interface implementation.
+func (a *AssetAllocationType) AssetAllocation() *AssetAllocationType {
+	return a
+}
+
+// AssetAllocation returns the asset allocation carried by the mint payload.
+// This is part of the SyscoinPayload interface implementation.
+func (a *MintSyscoinType) AssetAllocation() *AssetAllocationType {
+	return &a.Allocation
+}
+
+// AssetAllocation returns the asset allocation carried by the burn payload.
+// This is part of the SyscoinPayload interface implementation.
+func (a *SyscoinBurnToEthereumType) AssetAllocation() *AssetAllocationType {
+	return &a.Allocation
+}
+
+// IsSyscoinTx returns whether or not the passed transaction version is one of
+// the Syscoin transaction versions which carry an asset payload.
+func IsSyscoinTx(version int32) bool {
+	return version >= SyscoinTxVersionAllocationBurnToSyscoin &&
+		version <= SyscoinTxVersionAllocationSend
+}
+
+// IsAssetTx returns whether or not the passed transaction version creates,
+// updates or issues an asset, in which case the payload is an AssetType.
+func IsAssetTx(version int32) bool {
+	return version == SyscoinTxVersionAssetActivate ||
+		version == SyscoinTxVersionAssetUpdate ||
+		version == SyscoinTxVersionAssetSend
+}
+
+// IsAssetAllocationTx returns whether or not the passed transaction version
+// moves existing asset allocations, including mints and burns.
+func IsAssetAllocationTx(version int32) bool {
+	return IsSyscoinTx(version) && !IsAssetTx(version)
+}
+
+// extractNullData returns the data pushed by the passed null data script
+// along with whether or not the script is a null data script at all.  An
+// error is returned when the script starts with OP_RETURN but is not
+// followed by exactly one data push.
+func extractNullData(pkScript []byte) ([]byte, bool, error) {
+	if len(pkScript) == 0 || pkScript[0] != opReturn {
+		return nil, false, nil
+	}
+	script := pkScript[1:]
+	if len(script) == 0 {
+		str := "null data script does not contain a data push"
+		return nil, true, syscoinPayloadError(ErrMalformedSyscoinScript, str)
+	}
+
+	var dataLen, offset int
+	switch op := script[0]; {
+	case op >= 0x01 && op < opPushData1:
+		dataLen, offset = int(op), 1
+	case op == opPushData1 && len(script) >= 2:
+		dataLen, offset = int(script[1]), 2
+	case op == opPushData2 && len(script) >= 3:
+		dataLen = int(binary.LittleEndian.Uint16(script[1:3]))
+		offset = 3
+	case op == opPushData4 && len(script) >= 5:
+		dataLen = int(binary.LittleEndian.Uint32(script[1:5]))
+		offset = 5
+	default:
+		str := fmt.Sprintf("null data script opcode 0x%02x is not a "+
+			"data push", op)
+		return nil, true, syscoinPayloadError(ErrMalformedSyscoinScript, str)
+	}
+	if dataLen < 0 || len(script)-offset != dataLen {
+		str := fmt.Sprintf("null data script push of %d bytes does not "+
+			"match remaining script length %d", dataLen,
+			len(script)-offset)
+		return nil, true, syscoinPayloadError(ErrMalformedSyscoinScript, str)
+	}
+	return script[offset:], true, nil
+}
+
+// SyscoinData locates the null data output that carries the Syscoin payload
+// of the transaction and returns the pushed data along with the index of the
+// output.  An error of type SyscoinPayloadError is returned when the
+// transaction is not a Syscoin transaction or the carrier output is missing,
+// ambiguous or malformed.
+func (msg *MsgTx) SyscoinData() ([]byte, int, error) {
+	if !IsSyscoinTx(msg.Version) {
+		str := fmt.Sprintf("transaction version %d is not a Syscoin "+
+			"transaction version", msg.Version)
+		return nil, -1, syscoinPayloadError(ErrNotSyscoinTx, str)
+	}
+
+	var data []byte
+	index := -1
+	for i, txOut := range msg.TxOut {
+		pushed, isNullData, err := extractNullData(txOut.PkScript)
+		if err != nil {
+			return nil, -1, err
+		}
+		if !isNullData {
+			continue
+		}
+		if index != -1 {
+			str := fmt.Sprintf("transaction has multiple null data "+
+				"outputs (%d and %d)", index, i)
+			return nil, -1, syscoinPayloadError(
+				ErrMultipleSyscoinDataOutputs, str)
+		}
+		data, index = pushed, i
+	}
+	if index == -1 {
+		str := "transaction does not have a null data output"
+		return nil, -1, syscoinPayloadError(ErrNoSyscoinDataOutput, str)
+	}
+	return data, index, nil
+}
+
+// SyscoinPayload locates and decodes the Syscoin payload of the transaction.
+// The concrete type of the returned payload depends on the transaction
+// version:
+//
+//   - *AssetType for asset activate, update and send
+//   - *AssetAllocationType for allocation send and SYS burn to allocation
+//   - *MintSyscoinType for allocation mint
+//   - *SyscoinBurnToEthereumType for allocation burn to SYS or Ethereum
+//
+// An error of type SyscoinPayloadError is returned when the payload can not
+// be located or decoded.
+func (msg *MsgTx) SyscoinPayload() (SyscoinPayload, error) {
+	data, _, err := msg.SyscoinData()
+	if err != nil {
+		return nil, err
+	}
+
+	var payload SyscoinPayload
+	switch msg.Version {
+	case SyscoinTxVersionAssetActivate, SyscoinTxVersionAssetUpdate,
+		SyscoinTxVersionAssetSend:
+		payload = &AssetType{}
+	case SyscoinTxVersionAllocationSend,
+		SyscoinTxVersionSyscoinBurnToAllocation:
+		payload = &AssetAllocationType{}
+	case SyscoinTxVersionAllocationMint:
+		payload = &MintSyscoinType{}
+	default:
+		payload = &SyscoinBurnToEthereumType{}
+	}
+
+	r := bytes.NewReader(data)
+	if err := payload.Deserialize(r); err != nil {
+		str := fmt.Sprintf("unable to decode payload for transaction "+
+			"version %d: %v", msg.Version, err)
+		return nil, syscoinPayloadError(ErrMalformedSyscoinData, str)
+	}
+	if r.Len() != 0 {
+		str := fmt.Sprintf("payload for transaction version %d has %d "+
+			"trailing bytes", msg.Version, r.Len())
+		return nil, syscoinPayloadError(ErrTrailingSyscoinData, str)
+	}
+	return payload, nil
+}
+
+// AssetAllocation locates and decodes the Syscoin payload of the transaction
+// and returns the asset allocation it carries.  This is a convenience for
+// callers which only care about asset movements regardless of the specific
+// transaction version.
+func (msg *MsgTx) AssetAllocation() (*AssetAllocationType, error) {
+	payload, err := msg.SyscoinPayload()
+	if err != nil {
+		return nil, err
+	}
+	return payload.AssetAllocation(), nil
+}
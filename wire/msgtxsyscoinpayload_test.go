@@ -0,0 +1,215 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// nullDataScript returns a null data script which pushes the passed data
+// using the smallest push opcode.
+func nullDataScript(data []byte) []byte {
+	script := []byte{opReturn}
+	switch l := len(data); {
+	case l < opPushData1:
+		script = append(script, byte(l))
+	case l <= 0xff:
+		script = append(script, opPushData1, byte(l))
+	default:
+		script = append(script, opPushData2, byte(l), byte(l>>8))
+	}
+	return append(script, data...)
+}
+
+// syscoinTestTx returns a transaction with the passed version that carries
+// the serialized payload in its second output.
+func syscoinTestTx(t *testing.T, version int32, payload SyscoinPayload) *MsgTx {
+	var buf bytes.Buffer
+	if err := payload.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: unexpected error %v", err)
+	}
+	tx := NewMsgTx(version)
+	tx.AddTxOut(NewTxOut(1000, []byte{0x51}))
+	tx.AddTxOut(NewTxOut(0, nullDataScript(buf.Bytes())))
+	return tx
+}
+
+// TestSyscoinPayload ensures the Syscoin payload of every Syscoin transaction
+// version is located and decoded into the expected type.
+func TestSyscoinPayload(t *testing.T) {
+	allocation := AssetAllocationType{
+		VoutAssets: []AssetOutType{{
+			AssetGuid: 1234567,
+			Values:    []AssetOutValueType{{N: 0, ValueSat: 100000}},
+			NotarySig: []byte{},
+		}},
+	}
+	hash := bytes.Repeat([]byte{0x01}, HASH_SIZE)
+
+	tests := []struct {
+		name    string
+		version int32
+		payload SyscoinPayload
+	}{
+		{
+			name:    "asset activate",
+			version: SyscoinTxVersionAssetActivate,
+			payload: &AssetType{
+				Allocation:  allocation,
+				Precision:   8,
+				UpdateFlags: ASSET_INIT | ASSET_UPDATE_SUPPLY,
+				Symbol:      []byte("SYSX"),
+				MaxSupply:   100000000,
+				TotalSupply: 500,
+			},
+		},
+		{
+			name:    "allocation send",
+			version: SyscoinTxVersionAllocationSend,
+			payload: &allocation,
+		},
+		{
+			name:    "allocation mint",
+			version: SyscoinTxVersionAllocationMint,
+			payload: &MintSyscoinType{
+				Allocation:         allocation,
+				TxHash:             hash,
+				BlockHash:          hash,
+				TxPos:              1,
+				TxParentNodes:      []byte{0xc0},
+				TxPath:             []byte{0x80},
+				TxRoot:             hash,
+				ReceiptRoot:        hash,
+				ReceiptPos:         2,
+				ReceiptParentNodes: []byte{0xc0},
+			},
+		},
+		{
+			name:    "burn to ethereum",
+			version: SyscoinTxVersionAllocationBurnToEthereum,
+			payload: &SyscoinBurnToEthereumType{
+				Allocation: allocation,
+				EthAddress: bytes.Repeat([]byte{0xaa}, 20),
+			},
+		},
+		{
+			name:    "burn to syscoin",
+			version: SyscoinTxVersionAllocationBurnToSyscoin,
+			payload: &SyscoinBurnToEthereumType{
+				Allocation: allocation,
+				EthAddress: []byte{},
+			},
+		},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for _, test := range tests {
+		tx := syscoinTestTx(t, test.version, test.payload)
+		_, idx, err := tx.SyscoinData()
+		if err != nil {
+			t.Errorf("%s: SyscoinData unexpected error %v", test.name,
+				err)
+			continue
+		}
+		if idx != 1 {
+			t.Errorf("%s: SyscoinData wrong output index - got %d, "+
+				"want 1", test.name, idx)
+		}
+
+		payload, err := tx.SyscoinPayload()
+		if err != nil {
+			t.Errorf("%s: SyscoinPayload unexpected error %v",
+				test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(payload, test.payload) {
+			t.Errorf("%s: mismatched payload - got %v, want %v",
+				test.name, spew.Sdump(payload),
+				spew.Sdump(test.payload))
+			continue
+		}
+
+		alloc, err := tx.AssetAllocation()
+		if err != nil {
+			t.Errorf("%s: AssetAllocation unexpected error %v",
+				test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*alloc, allocation) {
+			t.Errorf("%s: mismatched allocation - got %v, want %v",
+				test.name, spew.Sdump(alloc),
+				spew.Sdump(allocation))
+		}
+	}
+}
+
+// TestSyscoinPayloadErrors ensures malformed Syscoin transactions are
+// rejected with the expected error codes.
+func TestSyscoinPayloadErrors(t *testing.T) {
+	allocation := &AssetAllocationType{
+		VoutAssets: []AssetOutType{{
+			AssetGuid: 42,
+			Values:    []AssetOutValueType{{N: 0, ValueSat: 1}},
+		}},
+	}
+	valid := syscoinTestTx(t, SyscoinTxVersionAllocationSend, allocation)
+	data := valid.TxOut[1].PkScript[2:]
+
+	notSyscoin := valid.Copy()
+	notSyscoin.Version = 2
+
+	noOutput := valid.Copy()
+	noOutput.TxOut = noOutput.TxOut[:1]
+
+	multiple := valid.Copy()
+	multiple.AddTxOut(NewTxOut(0, valid.TxOut[1].PkScript))
+
+	badPush := valid.Copy()
+	badPush.TxOut[1].PkScript = []byte{opReturn, 0x76}
+
+	shortPush := valid.Copy()
+	shortPush.TxOut[1].PkScript = append([]byte{opReturn, byte(len(data) + 1)},
+		data...)
+
+	truncated := valid.Copy()
+	truncated.TxOut[1].PkScript = nullDataScript(data[:len(data)-1])
+
+	trailing := valid.Copy()
+	trailing.TxOut[1].PkScript = nullDataScript(append(append([]byte{},
+		data...), 0x00))
+
+	tests := []struct {
+		name string
+		tx   *MsgTx
+		code SyscoinErrorCode
+	}{
+		{"not syscoin version", notSyscoin, ErrNotSyscoinTx},
+		{"no data output", noOutput, ErrNoSyscoinDataOutput},
+		{"multiple data outputs", multiple, ErrMultipleSyscoinDataOutputs},
+		{"non-push opcode", badPush, ErrMalformedSyscoinScript},
+		{"push length mismatch", shortPush, ErrMalformedSyscoinScript},
+		{"truncated payload", truncated, ErrMalformedSyscoinData},
+		{"trailing bytes", trailing, ErrTrailingSyscoinData},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for _, test := range tests {
+		_, err := test.tx.SyscoinPayload()
+		perr, ok := err.(SyscoinPayloadError)
+		if !ok {
+			t.Errorf("%s: wrong error type - got %T, want "+
+				"SyscoinPayloadError", test.name, err)
+			continue
+		}
+		if perr.ErrorCode != test.code {
+			t.Errorf("%s: wrong error code - got %v, want %v",
+				test.name, perr.ErrorCode, test.code)
+		}
+	}
+}